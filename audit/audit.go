@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"encoding/json"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultIndex represents the index name which audit documents are stored in
+	DefaultIndex = ".esnctl-audit"
+
+	docType = "operation"
+)
+
+// Record writes the given operation into audit index
+func Record(client es.Client, index string, op *operation.Operation) error {
+	doc, err := json.Marshal(op)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize operation")
+	}
+
+	if err := client.IndexDocument(index, docType, doc); err != nil {
+		return errors.Wrap(err, "failed to store audit document")
+	}
+
+	return nil
+}
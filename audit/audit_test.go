@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/operation"
+)
+
+type fakeClient struct {
+	es.Client
+
+	index   string
+	docType string
+	doc     []byte
+}
+
+func (c *fakeClient) IndexDocument(index, docType string, doc []byte) error {
+	c.index = index
+	c.docType = docType
+	c.doc = doc
+
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	client := &fakeClient{}
+
+	op := operation.New("remove", "http://example.com:9200")
+	op.Node = "ip-10-0-1-23.ap-northeast-1.compute.internal"
+	op.Finish(nil)
+
+	if err := Record(client, DefaultIndex, op); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if client.index != DefaultIndex {
+		t.Errorf("index does not match. expected: %q, got: %q", DefaultIndex, client.index)
+	}
+
+	var got operation.Operation
+
+	if err := json.Unmarshal(client.doc, &got); err != nil {
+		t.Fatalf("stored document is invalid: %s", err)
+	}
+
+	if got.Node != op.Node {
+		t.Errorf("node does not match. expected: %q, got: %q", op.Node, got.Node)
+	}
+
+	if got.Result != operation.ResultSucceeded {
+		t.Errorf("result does not match. expected: %q, got: %q", operation.ResultSucceeded, got.Result)
+	}
+}
@@ -9,7 +9,24 @@ import (
 
 // Client represents a wrapper of Auto Scaling API
 type Client struct {
-	api autoscalingiface.AutoScalingAPI
+	api      autoscalingiface.AutoScalingAPI
+	warmPool warmPoolAPI
+}
+
+// Group represents capacity and tags of ASG
+type Group struct {
+	Name            string
+	DesiredCapacity int
+	MinSize         int
+	MaxSize         int
+	Tags            map[string]string
+}
+
+// Instance represents instance attached to ASG
+type Instance struct {
+	ID               string
+	AvailabilityZone string
+	LifecycleState   string
 }
 
 // New creates and returns new Client object
@@ -19,14 +36,91 @@ func New(api autoscalingiface.AutoScalingAPI) *Client {
 	}
 }
 
+// AttachInstance attaches the given running instance to the given ASG
+// DesiredCapacity is increased by one, and the instance is registered with target groups of the ASG
+func (c *Client) AttachInstance(groupName, instanceID string) error {
+	_, err := c.api.AttachInstances(&autoscaling.AttachInstancesInput{
+		AutoScalingGroupName: aws.String(groupName),
+		InstanceIds: []*string{
+			aws.String(instanceID),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to attach instance")
+	}
+
+	return nil
+}
+
+// DescribeGroup returns capacity and tags of the given ASG
+func (c *Client) DescribeGroup(groupName string) (*Group, error) {
+	resp, err := c.api.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{
+			aws.String(groupName),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get AutoScaling Groups")
+	}
+
+	if len(resp.AutoScalingGroups) == 0 {
+		return nil, errors.Errorf("Auto Scaling Group %q does not exist", groupName)
+	}
+
+	asg := resp.AutoScalingGroups[0]
+
+	tags := map[string]string{}
+
+	for _, tag := range asg.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	return &Group{
+		Name:            aws.StringValue(asg.AutoScalingGroupName),
+		DesiredCapacity: int(aws.Int64Value(asg.DesiredCapacity)),
+		MinSize:         int(aws.Int64Value(asg.MinSize)),
+		MaxSize:         int(aws.Int64Value(asg.MaxSize)),
+		Tags:            tags,
+	}, nil
+}
+
+// DescribeInstances returns instances attached to the given ASG with their AZ and lifecycle state
+func (c *Client) DescribeInstances(groupName string) ([]*Instance, error) {
+	resp, err := c.api.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{
+			aws.String(groupName),
+		},
+	})
+	if err != nil {
+		return []*Instance{}, errors.Wrap(err, "failed to get AutoScaling Groups")
+	}
+
+	if len(resp.AutoScalingGroups) == 0 {
+		return []*Instance{}, errors.Errorf("Auto Scaling Group %q does not exist", groupName)
+	}
+
+	instances := []*Instance{}
+
+	for _, i := range resp.AutoScalingGroups[0].Instances {
+		instances = append(instances, &Instance{
+			ID:               aws.StringValue(i.InstanceId),
+			AvailabilityZone: aws.StringValue(i.AvailabilityZone),
+			LifecycleState:   aws.StringValue(i.LifecycleState),
+		})
+	}
+
+	return instances, nil
+}
+
 // DetachInstance detaches instance from the given ASG
-func (c *Client) DetachInstance(groupName, instanceID string) error {
+// If decrementDesiredCapacity is false, the ASG launches a replacement instance
+func (c *Client) DetachInstance(groupName, instanceID string, decrementDesiredCapacity bool) error {
 	_, err := c.api.DetachInstances(&autoscaling.DetachInstancesInput{
 		AutoScalingGroupName: aws.String(groupName),
 		InstanceIds: []*string{
 			aws.String(instanceID),
 		},
-		ShouldDecrementDesiredCapacity: aws.Bool(true),
+		ShouldDecrementDesiredCapacity: aws.Bool(decrementDesiredCapacity),
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed to detach instance")
@@ -65,18 +159,149 @@ func (c *Client) IncreaseInstances(groupName string, delta int) (int, error) {
 	return int(targetDesiredCapacity), nil
 }
 
+// ListGroups lists names of all ASGs
+func (c *Client) ListGroups() ([]string, error) {
+	groups := []string{}
+
+	input := &autoscaling.DescribeAutoScalingGroupsInput{}
+
+	for {
+		resp, err := c.api.DescribeAutoScalingGroups(input)
+		if err != nil {
+			return []string{}, errors.Wrap(err, "failed to get AutoScaling Groups")
+		}
+
+		for _, asg := range resp.AutoScalingGroups {
+			groups = append(groups, aws.StringValue(asg.AutoScalingGroupName))
+		}
+
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+
+		input.NextToken = resp.NextToken
+	}
+
+	return groups, nil
+}
+
+// ListGroupsByTag lists names of ASGs which have the given tag
+func (c *Client) ListGroupsByTag(key, value string) ([]string, error) {
+	groups := []string{}
+
+	input := &autoscaling.DescribeAutoScalingGroupsInput{}
+
+	for {
+		resp, err := c.api.DescribeAutoScalingGroups(input)
+		if err != nil {
+			return []string{}, errors.Wrap(err, "failed to get AutoScaling Groups")
+		}
+
+		for _, asg := range resp.AutoScalingGroups {
+			for _, tag := range asg.Tags {
+				if aws.StringValue(tag.Key) == key && aws.StringValue(tag.Value) == value {
+					groups = append(groups, aws.StringValue(asg.AutoScalingGroupName))
+					break
+				}
+			}
+		}
+
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+
+		input.NextToken = resp.NextToken
+	}
+
+	return groups, nil
+}
+
+// ListInstances lists instance IDs attached to the given ASG
+func (c *Client) ListInstances(groupName string) ([]string, error) {
+	resp, err := c.api.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{
+			aws.String(groupName),
+		},
+	})
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to get AutoScaling Groups")
+	}
+
+	if len(resp.AutoScalingGroups) == 0 {
+		return []string{}, errors.Errorf("Auto Scaling Group %q does not exist", groupName)
+	}
+
+	instances := []string{}
+
+	for _, instance := range resp.AutoScalingGroups[0].Instances {
+		instances = append(instances, aws.StringValue(instance.InstanceId))
+	}
+
+	return instances, nil
+}
+
+// RetrieveGroupOfInstance retrieves name of ASG which the given instance belongs to
+// Empty string is returned if the instance does not belong to any ASG
+func (c *Client) RetrieveGroupOfInstance(instanceID string) (string, error) {
+	resp, err := c.api.DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []*string{
+			aws.String(instanceID),
+		},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to describe AutoScaling instance")
+	}
+
+	if len(resp.AutoScalingInstances) == 0 {
+		return "", nil
+	}
+
+	return aws.StringValue(resp.AutoScalingInstances[0].AutoScalingGroupName), nil
+}
+
+// SetTag creates or updates the given tag of ASG
+// The tag is not propagated to instances launched by the ASG
+func (c *Client) SetTag(groupName, key, value string) error {
+	_, err := c.api.CreateOrUpdateTags(&autoscaling.CreateOrUpdateTagsInput{
+		Tags: []*autoscaling.Tag{
+			&autoscaling.Tag{
+				Key:               aws.String(key),
+				PropagateAtLaunch: aws.Bool(false),
+				ResourceId:        aws.String(groupName),
+				ResourceType:      aws.String("auto-scaling-group"),
+				Value:             aws.String(value),
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to tag AutoScaling Group")
+	}
+
+	return nil
+}
+
 // RetrieveTargetGroup retrieves target group ARN attached to the given ASG
 func (c *Client) RetrieveTargetGroup(groupName string) (string, error) {
-	resp, err := c.api.DescribeLoadBalancerTargetGroups(&autoscaling.DescribeLoadBalancerTargetGroupsInput{
+	input := &autoscaling.DescribeLoadBalancerTargetGroupsInput{
 		AutoScalingGroupName: aws.String(groupName),
-	})
-	if err != nil {
-		return "", errors.Wrap(err, "failed to retirve attached target group")
 	}
 
-	if len(resp.LoadBalancerTargetGroups) == 0 {
-		return "", errors.Errorf("no target group is attached to %q", groupName)
+	for {
+		resp, err := c.api.DescribeLoadBalancerTargetGroups(input)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to retirve attached target group")
+		}
+
+		if len(resp.LoadBalancerTargetGroups) > 0 {
+			return aws.StringValue(resp.LoadBalancerTargetGroups[0].LoadBalancerTargetGroupARN), nil
+		}
+
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+
+		input.NextToken = resp.NextToken
 	}
 
-	return aws.StringValue(resp.LoadBalancerTargetGroups[0].LoadBalancerTargetGroupARN), nil
+	return "", errors.Errorf("no target group is attached to %q", groupName)
 }
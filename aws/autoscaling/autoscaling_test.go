@@ -1,6 +1,7 @@
 package autoscaling
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -9,6 +10,133 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
+func TestAttachInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().AttachInstances(&autoscaling.AttachInstancesInput{
+		AutoScalingGroupName: aws.String("elasticsearch"),
+		InstanceIds: []*string{
+			aws.String("i-1234abcd"),
+		},
+	}).Return(&autoscaling.AttachInstancesOutput{}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	if err := client.AttachInstance("elasticsearch", "i-1234abcd"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestDescribeGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{
+			aws.String("elasticsearch"),
+		},
+	}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []*autoscaling.Group{
+			&autoscaling.Group{
+				AutoScalingGroupName: aws.String("elasticsearch"),
+				DesiredCapacity:      aws.Int64(3),
+				MinSize:              aws.Int64(1),
+				MaxSize:              aws.Int64(6),
+				Tags: []*autoscaling.TagDescription{
+					&autoscaling.TagDescription{
+						Key:   aws.String("esnctl:last-scale"),
+						Value: aws.String("desired=3,operation=add,time=2026-10-16T00:00:00Z"),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	expected := &Group{
+		Name:            "elasticsearch",
+		DesiredCapacity: 3,
+		MinSize:         1,
+		MaxSize:         6,
+		Tags: map[string]string{
+			"esnctl:last-scale": "desired=3,operation=add,time=2026-10-16T00:00:00Z",
+		},
+	}
+
+	got, err := client.DescribeGroup("elasticsearch")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("group does not match. expected: %#v, got: %#v", expected, got)
+	}
+}
+
+func TestDescribeInstances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{
+			aws.String("elasticsearch"),
+		},
+	}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []*autoscaling.Group{
+			&autoscaling.Group{
+				AutoScalingGroupName: aws.String("elasticsearch"),
+				Instances: []*autoscaling.Instance{
+					&autoscaling.Instance{
+						InstanceId:       aws.String("i-1234abcd"),
+						AvailabilityZone: aws.String("ap-northeast-1a"),
+						LifecycleState:   aws.String("InService"),
+					},
+					&autoscaling.Instance{
+						InstanceId:       aws.String("i-5678efab"),
+						AvailabilityZone: aws.String("ap-northeast-1c"),
+						LifecycleState:   aws.String("Pending"),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	expected := []*Instance{
+		&Instance{
+			ID:               "i-1234abcd",
+			AvailabilityZone: "ap-northeast-1a",
+			LifecycleState:   "InService",
+		},
+		&Instance{
+			ID:               "i-5678efab",
+			AvailabilityZone: "ap-northeast-1c",
+			LifecycleState:   "Pending",
+		},
+	}
+
+	got, err := client.DescribeInstances("elasticsearch")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("instances do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
 func TestDetachInstance(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -29,7 +157,7 @@ func TestDetachInstance(t *testing.T) {
 	groupName := "elasticsearch"
 	instanceID := "i-1234abcd"
 
-	if err := client.DetachInstance(groupName, instanceID); err != nil {
+	if err := client.DetachInstance(groupName, instanceID, true); err != nil {
 		t.Errorf("error should not be raised: %s", err)
 	}
 }
@@ -75,6 +203,184 @@ func TestIncreaseInstances(t *testing.T) {
 	}
 }
 
+func TestListGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	gomock.InOrder(
+		api.EXPECT().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+			AutoScalingGroups: []*autoscaling.Group{
+				&autoscaling.Group{
+					AutoScalingGroupName: aws.String("elasticsearch"),
+				},
+			},
+			NextToken: aws.String("token"),
+		}, nil),
+		api.EXPECT().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+			NextToken: aws.String("token"),
+		}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+			AutoScalingGroups: []*autoscaling.Group{
+				&autoscaling.Group{
+					AutoScalingGroupName: aws.String("kibana"),
+				},
+			},
+		}, nil),
+	)
+
+	client := &Client{
+		api: api,
+	}
+
+	expected := []string{"elasticsearch", "kibana"}
+
+	got, err := client.ListGroups()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("groups do not match. expected: %q, got: %q", expected, got)
+	}
+}
+
+func TestListGroupsByTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []*autoscaling.Group{
+			&autoscaling.Group{
+				AutoScalingGroupName: aws.String("elasticsearch-a"),
+				Tags: []*autoscaling.TagDescription{
+					&autoscaling.TagDescription{
+						Key:   aws.String("cluster"),
+						Value: aws.String("logs"),
+					},
+				},
+			},
+			&autoscaling.Group{
+				AutoScalingGroupName: aws.String("kibana"),
+			},
+			&autoscaling.Group{
+				AutoScalingGroupName: aws.String("elasticsearch-c"),
+				Tags: []*autoscaling.TagDescription{
+					&autoscaling.TagDescription{
+						Key:   aws.String("cluster"),
+						Value: aws.String("logs"),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	expected := []string{"elasticsearch-a", "elasticsearch-c"}
+
+	got, err := client.ListGroupsByTag("cluster", "logs")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("groups do not match. expected: %q, got: %q", expected, got)
+	}
+}
+
+func TestListInstances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{
+			aws.String("elasticsearch"),
+		},
+	}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []*autoscaling.Group{
+			&autoscaling.Group{
+				AutoScalingGroupName: aws.String("elasticsearch"),
+				Instances: []*autoscaling.Instance{
+					&autoscaling.Instance{
+						InstanceId: aws.String("i-1234abcd"),
+					},
+					&autoscaling.Instance{
+						InstanceId: aws.String("i-5678efab"),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	groupName := "elasticsearch"
+	expected := []string{"i-1234abcd", "i-5678efab"}
+
+	got, err := client.ListInstances(groupName)
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("instances do not match. expected: %q, got: %q", expected, got)
+	}
+}
+
+func TestRetrieveGroupOfInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []*string{
+			aws.String("i-1234abcd"),
+		},
+	}).Return(&autoscaling.DescribeAutoScalingInstancesOutput{
+		AutoScalingInstances: []*autoscaling.InstanceDetails{
+			&autoscaling.InstanceDetails{
+				AutoScalingGroupName: aws.String("elasticsearch"),
+				InstanceId:           aws.String("i-1234abcd"),
+			},
+		},
+	}, nil)
+	api.EXPECT().DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []*string{
+			aws.String("i-5678efab"),
+		},
+	}).Return(&autoscaling.DescribeAutoScalingInstancesOutput{
+		AutoScalingInstances: []*autoscaling.InstanceDetails{},
+	}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	got, err := client.RetrieveGroupOfInstance("i-1234abcd")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got != "elasticsearch" {
+		t.Errorf("group does not match. expected: %q, got: %q", "elasticsearch", got)
+	}
+
+	got, err = client.RetrieveGroupOfInstance("i-5678efab")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got != "" {
+		t.Errorf("empty group should be returned for instance outside of ASG. got: %q", got)
+	}
+}
+
 func TestRetrieveTargetGroup(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -106,3 +412,48 @@ func TestRetrieveTargetGroup(t *testing.T) {
 		t.Errorf("target group ARN does not match. expected: %q, got: %q", expected, got)
 	}
 }
+
+func TestReturnToWarmPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String("i-1234abcd"),
+		ShouldDecrementDesiredCapacity: aws.Bool(true),
+	}).Return(&autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	if err := client.ReturnToWarmPool("elasticsearch", "i-1234abcd"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestSetTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockAutoScalingAPI(ctrl)
+	api.EXPECT().CreateOrUpdateTags(&autoscaling.CreateOrUpdateTagsInput{
+		Tags: []*autoscaling.Tag{
+			&autoscaling.Tag{
+				Key:               aws.String("esnctl:last-scale"),
+				PropagateAtLaunch: aws.Bool(false),
+				ResourceId:        aws.String("elasticsearch"),
+				ResourceType:      aws.String("auto-scaling-group"),
+				Value:             aws.String("desired=3"),
+			},
+		},
+	}).Return(&autoscaling.CreateOrUpdateTagsOutput{}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	if err := client.SetTag("elasticsearch", "esnctl:last-scale", "desired=3"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
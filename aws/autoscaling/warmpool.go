@@ -0,0 +1,165 @@
+package autoscaling
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/pkg/errors"
+)
+
+// WarmPool represents warm pool of ASG
+type WarmPool struct {
+	// ReuseOnScaleIn is true if instances return to warm pool on scale in instead of being terminated
+	ReuseOnScaleIn bool
+
+	// Instances represents instances in warm pool, whose lifecycle state is e.g. Warmed:Stopped
+	Instances []*Instance
+}
+
+// Warmed returns instances which are ready to be moved into service
+func (p *WarmPool) Warmed() []*Instance {
+	instances := []*Instance{}
+
+	for _, i := range p.Instances {
+		switch i.LifecycleState {
+		case "Warmed:Stopped", "Warmed:Running", "Warmed:Hibernated":
+			instances = append(instances, i)
+		}
+	}
+
+	return instances
+}
+
+// warmPoolAPI represents warm pool API of Auto Scaling
+// aws-sdk-go in use predates warm pool, so requests are built with the generic client of Auto Scaling service
+type warmPoolAPI interface {
+	DescribeWarmPool(input *describeWarmPoolInput) (*describeWarmPoolOutput, error)
+}
+
+type describeWarmPoolInput struct {
+	_ struct{} `type:"structure"`
+
+	AutoScalingGroupName *string `type:"string" required:"true"`
+	NextToken            *string `type:"string"`
+}
+
+type describeWarmPoolOutput struct {
+	_ struct{} `type:"structure"`
+
+	Instances             []*warmPoolInstance    `type:"list"`
+	NextToken             *string                `type:"string"`
+	WarmPoolConfiguration *warmPoolConfiguration `type:"structure"`
+}
+
+type warmPoolInstance struct {
+	_ struct{} `type:"structure"`
+
+	AvailabilityZone *string `type:"string"`
+	InstanceId       *string `type:"string"`
+	LifecycleState   *string `type:"string"`
+}
+
+type warmPoolConfiguration struct {
+	_ struct{} `type:"structure"`
+
+	InstanceReusePolicy *instanceReusePolicy `type:"structure"`
+	Status              *string              `type:"string"`
+}
+
+type instanceReusePolicy struct {
+	_ struct{} `type:"structure"`
+
+	ReuseOnScaleIn *bool `type:"boolean"`
+}
+
+type rawWarmPoolAPI struct {
+	svc *autoscaling.AutoScaling
+}
+
+func (a *rawWarmPoolAPI) DescribeWarmPool(input *describeWarmPoolInput) (*describeWarmPoolOutput, error) {
+	output := &describeWarmPoolOutput{}
+
+	req := a.svc.NewRequest(&request.Operation{
+		Name:       "DescribeWarmPool",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, input, output)
+
+	return output, req.Send()
+}
+
+// NewWithWarmPool creates and returns new Client object which calls warm pool API through svc
+func NewWithWarmPool(api autoscalingiface.AutoScalingAPI, svc *autoscaling.AutoScaling) *Client {
+	return &Client{
+		api:      api,
+		warmPool: &rawWarmPoolAPI{svc: svc},
+	}
+}
+
+// DescribeWarmPool returns warm pool of the given ASG
+// nil is returned if the ASG has no warm pool, or Client is created without warm pool API
+func (c *Client) DescribeWarmPool(groupName string) (*WarmPool, error) {
+	if c.warmPool == nil {
+		return nil, nil
+	}
+
+	input := &describeWarmPoolInput{
+		AutoScalingGroupName: aws.String(groupName),
+	}
+
+	var pool *WarmPool
+
+	for {
+		resp, err := c.warmPool.DescribeWarmPool(input)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to describe warm pool")
+		}
+
+		if resp.WarmPoolConfiguration == nil || strings.HasPrefix(aws.StringValue(resp.WarmPoolConfiguration.Status), "PendingDelete") {
+			return nil, nil
+		}
+
+		if pool == nil {
+			pool = &WarmPool{
+				Instances: []*Instance{},
+			}
+
+			if policy := resp.WarmPoolConfiguration.InstanceReusePolicy; policy != nil {
+				pool.ReuseOnScaleIn = aws.BoolValue(policy.ReuseOnScaleIn)
+			}
+		}
+
+		for _, i := range resp.Instances {
+			pool.Instances = append(pool.Instances, &Instance{
+				ID:               aws.StringValue(i.InstanceId),
+				AvailabilityZone: aws.StringValue(i.AvailabilityZone),
+				LifecycleState:   aws.StringValue(i.LifecycleState),
+			})
+		}
+
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+
+		input.NextToken = resp.NextToken
+	}
+
+	return pool, nil
+}
+
+// ReturnToWarmPool scales in the given instance and decreases DesiredCapacity by one
+// The instance returns to warm pool if its reuse policy allows, otherwise it is terminated
+func (c *Client) ReturnToWarmPool(groupName, instanceID string) error {
+	_, err := c.api.TerminateInstanceInAutoScalingGroup(&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String(instanceID),
+		ShouldDecrementDesiredCapacity: aws.Bool(true),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to return instance to warm pool of %q", groupName)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package autoscaling
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+type fakeWarmPoolAPI struct {
+	pages []*describeWarmPoolOutput
+}
+
+func (a *fakeWarmPoolAPI) DescribeWarmPool(input *describeWarmPoolInput) (*describeWarmPoolOutput, error) {
+	if input.NextToken == nil {
+		return a.pages[0], nil
+	}
+
+	return a.pages[1], nil
+}
+
+func TestDescribeWarmPool(t *testing.T) {
+	client := &Client{
+		warmPool: &fakeWarmPoolAPI{
+			pages: []*describeWarmPoolOutput{
+				&describeWarmPoolOutput{
+					Instances: []*warmPoolInstance{
+						&warmPoolInstance{
+							AvailabilityZone: aws.String("ap-northeast-1a"),
+							InstanceId:       aws.String("i-1234abcd"),
+							LifecycleState:   aws.String("Warmed:Stopped"),
+						},
+					},
+					NextToken: aws.String("token"),
+					WarmPoolConfiguration: &warmPoolConfiguration{
+						InstanceReusePolicy: &instanceReusePolicy{
+							ReuseOnScaleIn: aws.Bool(true),
+						},
+						Status: aws.String("Active"),
+					},
+				},
+				&describeWarmPoolOutput{
+					Instances: []*warmPoolInstance{
+						&warmPoolInstance{
+							AvailabilityZone: aws.String("ap-northeast-1c"),
+							InstanceId:       aws.String("i-5678efab"),
+							LifecycleState:   aws.String("Warmed:Pending"),
+						},
+					},
+					WarmPoolConfiguration: &warmPoolConfiguration{
+						Status: aws.String("Active"),
+					},
+				},
+			},
+		},
+	}
+
+	got, err := client.DescribeWarmPool("elasticsearch")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := &WarmPool{
+		ReuseOnScaleIn: true,
+		Instances: []*Instance{
+			&Instance{
+				ID:               "i-1234abcd",
+				AvailabilityZone: "ap-northeast-1a",
+				LifecycleState:   "Warmed:Stopped",
+			},
+			&Instance{
+				ID:               "i-5678efab",
+				AvailabilityZone: "ap-northeast-1c",
+				LifecycleState:   "Warmed:Pending",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("warm pool does not match. expected: %#v, got: %#v", expected, got)
+	}
+
+	if warmed := got.Warmed(); len(warmed) != 1 || warmed[0].ID != "i-1234abcd" {
+		t.Errorf("only warmed instance should be returned. got: %v", warmed)
+	}
+}
+
+func TestDescribeWarmPool_noWarmPool(t *testing.T) {
+	client := &Client{
+		warmPool: &fakeWarmPoolAPI{
+			pages: []*describeWarmPoolOutput{
+				&describeWarmPoolOutput{},
+			},
+		},
+	}
+
+	got, err := client.DescribeWarmPool("elasticsearch")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got != nil {
+		t.Errorf("warm pool should be nil. got: %#v", got)
+	}
+}
@@ -1,48 +1,206 @@
 package aws
 
 import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	autoscalingapi "github.com/aws/aws-sdk-go/service/autoscaling"
+	cloudwatchapi "github.com/aws/aws-sdk-go/service/cloudwatch"
 	ec2api "github.com/aws/aws-sdk-go/service/ec2"
 	elbv2api "github.com/aws/aws-sdk-go/service/elbv2"
+	ssmapi "github.com/aws/aws-sdk-go/service/ssm"
+	stsapi "github.com/aws/aws-sdk-go/service/sts"
 	"github.com/dtan4/esnctl/aws/autoscaling"
+	"github.com/dtan4/esnctl/aws/cloudwatch"
 	"github.com/dtan4/esnctl/aws/ec2"
 	"github.com/dtan4/esnctl/aws/elbv2"
+	"github.com/dtan4/esnctl/aws/secretsmanager"
+	"github.com/dtan4/esnctl/aws/ssm"
+	"github.com/dtan4/esnctl/httpclient"
 	"github.com/pkg/errors"
 )
 
-var (
-	// AutoScaling represents Auto Scaling service client
-	AutoScaling *autoscaling.Client
-	// EC2 represents EC2 service client
-	EC2 *ec2.Client
-	// ELBv2 represents ELBV2 service client
-	ELBv2 *elbv2.Client
-)
+// AutoScalingClient represents interface of Auto Scaling API client
+type AutoScalingClient interface {
+	AttachInstance(groupName, instanceID string) error
+	DescribeGroup(groupName string) (*autoscaling.Group, error)
+	DescribeInstances(groupName string) ([]*autoscaling.Instance, error)
+	DescribeWarmPool(groupName string) (*autoscaling.WarmPool, error)
+	DetachInstance(groupName, instanceID string, decrementDesiredCapacity bool) error
+	IncreaseInstances(groupName string, delta int) (int, error)
+	ListGroups() ([]string, error)
+	ListGroupsByTag(key, value string) ([]string, error)
+	ListInstances(groupName string) ([]string, error)
+	RetrieveGroupOfInstance(instanceID string) (string, error)
+	RetrieveTargetGroup(groupName string) (string, error)
+	ReturnToWarmPool(groupName, instanceID string) error
+	SetTag(groupName, key, value string) error
+}
+
+// CloudWatchClient represents interface of CloudWatch API client
+type CloudWatchClient interface {
+	PutMetrics(namespace string, data []*cloudwatch.Datum) error
+}
+
+// EC2Client represents interface of EC2 API client
+type EC2Client interface {
+	DescribeInstance(instanceID string) (*ec2.Instance, error)
+	RebootInstance(instanceID string) error
+	RetrieveInstanceIDFromPrivateDNS(privateDNS string) (string, error)
+	SetTags(instanceID string, tags map[string]string) error
+	TerminateInstance(instanceID string) error
+}
+
+// ELBv2Client represents interface of ELBv2 API client
+type ELBv2Client interface {
+	DetachInstance(targetGroupARN, instanceID string) error
+	ListTargetInstances(targetGroupARN string) ([]string, error)
+	RegisterInstance(targetGroupARN, instanceID string) error
+}
+
+// SecretsManagerClient represents interface of Secrets Manager API client
+type SecretsManagerClient interface {
+	GetSecretString(arn string) (string, error)
+}
+
+// SSMClient represents interface of SSM API client
+type SSMClient interface {
+	GetParameter(name string) (string, error)
+}
 
-// Initialize creates AWS service client objects
-func Initialize(region string) error {
-	var (
-		sess *session.Session
-		err  error
-	)
+// Clients represents AWS service clients
+type Clients struct {
+	AutoScaling    AutoScalingClient
+	CloudWatch     CloudWatchClient
+	EC2            EC2Client
+	ELBv2          ELBv2Client
+	SecretsManager SecretsManagerClient
+	SSM            SSMClient
+}
+
+// Options represents options of AWS service clients
+type Options struct {
+	// MaxRetries represents the maximum number of retries for throttling and 5xx errors, with exponential backoff and jitter
+	MaxRetries int
+	// RateLimiter is waited for before every request including retries if not nil
+	RateLimiter *RateLimiter
+	// RoleARN is assumed for every request if given, e.g. to operate cluster in another account
+	RoleARN string
+	// Timeout bounds each API call. 0 means no timeout
+	Timeout time.Duration
+	// Trace logs every API request if not nil
+	Trace *log.Logger
+}
+
+// NewClients creates AWS service client objects for the given region
+// Region is read from environment or shared config if it is empty
+// Results of describe calls on Auto Scaling and EC2 are cached for a few seconds until mutating call
+func NewClients(region string, opts Options) (*Clients, error) {
+	sess, httpClient, err := newSession(region, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache is shared, so that mutating call of one service invalidates results of the other
+	c := newCache(cacheTTL)
+
+	autoScalingAPI := autoscalingapi.New(sess)
+
+	return &Clients{
+		AutoScaling:    autoscaling.NewWithWarmPool(&cachedAutoScalingAPI{AutoScalingAPI: autoScalingAPI, cache: c}, autoScalingAPI),
+		CloudWatch:     cloudwatch.New(cloudwatchapi.New(sess)),
+		EC2:            ec2.New(&cachedEC2API{EC2API: ec2api.New(sess), cache: c}),
+		ELBv2:          elbv2.New(elbv2api.New(sess)),
+		SecretsManager: secretsmanager.New(sess.Config.Credentials, httpClient),
+		SSM:            ssm.New(ssmapi.New(sess)),
+	}, nil
+}
+
+// ValidateCredentials checks that AWS credentials are available and accepted by AWS, by calling sts:GetCallerIdentity
+// sts:GetCallerIdentity requires no permission
+func ValidateCredentials(region string, opts Options) error {
+	sess, _, err := newSession(region, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := stsapi.New(sess).GetCallerIdentity(&stsapi.GetCallerIdentityInput{}); err != nil {
+		return errors.Wrap(err, "failed to validate AWS credentials")
+	}
 
-	if region == "" {
-		sess, err = session.NewSession()
+	return nil
+}
+
+func newSession(region string, opts Options) (*session.Session, *http.Client, error) {
+	config := aws.NewConfig().WithMaxRetries(opts.MaxRetries)
+	httpClient := &http.Client{}
+
+	if opts.Timeout > 0 {
+		httpClient.Timeout = opts.Timeout
+		config = config.WithHTTPClient(httpClient)
+	}
+
+	if opts.Trace != nil {
+		httpClient.Transport = httpclient.NewTraceTransport(nil, opts.Trace)
+		config = config.WithHTTPClient(httpClient)
+	}
+
+	if region != "" {
+		config = config.WithRegion(region)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create new AWS session")
+	}
+
+	if opts.RoleARN != "" {
+		// Credentials of the base session are used only to assume the role, and refreshed before expiry
+		sess, err = session.NewSession(config.Copy().WithCredentials(stscreds.NewCredentials(sess, opts.RoleARN)))
 		if err != nil {
-			return errors.Wrap(err, "failed to create new AWS session")
+			return nil, nil, errors.Wrapf(err, "failed to create AWS session assuming role %s", opts.RoleARN)
 		}
-	} else {
-		sess, err = session.NewSession(&aws.Config{Region: aws.String(region)})
+	}
+
+	if opts.RateLimiter != nil {
+		sess.Handlers.Send.PushFront(func(r *request.Request) {
+			opts.RateLimiter.Wait()
+		})
+	}
+
+	return sess, httpClient, nil
+}
+
+// RetrievePassword retrieves password from the given source
+// Source is either "ssm:<parameter name>" or ARN of Secrets Manager secret
+// If secret is JSON object (e.g. created for RDS), its "password" field is used
+func (c *Clients) RetrievePassword(source string) (string, error) {
+	switch {
+	case strings.HasPrefix(source, "ssm:"):
+		return c.SSM.GetParameter(strings.TrimPrefix(source, "ssm:"))
+	case strings.HasPrefix(source, "arn:") && strings.Contains(source, ":secretsmanager:"):
+		secret, err := c.SecretsManager.GetSecretString(source)
 		if err != nil {
-			return errors.Wrap(err, "failed to create new AWS session")
+			return "", err
 		}
-	}
 
-	AutoScaling = autoscaling.New(autoscalingapi.New(sess))
-	EC2 = ec2.New(ec2api.New(sess))
-	ELBv2 = elbv2.New(elbv2api.New(sess))
+		var v struct {
+			Password string `json:"password"`
+		}
 
-	return nil
+		if err := json.Unmarshal([]byte(secret), &v); err == nil && v.Password != "" {
+			return v.Password, nil
+		}
+
+		return secret, nil
+	}
+
+	return "", errors.Errorf("password source %q is invalid, must be \"ssm:<parameter name>\" or Secrets Manager ARN", source)
 }
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type fakeSecretsManagerClient struct {
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManagerClient) GetSecretString(arn string) (string, error) {
+	s, ok := f.secrets[arn]
+	if !ok {
+		return "", errors.Errorf("secret %q not found", arn)
+	}
+
+	return s, nil
+}
+
+type fakeSSMClient struct {
+	parameters map[string]string
+}
+
+func (f *fakeSSMClient) GetParameter(name string) (string, error) {
+	p, ok := f.parameters[name]
+	if !ok {
+		return "", errors.Errorf("parameter %q not found", name)
+	}
+
+	return p, nil
+}
+
+func TestRetrievePassword(t *testing.T) {
+	clients := &Clients{
+		SecretsManager: &fakeSecretsManagerClient{
+			secrets: map[string]string{
+				"arn:aws:secretsmanager:ap-northeast-1:012345678901:secret:es/prod-AbCdEf": `{"username":"elastic","password":"changeme"}`,
+				"arn:aws:secretsmanager:ap-northeast-1:012345678901:secret:es/dev-AbCdEf":  "plaintext",
+			},
+		},
+		SSM: &fakeSSMClient{
+			parameters: map[string]string{
+				"/es/prod/password": "secret",
+			},
+		},
+	}
+
+	testcases := []struct {
+		source   string
+		expected string
+	}{
+		{
+			source:   "ssm:/es/prod/password",
+			expected: "secret",
+		},
+		{
+			source:   "arn:aws:secretsmanager:ap-northeast-1:012345678901:secret:es/prod-AbCdEf",
+			expected: "changeme",
+		},
+		{
+			source:   "arn:aws:secretsmanager:ap-northeast-1:012345678901:secret:es/dev-AbCdEf",
+			expected: "plaintext",
+		},
+	}
+
+	for _, tc := range testcases {
+		got, err := clients.RetrievePassword(tc.source)
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+			continue
+		}
+
+		if got != tc.expected {
+			t.Errorf("password does not match. expected: %q, got: %q", tc.expected, got)
+		}
+	}
+
+	if _, err := clients.RetrievePassword("vault:secret/es"); err == nil {
+		t.Errorf("error should be raised for unsupported source")
+	}
+}
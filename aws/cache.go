@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	autoscalingapi "github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	ec2api "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// cacheTTL represents how long describe results are reused
+// Operation over many nodes calls the same describe APIs repeatedly within a few seconds
+const cacheTTL = 5 * time.Second
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// cache holds results of describe API calls keyed by request
+// All entries are invalidated by any mutating call, because it may change results of other requests
+type cache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]*cacheEntry{},
+	}
+}
+
+func (c *cache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+func (c *cache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]*cacheEntry{}
+}
+
+func (c *cache) set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &cacheEntry{
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+// cacheKey returns key of the given request. Request which cannot be serialized is not cached
+func cacheKey(name string, input interface{}) (string, bool) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return "", false
+	}
+
+	return name + string(b), true
+}
+
+// cachedAutoScalingAPI caches DescribeAutoScalingGroups and DescribeAutoScalingInstances
+type cachedAutoScalingAPI struct {
+	autoscalingiface.AutoScalingAPI
+
+	cache *cache
+}
+
+func (a *cachedAutoScalingAPI) DescribeAutoScalingGroups(input *autoscalingapi.DescribeAutoScalingGroupsInput) (*autoscalingapi.DescribeAutoScalingGroupsOutput, error) {
+	key, ok := cacheKey("DescribeAutoScalingGroups", input)
+	if ok {
+		if v, hit := a.cache.get(key); hit {
+			return v.(*autoscalingapi.DescribeAutoScalingGroupsOutput), nil
+		}
+	}
+
+	resp, err := a.AutoScalingAPI.DescribeAutoScalingGroups(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		a.cache.set(key, resp)
+	}
+
+	return resp, nil
+}
+
+func (a *cachedAutoScalingAPI) DescribeAutoScalingInstances(input *autoscalingapi.DescribeAutoScalingInstancesInput) (*autoscalingapi.DescribeAutoScalingInstancesOutput, error) {
+	key, ok := cacheKey("DescribeAutoScalingInstances", input)
+	if ok {
+		if v, hit := a.cache.get(key); hit {
+			return v.(*autoscalingapi.DescribeAutoScalingInstancesOutput), nil
+		}
+	}
+
+	resp, err := a.AutoScalingAPI.DescribeAutoScalingInstances(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		a.cache.set(key, resp)
+	}
+
+	return resp, nil
+}
+
+func (a *cachedAutoScalingAPI) AttachInstances(input *autoscalingapi.AttachInstancesInput) (*autoscalingapi.AttachInstancesOutput, error) {
+	defer a.cache.invalidate()
+
+	return a.AutoScalingAPI.AttachInstances(input)
+}
+
+func (a *cachedAutoScalingAPI) CreateOrUpdateTags(input *autoscalingapi.CreateOrUpdateTagsInput) (*autoscalingapi.CreateOrUpdateTagsOutput, error) {
+	defer a.cache.invalidate()
+
+	return a.AutoScalingAPI.CreateOrUpdateTags(input)
+}
+
+func (a *cachedAutoScalingAPI) DetachInstances(input *autoscalingapi.DetachInstancesInput) (*autoscalingapi.DetachInstancesOutput, error) {
+	defer a.cache.invalidate()
+
+	return a.AutoScalingAPI.DetachInstances(input)
+}
+
+func (a *cachedAutoScalingAPI) SetDesiredCapacity(input *autoscalingapi.SetDesiredCapacityInput) (*autoscalingapi.SetDesiredCapacityOutput, error) {
+	defer a.cache.invalidate()
+
+	return a.AutoScalingAPI.SetDesiredCapacity(input)
+}
+
+func (a *cachedAutoScalingAPI) TerminateInstanceInAutoScalingGroup(input *autoscalingapi.TerminateInstanceInAutoScalingGroupInput) (*autoscalingapi.TerminateInstanceInAutoScalingGroupOutput, error) {
+	defer a.cache.invalidate()
+
+	return a.AutoScalingAPI.TerminateInstanceInAutoScalingGroup(input)
+}
+
+// cachedEC2API caches DescribeInstances
+type cachedEC2API struct {
+	ec2iface.EC2API
+
+	cache *cache
+}
+
+func (e *cachedEC2API) DescribeInstances(input *ec2api.DescribeInstancesInput) (*ec2api.DescribeInstancesOutput, error) {
+	key, ok := cacheKey("DescribeInstances", input)
+	if ok {
+		if v, hit := e.cache.get(key); hit {
+			return v.(*ec2api.DescribeInstancesOutput), nil
+		}
+	}
+
+	resp, err := e.EC2API.DescribeInstances(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		e.cache.set(key, resp)
+	}
+
+	return resp, nil
+}
+
+func (e *cachedEC2API) TerminateInstances(input *ec2api.TerminateInstancesInput) (*ec2api.TerminateInstancesOutput, error) {
+	defer e.cache.invalidate()
+
+	return e.EC2API.TerminateInstances(input)
+}
@@ -0,0 +1,142 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	autoscalingapi "github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	ec2api "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+type countingAutoScalingAPI struct {
+	autoscalingiface.AutoScalingAPI
+
+	describeCalls int
+}
+
+func (f *countingAutoScalingAPI) DescribeAutoScalingGroups(input *autoscalingapi.DescribeAutoScalingGroupsInput) (*autoscalingapi.DescribeAutoScalingGroupsOutput, error) {
+	f.describeCalls++
+
+	return &autoscalingapi.DescribeAutoScalingGroupsOutput{}, nil
+}
+
+func (f *countingAutoScalingAPI) DetachInstances(input *autoscalingapi.DetachInstancesInput) (*autoscalingapi.DetachInstancesOutput, error) {
+	return &autoscalingapi.DetachInstancesOutput{}, nil
+}
+
+type countingEC2API struct {
+	ec2iface.EC2API
+
+	describeCalls int
+}
+
+func (f *countingEC2API) DescribeInstances(input *ec2api.DescribeInstancesInput) (*ec2api.DescribeInstancesOutput, error) {
+	f.describeCalls++
+
+	return &ec2api.DescribeInstancesOutput{}, nil
+}
+
+func TestCachedAutoScalingAPI(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := newCache(cacheTTL)
+	c.now = func() time.Time { return now }
+
+	api := &countingAutoScalingAPI{}
+	cached := &cachedAutoScalingAPI{AutoScalingAPI: api, cache: c}
+
+	describe := func(groupName string) {
+		if _, err := cached.DescribeAutoScalingGroups(&autoscalingapi.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []*string{
+				aws.String(groupName),
+			},
+		}); err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	testcases := []struct {
+		description string
+		prepare     func()
+		groupName   string
+		calls       int
+	}{
+		{
+			description: "first call",
+			prepare:     func() {},
+			groupName:   "elasticsearch",
+			calls:       1,
+		},
+		{
+			description: "the same request",
+			prepare:     func() {},
+			groupName:   "elasticsearch",
+			calls:       1,
+		},
+		{
+			description: "different request",
+			prepare:     func() {},
+			groupName:   "kibana",
+			calls:       2,
+		},
+		{
+			description: "after mutating call",
+			prepare: func() {
+				cached.DetachInstances(&autoscalingapi.DetachInstancesInput{})
+			},
+			groupName: "elasticsearch",
+			calls:     3,
+		},
+		{
+			description: "after expiration",
+			prepare: func() {
+				now = now.Add(cacheTTL)
+			},
+			groupName: "elasticsearch",
+			calls:     4,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc.prepare()
+		describe(tc.groupName)
+
+		if api.describeCalls != tc.calls {
+			t.Errorf("%s: number of API calls does not match. expected: %d, got: %d", tc.description, tc.calls, api.describeCalls)
+		}
+	}
+}
+
+func TestCachedEC2API_sharedCache(t *testing.T) {
+	c := newCache(cacheTTL)
+
+	asAPI := &countingAutoScalingAPI{}
+	ec2API := &countingEC2API{}
+
+	cachedAS := &cachedAutoScalingAPI{AutoScalingAPI: asAPI, cache: c}
+	cachedEC2 := &cachedEC2API{EC2API: ec2API, cache: c}
+
+	input := &ec2api.DescribeInstancesInput{
+		InstanceIds: []*string{
+			aws.String("i-1234abcd"),
+		},
+	}
+
+	cachedEC2.DescribeInstances(input)
+	cachedEC2.DescribeInstances(input)
+
+	if ec2API.describeCalls != 1 {
+		t.Errorf("the same request should be cached. got: %d calls", ec2API.describeCalls)
+	}
+
+	// Detaching from Auto Scaling Group may change instance state as well
+	cachedAS.DetachInstances(&autoscalingapi.DetachInstancesInput{})
+	cachedEC2.DescribeInstances(input)
+
+	if ec2API.describeCalls != 2 {
+		t.Errorf("cache should be invalidated by mutating call of other service. got: %d calls", ec2API.describeCalls)
+	}
+}
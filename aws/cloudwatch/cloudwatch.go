@@ -0,0 +1,105 @@
+package cloudwatch
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/pkg/errors"
+)
+
+// maxDataPerRequest represents the number of metric data sent in one PutMetricData request
+const maxDataPerRequest = 20
+
+const (
+	// UnitCount represents unit of counted metric
+	UnitCount = cloudwatch.StandardUnitCount
+	// UnitNone represents unit of metric without unit, e.g. status level
+	UnitNone = cloudwatch.StandardUnitNone
+	// UnitPercent represents unit of percentage metric
+	UnitPercent = cloudwatch.StandardUnitPercent
+	// UnitSeconds represents unit of duration metric
+	UnitSeconds = cloudwatch.StandardUnitSeconds
+)
+
+// Client represents a wrapper of CloudWatch API
+type Client struct {
+	api cloudwatchiface.CloudWatchAPI
+}
+
+// Datum represents one data point of custom metric
+type Datum struct {
+	Name       string
+	Value      float64
+	Unit       string
+	Dimensions map[string]string
+	Timestamp  time.Time
+}
+
+// New creates and returns new Client object
+func New(api cloudwatchiface.CloudWatchAPI) *Client {
+	return &Client{
+		api: api,
+	}
+}
+
+// PutMetrics publishes the given data points into the given namespace
+// Data are split into requests of maxDataPerRequest
+func (c *Client) PutMetrics(namespace string, data []*Datum) error {
+	for start := 0; start < len(data); start += maxDataPerRequest {
+		end := start + maxDataPerRequest
+		if end > len(data) {
+			end = len(data)
+		}
+
+		metricData := make([]*cloudwatch.MetricDatum, 0, end-start)
+
+		for _, d := range data[start:end] {
+			metricData = append(metricData, metricDatum(d))
+		}
+
+		_, err := c.api.PutMetricData(&cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(namespace),
+			MetricData: metricData,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to put metric data")
+		}
+	}
+
+	return nil
+}
+
+func metricDatum(d *Datum) *cloudwatch.MetricDatum {
+	keys := make([]string, 0, len(d.Dimensions))
+
+	for k := range d.Dimensions {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	dimensions := make([]*cloudwatch.Dimension, 0, len(keys))
+
+	for _, k := range keys {
+		dimensions = append(dimensions, &cloudwatch.Dimension{
+			Name:  aws.String(k),
+			Value: aws.String(d.Dimensions[k]),
+		})
+	}
+
+	md := &cloudwatch.MetricDatum{
+		MetricName: aws.String(d.Name),
+		Dimensions: dimensions,
+		Unit:       aws.String(d.Unit),
+		Value:      aws.Float64(d.Value),
+	}
+
+	if !d.Timestamp.IsZero() {
+		md.Timestamp = aws.Time(d.Timestamp)
+	}
+
+	return md
+}
@@ -0,0 +1,64 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+type fakeCloudWatchAPI struct {
+	cloudwatchiface.CloudWatchAPI
+
+	inputs []*cloudwatch.PutMetricDataInput
+}
+
+func (f *fakeCloudWatchAPI) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	f.inputs = append(f.inputs, input)
+
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+func TestPutMetrics(t *testing.T) {
+	api := &fakeCloudWatchAPI{}
+	client := &Client{
+		api: api,
+	}
+
+	data := []*Datum{}
+
+	for i := 0; i < 25; i++ {
+		data = append(data, &Datum{
+			Name:  fmt.Sprintf("Metric%d", i),
+			Value: float64(i),
+			Unit:  UnitCount,
+			Dimensions: map[string]string{
+				"Cluster": "logs",
+				"Command": "remove",
+			},
+		})
+	}
+
+	if err := client.PutMetrics("esnctl/Operations", data); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(api.inputs) != 2 {
+		t.Fatalf("data should be split into 2 requests. got: %d", len(api.inputs))
+	}
+
+	if got := len(api.inputs[0].MetricData) + len(api.inputs[1].MetricData); got != 25 {
+		t.Errorf("number of data does not match. expected: 25, got: %d", got)
+	}
+
+	if got := aws.StringValue(api.inputs[1].Namespace); got != "esnctl/Operations" {
+		t.Errorf("namespace does not match. expected: %q, got: %q", "esnctl/Operations", got)
+	}
+
+	dimensions := api.inputs[0].MetricData[0].Dimensions
+	if len(dimensions) != 2 || aws.StringValue(dimensions[0].Name) != "Cluster" || aws.StringValue(dimensions[1].Name) != "Command" {
+		t.Errorf("dimensions should be sorted by name. got: %v", dimensions)
+	}
+}
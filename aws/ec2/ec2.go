@@ -1,6 +1,8 @@
 package ec2
 
 import (
+	"sort"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
@@ -12,6 +14,17 @@ type Client struct {
 	api ec2iface.EC2API
 }
 
+// Instance represents EC2 instance
+type Instance struct {
+	ID           string
+	PrivateDNS   string
+	State        string
+	InstanceType string
+
+	// Lifecycle is spot or on-demand
+	Lifecycle string
+}
+
 // New creates and returns new Client object
 func New(api ec2iface.EC2API) *Client {
 	return &Client{
@@ -19,9 +32,61 @@ func New(api ec2iface.EC2API) *Client {
 	}
 }
 
+// DescribeInstance returns private DNS name, state (e.g. running), instance type and lifecycle of the given instance
+func (c *Client) DescribeInstance(instanceID string) (*Instance, error) {
+	resp, err := c.api.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{
+			aws.String(instanceID),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe instance")
+	}
+
+	for _, reservation := range resp.Reservations {
+		for _, i := range reservation.Instances {
+			instance := &Instance{
+				ID:           aws.StringValue(i.InstanceId),
+				PrivateDNS:   aws.StringValue(i.PrivateDnsName),
+				InstanceType: aws.StringValue(i.InstanceType),
+				Lifecycle:    "on-demand",
+			}
+
+			// InstanceLifecycle is set only for spot and scheduled instances
+			if aws.StringValue(i.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot {
+				instance.Lifecycle = "spot"
+			}
+
+			if i.State != nil {
+				instance.State = aws.StringValue(i.State.Name)
+			}
+
+			return instance, nil
+		}
+	}
+
+	return nil, errors.Errorf("instance %s not found", instanceID)
+}
+
+// RebootInstance reboots the given instance
+// Reboot is only requested, and the instance may not have stopped yet when this returns
+func (c *Client) RebootInstance(instanceID string) error {
+	_, err := c.api.RebootInstances(&ec2.RebootInstancesInput{
+		InstanceIds: []*string{
+			aws.String(instanceID),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to reboot instance")
+	}
+
+	return nil
+}
+
 // RetrieveInstanceIDFromPrivateDNS retrieves instance ID from private DNS name
+// Filtered result may come on later page, so pages are read until the instance is found
 func (c *Client) RetrieveInstanceIDFromPrivateDNS(privateDNS string) (string, error) {
-	resp, err := c.api.DescribeInstances(&ec2.DescribeInstancesInput{
+	input := &ec2.DescribeInstancesInput{
 		Filters: []*ec2.Filter{
 			&ec2.Filter{
 				Name: aws.String("private-dns-name"),
@@ -30,14 +95,69 @@ func (c *Client) RetrieveInstanceIDFromPrivateDNS(privateDNS string) (string, er
 				},
 			},
 		},
-	})
-	if err != nil {
-		return "", errors.Wrap(err, "failed to retrieve instance ID")
 	}
 
-	if len(resp.Reservations) == 0 || len(resp.Reservations[0].Instances) == 0 {
-		return "", errors.Errorf("instance with %q not found", privateDNS)
+	for {
+		resp, err := c.api.DescribeInstances(input)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to retrieve instance ID")
+		}
+
+		for _, reservation := range resp.Reservations {
+			if len(reservation.Instances) > 0 {
+				return aws.StringValue(reservation.Instances[0].InstanceId), nil
+			}
+		}
+
+		if aws.StringValue(resp.NextToken) == "" {
+			break
+		}
+
+		input.NextToken = resp.NextToken
+	}
+
+	return "", errors.Errorf("instance with %q not found", privateDNS)
+}
+
+// SetTags creates or updates the given tags of the instance
+func (c *Client) SetTags(instanceID string, tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	input := &ec2.CreateTagsInput{
+		Resources: []*string{
+			aws.String(instanceID),
+		},
+	}
+
+	for _, k := range keys {
+		input.Tags = append(input.Tags, &ec2.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(tags[k]),
+		})
+	}
+
+	if _, err := c.api.CreateTags(input); err != nil {
+		return errors.Wrap(err, "failed to tag instance")
+	}
+
+	return nil
+}
+
+// TerminateInstance terminates the given instance
+func (c *Client) TerminateInstance(instanceID string) error {
+	_, err := c.api.TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: []*string{
+			aws.String(instanceID),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to terminate instance")
 	}
 
-	return aws.StringValue(resp.Reservations[0].Instances[0].InstanceId), nil
+	return nil
 }
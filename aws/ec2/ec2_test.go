@@ -1,6 +1,7 @@
 package ec2
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -9,6 +10,75 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
+func TestDescribeInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockEC2API(ctrl)
+	api.EXPECT().DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{
+			aws.String("i-1234abcd"),
+		},
+	}).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{
+			&ec2.Reservation{
+				Instances: []*ec2.Instance{
+					&ec2.Instance{
+						InstanceId:     aws.String("i-1234abcd"),
+						PrivateDnsName: aws.String("ip-10-0-1-21.ap-northeast-1.compute.internal"),
+						State: &ec2.InstanceState{
+							Name: aws.String("running"),
+						},
+						InstanceType:      aws.String("r5.large"),
+						InstanceLifecycle: aws.String("spot"),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	got, err := client.DescribeInstance("i-1234abcd")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := &Instance{
+		ID:           "i-1234abcd",
+		PrivateDNS:   "ip-10-0-1-21.ap-northeast-1.compute.internal",
+		State:        "running",
+		InstanceType: "r5.large",
+		Lifecycle:    "spot",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("instance does not match. expected: %#v, got: %#v", expected, got)
+	}
+}
+
+func TestRebootInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockEC2API(ctrl)
+	api.EXPECT().RebootInstances(&ec2.RebootInstancesInput{
+		InstanceIds: []*string{
+			aws.String("i-1234abcd"),
+		},
+	}).Return(&ec2.RebootInstancesOutput{}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	if err := client.RebootInstance("i-1234abcd"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
 func TestRetrieveInstanceIDFromPrivateDNS(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -52,3 +122,108 @@ func TestRetrieveInstanceIDFromPrivateDNS(t *testing.T) {
 		t.Errorf("instance ID does not match. expected: %q, got: %q", expected, got)
 	}
 }
+
+func TestRetrieveInstanceIDFromPrivateDNS_paginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	filters := []*ec2.Filter{
+		&ec2.Filter{
+			Name: aws.String("private-dns-name"),
+			Values: []*string{
+				aws.String("ip-10-0-1-23.ap-northeast-1.compute.internal"),
+			},
+		},
+	}
+
+	api := mock.NewMockEC2API(ctrl)
+	gomock.InOrder(
+		api.EXPECT().DescribeInstances(&ec2.DescribeInstancesInput{
+			Filters: filters,
+		}).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{},
+			NextToken:    aws.String("token"),
+		}, nil),
+		api.EXPECT().DescribeInstances(&ec2.DescribeInstancesInput{
+			Filters:   filters,
+			NextToken: aws.String("token"),
+		}).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{
+				&ec2.Reservation{
+					Instances: []*ec2.Instance{
+						&ec2.Instance{
+							InstanceId: aws.String("i-1234abcd"),
+						},
+					},
+				},
+			},
+		}, nil),
+	)
+
+	client := &Client{
+		api: api,
+	}
+
+	got, err := client.RetrieveInstanceIDFromPrivateDNS("ip-10-0-1-23.ap-northeast-1.compute.internal")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got != "i-1234abcd" {
+		t.Errorf("instance ID does not match. expected: %q, got: %q", "i-1234abcd", got)
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockEC2API(ctrl)
+	api.EXPECT().CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{
+			aws.String("i-1234abcd"),
+		},
+		Tags: []*ec2.Tag{
+			&ec2.Tag{
+				Key:   aws.String("esnctl:operation-id"),
+				Value: aws.String("20180110-091200-abcd"),
+			},
+			&ec2.Tag{
+				Key:   aws.String("esnctl:removed-by"),
+				Value: aws.String("alice"),
+			},
+		},
+	}).Return(&ec2.CreateTagsOutput{}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	err := client.SetTags("i-1234abcd", map[string]string{
+		"esnctl:removed-by":   "alice",
+		"esnctl:operation-id": "20180110-091200-abcd",
+	})
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestTerminateInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockEC2API(ctrl)
+	api.EXPECT().TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: []*string{
+			aws.String("i-1234abcd"),
+		},
+	}).Return(&ec2.TerminateInstancesOutput{}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	if err := client.TerminateInstance("i-1234abcd"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
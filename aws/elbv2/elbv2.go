@@ -37,6 +37,7 @@ func (c *Client) DetachInstance(targetGroupARN, instanceID string) error {
 }
 
 // ListTargetInstances lists instance IDs attached to the given target group
+// DescribeTargetHealth is not paginated and returns all targets at once
 func (c *Client) ListTargetInstances(targetGroupARN string) ([]string, error) {
 	resp, err := c.api.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
 		TargetGroupArn: aws.String(targetGroupARN),
@@ -53,3 +54,21 @@ func (c *Client) ListTargetInstances(targetGroupARN string) ([]string, error) {
 
 	return instances, nil
 }
+
+// RegisterInstance registers the given instance with the given target group
+// Registering instance which is already registered does nothing
+func (c *Client) RegisterInstance(targetGroupARN, instanceID string) error {
+	_, err := c.api.RegisterTargets(&elbv2.RegisterTargetsInput{
+		TargetGroupArn: aws.String(targetGroupARN),
+		Targets: []*elbv2.TargetDescription{
+			&elbv2.TargetDescription{
+				Id: aws.String(instanceID),
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to register instance")
+	}
+
+	return nil
+}
@@ -83,3 +83,29 @@ func TestListTargetInstances(t *testing.T) {
 		t.Errorf("instance IDs does not match. expected: %q, got: %q", expected, got)
 	}
 }
+
+func TestRegisterInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := mock.NewMockELBV2API(ctrl)
+	api.EXPECT().RegisterTargets(&elbv2.RegisterTargetsInput{
+		TargetGroupArn: aws.String("arn:aws:elasticloadbalancing:ap-northeast-1:012345678901:targetgroup/elasticsearch/0123abcd5678efab"),
+		Targets: []*elbv2.TargetDescription{
+			&elbv2.TargetDescription{
+				Id: aws.String("i-1234abcd"),
+			},
+		},
+	}).Return(&elbv2.RegisterTargetsOutput{}, nil)
+
+	client := &Client{
+		api: api,
+	}
+
+	targetGroupARN := "arn:aws:elasticloadbalancing:ap-northeast-1:012345678901:targetgroup/elasticsearch/0123abcd5678efab"
+	instanceID := "i-1234abcd"
+
+	if err := client.RegisterInstance(targetGroupARN, instanceID); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces out AWS API requests not to exceed the given number of requests per second
+// Throttling is applied per account, so one RateLimiter should be shared by all clients in the process
+type RateLimiter struct {
+	interval time.Duration
+	now      func() time.Time
+	sleep    func(time.Duration)
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimiter creates new RateLimiter object
+// nil is returned if maxRPS is not greater than 0, which means no limit
+func NewRateLimiter(maxRPS float64) *RateLimiter {
+	if maxRPS <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / maxRPS),
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+// Wait blocks until the next request is allowed
+// nil RateLimiter never blocks
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+
+	now := l.now()
+
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	l.mu.Unlock()
+
+	if wait > 0 {
+		l.sleep(wait)
+	}
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiter_unlimited(t *testing.T) {
+	for _, maxRPS := range []float64{0, -1} {
+		l := NewRateLimiter(maxRPS)
+		if l != nil {
+			t.Errorf("limiter should be nil for %f rps", maxRPS)
+		}
+
+		// nil limiter must not block
+		l.Wait()
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	slept := []time.Duration{}
+
+	l := NewRateLimiter(4)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	for i := 0; i < 3; i++ {
+		l.Wait()
+	}
+
+	expected := []time.Duration{250 * time.Millisecond, 250 * time.Millisecond}
+
+	if len(slept) != len(expected) {
+		t.Fatalf("number of waits does not match. expected: %d, got: %d", len(expected), len(slept))
+	}
+
+	for i := range expected {
+		if slept[i] != expected[i] {
+			t.Errorf("wait does not match. expected: %s, got: %s", expected[i], slept[i])
+		}
+	}
+
+	// Idle time is not accumulated as burst
+	now = now.Add(10 * time.Second)
+	slept = []time.Duration{}
+
+	l.Wait()
+
+	if len(slept) != 0 {
+		t.Errorf("first request after idle time should not wait. got: %v", slept)
+	}
+}
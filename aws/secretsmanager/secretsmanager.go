@@ -0,0 +1,140 @@
+package secretsmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	serviceName          = "secretsmanager"
+	targetGetSecretValue = "secretsmanager.GetSecretValue"
+)
+
+// Client represents Secrets Manager API client
+// The bundled AWS SDK does not have Secrets Manager, so requests are signed and sent directly
+type Client struct {
+	credentials *credentials.Credentials
+	httpClient  *http.Client
+
+	// endpoint overrides regional endpoint if not empty
+	endpoint string
+}
+
+type getSecretValueResponse struct {
+	SecretString string `json:"SecretString"`
+}
+
+type errorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// New creates and returns new Client object
+func New(creds *credentials.Credentials, httpClient *http.Client) *Client {
+	return &Client{
+		credentials: creds,
+		httpClient:  httpClient,
+	}
+}
+
+// GetSecretString retrieves SecretString of the given secret ARN
+// Region is determined from the ARN
+func (c *Client) GetSecretString(arn string) (string, error) {
+	// arn:<partition>:secretsmanager:<region>:<account>:secret:<name>
+	fields := strings.SplitN(arn, ":", 7)
+	if len(fields) != 7 || fields[0] != "arn" || fields[2] != serviceName || fields[3] == "" {
+		return "", errors.Errorf("secret ARN %q is invalid", arn)
+	}
+
+	region := fields[3]
+
+	endpoint := c.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com/", serviceName, region)
+
+		if fields[1] == "aws-cn" {
+			endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com.cn/", serviceName, region)
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"SecretId": arn,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to build request body")
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make http request")
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", targetGetSecretValue)
+
+	// Signer attaches the signed body to the request
+	if _, err := v4.NewSigner(c.credentials).Sign(req, bytes.NewReader(body), serviceName, region, time.Now()); err != nil {
+		return "", errors.Wrap(err, "failed to sign request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve secret")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+
+		if err := json.Unmarshal(respBody, &e); err == nil && e.Type != "" {
+			return "", &Error{
+				code:    e.Type[strings.LastIndex(e.Type, "#")+1:],
+				message: e.Message,
+			}
+		}
+
+		return "", errors.Errorf("failed to retrieve secret. code: %d, body: %s", resp.StatusCode, respBody)
+	}
+
+	var r getSecretValueResponse
+
+	if err := json.Unmarshal(respBody, &r); err != nil {
+		return "", errors.Wrap(err, "invalid response body")
+	}
+
+	if r.SecretString == "" {
+		return "", errors.Errorf("secret %q does not have SecretString", arn)
+	}
+
+	return r.SecretString, nil
+}
+
+// Error represents error returned from Secrets Manager API
+type Error struct {
+	code    string
+	message string
+}
+
+// Code returns error code, e.g. AccessDeniedException
+func (e *Error) Code() string {
+	return e.code
+}
+
+// Error returns error message
+func (e *Error) Error() string {
+	return e.code + ": " + e.message
+}
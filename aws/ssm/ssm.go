@@ -0,0 +1,40 @@
+package ssm
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/pkg/errors"
+)
+
+// Client represents a wrapper of SSM API
+type Client struct {
+	api ssmiface.SSMAPI
+}
+
+// New creates and returns new Client object
+func New(api ssmiface.SSMAPI) *Client {
+	return &Client{
+		api: api,
+	}
+}
+
+// GetParameter retrieves value of the given parameter from Parameter Store
+// SecureString parameter is decrypted
+func (c *Client) GetParameter(name string) (string, error) {
+	resp, err := c.api.GetParameters(&ssm.GetParametersInput{
+		Names: []*string{
+			aws.String(name),
+		},
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve parameter")
+	}
+
+	if len(resp.Parameters) == 0 {
+		return "", errors.Errorf("parameter %q not found", name)
+	}
+
+	return aws.StringValue(resp.Parameters[0].Value), nil
+}
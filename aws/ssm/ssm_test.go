@@ -0,0 +1,60 @@
+package ssm
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+type fakeSSMAPI struct {
+	ssmiface.SSMAPI
+
+	parameters map[string]string
+}
+
+func (f *fakeSSMAPI) GetParameters(input *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
+	if !aws.BoolValue(input.WithDecryption) {
+		return nil, nil
+	}
+
+	out := &ssm.GetParametersOutput{}
+
+	for _, name := range input.Names {
+		if v, ok := f.parameters[aws.StringValue(name)]; ok {
+			out.Parameters = append(out.Parameters, &ssm.Parameter{
+				Name:  name,
+				Type:  aws.String("SecureString"),
+				Value: aws.String(v),
+			})
+		} else {
+			out.InvalidParameters = append(out.InvalidParameters, name)
+		}
+	}
+
+	return out, nil
+}
+
+func TestGetParameter(t *testing.T) {
+	client := &Client{
+		api: &fakeSSMAPI{
+			parameters: map[string]string{
+				"/es/prod/password": "changeme",
+			},
+		},
+	}
+
+	got, err := client.GetParameter("/es/prod/password")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got != "changeme" {
+		t.Errorf("parameter does not match. expected: %q, got: %q", "changeme", got)
+	}
+
+	if _, err := client.GetParameter("/es/dev/password"); err == nil {
+		t.Errorf("error should be raised for unknown parameter")
+	}
+}
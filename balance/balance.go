@@ -0,0 +1,184 @@
+package balance
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+)
+
+// Color codes have the same length so that tabwriter aligns colored and uncolored rows
+const (
+	colorCyan    = "\x1b[36m"
+	colorDefault = "\x1b[39m"
+	colorRed     = "\x1b[31m"
+	colorReset   = "\x1b[0m"
+)
+
+// Node represents shards and disk usage of node
+type Node struct {
+	Name             string  `json:"name"`
+	AvailabilityZone string  `json:"availability_zone"`
+	Shards           int     `json:"shards"`
+	DiskUsage        float64 `json:"disk_usage"`
+
+	// ShardDeviation and DiskDeviation represent deviation from the mean in percent
+	ShardDeviation float64 `json:"shard_deviation"`
+	DiskDeviation  float64 `json:"disk_deviation"`
+	Outlier        bool    `json:"outlier"`
+}
+
+// Zone represents shards and disk usage of nodes in the same AZ
+// Shards and DiskUsage are averaged per node so that AZs with different number of nodes can be compared
+type Zone struct {
+	Name      string  `json:"name"`
+	Nodes     int     `json:"nodes"`
+	Shards    float64 `json:"shards_per_node"`
+	DiskUsage float64 `json:"disk_usage"`
+
+	ShardDeviation float64 `json:"shard_deviation"`
+	DiskDeviation  float64 `json:"disk_deviation"`
+	Outlier        bool    `json:"outlier"`
+}
+
+// Report represents shard balance of cluster
+type Report struct {
+	MeanShards    float64 `json:"mean_shards"`
+	MeanDiskUsage float64 `json:"mean_disk_usage"`
+	Threshold     float64 `json:"threshold"`
+	Nodes         []*Node `json:"nodes"`
+	Zones         []*Zone `json:"zones"`
+}
+
+// New calculates deviation of the given nodes and AZs from the mean
+// Node or AZ deviating more than threshold percent in shards or disk usage is marked as outlier
+func New(nodes []*Node, threshold float64) *Report {
+	r := &Report{
+		Threshold: threshold,
+		Nodes:     nodes,
+		Zones:     []*Zone{},
+	}
+
+	sort.Slice(r.Nodes, func(i, j int) bool {
+		return r.Nodes[i].Name < r.Nodes[j].Name
+	})
+
+	if len(nodes) == 0 {
+		return r
+	}
+
+	zones := map[string]*Zone{}
+
+	for _, n := range nodes {
+		r.MeanShards += float64(n.Shards)
+		r.MeanDiskUsage += n.DiskUsage
+
+		// Nodes without AZ, e.g. outside of EC2, are not counted in any AZ
+		if n.AvailabilityZone == "" {
+			continue
+		}
+
+		z, ok := zones[n.AvailabilityZone]
+		if !ok {
+			z = &Zone{Name: n.AvailabilityZone}
+			zones[n.AvailabilityZone] = z
+			r.Zones = append(r.Zones, z)
+		}
+
+		z.Nodes++
+		z.Shards += float64(n.Shards)
+		z.DiskUsage += n.DiskUsage
+	}
+
+	r.MeanShards /= float64(len(nodes))
+	r.MeanDiskUsage /= float64(len(nodes))
+
+	for _, n := range r.Nodes {
+		n.ShardDeviation = deviation(float64(n.Shards), r.MeanShards)
+		n.DiskDeviation = deviation(n.DiskUsage, r.MeanDiskUsage)
+		n.Outlier = math.Abs(n.ShardDeviation) > threshold || math.Abs(n.DiskDeviation) > threshold
+	}
+
+	sort.Slice(r.Zones, func(i, j int) bool {
+		return r.Zones[i].Name < r.Zones[j].Name
+	})
+
+	for _, z := range r.Zones {
+		z.Shards /= float64(z.Nodes)
+		z.DiskUsage /= float64(z.Nodes)
+		z.ShardDeviation = deviation(z.Shards, r.MeanShards)
+		z.DiskDeviation = deviation(z.DiskUsage, r.MeanDiskUsage)
+		z.Outlier = math.Abs(z.ShardDeviation) > threshold || math.Abs(z.DiskDeviation) > threshold
+	}
+
+	return r
+}
+
+// Render prints tables of nodes and AZs
+// With color, outliers above the mean are shown in red, and ones below the mean in cyan
+func (r *Report) Render(out io.Writer, color bool) {
+	fmt.Fprintf(out, "Mean: %.1f shards, %.1f%% disk / node\n\n", r.MeanShards, r.MeanDiskUsage)
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, paint("NODE\tAZ\tSHARDS\tDEVIATION\tDISK\tDEVIATION", color, false, 0))
+
+	for _, n := range r.Nodes {
+		row := fmt.Sprintf("%s\t%s\t%d\t%+.1f%%\t%.1f%%\t%+.1f%%", n.Name, orDash(n.AvailabilityZone), n.Shards, n.ShardDeviation, n.DiskUsage, n.DiskDeviation)
+		fmt.Fprintln(w, paint(row, color, n.Outlier, n.ShardDeviation+n.DiskDeviation))
+	}
+
+	w.Flush()
+
+	if len(r.Zones) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, paint("AZ\tNODES\tSHARDS/NODE\tDEVIATION\tDISK\tDEVIATION", color, false, 0))
+
+	for _, z := range r.Zones {
+		row := fmt.Sprintf("%s\t%d\t%.1f\t%+.1f%%\t%.1f%%\t%+.1f%%", z.Name, z.Nodes, z.Shards, z.ShardDeviation, z.DiskUsage, z.DiskDeviation)
+		fmt.Fprintln(w, paint(row, color, z.Outlier, z.ShardDeviation+z.DiskDeviation))
+	}
+
+	w.Flush()
+}
+
+// deviation returns difference of v from mean in percent of mean
+func deviation(v, mean float64) float64 {
+	if mean == 0 {
+		return 0
+	}
+
+	return (v - mean) / mean * 100
+}
+
+// paint wraps the whole row in color. Outlier above the mean is red, and one below the mean is cyan
+func paint(row string, color, outlier bool, direction float64) string {
+	if !color {
+		return row
+	}
+
+	code := colorDefault
+
+	if outlier {
+		if direction > 0 {
+			code = colorRed
+		} else {
+			code = colorCyan
+		}
+	}
+
+	return code + row + colorReset
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,111 @@
+package balance
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testNodes() []*Node {
+	return []*Node{
+		&Node{Name: "node-3", AvailabilityZone: "us-east-1b", Shards: 14, DiskUsage: 70},
+		&Node{Name: "node-1", AvailabilityZone: "us-east-1a", Shards: 10, DiskUsage: 50},
+		&Node{Name: "node-2", AvailabilityZone: "us-east-1a", Shards: 6, DiskUsage: 30},
+		&Node{Name: "node-4", Shards: 10, DiskUsage: 50},
+	}
+}
+
+func TestNew(t *testing.T) {
+	r := New(testNodes(), 20)
+
+	if r.MeanShards != 10 {
+		t.Errorf("mean shards does not match. expected: 10, got: %f", r.MeanShards)
+	}
+
+	if r.MeanDiskUsage != 50 {
+		t.Errorf("mean disk usage does not match. expected: 50, got: %f", r.MeanDiskUsage)
+	}
+
+	expectedNodes := []struct {
+		name      string
+		deviation float64
+		outlier   bool
+	}{
+		{name: "node-1", deviation: 0, outlier: false},
+		{name: "node-2", deviation: -40, outlier: true},
+		{name: "node-3", deviation: 40, outlier: true},
+		{name: "node-4", deviation: 0, outlier: false},
+	}
+
+	for i, expected := range expectedNodes {
+		n := r.Nodes[i]
+
+		if n.Name != expected.name {
+			t.Errorf("nodes should be sorted by name. expected: %s, got: %s", expected.name, n.Name)
+		}
+
+		if n.ShardDeviation != expected.deviation {
+			t.Errorf("deviation of %s does not match. expected: %f, got: %f", n.Name, expected.deviation, n.ShardDeviation)
+		}
+
+		if n.Outlier != expected.outlier {
+			t.Errorf("outlier of %s does not match. expected: %t, got: %t", n.Name, expected.outlier, n.Outlier)
+		}
+	}
+
+	// node-4 without AZ is not counted in AZs
+	if len(r.Zones) != 2 {
+		t.Fatalf("number of AZs does not match. expected: 2, got: %d", len(r.Zones))
+	}
+
+	if z := r.Zones[0]; z.Name != "us-east-1a" || z.Nodes != 2 || z.Shards != 8 || z.ShardDeviation != -20 || z.Outlier {
+		t.Errorf("AZ does not match. got: %+v", z)
+	}
+
+	if z := r.Zones[1]; z.Name != "us-east-1b" || z.Nodes != 1 || z.Shards != 14 || !z.Outlier {
+		t.Errorf("AZ does not match. got: %+v", z)
+	}
+}
+
+func TestNew_empty(t *testing.T) {
+	r := New([]*Node{}, 20)
+
+	if r.MeanShards != 0 || len(r.Zones) != 0 {
+		t.Errorf("empty report should be returned. got: %+v", r)
+	}
+}
+
+func TestRender(t *testing.T) {
+	var out bytes.Buffer
+
+	New(testNodes(), 20).Render(&out, false)
+
+	expected := `Mean: 10.0 shards, 50.0% disk / node
+
+NODE    AZ          SHARDS  DEVIATION  DISK   DEVIATION
+node-1  us-east-1a  10      +0.0%      50.0%  +0.0%
+node-2  us-east-1a  6       -40.0%     30.0%  -40.0%
+node-3  us-east-1b  14      +40.0%     70.0%  +40.0%
+node-4  -           10      +0.0%      50.0%  +0.0%
+
+AZ          NODES  SHARDS/NODE  DEVIATION  DISK   DEVIATION
+us-east-1a  2      8.0          -20.0%     40.0%  -20.0%
+us-east-1b  1      14.0         +40.0%     70.0%  +40.0%
+`
+
+	if out.String() != expected {
+		t.Errorf("output does not match. expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestRender_color(t *testing.T) {
+	var out bytes.Buffer
+
+	New(testNodes(), 20).Render(&out, true)
+
+	for _, s := range []string{colorRed + "node-3", colorCyan + "node-2", colorDefault + "node-1"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output should contain %q. got: %q", s, out.String())
+		}
+	}
+}
@@ -1,22 +1,19 @@
 package cmd
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"io/ioutil"
 	"net/http"
-	"time"
+	"strings"
 
-	"github.com/dtan4/esnctl/aws"
 	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
-const (
-	addMaxRetry     = 120
-	addSleepSeconds = 5
-)
-
 // addCmd represents the add command
 var addCmd = &cobra.Command{
 	SilenceErrors: true,
@@ -27,90 +24,112 @@ var addCmd = &cobra.Command{
 }
 
 var addOpts = struct {
-	autoScalingGroup string
-	clusterURL       string
-	delta            int
-	region           string
+	autoScalingGroups []string
+	clusterURL        string
+	delta             int
+	groupTag          string
+	instanceID        string
+	region            string
+	registerAfterJoin bool
+	tagScale          bool
+	warmupQueries     string
+	operationOptions
+	slmWindowOptions
 }{}
 
 func doAdd(cmd *cobra.Command, args []string) error {
 	if addOpts.clusterURL == "" {
-		return errors.New("Elasticsearch cluster URL (--cluster-url) must be specified")
-	}
-
-	if addOpts.autoScalingGroup == "" {
-		return errors.New("Auto Scaling Group (--group) must be specified")
-	}
-
-	if addOpts.delta < 1 {
-		return errors.New("number to add instances must be greater than 0")
-	}
-
-	httpClient := &http.Client{}
-
-	client, err := es.New(addOpts.clusterURL, httpClient)
-	if err != nil {
-		return errors.Wrap(err, "failed to create Elasitcsearch API client")
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
 	}
 
-	if err := aws.Initialize(addOpts.region); err != nil {
-		return errors.Wrap(err, "failed to initialize AWS service clients")
+	if len(addOpts.autoScalingGroups) == 0 && addOpts.groupTag == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group or --group-tag) must be specified")
 	}
 
-	log.Println("===> Disabling shard reallocation...")
-
-	if err := client.DisableReallocation(); err != nil {
-		return errors.Wrap(err, "failed to disable reallocation")
-	}
-
-	log.Printf("===> Launching %d instances on %s...\n", addOpts.delta, addOpts.autoScalingGroup)
-
-	desiredCapacity, err := aws.AutoScaling.IncreaseInstances(addOpts.autoScalingGroup, addOpts.delta)
-	if err != nil {
-		return errors.Wrap(err, "failed to increase instance")
+	if addOpts.instanceID != "" {
+		if addOpts.delta > 1 {
+			return exitcode.New(exitcode.Validation, "only one instance can be attached with --instance-id")
+		}
+	} else if addOpts.delta < 1 {
+		return exitcode.New(exitcode.Validation, "number to add instances must be greater than 0")
 	}
 
-	log.Println("===> Waiting for nodes join to Elasticsearch cluster...")
+	var warmupQueries []*es.WarmupQuery
 
-	retryCount := 0
+	if addOpts.warmupQueries != "" {
+		if mock {
+			return exitcode.New(exitcode.Validation, "warm-up queries are not supported by fake cluster (--mock)")
+		}
 
-	for {
-		nodes, err := client.ListNodes()
+		data, err := ioutil.ReadFile(addOpts.warmupQueries)
 		if err != nil {
-			return errors.Wrap(err, "failed to list nodes")
+			return exitcode.Wrap(errors.Wrap(err, "failed to read warm-up queries"), exitcode.Validation)
 		}
 
-		if len(nodes) == desiredCapacity {
-			fmt.Print("\n")
-			break
+		warmupQueries, err = es.ParseWarmupQueries(data)
+		if err != nil {
+			return err
 		}
+	}
 
-		fmt.Print(".")
+	// JVM of added nodes is checked via HTTP, which fake cluster does not serve
+	var httpClient *http.Client
 
-		if retryCount == addMaxRetry {
-			return errors.New("timed out: added nodes do not join to Elasticsearch cluster")
-		}
+	if !mock {
+		var err error
 
-		retryCount++
-		time.Sleep(addSleepSeconds * time.Second)
+		httpClient, err = newHTTPClient(addOpts.clusterURL)
+		if err != nil {
+			return err
+		}
 	}
 
-	log.Println("===> Enabling shard reallocation...")
-
-	if err := client.EnableReallocation(); err != nil {
-		return errors.Wrap(err, "failed to enable reallocation")
+	w, err := newWorkflow(addOpts.clusterURL, addOpts.region)
+	if err != nil {
+		return err
 	}
 
-	log.Println("===> Finished!")
+	groups, err := w.ResolveGroups(addOpts.autoScalingGroups, addOpts.groupTag)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	op := operation.New("add", addOpts.clusterURL)
+	op.Group = strings.Join(groups, ",")
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, addOpts.operationOptions, func(ctx context.Context) error {
+		return w.AddNodes(ctx, workflow.AddOptions{
+			Groups:            groups,
+			Count:             addOpts.delta,
+			InstanceID:        addOpts.instanceID,
+			WarmupQueries:     warmupQueries,
+			RegisterAfterJoin: addOpts.registerAfterJoin,
+			TagScale:          addOpts.tagScale,
+			HTTPClient:        httpClient,
+			SLMWindow:         addOpts.slmWindowOptions.window,
+			RespectSLMWindow:  addOpts.slmWindowOptions.respect,
+			Operation:         op,
+		})
+	})
 }
 
 func init() {
 	RootCmd.AddCommand(addCmd)
 
-	addCmd.Flags().StringVar(&addOpts.autoScalingGroup, "group", "", "Auto Scaling Group")
-	addCmd.Flags().StringVar(&addOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL")
+	addCmd.Flags().StringSliceVar(&addOpts.autoScalingGroups, "group", []string{}, "Auto Scaling Group (repeat to distribute instances across groups)")
+	addCmd.Flags().StringVar(&addOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	addCmd.Flags().StringVar(&addOpts.groupTag, "group-tag", "", "Select Auto Scaling Groups by tag in key=value format")
+	addCmd.Flags().StringVar(&addOpts.instanceID, "instance-id", "", "Attach the running instance instead of launching new instances")
 	addCmd.Flags().IntVarP(&addOpts.delta, "number", "n", 0, "Number to add instances")
 	addCmd.Flags().StringVar(&addOpts.region, "region", "", "AWS region")
+	addCmd.Flags().BoolVar(&addOpts.registerAfterJoin, "register-after-join", false, "Register added instances with target group only after the cluster is green without shards moving")
+	addCmd.Flags().BoolVar(&addOpts.tagScale, "tag-scale", false, "Record the new desired capacity in esnctl:last-scale tag of Auto Scaling Group")
+	addCmd.Flags().StringVar(&addOpts.warmupQueries, "warmup-queries", "", "JSON file of searches run on added nodes before they are registered with target group")
+	addOpts.operationOptions.addFlags(addCmd)
+	addOpts.slmWindowOptions.addFlags(addCmd)
+
+	markFlagCompletion(addCmd.Flags(), "group")
 }
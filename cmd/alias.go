@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/spf13/cobra"
+)
+
+// aliasCmd represents the alias command
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage index aliases with the same credentials as other commands",
+}
+
+// aliasAddCmd represents the alias add command
+var aliasAddCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "add INDEX ALIAS",
+	Short:         "Add alias to index",
+	RunE:          doAliasAdd,
+}
+
+// aliasRemoveCmd represents the alias remove command
+var aliasRemoveCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "remove INDEX ALIAS",
+	Short:         "Remove alias from index",
+	RunE:          doAliasRemove,
+}
+
+// aliasSwapCmd represents the alias swap command
+var aliasSwapCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "swap ALIAS INDEX",
+	Short:         "Point alias at index atomically, removing it from all other indices",
+	RunE:          doAliasSwap,
+}
+
+var aliasOpts = struct {
+	clusterURL string
+	writeIndex bool
+}{}
+
+func doAliasAdd(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return exitcode.New(exitcode.Validation, "index and alias must be specified")
+	}
+
+	clusterURL, httpClient, err := newAliasClient()
+	if err != nil {
+		return err
+	}
+
+	if err := es.AddAlias(clusterURL, httpClient, args[0], args[1], aliasOpts.writeIndex); err != nil {
+		return err
+	}
+
+	log.Printf("===> Alias %s is added to %s\n", args[1], args[0])
+
+	return nil
+}
+
+func doAliasRemove(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return exitcode.New(exitcode.Validation, "index and alias must be specified")
+	}
+
+	clusterURL, httpClient, err := newAliasClient()
+	if err != nil {
+		return err
+	}
+
+	if err := es.RemoveAlias(clusterURL, httpClient, args[0], args[1]); err != nil {
+		return err
+	}
+
+	log.Printf("===> Alias %s is removed from %s\n", args[1], args[0])
+
+	return nil
+}
+
+func doAliasSwap(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return exitcode.New(exitcode.Validation, "alias and index must be specified")
+	}
+
+	clusterURL, httpClient, err := newAliasClient()
+	if err != nil {
+		return err
+	}
+
+	previous, err := es.SwapAlias(clusterURL, httpClient, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	if len(previous) > 0 {
+		log.Printf("===> Alias %s is removed from %s\n", args[0], strings.Join(previous, ", "))
+	}
+
+	log.Printf("===> Alias %s now points to %s\n", args[0], args[1])
+
+	return nil
+}
+
+func newAliasClient() (string, *http.Client, error) {
+	if aliasOpts.clusterURL == "" {
+		return "", nil, exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if mock {
+		return "", nil, exitcode.New(exitcode.Validation, "esnctl alias cannot be used with --mock")
+	}
+
+	httpClient, err := newHTTPClient(aliasOpts.clusterURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	clusterURL, err := withCredentials(aliasOpts.clusterURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return clusterURL, httpClient, nil
+}
+
+func init() {
+	RootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+	aliasCmd.AddCommand(aliasSwapCmd)
+
+	aliasCmd.PersistentFlags().StringVar(&aliasOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+
+	aliasAddCmd.Flags().BoolVar(&aliasOpts.writeIndex, "write-index", false, "Mark index as write index of alias")
+}
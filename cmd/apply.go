@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"net/url"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/manifest"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/plan"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "apply [PLANFILE]",
+	Short:         "Apply operation plan made by esnctl plan, or manifest of operations",
+	RunE:          doApply,
+}
+
+var applyOpts = struct {
+	clusterURL string
+	filename   string
+	group      string
+	region     string
+	operationOptions
+}{}
+
+func doApply(cmd *cobra.Command, args []string) error {
+	if applyOpts.filename != "" {
+		return applyManifest(applyOpts.filename)
+	}
+
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "plan file or manifest (-f) must be specified")
+	}
+
+	p, err := plan.Load(args[0])
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "failed to load plan"), exitcode.Validation)
+	}
+
+	// Plan does not keep credentials, so they can be given via --cluster-url
+	clusterURL := p.ClusterURL
+
+	if applyOpts.clusterURL != "" {
+		if err := checkSameCluster(p.ClusterURL, applyOpts.clusterURL); err != nil {
+			return err
+		}
+
+		clusterURL = applyOpts.clusterURL
+	}
+
+	w, err := newWorkflow(clusterURL, p.Region)
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("apply", clusterURL)
+	op.Group = p.Group
+	op.Node = p.NodeName
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, applyOpts.operationOptions, func(ctx context.Context) error {
+		return w.ApplyPlan(ctx, p, op)
+	})
+}
+
+// applyManifest executes operations in the given manifest sequentially
+// Execution stops at the first failed operation
+func applyManifest(filename string) error {
+	m, err := manifest.Load(filename)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "failed to load manifest"), exitcode.Validation)
+	}
+
+	if applyOpts.clusterURL != "" {
+		m.ClusterURL = applyOpts.clusterURL
+	}
+
+	if applyOpts.group != "" {
+		m.Group = applyOpts.group
+	}
+
+	if applyOpts.region != "" {
+		m.Region = applyOpts.region
+	}
+
+	if err := m.Validate(); err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "manifest is invalid"), exitcode.Validation)
+	}
+
+	w, err := newWorkflow(m.ClusterURL, m.Region)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	for i, mop := range m.Operations {
+		op := operation.New(mop.Action, m.ClusterURL)
+		op.Group = m.Group
+		op.Node = mop.Node
+
+		var fn func(ctx context.Context) error
+
+		switch mop.Action {
+		case manifest.ActionAdd:
+			log.Printf("===> [%d/%d] Adding %d nodes\n", i+1, len(m.Operations), mop.Count)
+
+			fn = func(ctx context.Context) error {
+				return w.AddNodes(ctx, workflow.AddOptions{
+					Group:     m.Group,
+					Count:     mop.Count,
+					Operation: op,
+				})
+			}
+		case manifest.ActionRemove:
+			log.Printf("===> [%d/%d] Removing %s\n", i+1, len(m.Operations), mop.Node)
+
+			fn = func(ctx context.Context) error {
+				return w.RemoveNode(ctx, workflow.RemoveOptions{
+					Group:     m.Group,
+					NodeName:  mop.Node,
+					Operation: op,
+				})
+			}
+		}
+
+		if err := runOperation(ctx, op, w.ES, applyOpts.operationOptions, fn); err != nil {
+			return errors.Wrapf(err, "operations[%d] failed", i)
+		}
+	}
+
+	return nil
+}
+
+// checkSameCluster checks whether the given URLs point the same cluster, ignoring credentials
+func checkSameCluster(planned, given string) error {
+	planned = es.SplitURLs(planned)[0]
+	given = es.SplitURLs(given)[0]
+
+	pu, err := url.Parse(planned)
+	if err != nil {
+		return errors.Wrap(err, "cluster URL in plan is invalid")
+	}
+
+	gu, err := url.Parse(given)
+	if err != nil {
+		return errors.Wrap(err, "cluster URL is invalid")
+	}
+
+	if pu.Scheme != gu.Scheme || pu.Host != gu.Host {
+		return exitcode.Errorf(exitcode.Validation, "cluster URL %s does not match the one in plan", gu.Host)
+	}
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVar(&applyOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL with credentials (default: URL in plan or manifest)")
+	applyCmd.Flags().StringVarP(&applyOpts.filename, "filename", "f", "", "Manifest file of operations")
+	applyCmd.Flags().StringVar(&applyOpts.group, "group", "", "Auto Scaling Group (manifest only, default: group in manifest)")
+	applyCmd.Flags().StringVar(&applyOpts.region, "region", "", "AWS region (manifest only, default: region in manifest)")
+	applyOpts.operationOptions.addFlags(applyCmd)
+
+	markFlagCompletion(applyCmd.Flags(), "group")
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/dtan4/esnctl/audit"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// writeAuditLog stores the given operation into audit index
+// If auditClusterURL is empty, the operated cluster itself is used
+func writeAuditLog(op *operation.Operation, client es.Client, auditClusterURL, auditIndex string) error {
+	if auditClusterURL != "" {
+		c, err := newESClient(auditClusterURL)
+		if err != nil {
+			return errors.Wrap(err, "failed to create Elasticsearch API client for audit cluster")
+		}
+
+		client = c
+	}
+
+	if err := audit.Record(client, auditIndex, op); err != nil {
+		return errors.Wrap(err, "failed to record audit log")
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dtan4/esnctl/balance"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// defaultBalanceThreshold represents the default deviation in percent regarded as outlier
+const defaultBalanceThreshold = 20.0
+
+// balanceReportCmd represents the balance-report command
+var balanceReportCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "balance-report",
+	Short:         "Show shards and disk usage per node and AZ with deviation from the mean",
+	RunE:          doBalanceReport,
+}
+
+var balanceReportOpts = struct {
+	autoScalingGroup string
+	clusterURL       string
+	output           string
+	region           string
+	threshold        float64
+}{}
+
+func doBalanceReport(cmd *cobra.Command, args []string) error {
+	if balanceReportOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if balanceReportOpts.autoScalingGroup == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group) must be specified")
+	}
+
+	if balanceReportOpts.output != "text" && balanceReportOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", balanceReportOpts.output)
+	}
+
+	w, err := newWorkflow(balanceReportOpts.clusterURL, balanceReportOpts.region)
+	if err != nil {
+		return err
+	}
+
+	list, err := ui.LoadNodes(w, balanceReportOpts.autoScalingGroup)
+	if err != nil {
+		return err
+	}
+
+	// Nodes outside of the group, e.g. dedicated masters without shards, would skew the mean
+	nodes := []*balance.Node{}
+
+	for _, n := range list {
+		if n.LifecycleState == "" {
+			continue
+		}
+
+		nodes = append(nodes, &balance.Node{
+			Name:             n.Name,
+			AvailabilityZone: n.AvailabilityZone,
+			Shards:           n.Shards,
+			DiskUsage:        n.DiskUsage,
+		})
+	}
+
+	if len(nodes) == 0 {
+		return errors.Errorf("no node is found in Auto Scaling Group %q", balanceReportOpts.autoScalingGroup)
+	}
+
+	report := balance.New(nodes, balanceReportOpts.threshold)
+
+	if balanceReportOpts.output == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode report")
+		}
+
+		fmt.Println(string(b))
+
+		return nil
+	}
+
+	report.Render(os.Stdout, useColor())
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(balanceReportCmd)
+
+	balanceReportCmd.Flags().StringVar(&balanceReportOpts.autoScalingGroup, "group", "", "Auto Scaling Group")
+	balanceReportCmd.Flags().StringVar(&balanceReportOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	balanceReportCmd.Flags().StringVar(&balanceReportOpts.output, "output", "text", "Output format (text, json)")
+	balanceReportCmd.Flags().StringVar(&balanceReportOpts.region, "region", "", "AWS region")
+	balanceReportCmd.Flags().Float64Var(&balanceReportOpts.threshold, "threshold", defaultBalanceThreshold, "Deviation from the mean in percent regarded as outlier")
+
+	markFlagCompletion(balanceReportCmd.Flags(), "group")
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// defaultBenchMaxSizeMB represents the default upper limit of sample shard size
+// Larger shard measures sustained throughput better, but takes longer to move back and forth
+const defaultBenchMaxSizeMB = 1024
+
+// benchRecoveryCmd represents the bench-recovery command
+var benchRecoveryCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "bench-recovery",
+	Short:         "Relocate sample shard back and forth to measure recovery throughput and project drain duration",
+	RunE:          doBenchRecovery,
+}
+
+var benchRecoveryOpts = struct {
+	clusterURL string
+	fromNode   string
+	index      string
+	maxSizeMB  int64
+	output     string
+	shard      int
+	toNode     string
+	operationOptions
+}{}
+
+func doBenchRecovery(cmd *cobra.Command, args []string) error {
+	if benchRecoveryOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if benchRecoveryOpts.fromNode == "" || benchRecoveryOpts.toNode == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch nodes to relocate sample shard between (--from-node, --to-node) must be specified")
+	}
+
+	if benchRecoveryOpts.output != "text" && benchRecoveryOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", benchRecoveryOpts.output)
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "esnctl bench-recovery cannot be used with --mock")
+	}
+
+	// AWS resources are not touched, so region is not needed
+	w, err := newWorkflow(benchRecoveryOpts.clusterURL, "")
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := newHTTPClient(benchRecoveryOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("bench-recovery", benchRecoveryOpts.clusterURL)
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, benchRecoveryOpts.operationOptions, func(ctx context.Context) error {
+		b, err := w.BenchRecovery(ctx, workflow.BenchRecoveryOptions{
+			FromNode:   benchRecoveryOpts.fromNode,
+			ToNode:     benchRecoveryOpts.toNode,
+			Index:      benchRecoveryOpts.index,
+			Shard:      benchRecoveryOpts.shard,
+			MaxBytes:   benchRecoveryOpts.maxSizeMB << 20,
+			HTTPClient: httpClient,
+			Operation:  op,
+		})
+		if err != nil {
+			return err
+		}
+
+		if benchRecoveryOpts.output == "json" {
+			body, err := json.MarshalIndent(b, "", "  ")
+			if err != nil {
+				return errors.Wrap(err, "failed to encode benchmark")
+			}
+
+			fmt.Println(string(body))
+
+			return nil
+		}
+
+		b.Render(os.Stdout)
+
+		return nil
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(benchRecoveryCmd)
+
+	benchRecoveryCmd.Flags().StringVar(&benchRecoveryOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	benchRecoveryCmd.Flags().StringVar(&benchRecoveryOpts.fromNode, "from-node", "", "Elasticsearch node name to relocate sample shard from")
+	benchRecoveryCmd.Flags().StringVar(&benchRecoveryOpts.index, "index", "", "Index of sample shard (default: the largest shard up to --max-size-mb)")
+	benchRecoveryCmd.Flags().Int64Var(&benchRecoveryOpts.maxSizeMB, "max-size-mb", defaultBenchMaxSizeMB, "Upper limit of sample shard size in MiB")
+	benchRecoveryCmd.Flags().StringVar(&benchRecoveryOpts.output, "output", "text", "Output format (text, json)")
+	benchRecoveryCmd.Flags().IntVar(&benchRecoveryOpts.shard, "shard", 0, "Shard number of sample shard, used with --index")
+	benchRecoveryCmd.Flags().StringVar(&benchRecoveryOpts.toNode, "to-node", "", "Elasticsearch node name to relocate sample shard to")
+	benchRecoveryOpts.operationOptions.addFlags(benchRecoveryCmd)
+}
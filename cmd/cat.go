@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// catCmd represents the cat command
+var catCmd = &cobra.Command{
+	Use:   "cat",
+	Short: "Call _cat APIs with the same credentials, TLS and tunnel as other commands",
+}
+
+var catOpts = struct {
+	clusterURL string
+	output     string
+}{}
+
+func newCatCmd(api string) *cobra.Command {
+	return &cobra.Command{
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Use:           api + " [ARG...]",
+		Short:         "Call _cat/" + api,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doCat(api, args)
+		},
+	}
+}
+
+func doCat(api string, args []string) error {
+	if catOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if catOpts.output != "table" && catOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format %q is not supported, must be table or json", catOpts.output)
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "esnctl cat cannot be used with --mock")
+	}
+
+	httpClient, err := newHTTPClient(catOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	clusterURL, err := withCredentials(catOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	body, err := es.Cat(clusterURL, httpClient, api, args, catOpts.output == "json")
+	if err != nil {
+		return err
+	}
+
+	if catOpts.output == "json" {
+		var buf bytes.Buffer
+
+		if err := json.Indent(&buf, body, "", "  "); err != nil {
+			return errors.Wrap(err, "invalid response body")
+		}
+
+		buf.WriteString("\n")
+		body = buf.Bytes()
+	}
+
+	os.Stdout.Write(body)
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(catCmd)
+
+	// Persistent flags are shared with API subcommands
+	catCmd.PersistentFlags().StringVar(&catOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	catCmd.PersistentFlags().StringVar(&catOpts.output, "output", "table", "Output format (table, json)")
+
+	for _, api := range es.CatAPIs {
+		catCmd.AddCommand(newCatCmd(api))
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/metrics"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// publishOperationMetrics publishes metrics of the finished operation to CloudWatch
+// Failure is only logged, because the operation has already finished
+func publishOperationMetrics(op *operation.Operation) {
+	if mock {
+		log.Println("===> Skipping CloudWatch metrics with --mock")
+		return
+	}
+
+	clients, err := aws.NewClients("", awsOptions())
+	if err != nil {
+		log.Println(errors.Wrap(err, "failed to initialize AWS service clients"))
+		return
+	}
+
+	if err := clients.CloudWatch.PutMetrics(metrics.OperationNamespace, metrics.Operation(op)); err != nil {
+		log.Println(errors.Wrap(err, "failed to publish operation metrics"))
+	}
+}
+
+// publishClusterMetrics publishes health metrics of the configured clusters to CloudWatch every interval until ctx is done
+// Metrics are published into the region of each cluster, dimensioned by cluster name in configuration
+func publishClusterMetrics(ctx context.Context, clusters map[string]*config.Cluster, interval time.Duration) {
+	names := make([]string, 0, len(clusters))
+
+	for name := range clusters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for {
+		for _, name := range names {
+			if err := publishClusterMetricsOnce(name, clusters[name]); err != nil {
+				log.Printf("failed to publish metrics of cluster %s: %s\n", name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func publishClusterMetricsOnce(name string, cluster *config.Cluster) error {
+	state, err := collectClusterState(cluster)
+	if err != nil {
+		return err
+	}
+
+	if mock {
+		return nil
+	}
+
+	clients, err := aws.NewClients(cluster.Region, clusterAWSOptions(cluster))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize AWS service clients")
+	}
+
+	return clients.CloudWatch.PutMetrics(metrics.ClusterNamespace, metrics.Cluster(name, state))
+}
+
+// collectClusterState retrieves state of the given cluster exposed as metrics
+func collectClusterState(cluster *config.Cluster) (*metrics.ClusterState, error) {
+	client, err := newESClient(cluster.ClusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics.CollectCluster(client)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// clusterResult represents output of read-only command against one configured cluster
+type clusterResult struct {
+	name    string
+	cluster *config.Cluster
+	output  []byte
+	err     error
+}
+
+// fanOutClusters runs fn against every configured cluster concurrently
+// Results are returned in the order of cluster name regardless of which finishes first
+func fanOutClusters(fn func(name string, cluster *config.Cluster, out io.Writer) error) ([]*clusterResult, error) {
+	names := cfg.ClusterNames()
+
+	if len(names) == 0 {
+		return nil, exitcode.Errorf(exitcode.Validation, "no cluster is configured in %s", cfgFile)
+	}
+
+	results := make([]*clusterResult, len(names))
+
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+
+		go func(i int, name string) {
+			defer wg.Done()
+
+			var buf bytes.Buffer
+
+			cluster, err := cfg.Cluster(name)
+			if err == nil {
+				err = fn(name, cluster, &buf)
+			}
+
+			results[i] = &clusterResult{
+				name:    name,
+				cluster: cluster,
+				output:  buf.Bytes(),
+				err:     err,
+			}
+		}(i, name)
+	}
+
+	wg.Wait()
+
+	return results, nil
+}
+
+// renderClusterResults writes output of each cluster under its header, and returns error if any cluster failed
+func renderClusterResults(out io.Writer, results []*clusterResult) error {
+	failed := []string{}
+
+	for _, r := range results {
+		if r.cluster != nil {
+			fmt.Fprintf(out, "=== %s (%s)\n", r.name, operation.SanitizeURL(r.cluster.ClusterURL))
+		} else {
+			fmt.Fprintf(out, "=== %s\n", r.name)
+		}
+
+		out.Write(r.output)
+
+		if r.err != nil {
+			fmt.Fprintf(out, "ERROR: %s\n", r.err)
+			failed = append(failed, r.name)
+		}
+
+		fmt.Fprintln(out)
+	}
+
+	return clusterResultsErr(results, failed)
+}
+
+func clusterResultsErr(results []*clusterResult, failed []string) error {
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return errors.Errorf("%d of %d clusters failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+}
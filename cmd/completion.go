@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// bashCompletionFunction is embedded into bash completion script generated by cobra
+// Custom flag completions call hidden __complete command with words typed so far
+const bashCompletionFunction = `
+__esnctl_complete_values()
+{
+    local values
+    values=$("${words[0]}" __complete "${words[@]:1:$((cword-1))}" "${cur}" 2>/dev/null)
+    COMPREPLY=( $(compgen -W "${values}" -- "${cur}") )
+}
+
+__esnctl_complete_group()
+{
+    __esnctl_complete_values
+}
+
+__esnctl_complete_node_name()
+{
+    __esnctl_complete_values
+}
+`
+
+const zshCompletionScript = `#compdef esnctl
+
+_esnctl()
+{
+    local -a candidates
+    candidates=(${(f)"$(${words[1]} __complete "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)"})
+    compadd -a candidates
+}
+
+# Autoloaded from fpath, or sourced
+if [ "$funcstack[1]" = "_esnctl" ]; then
+    _esnctl "$@"
+else
+    compdef _esnctl esnctl
+fi
+`
+
+const fishCompletionScript = `function __esnctl_complete
+    set -l args (commandline -opc)
+    set -e args[1]
+    command esnctl __complete $args (commandline -ct) 2>/dev/null
+end
+
+complete -c esnctl -f -a '(__esnctl_complete)'
+`
+
+// flagCompletions represents functions to list values of flags which are completed dynamically
+var flagCompletions = map[string]func(cmd *cobra.Command) ([]string, error){
+	"group":     completeGroups,
+	"node-name": completeNodeNames,
+}
+
+var completionCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "completion bash|zsh|fish",
+	Short:         "Output shell completion script",
+	RunE:          doCompletion,
+}
+
+var completeCmd = &cobra.Command{
+	DisableFlagParsing: true,
+	Hidden:             true,
+	Use:                "__complete [words...] <current word>",
+	Short:              "Print completion candidates of the current word",
+	RunE:               doComplete,
+}
+
+func doCompletion(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "Shell (bash, zsh or fish) must be specified")
+	}
+
+	return writeCompletion(os.Stdout, args[0])
+}
+
+// writeCompletion writes completion script of the given shell
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return RootCmd.GenBashCompletion(w)
+	case "zsh":
+		_, err := io.WriteString(w, zshCompletionScript)
+		return err
+	case "fish":
+		_, err := io.WriteString(w, fishCompletionScript)
+		return err
+	default:
+		return exitcode.Errorf(exitcode.Validation, "Shell %q is not supported", shell)
+	}
+}
+
+// doComplete prints candidates of the last argument, one per line
+// Nothing is printed on error not to break the command line being edited
+func doComplete(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	words, current := args[:len(args)-1], args[len(args)-1]
+
+	target, rest, err := RootCmd.Find(words)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		candidates []string
+		flagName   string
+		prefix     string
+	)
+
+	switch {
+	case strings.HasPrefix(current, "--") && strings.Contains(current, "="):
+		i := strings.Index(current, "=")
+		flagName, prefix = current[2:i], current[:i+1]
+	case strings.HasPrefix(current, "-"):
+		candidates = listFlags(target)
+	case len(rest) > 0 && valueRequired(target, rest[len(rest)-1]):
+		flagName = strings.TrimLeft(rest[len(rest)-1], "-")
+		rest = rest[:len(rest)-1]
+	default:
+		candidates = listSubcommands(target)
+	}
+
+	if f, ok := flagCompletions[flagName]; ok {
+		// Flags typed so far, e.g. --cluster-url, are used to query values
+		target.ParseFlags(rest)
+
+		values, err := f(target)
+		if err != nil {
+			return nil
+		}
+
+		for _, v := range values {
+			candidates = append(candidates, prefix+v)
+		}
+	}
+
+	for _, c := range candidates {
+		if strings.HasPrefix(c, current) {
+			fmt.Println(c)
+		}
+	}
+
+	return nil
+}
+
+func completeGroups(cmd *cobra.Command) ([]string, error) {
+	if mock {
+		return getMockCluster().AutoScaling().ListGroups()
+	}
+
+	clients, err := aws.NewClients(flagValue(cmd, "region"), awsOptions())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize AWS service clients")
+	}
+
+	return clients.AutoScaling.ListGroups()
+}
+
+func completeNodeNames(cmd *cobra.Command) ([]string, error) {
+	clusterURL := flagValue(cmd, "cluster-url")
+	if clusterURL == "" && !mock {
+		return nil, errors.New("Elasticsearch cluster URL (--cluster-url) is not specified")
+	}
+
+	esClient, err := newESClient(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return esClient.ListNodes()
+}
+
+func flagValue(cmd *cobra.Command, name string) string {
+	f := cmd.Flags().Lookup(name)
+	if f == nil {
+		return ""
+	}
+
+	return f.Value.String()
+}
+
+func listFlags(cmd *cobra.Command) []string {
+	flags := []string{}
+
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			flags = append(flags, "--"+f.Name)
+		}
+	})
+
+	cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			flags = append(flags, "--"+f.Name)
+		}
+	})
+
+	return flags
+}
+
+func listSubcommands(cmd *cobra.Command) []string {
+	names := []string{}
+
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() {
+			names = append(names, c.Name())
+		}
+	}
+
+	return names
+}
+
+// markFlagCompletion registers dynamic completion of the given flag to bash completion script
+func markFlagCompletion(flags *pflag.FlagSet, name string) {
+	cobra.MarkFlagCustom(flags, name, "__esnctl_complete_"+strings.Replace(name, "-", "_", -1))
+}
+
+// valueRequired returns whether the given word is a flag waiting for its value
+func valueRequired(cmd *cobra.Command, word string) bool {
+	if !strings.HasPrefix(word, "--") || strings.Contains(word, "=") {
+		return false
+	}
+
+	f := cmd.LocalFlags().Lookup(word[2:])
+	if f == nil {
+		f = cmd.InheritedFlags().Lookup(word[2:])
+	}
+
+	return f != nil && f.NoOptDefVal == ""
+}
+
+func init() {
+	RootCmd.AddCommand(completionCmd)
+	RootCmd.AddCommand(completeCmd)
+
+	RootCmd.BashCompletionFunction = bashCompletionFunction
+}
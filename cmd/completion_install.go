@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// completionInstallCmd represents the completion install command
+var completionInstallCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "install [bash|zsh|fish]",
+	Short:         "Install shell completion script where the shell loads it automatically",
+	RunE:          doCompletionInstall,
+}
+
+var completionInstallOpts = struct {
+	path   string
+	prefix string
+	system bool
+}{}
+
+func doCompletionInstall(cmd *cobra.Command, args []string) error {
+	var shell string
+
+	switch len(args) {
+	case 0:
+		shell = filepath.Base(os.Getenv("SHELL"))
+		if shell == "." || shell == "" {
+			return exitcode.New(exitcode.Validation, "Shell cannot be detected from SHELL, specify bash, zsh or fish")
+		}
+	case 1:
+		shell = args[0]
+	default:
+		return exitcode.New(exitcode.Validation, "Only one shell can be specified")
+	}
+
+	path := completionInstallOpts.path
+
+	if path == "" {
+		p, err := completionPath(shell, completionInstallOpts.system, completionInstallOpts.prefix)
+		if err != nil {
+			return err
+		}
+
+		path = p
+	}
+
+	var buf bytes.Buffer
+
+	if err := writeCompletion(&buf, shell); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "failed to create completion directory")
+	}
+
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write completion script to %s", path)
+	}
+
+	fmt.Printf("Installed %s completion to %s\n", shell, path)
+
+	if shell == "zsh" && !completionInstallOpts.system && completionInstallOpts.path == "" {
+		fmt.Printf("Add the following line to ~/.zshrc before compinit unless %s is in fpath:\n", filepath.Dir(path))
+		fmt.Printf("  fpath=(%s $fpath)\n", filepath.Dir(path))
+	}
+
+	fmt.Println("Restart the shell to enable completion")
+
+	return nil
+}
+
+// completionPath returns the path from which the shell loads completion of esnctl
+// System-wide paths are under prefix, e.g. /usr for deb/rpm packages, and user paths follow XDG base directories
+func completionPath(shell string, system bool, prefix string) (string, error) {
+	if system {
+		switch shell {
+		case "bash":
+			return filepath.Join(prefix, "share", "bash-completion", "completions", "esnctl"), nil
+		case "zsh":
+			return filepath.Join(prefix, "share", "zsh", "site-functions", "_esnctl"), nil
+		case "fish":
+			return filepath.Join(prefix, "share", "fish", "vendor_completions.d", "esnctl.fish"), nil
+		}
+
+		return "", exitcode.Errorf(exitcode.Validation, "Shell %q is not supported", shell)
+	}
+
+	home := filepath.Dir(config.Dir())
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(dataHome, "bash-completion", "completions", "esnctl"), nil
+	case "zsh":
+		zdotdir := os.Getenv("ZDOTDIR")
+		if zdotdir == "" {
+			zdotdir = home
+		}
+
+		return filepath.Join(zdotdir, ".zsh", "completions", "_esnctl"), nil
+	case "fish":
+		return filepath.Join(configHome, "fish", "completions", "esnctl.fish"), nil
+	}
+
+	return "", exitcode.Errorf(exitcode.Validation, "Shell %q is not supported", shell)
+}
+
+func init() {
+	completionCmd.AddCommand(completionInstallCmd)
+
+	completionInstallCmd.Flags().StringVar(&completionInstallOpts.path, "path", "", "Write completion script to the given path instead of the default location")
+	completionInstallCmd.Flags().StringVar(&completionInstallOpts.prefix, "prefix", "/usr/local", "Installation prefix of system-wide completion, e.g. /usr for deb/rpm packages")
+	completionInstallCmd.Flags().BoolVar(&completionInstallOpts.system, "system", false, "Install completion for all users under --prefix instead of the current user")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dtan4/esnctl/controller"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/kube"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// controllerCmd represents the controller command
+var controllerCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "controller",
+	Short:         "Run Kubernetes controller executing ESNodeRemoval resources",
+	RunE:          doController,
+}
+
+var controllerOpts = struct {
+	apiServer string
+	interval  time.Duration
+	namespace string
+	token     string
+
+	leaderElection leaderElectionOptions
+	operationOptions
+}{}
+
+func doController(cmd *cobra.Command, args []string) error {
+	if scheduleAt != "" {
+		return exitcode.New(exitcode.Validation, "--at is not supported by controller, which runs operations as resources are created")
+	}
+
+	var (
+		client *kube.Client
+		err    error
+	)
+
+	if controllerOpts.apiServer == "" {
+		client, err = kube.NewInCluster()
+		if err != nil {
+			return errors.Wrap(err, "failed to create in-cluster Kubernetes API client")
+		}
+	} else {
+		client = kube.New(controllerOpts.apiServer, controllerOpts.token, &http.Client{})
+	}
+
+	c := controller.New(client, controllerOpts.namespace, runControllerOperation)
+
+	c.Leader, err = controllerOpts.leaderElection.start(context.Background(), "controller", nil)
+	if err != nil {
+		return err
+	}
+
+	log.Println("===> Watching ESNodeRemoval resources...")
+
+	return c.Run(context.Background(), controllerOpts.interval)
+}
+
+// runControllerOperation removes node described in the given resource
+func runControllerOperation(op *operation.Operation, r *kube.NodeRemoval) error {
+	w, err := newWorkflow(r.Spec.ClusterURL, r.Spec.Region)
+	if err != nil {
+		return err
+	}
+
+	// Operations run concurrently, so progress bars would be mixed up
+	w.ProgressBar = false
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, controllerOpts.operationOptions, func(ctx context.Context) error {
+		return w.RemoveNode(ctx, workflow.RemoveOptions{
+			Group:     r.Spec.Group,
+			NodeName:  r.Spec.NodeName,
+			Operation: op,
+		})
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(controllerCmd)
+
+	controllerCmd.Flags().StringVar(&controllerOpts.apiServer, "api-server", "", "Kubernetes API server URL (default: in-cluster configuration)")
+	controllerCmd.Flags().DurationVar(&controllerOpts.interval, "interval", 10*time.Second, "Interval to check resources")
+	controllerCmd.Flags().StringVar(&controllerOpts.namespace, "namespace", "", "Namespace to watch (default: all namespaces)")
+	controllerCmd.Flags().StringVar(&controllerOpts.token, "token", "", "Bearer token for --api-server")
+	controllerOpts.leaderElection.addFlags(controllerCmd)
+	controllerOpts.operationOptions.addFlags(controllerCmd)
+}
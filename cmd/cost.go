@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dtan4/esnctl/cost"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// costCmd represents the cost command
+var costCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "cost",
+	Short:         "Estimate monthly cost of nodes, and marginal cost of adding nodes",
+	RunE:          doCost,
+}
+
+var costOpts = struct {
+	add               int
+	autoScalingGroups []string
+	clusterURL        string
+	groupTag          string
+	output            string
+	prices            string
+	region            string
+}{}
+
+func doCost(cmd *cobra.Command, args []string) error {
+	if costOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if len(costOpts.autoScalingGroups) == 0 && costOpts.groupTag == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group or --group-tag) must be specified")
+	}
+
+	if costOpts.add < 0 {
+		return exitcode.New(exitcode.Validation, "number to add instances (--add) must not be negative")
+	}
+
+	if costOpts.output != "text" && costOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", costOpts.output)
+	}
+
+	prices := cost.DefaultPrices
+
+	if costOpts.prices != "" {
+		data, err := ioutil.ReadFile(costOpts.prices)
+		if err != nil {
+			return exitcode.Wrap(errors.Wrap(err, "failed to read prices"), exitcode.Validation)
+		}
+
+		prices, err = cost.ParsePrices(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := newWorkflow(costOpts.clusterURL, costOpts.region)
+	if err != nil {
+		return err
+	}
+
+	groups, err := w.ResolveGroups(costOpts.autoScalingGroups, costOpts.groupTag)
+	if err != nil {
+		return err
+	}
+
+	instances, err := w.DescribeGroupInstances(groups)
+	if err != nil {
+		return err
+	}
+
+	report, err := cost.New(instances, prices, costOpts.add)
+	if err != nil {
+		return err
+	}
+
+	if costOpts.output == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode report")
+		}
+
+		fmt.Println(string(b))
+
+		return nil
+	}
+
+	report.Render(os.Stdout)
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(costCmd)
+
+	costCmd.Flags().IntVar(&costOpts.add, "add", 0, "Number of instances planned to add")
+	costCmd.Flags().StringVar(&costOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	costCmd.Flags().StringSliceVar(&costOpts.autoScalingGroups, "group", []string{}, "Auto Scaling Group (repeat for clusters spread over groups)")
+	costCmd.Flags().StringVar(&costOpts.groupTag, "group-tag", "", "Select Auto Scaling Groups by tag in key=value format")
+	costCmd.Flags().StringVar(&costOpts.output, "output", "text", "Output format (text, json)")
+	costCmd.Flags().StringVar(&costOpts.prices, "prices", "", "JSON file of hourly prices by instance type, overriding the bundled on-demand prices of us-east-1")
+	costCmd.Flags().StringVar(&costOpts.region, "region", "", "AWS region")
+
+	markFlagCompletion(costCmd.Flags(), "group")
+}
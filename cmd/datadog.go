@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dtan4/esnctl/datadog"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+)
+
+// defaultDatadogMuteWindow represents the default duration of Datadog downtime
+const defaultDatadogMuteWindow = 2 * time.Hour
+
+// startDatadog posts Datadog event of operation start and mutes monitors in --datadog-mute-scope
+// The returned function posts event of the result and unmutes monitors. If esnctl dies before that, monitors are unmuted after --datadog-mute-window
+func startDatadog(op *operation.Operation, opts operationOptions) (func(error), error) {
+	if opts.datadogMuteWindow <= 0 {
+		return nil, exitcode.Errorf(exitcode.Validation, "Datadog mute window (--datadog-mute-window) must be positive. got: %s", opts.datadogMuteWindow)
+	}
+
+	if mock {
+		log.Println("===> Skipping Datadog event and downtime with --mock")
+		return func(error) {}, nil
+	}
+
+	client, err := datadog.NewFromEnv()
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	title := fmt.Sprintf("esnctl %s", op.Command)
+	tags := []string{"esnctl", "operation:" + op.Command, "operation_id:" + op.ID}
+
+	var downtime *datadog.Downtime
+
+	if len(opts.datadogMuteScopes) > 0 {
+		d, err := client.ScheduleDowntime(opts.datadogMuteScopes, fmt.Sprintf("%s on %s (operation %s)", title, op.Cluster, op.ID), opts.datadogMuteWindow)
+		if err != nil {
+			return nil, err
+		}
+
+		op.Logf("===> Muted Datadog monitors in %v (downtime %d)\n", opts.datadogMuteScopes, d.ID)
+
+		downtime = d
+	}
+
+	if opts.datadogEvent {
+		err := client.PostEvent(&datadog.Event{
+			Title:     title + " started",
+			Text:      fmt.Sprintf("%s on %s by %s (operation %s)", title, op.Cluster, op.User, op.ID),
+			AlertType: datadog.AlertInfo,
+			Tags:      tags,
+		})
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	return func(result error) {
+		if downtime != nil {
+			if err := client.CancelDowntime(downtime.ID); err != nil {
+				log.Println(err)
+			} else {
+				op.Logf("===> Unmuted Datadog monitors (downtime %d)\n", downtime.ID)
+			}
+		}
+
+		if !opts.datadogEvent {
+			return
+		}
+
+		e := &datadog.Event{
+			Title:     title + " succeeded",
+			Text:      fmt.Sprintf("%s on %s by %s (operation %s) took %s", title, op.Cluster, op.User, op.ID, time.Since(op.StartedAt).Round(time.Second)),
+			AlertType: datadog.AlertSuccess,
+			Tags:      tags,
+		}
+
+		if result != nil {
+			e.Title = title + " failed"
+			e.Text = fmt.Sprintf("%s on %s by %s (operation %s) failed: %s", title, op.Cluster, op.User, op.ID, result)
+			e.AlertType = datadog.AlertError
+		}
+
+		if err := client.PostEvent(e); err != nil {
+			log.Println(err)
+		}
+	}, nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// decommissionZoneCmd represents the decommission-zone command
+var decommissionZoneCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "decommission-zone",
+	Short:         "Drain all nodes in AZ with OpenSearch zone decommission API",
+	RunE:          doDecommissionZone,
+}
+
+var decommissionZoneOpts = struct {
+	attribute        string
+	autoScalingGroup string
+	clusterURL       string
+	region           string
+	terminate        bool
+	zone             string
+	operationOptions
+}{}
+
+func doDecommissionZone(cmd *cobra.Command, args []string) error {
+	if decommissionZoneOpts.autoScalingGroup == "" {
+		return exitcode.New(exitcode.Validation, "AutoScaling Group (--group) must be specified")
+	}
+
+	if decommissionZoneOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if decommissionZoneOpts.zone == "" {
+		return exitcode.New(exitcode.Validation, "AZ (--zone) must be specified")
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "zone decommission is not supported by fake cluster (--mock)")
+	}
+
+	httpClient, err := newHTTPClient(decommissionZoneOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	w, err := newWorkflow(decommissionZoneOpts.clusterURL, decommissionZoneOpts.region)
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("decommission-zone", decommissionZoneOpts.clusterURL)
+	op.Group = decommissionZoneOpts.autoScalingGroup
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, decommissionZoneOpts.operationOptions, func(ctx context.Context) error {
+		return w.DecommissionZone(ctx, workflow.DecommissionZoneOptions{
+			Group:              decommissionZoneOpts.autoScalingGroup,
+			Zone:               decommissionZoneOpts.zone,
+			Attribute:          decommissionZoneOpts.attribute,
+			HTTPClient:         httpClient,
+			TerminateInstances: decommissionZoneOpts.terminate,
+			Operation:          op,
+		})
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(decommissionZoneCmd)
+
+	decommissionZoneCmd.Flags().StringVar(&decommissionZoneOpts.attribute, "attribute", "zone", "Awareness attribute whose value is AZ")
+	decommissionZoneCmd.Flags().StringVar(&decommissionZoneOpts.autoScalingGroup, "group", "", "Auto Scaling Group")
+	decommissionZoneCmd.Flags().StringVar(&decommissionZoneOpts.clusterURL, "cluster-url", "", "OpenSearch cluster URL (comma-separated URLs to fail over)")
+	decommissionZoneCmd.Flags().StringVar(&decommissionZoneOpts.region, "region", "", "AWS region")
+	decommissionZoneCmd.Flags().BoolVar(&decommissionZoneOpts.terminate, "terminate", false, "Terminate instances after detaching them")
+	decommissionZoneCmd.Flags().StringVar(&decommissionZoneOpts.zone, "zone", "", "AZ to decommission (e.g. ap-northeast-1a)")
+	decommissionZoneOpts.operationOptions.addFlags(decommissionZoneCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// drainIndexCmd represents the drain-index command
+var drainIndexCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "drain-index",
+	Short:         "Move shards of the given index off node",
+	RunE:          doDrainIndex,
+}
+
+var drainIndexOpts = struct {
+	clusterURL string
+	index      string
+	nodeName   string
+	operationOptions
+}{}
+
+func doDrainIndex(cmd *cobra.Command, args []string) error {
+	if drainIndexOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if drainIndexOpts.index == "" {
+		return exitcode.New(exitcode.Validation, "Index (--index) must be specified")
+	}
+
+	if drainIndexOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch Node (--node-name) name must be specified")
+	}
+
+	// AWS resources are not touched, so region is not needed
+	w, err := newWorkflow(drainIndexOpts.clusterURL, "")
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("drain-index", drainIndexOpts.clusterURL)
+	op.Node = drainIndexOpts.nodeName
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, drainIndexOpts.operationOptions, func(ctx context.Context) error {
+		return w.DrainIndex(ctx, workflow.DrainIndexOptions{
+			Index:     drainIndexOpts.index,
+			NodeName:  drainIndexOpts.nodeName,
+			Operation: op,
+		})
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(drainIndexCmd)
+
+	drainIndexCmd.Flags().StringVar(&drainIndexOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	drainIndexCmd.Flags().StringVar(&drainIndexOpts.index, "index", "", "Index to move off the node")
+	drainIndexCmd.Flags().StringVar(&drainIndexOpts.nodeName, "node-name", "", "Elasticsearch node name to move shards from")
+	drainIndexOpts.operationOptions.addFlags(drainIndexCmd)
+
+	markFlagCompletion(drainIndexCmd.Flags(), "node-name")
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "drift",
+	Short:         "Report divergence of Auto Scaling Group desired capacity from the declared one, e.g. in Terraform",
+	RunE:          doDrift,
+}
+
+var driftOpts = struct {
+	allClusters     bool
+	desiredCapacity int
+	failOnDrift     bool
+	group           string
+	output          string
+	region          string
+}{}
+
+func doDrift(cmd *cobra.Command, args []string) error {
+	if driftOpts.output != "text" && driftOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", driftOpts.output)
+	}
+
+	if driftOpts.allClusters {
+		return doDriftAllClusters()
+	}
+
+	if driftOpts.group == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group) or --all-clusters must be specified")
+	}
+
+	declared := driftOpts.desiredCapacity
+
+	if !cmd.Flags().Changed("desired-capacity") {
+		capacity, ok := cfg.DesiredCapacity(driftOpts.group)
+		if !ok {
+			return exitcode.Errorf(exitcode.Validation, "declared desired capacity must be given by --desired-capacity or desired_capacity of %s in %s", driftOpts.group, cfgFile)
+		}
+
+		declared = capacity
+	}
+
+	report, err := checkDrift(driftOpts.group, driftOpts.region, declared, awsOptions())
+	if err != nil {
+		return err
+	}
+
+	if driftOpts.output == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode report")
+		}
+
+		fmt.Println(string(b))
+	} else {
+		report.Render(os.Stdout)
+	}
+
+	if driftOpts.failOnDrift {
+		return report.Err()
+	}
+
+	return nil
+}
+
+// doDriftAllClusters checks drift of every configured cluster declaring desired capacity concurrently
+func doDriftAllClusters() error {
+	var (
+		mu      sync.Mutex
+		reports = map[string]*workflow.DriftReport{}
+	)
+
+	results, err := fanOutClusters(func(name string, cluster *config.Cluster, out io.Writer) error {
+		if cluster.Group == "" || cluster.DesiredCapacity <= 0 {
+			fmt.Fprintln(out, "Skipped: group or desired_capacity is not configured")
+			return nil
+		}
+
+		report, err := checkDrift(cluster.Group, cluster.Region, cluster.DesiredCapacity, clusterAWSOptions(cluster))
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		reports[name] = report
+		mu.Unlock()
+
+		report.Render(out)
+
+		if driftOpts.failOnDrift {
+			return report.Err()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if driftOpts.output != "json" {
+		return renderClusterResults(os.Stdout, results)
+	}
+
+	v := map[string]interface{}{}
+	failed := []string{}
+
+	for _, r := range results {
+		if report, ok := reports[r.name]; ok {
+			v[r.name] = report
+		}
+
+		if r.err != nil {
+			failed = append(failed, r.name)
+
+			if _, ok := reports[r.name]; !ok {
+				v[r.name] = map[string]string{"error": r.err.Error()}
+			}
+		}
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode report")
+	}
+
+	fmt.Println(string(b))
+
+	return clusterResultsErr(results, failed)
+}
+
+func checkDrift(group, region string, declared int, opts aws.Options) (*workflow.DriftReport, error) {
+	// Elasticsearch is not touched, so only Auto Scaling client is set up
+	w := &workflow.Workflow{}
+
+	if mock {
+		w.AutoScaling = getMockCluster().AutoScaling()
+	} else {
+		clients, err := aws.NewClients(region, opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize AWS service clients")
+		}
+
+		w.AutoScaling = clients.AutoScaling
+	}
+
+	return w.Drift(workflow.DriftOptions{
+		Group:           group,
+		DesiredCapacity: declared,
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(driftCmd)
+
+	driftCmd.Flags().BoolVar(&driftOpts.allClusters, "all-clusters", false, "Check all clusters declaring group and desired_capacity in config file concurrently")
+	driftCmd.Flags().IntVar(&driftOpts.desiredCapacity, "desired-capacity", 0, "Declared desired capacity (desired_capacity of the cluster with the same group in config file if omitted)")
+	driftCmd.Flags().BoolVar(&driftOpts.failOnDrift, "fail-on-drift", false, "Exit with non-zero status if desired capacity drifted")
+	driftCmd.Flags().StringVar(&driftOpts.group, "group", "", "Auto Scaling Group")
+	driftCmd.Flags().StringVar(&driftOpts.output, "output", "text", "Output format (text, json)")
+	driftCmd.Flags().StringVar(&driftOpts.region, "region", "", "AWS region")
+
+	markFlagCompletion(driftCmd.Flags(), "group")
+}
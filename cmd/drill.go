@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// drillCmd represents the drill command
+var drillCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "drill",
+	Short:         "Drain node and let shards return without removing it, measuring how long removal would take",
+	RunE:          doDrill,
+}
+
+var drillOpts = struct {
+	clusterURL string
+	nodeName   string
+	tolerance  int
+	window     time.Duration
+	operationOptions
+}{}
+
+func doDrill(cmd *cobra.Command, args []string) error {
+	if drillOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if drillOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch Node (--node-name) name must be specified")
+	}
+
+	// AWS resources are not touched, so region is not needed
+	w, err := newWorkflow(drillOpts.clusterURL, "")
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("drill", drillOpts.clusterURL)
+	op.Node = drillOpts.nodeName
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, drillOpts.operationOptions, func(ctx context.Context) error {
+		report, err := w.Drill(ctx, workflow.DrillOptions{
+			NodeName:  drillOpts.nodeName,
+			Tolerance: drillOpts.tolerance,
+			Window:    drillOpts.window,
+			Operation: op,
+		})
+
+		if report != nil {
+			report.Render(os.Stdout)
+		}
+
+		return err
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(drillCmd)
+
+	drillCmd.Flags().StringVar(&drillOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	drillCmd.Flags().StringVar(&drillOpts.nodeName, "node-name", "", "Elasticsearch node name to drill removal of")
+	drillCmd.Flags().IntVar(&drillOpts.tolerance, "tolerance", defaultRebalanceTolerance, "How many shards fewer than before the node may hold when shards return")
+	drillCmd.Flags().DurationVar(&drillOpts.window, "window", 0, "Maintenance window which drain must finish within (e.g. 30m)")
+	drillOpts.operationOptions.addFlags(drillCmd)
+
+	markFlagCompletion(drillCmd.Flags(), "node-name")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/lock"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// forceUnlockCmd represents the force-unlock command
+var forceUnlockCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "force-unlock",
+	Short:         "Release cluster lock forcibly",
+	RunE:          doForceUnlock,
+}
+
+var forceUnlockOpts = struct {
+	clusterURL string
+}{}
+
+func doForceUnlock(cmd *cobra.Command, args []string) error {
+	if forceUnlockOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	client, err := newESClient(forceUnlockOpts.clusterURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Elasitcsearch API client")
+	}
+
+	holder, err := lock.Get(client)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster lock")
+	}
+
+	if holder == nil {
+		log.Println("Cluster is not locked")
+		return nil
+	}
+
+	state := ""
+	if holder.Expired(time.Now()) {
+		state = "expired "
+	}
+
+	log.Printf("===> Releasing %scluster lock held by %s@%s (operation %s, %s) since %s...\n", state, holder.Owner, holder.Host, holder.OperationID, holder.Command, holder.AcquiredAt.Format(time.RFC3339))
+
+	if err := lock.ForceUnlock(client); err != nil {
+		return errors.Wrap(err, "failed to release cluster lock")
+	}
+
+	log.Println("===> Finished!")
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(forceUnlockCmd)
+
+	forceUnlockCmd.Flags().StringVar(&forceUnlockOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/forecast"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// forecastCmd represents the forecast command
+var forecastCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "forecast",
+	Short:         "Record disk usage, and forecast when the cluster hits high watermark and how many nodes to add",
+	RunE:          doForecast,
+}
+
+var forecastOpts = struct {
+	clusterURL    string
+	days          int
+	highWatermark float64
+	noRecord      bool
+	output        string
+	region        string
+	target        float64
+	window        time.Duration
+}{}
+
+func doForecast(cmd *cobra.Command, args []string) error {
+	if forecastOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "forecast is not supported by fake cluster (--mock)")
+	}
+
+	if forecastOpts.days < 1 {
+		return exitcode.New(exitcode.Validation, "days to forecast (--days) must be greater than 0")
+	}
+
+	if forecastOpts.target <= 0 || forecastOpts.target > 100 {
+		return exitcode.New(exitcode.Validation, "target disk usage (--target) must be in (0, 100]")
+	}
+
+	if forecastOpts.output != "text" && forecastOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", forecastOpts.output)
+	}
+
+	w, err := newWorkflow(forecastOpts.clusterURL, forecastOpts.region)
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := newHTTPClient(forecastOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	highWatermark := forecastOpts.highWatermark
+
+	if highWatermark == 0 {
+		settings, err := w.ES.ClusterSettings()
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve cluster settings")
+		}
+
+		highWatermark, err = forecast.ParseWatermark(settings[forecast.HighWatermarkSetting])
+		if err != nil {
+			return err
+		}
+	}
+
+	usage, err := es.ClusterDiskUsage(w.ClusterURL, httpClient)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve disk usage")
+	}
+
+	cluster := operation.SanitizeURL(forecastOpts.clusterURL)
+	now := time.Now().UTC()
+
+	store := forecast.New(forecast.DefaultDir())
+
+	if !forecastOpts.noRecord {
+		if err := store.Append(&forecast.Sample{Cluster: cluster, Time: now, DiskUsage: *usage}); err != nil {
+			return err
+		}
+	}
+
+	samples, err := store.List(cluster, now.Add(-forecastOpts.window))
+	if err != nil {
+		return err
+	}
+
+	if len(samples) < 2 || !samples[len(samples)-1].Time.After(samples[0].Time) {
+		fmt.Fprintf(os.Stderr, "Not enough samples in the last %s to forecast. Run esnctl forecast periodically, e.g. hourly from cron\n", forecastOpts.window)
+		return nil
+	}
+
+	f, err := forecast.Project(samples, highWatermark, forecastOpts.target, forecastOpts.days)
+	if err != nil {
+		return err
+	}
+
+	if forecastOpts.output == "json" {
+		b, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode forecast")
+		}
+
+		fmt.Println(string(b))
+
+		return nil
+	}
+
+	f.Render(os.Stdout)
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(forecastCmd)
+
+	forecastCmd.Flags().StringVar(&forecastOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	forecastCmd.Flags().IntVar(&forecastOpts.days, "days", 30, "Days to stay under target disk usage")
+	forecastCmd.Flags().Float64Var(&forecastOpts.highWatermark, "high-watermark", 0, "High disk watermark in percent (default: read from cluster settings)")
+	forecastCmd.Flags().BoolVar(&forecastOpts.noRecord, "no-record", false, "Forecast from recorded samples without recording current disk usage")
+	forecastCmd.Flags().StringVar(&forecastOpts.output, "output", "text", "Output format (text, json)")
+	forecastCmd.Flags().StringVar(&forecastOpts.region, "region", "", "AWS region")
+	forecastCmd.Flags().Float64Var(&forecastOpts.target, "target", 70, "Target disk usage in percent")
+	forecastCmd.Flags().DurationVar(&forecastOpts.window, "window", 7*24*time.Hour, "Window of samples to fit growth")
+}
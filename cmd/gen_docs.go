@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/version"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	docsFormatMan      = "man"
+	docsFormatMarkdown = "markdown"
+
+	// envAnnotation is flag annotation listing environment variables read together with the flag
+	envAnnotation = "esnctl_env"
+)
+
+// envDescriptions represents environment variables esnctl reads
+var envDescriptions = map[string]string{
+	"AWS_ACCESS_KEY_ID":         "AWS access key ID",
+	"AWS_PROFILE":               "Profile in AWS shared credentials file",
+	"AWS_REGION":                "Default AWS region of AWS SDK",
+	"AWS_SECRET_ACCESS_KEY":     "AWS secret access key",
+	"DD_API_KEY":                "Datadog API key",
+	"DD_APP_KEY":                "Datadog application key, required to mute monitors",
+	"DD_SITE":                   "Datadog site (default: datadoghq.com)",
+	"ESNCTL_TELEMETRY":          "Opt in anonymous usage reporting if set to 1",
+	"ESNCTL_TELEMETRY_ENDPOINT": "URL usage reports are posted to",
+	"GITHUB_TOKEN":              "GitHub token to avoid rate limit of GitHub API",
+	"HTTPS_PROXY":               "Proxy URL of HTTPS requests",
+	"HTTP_PROXY":                "Proxy URL of HTTP requests",
+	"NO_COLOR":                  "Disable colored output if set",
+	"NO_PROXY":                  "Hosts connected without proxy",
+	"PAGERDUTY_FROM":            "Email address of PagerDuty user creating maintenance windows",
+	"PAGERDUTY_TOKEN":           "PagerDuty API token",
+	"TRACE":                     "Print stack trace of error if set to 1",
+	"VAULT_ADDR":                "Vault server address",
+	"VAULT_NAMESPACE":           "Vault namespace (Vault Enterprise)",
+	"VAULT_TOKEN":               "Vault token (default: ~/.vault-token)",
+}
+
+// globalEnv represents environment variables read regardless of flags
+var globalEnv = []string{"AWS_ACCESS_KEY_ID", "AWS_PROFILE", "AWS_REGION", "AWS_SECRET_ACCESS_KEY", "ESNCTL_TELEMETRY", "ESNCTL_TELEMETRY_ENDPOINT", "TRACE"}
+
+// commandEnv represents environment variables read by the command regardless of flags
+var commandEnv = map[string][]string{
+	"self-update": {"GITHUB_TOKEN"},
+}
+
+// genDocsCmd represents the gen-docs command
+var genDocsCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "gen-docs",
+	Short:         "Generate man pages or Markdown reference of all commands",
+	RunE:          doGenDocs,
+}
+
+var genDocsOpts = struct {
+	dir    string
+	format string
+}{}
+
+func doGenDocs(cmd *cobra.Command, args []string) error {
+	var (
+		ext   string
+		write func(w io.Writer, c *cobra.Command) error
+	)
+
+	switch genDocsOpts.format {
+	case docsFormatMan:
+		ext, write = ".1", writeManPage
+	case docsFormatMarkdown:
+		ext, write = ".md", writeMarkdownPage
+	default:
+		return exitcode.Errorf(exitcode.Validation, "--format must be %q or %q", docsFormatMan, docsFormatMarkdown)
+	}
+
+	if err := os.MkdirAll(genDocsOpts.dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create output directory")
+	}
+
+	for _, c := range documentedCommands(RootCmd) {
+		var buf bytes.Buffer
+
+		if err := write(&buf, c); err != nil {
+			return err
+		}
+
+		path := filepath.Join(genDocsOpts.dir, docsBaseName(c, genDocsOpts.format)+ext)
+
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", path)
+		}
+
+		fmt.Println(path)
+	}
+
+	return nil
+}
+
+// documentedCommands returns the given command and its visible descendants
+func documentedCommands(cmd *cobra.Command) []*cobra.Command {
+	commands := []*cobra.Command{cmd}
+
+	for _, c := range cmd.Commands() {
+		if !c.IsAvailableCommand() || c.Name() == "help" {
+			continue
+		}
+
+		commands = append(commands, documentedCommands(c)...)
+	}
+
+	return commands
+}
+
+// docsBaseName returns file name of the command page without extension, "esnctl-remove" for man and "esnctl_remove" for Markdown
+func docsBaseName(cmd *cobra.Command, format string) string {
+	sep := "_"
+	if format == docsFormatMan {
+		sep = "-"
+	}
+
+	return strings.Replace(cmd.CommandPath(), " ", sep, -1)
+}
+
+// markFlagEnv records environment variables read together with the given flag, shown in generated documents
+func markFlagEnv(flags *pflag.FlagSet, name string, envs ...string) {
+	flags.SetAnnotation(name, envAnnotation, envs)
+}
+
+// commandEnvVars returns sorted environment variables which affect the command
+func commandEnvVars(cmd *cobra.Command) []string {
+	seen := map[string]bool{}
+
+	for _, env := range globalEnv {
+		seen[env] = true
+	}
+
+	for _, env := range commandEnv[commandKey(cmd)] {
+		seen[env] = true
+	}
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		for _, env := range f.Annotations[envAnnotation] {
+			seen[env] = true
+		}
+	})
+
+	cmd.InheritedFlags().VisitAll(func(f *pflag.Flag) {
+		for _, env := range f.Annotations[envAnnotation] {
+			seen[env] = true
+		}
+	})
+
+	envs := make([]string, 0, len(seen))
+
+	for env := range seen {
+		envs = append(envs, env)
+	}
+
+	sort.Strings(envs)
+
+	return envs
+}
+
+// flagNames returns flag names in "-s, --name <type>" format
+func flagNames(f *pflag.Flag) string {
+	name := "--" + f.Name
+
+	if f.Shorthand != "" {
+		name = "-" + f.Shorthand + ", " + name
+	}
+
+	if f.Value.Type() != "bool" {
+		name += " <" + f.Value.Type() + ">"
+	}
+
+	return name
+}
+
+// flagDescription returns usage of the flag with its default value and environment variables
+func flagDescription(f *pflag.Flag) string {
+	desc := f.Usage
+
+	switch f.DefValue {
+	case "", "0", "0s", "false", "[]":
+	default:
+		desc += fmt.Sprintf(" (default: %s)", f.DefValue)
+	}
+
+	if envs := f.Annotations[envAnnotation]; len(envs) > 0 {
+		desc += " [env: " + strings.Join(envs, ", ") + "]"
+	}
+
+	return desc
+}
+
+func visibleFlags(flags *pflag.FlagSet) []*pflag.Flag {
+	result := []*pflag.Flag{}
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		if !f.Hidden {
+			result = append(result, f)
+		}
+	})
+
+	return result
+}
+
+func commandDescription(cmd *cobra.Command) string {
+	if cmd.Long != "" {
+		return cmd.Long
+	}
+
+	return cmd.Short
+}
+
+func relatedCommands(cmd *cobra.Command) []*cobra.Command {
+	related := []*cobra.Command{}
+
+	if cmd.HasParent() {
+		related = append(related, cmd.Parent())
+	}
+
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() && c.Name() != "help" {
+			related = append(related, c)
+		}
+	}
+
+	return related
+}
+
+// roffEscape escapes text to be put in man page
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	s = strings.Replace(s, "-", `\-`, -1)
+
+	lines := strings.Split(s, "\n")
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func writeManPage(w io.Writer, cmd *cobra.Command) error {
+	name := docsBaseName(cmd, docsFormatMan)
+	date := time.Now().UTC().Format("Jan 2006")
+
+	if t, err := time.Parse(time.RFC3339, version.BuildDate); err == nil {
+		date = t.Format("Jan 2006")
+	}
+
+	fmt.Fprintf(w, ".TH %q \"1\" %q \"esnctl %s\" \"esnctl Manual\"\n", strings.ToUpper(name), date, version.Version)
+
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintf(w, "%s \\- %s\n", roffEscape(name), roffEscape(cmd.Short))
+
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, "\\fB%s\\fP\n", roffEscape(cmd.UseLine()))
+
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, roffEscape(commandDescription(cmd)))
+
+	writeManFlags(w, "OPTIONS", visibleFlags(cmd.NonInheritedFlags()))
+	writeManFlags(w, "OPTIONS INHERITED FROM PARENT COMMANDS", visibleFlags(cmd.InheritedFlags()))
+
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+
+	for _, env := range commandEnvVars(cmd) {
+		fmt.Fprintf(w, ".TP\n\\fB%s\\fP\n%s\n", roffEscape(env), roffEscape(envDescriptions[env]))
+	}
+
+	fmt.Fprintln(w, ".SH EXIT STATUS")
+
+	for _, d := range exitcode.Descriptions {
+		fmt.Fprintf(w, ".TP\n\\fB%d\\fP\n%s\n", d.Code, roffEscape(d.Description))
+	}
+
+	if related := relatedCommands(cmd); len(related) > 0 {
+		fmt.Fprintln(w, ".SH SEE ALSO")
+
+		refs := make([]string, 0, len(related))
+
+		for _, c := range related {
+			refs = append(refs, fmt.Sprintf("\\fB%s\\fP(1)", roffEscape(docsBaseName(c, docsFormatMan))))
+		}
+
+		fmt.Fprintln(w, strings.Join(refs, ", "))
+	}
+
+	return nil
+}
+
+func writeManFlags(w io.Writer, title string, flags []*pflag.Flag) {
+	if len(flags) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, ".SH %s\n", title)
+
+	for _, f := range flags {
+		fmt.Fprintf(w, ".TP\n\\fB%s\\fP\n%s\n", roffEscape(flagNames(f)), roffEscape(flagDescription(f)))
+	}
+}
+
+func writeMarkdownPage(w io.Writer, cmd *cobra.Command) error {
+	fmt.Fprintf(w, "## %s\n\n", cmd.CommandPath())
+	fmt.Fprintf(w, "%s\n\n", cmd.Short)
+
+	if cmd.Long != "" {
+		fmt.Fprintf(w, "### Description\n\n%s\n\n", cmd.Long)
+	}
+
+	fmt.Fprintf(w, "### Synopsis\n\n```\n%s\n```\n\n", cmd.UseLine())
+
+	writeMarkdownFlags(w, "Options", visibleFlags(cmd.NonInheritedFlags()))
+	writeMarkdownFlags(w, "Options inherited from parent commands", visibleFlags(cmd.InheritedFlags()))
+
+	fmt.Fprintf(w, "### Environment\n\n| Variable | Description |\n|---|---|\n")
+
+	for _, env := range commandEnvVars(cmd) {
+		fmt.Fprintf(w, "| `%s` | %s |\n", env, envDescriptions[env])
+	}
+
+	fmt.Fprintf(w, "\n### Exit status\n\n| Code | Description |\n|---|---|\n")
+
+	for _, d := range exitcode.Descriptions {
+		fmt.Fprintf(w, "| `%d` | %s |\n", d.Code, d.Description)
+	}
+
+	if related := relatedCommands(cmd); len(related) > 0 {
+		fmt.Fprintf(w, "\n### See also\n\n")
+
+		for _, c := range related {
+			fmt.Fprintf(w, "* [%s](%s.md) - %s\n", c.CommandPath(), docsBaseName(c, docsFormatMarkdown), c.Short)
+		}
+	}
+
+	return nil
+}
+
+func writeMarkdownFlags(w io.Writer, title string, flags []*pflag.Flag) {
+	if len(flags) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "### %s\n\n| Flag | Description |\n|---|---|\n", title)
+
+	for _, f := range flags {
+		fmt.Fprintf(w, "| `%s` | %s |\n", flagNames(f), strings.Replace(flagDescription(f), "|", `\|`, -1))
+	}
+
+	fmt.Fprintln(w)
+}
+
+func init() {
+	RootCmd.AddCommand(genDocsCmd)
+
+	genDocsCmd.Flags().StringVar(&genDocsOpts.dir, "dir", "docs", "Directory to write documents to")
+	genDocsCmd.Flags().StringVar(&genDocsOpts.format, "format", docsFormatMan, "Document format, \"man\" or \"markdown\"")
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/metrics"
+	"github.com/spf13/cobra"
+)
+
+// healthCmd represents the health command
+var healthCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "health",
+	Short:         "Show cluster health, shards in transition and the highest disk and heap usage",
+	RunE:          doHealth,
+}
+
+var healthOpts = struct {
+	allClusters bool
+	clusterURL  string
+}{}
+
+func doHealth(cmd *cobra.Command, args []string) error {
+	if healthOpts.allClusters {
+		results, err := fanOutClusters(func(name string, cluster *config.Cluster, out io.Writer) error {
+			return showHealth(cluster.ClusterURL, out)
+		})
+		if err != nil {
+			return err
+		}
+
+		return renderClusterResults(os.Stdout, results)
+	}
+
+	if healthOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) or --all-clusters must be specified")
+	}
+
+	return showHealth(healthOpts.clusterURL, os.Stdout)
+}
+
+func showHealth(clusterURL string, out io.Writer) error {
+	client, err := newESClient(clusterURL)
+	if err != nil {
+		return err
+	}
+
+	s, err := metrics.CollectCluster(client)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 1, ' ', 0)
+
+	fmt.Fprintf(w, "Status:\t%s\n", s.Status)
+	fmt.Fprintf(w, "Nodes:\t%d\n", s.Nodes)
+	fmt.Fprintf(w, "Relocating shards:\t%d\n", s.RelocatingShards)
+	fmt.Fprintf(w, "Initializing shards:\t%d\n", s.InitializingShards)
+	fmt.Fprintf(w, "Unassigned shards:\t%d\n", s.UnassignedShards)
+	fmt.Fprintf(w, "Excluded nodes:\t%d\n", s.ExcludedNodes)
+	fmt.Fprintf(w, "Max disk usage:\t%.1f%%\n", s.MaxDiskPercent)
+	fmt.Fprintf(w, "Max heap usage:\t%.1f%%\n", s.MaxHeapPercent)
+
+	return w.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(healthCmd)
+
+	healthCmd.Flags().BoolVar(&healthOpts.allClusters, "all-clusters", false, "Show health of all clusters in config file concurrently")
+	healthCmd.Flags().StringVar(&healthOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/history"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "history",
+	Short:         "Show operation history",
+	RunE:          doHistory,
+}
+
+// historyShowCmd represents the history show command
+var historyShowCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "show ID",
+	Short:         "Show operation detail",
+	RunE:          doHistoryShow,
+}
+
+var historyOpts = struct {
+	cluster string
+	limit   int
+}{}
+
+func doHistory(cmd *cobra.Command, args []string) error {
+	ops, err := history.New(history.DefaultDir()).List()
+	if err != nil {
+		return errors.Wrap(err, "failed to load operation history")
+	}
+
+	filtered := []*operation.Operation{}
+
+	for _, op := range ops {
+		if historyOpts.cluster != "" && !strings.Contains(op.Cluster, historyOpts.cluster) {
+			continue
+		}
+
+		filtered = append(filtered, op)
+	}
+
+	if historyOpts.limit > 0 && len(filtered) > historyOpts.limit {
+		filtered = filtered[len(filtered)-historyOpts.limit:]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTARTED\tCOMMAND\tCLUSTER\tTARGET\tDURATION\tRESULT")
+
+	for i := len(filtered) - 1; i >= 0; i-- {
+		op := filtered[i]
+
+		target := op.Node
+		if target == "" {
+			target = op.Group
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", op.ID, op.StartedAt.Local().Format(time.RFC3339), op.Command, op.Cluster, target, roundDuration(op.Duration()), op.Result)
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+func doHistoryShow(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "operation ID must be specified")
+	}
+
+	op, err := history.New(history.DefaultDir()).Get(args[0])
+	if err != nil {
+		return errors.Wrap(err, "failed to load operation")
+	}
+
+	fmt.Printf("ID:       %s\n", op.ID)
+	fmt.Printf("Command:  %s\n", op.Command)
+	fmt.Printf("User:     %s\n", op.User)
+	fmt.Printf("Cluster:  %s\n", op.Cluster)
+	fmt.Printf("Group:    %s\n", op.Group)
+	fmt.Printf("Node:     %s\n", op.Node)
+	fmt.Printf("Started:  %s\n", op.StartedAt.Local().Format(time.RFC3339))
+	fmt.Printf("Finished: %s\n", op.FinishedAt.Local().Format(time.RFC3339))
+	fmt.Printf("Duration: %s\n", roundDuration(op.Duration()))
+	fmt.Printf("Result:   %s\n", op.Result)
+
+	if op.Error != "" {
+		fmt.Printf("Error:    %s\n", op.Error)
+	}
+
+	fmt.Println("Phases:")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	for _, phase := range op.Phases {
+		fmt.Fprintf(w, "  %s\t%s\n", phase.Name, roundDuration(phase.FinishedAt.Sub(phase.StartedAt)))
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+// beginHistory records the given operation as running, so that it can be found if the process dies before it finishes
+// Failure is only logged not to block the operation
+func beginHistory(op *operation.Operation) {
+	if err := history.New(history.DefaultDir()).Begin(op); err != nil {
+		log.Println(errors.Wrap(err, "failed to record running operation"))
+	}
+}
+
+// saveHistory appends the given operation to local history
+// Failure is only logged not to hide the result of operation itself
+func saveHistory(op *operation.Operation) {
+	if err := history.New(history.DefaultDir()).Append(op); err != nil {
+		log.Println(errors.Wrap(err, "failed to save operation history"))
+	}
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	return d - d%time.Second
+}
+
+func init() {
+	RootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+
+	historyCmd.Flags().StringVar(&historyOpts.cluster, "cluster", "", "Show operations only against clusters matching the given string")
+	historyCmd.Flags().IntVarP(&historyOpts.limit, "limit", "n", 20, "Number of operations to show")
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/indices"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// indicesCmd represents the indices command
+var indicesCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "indices",
+	Short:         "List indices with health, size and shards placed on the given node",
+	RunE:          doIndices,
+}
+
+var indicesOpts = struct {
+	clusterURL string
+	nodeName   string
+	output     string
+}{}
+
+func doIndices(cmd *cobra.Command, args []string) error {
+	if indicesOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if indicesOpts.output != "text" && indicesOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", indicesOpts.output)
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "esnctl indices cannot be used with --mock")
+	}
+
+	httpClient, err := newHTTPClient(indicesOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	clusterURL, err := withCredentials(indicesOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	list, err := es.ListIndices(clusterURL, httpClient)
+	if err != nil {
+		return err
+	}
+
+	client, err := newESClient(indicesOpts.clusterURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Elasticsearch API client")
+	}
+
+	shards, err := client.ListShards()
+	if err != nil {
+		return errors.Wrap(err, "failed to list shards")
+	}
+
+	rows := indices.New(list, shards, indicesOpts.nodeName)
+
+	if indicesOpts.output == "json" {
+		b, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode indices")
+		}
+
+		fmt.Println(string(b))
+
+		return nil
+	}
+
+	indices.Render(os.Stdout, rows, indicesOpts.nodeName)
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(indicesCmd)
+
+	indicesCmd.Flags().StringVar(&indicesOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	indicesCmd.Flags().StringVar(&indicesOpts.nodeName, "node-name", "", "Show only indices having shards on the node, with impact of removing it")
+	indicesCmd.Flags().StringVar(&indicesOpts.output, "output", "text", "Output format (text, json)")
+
+	markFlagCompletion(indicesCmd.Flags(), "node-name")
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/history"
+	"github.com/dtan4/esnctl/lock"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/server"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+)
+
+const (
+	// interruptedReport only logs interrupted operations found on startup
+	interruptedReport = "report"
+	// interruptedResume resumes interrupted removals and drains
+	interruptedResume = "resume"
+	// interruptedRollback clears allocation exclusion and registers the node with target group again
+	interruptedRollback = "rollback"
+)
+
+// interruptedDrain represents node removal or drain left half-done, e.g. by restart of esnctl server
+type interruptedDrain struct {
+	name    string
+	cluster *config.Cluster
+	node    string
+
+	// op is the unfinished operation, or nil if only allocation exclusion is left, which is reported but never resumed nor rolled back
+	op *operation.Operation
+}
+
+// findInterruptedDrains scans the given clusters for allocation exclusions and local history for unfinished operations
+// Unfinished operations still running, i.e. whose process is alive or which hold cluster lock, are skipped
+// Unfinished operations other than removal or drain are returned separately
+func findInterruptedDrains(clusters map[string]*config.Cluster) ([]*interruptedDrain, []*operation.Operation, error) {
+	store := history.New(history.DefaultDir())
+
+	unfinished, err := store.Unfinished()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	finished, err := store.List()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	drains := []*interruptedDrain{}
+	others := []*operation.Operation{}
+
+	for _, name := range names {
+		cluster := clusters[name]
+		clusterURL := operation.SanitizeURL(cluster.ClusterURL)
+
+		// Unreachable cluster should not keep server from starting
+		client, err := newESClient(cluster.ClusterURL)
+		if err != nil {
+			log.Println(errors.Wrapf(err, "failed to connect to cluster %s", name))
+			client = nil
+		}
+
+		locked := lockedOperation(client, name)
+		covered := map[string]bool{}
+
+		for _, op := range unfinished {
+			if op.Cluster != clusterURL {
+				continue
+			}
+
+			drain := (op.Command == server.ActionRemove || op.Command == server.ActionDrain) && op.Node != ""
+
+			alive, err := store.Alive(op)
+			if err != nil {
+				log.Println(errors.Wrapf(err, "failed to check whether operation %s is running", op.ID))
+			}
+
+			if alive || op.ID == locked {
+				log.Printf("===> %s operation %s against %s started at %s is still running\n", op.Command, op.ID, op.Cluster, op.StartedAt.Local().Format(time.RFC3339))
+
+				if drain {
+					covered[op.Node] = true
+				}
+
+				continue
+			}
+
+			if drain {
+				drains = append(drains, &interruptedDrain{name: name, cluster: cluster, node: op.Node, op: op})
+				covered[op.Node] = true
+			} else {
+				others = append(others, op)
+			}
+		}
+
+		if client == nil {
+			continue
+		}
+
+		settings, err := client.ClusterSettings()
+		if err != nil {
+			log.Println(errors.Wrapf(err, "failed to look for allocation exclusions in cluster %s", name))
+			continue
+		}
+
+		for _, node := range strings.Split(settings[workflow.ExcludeNameSetting], ",") {
+			node = strings.TrimSpace(node)
+
+			if node == "" || covered[node] || drainedIntentionally(finished, clusterURL, node) {
+				continue
+			}
+
+			drains = append(drains, &interruptedDrain{name: name, cluster: cluster, node: node})
+		}
+	}
+
+	return drains, others, nil
+}
+
+// lockedOperation returns ID of the operation holding unexpired cluster lock, or empty string if there is none
+func lockedOperation(client es.Client, name string) string {
+	if client == nil {
+		return ""
+	}
+
+	info, err := lock.Get(client)
+	if err != nil {
+		log.Println(errors.Wrapf(err, "failed to get cluster lock of cluster %s", name))
+		return ""
+	}
+
+	if info == nil || info.Expired(time.Now()) {
+		return ""
+	}
+
+	return info.OperationID
+}
+
+// drainedIntentionally returns whether the node was excluded by drain which finished successfully
+func drainedIntentionally(ops []*operation.Operation, clusterURL, node string) bool {
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+
+		if op.Cluster == clusterURL && op.Node == node {
+			return op.Command == server.ActionDrain && op.Result == operation.ResultSucceeded
+		}
+	}
+
+	return false
+}
+
+// handleInterrupted reports, resumes or rolls back operations interrupted before the server started
+func handleInterrupted(s *server.Server, mode string) error {
+	switch mode {
+	case interruptedReport, interruptedResume, interruptedRollback:
+	default:
+		return exitcode.Errorf(exitcode.Validation, "--on-interrupted must be %s, %s or %s", interruptedReport, interruptedResume, interruptedRollback)
+	}
+
+	drains, others, err := findInterruptedDrains(cfg.Clusters)
+	if err != nil {
+		return errors.Wrap(err, "failed to look for interrupted operations")
+	}
+
+	for _, op := range others {
+		log.Printf("===> WARNING: %s operation %s against %s started at %s did not finish\n", op.Command, op.ID, op.Cluster, op.StartedAt.Local().Format(time.RFC3339))
+
+		if mode != interruptedReport {
+			op.Finish(errors.New("interrupted by restart of esnctl server"))
+			saveHistory(op)
+		}
+	}
+
+	for _, d := range drains {
+		// Exclusion without operation record may be made intentionally, e.g. by another host, so it is only reported
+		if d.op == nil {
+			log.Printf("===> WARNING: node %s in cluster %s is excluded from shard allocation without running operation\n", d.node, d.name)
+			log.Println("     Left as it is without operation record, clear the exclusion or remove the node explicitly")
+			continue
+		}
+
+		log.Printf("===> WARNING: %s operation %s of node %s in cluster %s did not finish\n", d.op.Command, d.op.ID, d.node, d.name)
+
+		switch mode {
+		case interruptedReport:
+			log.Println("     Restart with --on-interrupted=resume or --on-interrupted=rollback to finish it")
+		case interruptedResume:
+			if err := resumeInterrupted(s, d); err != nil {
+				log.Println(errors.Wrapf(err, "failed to resume operation %s", d.op.ID))
+			}
+		case interruptedRollback:
+			if err := rollbackInterrupted(d); err != nil {
+				log.Println(errors.Wrapf(err, "failed to roll back removal of node %s", d.node))
+			}
+		}
+	}
+
+	return nil
+}
+
+// resumeInterrupted queues the interrupted operation again with the same ID
+// Steps already done are skipped by workflow
+func resumeInterrupted(s *server.Server, d *interruptedDrain) error {
+	op := operation.New(d.op.Command, d.cluster.ClusterURL)
+	op.ID = d.op.ID
+	op.User = d.op.User
+	op.Group = d.op.Group
+	op.Node = d.node
+
+	if _, err := s.Submit(d.name, op, &server.Request{Action: d.op.Command, NodeName: d.node}); err != nil {
+		return err
+	}
+
+	log.Printf("===> Resuming %s operation %s of node %s in cluster %s\n", op.Command, op.ID, d.node, d.name)
+
+	return nil
+}
+
+// rollbackInterrupted clears allocation exclusion of the node and registers it with target group again
+// The interrupted operation is recorded as failed
+func rollbackInterrupted(d *interruptedDrain) error {
+	w, err := newClusterWorkflow(d.cluster)
+	if err != nil {
+		return err
+	}
+
+	w.ProgressBar = false
+
+	group := d.cluster.Group
+	if d.op.Group != "" {
+		group = d.op.Group
+	}
+
+	op := operation.New("cancel-remove", d.cluster.ClusterURL)
+	op.Group = group
+	op.Node = d.node
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	// Rollback restores the cluster, so it runs regardless of maintenance windows
+	opts := serverOpts.operationOptions
+	opts.overrideWindow = true
+
+	err = runOperation(ctx, op, w.ES, opts, func(ctx context.Context) error {
+		return w.CancelRemoval(ctx, workflow.RemoveOptions{
+			Group:     group,
+			NodeName:  d.node,
+			Operation: op,
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	d.op.Finish(errors.Errorf("interrupted by restart of esnctl server, rolled back by operation %s", op.ID))
+	saveHistory(d.op)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/lock"
+	"github.com/dtan4/esnctl/metrics"
+	"github.com/spf13/cobra"
+)
+
+const defaultLeaderLeaseDuration = 15 * time.Second
+
+// leaderElectionOptions represents options of leader election shared by long-running commands
+type leaderElectionOptions struct {
+	enabled       bool
+	clusterURL    string
+	leaseDuration time.Duration
+}
+
+func (o *leaderElectionOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.enabled, "leader-elect", false, "Elect one leader among replicas, so that only the leader executes operations")
+	cmd.Flags().StringVar(&o.clusterURL, "leader-elect-cluster-url", "", "Elasticsearch cluster URL to store leader lease in (required with --leader-elect)")
+	cmd.Flags().DurationVar(&o.leaseDuration, "leader-lease-duration", defaultLeaderLeaseDuration, "Duration of leader lease. Standby takes over within twice the duration after the leader dies")
+}
+
+// start campaigns for leadership in background under the given election name
+// Returned function reports whether this instance is the leader, or nil if leader election is disabled
+func (o *leaderElectionOptions) start(ctx context.Context, name string, collector *metrics.Collector) (func() bool, error) {
+	if !o.enabled {
+		return nil, nil
+	}
+
+	if o.clusterURL == "" {
+		return nil, exitcode.Errorf(exitcode.Validation, "--leader-elect-cluster-url must be specified with --leader-elect")
+	}
+
+	if o.leaseDuration <= 0 {
+		return nil, exitcode.Errorf(exitcode.Validation, "--leader-lease-duration must be positive")
+	}
+
+	client, err := newESClient(o.clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+
+	e := lock.NewElector(client, name, fmt.Sprintf("%s-%d", host, os.Getpid()), o.leaseDuration)
+
+	if collector != nil {
+		collector.SetLeader(false)
+		e.OnChange = collector.SetLeader
+	}
+
+	// Campaign once synchronously, so that the first request is not rejected needlessly
+	if err := e.Campaign(); err != nil {
+		return nil, err
+	}
+
+	if holder, err := e.Holder(); err == nil && holder != e.Identity() {
+		log.Printf("===> Standing by, %s is the leader of %s\n", holder, name)
+	}
+
+	go e.Run(ctx)
+
+	return e.IsLeader, nil
+}
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
+	"io"
+	"os"
 
-	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -19,17 +21,31 @@ var listCmd = &cobra.Command{
 }
 
 var listOpts = struct {
-	clusterURL string
+	allClusters bool
+	clusterURL  string
 }{}
 
 func doList(cmd *cobra.Command, args []string) error {
+	if listOpts.allClusters {
+		results, err := fanOutClusters(func(name string, cluster *config.Cluster, out io.Writer) error {
+			return listNodes(cluster.ClusterURL, out)
+		})
+		if err != nil {
+			return err
+		}
+
+		return renderClusterResults(os.Stdout, results)
+	}
+
 	if listOpts.clusterURL == "" {
-		return errors.New("Elasticsearch cluster (--cluster-url) must be specified")
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster (--cluster-url) or --all-clusters must be specified")
 	}
 
-	httpClient := &http.Client{}
+	return listNodes(listOpts.clusterURL, os.Stdout)
+}
 
-	client, err := es.New(listOpts.clusterURL, httpClient)
+func listNodes(clusterURL string, out io.Writer) error {
+	client, err := newESClient(clusterURL)
 	if err != nil {
 		return errors.Wrap(err, "failed to create Elasitcsearch API client")
 	}
@@ -40,7 +56,7 @@ func doList(cmd *cobra.Command, args []string) error {
 	}
 
 	for _, node := range nodes {
-		fmt.Println(node)
+		fmt.Fprintln(out, node)
 	}
 
 	return nil
@@ -49,5 +65,6 @@ func doList(cmd *cobra.Command, args []string) error {
 func init() {
 	RootCmd.AddCommand(listCmd)
 
-	listCmd.Flags().StringVar(&listOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL")
+	listCmd.Flags().BoolVar(&listOpts.allClusters, "all-clusters", false, "List nodes of all clusters in config file concurrently")
+	listCmd.Flags().StringVar(&listOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
 }
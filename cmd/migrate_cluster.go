@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// migrateClusterCmd represents the migrate-cluster command
+var migrateClusterCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "migrate-cluster",
+	Short:         "Copy indices into a new cluster with reindex from remote or snapshot, and assist cutover",
+	RunE:          doMigrateCluster,
+}
+
+var migrateClusterOpts = struct {
+	cutover    bool
+	destGroup  string
+	destURL    string
+	indices    []string
+	method     string
+	region     string
+	repository string
+	sourceURL  string
+	operationOptions
+}{}
+
+func doMigrateCluster(cmd *cobra.Command, args []string) error {
+	if migrateClusterOpts.sourceURL == "" {
+		return exitcode.New(exitcode.Validation, "Source Elasticsearch cluster URL (--source-url) must be specified")
+	}
+
+	if migrateClusterOpts.destURL == "" {
+		return exitcode.New(exitcode.Validation, "Destination Elasticsearch cluster URL (--dest-url) must be specified")
+	}
+
+	if len(migrateClusterOpts.indices) == 0 {
+		return exitcode.New(exitcode.Validation, "Indices to migrate (--index) must be specified")
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "migrate-cluster is not supported by fake cluster (--mock)")
+	}
+
+	sourceHTTPClient, err := newHTTPClient(migrateClusterOpts.sourceURL)
+	if err != nil {
+		return err
+	}
+
+	sourceURL, err := withCredentials(migrateClusterOpts.sourceURL)
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := newHTTPClient(migrateClusterOpts.destURL)
+	if err != nil {
+		return err
+	}
+
+	w, err := newWorkflow(migrateClusterOpts.destURL, migrateClusterOpts.region)
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("migrate-cluster", migrateClusterOpts.destURL)
+	op.Group = migrateClusterOpts.destGroup
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, migrateClusterOpts.operationOptions, func(ctx context.Context) error {
+		return w.MigrateCluster(ctx, workflow.MigrateClusterOptions{
+			SourceURL:        sourceURL,
+			Indices:          migrateClusterOpts.indices,
+			Method:           migrateClusterOpts.method,
+			Repository:       migrateClusterOpts.repository,
+			Cutover:          migrateClusterOpts.cutover,
+			DestGroup:        migrateClusterOpts.destGroup,
+			SourceHTTPClient: sourceHTTPClient,
+			HTTPClient:       httpClient,
+			Operation:        op,
+		})
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(migrateClusterCmd)
+
+	migrateClusterCmd.Flags().BoolVar(&migrateClusterOpts.cutover, "cutover", false, "Block writes to source indices before copying them, then copy their aliases")
+	migrateClusterCmd.Flags().StringVar(&migrateClusterOpts.destGroup, "dest-group", "", "Auto Scaling Group of the destination cluster, whose target group is verified on cutover")
+	migrateClusterCmd.Flags().StringVar(&migrateClusterOpts.destURL, "dest-url", "", "Destination Elasticsearch cluster URL")
+	migrateClusterCmd.Flags().StringSliceVar(&migrateClusterOpts.indices, "index", []string{}, "Index name or pattern to migrate (repeatable)")
+	migrateClusterCmd.Flags().StringVar(&migrateClusterOpts.method, "method", workflow.MigrateReindex, "How to copy indices (reindex, snapshot)")
+	migrateClusterCmd.Flags().StringVar(&migrateClusterOpts.region, "region", "", "AWS region")
+	migrateClusterCmd.Flags().StringVar(&migrateClusterOpts.repository, "repository", "", "Snapshot repository registered in both clusters (--method snapshot)")
+	migrateClusterCmd.Flags().StringVar(&migrateClusterOpts.sourceURL, "source-url", "", "Source Elasticsearch cluster URL")
+	migrateClusterOpts.operationOptions.addFlags(migrateClusterCmd)
+}
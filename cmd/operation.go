@@ -0,0 +1,552 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dtan4/esnctl/audit"
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+	"github.com/dtan4/esnctl/history"
+	"github.com/dtan4/esnctl/httpclient"
+	"github.com/dtan4/esnctl/lock"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/tunnel"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// outputText prints phase banners and progress for humans
+	outputText = "text"
+	// outputJSONL writes one JSON object per phase transition and progress check to stdout
+	outputJSONL = "jsonl"
+)
+
+// outputFormat is given by --output of commands which modify cluster
+// It is not in operationOptions because progress output of workflows depends on it
+var outputFormat = outputText
+
+// scheduleAt is given by --at of commands which modify cluster
+// It is not in operationOptions because deadline of --operation-timeout counts from it
+var scheduleAt string
+
+var eventObserver *operation.JSONLObserver
+
+// getEventObserver returns observer writing events to stdout, shared in the process
+func getEventObserver() *operation.JSONLObserver {
+	if eventObserver == nil {
+		eventObserver = operation.NewJSONLObserver(os.Stdout)
+	}
+
+	return eventObserver
+}
+
+// operationOptions represents options shared by commands which modify cluster
+type operationOptions struct {
+	audit           bool
+	auditClusterURL string
+	auditIndex      string
+	lock            bool
+	lockTimeout     time.Duration
+	operationID     string
+	overrideWindow  bool
+
+	cloudWatchMetrics bool
+	datadogEvent      bool
+	datadogMuteScopes []string
+	datadogMuteWindow time.Duration
+	pagerDutyServices []string
+	pagerDutyWindow   time.Duration
+}
+
+func (o *operationOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&scheduleAt, "at", "", "Start operation at the given time in RFC 3339 format, e.g. 2024-06-01T02:00Z, waiting until then")
+	cmd.Flags().BoolVar(&o.audit, "audit", false, "Record operation into audit index")
+	cmd.Flags().StringVar(&o.auditClusterURL, "audit-cluster-url", "", "Elasticsearch cluster URL to store audit log (default: --cluster-url)")
+	cmd.Flags().StringVar(&o.auditIndex, "audit-index", audit.DefaultIndex, "Index name to store audit log")
+	cmd.Flags().BoolVar(&o.cloudWatchMetrics, "cloudwatch-metrics", false, "Publish metrics of operation (duration, drain duration, shards moved, failures) to CloudWatch under esnctl/Operations")
+	cmd.Flags().BoolVar(&o.datadogEvent, "datadog-event", false, "Post Datadog events when operation starts and finishes (with DD_API_KEY)")
+	cmd.Flags().StringSliceVar(&o.datadogMuteScopes, "datadog-mute-scope", []string{}, "Mute Datadog monitors in the given scope during operation, e.g. cluster:logs (with DD_API_KEY and DD_APP_KEY)")
+	cmd.Flags().DurationVar(&o.datadogMuteWindow, "datadog-mute-window", defaultDatadogMuteWindow, "Maximum duration of Datadog downtime, in case esnctl dies before unmuting monitors")
+	cmd.Flags().BoolVar(&o.lock, "lock", true, "Acquire cluster lock during operation, stopping it if the lock is taken over (--lock=false to disable)")
+	cmd.Flags().DurationVar(&o.lockTimeout, "lock-timeout", 0, "How long to wait for cluster lock held by another operation")
+	cmd.Flags().StringVar(&outputFormat, "output", outputText, "Output format of progress, \"text\" or \"jsonl\" (JSON lines on stdout for automation)")
+	cmd.Flags().BoolVar(&o.overrideWindow, "override-window", false, "Run operation outside maintenance windows configured in config file")
+	cmd.Flags().StringVar(&o.operationID, "operation-id", "", "Operation ID to resume prior operation or to correlate with external systems (default: generated)")
+	cmd.Flags().StringSliceVar(&o.pagerDutyServices, "pagerduty-service", []string{}, "PagerDuty service ID put in maintenance window during operation (with PAGERDUTY_TOKEN)")
+	cmd.Flags().DurationVar(&o.pagerDutyWindow, "pagerduty-window", defaultPagerDutyWindow, "Maximum duration of PagerDuty maintenance window, in case esnctl dies before closing it")
+
+	markFlagEnv(cmd.Flags(), "datadog-event", "DD_API_KEY", "DD_SITE")
+	markFlagEnv(cmd.Flags(), "datadog-mute-scope", "DD_API_KEY", "DD_APP_KEY", "DD_SITE")
+	markFlagEnv(cmd.Flags(), "pagerduty-service", "PAGERDUTY_FROM", "PAGERDUTY_TOKEN")
+}
+
+// priorOperation returns the operation in history with the ID given by --operation-id
+// nil is returned if no ID is given or the ID is new
+func (o *operationOptions) priorOperation() *operation.Operation {
+	if o.operationID == "" {
+		return nil
+	}
+
+	op, err := history.New(history.DefaultDir()).Get(o.operationID)
+	if err != nil {
+		return nil
+	}
+
+	return op
+}
+
+// runOperation executes fn as the given operation
+// Cluster lock, operation history and audit log are handled here
+// Context passed to fn is canceled if cluster lock is taken over by another operation during fn
+func runOperation(ctx context.Context, op *operation.Operation, client es.Client, opts operationOptions, fn func(ctx context.Context) error) error {
+	if opts.operationID != "" {
+		op.ID = opts.operationID
+	}
+
+	switch outputFormat {
+	case outputText:
+	case outputJSONL:
+		op.Observer = getEventObserver()
+	default:
+		return exitcode.Errorf(exitcode.Validation, "output format %q is not supported, must be text or jsonl", outputFormat)
+	}
+
+	if err := waitForSchedule(op, opts); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lost <-chan struct{}
+
+	if opts.lock {
+		l, err := lock.Acquire(client, op, opts.lockTimeout)
+		if err != nil {
+			return errors.Wrap(err, "failed to acquire cluster lock")
+		}
+
+		defer func() {
+			if err := l.Release(); err != nil {
+				log.Println(errors.Wrap(err, "failed to release cluster lock"))
+			}
+		}()
+
+		lost = l.Lost()
+
+		// Another operation may be modifying the cluster already, so this one stops at once
+		go func() {
+			select {
+			case <-lost:
+				op.Logf("===> WARNING: cluster lock has been lost, stopping operation\n")
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	// Integrations are finished in reverse order after the operation, whether it succeeds or not
+	finishers := []func(error){}
+	finish := func(err error) {
+		for i := len(finishers) - 1; i >= 0; i-- {
+			finishers[i](err)
+		}
+	}
+
+	if len(opts.pagerDutyServices) > 0 {
+		end, err := openMaintenanceWindow(op, opts)
+		if err != nil {
+			return errors.Wrap(err, "failed to open PagerDuty maintenance window")
+		}
+
+		finishers = append(finishers, func(error) { end() })
+	}
+
+	if opts.datadogEvent || len(opts.datadogMuteScopes) > 0 {
+		end, err := startDatadog(op, opts)
+		if err != nil {
+			finish(err)
+			return errors.Wrap(err, "failed to start Datadog integration")
+		}
+
+		finishers = append(finishers, end)
+	}
+
+	beginHistory(op)
+
+	err := fn(ctx)
+
+	select {
+	case <-lost:
+		err = exitcode.Wrap(errors.Wrap(lock.ErrTakenOver, "operation stopped because cluster lock was lost"), exitcode.Aborted)
+	default:
+	}
+
+	finish(err)
+
+	op.Finish(err)
+	saveHistory(op)
+
+	if opts.cloudWatchMetrics {
+		publishOperationMetrics(op)
+	}
+
+	if opts.audit {
+		if err := writeAuditLog(op, client, opts.auditClusterURL, opts.auditIndex); err != nil {
+			log.Println(err)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	op.Logf("===> Finished!\n")
+
+	return nil
+}
+
+// mockClusterSize represents the number of nodes in fake cluster used with --mock
+const mockClusterSize = 3
+
+var mockCluster *fake.Cluster
+
+// getMockCluster returns fake cluster shared in the process
+func getMockCluster() *fake.Cluster {
+	if mockCluster == nil {
+		log.Printf("===> Using in-memory fake cluster with %d nodes\n", mockClusterSize)
+		mockCluster = fake.NewCluster(mockClusterSize)
+	}
+
+	return mockCluster
+}
+
+var awsRateLimiter *aws.RateLimiter
+
+// getAWSRateLimiter returns AWS API rate limiter shared in the process
+// nil is returned if --aws-max-rps is not set
+func getAWSRateLimiter() *aws.RateLimiter {
+	if awsRateLimiter == nil {
+		awsRateLimiter = aws.NewRateLimiter(awsMaxRPS)
+	}
+
+	return awsRateLimiter
+}
+
+// awsOptions returns options of AWS service clients given by global flags
+func awsOptions() aws.Options {
+	return aws.Options{
+		MaxRetries:  maxAPIRetries,
+		RateLimiter: getAWSRateLimiter(),
+		RoleARN:     roleARN,
+		Timeout:     requestTimeout,
+		Trace:       traceLogger,
+	}
+}
+
+// clusterAWSOptions returns options of AWS service clients for the named cluster in config file
+// role_arn of the cluster takes precedence over --role-arn
+func clusterAWSOptions(cluster *config.Cluster) aws.Options {
+	opts := awsOptions()
+
+	if cluster.RoleARN != "" {
+		opts.RoleARN = cluster.RoleARN
+	}
+
+	return opts
+}
+
+// newContext returns context bounded by --operation-timeout
+func newContext() (context.Context, context.CancelFunc) {
+	if operationTimeout > 0 {
+		// Deadline counts from the scheduled time, not to expire while waiting for --at
+		return context.WithTimeout(context.Background(), untilScheduled()+operationTimeout)
+	}
+
+	return context.WithCancel(context.Background())
+}
+
+// newESClient creates Elasticsearch API client, or returns fake cluster with --mock
+func newESClient(clusterURL string) (es.Client, error) {
+	if mock {
+		return getMockCluster(), nil
+	}
+
+	httpClient, err := newHTTPClient(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterURL, err = withCredentials(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if sniff {
+		return es.NewWithSniffing(clusterURL, httpClient)
+	}
+
+	return es.New(clusterURL, httpClient)
+}
+
+// detectCapabilities detects distribution, version and available APIs of the given cluster
+func detectCapabilities(clusterURL string) (*es.Capabilities, error) {
+	if mock {
+		return es.NewCapabilities(es.DistributionElasticsearch, fake.Version)
+	}
+
+	httpClient, err := newHTTPClient(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterURL, err = withCredentials(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return es.DetectCapabilities(es.SplitURLs(clusterURL)[0], httpClient)
+}
+
+var (
+	httpClients   = map[string]*http.Client{}
+	httpClientsMu sync.Mutex
+)
+
+// newHTTPClient returns http.Client used to call Elasticsearch API of the given cluster
+// The client is shared in the process per cluster, so that connections are reused among workflows and polling loops
+func newHTTPClient(clusterURL string) (*http.Client, error) {
+	httpClientsMu.Lock()
+	defer httpClientsMu.Unlock()
+
+	if client, ok := httpClients[clusterURL]; ok {
+		return client, nil
+	}
+
+	client, err := buildHTTPClient(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClients[clusterURL] = client
+
+	return client, nil
+}
+
+// buildHTTPClient creates http.Client used to call Elasticsearch API of the given cluster
+// Transient errors are retried up to --max-api-retries times, and each attempt is bounded by --request-timeout
+// With --tunnel, requests are sent through tunnel opened for the cluster
+func buildHTTPClient(clusterURL string) (*http.Client, error) {
+	var dialAddr string
+
+	if tunnelSpec != "" {
+		if sniff {
+			return nil, exitcode.New(exitcode.Validation, "--sniff cannot be used with --tunnel")
+		}
+
+		addr, err := openTunnel(clusterURL)
+		if err != nil {
+			return nil, err
+		}
+
+		dialAddr = addr
+	}
+
+	var tlsConfig *tls.Config
+
+	if vaultPath != "" {
+		creds, err := getVaultCredentials()
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig, err = creds.TLSConfig()
+		if err != nil {
+			return nil, errors.Wrapf(err, "secret %s is invalid", vaultPath)
+		}
+	}
+
+	client, err := httpclient.New(httpclient.Options{
+		DialAddr:   dialAddr,
+		Headers:    headers,
+		MaxRetries: maxAPIRetries,
+		ProxyURL:   proxyURL,
+		Timeout:    requestTimeout,
+		TLSConfig:  tlsConfig,
+		Trace:      traceLogger,
+	})
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	return client, nil
+}
+
+var (
+	tunnels   = map[string]*tunnel.Tunnel{}
+	tunnelsMu sync.Mutex
+)
+
+// closeTunnels stops all tunnels opened in the process
+func closeTunnels() {
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+
+	for addr, t := range tunnels {
+		if err := t.Close(); err != nil {
+			log.Printf("failed to close tunnel to %s: %s\n", addr, err)
+		}
+
+		delete(tunnels, addr)
+	}
+}
+
+// openTunnel opens tunnel given by --tunnel to the given cluster and returns its local address
+// Tunnel is shared by clusters with the same host and port
+func openTunnel(clusterURL string) (string, error) {
+	spec, err := tunnel.ParseSpec(tunnelSpec)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	// Tunnel is opened only for the first URL, because all requests go through the tunnel
+	remoteAddr, err := tunnel.RemoteAddr(es.SplitURLs(clusterURL)[0])
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+
+	if t, ok := tunnels[remoteAddr]; ok {
+		return t.LocalAddr, nil
+	}
+
+	log.Printf("===> Opening %s tunnel to %s via %s...\n", spec.Type, remoteAddr, spec.Target)
+
+	t, err := tunnel.Open(spec, remoteAddr)
+	if err != nil {
+		return "", exitcode.Wrap(errors.Wrap(err, "failed to open tunnel"), exitcode.ESUnreachable)
+	}
+
+	tunnels[remoteAddr] = t
+
+	return t.LocalAddr, nil
+}
+
+// newWorkflow creates Workflow object which prints progress to stdout, as progress bar if stdout is terminal
+// Progress is discarded with --quiet
+// With --mock, Workflow operates fake cluster instead of real Elasticsearch and AWS
+func newWorkflow(clusterURL, region string) (*workflow.Workflow, error) {
+	return newWorkflowWithRole(clusterURL, region, roleARN)
+}
+
+// newClusterWorkflow creates Workflow object for the named cluster in config file, assuming its role_arn if given
+func newClusterWorkflow(cluster *config.Cluster) (*workflow.Workflow, error) {
+	return newWorkflowWithRole(cluster.ClusterURL, cluster.Region, clusterAWSOptions(cluster).RoleARN)
+}
+
+func newWorkflowWithRole(clusterURL, region, role string) (*workflow.Workflow, error) {
+	if mock {
+		c := getMockCluster()
+
+		capabilities, err := es.NewCapabilities(es.DistributionElasticsearch, fake.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		return &workflow.Workflow{
+			ClusterURL:   clusterURL,
+			Region:       region,
+			AutoScaling:  c.AutoScaling(),
+			EC2:          c.EC2(),
+			ELBv2:        c.ELBv2(),
+			ES:           c,
+			Capabilities: capabilities,
+			Progress:     progressOutput(),
+			ProgressBar:  showProgressBar(),
+			MinPoll:      minPoll,
+			MaxPoll:      maxPoll,
+
+			LBDrainTimeout:    lbDrainTimeout,
+			ShardDrainTimeout: shardDrainTimeout,
+			JoinTimeout:       joinTimeout,
+			MLTimeout:         mlTimeout,
+		}, nil
+	}
+
+	httpClient, err := newHTTPClient(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterURL, err = withVaultCredentials(clusterURL)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := workflow.New(clusterURL, region, workflow.ClientOptions{
+		AWSRateLimiter: getAWSRateLimiter(),
+		HTTPClient:     httpClient,
+		MaxAPIRetries:  maxAPIRetries,
+		PasswordFrom:   passwordFrom,
+		RequestTimeout: requestTimeout,
+		RoleARN:        role,
+		Sniff:          sniff,
+		Trace:          traceLogger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w.Progress = progressOutput()
+	w.ProgressBar = showProgressBar()
+	w.MinPoll = minPoll
+	w.MaxPoll = maxPoll
+	w.LBDrainTimeout = lbDrainTimeout
+	w.ShardDrainTimeout = shardDrainTimeout
+	w.JoinTimeout = joinTimeout
+	w.MLTimeout = mlTimeout
+
+	return w, nil
+}
+
+// withCredentials returns cluster URL with credentials read from Vault or --password-from
+func withCredentials(clusterURL string) (string, error) {
+	clusterURL, err := withVaultCredentials(clusterURL)
+	if err != nil {
+		return "", err
+	}
+
+	if passwordFrom == "" {
+		return clusterURL, nil
+	}
+
+	clients, err := aws.NewClients("", awsOptions())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to initialize AWS service clients")
+	}
+
+	password, err := clients.RetrievePassword(passwordFrom)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve Elasticsearch password")
+	}
+
+	clusterURL, err = es.SetPassword(clusterURL, password)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	return clusterURL, nil
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/pagerduty"
+)
+
+// defaultPagerDutyWindow represents the default duration of PagerDuty maintenance window
+const defaultPagerDutyWindow = 2 * time.Hour
+
+// openMaintenanceWindow creates PagerDuty maintenance window of --pagerduty-service during the operation
+// The returned function ends the window. If esnctl dies before that, the window ends after --pagerduty-window
+func openMaintenanceWindow(op *operation.Operation, opts operationOptions) (func(), error) {
+	if opts.pagerDutyWindow <= 0 {
+		return nil, exitcode.Errorf(exitcode.Validation, "PagerDuty maintenance window (--pagerduty-window) must be positive. got: %s", opts.pagerDutyWindow)
+	}
+
+	if mock {
+		log.Println("===> Skipping PagerDuty maintenance window with --mock")
+		return func() {}, nil
+	}
+
+	client, err := pagerduty.NewFromEnv()
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	description := fmt.Sprintf("esnctl %s on %s (operation %s)", op.Command, op.Cluster, op.ID)
+
+	mw, err := client.CreateMaintenanceWindow(opts.pagerDutyServices, description, opts.pagerDutyWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	op.Logf("===> Opened PagerDuty maintenance window %s until %s\n", mw.ID, mw.EndTime.Local().Format(time.RFC3339))
+
+	return func() {
+		if err := client.EndMaintenanceWindow(mw.ID); err != nil {
+			log.Println(err)
+			return
+		}
+
+		op.Logf("===> Closed PagerDuty maintenance window %s\n", mw.ID)
+	}, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Make operation plan to review before applying",
+}
+
+// planRemoveCmd represents the plan remove command
+var planRemoveCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "remove",
+	Short:         "Make node removal plan",
+	RunE:          doPlanRemove,
+}
+
+var planRemoveOpts = struct {
+	autoScalingGroup string
+	clusterURL       string
+	nodeName         string
+	output           string
+	region           string
+}{}
+
+func doPlanRemove(cmd *cobra.Command, args []string) error {
+	if planRemoveOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if planRemoveOpts.autoScalingGroup == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group) must be specified")
+	}
+
+	if planRemoveOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch Node (--node-name) name must be specified")
+	}
+
+	w, err := newWorkflow(planRemoveOpts.clusterURL, planRemoveOpts.region)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	p, err := w.PlanRemoval(ctx, workflow.RemoveOptions{
+		Group:     planRemoveOpts.autoScalingGroup,
+		NodeName:  planRemoveOpts.nodeName,
+		Operation: operation.New("plan", planRemoveOpts.clusterURL),
+	})
+	if err != nil {
+		return err
+	}
+
+	if planRemoveOpts.output == "" {
+		return p.Write(os.Stdout)
+	}
+
+	f, err := os.Create(planRemoveOpts.output)
+	if err != nil {
+		return errors.Wrap(err, "failed to create plan file")
+	}
+	defer f.Close()
+
+	return p.Write(f)
+}
+
+func init() {
+	RootCmd.AddCommand(planCmd)
+	planCmd.AddCommand(planRemoveCmd)
+
+	planRemoveCmd.Flags().StringVar(&planRemoveOpts.autoScalingGroup, "group", "", "Auto Scaling Group")
+	planRemoveCmd.Flags().StringVar(&planRemoveOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	planRemoveCmd.Flags().StringVar(&planRemoveOpts.nodeName, "node-name", "", "Elasticsearch node name to remove")
+	planRemoveCmd.Flags().StringVarP(&planRemoveOpts.output, "output", "o", "", "File to write plan (default: stdout)")
+	planRemoveCmd.Flags().StringVar(&planRemoveOpts.region, "region", "", "AWS region")
+
+	markFlagCompletion(planRemoveCmd.Flags(), "group")
+	markFlagCompletion(planRemoveCmd.Flags(), "node-name")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// expandAlias replaces alias defined in configuration file with the command line it stands for
+// Built-in commands take precedence over aliases, and arguments after alias are appended to the expanded command line
+func expandAlias(args []string) ([]string, error) {
+	i := commandArgIndex(args)
+	if i < 0 {
+		return args, nil
+	}
+
+	name := args[i]
+
+	for _, c := range RootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return args, nil
+		}
+	}
+
+	c, err := config.Load(configPathFromArgs(args))
+	if err != nil {
+		// Reported by initConfig later
+		return args, nil
+	}
+
+	expanded, ok, err := c.ExpandAlias(name)
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	if !ok {
+		return args, nil
+	}
+
+	result := append([]string{}, args[:i]...)
+	result = append(result, expanded...)
+	result = append(result, args[i+1:]...)
+
+	return result, nil
+}
+
+// commandArgIndex returns index of the first argument which is neither a global flag nor its value
+func commandArgIndex(args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			return -1
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return i
+		}
+
+		if strings.Contains(arg, "=") {
+			continue
+		}
+
+		name := strings.TrimLeft(arg, "-")
+
+		f := RootCmd.PersistentFlags().Lookup(name)
+		if f == nil && len(name) == 1 && !strings.HasPrefix(arg, "--") {
+			f = RootCmd.PersistentFlags().ShorthandLookup(name)
+		}
+
+		// Flag taking value, e.g. --config <path>
+		if f != nil && f.NoOptDefVal == "" {
+			i++
+		}
+	}
+
+	return -1
+}
+
+// configPathFromArgs returns the value of --config before flags are parsed
+func configPathFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		if strings.HasPrefix(arg, "--config=") {
+			return strings.TrimPrefix(arg, "--config=")
+		}
+
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+
+	return config.DefaultPath()
+}
+
+// applyDefaults sets flags of the command to defaults in configuration file unless given in command line
+func applyDefaults(cmd *cobra.Command, args []string) error {
+	path := commandKey(cmd)
+
+	defaults := cfg.Defaults[path]
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(defaults))
+
+	for name := range defaults {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := cmd.Flags().Lookup(name)
+		if f == nil {
+			return exitcode.Errorf(exitcode.Validation, "defaults of %q in %s: unknown flag --%s", path, cfgFile, name)
+		}
+
+		if f.Changed {
+			continue
+		}
+
+		if err := cmd.Flags().Set(name, defaults[name]); err != nil {
+			return exitcode.Wrap(errors.Wrapf(err, "defaults of %q in %s: invalid value of --%s", path, cfgFile, name), exitcode.Validation)
+		}
+	}
+
+	return nil
+}
+
+// commandKey returns command path without root command, e.g. "snapshot create"
+func commandKey(cmd *cobra.Command) string {
+	return strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()), " ")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/metrics"
+)
+
+// collectPrometheusMetrics updates cluster gauges of the collector every interval until ctx is done
+// Gauges of the cluster keep the last value if its state cannot be retrieved
+func collectPrometheusMetrics(ctx context.Context, collector *metrics.Collector, clusters map[string]*config.Cluster, interval time.Duration) {
+	names := make([]string, 0, len(clusters))
+
+	for name := range clusters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for {
+		for _, name := range names {
+			state, err := collectClusterState(clusters[name])
+			if err != nil {
+				log.Printf("failed to collect metrics of cluster %s: %s\n", name, err)
+				continue
+			}
+
+			collector.SetCluster(name, state)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
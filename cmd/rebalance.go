@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// defaultRebalanceTolerance represents the default acceptable difference of shards between nodes
+// Elasticsearch balances shards per index too, so the total rarely becomes exactly even
+const defaultRebalanceTolerance = 2
+
+// rebalanceCmd represents the rebalance command
+var rebalanceCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "rebalance",
+	Short:         "Rebalance shards and wait until they are spread evenly",
+	RunE:          doRebalance,
+}
+
+var rebalanceOpts = struct {
+	clusterURL  string
+	concurrency int
+	tolerance   int
+	operationOptions
+}{}
+
+func doRebalance(cmd *cobra.Command, args []string) error {
+	if rebalanceOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	// AWS resources are not touched, so region is not needed
+	w, err := newWorkflow(rebalanceOpts.clusterURL, "")
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("rebalance", rebalanceOpts.clusterURL)
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, rebalanceOpts.operationOptions, func(ctx context.Context) error {
+		return w.Rebalance(ctx, workflow.RebalanceOptions{
+			Concurrency: rebalanceOpts.concurrency,
+			Tolerance:   rebalanceOpts.tolerance,
+			Operation:   op,
+		})
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(rebalanceCmd)
+
+	rebalanceCmd.Flags().StringVar(&rebalanceOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	rebalanceCmd.Flags().IntVar(&rebalanceOpts.concurrency, "concurrency", 0, "Temporary cluster_concurrent_rebalance during rebalancing (0 keeps the current value)")
+	rebalanceCmd.Flags().IntVar(&rebalanceOpts.tolerance, "tolerance", defaultRebalanceTolerance, "Acceptable difference of the number of shards between nodes")
+	rebalanceOpts.operationOptions.addFlags(rebalanceCmd)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dtan4/esnctl/cost"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/recommend"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// recommendCmd represents the recommend command
+var recommendCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "recommend",
+	Short:         "Recommend scaling in, scaling out or changing instance type from utilization of nodes",
+	RunE:          doRecommend,
+}
+
+var recommendOpts = struct {
+	autoScalingGroups []string
+	clusterURL        string
+	groupTag          string
+	output            string
+	prices            string
+	region            string
+}{}
+
+func doRecommend(cmd *cobra.Command, args []string) error {
+	if recommendOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if len(recommendOpts.autoScalingGroups) == 0 && recommendOpts.groupTag == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group or --group-tag) must be specified")
+	}
+
+	if recommendOpts.output != "text" && recommendOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", recommendOpts.output)
+	}
+
+	prices := cost.DefaultPrices
+
+	if recommendOpts.prices != "" {
+		data, err := ioutil.ReadFile(recommendOpts.prices)
+		if err != nil {
+			return exitcode.Wrap(errors.Wrap(err, "failed to read prices"), exitcode.Validation)
+		}
+
+		prices, err = cost.ParsePrices(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := newWorkflow(recommendOpts.clusterURL, recommendOpts.region)
+	if err != nil {
+		return err
+	}
+
+	groups, err := w.ResolveGroups(recommendOpts.autoScalingGroups, recommendOpts.groupTag)
+	if err != nil {
+		return err
+	}
+
+	instances, err := w.DescribeGroupInstances(groups)
+	if err != nil {
+		return err
+	}
+
+	stats, err := w.ES.NodeStats()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve node stats")
+	}
+
+	// Instances which have not joined the cluster yet have no utilization, and nodes outside of the groups are not sized here
+	nodes := []*recommend.Node{}
+
+	for _, i := range instances {
+		for _, s := range stats {
+			if s.Name != i.PrivateDNS {
+				continue
+			}
+
+			nodes = append(nodes, &recommend.Node{
+				Name:         s.Name,
+				InstanceType: i.InstanceType,
+				CPUPercent:   s.CPUPercent,
+				HeapPercent:  s.HeapPercent,
+				DiskPercent:  s.DiskPercent,
+			})
+		}
+	}
+
+	r, err := recommend.New(nodes, prices)
+	if err != nil {
+		return err
+	}
+
+	if recommendOpts.output == "json" {
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode recommendation")
+		}
+
+		fmt.Println(string(b))
+
+		return nil
+	}
+
+	r.Render(os.Stdout)
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(recommendCmd)
+
+	recommendCmd.Flags().StringVar(&recommendOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	recommendCmd.Flags().StringSliceVar(&recommendOpts.autoScalingGroups, "group", []string{}, "Auto Scaling Group (repeat for clusters spread over groups)")
+	recommendCmd.Flags().StringVar(&recommendOpts.groupTag, "group-tag", "", "Select Auto Scaling Groups by tag in key=value format")
+	recommendCmd.Flags().StringVar(&recommendOpts.output, "output", "text", "Output format (text, json)")
+	recommendCmd.Flags().StringVar(&recommendOpts.prices, "prices", "", "JSON file of hourly prices by instance type, overriding the bundled on-demand prices of us-east-1")
+	recommendCmd.Flags().StringVar(&recommendOpts.region, "region", "", "AWS region")
+
+	markFlagCompletion(recommendCmd.Flags(), "group")
+}
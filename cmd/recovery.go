@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/recovery"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// recoveryCmd represents the recovery command
+var recoveryCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "recovery",
+	Short:         "Show active shard recoveries with throughput per node",
+	RunE:          doRecovery,
+}
+
+var recoveryOpts = struct {
+	clusterURL string
+	output     string
+}{}
+
+func doRecovery(cmd *cobra.Command, args []string) error {
+	if recoveryOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if recoveryOpts.output != "text" && recoveryOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", recoveryOpts.output)
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "esnctl recovery cannot be used with --mock")
+	}
+
+	httpClient, err := newHTTPClient(recoveryOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	clusterURL, err := withCredentials(recoveryOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	recoveries, err := es.ActiveRecoveries(clusterURL, httpClient)
+	if err != nil {
+		return err
+	}
+
+	report := recovery.New(recoveries)
+
+	if recoveryOpts.output == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode report")
+		}
+
+		fmt.Println(string(b))
+
+		return nil
+	}
+
+	report.Render(os.Stdout)
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(recoveryCmd)
+
+	recoveryCmd.Flags().StringVar(&recoveryOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	recoveryCmd.Flags().StringVar(&recoveryOpts.output, "output", "text", "Output format (text, json)")
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// reindexCmd represents the reindex command
+var reindexCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "reindex",
+	Short:         "Copy documents into another index with progress, throttling and retry",
+	RunE:          doReindex,
+}
+
+// reindexRethrottleCmd represents the reindex rethrottle command
+var reindexRethrottleCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "rethrottle TASK_ID",
+	Short:         "Change requests per second of running reindex task",
+	RunE:          doReindexRethrottle,
+}
+
+var reindexOpts = struct {
+	clusterURL        string
+	conflicts         string
+	dest              string
+	requestsPerSecond float64
+	retries           int
+	slices            string
+	source            string
+	sourceURL         string
+	operationOptions
+}{}
+
+func doReindex(cmd *cobra.Command, args []string) error {
+	if reindexOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if reindexOpts.source == "" {
+		return exitcode.New(exitcode.Validation, "Source index (--source) must be specified")
+	}
+
+	if reindexOpts.dest == "" {
+		return exitcode.New(exitcode.Validation, "Destination index (--dest) must be specified")
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "reindex is not supported by fake cluster (--mock)")
+	}
+
+	sourceURL := reindexOpts.sourceURL
+
+	if sourceURL != "" {
+		var err error
+
+		sourceURL, err = withCredentials(sourceURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	httpClient, err := newHTTPClient(reindexOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	// AWS resources are not touched, so region is not needed
+	w, err := newWorkflow(reindexOpts.clusterURL, "")
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("reindex", reindexOpts.clusterURL)
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, reindexOpts.operationOptions, func(ctx context.Context) error {
+		return w.Reindex(ctx, workflow.ReindexOptions{
+			Source:            reindexOpts.source,
+			Dest:              reindexOpts.dest,
+			SourceURL:         sourceURL,
+			Slices:            reindexOpts.slices,
+			RequestsPerSecond: reindexOpts.requestsPerSecond,
+			Conflicts:         reindexOpts.conflicts,
+			Retries:           reindexOpts.retries,
+			HTTPClient:        httpClient,
+			Operation:         op,
+		})
+	})
+}
+
+func doReindexRethrottle(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "task ID must be specified")
+	}
+
+	if reindexOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	httpClient, err := newHTTPClient(reindexOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	clusterURL, err := withCredentials(reindexOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	if err := es.RethrottleReindex(clusterURL, httpClient, args[0], reindexOpts.requestsPerSecond); err != nil {
+		return err
+	}
+
+	if reindexOpts.requestsPerSecond > 0 {
+		log.Printf("===> Task %s is throttled to %g requests per second\n", args[0], reindexOpts.requestsPerSecond)
+	} else {
+		log.Printf("===> Task %s is unthrottled\n", args[0])
+	}
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(reindexCmd)
+	reindexCmd.AddCommand(reindexRethrottleCmd)
+
+	reindexCmd.PersistentFlags().StringVar(&reindexOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	reindexCmd.PersistentFlags().Float64Var(&reindexOpts.requestsPerSecond, "requests-per-second", 0, "Throttle reindex to this many documents per second (default: unlimited)")
+
+	reindexCmd.Flags().StringVar(&reindexOpts.conflicts, "conflicts", "proceed", "What to do on version conflicts (abort, proceed)")
+	reindexCmd.Flags().StringVar(&reindexOpts.dest, "dest", "", "Destination index")
+	reindexCmd.Flags().IntVar(&reindexOpts.retries, "retries", 3, "How many times failed reindex is retried for missing documents")
+	reindexCmd.Flags().StringVar(&reindexOpts.slices, "slices", "", "Number of slices to parallelize reindex, or auto")
+	reindexCmd.Flags().StringVar(&reindexOpts.source, "source", "", "Source index")
+	reindexCmd.Flags().StringVar(&reindexOpts.sourceURL, "source-url", "", "Remote Elasticsearch cluster URL to read source index from")
+	reindexOpts.operationOptions.addFlags(reindexCmd)
+}
@@ -1,22 +1,16 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"net/http"
 	"time"
 
-	"github.com/dtan4/esnctl/aws"
-	"github.com/dtan4/esnctl/es"
-	"github.com/pkg/errors"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
 	"github.com/spf13/cobra"
 )
 
-const (
-	removeMaxRetry     = 60
-	removeSleepSeconds = 5
-)
-
 // removeCmd represents the remove command
 var removeCmd = &cobra.Command{
 	SilenceErrors: true,
@@ -27,143 +21,181 @@ var removeCmd = &cobra.Command{
 }
 
 var removeOpts = struct {
+	alias             string
+	autoScalingGroups []string
+	clusterURL        string
+	fixIndexFilters   bool
+	force             bool
+	groupTag          string
+	keepCapacity      bool
+	nodeName          string
+	region            string
+	rolloverFirst     bool
+	tagScale          bool
+	terminate         bool
+	warmPool          bool
+	operationOptions
+	slmWindowOptions
+
+	// autoScalingGroup is the group which the instance of the node belongs to, resolved by resolveRemoveGroup
 	autoScalingGroup string
-	clusterURL       string
-	nodeName         string
-	region           string
 }{}
 
 func doRemove(cmd *cobra.Command, args []string) error {
-	if removeOpts.clusterURL == "" {
-		return errors.New("Elasticsearch cluster URL (--cluster-url) must be specified")
+	if err := resumeRemove(); err != nil {
+		return err
 	}
 
-	if removeOpts.autoScalingGroup == "" {
-		return errors.New("Auto Scaling Group (--group) must be specified")
+	// Node can be selected interactively on terminal
+	if err := validateRemoveOpts(!isTerminal()); err != nil {
+		return err
 	}
 
-	if removeOpts.nodeName == "" {
-		return errors.New("Elasticsearch Node (--node-name) name must be specified")
+	if removeOpts.rolloverFirst && removeOpts.alias == "" {
+		return exitcode.New(exitcode.Validation, "write alias (--alias) must be specified with --rollover-first")
 	}
 
-	httpClient := &http.Client{}
+	rolloverAlias := ""
+	if removeOpts.rolloverFirst {
+		rolloverAlias = removeOpts.alias
+	}
 
-	client, err := es.New(removeOpts.clusterURL, httpClient)
+	w, err := newWorkflow(removeOpts.clusterURL, removeOpts.region)
 	if err != nil {
-		return errors.Wrap(err, "failed to create Elasitcsearch API client")
+		return err
 	}
 
-	if err := aws.Initialize(removeOpts.region); err != nil {
-		return errors.Wrap(err, "failed to initialize AWS service clients")
+	if err := resolveRemoveGroup(w); err != nil {
+		return err
 	}
 
-	log.Println("===> Retrieving target instance ID...")
+	if removeOpts.nodeName == "" {
+		nodeName, err := pickNode(w, removeOpts.autoScalingGroup)
+		if err != nil {
+			return err
+		}
 
-	instanceID, err := aws.EC2.RetrieveInstanceIDFromPrivateDNS(removeOpts.nodeName)
-	if err != nil {
-		return errors.Wrap(err, "failed to retrieve instance ID")
+		removeOpts.nodeName = nodeName
 	}
 
-	log.Println("===> Retrieving target group...")
-
-	targetGroupARN, err := aws.AutoScaling.RetrieveTargetGroup(removeOpts.autoScalingGroup)
-	if err != nil {
-		return errors.Wrap(err, "failed to retrieve target group")
+	if removeOpts.force {
+		log.Printf("===> WARNING: --force skips shard drain of %s. Shards without replica on other nodes will be lost\n", removeOpts.nodeName)
 	}
 
-	log.Println("===> Detaching instance from target group...")
+	op := operation.New("remove", removeOpts.clusterURL)
+	op.Group = removeOpts.autoScalingGroup
+	op.Node = removeOpts.nodeName
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, removeOpts.operationOptions, func(ctx context.Context) error {
+		return w.RemoveNode(ctx, workflow.RemoveOptions{
+			Group:               removeOpts.autoScalingGroup,
+			NodeName:            removeOpts.nodeName,
+			Force:               removeOpts.force,
+			FixIndexFilters:     removeOpts.fixIndexFilters,
+			RolloverAlias:       rolloverAlias,
+			TerminateInstance:   removeOpts.terminate,
+			ReturnToWarmPool:    removeOpts.warmPool,
+			KeepDesiredCapacity: removeOpts.keepCapacity,
+			TagScale:            removeOpts.tagScale,
+			Operation:           op,
+			SLMWindow:           removeOpts.slmWindowOptions.window,
+			RespectSLMWindow:    removeOpts.slmWindowOptions.respect,
+		})
+	})
+}
 
-	if err := aws.ELBv2.DetachInstance(targetGroupARN, instanceID); err != nil {
-		return errors.Wrap(err, "failed to detach instance from target group")
+// resumeRemove takes over group and node of the prior operation given by --operation-id
+// Steps already done by the prior operation are skipped by workflow
+func resumeRemove() error {
+	prior := removeOpts.priorOperation()
+	if prior == nil {
+		return nil
 	}
 
-	log.Println("===> Waiting for connection draining...")
-
-	retryCount := 0
-
-	for {
-		instances, err := aws.ELBv2.ListTargetInstances(targetGroupARN)
-		if err != nil {
-			return errors.Wrap(err, "failed to list instances attached to target group")
-		}
-
-		found := false
+	if prior.Command != "remove" {
+		return exitcode.Errorf(exitcode.Validation, "operation %s is not remove but %s", prior.ID, prior.Command)
+	}
 
-		for _, instance := range instances {
-			if instance == instanceID {
-				found = true
-				break
-			}
-		}
+	if len(removeOpts.autoScalingGroups) == 0 && removeOpts.groupTag == "" {
+		removeOpts.autoScalingGroups = []string{prior.Group}
+	}
 
-		if !found {
-			fmt.Print("\n")
-			break
-		}
+	if removeOpts.nodeName == "" {
+		removeOpts.nodeName = prior.Node
+	}
 
-		fmt.Print(".")
+	log.Printf("===> Resuming operation %s started at %s (result: %s)\n", prior.ID, prior.StartedAt.Local().Format(time.RFC3339), prior.Result)
 
-		if retryCount == removeMaxRetry {
-			return errors.New("timed out: instance still remains on target group")
-		}
+	return nil
+}
 
-		retryCount++
-		time.Sleep(removeSleepSeconds * time.Second)
+// resolveRemoveGroup resolves --group and --group-tag into the group which the instance of the node belongs to
+// Node must be given if multiple groups are resolved
+func resolveRemoveGroup(w *workflow.Workflow) error {
+	groups, err := w.ResolveGroups(removeOpts.autoScalingGroups, removeOpts.groupTag)
+	if err != nil {
+		return err
 	}
 
-	log.Println("===> Excluding target node from shard allocation group...")
-
-	if err := client.ExcludeNodeFromAllocation(removeOpts.nodeName); err != nil {
-		return errors.Wrap(err, "failed to exclude node from allocation group")
+	if len(groups) == 1 {
+		removeOpts.autoScalingGroup = groups[0]
+		return nil
 	}
 
-	log.Println("===> Waiting for shards escape from target node...")
-
-	retryCount = 0
-
-	for {
-		shards, err := client.ListShardsOnNode(removeOpts.nodeName)
-		if err != nil {
-			return errors.Wrap(err, "failed to list shards on the given node")
-		}
+	if removeOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch Node (--node-name) name must be specified with multiple Auto Scaling Groups")
+	}
 
-		if len(shards) == 0 {
-			fmt.Print("\n")
-			break
-		}
+	group, err := w.GroupOfNode(removeOpts.nodeName, groups)
+	if err != nil {
+		return err
+	}
 
-		fmt.Print(".")
+	removeOpts.autoScalingGroup = group
 
-		if retryCount == removeMaxRetry {
-			return errors.New("timed out: shards do not escaped from the given node")
-		}
+	return nil
+}
 
-		retryCount++
-		time.Sleep(removeSleepSeconds * time.Second)
+func validateRemoveOpts(requireNodeName bool) error {
+	if removeOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
 	}
 
-	log.Println("===> Shutting down target node...")
-
-	if err := client.Shutdown(removeOpts.nodeName); err != nil {
-		return errors.Wrap(err, "failed to shutdown node")
+	if len(removeOpts.autoScalingGroups) == 0 && removeOpts.groupTag == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group or --group-tag) must be specified")
 	}
 
-	log.Println("===> Detaching target instance...")
-
-	if err := aws.AutoScaling.DetachInstance(removeOpts.autoScalingGroup, instanceID); err != nil {
-		return errors.Wrap(err, "failed to detach instance from AutoScaling Group")
+	if requireNodeName && removeOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch Node (--node-name) name must be specified")
 	}
 
-	log.Println("===> Finished!")
-
 	return nil
 }
 
 func init() {
 	RootCmd.AddCommand(removeCmd)
 
-	removeCmd.Flags().StringVar(&removeOpts.autoScalingGroup, "group", "", "Auto Scaling Group")
-	removeCmd.Flags().StringVar(&removeOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL")
-	removeCmd.Flags().StringVar(&removeOpts.nodeName, "node-name", "", "Elasticsearch node name to remove")
-	removeCmd.Flags().StringVar(&removeOpts.region, "region", "", "AWS region")
+	// Persistent flags are shared with step subcommands
+	removeCmd.PersistentFlags().StringSliceVar(&removeOpts.autoScalingGroups, "group", []string{}, "Auto Scaling Group (repeat if the cluster spreads over several groups)")
+	removeCmd.PersistentFlags().StringVar(&removeOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	removeCmd.PersistentFlags().StringVar(&removeOpts.groupTag, "group-tag", "", "Select Auto Scaling Groups by tag in key=value format")
+	removeCmd.PersistentFlags().BoolVar(&removeOpts.keepCapacity, "keep-desired-capacity", false, "Detach instance without decrementing desired capacity, so that Auto Scaling Group launches replacement")
+	removeCmd.PersistentFlags().StringVar(&removeOpts.nodeName, "node-name", "", "Elasticsearch node name to remove (selected interactively on terminal if omitted)")
+	removeCmd.PersistentFlags().StringVar(&removeOpts.region, "region", "", "AWS region")
+	removeCmd.PersistentFlags().BoolVar(&removeOpts.tagScale, "tag-scale", false, "Record the new desired capacity in esnctl:last-scale tag of Auto Scaling Group")
+	removeOpts.operationOptions.addFlags(removeCmd)
+	removeOpts.slmWindowOptions.addFlags(removeCmd)
+
+	removeCmd.Flags().StringVar(&removeOpts.alias, "alias", "", "Write alias rolled over with --rollover-first")
+	removeCmd.Flags().BoolVar(&removeOpts.fixIndexFilters, "fix-index-filters", false, "Reset index allocation filters pinning shards to the node before drain")
+	removeCmd.Flags().BoolVar(&removeOpts.force, "force", false, "Skip shard drain even if node is still in the cluster, e.g. partitioned one")
+	removeCmd.Flags().BoolVar(&removeOpts.rolloverFirst, "rollover-first", false, "Roll over --alias before drain if its write index has primaries on the node")
+	removeCmd.Flags().BoolVar(&removeOpts.terminate, "terminate", false, "Terminate instance after detaching it")
+	removeCmd.Flags().BoolVar(&removeOpts.warmPool, "warm-pool", false, "Return instance to warm pool of Auto Scaling Group instead of detaching it")
+
+	markFlagCompletion(removeCmd.PersistentFlags(), "group")
+	markFlagCompletion(removeCmd.PersistentFlags(), "node-name")
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// removeStepCmds represents subcommands which execute one step of node removal
+// Every step exits successfully if it has already been done, so that external orchestrators can retry them
+var removeStepCmds = []*cobra.Command{
+	newRemoveStepCmd(workflow.StepDetachLB),
+	newRemoveStepCmd(workflow.StepWaitLB),
+	newRemoveStepCmd(workflow.StepExclude),
+	newRemoveStepCmd(workflow.StepWaitDrain),
+	newRemoveStepCmd(workflow.StepShutdown),
+	newRemoveStepCmd(workflow.StepWaitML),
+	newRemoveStepCmd(workflow.StepDetachASG),
+}
+
+func newRemoveStepCmd(step string) *cobra.Command {
+	return &cobra.Command{
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		Use:           step,
+		Short:         workflow.RemoveStepDescription(step),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doRemoveStep(step)
+		},
+	}
+}
+
+func doRemoveStep(step string) error {
+	if err := validateRemoveOpts(true); err != nil {
+		return err
+	}
+
+	w, err := newWorkflow(removeOpts.clusterURL, removeOpts.region)
+	if err != nil {
+		return err
+	}
+
+	if err := resolveRemoveGroup(w); err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	s, err := w.RemoveStep(ctx, &workflow.RemoveState{
+		Group:               removeOpts.autoScalingGroup,
+		NodeName:            removeOpts.nodeName,
+		Step:                workflow.StepResolve,
+		KeepDesiredCapacity: removeOpts.keepCapacity,
+		TagScale:            removeOpts.tagScale,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Step = step
+
+	log.Printf("===> %s...\n", workflow.RemoveStepDescription(step))
+
+	next, err := w.RemoveStep(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if next.Waiting {
+		return exitcode.Errorf(exitcode.Pending, "not finished yet: %s", workflow.RemoveStepDescription(step))
+	}
+
+	if next.Skipped {
+		log.Printf("===> %s\n", workflow.SkippedMessage)
+	}
+
+	log.Println("===> Finished!")
+
+	return nil
+}
+
+func init() {
+	for _, cmd := range removeStepCmds {
+		removeCmd.AddCommand(cmd)
+	}
+}
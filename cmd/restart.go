@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+const defaultDelayedTimeout = 5 * time.Minute
+
+// restartCmd represents the restart command
+var restartCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "restart",
+	Short:         "Reboot instance of node without reallocating its shards elsewhere",
+	RunE:          doRestart,
+}
+
+var restartOpts = struct {
+	clusterURL     string
+	delayedTimeout time.Duration
+	nodeName       string
+	region         string
+	operationOptions
+	slmWindowOptions
+}{}
+
+func doRestart(cmd *cobra.Command, args []string) error {
+	if restartOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if restartOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch Node (--node-name) name must be specified")
+	}
+
+	w, err := newWorkflow(restartOpts.clusterURL, restartOpts.region)
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("restart", restartOpts.clusterURL)
+	op.Node = restartOpts.nodeName
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, restartOpts.operationOptions, func(ctx context.Context) error {
+		return w.RestartNode(ctx, workflow.RestartOptions{
+			NodeName:         restartOpts.nodeName,
+			DelayedTimeout:   restartOpts.delayedTimeout,
+			SLMWindow:        restartOpts.slmWindowOptions.window,
+			RespectSLMWindow: restartOpts.slmWindowOptions.respect,
+			Operation:        op,
+		})
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(restartCmd)
+
+	restartCmd.Flags().StringVar(&restartOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	restartCmd.Flags().DurationVar(&restartOpts.delayedTimeout, "delayed-timeout", defaultDelayedTimeout, "How long to delay reallocation of shards on the node while it is away")
+	restartCmd.Flags().StringVar(&restartOpts.nodeName, "node-name", "", "Elasticsearch node name to restart")
+	restartCmd.Flags().StringVar(&restartOpts.region, "region", "", "AWS region")
+	restartOpts.operationOptions.addFlags(restartCmd)
+	restartOpts.slmWindowOptions.addFlags(restartCmd)
+
+	markFlagCompletion(restartCmd.Flags(), "node-name")
+}
@@ -1,36 +1,210 @@
 package cmd
 
 import (
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"time"
 
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/logfile"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// defaultMaxAPIRetries represents the default number of retries for transient Elasticsearch and AWS API errors
+	defaultMaxAPIRetries = 5
+	// defaultRequestTimeout represents the default timeout of each Elasticsearch and AWS API call
+	defaultRequestTimeout = 30 * time.Second
+)
+
+var (
+	awsMaxRPS         float64
+	cfg               *config.Config
+	cfgFile           string
+	executedCmd       *cobra.Command
+	headers           []string
+	joinTimeout       time.Duration
+	lbDrainTimeout    time.Duration
+	logFile           string
+	logFileWriter     *logfile.Writer
+	logMaxBackups     int
+	logMaxSize        int
+	maxAPIRetries     int
+	maxPoll           time.Duration
+	minPoll           time.Duration
+	mlTimeout         time.Duration
+	mock              bool
+	noColor           bool
+	operationTimeout  time.Duration
+	passwordFrom      string
+	proxyURL          string
+	quiet             bool
+	requestTimeout    time.Duration
+	roleARN           string
+	shardDrainTimeout time.Duration
+	sniff             bool
+	traceLogger       *log.Logger
+	tunnelSpec        string
+	vaultPath         string
+)
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-	Use:   "esnctl",
-	Short: "A brief description of your application",
+	Use:               "esnctl",
+	Short:             "A brief description of your application",
+	PersistentPreRunE: persistentPreRun,
 }
 
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
+	args, err := expandAlias(os.Args[1:])
+	if err == nil {
+		RootCmd.SetArgs(args)
+		err = RootCmd.Execute()
+	}
+
+	reportTelemetry(err)
+
+	closeTunnels()
+
+	if err != nil {
+		// Errors are shown even with --quiet
+		log.SetOutput(withLogFile(os.Stderr))
+
 		if trace := os.Getenv("TRACE"); trace == "1" {
 			log.Printf("%+v\n", err)
 		} else {
 			log.Println(err)
 		}
 
-		os.Exit(1)
+		closeLogFile()
+		os.Exit(exitcode.Code(err))
 	}
+
+	closeLogFile()
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initOutput)
+
+	RootCmd.PersistentFlags().Float64Var(&awsMaxRPS, "aws-max-rps", 0, "Maximum number of AWS API requests per second shared by all AWS clients (0 means no limit)")
+	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", config.DefaultPath(), "Configuration file")
+	RootCmd.PersistentFlags().StringArrayVar(&headers, "header", []string{}, "Extra header added to every Elasticsearch API request, in \"Name: value\" format (can be repeated)")
+	RootCmd.PersistentFlags().DurationVar(&joinTimeout, "join-timeout", 10*time.Minute, "How long to wait for added nodes to join the cluster")
+	RootCmd.PersistentFlags().DurationVar(&lbDrainTimeout, "lb-drain-timeout", 5*time.Minute, "How long to wait for connection draining of target group")
+	RootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Duplicate all output including debug-level API traces to the given file")
+	RootCmd.PersistentFlags().IntVar(&logMaxBackups, "log-max-backups", 5, "Number of rotated log files to keep")
+	RootCmd.PersistentFlags().IntVar(&logMaxSize, "log-max-size", 100, "Size in megabytes at which log file is rotated (0 disables rotation)")
+	RootCmd.PersistentFlags().IntVar(&maxAPIRetries, "max-api-retries", defaultMaxAPIRetries, "Maximum number of retries for throttled or failed (429/5xx) Elasticsearch and AWS API calls")
+	RootCmd.PersistentFlags().DurationVar(&maxPoll, "max-poll", 30*time.Second, "Upper limit of interval between status checks while waiting")
+	RootCmd.PersistentFlags().DurationVar(&minPoll, "min-poll", 2*time.Second, "Interval of the first status checks while waiting, backed off up to --max-poll while nothing changes")
+	RootCmd.PersistentFlags().DurationVar(&mlTimeout, "ml-timeout", 5*time.Minute, "How long to wait for machine learning jobs of node being removed to be reassigned to other nodes")
+	RootCmd.PersistentFlags().BoolVar(&mock, "mock", false, "Operate in-memory fake cluster instead of real Elasticsearch and AWS (for testing)")
+	RootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (NO_COLOR environment variable is also respected)")
+	RootCmd.PersistentFlags().DurationVar(&operationTimeout, "operation-timeout", 0, "Deadline of the whole operation (0 means no deadline)")
+	RootCmd.PersistentFlags().StringVar(&passwordFrom, "password-from", "", "Retrieve Elasticsearch password from \"ssm:<parameter name>\" or Secrets Manager ARN (username must be in --cluster-url)")
+	RootCmd.PersistentFlags().StringVar(&proxyURL, "proxy-url", "", "Proxy URL used to call Elasticsearch API (default: HTTP_PROXY, HTTPS_PROXY and NO_PROXY)")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress phase banners and progress output, show errors only")
+	RootCmd.PersistentFlags().StringVar(&roleARN, "role-arn", "", "IAM role assumed to call AWS API, e.g. for cluster in another account (role_arn in config file for named clusters)")
+	RootCmd.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", defaultRequestTimeout, "Timeout of each Elasticsearch and AWS API call (0 means no timeout)")
+	RootCmd.PersistentFlags().DurationVar(&shardDrainTimeout, "shard-drain-timeout", 5*time.Minute, "How long to wait for shards to escape from node, e.g. hours for nodes with large shards")
+	RootCmd.PersistentFlags().BoolVar(&sniff, "sniff", false, "Discover nodes from _nodes/http and distribute Elasticsearch API requests among them")
+	RootCmd.PersistentFlags().StringVar(&tunnelSpec, "tunnel", "", "Reach Elasticsearch through port forward, \"ssm:<instance ID>\" or \"ssh:[user@]<host>\"")
+	RootCmd.PersistentFlags().StringVar(&vaultPath, "vault-path", "", "Read Elasticsearch credentials from Vault secret at the given path (with VAULT_ADDR and VAULT_TOKEN)")
+
+	markFlagEnv(RootCmd.PersistentFlags(), "no-color", "NO_COLOR")
+	markFlagEnv(RootCmd.PersistentFlags(), "proxy-url", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY")
+	markFlagEnv(RootCmd.PersistentFlags(), "vault-path", "VAULT_ADDR", "VAULT_NAMESPACE", "VAULT_TOKEN")
+}
+
+// persistentPreRun runs before every command
+func persistentPreRun(cmd *cobra.Command, args []string) error {
+	executedCmd = cmd
+
+	return applyDefaults(cmd, args)
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	c, err := config.Load(cfgFile)
+	if err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+
+	cfg = c
+}
+
+// initOutput applies --quiet and --log-file to log output
+// Log file receives everything regardless of --quiet, and API requests are traced only into log file
+func initOutput() {
+	if logFile != "" {
+		w, err := logfile.New(logFile, int64(logMaxSize)*1024*1024, logMaxBackups)
+		if err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+
+		logFileWriter = w
+		traceLogger = log.New(w, "", log.LstdFlags)
+	}
+
+	var console io.Writer = os.Stderr
+
+	if quiet {
+		console = ioutil.Discard
+	}
+
+	log.SetOutput(withLogFile(console))
+}
+
+// closeLogFile closes log file opened by --log-file
+func closeLogFile() {
+	if logFileWriter != nil {
+		logFileWriter.Close()
+	}
+}
+
+// withLogFile returns writer which duplicates output to log file if --log-file is given
+func withLogFile(w io.Writer) io.Writer {
+	if logFileWriter == nil {
+		return w
+	}
+
+	return io.MultiWriter(w, logFileWriter)
+}
+
+// progressOutput returns writer which receives progress of waiting, discarding it with --quiet or --output jsonl
+// Progress lines are also written to log file, but progress bar is not
+func progressOutput() io.Writer {
+	var console io.Writer = os.Stdout
+
+	if quiet || outputFormat != outputText {
+		console = ioutil.Discard
+	}
+
+	if showProgressBar() {
+		return console
+	}
+
+	return withLogFile(console)
+}
+
+// showProgressBar returns whether progress is drawn as progress bar
+func showProgressBar() bool {
+	return !quiet && outputFormat == outputText && isCharDevice(os.Stdout)
+}
+
+// useColor returns whether output to stdout can be colored
+// Color is disabled by --no-color, NO_COLOR environment variable (https://no-color.org/) or non-terminal stdout
+func useColor() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	return isCharDevice(os.Stdout)
 }
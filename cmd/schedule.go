@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/schedule"
+	"github.com/pkg/errors"
+)
+
+// untilScheduled returns duration until --at, or 0 if it is not given or already passed
+func untilScheduled() time.Duration {
+	if scheduleAt == "" {
+		return 0
+	}
+
+	t, err := schedule.ParseTime(scheduleAt)
+	if err != nil {
+		return 0
+	}
+
+	if d := time.Until(t); d > 0 {
+		return d
+	}
+
+	return 0
+}
+
+// waitForSchedule waits until --at after checking that the operation starts in maintenance window
+// Waiting is aborted by interrupt signal
+func waitForSchedule(op *operation.Operation, opts operationOptions) error {
+	start := time.Now()
+
+	if scheduleAt != "" {
+		t, err := schedule.ParseTime(scheduleAt)
+		if err != nil {
+			return exitcode.Wrap(err, exitcode.Validation)
+		}
+
+		start = t
+	}
+
+	if err := checkMaintenanceWindow(start, opts.overrideWindow); err != nil {
+		return err
+	}
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+
+	op.Logf("===> Waiting until %s (%s) to start operation\n", start.Format(time.RFC3339), wait.Round(time.Second))
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	select {
+	case <-sig:
+		return exitcode.New(exitcode.Aborted, "interrupted while waiting for scheduled time")
+	case <-time.After(wait):
+	}
+
+	return nil
+}
+
+// checkMaintenanceWindow returns error if t is outside maintenance windows in config file, unless override is true
+func checkMaintenanceWindow(t time.Time, override bool) error {
+	if len(cfg.MaintenanceWindows) == 0 {
+		return nil
+	}
+
+	ok, err := schedule.InWindow(cfg.MaintenanceWindows, t)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrapf(err, "invalid maintenance window in %s", cfgFile), exitcode.Validation)
+	}
+
+	if ok {
+		return nil
+	}
+
+	if override {
+		log.Printf("===> WARNING: running outside maintenance windows with --override-window\n")
+		return nil
+	}
+
+	return exitcode.Errorf(exitcode.Validation, "%s is outside maintenance windows configured in %s, schedule with --at or use --override-window", t.Format(time.RFC3339), cfgFile)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/dtan4/esnctl/httpclient"
+	"github.com/dtan4/esnctl/selfupdate"
+	"github.com/dtan4/esnctl/version"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "self-update",
+	Short:         "Update esnctl to the latest release",
+	RunE:          doSelfUpdate,
+}
+
+var selfUpdateOpts = struct {
+	check bool
+	force bool
+}{}
+
+func doSelfUpdate(cmd *cobra.Command, args []string) error {
+	httpClient, err := httpclient.New(httpclient.Options{
+		MaxRetries: maxAPIRetries,
+		ProxyURL:   proxyURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := selfupdate.New(httpClient, os.Getenv("GITHUB_TOKEN"))
+
+	release, err := client.LatestRelease()
+	if err != nil {
+		return err
+	}
+
+	if release.TagName == version.Version && !selfUpdateOpts.force {
+		fmt.Printf("esnctl %s is the latest version\n", version.Version)
+		return nil
+	}
+
+	if selfUpdateOpts.check {
+		fmt.Printf("esnctl %s is available (current: %s)\n", release.TagName, version.Version)
+		return nil
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "failed to find executable path")
+	}
+
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to find executable path")
+	}
+
+	log.Printf("===> Downloading esnctl %s for %s-%s...\n", release.TagName, runtime.GOOS, runtime.GOARCH)
+
+	binary, err := client.Download(release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	if err := selfupdate.Replace(path, binary); err != nil {
+		return err
+	}
+
+	log.Printf("===> Updated %s from %s to %s\n", path, version.Version, release.TagName)
+
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateOpts.check, "check", false, "Only check whether newer version is available")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateOpts.force, "force", false, "Update even if the current version is the latest")
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/lock"
+	"github.com/dtan4/esnctl/metrics"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/schedule"
+	"github.com/dtan4/esnctl/server"
+	"github.com/dtan4/esnctl/ui"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// serverCmd represents the server command
+var serverCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "server",
+	Short:         "Run HTTP API server executing operations asynchronously",
+	RunE:          doServer,
+}
+
+var serverOpts = struct {
+	cloudWatchInterval time.Duration
+	grpcListen         string
+	listen             string
+	metricsInterval    time.Duration
+	noAuth             bool
+	onInterrupted      string
+	leaderElection     leaderElectionOptions
+	operationOptions
+}{}
+
+func doServer(cmd *cobra.Command, args []string) error {
+	if len(cfg.Clusters) == 0 {
+		return exitcode.Errorf(exitcode.Validation, "no cluster is configured in %s", cfgFile)
+	}
+
+	if scheduleAt != "" {
+		return exitcode.New(exitcode.Validation, "--at is not supported by server, request operation with \"at\" instead")
+	}
+
+	if err := schedule.Validate(cfg.MaintenanceWindows); err != nil {
+		return exitcode.Wrap(errors.Wrapf(err, "invalid maintenance window in %s", cfgFile), exitcode.Validation)
+	}
+
+	if serverOpts.cloudWatchInterval > 0 {
+		log.Printf("===> Publishing cluster metrics to CloudWatch every %s\n", serverOpts.cloudWatchInterval)
+		go publishClusterMetrics(context.Background(), cfg.Clusters, serverOpts.cloudWatchInterval)
+	}
+
+	principals, err := server.NewPrincipals(cfg.API)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrap(err, "invalid API tokens in configuration file"), exitcode.Validation)
+	}
+
+	if len(principals) == 0 {
+		if !serverOpts.noAuth {
+			return exitcode.Errorf(exitcode.Validation, "no API token is configured in %s, configure api.tokens or give --no-auth to serve API without authentication", cfgFile)
+		}
+
+		log.Println("===> WARNING: API authentication is disabled by --no-auth, so anyone can execute operations")
+	}
+
+	s := server.New(cfg, runServerOperation)
+	s.Checks = serverChecks(cfg.Clusters)
+	s.Nodes = listServerNodes
+	s.OverrideWindow = serverOpts.overrideWindow
+	s.Principals = principals
+
+	var collector *metrics.Collector
+
+	if serverOpts.metricsInterval > 0 {
+		collector = metrics.NewCollector()
+		s.Metrics = collector
+
+		log.Printf("===> Exposing Prometheus metrics at /metrics, collecting cluster metrics every %s\n", serverOpts.metricsInterval)
+		go collectPrometheusMetrics(context.Background(), collector, cfg.Clusters, serverOpts.metricsInterval)
+	}
+
+	s.Leader, err = serverOpts.leaderElection.start(context.Background(), "server", collector)
+	if err != nil {
+		return err
+	}
+
+	// Standby leaves interrupted operations to the leader, which would execute them
+	if s.Leader == nil || s.Leader() {
+		if err := handleInterrupted(s, serverOpts.onInterrupted); err != nil {
+			return err
+		}
+	}
+
+	errs := make(chan error, 2)
+
+	if serverOpts.grpcListen != "" {
+		log.Printf("Serving gRPC API on %s ...\n", serverOpts.grpcListen)
+
+		go func() {
+			errs <- s.ListenAndServeGRPC(serverOpts.grpcListen)
+		}()
+	}
+
+	log.Printf("Listening on %s ...\n", serverOpts.listen)
+
+	go func() {
+		errs <- s.ListenAndServe(serverOpts.listen)
+	}()
+
+	// Server stops when either API stops serving
+	return <-errs
+}
+
+// serverChecks returns readiness checks of the configured clusters
+// Elasticsearch must be reachable, AWS credentials (assuming role_arn if given) must be valid, and cluster lock must be readable with --lock
+func serverChecks(clusters map[string]*config.Cluster) map[string]server.CheckFunc {
+	checks := map[string]server.CheckFunc{}
+
+	for name, cluster := range clusters {
+		cluster := cluster
+
+		checks["elasticsearch/"+name] = func() error {
+			client, err := newESClient(cluster.ClusterURL)
+			if err != nil {
+				return err
+			}
+
+			_, err = client.ClusterHealth()
+
+			return err
+		}
+
+		if serverOpts.operationOptions.lock {
+			checks["lock/"+name] = func() error {
+				client, err := newESClient(cluster.ClusterURL)
+				if err != nil {
+					return err
+				}
+
+				_, err = lock.Get(client)
+
+				return err
+			}
+		}
+
+		if !mock {
+			checks["aws/"+name] = func() error {
+				return aws.ValidateCredentials(cluster.Region, clusterAWSOptions(cluster))
+			}
+		}
+	}
+
+	return checks
+}
+
+// runServerOperation executes operation requested via API
+func runServerOperation(op *operation.Operation, cluster *config.Cluster, req *server.Request) error {
+	w, err := newClusterWorkflow(cluster)
+	if err != nil {
+		return err
+	}
+
+	// Operations run one by one, but progress is interleaved with request logs of the server, so it is written as lines instead of bar redrawn in place
+	w.ProgressBar = false
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	opts := serverOpts.operationOptions
+	opts.overrideWindow = opts.overrideWindow || req.OverrideWindow
+
+	return runOperation(ctx, op, w.ES, opts, func(ctx context.Context) error {
+		switch req.Action {
+		case server.ActionAdd:
+			return w.AddNodes(ctx, workflow.AddOptions{
+				Group:     cluster.Group,
+				Count:     req.Count,
+				Operation: op,
+			})
+		case server.ActionRemove:
+			return w.RemoveNode(ctx, workflow.RemoveOptions{
+				Group:     cluster.Group,
+				NodeName:  req.NodeName,
+				Operation: op,
+			})
+		case server.ActionDrain:
+			return w.DrainNode(ctx, workflow.RemoveOptions{
+				Group:     cluster.Group,
+				NodeName:  req.NodeName,
+				Operation: op,
+			})
+		default:
+			return errors.Errorf("unsupported action %q", req.Action)
+		}
+	})
+}
+
+// listServerNodes lists nodes of the given cluster shown in dashboard
+func listServerNodes(cluster *config.Cluster) ([]*server.Node, error) {
+	w, err := newClusterWorkflow(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := ui.LoadNodes(w, cluster.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*server.Node, 0, len(list))
+
+	for _, n := range list {
+		nodes = append(nodes, &server.Node{
+			Name:             n.Name,
+			InstanceID:       n.InstanceID,
+			AvailabilityZone: n.AvailabilityZone,
+			LifecycleState:   n.LifecycleState,
+			Shards:           n.Shards,
+			DiskUsage:        n.DiskUsage,
+		})
+	}
+
+	return nodes, nil
+}
+
+func init() {
+	RootCmd.AddCommand(serverCmd)
+
+	serverCmd.Flags().DurationVar(&serverOpts.cloudWatchInterval, "cloudwatch-interval", 0, "Interval to publish health metrics of configured clusters to CloudWatch under esnctl/Cluster (0 disables)")
+	serverCmd.Flags().StringVar(&serverOpts.grpcListen, "grpc-listen", "", "Address to serve gRPC API on, e.g. :9090 (empty disables gRPC API)")
+	serverCmd.Flags().StringVar(&serverOpts.listen, "listen", ":8080", "Address to listen on")
+	serverCmd.Flags().DurationVar(&serverOpts.metricsInterval, "metrics-interval", 30*time.Second, "Interval to collect cluster metrics exposed at /metrics in Prometheus format (0 disables /metrics)")
+	serverCmd.Flags().BoolVar(&serverOpts.noAuth, "no-auth", false, "Serve API without authentication if no API token is configured, e.g. on localhost only")
+	serverCmd.Flags().StringVar(&serverOpts.onInterrupted, "on-interrupted", interruptedReport, "What to do with node removals and drains interrupted before startup, \"report\", \"resume\" or \"rollback\"")
+	serverOpts.leaderElection.addFlags(serverCmd)
+	serverOpts.operationOptions.addFlags(serverCmd)
+}
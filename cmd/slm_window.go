@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultSLMWindow = time.Hour
+
+// slmWindowOptions represents options of snapshot lifecycle management schedule check shared by add, remove and restart
+type slmWindowOptions struct {
+	respect bool
+	window  time.Duration
+}
+
+func (o *slmWindowOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.respect, "respect-slm-window", false, "Wait for snapshots of SLM policies in progress or scheduled within --slm-window to finish before operation")
+	cmd.Flags().DurationVar(&o.window, "slm-window", defaultSLMWindow, "How far ahead to look for scheduled snapshots of SLM policies. 0 disables the check")
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// snapshotPollInterval represents interval between checks of snapshot state with --wait
+const snapshotPollInterval = 5 * time.Second
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Take and inspect snapshots with the same credentials as other commands",
+}
+
+// snapshotCreateCmd represents the snapshot create command
+var snapshotCreateCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "create [NAME]",
+	Short:         "Take snapshot of all indices (NAME defaults to esnctl-<timestamp>)",
+	RunE:          doSnapshotCreate,
+}
+
+// snapshotListCmd represents the snapshot list command
+var snapshotListCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "list",
+	Short:         "List snapshots in repository",
+	RunE:          doSnapshotList,
+}
+
+// snapshotStatusCmd represents the snapshot status command
+var snapshotStatusCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "status NAME",
+	Short:         "Show snapshot detail",
+	RunE:          doSnapshotStatus,
+}
+
+var snapshotOpts = struct {
+	clusterURL string
+	repository string
+	wait       bool
+}{}
+
+func doSnapshotCreate(cmd *cobra.Command, args []string) error {
+	client, err := newSnapshotClient()
+	if err != nil {
+		return err
+	}
+
+	name := "esnctl-" + time.Now().UTC().Format("20060102150405")
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	log.Printf("===> Creating snapshot %s in %s...\n", name, snapshotOpts.repository)
+
+	if err := client.CreateSnapshot(snapshotOpts.repository, name); err != nil {
+		return errors.Wrap(err, "failed to create snapshot")
+	}
+
+	if !snapshotOpts.wait {
+		return nil
+	}
+
+	log.Println("===> Waiting for snapshot to complete...")
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	for {
+		s, err := client.GetSnapshot(snapshotOpts.repository, name)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve snapshot")
+		}
+
+		if s.Done() {
+			if s.State != snapshot.StateSuccess {
+				return errors.Errorf("snapshot %s finished with state %s. failed shards: %d/%d", name, s.State, s.FailedShards, s.TotalShards)
+			}
+
+			log.Printf("===> Snapshot %s completed in %s\n", name, roundDuration(s.Duration()))
+
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(snapshotPollInterval):
+		}
+	}
+}
+
+func doSnapshotList(cmd *cobra.Command, args []string) error {
+	client, err := newSnapshotClient()
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := client.ListSnapshots(snapshotOpts.repository)
+	if err != nil {
+		return errors.Wrap(err, "failed to list snapshots")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tSTARTED\tDURATION\tSHARDS")
+
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%d\n", s.Name, s.State, s.StartTime.Local().Format(time.RFC3339), roundDuration(s.Duration()), s.SuccessfulShards, s.TotalShards)
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+func doSnapshotStatus(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "snapshot name must be specified")
+	}
+
+	client, err := newSnapshotClient()
+	if err != nil {
+		return err
+	}
+
+	s, err := client.GetSnapshot(snapshotOpts.repository, args[0])
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve snapshot")
+	}
+
+	fmt.Printf("Name:     %s\n", s.Name)
+	fmt.Printf("State:    %s\n", s.State)
+	fmt.Printf("Started:  %s\n", s.StartTime.Local().Format(time.RFC3339))
+
+	if !s.EndTime.IsZero() {
+		fmt.Printf("Finished: %s\n", s.EndTime.Local().Format(time.RFC3339))
+	}
+
+	fmt.Printf("Duration: %s\n", roundDuration(s.Duration()))
+	fmt.Printf("Shards:   %d/%d (failed: %d)\n", s.SuccessfulShards, s.TotalShards, s.FailedShards)
+	fmt.Printf("Indices:  %d\n", len(s.Indices))
+
+	return nil
+}
+
+// newSnapshotClient validates common flags and creates Elasticsearch API client
+func newSnapshotClient() (es.Client, error) {
+	if snapshotOpts.clusterURL == "" {
+		return nil, exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if snapshotOpts.repository == "" {
+		return nil, exitcode.New(exitcode.Validation, "Snapshot repository (--repository) must be specified")
+	}
+
+	client, err := newESClient(snapshotOpts.clusterURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Elasticsearch API client")
+	}
+
+	return client, nil
+}
+
+func init() {
+	RootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotStatusCmd)
+
+	snapshotCmd.PersistentFlags().StringVar(&snapshotOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	snapshotCmd.PersistentFlags().StringVar(&snapshotOpts.repository, "repository", "", "Snapshot repository registered in the cluster")
+
+	snapshotCreateCmd.Flags().BoolVar(&snapshotOpts.wait, "wait", false, "Wait until snapshot completes")
+}
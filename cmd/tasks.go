@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// tasksCmd represents the tasks command
+var tasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "List and cancel tasks running in cluster",
+}
+
+// tasksListCmd represents the tasks list command
+var tasksListCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "list",
+	Short:         "List running tasks, longest first",
+	RunE:          doTasksList,
+}
+
+// tasksCancelCmd represents the tasks cancel command
+var tasksCancelCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "cancel ID",
+	Short:         "Cancel task (ID is node_id:task_number)",
+	RunE:          doTasksCancel,
+}
+
+// pendingTasksCmd represents the pending-tasks command
+var pendingTasksCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "pending-tasks",
+	Short:         "List cluster-state update tasks queued in master",
+	RunE:          doPendingTasks,
+}
+
+var tasksOpts = struct {
+	actions    string
+	clusterURL string
+	output     string
+}{}
+
+func doTasksList(cmd *cobra.Command, args []string) error {
+	clusterURL, httpClient, err := newTasksClient()
+	if err != nil {
+		return err
+	}
+
+	tasks, err := es.Tasks(clusterURL, httpClient, tasksOpts.actions)
+	if err != nil {
+		return errors.Wrap(err, "failed to list tasks")
+	}
+
+	if tasksOpts.output == "json" {
+		return printJSON(tasks)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNODE\tACTION\tRUNNING\tCANCELLABLE\tPARENT\tDESCRIPTION")
+
+	for _, t := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\t%s\n", t.ID, t.Node, t.Action, roundDuration(t.RunningTime), t.Cancellable, orDash(t.ParentID), t.Description)
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+func doTasksCancel(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "task ID must be specified")
+	}
+
+	clusterURL, httpClient, err := newTasksClient()
+	if err != nil {
+		return err
+	}
+
+	if err := es.CancelTask(clusterURL, httpClient, args[0]); err != nil {
+		return err
+	}
+
+	log.Printf("===> Task %s was cancelled\n", args[0])
+
+	return nil
+}
+
+func doPendingTasks(cmd *cobra.Command, args []string) error {
+	clusterURL, httpClient, err := newTasksClient()
+	if err != nil {
+		return err
+	}
+
+	tasks, err := es.PendingTasks(clusterURL, httpClient)
+	if err != nil {
+		return errors.Wrap(err, "failed to list pending tasks")
+	}
+
+	if tasksOpts.output == "json" {
+		return printJSON(tasks)
+	}
+
+	if len(tasks) == 0 {
+		fmt.Println("No pending task")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ORDER\tPRIORITY\tIN QUEUE\tEXECUTING\tSOURCE")
+
+	for _, t := range tasks {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\n", t.InsertOrder, t.Priority, roundDuration(t.TimeInQueue), t.Executing, t.Source)
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+// newTasksClient validates common flags and returns cluster URL with credentials and HTTP client
+func newTasksClient() (string, *http.Client, error) {
+	if tasksOpts.clusterURL == "" {
+		return "", nil, exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if tasksOpts.output != "text" && tasksOpts.output != "json" {
+		return "", nil, exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", tasksOpts.output)
+	}
+
+	if mock {
+		return "", nil, exitcode.New(exitcode.Validation, "tasks and pending-tasks cannot be used with --mock")
+	}
+
+	httpClient, err := newHTTPClient(tasksOpts.clusterURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	clusterURL, err := withCredentials(tasksOpts.clusterURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return clusterURL, httpClient, nil
+}
+
+// printJSON prints the given value as indented JSON
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode JSON")
+	}
+
+	fmt.Println(string(b))
+
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func init() {
+	RootCmd.AddCommand(tasksCmd)
+	tasksCmd.AddCommand(tasksListCmd)
+	tasksCmd.AddCommand(tasksCancelCmd)
+	RootCmd.AddCommand(pendingTasksCmd)
+
+	// Persistent flags are shared with subcommands
+	tasksCmd.PersistentFlags().StringVar(&tasksOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	tasksCmd.PersistentFlags().StringVar(&tasksOpts.output, "output", "text", "Output format (text, json)")
+
+	tasksListCmd.Flags().StringVar(&tasksOpts.actions, "actions", "", "Comma-separated action patterns to filter tasks, e.g. '*reindex,cluster:*'")
+
+	pendingTasksCmd.Flags().StringVar(&tasksOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	pendingTasksCmd.Flags().StringVar(&tasksOpts.output, "output", "text", "Output format (text, json)")
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/telemetry"
+	"github.com/dtan4/esnctl/version"
+	"github.com/spf13/cobra"
+)
+
+// telemetryTimeout represents timeout of sending usage report, not to delay exit of the command
+const telemetryTimeout = 2 * time.Second
+
+// telemetryCmd represents the telemetry command
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Show or turn off anonymous usage reporting (opt in with ESNCTL_TELEMETRY=1)",
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "status",
+	Short:         "Show whether usage reporting is enabled and what is reported",
+	RunE:          doTelemetryStatus,
+}
+
+var telemetryOffCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "off",
+	Short:         "Turn off usage reporting even if ESNCTL_TELEMETRY=1 is set",
+	RunE:          doTelemetryOff,
+}
+
+func doTelemetryStatus(cmd *cobra.Command, args []string) error {
+	s, err := telemetry.CurrentStatus(config.Dir())
+	if err != nil {
+		return err
+	}
+
+	if s.Enabled {
+		fmt.Printf("Telemetry is enabled (%s)\n", s.Reason)
+	} else {
+		fmt.Printf("Telemetry is disabled (%s)\n", s.Reason)
+	}
+
+	if s.Endpoint != "" {
+		fmt.Printf("Endpoint: %s\n", s.Endpoint)
+	} else {
+		fmt.Printf("Endpoint: (none, set %s)\n", telemetry.EndpointEnvVar)
+	}
+	fmt.Println("Reported: command name, esnctl version, OS and architecture, Elasticsearch version (major only), provider and exit code name")
+	fmt.Println("Never reported: cluster URLs, node names, Auto Scaling Groups, AWS accounts, flag values and error messages")
+
+	return nil
+}
+
+func doTelemetryOff(cmd *cobra.Command, args []string) error {
+	if err := telemetry.Disable(config.Dir()); err != nil {
+		return err
+	}
+
+	fmt.Println("Telemetry is turned off")
+
+	return nil
+}
+
+// reportTelemetry sends anonymous usage of the executed command if opted in
+// Any failure is ignored not to affect the command
+func reportTelemetry(err error) {
+	if executedCmd == nil || executedCmd.Hidden || executedCmd.HasParent() && executedCmd.Parent() == telemetryCmd {
+		return
+	}
+
+	s, serr := telemetry.CurrentStatus(config.Dir())
+	if serr != nil || !s.Enabled {
+		return
+	}
+
+	buckets := map[string]bool{}
+
+	for _, c := range es.DetectedCapabilities() {
+		buckets[c.VersionBucket()] = true
+	}
+
+	versions := make([]string, 0, len(buckets))
+
+	for v := range buckets {
+		versions = append(versions, v)
+	}
+
+	sort.Strings(versions)
+
+	provider := "aws"
+	if mock {
+		provider = "mock"
+	}
+
+	telemetry.Send(&http.Client{Timeout: telemetryTimeout}, s.Endpoint, &telemetry.Event{
+		Command:    commandKey(executedCmd),
+		Version:    version.Version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		ESVersions: versions,
+		Provider:   provider,
+		ErrorClass: exitcode.Name(exitcode.Code(err)),
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(telemetryCmd)
+
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+	telemetryCmd.AddCommand(telemetryOffCmd)
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// templateCmd represents the template command
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage index templates with the same credentials as other commands",
+}
+
+// templateGetCmd represents the template get command
+var templateGetCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "get NAME",
+	Short:         "Print index template as JSON",
+	RunE:          doTemplateGet,
+}
+
+// templateListCmd represents the template list command
+var templateListCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "list",
+	Short:         "List index templates",
+	RunE:          doTemplateList,
+}
+
+// templatePutCmd represents the template put command
+var templatePutCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "put NAME",
+	Short:         "Create or replace index template from JSON file",
+	RunE:          doTemplatePut,
+}
+
+var templateOpts = struct {
+	clusterURL string
+	composable bool
+	file       string
+}{}
+
+func doTemplateGet(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "template name must be specified")
+	}
+
+	clusterURL, httpClient, err := newTemplateClient()
+	if err != nil {
+		return err
+	}
+
+	body, err := es.GetTemplate(clusterURL, httpClient, args[0], templateOpts.composable)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return errors.Wrap(err, "invalid response body")
+	}
+
+	buf.WriteString("\n")
+	os.Stdout.Write(buf.Bytes())
+
+	return nil
+}
+
+func doTemplateList(cmd *cobra.Command, args []string) error {
+	clusterURL, httpClient, err := newTemplateClient()
+	if err != nil {
+		return err
+	}
+
+	templates, err := es.ListTemplates(clusterURL, httpClient, templateOpts.composable)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATTERNS\tORDER")
+
+	for _, t := range templates {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", t.Name, strings.Join(t.Patterns, ","), t.Order)
+	}
+
+	w.Flush()
+
+	return nil
+}
+
+func doTemplatePut(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return exitcode.New(exitcode.Validation, "template name must be specified")
+	}
+
+	if templateOpts.file == "" {
+		return exitcode.New(exitcode.Validation, "template file (--file) must be specified")
+	}
+
+	body, err := ioutil.ReadFile(templateOpts.file)
+	if err != nil {
+		return exitcode.Wrap(errors.Wrapf(err, "failed to read template file %s", templateOpts.file), exitcode.Validation)
+	}
+
+	clusterURL, httpClient, err := newTemplateClient()
+	if err != nil {
+		return err
+	}
+
+	if err := es.PutTemplate(clusterURL, httpClient, args[0], templateOpts.composable, body); err != nil {
+		return err
+	}
+
+	log.Printf("===> Template %s is updated\n", args[0])
+
+	return nil
+}
+
+func newTemplateClient() (string, *http.Client, error) {
+	if templateOpts.clusterURL == "" {
+		return "", nil, exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if mock {
+		return "", nil, exitcode.New(exitcode.Validation, "esnctl template cannot be used with --mock")
+	}
+
+	httpClient, err := newHTTPClient(templateOpts.clusterURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	clusterURL, err := withCredentials(templateOpts.clusterURL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return clusterURL, httpClient, nil
+}
+
+func init() {
+	RootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateGetCmd)
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templatePutCmd)
+
+	templateCmd.PersistentFlags().StringVar(&templateOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	templateCmd.PersistentFlags().BoolVar(&templateOpts.composable, "composable", false, "Use composable index templates (_index_template) instead of legacy ones")
+
+	templatePutCmd.Flags().StringVar(&templateOpts.file, "file", "", "JSON file of template body")
+}
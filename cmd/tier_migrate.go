@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// tierMigrateCmd represents the tier-migrate command
+var tierMigrateCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "tier-migrate",
+	Short:         "Move indices on node to another tier of hot/warm architecture",
+	RunE:          doTierMigrate,
+}
+
+var tierMigrateOpts = struct {
+	clusterURL string
+	fromAttr   string
+	nodeName   string
+	toAttr     string
+	operationOptions
+}{}
+
+func doTierMigrate(cmd *cobra.Command, args []string) error {
+	if tierMigrateOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if tierMigrateOpts.fromAttr == "" {
+		return exitcode.New(exitcode.Validation, "Node attribute of the current tier (--from-attr) must be specified")
+	}
+
+	if tierMigrateOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch Node (--node-name) name must be specified")
+	}
+
+	// AWS resources are not touched, so region is not needed
+	w, err := newWorkflow(tierMigrateOpts.clusterURL, "")
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("tier-migrate", tierMigrateOpts.clusterURL)
+	op.Node = tierMigrateOpts.nodeName
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return runOperation(ctx, op, w.ES, tierMigrateOpts.operationOptions, func(ctx context.Context) error {
+		return w.MigrateTier(ctx, workflow.MigrateTierOptions{
+			NodeName:  tierMigrateOpts.nodeName,
+			From:      tierMigrateOpts.fromAttr,
+			To:        tierMigrateOpts.toAttr,
+			Operation: op,
+		})
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(tierMigrateCmd)
+
+	tierMigrateCmd.Flags().StringVar(&tierMigrateOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	tierMigrateCmd.Flags().StringVar(&tierMigrateOpts.fromAttr, "from-attr", "", "Node attribute of the current tier (e.g. box_type=hot)")
+	tierMigrateCmd.Flags().StringVar(&tierMigrateOpts.nodeName, "node-name", "", "Elasticsearch node name to move indices from")
+	tierMigrateCmd.Flags().StringVar(&tierMigrateOpts.toAttr, "to-attr", "box_type=warm", "Node attribute of the tier to move indices to")
+	tierMigrateOpts.operationOptions.addFlags(tierMigrateCmd)
+
+	markFlagCompletion(tierMigrateCmd.Flags(), "node-name")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// defaultTopInterval represents the default interval of refreshing esnctl top
+const defaultTopInterval = 5 * time.Second
+
+var topCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "top",
+	Short:         "Watch CPU, heap, disk, load and shards of each node",
+	RunE:          doTop,
+}
+
+var topOpts = struct {
+	clusterURL string
+	interval   time.Duration
+	iterations int
+}{}
+
+func doTop(cmd *cobra.Command, args []string) error {
+	if topOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if topOpts.interval <= 0 {
+		return exitcode.New(exitcode.Validation, "Refresh interval (--interval) must be positive")
+	}
+
+	client, err := newESClient(topOpts.clusterURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Elasticsearch API client")
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return ui.Top(ctx, client, topOpts.clusterURL, os.Stdout, topOpts.interval, topOpts.iterations)
+}
+
+func init() {
+	RootCmd.AddCommand(topCmd)
+
+	topCmd.Flags().StringVar(&topOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	topCmd.Flags().DurationVar(&topOpts.interval, "interval", defaultTopInterval, "Refresh interval")
+	topCmd.Flags().IntVarP(&topOpts.iterations, "iterations", "n", 0, "Number of refreshes before exit (0 means until interrupted)")
+}
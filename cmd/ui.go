@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/ui"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var uiCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "ui",
+	Short:         "Operate nodes interactively in terminal UI",
+	RunE:          doUI,
+}
+
+var uiOpts = struct {
+	autoScalingGroup string
+	clusterURL       string
+	region           string
+	operationOptions
+}{}
+
+func doUI(cmd *cobra.Command, args []string) error {
+	if uiOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if uiOpts.autoScalingGroup == "" {
+		return exitcode.New(exitcode.Validation, "Auto Scaling Group (--group) must be specified")
+	}
+
+	if outputFormat != outputText {
+		return exitcode.New(exitcode.Validation, "--output cannot be used with esnctl ui")
+	}
+
+	w, err := newWorkflow(uiOpts.clusterURL, uiOpts.region)
+	if err != nil {
+		return err
+	}
+
+	restore, err := makeRaw()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	u := ui.New(w, uiOpts.autoScalingGroup, os.Stdin, os.Stdout, func(ctx context.Context, op *operation.Operation, fn func(ctx context.Context) error) error {
+		return runOperation(ctx, op, w.ES, uiOpts.operationOptions, fn)
+	})
+
+	return u.Run(ctx)
+}
+
+// isTerminal returns whether both stdin and stdout are terminal
+func isTerminal() bool {
+	return isCharDevice(os.Stdin) && isCharDevice(os.Stdout)
+}
+
+// isCharDevice returns whether the given file is terminal
+func isCharDevice(f *os.File) bool {
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// makeRaw switches terminal to non-canonical mode without echo and signals, and returns function to restore it
+func makeRaw() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get terminal settings (stdin must be terminal)")
+	}
+
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, errors.Wrap(err, "failed to change terminal settings")
+	}
+
+	return func() {
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+// pickNode lets user select node to operate on terminal
+func pickNode(w *workflow.Workflow, group string) (string, error) {
+	restore, err := makeRaw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	return ui.PickNode(w, group, os.Stdin, os.Stdout)
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+func init() {
+	RootCmd.AddCommand(uiCmd)
+
+	uiCmd.Flags().StringVar(&uiOpts.autoScalingGroup, "group", "", "Auto Scaling Group")
+	uiCmd.Flags().StringVar(&uiOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	uiCmd.Flags().StringVar(&uiOpts.region, "region", "", "AWS region")
+	uiOpts.operationOptions.addFlags(uiCmd)
+
+	markFlagCompletion(uiCmd.Flags(), "group")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/spf13/cobra"
+)
+
+// unblockCmd represents the unblock command
+var unblockCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "unblock [INDEX...]",
+	Short:         "Clear read-only blocks set by flood-stage disk watermark after adding capacity",
+	RunE:          doUnblock,
+}
+
+var unblockOpts = struct {
+	blocks     []string
+	clusterURL string
+	force      bool
+	operationOptions
+}{}
+
+func doUnblock(cmd *cobra.Command, args []string) error {
+	if unblockOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if mock {
+		return exitcode.New(exitcode.Validation, "esnctl unblock cannot be used with --mock")
+	}
+
+	// AWS resources are not touched, so region is not needed
+	w, err := newWorkflow(unblockOpts.clusterURL, "")
+	if err != nil {
+		return err
+	}
+
+	httpClient, err := newHTTPClient(unblockOpts.clusterURL)
+	if err != nil {
+		return err
+	}
+
+	op := operation.New("unblock", unblockOpts.clusterURL)
+
+	return runOperation(context.Background(), op, w.ES, unblockOpts.operationOptions, func(context.Context) error {
+		unblocked, err := w.Unblock(workflow.UnblockOptions{
+			Indices:    args,
+			Blocks:     unblockOpts.blocks,
+			Force:      unblockOpts.force,
+			HTTPClient: httpClient,
+			Operation:  op,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(unblocked) == 0 {
+			log.Println("===> No index is blocked")
+			return nil
+		}
+
+		for _, b := range unblocked {
+			log.Printf("===> %s is unblocked (%s)\n", b.Index, strings.Join(b.Blocks, ", "))
+		}
+
+		return nil
+	})
+}
+
+func init() {
+	RootCmd.AddCommand(unblockCmd)
+
+	unblockCmd.Flags().StringSliceVar(&unblockOpts.blocks, "block", []string{es.ReadOnlyAllowDeleteBlock}, "Blocks to clear (read_only_allow_delete, read_only, write, read, metadata)")
+	unblockCmd.Flags().StringVar(&unblockOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	unblockCmd.Flags().BoolVar(&unblockOpts.force, "force", false, "Clear read_only_allow_delete even if some node is above flood-stage disk watermark")
+	unblockOpts.operationOptions.addFlags(unblockCmd)
+}
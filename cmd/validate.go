@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Run pre-flight checks of operation without changing anything, e.g. as CI gate",
+}
+
+// validateRemoveCmd represents the validate remove command
+var validateRemoveCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "remove",
+	Short:         "Check whether esnctl remove with the same flags would be accepted",
+	RunE:          doValidateRemove,
+}
+
+var validateOpts = struct {
+	output string
+}{}
+
+func doValidateRemove(cmd *cobra.Command, args []string) error {
+	if err := validateRemoveOpts(true); err != nil {
+		return err
+	}
+
+	if validateOpts.output != "text" && validateOpts.output != "json" {
+		return exitcode.Errorf(exitcode.Validation, "output format (--output) must be text or json. got: %q", validateOpts.output)
+	}
+
+	if removeOpts.rolloverFirst && removeOpts.alias == "" {
+		return exitcode.New(exitcode.Validation, "write alias (--alias) must be specified with --rollover-first")
+	}
+
+	rolloverAlias := ""
+	if removeOpts.rolloverFirst {
+		rolloverAlias = removeOpts.alias
+	}
+
+	w, err := newWorkflow(removeOpts.clusterURL, removeOpts.region)
+	if err != nil {
+		return err
+	}
+
+	if err := resolveRemoveGroup(w); err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	report, err := w.ValidateRemoval(ctx, workflow.RemoveOptions{
+		Group:               removeOpts.autoScalingGroup,
+		NodeName:            removeOpts.nodeName,
+		Force:               removeOpts.force,
+		FixIndexFilters:     removeOpts.fixIndexFilters,
+		RolloverAlias:       rolloverAlias,
+		TerminateInstance:   removeOpts.terminate,
+		ReturnToWarmPool:    removeOpts.warmPool,
+		KeepDesiredCapacity: removeOpts.keepCapacity,
+	})
+	if err != nil {
+		return err
+	}
+
+	if validateOpts.output == "json" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to encode report")
+		}
+
+		fmt.Println(string(b))
+	} else {
+		report.Render(os.Stdout)
+	}
+
+	return report.Err()
+}
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+	validateCmd.AddCommand(validateRemoveCmd)
+
+	validateCmd.PersistentFlags().StringVar(&validateOpts.output, "output", "text", "Output format (text, json)")
+
+	// Flags are shared with esnctl remove, so that the same flags can be validated in CI
+	flags := validateRemoveCmd.Flags()
+	flags.StringVar(&removeOpts.alias, "alias", "", "Write alias rolled over with --rollover-first")
+	flags.StringSliceVar(&removeOpts.autoScalingGroups, "group", []string{}, "Auto Scaling Group (repeat if the cluster spreads over several groups)")
+	flags.StringVar(&removeOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	flags.BoolVar(&removeOpts.fixIndexFilters, "fix-index-filters", false, "Reset index allocation filters pinning shards to the node before drain")
+	flags.BoolVar(&removeOpts.force, "force", false, "Skip shard drain even if node is still in the cluster, e.g. partitioned one")
+	flags.StringVar(&removeOpts.groupTag, "group-tag", "", "Select Auto Scaling Groups by tag in key=value format")
+	flags.BoolVar(&removeOpts.keepCapacity, "keep-desired-capacity", false, "Detach instance without decrementing desired capacity, so that Auto Scaling Group launches replacement")
+	flags.StringVar(&removeOpts.nodeName, "node-name", "", "Elasticsearch node name to remove")
+	flags.StringVar(&removeOpts.region, "region", "", "AWS region")
+	flags.BoolVar(&removeOpts.rolloverFirst, "rollover-first", false, "Roll over --alias before drain if its write index has primaries on the node")
+	flags.BoolVar(&removeOpts.terminate, "terminate", false, "Terminate instance after detaching it")
+	flags.BoolVar(&removeOpts.warmPool, "warm-pool", false, "Return instance to warm pool of Auto Scaling Group instead of detaching it")
+
+	markFlagCompletion(flags, "group")
+	markFlagCompletion(flags, "node-name")
+}
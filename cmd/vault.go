@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/vault"
+	"github.com/pkg/errors"
+)
+
+var (
+	vaultCredentials   *vault.Credentials
+	vaultCredentialsMu sync.Mutex
+)
+
+// getVaultCredentials reads Elasticsearch credentials from --vault-path only once in the process
+// Renewable lease (e.g. dynamic credentials of database secrets engine) is renewed until the process exits
+func getVaultCredentials() (*vault.Credentials, error) {
+	vaultCredentialsMu.Lock()
+	defer vaultCredentialsMu.Unlock()
+
+	if vaultCredentials != nil {
+		return vaultCredentials, nil
+	}
+
+	if passwordFrom != "" {
+		return nil, exitcode.New(exitcode.Validation, "--vault-path cannot be used with --password-from")
+	}
+
+	client, err := vault.NewFromEnv()
+	if err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	secret, err := client.Read(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := secret.Credentials()
+	if err != nil {
+		return nil, errors.Wrapf(err, "secret %s is invalid", vaultPath)
+	}
+
+	if secret.Renewable {
+		log.Printf("===> Renewing Vault lease %s in background\n", secret.LeaseID)
+		go client.KeepRenewing(context.Background(), secret)
+	}
+
+	vaultCredentials = creds
+
+	return creds, nil
+}
+
+// withVaultCredentials returns cluster URL with username and password read from --vault-path
+// The given URL is returned as is if --vault-path is not set or only client certificate is stored
+func withVaultCredentials(clusterURL string) (string, error) {
+	if vaultPath == "" {
+		return clusterURL, nil
+	}
+
+	creds, err := getVaultCredentials()
+	if err != nil {
+		return "", err
+	}
+
+	if creds.Password == "" {
+		return clusterURL, nil
+	}
+
+	u, err := es.SetCredentials(clusterURL, creds.Username, creds.Password)
+	if err != nil {
+		return "", exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	return u, nil
+}
@@ -2,23 +2,67 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/dtan4/esnctl/version"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
 var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print version",
-	RunE:  doVersion,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "version",
+	Short:         "Print version",
+	RunE:          doVersion,
 }
 
+var versionOpts = struct {
+	clusterURL string
+}{}
+
 func doVersion(cmd *cobra.Command, args []string) error {
 	fmt.Println(version.String())
 
+	if versionOpts.clusterURL == "" {
+		return nil
+	}
+
+	capabilities, err := detectCapabilities(versionOpts.clusterURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to detect Elasticsearch version")
+	}
+
+	name := "Elasticsearch"
+	if capabilities.OpenSearch() {
+		name = "OpenSearch"
+	}
+
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\n", name, capabilities.Version, supportedString(capabilities.Supported()))
+
+	for _, f := range capabilities.Features() {
+		fmt.Fprintf(w, "  %s\t\t%s\n", f.Name, supportedString(f.Supported))
+	}
+
+	w.Flush()
+
 	return nil
 }
 
+func supportedString(supported bool) string {
+	if supported {
+		return "supported"
+	}
+
+	return "not supported"
+}
+
 func init() {
 	RootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().StringVar(&versionOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL to report compatibility with")
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/ui"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var watchDrainCmd = &cobra.Command{
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Use:           "watch-drain",
+	Short:         "Wait until shards escape from the given node",
+	RunE:          doWatchDrain,
+}
+
+var watchDrainOpts = struct {
+	clusterURL string
+	interval   time.Duration
+	nodeName   string
+}{}
+
+func doWatchDrain(cmd *cobra.Command, args []string) error {
+	if watchDrainOpts.clusterURL == "" {
+		return exitcode.New(exitcode.Validation, "Elasticsearch cluster URL (--cluster-url) must be specified")
+	}
+
+	if watchDrainOpts.nodeName == "" {
+		return exitcode.New(exitcode.Validation, "Node name (--node-name) must be specified")
+	}
+
+	if watchDrainOpts.interval <= 0 {
+		return exitcode.New(exitcode.Validation, "Check interval (--interval) must be positive")
+	}
+
+	client, err := newESClient(watchDrainOpts.clusterURL)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Elasticsearch API client")
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return ui.WatchDrain(ctx, client, watchDrainOpts.nodeName, os.Stdout, watchDrainOpts.interval)
+}
+
+func init() {
+	RootCmd.AddCommand(watchDrainCmd)
+
+	watchDrainCmd.Flags().StringVar(&watchDrainOpts.clusterURL, "cluster-url", "", "Elasticsearch cluster URL (comma-separated URLs to fail over)")
+	watchDrainCmd.Flags().DurationVar(&watchDrainOpts.interval, "interval", defaultTopInterval, "Check interval")
+	watchDrainCmd.Flags().StringVar(&watchDrainOpts.nodeName, "node-name", "", "Elasticsearch node name to watch")
+	markFlagCompletion(watchDrainCmd.Flags(), "node-name")
+}
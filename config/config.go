@@ -0,0 +1,219 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Config represents esnctl configuration file
+type Config struct {
+	Clusters map[string]*Cluster `yaml:"clusters"`
+
+	// API represents access control of esnctl server
+	API *API `yaml:"api"`
+
+	// Aliases maps user-defined command name to the command line it expands to, e.g. "remove --lock --audit"
+	Aliases map[string]string `yaml:"aliases"`
+
+	// MaintenanceWindows restricts when operations can run. Operations run anytime if empty
+	MaintenanceWindows []*MaintenanceWindow `yaml:"maintenance_windows"`
+
+	// Defaults maps command path, e.g. "remove" or "snapshot create", to default values of its flags
+	Defaults map[string]map[string]string `yaml:"defaults"`
+}
+
+// API represents access control of esnctl server
+// API is open to anyone if no token is configured
+type API struct {
+	Tokens []*Token `yaml:"tokens"`
+}
+
+// Token represents bearer token accepted by esnctl server
+type Token struct {
+	// Name identifies the token holder in operation records and logs
+	Name string `yaml:"name"`
+
+	// SHA256 represents hex-encoded SHA-256 digest of the token, not to store the token itself
+	SHA256 string `yaml:"sha256"`
+
+	// Role is one of viewer, operator and admin
+	Role string `yaml:"role"`
+
+	// Clusters limits clusters the token can access. All clusters are accessible if empty
+	Clusters []string `yaml:"clusters"`
+}
+
+// MaintenanceWindow represents recurring period in which operations are allowed
+type MaintenanceWindow struct {
+	// Days are days of week the window starts on, e.g. sat and sun. Every day if empty
+	Days []string `yaml:"days"`
+
+	// Start and End are time of day in HH:MM format. Window ending before it starts ends on the next day
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+
+	// Timezone is IANA time zone name of Start and End, e.g. Asia/Tokyo (default: UTC)
+	Timezone string `yaml:"timezone"`
+}
+
+// Cluster represents named Elasticsearch cluster
+type Cluster struct {
+	ClusterURL string `yaml:"cluster_url"`
+	Group      string `yaml:"group"`
+	Region     string `yaml:"region"`
+
+	// RoleARN represents IAM role assumed to operate the cluster, e.g. in another AWS account
+	RoleARN string `yaml:"role_arn"`
+
+	// DesiredCapacity represents desired capacity of Group declared outside esnctl, e.g. in Terraform
+	DesiredCapacity int `yaml:"desired_capacity"`
+}
+
+// Dir returns esnctl configuration directory, ~/.esnctl
+func Dir() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		home = os.Getenv("USERPROFILE")
+	}
+
+	return filepath.Join(home, ".esnctl")
+}
+
+// DefaultPath returns the default configuration file path
+func DefaultPath() string {
+	return filepath.Join(Dir(), "config.yaml")
+}
+
+// Load reads configuration from the given file
+// Returns empty configuration if the file does not exist
+func Load(path string) (*Config, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{
+				Clusters: map[string]*Cluster{},
+			}, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to read configuration file")
+	}
+
+	var c Config
+
+	if err := yaml.UnmarshalStrict(body, &c); err != nil {
+		return nil, errors.Wrap(err, "failed to parse configuration file")
+	}
+
+	if c.Clusters == nil {
+		c.Clusters = map[string]*Cluster{}
+	}
+
+	return &c, nil
+}
+
+// Cluster returns the cluster with the given name
+func (c *Config) Cluster(name string) (*Cluster, error) {
+	cluster, ok := c.Clusters[name]
+	if !ok {
+		return nil, errors.Errorf("cluster %q is not configured", name)
+	}
+
+	if cluster.ClusterURL == "" {
+		return nil, errors.Errorf("cluster_url of cluster %q is empty", name)
+	}
+
+	return cluster, nil
+}
+
+// ClusterNames returns sorted names of configured clusters
+func (c *Config) ClusterNames() []string {
+	names := []string{}
+
+	for name := range c.Clusters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ExpandAlias returns the command line the given alias expands to
+// Arguments are split by whitespace, and quotes group words containing whitespace
+func (c *Config) ExpandAlias(name string) ([]string, bool, error) {
+	line, ok := c.Aliases[name]
+	if !ok {
+		return nil, false, nil
+	}
+
+	args, err := splitCommandLine(line)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "alias %q is invalid", name)
+	}
+
+	if len(args) == 0 {
+		return nil, false, errors.Errorf("alias %q is empty", name)
+	}
+
+	return args, true, nil
+}
+
+func splitCommandLine(line string) ([]string, error) {
+	args := []string{}
+
+	var (
+		current []rune
+		inArg   bool
+		quote   rune
+	)
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current = append(current, r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, string(current))
+				current = nil
+				inArg = false
+			}
+		default:
+			current = append(current, r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.Errorf("quote %c is not closed", quote)
+	}
+
+	if inArg {
+		args = append(args, string(current))
+	}
+
+	return args, nil
+}
+
+// DesiredCapacity returns desired capacity declared for the given Auto Scaling Group
+func (c *Config) DesiredCapacity(group string) (int, bool) {
+	for _, cluster := range c.Clusters {
+		if cluster.Group == group && cluster.DesiredCapacity > 0 {
+			return cluster.DesiredCapacity, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "esnctl-config")
+	if err != nil {
+		t.Fatalf("failed to create temporary file: %s", err)
+	}
+	defer os.Remove(f.Name())
+
+	f.WriteString(`clusters:
+  logs:
+    cluster_url: http://logs.example.com
+    group: elasticsearch-logs
+    region: ap-northeast-1
+  search:
+    cluster_url: http://search.example.com
+    group: elasticsearch-search
+`)
+	f.Close()
+
+	c, err := Load(f.Name())
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []string{"logs", "search"}
+
+	if got := c.ClusterNames(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("cluster names do not match. expected: %v, got: %v", expected, got)
+	}
+
+	cluster, err := c.Cluster("logs")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if cluster.Group != "elasticsearch-logs" {
+		t.Errorf("group does not match. expected: %q, got: %q", "elasticsearch-logs", cluster.Group)
+	}
+
+	if _, err := c.Cluster("metrics"); err == nil {
+		t.Errorf("error should be raised for unknown cluster")
+	}
+}
+
+func TestLoad_notExist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-config")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if len(c.Clusters) != 0 {
+		t.Errorf("configuration should be empty. got: %d clusters", len(c.Clusters))
+	}
+}
+
+func TestDesiredCapacity(t *testing.T) {
+	c := &Config{
+		Clusters: map[string]*Cluster{
+			"logs": &Cluster{
+				ClusterURL:      "http://logs.example.com",
+				Group:           "elasticsearch-logs",
+				DesiredCapacity: 6,
+			},
+			"search": &Cluster{
+				ClusterURL: "http://search.example.com",
+				Group:      "elasticsearch-search",
+			},
+		},
+	}
+
+	if got, ok := c.DesiredCapacity("elasticsearch-logs"); !ok || got != 6 {
+		t.Errorf("desired capacity does not match. expected: 6, got: %d (%t)", got, ok)
+	}
+
+	if _, ok := c.DesiredCapacity("elasticsearch-search"); ok {
+		t.Errorf("desired capacity should not be declared for elasticsearch-search")
+	}
+}
+
+func TestExpandAlias(t *testing.T) {
+	c := &Config{
+		Aliases: map[string]string{
+			"prod-remove": `remove --cluster-url http://logs.example.com --lock --group-tag "Name=elasticsearch logs"`,
+			"broken":      `remove --group-tag "Name=elasticsearch`,
+		},
+	}
+
+	got, ok, err := c.ExpandAlias("prod-remove")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !ok {
+		t.Fatalf("alias should be found")
+	}
+
+	expected := []string{"remove", "--cluster-url", "http://logs.example.com", "--lock", "--group-tag", "Name=elasticsearch logs"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expanded arguments do not match. expected: %q, got: %q", expected, got)
+	}
+
+	if _, ok, _ := c.ExpandAlias("remove"); ok {
+		t.Errorf("undefined alias should not be found")
+	}
+
+	if _, _, err := c.ExpandAlias("broken"); err == nil {
+		t.Errorf("error should be raised for unclosed quote")
+	}
+}
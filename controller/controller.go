@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dtan4/esnctl/kube"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// statusInterval represents how often progress is written into resource status
+var statusInterval = 5 * time.Second
+
+// Client represents Kubernetes API used by Controller
+type Client interface {
+	ListNodeRemovals(namespace string) ([]*kube.NodeRemoval, error)
+	UpdateNodeRemovalStatus(r *kube.NodeRemoval) error
+}
+
+// RunFunc executes node removal described in the given resource
+type RunFunc func(op *operation.Operation, r *kube.NodeRemoval) error
+
+// Controller executes ESNodeRemoval resources one by one and reports progress in their status
+type Controller struct {
+	client    Client
+	namespace string
+	run       RunFunc
+	started   bool
+
+	// Leader reports whether this instance is the leader if not nil
+	// Resources are reconciled only by the leader
+	Leader func() bool
+}
+
+// New creates new Controller object
+// Resources in all namespaces are watched if namespace is empty
+func New(client Client, namespace string, run RunFunc) *Controller {
+	return &Controller{
+		client:    client,
+		namespace: namespace,
+		run:       run,
+	}
+}
+
+// Run reconciles resources every interval until ctx is canceled
+func (c *Controller) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if c.Leader == nil || c.Leader() {
+			if err := c.Reconcile(); err != nil {
+				log.Println(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Reconcile executes resources which have not been processed yet
+func (c *Controller) Reconcile() error {
+	removals, err := c.client.ListNodeRemovals(c.namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to list ESNodeRemoval resources")
+	}
+
+	first := !c.started
+	c.started = true
+
+	for _, r := range removals {
+		switch r.Status.Phase {
+		case "":
+			c.process(r)
+		case kube.PhaseRunning:
+			// Removal is executed synchronously, so Running one at startup was interrupted
+			if first {
+				c.fail(r, "controller was restarted during operation; check the cluster and recreate the resource to retry")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) process(r *kube.NodeRemoval) {
+	if err := validate(&r.Spec); err != nil {
+		c.fail(r, err.Error())
+		return
+	}
+
+	op := operation.New("remove", r.Spec.ClusterURL)
+	op.Group = r.Spec.Group
+	op.Node = r.Spec.NodeName
+
+	startedAt := op.StartedAt
+
+	r.Status = kube.NodeRemovalStatus{
+		Phase:       kube.PhaseRunning,
+		OperationID: op.ID,
+		StartedAt:   &startedAt,
+	}
+	c.updateStatus(r)
+
+	log.Printf("===> Removing %s (%s/%s)\n", r.Spec.NodeName, r.Metadata.Namespace, r.Metadata.Name)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- c.run(op, r)
+	}()
+
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+
+	var err error
+
+Loop:
+	for {
+		select {
+		case err = <-done:
+			break Loop
+		case <-ticker.C:
+			if step := currentStep(op); step != r.Status.Step {
+				r.Status.Step = step
+				c.updateStatus(r)
+			}
+		}
+	}
+
+	finishedAt := time.Now()
+
+	r.Status.Step = currentStep(op)
+	r.Status.FinishedAt = &finishedAt
+
+	if err != nil {
+		r.Status.Phase = kube.PhaseFailed
+		r.Status.Message = err.Error()
+	} else {
+		r.Status.Phase = kube.PhaseSucceeded
+		r.Status.Message = ""
+	}
+
+	c.updateStatus(r)
+}
+
+func (c *Controller) fail(r *kube.NodeRemoval, message string) {
+	now := time.Now()
+
+	r.Status.Phase = kube.PhaseFailed
+	r.Status.Message = message
+	r.Status.FinishedAt = &now
+
+	c.updateStatus(r)
+}
+
+func (c *Controller) updateStatus(r *kube.NodeRemoval) {
+	if err := c.client.UpdateNodeRemovalStatus(r); err != nil {
+		log.Println(errors.Wrapf(err, "failed to update status of %s/%s", r.Metadata.Namespace, r.Metadata.Name))
+	}
+}
+
+func currentStep(op *operation.Operation) string {
+	s := op.Snapshot()
+
+	if len(s.Phases) == 0 {
+		return ""
+	}
+
+	return s.Phases[len(s.Phases)-1].Name
+}
+
+func validate(spec *kube.NodeRemovalSpec) error {
+	if spec.ClusterURL == "" {
+		return errors.New("spec.clusterURL must be specified")
+	}
+
+	if spec.Group == "" {
+		return errors.New("spec.group must be specified")
+	}
+
+	if spec.NodeName == "" {
+		return errors.New("spec.nodeName must be specified")
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/kube"
+	"github.com/dtan4/esnctl/operation"
+)
+
+type fakeClient struct {
+	removals []*kube.NodeRemoval
+	updates  []kube.NodeRemovalStatus
+}
+
+func (c *fakeClient) ListNodeRemovals(namespace string) ([]*kube.NodeRemoval, error) {
+	return c.removals, nil
+}
+
+func (c *fakeClient) UpdateNodeRemovalStatus(r *kube.NodeRemoval) error {
+	c.updates = append(c.updates, r.Status)
+	return nil
+}
+
+func newNodeRemoval(name, nodeName, phase string) *kube.NodeRemoval {
+	return &kube.NodeRemoval{
+		Metadata: kube.ObjectMeta{
+			Name:      name,
+			Namespace: "logging",
+		},
+		Spec: kube.NodeRemovalSpec{
+			ClusterURL: "http://elasticsearch.example.com",
+			Group:      "elasticsearch",
+			NodeName:   nodeName,
+		},
+		Status: kube.NodeRemovalStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func init() {
+	statusInterval = 1 * time.Millisecond
+}
+
+func TestReconcile(t *testing.T) {
+	client := &fakeClient{
+		removals: []*kube.NodeRemoval{
+			newNodeRemoval("remove-node-21", "ip-10-0-1-21.ap-northeast-1.compute.internal", ""),
+			newNodeRemoval("remove-node-22", "ip-10-0-1-22.ap-northeast-1.compute.internal", kube.PhaseSucceeded),
+			newNodeRemoval("remove-node-23", "ip-10-0-1-23.ap-northeast-1.compute.internal", ""),
+		},
+	}
+
+	removed := []string{}
+
+	c := New(client, "logging", func(op *operation.Operation, r *kube.NodeRemoval) error {
+		removed = append(removed, r.Spec.NodeName)
+		op.Phase("Shutting down target node")
+
+		if r.Metadata.Name == "remove-node-23" {
+			return errors.New("failed to shutdown node")
+		}
+
+		return nil
+	})
+
+	if err := c.Reconcile(); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(removed) != 2 {
+		t.Errorf("number of removed nodes does not match. expected: 2, got: %v", removed)
+	}
+
+	if got := client.removals[0].Status; got.Phase != kube.PhaseSucceeded || got.Step != "Shutting down target node" || got.OperationID == "" {
+		t.Errorf("status does not match. got: %#v", got)
+	}
+
+	if got := client.removals[2].Status; got.Phase != kube.PhaseFailed || got.Message != "failed to shutdown node" {
+		t.Errorf("status does not match. got: %#v", got)
+	}
+}
+
+func TestReconcile_interrupted(t *testing.T) {
+	client := &fakeClient{
+		removals: []*kube.NodeRemoval{
+			newNodeRemoval("remove-node-21", "ip-10-0-1-21.ap-northeast-1.compute.internal", kube.PhaseRunning),
+			newNodeRemoval("remove-node-22", "", ""),
+		},
+	}
+
+	c := New(client, "", func(op *operation.Operation, r *kube.NodeRemoval) error {
+		t.Errorf("invalid or interrupted resource should not be executed: %s", r.Metadata.Name)
+		return nil
+	})
+
+	if err := c.Reconcile(); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	for _, r := range client.removals {
+		if r.Status.Phase != kube.PhaseFailed {
+			t.Errorf("phase of %s does not match. expected: %q, got: %q", r.Metadata.Name, kube.PhaseFailed, r.Status.Phase)
+		}
+	}
+}
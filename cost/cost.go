@@ -0,0 +1,248 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/aws/ec2"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+// HoursPerMonth represents the average number of hours in a month, which AWS uses for monthly estimates
+const HoursPerMonth = 730
+
+// Price represents hourly price of instance type in USD
+// Spot is 0 if unknown, and then spot instances are estimated at on-demand price as upper bound
+type Price struct {
+	OnDemand float64 `json:"on_demand"`
+	Spot     float64 `json:"spot"`
+}
+
+// DefaultPrices represents on-demand prices of Linux instances in us-east-1, bundled so that no Pricing API call is needed
+// Prices differ by region and change over time, so they should be overridden with ParsePrices for accurate estimate
+var DefaultPrices = map[string]*Price{
+	"c5.large":    {OnDemand: 0.085},
+	"c5.xlarge":   {OnDemand: 0.17},
+	"c5.2xlarge":  {OnDemand: 0.34},
+	"c5.4xlarge":  {OnDemand: 0.68},
+	"i3.large":    {OnDemand: 0.156},
+	"i3.xlarge":   {OnDemand: 0.312},
+	"i3.2xlarge":  {OnDemand: 0.624},
+	"i3.4xlarge":  {OnDemand: 1.248},
+	"m4.large":    {OnDemand: 0.1},
+	"m4.xlarge":   {OnDemand: 0.2},
+	"m5.large":    {OnDemand: 0.096},
+	"m5.xlarge":   {OnDemand: 0.192},
+	"m5.2xlarge":  {OnDemand: 0.384},
+	"m5.4xlarge":  {OnDemand: 0.768},
+	"r4.large":    {OnDemand: 0.133},
+	"r4.xlarge":   {OnDemand: 0.266},
+	"r4.2xlarge":  {OnDemand: 0.532},
+	"r5.large":    {OnDemand: 0.126},
+	"r5.xlarge":   {OnDemand: 0.252},
+	"r5.2xlarge":  {OnDemand: 0.504},
+	"r5.4xlarge":  {OnDemand: 1.008},
+	"r5d.large":   {OnDemand: 0.144},
+	"r5d.xlarge":  {OnDemand: 0.288},
+	"r5d.2xlarge": {OnDemand: 0.576},
+	"t3.medium":   {OnDemand: 0.0416},
+	"t3.large":    {OnDemand: 0.0832},
+	"t3.xlarge":   {OnDemand: 0.1664},
+}
+
+// ParsePrices returns DefaultPrices overridden by the given JSON object keyed by instance type
+func ParsePrices(data []byte) (map[string]*Price, error) {
+	var overrides map[string]*Price
+
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, exitcode.Wrap(err, exitcode.Validation)
+	}
+
+	prices := map[string]*Price{}
+
+	for t, p := range DefaultPrices {
+		prices[t] = p
+	}
+
+	for t, p := range overrides {
+		if p == nil || p.OnDemand <= 0 {
+			return nil, exitcode.Errorf(exitcode.Validation, "on-demand price of %s must be greater than 0", t)
+		}
+
+		prices[t] = p
+	}
+
+	return prices, nil
+}
+
+// Node represents estimated cost of node
+type Node struct {
+	Name         string  `json:"name"`
+	InstanceID   string  `json:"instance_id"`
+	InstanceType string  `json:"instance_type"`
+	Lifecycle    string  `json:"lifecycle"`
+	Hourly       float64 `json:"hourly"`
+	Monthly      float64 `json:"monthly"`
+
+	// Estimated is true if spot instance is priced at on-demand price because spot price is unknown
+	Estimated bool `json:"estimated"`
+}
+
+// Addition represents marginal cost of adding instances
+// New instances are priced as on-demand, because Auto Scaling decides the lifecycle on launch
+type Addition struct {
+	Count        int     `json:"count"`
+	InstanceType string  `json:"instance_type"`
+	Monthly      float64 `json:"monthly"`
+}
+
+// Report represents estimated monthly cost of fleet in USD
+type Report struct {
+	Nodes    []*Node   `json:"nodes"`
+	OnDemand float64   `json:"on_demand"`
+	Spot     float64   `json:"spot"`
+	Monthly  float64   `json:"monthly"`
+	Addition *Addition `json:"addition,omitempty"`
+}
+
+// New estimates monthly cost of the given instances, and marginal cost of adding the given number of instances
+// Instances are added with the most common instance type in the fleet
+func New(instances []*ec2.Instance, prices map[string]*Price, add int) (*Report, error) {
+	r := &Report{
+		Nodes: []*Node{},
+	}
+
+	unknown := []string{}
+	types := map[string]int{}
+
+	for _, i := range instances {
+		p, ok := prices[i.InstanceType]
+		if !ok {
+			if !contains(unknown, i.InstanceType) {
+				unknown = append(unknown, i.InstanceType)
+			}
+
+			continue
+		}
+
+		n := &Node{
+			Name:         i.PrivateDNS,
+			InstanceID:   i.ID,
+			InstanceType: i.InstanceType,
+			Lifecycle:    i.Lifecycle,
+			Hourly:       p.OnDemand,
+		}
+
+		if i.Lifecycle == "spot" {
+			if p.Spot > 0 {
+				n.Hourly = p.Spot
+			} else {
+				n.Estimated = true
+			}
+		}
+
+		n.Monthly = n.Hourly * HoursPerMonth
+
+		if n.Lifecycle == "spot" {
+			r.Spot += n.Monthly
+		} else {
+			r.OnDemand += n.Monthly
+		}
+
+		types[i.InstanceType]++
+		r.Nodes = append(r.Nodes, n)
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, exitcode.Errorf(exitcode.Validation, "price of %s is unknown. Give it with --prices", strings.Join(unknown, ", "))
+	}
+
+	r.Monthly = r.OnDemand + r.Spot
+
+	sort.Slice(r.Nodes, func(i, j int) bool {
+		return r.Nodes[i].Name < r.Nodes[j].Name
+	})
+
+	if add > 0 && len(types) > 0 {
+		t := mostCommon(types)
+
+		r.Addition = &Addition{
+			Count:        add,
+			InstanceType: t,
+			Monthly:      prices[t].OnDemand * HoursPerMonth * float64(add),
+		}
+	}
+
+	return r, nil
+}
+
+// Render prints table of nodes followed by total and marginal cost
+// Spot instances priced at on-demand price are marked with *
+func (r *Report) Render(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tINSTANCE ID\tTYPE\tLIFECYCLE\tHOURLY\tMONTHLY")
+
+	for _, n := range r.Nodes {
+		mark := ""
+		if n.Estimated {
+			mark = "*"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s%s\t$%.4f\t$%.2f\n", orDash(n.Name), n.InstanceID, n.InstanceType, n.Lifecycle, mark, n.Hourly, n.Monthly)
+	}
+
+	w.Flush()
+
+	fmt.Fprintf(out, "\nOn-demand: $%.2f / month\n", r.OnDemand)
+	fmt.Fprintf(out, "Spot:      $%.2f / month\n", r.Spot)
+	fmt.Fprintf(out, "Total:     $%.2f / month\n", r.Monthly)
+
+	for _, n := range r.Nodes {
+		if n.Estimated {
+			fmt.Fprintln(out, "* spot price is unknown, so on-demand price is used as upper bound")
+			break
+		}
+	}
+
+	if a := r.Addition; a != nil {
+		fmt.Fprintf(out, "\nAdding %d x %s: +$%.2f / month (total $%.2f / month)\n", a.Count, a.InstanceType, a.Monthly, r.Monthly+a.Monthly)
+	}
+}
+
+// mostCommon returns the key counted the most. The smallest key in lexical order wins on tie
+func mostCommon(counts map[string]int) string {
+	key := ""
+	count := 0
+
+	for k, c := range counts {
+		if c > count || (c == count && k < key) {
+			key = k
+			count = c
+		}
+	}
+
+	return key
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
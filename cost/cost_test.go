@@ -0,0 +1,115 @@
+package cost
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dtan4/esnctl/aws/ec2"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+func testInstances() []*ec2.Instance {
+	return []*ec2.Instance{
+		&ec2.Instance{ID: "i-3", PrivateDNS: "node-3", InstanceType: "r5.large", Lifecycle: "spot"},
+		&ec2.Instance{ID: "i-1", PrivateDNS: "node-1", InstanceType: "r5.large", Lifecycle: "on-demand"},
+		&ec2.Instance{ID: "i-2", PrivateDNS: "node-2", InstanceType: "m5.large", Lifecycle: "spot"},
+	}
+}
+
+func TestNew(t *testing.T) {
+	prices := map[string]*Price{
+		"m5.large": {OnDemand: 0.1, Spot: 0.05},
+		"r5.large": {OnDemand: 0.2},
+	}
+
+	r, err := New(testInstances(), prices, 2)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []struct {
+		name      string
+		monthly   float64
+		estimated bool
+	}{
+		{name: "node-1", monthly: 146, estimated: false},
+		{name: "node-2", monthly: 36.5, estimated: false},
+		{name: "node-3", monthly: 146, estimated: true},
+	}
+
+	for i, e := range expected {
+		n := r.Nodes[i]
+
+		if n.Name != e.name || !near(n.Monthly, e.monthly) || n.Estimated != e.estimated {
+			t.Errorf("node %d does not match. expected: %+v, got: %+v", i, e, n)
+		}
+	}
+
+	if !near(r.OnDemand, 146) || !near(r.Spot, 182.5) || !near(r.Monthly, 328.5) {
+		t.Errorf("total does not match. got: on-demand %f, spot %f, total %f", r.OnDemand, r.Spot, r.Monthly)
+	}
+
+	if r.Addition == nil || r.Addition.InstanceType != "r5.large" || !near(r.Addition.Monthly, 292) {
+		t.Errorf("addition does not match. got: %+v", r.Addition)
+	}
+}
+
+func TestNew_unknownPrice(t *testing.T) {
+	_, err := New(testInstances(), map[string]*Price{"r5.large": {OnDemand: 0.2}}, 0)
+	if exitcode.Code(err) != exitcode.Validation {
+		t.Fatalf("validation error should be raised. got: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "m5.large") {
+		t.Errorf("error should name the instance type. got: %s", err)
+	}
+}
+
+func TestParsePrices(t *testing.T) {
+	prices, err := ParsePrices([]byte(`{"r5.large": {"on_demand": 0.15, "spot": 0.04}, "x1.large": {"on_demand": 1}}`))
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if p := prices["r5.large"]; p.OnDemand != 0.15 || p.Spot != 0.04 {
+		t.Errorf("price should be overridden. got: %+v", p)
+	}
+
+	if _, ok := prices["x1.large"]; !ok {
+		t.Errorf("price should be added")
+	}
+
+	if _, ok := prices["m5.large"]; !ok {
+		t.Errorf("default price should be kept")
+	}
+
+	if DefaultPrices["r5.large"].OnDemand == 0.15 {
+		t.Errorf("DefaultPrices should not be modified")
+	}
+
+	if _, err := ParsePrices([]byte(`{"r5.large": {"spot": 0.04}}`)); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised. got: %v", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	r, err := New(testInstances(), map[string]*Price{"m5.large": {OnDemand: 0.1, Spot: 0.05}, "r5.large": {OnDemand: 0.2}}, 1)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	var buf bytes.Buffer
+	r.Render(&buf)
+
+	for _, s := range []string{"node-3  i-3", "spot*", "Total:     $328.50 / month", "Adding 1 x r5.large: +$146.00 / month (total $474.50 / month)"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("output should contain %q. got:\n%s", s, buf.String())
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	d := a - b
+	return d < 1e-6 && d > -1e-6
+}
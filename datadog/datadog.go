@@ -0,0 +1,179 @@
+package datadog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSite represents Datadog site used if DD_SITE is not set
+const DefaultSite = "datadoghq.com"
+
+const (
+	// AlertInfo represents alert type of informational event
+	AlertInfo = "info"
+	// AlertSuccess represents alert type of event on success
+	AlertSuccess = "success"
+	// AlertError represents alert type of event on failure
+	AlertError = "error"
+)
+
+// Client represents Datadog API client
+type Client struct {
+	addr       string
+	apiKey     string
+	appKey     string
+	httpClient *http.Client
+}
+
+// Event represents Datadog event
+type Event struct {
+	Title     string   `json:"title"`
+	Text      string   `json:"text"`
+	AlertType string   `json:"alert_type,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// Downtime represents Datadog downtime muting monitors in the given scopes
+type Downtime struct {
+	ID      int64    `json:"id,omitempty"`
+	Scope   []string `json:"scope"`
+	Message string   `json:"message"`
+	Start   int64    `json:"start"`
+	End     int64    `json:"end"`
+}
+
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// New creates new Client object
+// appKey is required only for downtimes
+func New(addr, apiKey, appKey string, httpClient *http.Client) *Client {
+	return &Client{
+		addr:       strings.TrimSuffix(addr, "/"),
+		apiKey:     apiKey,
+		appKey:     appKey,
+		httpClient: httpClient,
+	}
+}
+
+// NewFromEnv creates new Client object from DD_API_KEY, DD_APP_KEY and DD_SITE
+func NewFromEnv() (*Client, error) {
+	apiKey := os.Getenv("DD_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("DD_API_KEY must be set")
+	}
+
+	site := os.Getenv("DD_SITE")
+	if site == "" {
+		site = DefaultSite
+	}
+
+	return New("https://api."+site, apiKey, os.Getenv("DD_APP_KEY"), &http.Client{}), nil
+}
+
+// CancelDowntime cancels the given downtime, so that monitors are unmuted
+func (c *Client) CancelDowntime(id int64) error {
+	if err := c.do("DELETE", fmt.Sprintf("/api/v1/downtime/%d", id), nil, nil); err != nil {
+		return errors.Wrapf(err, "failed to cancel downtime %d", id)
+	}
+
+	return nil
+}
+
+// PostEvent posts the given event
+func (c *Client) PostEvent(e *Event) error {
+	if err := c.do("POST", "/api/v1/events", e, nil); err != nil {
+		return errors.Wrap(err, "failed to post event")
+	}
+
+	return nil
+}
+
+// ScheduleDowntime mutes monitors in the given scopes, e.g. cluster:logs, from now until now + duration
+func (c *Client) ScheduleDowntime(scopes []string, message string, duration time.Duration) (*Downtime, error) {
+	if c.appKey == "" {
+		return nil, errors.New("DD_APP_KEY must be set to mute monitors")
+	}
+
+	now := time.Now()
+
+	body := &Downtime{
+		Scope:   scopes,
+		Message: message,
+		Start:   now.Unix(),
+		End:     now.Add(duration).Unix(),
+	}
+
+	var d Downtime
+
+	if err := c.do("POST", "/api/v1/downtime", body, &d); err != nil {
+		return nil, errors.Wrap(err, "failed to schedule downtime")
+	}
+
+	return &d, nil
+}
+
+func (c *Client) do(method, path string, body interface{}, v interface{}) error {
+	var reqBody []byte
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to build request body")
+		}
+
+		reqBody = b
+	}
+
+	req, err := http.NewRequest(method, c.addr+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to make http request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", c.apiKey)
+
+	if c.appKey != "" {
+		req.Header.Set("DD-APPLICATION-KEY", c.appKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to access to Datadog")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode/100 != 2 {
+		var e errorResponse
+
+		if err := json.Unmarshal(respBody, &e); err == nil && len(e.Errors) > 0 {
+			return errors.Errorf("code: %d, errors: %s", resp.StatusCode, strings.Join(e.Errors, ", "))
+		}
+
+		return errors.Errorf("code: %d, body: %s", resp.StatusCode, respBody)
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return errors.Wrap(err, "invalid response body")
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package datadog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPostEvent(t *testing.T) {
+	var got Event
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "apikey" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["Forbidden"]}`))
+			return
+		}
+
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/events" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		json.NewDecoder(r.Body).Decode(&got)
+
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "apikey", "", &http.Client{})
+
+	e := &Event{
+		Title:     "esnctl remove started",
+		Text:      "ip-10-0-1-21.ec2.internal",
+		AlertType: AlertInfo,
+		Tags:      []string{"esnctl", "operation:remove"},
+	}
+
+	if err := client.PostEvent(e); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got.Title != e.Title || got.AlertType != AlertInfo || len(got.Tags) != 2 {
+		t.Errorf("event does not match. expected: %+v, got: %+v", e, got)
+	}
+
+	client = New(ts.URL, "wrong", "", &http.Client{})
+
+	if err := client.PostEvent(e); err == nil {
+		t.Errorf("error should be raised with wrong API key")
+	}
+}
+
+func TestScheduleDowntime(t *testing.T) {
+	var got Downtime
+	cancelled := ""
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-APPLICATION-KEY") != "appkey" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["Forbidden"]}`))
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/downtime":
+			json.NewDecoder(r.Body).Decode(&got)
+			w.Write([]byte(`{"id":1625,"scope":["cluster:logs"],"message":"esnctl remove"}`))
+		case r.Method == http.MethodDelete:
+			cancelled = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	if _, err := New(ts.URL, "apikey", "", &http.Client{}).ScheduleDowntime([]string{"cluster:logs"}, "esnctl remove", time.Hour); err == nil {
+		t.Errorf("error should be raised without application key")
+	}
+
+	client := New(ts.URL, "apikey", "appkey", &http.Client{})
+
+	d, err := client.ScheduleDowntime([]string{"cluster:logs"}, "esnctl remove", 2*time.Hour)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if d.ID != 1625 {
+		t.Errorf("downtime ID does not match. expected: 1625, got: %d", d.ID)
+	}
+
+	if got.End-got.Start != int64((2*time.Hour).Seconds()) || len(got.Scope) != 1 || got.Scope[0] != "cluster:logs" {
+		t.Errorf("requested downtime does not match. got: %+v", got)
+	}
+
+	if err := client.CancelDowntime(d.ID); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if cancelled != "/api/v1/downtime/1625" {
+		t.Errorf("cancelled path does not match. got: %q", cancelled)
+	}
+}
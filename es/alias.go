@@ -0,0 +1,107 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// AliasIndices returns indices which the given alias points to
+func AliasIndices(clusterURL string, httpClient *http.Client, alias string) ([]string, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/_aliases", url.Values{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve aliases")
+	}
+
+	var resp map[string]struct {
+		Aliases map[string]json.RawMessage `json:"aliases"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse aliases")
+	}
+
+	indices := []string{}
+
+	for index, a := range resp {
+		if _, ok := a.Aliases[alias]; ok {
+			indices = append(indices, index)
+		}
+	}
+
+	sort.Strings(indices)
+
+	return indices, nil
+}
+
+// AddAlias points the given alias to the index
+// With writeIndex, the index receives writes through the alias pointing to multiple indices (Elasticsearch 6.4 or later)
+func AddAlias(clusterURL string, httpClient *http.Client, index, alias string, writeIndex bool) error {
+	add := map[string]interface{}{
+		"index": index,
+		"alias": alias,
+	}
+
+	if writeIndex {
+		add["is_write_index"] = true
+	}
+
+	return updateAliases(clusterURL, httpClient, []map[string]interface{}{
+		{"add": add},
+	})
+}
+
+// RemoveAlias removes the given alias from the index
+func RemoveAlias(clusterURL string, httpClient *http.Client, index, alias string) error {
+	return updateAliases(clusterURL, httpClient, []map[string]interface{}{
+		{"remove": map[string]string{"index": index, "alias": alias}},
+	})
+}
+
+// SwapAlias atomically points the given alias only to the index, removing it from the other indices
+// Indices the alias pointed to are returned
+func SwapAlias(clusterURL string, httpClient *http.Client, alias, index string) ([]string, error) {
+	current, err := AliasIndices(clusterURL, httpClient, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []map[string]interface{}{}
+
+	for _, i := range current {
+		if i == index {
+			continue
+		}
+
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]string{"index": i, "alias": alias},
+		})
+	}
+
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]string{"index": index, "alias": alias},
+	})
+
+	if err := updateAliases(clusterURL, httpClient, actions); err != nil {
+		return nil, err
+	}
+
+	return current, nil
+}
+
+// updateAliases applies the given actions in one request, so that they take effect atomically
+func updateAliases(clusterURL string, httpClient *http.Client, actions []map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return errors.Wrap(err, "failed to build aliases request")
+	}
+
+	if _, err := requestWithBody(clusterURL, httpClient, http.MethodPost, "/_aliases", url.Values{}, body); err != nil {
+		return errors.Wrap(err, "failed to update aliases")
+	}
+
+	return nil
+}
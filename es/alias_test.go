@@ -0,0 +1,56 @@
+package es
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSwapAlias(t *testing.T) {
+	var body []byte
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(`{"logs-1": {"aliases": {"logs": {}}}, "logs-2": {"aliases": {"logs": {}, "logs-read": {}}}, "logs-3": {"aliases": {}}}`))
+		case "POST":
+			body, _ = ioutil.ReadAll(r.Body)
+			w.Write([]byte(`{"acknowledged": true}`))
+		}
+	}))
+	defer ts.Close()
+
+	got, err := SwapAlias(ts.URL, &http.Client{}, "logs", "logs-3")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if expected := []string{"logs-1", "logs-2"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("previous indices do not match. expected: %q, got: %q", expected, got)
+	}
+
+	expected := `{"actions":[{"remove":{"alias":"logs","index":"logs-1"}},{"remove":{"alias":"logs","index":"logs-2"}},{"add":{"alias":"logs","index":"logs-3"}}]}`
+
+	if string(body) != expected {
+		t.Errorf("body does not match. expected: %s, got: %s", expected, body)
+	}
+}
+
+func TestAddAlias(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if expected := `{"actions":[{"add":{"alias":"logs","index":"logs-3","is_write_index":true}}]}`; string(body) != expected {
+			t.Errorf("body does not match. expected: %s, got: %s", expected, body)
+		}
+
+		w.Write([]byte(`{"acknowledged": true}`))
+	}))
+	defer ts.Close()
+
+	if err := AddAlias(ts.URL, &http.Client{}, "logs-3", "logs", true); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
@@ -0,0 +1,130 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadOnlyAllowDeleteBlock represents block set by Elasticsearch when node exceeds flood-stage disk watermark
+const ReadOnlyAllowDeleteBlock = "read_only_allow_delete"
+
+// blockSettingPrefix represents prefix of index settings of blocks, e.g. index.blocks.write
+const blockSettingPrefix = "index.blocks."
+
+// IndexBlocks represents blocks set on index
+type IndexBlocks struct {
+	Index  string
+	Blocks []string
+}
+
+// BlockedIndices returns indices having any of the given blocks, or any block if blocks is empty, sorted by name
+func BlockedIndices(clusterURL string, httpClient *http.Client, blocks []string) ([]*IndexBlocks, error) {
+	query := url.Values{}
+	query.Set("flat_settings", "true")
+
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/_all/_settings/index.blocks.*", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse index settings")
+	}
+
+	indices := []*IndexBlocks{}
+
+	for index, s := range resp {
+		set := []string{}
+
+		for k, v := range s.Settings {
+			// Values are strings in flat settings, but booleans given at index creation may be kept as is
+			if !strings.HasPrefix(k, blockSettingPrefix) || !isTrue(v) {
+				continue
+			}
+
+			block := strings.TrimPrefix(k, blockSettingPrefix)
+
+			if len(blocks) > 0 && !containsString(blocks, block) {
+				continue
+			}
+
+			set = append(set, block)
+		}
+
+		if len(set) == 0 {
+			continue
+		}
+
+		sort.Strings(set)
+
+		indices = append(indices, &IndexBlocks{
+			Index:  index,
+			Blocks: set,
+		})
+	}
+
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i].Index < indices[j].Index
+	})
+
+	return indices, nil
+}
+
+// ClearBlocks removes the given blocks from the given indices
+// Settings are reset to null instead of false, so that the indices look as if the blocks were never set
+func ClearBlocks(clusterURL string, httpClient *http.Client, indices, blocks []string) error {
+	if len(indices) == 0 || len(blocks) == 0 {
+		return nil
+	}
+
+	settings := map[string]interface{}{}
+
+	for _, b := range blocks {
+		settings[blockSettingPrefix+b] = nil
+	}
+
+	body, err := json.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode index settings")
+	}
+
+	escaped := make([]string, 0, len(indices))
+
+	for _, index := range indices {
+		escaped = append(escaped, url.PathEscape(index))
+	}
+
+	_, err = requestWithBody(clusterURL, httpClient, http.MethodPut, "/"+strings.Join(escaped, ",")+"/_settings", url.Values{}, body)
+
+	return err
+}
+
+func isTrue(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+
+	return false
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,78 @@
+package es
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBlockedIndices(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_all/_settings/index.blocks.*" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		w.Write([]byte(`{
+  "logs-1": {"settings": {"index.blocks.read_only_allow_delete": "true"}},
+  "logs-2": {"settings": {"index.blocks.write": "true", "index.blocks.read_only_allow_delete": "false"}},
+  "logs-3": {"settings": {}},
+  "logs-4": {"settings": {"index.blocks.write": true, "index.blocks.read_only_allow_delete": "true"}}
+}`))
+	}))
+	defer ts.Close()
+
+	testcases := []struct {
+		blocks   []string
+		expected []*IndexBlocks
+	}{
+		{
+			blocks: []string{ReadOnlyAllowDeleteBlock},
+			expected: []*IndexBlocks{
+				{Index: "logs-1", Blocks: []string{"read_only_allow_delete"}},
+				{Index: "logs-4", Blocks: []string{"read_only_allow_delete"}},
+			},
+		},
+		{
+			blocks: nil,
+			expected: []*IndexBlocks{
+				{Index: "logs-1", Blocks: []string{"read_only_allow_delete"}},
+				{Index: "logs-2", Blocks: []string{"write"}},
+				{Index: "logs-4", Blocks: []string{"read_only_allow_delete", "write"}},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		got, err := BlockedIndices(ts.URL, &http.Client{}, tc.blocks)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("blocked indices do not match. expected: %+v, got: %+v", tc.expected, got)
+		}
+	}
+}
+
+func TestClearBlocks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/logs-1,logs-4/_settings" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if expected := `{"index.blocks.read_only_allow_delete":null}`; string(body) != expected {
+			t.Errorf("body does not match. expected: %s, got: %s", expected, body)
+		}
+
+		w.Write([]byte(`{"acknowledged": true}`))
+	}))
+	defer ts.Close()
+
+	if err := ClearBlocks(ts.URL, &http.Client{}, []string{"logs-1", "logs-4"}, []string{ReadOnlyAllowDeleteBlock}); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
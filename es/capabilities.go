@@ -0,0 +1,182 @@
+package es
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+// Capabilities represents APIs available in the cluster, derived from its distribution and version
+type Capabilities struct {
+	Distribution string
+	Version      string
+
+	major int
+	minor int
+}
+
+var (
+	capabilities   = map[string]*Capabilities{}
+	capabilitiesMu sync.Mutex
+)
+
+// NewCapabilities creates Capabilities of the given distribution and version
+func NewCapabilities(distribution, version string) (*Capabilities, error) {
+	major, minor, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Capabilities{
+		Distribution: distribution,
+		Version:      version,
+		major:        major,
+		minor:        minor,
+	}, nil
+}
+
+// DetectCapabilities returns Capabilities of the given cluster
+// Root API is requested only once per cluster URL in the process, and the result is reused
+func DetectCapabilities(clusterURL string, httpClient *http.Client) (*Capabilities, error) {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+
+	if c, ok := capabilities[clusterURL]; ok {
+		return c, nil
+	}
+
+	distribution, version, err := DetectDistribution(clusterURL, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewCapabilities(distribution, version)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities[clusterURL] = c
+
+	return c, nil
+}
+
+// String returns distribution and version, e.g. "opensearch 2.11.0"
+func (c *Capabilities) String() string {
+	return c.Distribution + " " + c.Version
+}
+
+// VersionBucket returns distribution and major version, e.g. "elasticsearch 7.x"
+func (c *Capabilities) VersionBucket() string {
+	return fmt.Sprintf("%s %d.x", c.Distribution, c.major)
+}
+
+// DetectedCapabilities returns Capabilities of clusters detected so far in the process
+func DetectedCapabilities() []*Capabilities {
+	capabilitiesMu.Lock()
+	defer capabilitiesMu.Unlock()
+
+	result := make([]*Capabilities, 0, len(capabilities))
+
+	for _, c := range capabilities {
+		result = append(result, c)
+	}
+
+	return result
+}
+
+// OpenSearch returns whether the cluster is OpenSearch
+func (c *Capabilities) OpenSearch() bool {
+	return c.Distribution == DistributionOpenSearch
+}
+
+// Supported returns whether esnctl has API client for the cluster
+func (c *Capabilities) Supported() bool {
+	if c.OpenSearch() {
+		return true
+	}
+
+	switch c.major {
+	case 1, 2, 5, 6, 7, 8:
+		return true
+	}
+
+	return false
+}
+
+// SupportsShutdownAPI returns whether _cluster/nodes/<node>/_shutdown is available
+// It was removed in Elasticsearch 2.0
+func (c *Capabilities) SupportsShutdownAPI() bool {
+	return !c.OpenSearch() && c.major < 2
+}
+
+// SupportsVotingExclusions returns whether _cluster/voting_config_exclusions is available
+// It was added in Elasticsearch 7.0, and OpenSearch inherits it
+func (c *Capabilities) SupportsVotingExclusions() bool {
+	return c.OpenSearch() || c.major >= 7
+}
+
+// SupportsSLM returns whether snapshot lifecycle management (_slm/policy) is available
+// It was added in Elasticsearch 7.4. OpenSearch has snapshot management plugin with different API instead
+func (c *Capabilities) SupportsSLM() bool {
+	return !c.OpenSearch() && c.atLeast(7, 4)
+}
+
+// SupportsNodeShutdownAPI returns whether _nodes/<node>/shutdown is available
+// It was added in Elasticsearch 7.15
+func (c *Capabilities) SupportsNodeShutdownAPI() bool {
+	return !c.OpenSearch() && c.atLeast(7, 15)
+}
+
+// SupportsRollover returns whether _rollover is available
+// It was added in Elasticsearch 5.0
+func (c *Capabilities) SupportsRollover() bool {
+	return c.OpenSearch() || c.major >= 5
+}
+
+// SupportsMLUpgradeMode returns whether machine learning upgrade mode is available
+// It was added in Elasticsearch 6.7. OpenSearch does not have anomaly detection jobs of Elasticsearch
+func (c *Capabilities) SupportsMLUpgradeMode() bool {
+	return !c.OpenSearch() && c.atLeast(6, 7)
+}
+
+// SupportsZoneDecommission returns whether _cluster/decommission/awareness is available
+// It was added in OpenSearch 2.4
+func (c *Capabilities) SupportsZoneDecommission() bool {
+	return c.OpenSearch() && c.atLeast(2, 4)
+}
+
+// SupportsComposableTemplates returns whether composable index templates (_index_template) are available
+// They were added in Elasticsearch 7.8, and OpenSearch inherits them
+func (c *Capabilities) SupportsComposableTemplates() bool {
+	return c.OpenSearch() || c.atLeast(7, 8)
+}
+
+// Require returns validation error with the given advice if supported is false, so that flows fail before changing cluster
+// e.g. c.Require(c.SupportsRollover(), "rollover API", "Roll over the alias manually")
+func (c *Capabilities) Require(supported bool, feature, advice string) error {
+	if supported {
+		return nil
+	}
+
+	return exitcode.Errorf(exitcode.Validation, "%s is not available in %s. %s", feature, c, advice)
+}
+
+// Features returns whether each version dependent API is supported by the cluster
+func (c *Capabilities) Features() []Feature {
+	fs := []Feature{}
+
+	for _, f := range features {
+		fs = append(fs, Feature{
+			Name:      f.name,
+			Supported: f.supported(c),
+		})
+	}
+
+	return fs
+}
+
+func (c *Capabilities) atLeast(major, minor int) bool {
+	return c.major > major || (c.major == major && c.minor >= minor)
+}
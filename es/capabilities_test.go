@@ -0,0 +1,115 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+func TestCapabilities_openSearch(t *testing.T) {
+	testcases := []struct {
+		version  string
+		expected []Feature
+	}{
+		{
+			version: "2.3.0",
+			expected: []Feature{
+				{Name: "shutdown API", Supported: false},
+				{Name: "voting exclusions", Supported: true},
+				{Name: "snapshot lifecycle management", Supported: false},
+				{Name: "node shutdown API", Supported: false},
+				{Name: "rollover API", Supported: true},
+				{Name: "ML upgrade mode", Supported: false},
+				{Name: "zone decommission API", Supported: false},
+				{Name: "composable index templates", Supported: true},
+			},
+		},
+		{
+			version: "2.11.0",
+			expected: []Feature{
+				{Name: "shutdown API", Supported: false},
+				{Name: "voting exclusions", Supported: true},
+				{Name: "snapshot lifecycle management", Supported: false},
+				{Name: "node shutdown API", Supported: false},
+				{Name: "rollover API", Supported: true},
+				{Name: "ML upgrade mode", Supported: false},
+				{Name: "zone decommission API", Supported: true},
+				{Name: "composable index templates", Supported: true},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		c, err := NewCapabilities(DistributionOpenSearch, tc.version)
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+			continue
+		}
+
+		if !c.Supported() {
+			t.Errorf("OpenSearch %s should be supported", tc.version)
+		}
+
+		if got := c.Features(); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("features do not match. version: %s, expected: %v, got: %v", tc.version, tc.expected, got)
+		}
+	}
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	requests := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"version": {"distribution": "opensearch", "number": "2.11.0"}}`))
+	}))
+	defer ts.Close()
+
+	for i := 0; i < 2; i++ {
+		c, err := DetectCapabilities(ts.URL, &http.Client{})
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		if c.String() != "opensearch 2.11.0" {
+			t.Errorf("capabilities do not match. got: %s", c)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("root API should be requested only once. got: %d", requests)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	c, err := NewCapabilities(DistributionElasticsearch, "2.3.0")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if err := c.Require(c.SupportsShutdownAPI(), "shutdown API", ""); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised. got: %v", err)
+	}
+
+	if err := c.Require(c.SupportsZoneDecommission(), "zone decommission API", "Use esnctl remove instead."); err == nil || err.Error() != "zone decommission API is not available in elasticsearch 2.3.0. Use esnctl remove instead." {
+		t.Errorf("error message does not match. got: %v", err)
+	}
+
+	if err := c.Require(!c.SupportsShutdownAPI(), "whatever", ""); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestCapabilities_VersionBucket(t *testing.T) {
+	c, err := NewCapabilities(DistributionElasticsearch, "7.17.3")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got, expected := c.VersionBucket(), DistributionElasticsearch+" 7.x"; got != expected {
+		t.Errorf("version bucket does not match. expected: %q, got: %q", expected, got)
+	}
+}
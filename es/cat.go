@@ -0,0 +1,64 @@
+package es
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CatAPIs represents _cat APIs which Cat supports
+var CatAPIs = []string{
+	"allocation",
+	"indices",
+	"nodes",
+	"recovery",
+	"shards",
+}
+
+// Cat calls the given _cat API and returns response body as is
+// args are appended to the path, e.g. index names of _cat/shards
+// JSON array is returned if asJSON is true, otherwise table with header
+func Cat(clusterURL string, httpClient *http.Client, api string, args []string, asJSON bool) ([]byte, error) {
+	if !isCatAPI(api) {
+		return nil, errors.Errorf("_cat/%s is not supported, must be one of %s", api, strings.Join(CatAPIs, ", "))
+	}
+
+	query := url.Values{}
+
+	if asJSON {
+		query.Set("format", "json")
+	} else {
+		query.Set("v", "true")
+	}
+
+	return cat(clusterURL, httpClient, api, args, query)
+}
+
+// cat sends GET request to _cat API with the given query
+func cat(clusterURL string, httpClient *http.Client, api string, args []string, query url.Values) ([]byte, error) {
+	path := "/_cat/" + api
+
+	if len(args) > 0 {
+		escaped := make([]string, 0, len(args))
+
+		for _, arg := range args {
+			escaped = append(escaped, url.PathEscape(arg))
+		}
+
+		path += "/" + strings.Join(escaped, ",")
+	}
+
+	return request(clusterURL, httpClient, "GET", path, query)
+}
+
+func isCatAPI(api string) bool {
+	for _, a := range CatAPIs {
+		if a == api {
+			return true
+		}
+	}
+
+	return false
+}
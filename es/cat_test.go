@@ -0,0 +1,61 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCat(t *testing.T) {
+	var (
+		gotURL  string
+		gotUser string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotUser, _, _ = r.BasicAuth()
+
+		w.Write([]byte("index shard prirep state\nlogs  0     p      STARTED\n"))
+	}))
+	defer ts.Close()
+
+	clusterURL := "http://elastic:secret@" + strings.TrimPrefix(ts.URL, "http://")
+
+	testcases := []struct {
+		api      string
+		args     []string
+		asJSON   bool
+		expected string
+	}{
+		{api: "nodes", expected: "/_cat/nodes?v=true"},
+		{api: "shards", args: []string{"logs-2018.01.01", "metrics"}, expected: "/_cat/shards/logs-2018.01.01,metrics?v=true"},
+		{api: "recovery", asJSON: true, expected: "/_cat/recovery?format=json"},
+	}
+
+	for _, tc := range testcases {
+		body, err := Cat(clusterURL, &http.Client{}, tc.api, tc.args, tc.asJSON)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		if gotURL != tc.expected {
+			t.Errorf("request URL does not match. expected: %q, got: %q", tc.expected, gotURL)
+		}
+
+		if gotUser != "elastic" {
+			t.Errorf("credentials in cluster URL should be used. got user: %q", gotUser)
+		}
+
+		if !strings.HasPrefix(string(body), "index shard") {
+			t.Errorf("response body should be returned as is. got: %q", string(body))
+		}
+	}
+}
+
+func TestCat_unsupported(t *testing.T) {
+	if _, err := Cat("http://localhost:9200", &http.Client{}, "health", []string{}, false); err == nil {
+		t.Errorf("error should be raised for unsupported API")
+	}
+}
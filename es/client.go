@@ -1,11 +1,49 @@
 package es
 
+import (
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/es/stats"
+)
+
 // Client represents innterface of Elasticsearch API client
 type Client interface {
+	ClearNodeShutdown(nodeName string) error
+	ClearVotingExclusions() error
+	ClusterHealth() (*stats.Health, error)
+	ClusterSettings() (map[string]string, error)
+	CreateDocument(index, docType, id string, doc []byte) (bool, error)
+	CreateSnapshot(repository, name string) error
+	DeleteDocument(index, docType, id string) error
+	DeleteDocumentIfVersion(index, docType, id string, version *stats.DocumentVersion) (bool, error)
 	DisableReallocation() error
+	DiskUsage() (map[string]float64, error)
 	EnableReallocation() error
 	ExcludeNodeFromAllocation(nodeName string) error
+	ExcludeNodeFromIndexAllocation(index, nodeName string) error
+	GetDocument(index, docType, id string) ([]byte, error)
+	GetDocumentVersion(index, docType, id string) ([]byte, *stats.DocumentVersion, error)
+	GetSnapshot(repository, name string) (*snapshot.Snapshot, error)
+	IndexAllocationFilters() (map[string]map[string]string, error)
+	IndexDocument(index, docType string, doc []byte) error
+	IndexSettings(key string) (map[string]string, error)
 	ListNodes() ([]string, error)
+	ListShards() ([]*stats.Shard, error)
 	ListShardsOnNode(nodeName string) ([]string, error)
+	ListSnapshots(repository string) ([]*snapshot.Snapshot, error)
+	MLJobs() ([]*stats.MLJob, error)
+	NodeAttributes(nodeName string) (map[string]string, error)
+	NodeRoles() (map[string][]string, error)
+	NodeStats() ([]*stats.Node, error)
+	PrepareNodeShutdown(nodeName string) error
+	Reroute() error
+	RequireIndexAllocationAttribute(index, key, value string) error
+	ResetIndexSettings(index string, keys []string) error
+	Rollover(alias string) (string, error)
+	SLMPolicies() ([]*snapshot.Policy, error)
+	SetMLUpgradeMode(enabled bool) error
 	Shutdown(nodeName string) error
+	UpdateClusterSettings(settings map[string]string) error
+	UpdateDocumentIfVersion(index, docType, id string, doc []byte, version *stats.DocumentVersion) (bool, error)
+	UpdateIndexSettings(index string, settings map[string]string) error
+	WriteIndices() (map[string]string, error)
 }
@@ -0,0 +1,89 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dtan4/esnctl/es/v7"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DistributionElasticsearch represents Elasticsearch, which does not report distribution in root API
+	DistributionElasticsearch = v7.DistributionElasticsearch
+	// DistributionOpenSearch represents OpenSearch
+	DistributionOpenSearch = v7.DistributionOpenSearch
+)
+
+const (
+	// DecommissionSuccessful represents zone decommission which has finished
+	DecommissionSuccessful = "successful"
+	// DecommissionFailed represents zone decommission which has failed
+	DecommissionFailed = "failed"
+)
+
+// DetectDistribution returns distribution name and version number of the given endpoint
+func DetectDistribution(clusterURL string, httpClient *http.Client) (string, string, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/", url.Values{})
+	if err != nil {
+		return "", "", err
+	}
+
+	var resp struct {
+		OK      *struct{} `json:"OK"`
+		Version struct {
+			Distribution string `json:"distribution"`
+			Number       string `json:"number"`
+		} `json:"version"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", "", errors.Wrap(err, "invalid response body")
+	}
+
+	if resp.OK != nil {
+		return DistributionElasticsearch, "1.0.0", nil
+	}
+
+	if resp.Version.Number == "" {
+		return "", "", errors.New("version number field not found")
+	}
+
+	if resp.Version.Distribution == "" {
+		return DistributionElasticsearch, resp.Version.Number, nil
+	}
+
+	return resp.Version.Distribution, resp.Version.Number, nil
+}
+
+// DecommissionZone decommissions nodes which have the given value of awareness attribute
+func DecommissionZone(clusterURL string, httpClient *http.Client, attribute, zone string) error {
+	path := fmt.Sprintf("/_cluster/decommission/awareness/%s/%s", url.PathEscape(attribute), url.PathEscape(zone))
+
+	if _, err := request(clusterURL, httpClient, http.MethodPut, path, url.Values{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DecommissionStatus returns status of zone decommission of the given awareness attribute, e.g. draining, successful
+// Empty string is returned if no zone is decommissioned
+func DecommissionStatus(clusterURL string, httpClient *http.Client, attribute, zone string) (string, error) {
+	path := fmt.Sprintf("/_cluster/decommission/awareness/%s/_status", url.PathEscape(attribute))
+
+	body, err := request(clusterURL, httpClient, http.MethodGet, path, url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	var statuses map[string]string
+
+	if err := json.Unmarshal(body, &statuses); err != nil {
+		return "", errors.Wrap(err, "invalid response body")
+	}
+
+	return statuses[zone], nil
+}
@@ -0,0 +1,96 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectDistribution(t *testing.T) {
+	testcases := []struct {
+		body         string
+		distribution string
+		version      string
+	}{
+		{
+			body:         `{"OK":{}}`,
+			distribution: "elasticsearch",
+			version:      "1.0.0",
+		},
+		{
+			body:         `{"name": "node-1", "version": {"number": "6.8.23"}}`,
+			distribution: "elasticsearch",
+			version:      "6.8.23",
+		},
+		{
+			body:         `{"name": "node-1", "version": {"distribution": "opensearch", "number": "2.11.0"}}`,
+			distribution: "opensearch",
+			version:      "2.11.0",
+		},
+	}
+
+	for _, tc := range testcases {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tc.body))
+		}))
+
+		distribution, version, err := DetectDistribution(ts.URL, &http.Client{})
+		ts.Close()
+
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+			continue
+		}
+
+		if distribution != tc.distribution || version != tc.version {
+			t.Errorf("distribution does not match. expected: %s %s, got: %s %s", tc.distribution, tc.version, distribution, version)
+		}
+	}
+}
+
+func TestDecommissionZone(t *testing.T) {
+	var gotMethod, gotPath string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.Write([]byte(`{"acknowledged": true}`))
+	}))
+	defer ts.Close()
+
+	if err := DecommissionZone(ts.URL, &http.Client{}, "zone", "ap-northeast-1a"); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if gotMethod != "PUT" || gotPath != "/_cluster/decommission/awareness/zone/ap-northeast-1a" {
+		t.Errorf("unexpected request: %s %s", gotMethod, gotPath)
+	}
+}
+
+func TestDecommissionStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cluster/decommission/awareness/zone/_status" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+
+		w.Write([]byte(`{"ap-northeast-1a": "draining"}`))
+	}))
+	defer ts.Close()
+
+	got, err := DecommissionStatus(ts.URL, &http.Client{}, "zone", "ap-northeast-1a")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got != "draining" {
+		t.Errorf("status does not match. expected: draining, got: %s", got)
+	}
+
+	got, err = DecommissionStatus(ts.URL, &http.Client{}, "zone", "ap-northeast-1c")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got != "" {
+		t.Errorf("status of zone not decommissioned should be empty. got: %s", got)
+	}
+}
@@ -0,0 +1,85 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DiskUsage represents disk usage of data nodes in total
+type DiskUsage struct {
+	Nodes          int   `json:"nodes"`
+	TotalBytes     int64 `json:"total_bytes"`
+	AvailableBytes int64 `json:"available_bytes"`
+
+	// StoreBytes represents total size of shards, which grows with indices unlike the rest of used disk
+	StoreBytes int64 `json:"store_bytes"`
+}
+
+// UsedBytes returns used disk space including files other than shards
+func (d *DiskUsage) UsedBytes() int64 {
+	return d.TotalBytes - d.AvailableBytes
+}
+
+// ClusterDiskUsage returns disk usage of data nodes from _nodes/stats/fs,indices
+// Nodes without roles, i.e. Elasticsearch 2.x and older, are regarded as data nodes
+func ClusterDiskUsage(clusterURL string, httpClient *http.Client) (*DiskUsage, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/_nodes/stats/fs,indices", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Nodes map[string]struct {
+			Roles []string `json:"roles"`
+			FS    struct {
+				Total struct {
+					TotalInBytes     int64 `json:"total_in_bytes"`
+					AvailableInBytes int64 `json:"available_in_bytes"`
+				} `json:"total"`
+			} `json:"fs"`
+			Indices struct {
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"indices"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse nodes stats")
+	}
+
+	usage := &DiskUsage{}
+
+	for _, n := range resp.Nodes {
+		if !hasDataRole(n.Roles) {
+			continue
+		}
+
+		usage.Nodes++
+		usage.TotalBytes += n.FS.Total.TotalInBytes
+		usage.AvailableBytes += n.FS.Total.AvailableInBytes
+		usage.StoreBytes += n.Indices.Store.SizeInBytes
+	}
+
+	return usage, nil
+}
+
+// hasDataRole returns whether node holds shards, e.g. data, data_hot or data_content role
+func hasDataRole(roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+
+	for _, r := range roles {
+		if strings.HasPrefix(r, "data") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClusterDiskUsage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_nodes/stats/fs,indices" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		w.Write([]byte(`{"nodes": {
+  "n1": {"roles": ["data", "ingest"], "fs": {"total": {"total_in_bytes": 1000, "available_in_bytes": 400}}, "indices": {"store": {"size_in_bytes": 500}}},
+  "n2": {"roles": ["data_hot"], "fs": {"total": {"total_in_bytes": 1000, "available_in_bytes": 600}}, "indices": {"store": {"size_in_bytes": 300}}},
+  "n3": {"roles": ["master"], "fs": {"total": {"total_in_bytes": 100, "available_in_bytes": 90}}, "indices": {"store": {"size_in_bytes": 0}}}
+}}`))
+	}))
+	defer ts.Close()
+
+	got, err := ClusterDiskUsage(ts.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := DiskUsage{Nodes: 2, TotalBytes: 2000, AvailableBytes: 1000, StoreBytes: 800}
+
+	if *got != expected {
+		t.Errorf("disk usage does not match. expected: %+v, got: %+v", expected, *got)
+	}
+
+	if got.UsedBytes() != 1000 {
+		t.Errorf("used bytes does not match. expected: 1000, got: %d", got.UsedBytes())
+	}
+}
@@ -12,16 +12,47 @@ import (
 	"github.com/dtan4/esnctl/es/v2"
 	"github.com/dtan4/esnctl/es/v5"
 	"github.com/dtan4/esnctl/es/v6"
+	"github.com/dtan4/esnctl/es/v7"
 	"github.com/pkg/errors"
 )
 
 // New creates and returns appropriate Elasticsearch client
+// clusterURL can be comma-separated list of URLs, and requests fail over to the next URL on connection errors
 func New(clusterURL string, httpClient *http.Client) (Client, error) {
-	version, err := DetectVersion(clusterURL, httpClient)
+	urls := SplitURLs(clusterURL)
+	clusterURL = urls[0]
+
+	if len(urls) > 1 {
+		c, err := withFailover(httpClient, urls[0], urls, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		httpClient = c
+	}
+
+	return newClient(clusterURL, httpClient)
+}
+
+// newClient creates client of the distribution and version detected from the given endpoint
+func newClient(clusterURL string, httpClient *http.Client) (Client, error) {
+	c, err := DetectCapabilities(clusterURL, httpClient)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to detect Elasticsearch version")
 	}
 
+	distribution, version := c.Distribution, c.Version
+
+	// OpenSearch 1.x and 2.x are compatible with Elasticsearch 7.10, not with Elasticsearch of the same major version
+	if c.OpenSearch() {
+		client, err := v7.NewClient(clusterURL, httpClient, distribution, version)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create OpenSearch API client")
+		}
+
+		return client, nil
+	}
+
 	digits := strings.Split(version, ".")
 
 	switch digits[0] {
@@ -52,6 +83,13 @@ func New(clusterURL string, httpClient *http.Client) (Client, error) {
 			return nil, errors.Wrap(err, "failed to create Elasticsearch API client")
 		}
 
+		return client, nil
+	case "7", "8":
+		client, err := v7.NewClient(clusterURL, httpClient, distribution, version)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create Elasticsearch API client")
+		}
+
 		return client, nil
 	}
 
@@ -120,3 +158,37 @@ func DetectVersion(clusterURL string, httpClient *http.Client) (string, error) {
 
 	return vn, nil
 }
+
+// SetCredentials sets username and password to the given cluster URLs
+// If username is empty, username in URL is kept. Returns error if no username is available
+func SetCredentials(clusterURL, username, password string) (string, error) {
+	urls := []string{}
+
+	for _, rawurl := range SplitURLs(clusterURL) {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return "", errors.Wrap(err, "cluster URL is invalid")
+		}
+
+		name := username
+
+		if name == "" && u.User != nil {
+			name = u.User.Username()
+		}
+
+		if name == "" {
+			return "", errors.Errorf("username must be specified in cluster URL %s to set password", u.Host)
+		}
+
+		u.User = url.UserPassword(name, password)
+		urls = append(urls, u.String())
+	}
+
+	return strings.Join(urls, ","), nil
+}
+
+// SetPassword sets password to the given cluster URLs, keeping username
+// Returns error if username is not specified in URL
+func SetPassword(clusterURL, password string) (string, error) {
+	return SetCredentials(clusterURL, "", password)
+}
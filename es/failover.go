@@ -0,0 +1,161 @@
+package es
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// failoverTransport sends requests to the current endpoint, and fails over to the next endpoint on connection errors
+// The endpoint which succeeded last is kept for subsequent requests
+// If spread is greater than 0, requests are distributed among the first spread endpoints in round robin instead
+type failoverTransport struct {
+	base      http.RoundTripper
+	primary   string
+	endpoints []*url.URL
+	spread    int
+
+	mu      sync.Mutex
+	current int
+	next    int
+}
+
+// SplitURLs splits comma-separated cluster URLs
+// The given string is returned as is if it has no URL, so the result always has at least one element
+func SplitURLs(clusterURL string) []string {
+	urls := []string{}
+
+	for _, u := range strings.Split(clusterURL, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+
+	if len(urls) == 0 {
+		return []string{clusterURL}
+	}
+
+	return urls
+}
+
+// withFailover returns http.Client which fails over requests for primary among the given URLs
+// Requests for the other hosts are sent as is
+func withFailover(httpClient *http.Client, primary string, urls []string, spread int) (*http.Client, error) {
+	p, err := url.Parse(primary)
+	if err != nil {
+		return nil, errors.Errorf("cluster URL %q is invalid", primary)
+	}
+
+	endpoints := []*url.URL{}
+
+	for _, rawurl := range urls {
+		u, err := url.Parse(rawurl)
+		if err != nil || u.Host == "" {
+			return nil, errors.Errorf("cluster URL %q is invalid", rawurl)
+		}
+
+		endpoints = append(endpoints, u)
+	}
+
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	c := *httpClient
+	c.Transport = &failoverTransport{
+		base:      base,
+		primary:   p.Host,
+		endpoints: endpoints,
+		spread:    spread,
+	}
+
+	return &c, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.primary {
+		return t.base.RoundTrip(req)
+	}
+
+	var body []byte
+
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+
+		body = b
+	}
+
+	start := t.start()
+
+	var lastErr error
+
+	for i := 0; i < len(t.endpoints); i++ {
+		n := (start + i) % len(t.endpoints)
+		e := t.endpoints[n]
+
+		r := *req
+		u := *req.URL
+		u.Scheme = e.Scheme
+		u.Host = e.Host
+		r.URL = &u
+		r.Host = ""
+
+		if e.User != nil {
+			r.Header = http.Header{}
+
+			for k, v := range req.Header {
+				r.Header[k] = v
+			}
+
+			password, _ := e.User.Password()
+			r.SetBasicAuth(e.User.Username(), password)
+		}
+
+		if req.Body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := t.base.RoundTrip(&r)
+		if err == nil {
+			t.mu.Lock()
+			t.current = n
+			t.mu.Unlock()
+
+			return resp, nil
+		}
+
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// start returns index of endpoint tried first
+func (t *failoverTransport) start() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.spread > 0 {
+		n := t.next % t.spread
+		t.next++
+
+		return n
+	}
+
+	return t.current
+}
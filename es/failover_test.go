@@ -0,0 +1,79 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSplitURLs(t *testing.T) {
+	testcases := []struct {
+		clusterURL string
+		expected   []string
+	}{
+		{
+			clusterURL: "http://example.com:9200",
+			expected:   []string{"http://example.com:9200"},
+		},
+		{
+			clusterURL: "http://es-1.example.com:9200, http://es-2.example.com:9200,",
+			expected:   []string{"http://es-1.example.com:9200", "http://es-2.example.com:9200"},
+		},
+	}
+
+	for _, tc := range testcases {
+		if got := SplitURLs(tc.clusterURL); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("URLs do not match. expected: %q, got: %q", tc.expected, got)
+		}
+	}
+}
+
+func TestWithFailover(t *testing.T) {
+	calls := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			t.Errorf("credentials of the endpoint should be used. got: %q, %q", user, pass)
+		}
+	}))
+	defer ts.Close()
+
+	// nothing listens on port 1
+	down := "http://127.0.0.1:1"
+	up := "http://user:pass@" + ts.Listener.Addr().String()
+
+	client, err := withFailover(&http.Client{}, down, []string{down, up}, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(down + "/_cat/nodes")
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	if calls != 2 {
+		t.Errorf("requests should be sent to the available endpoint. got: %d", calls)
+	}
+
+	if current := client.Transport.(*failoverTransport).current; current != 1 {
+		t.Errorf("available endpoint should be kept. got: %d", current)
+	}
+}
+
+func TestWithFailover_allDown(t *testing.T) {
+	client, err := withFailover(&http.Client{}, "http://127.0.0.1:1", []string{"http://127.0.0.1:1", "http://127.0.0.1:2"}, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if _, err := client.Get("http://127.0.0.1:1/"); err == nil {
+		t.Errorf("error should be raised if all endpoints are down")
+	}
+}
@@ -0,0 +1,92 @@
+package es
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Feature represents Elasticsearch API which is available only in some versions
+type Feature struct {
+	Name      string
+	Supported bool
+}
+
+var features = []struct {
+	name      string
+	supported func(c *Capabilities) bool
+}{
+	{
+		name:      "shutdown API",
+		supported: (*Capabilities).SupportsShutdownAPI,
+	},
+	{
+		name:      "voting exclusions",
+		supported: (*Capabilities).SupportsVotingExclusions,
+	},
+	{
+		name:      "snapshot lifecycle management",
+		supported: (*Capabilities).SupportsSLM,
+	},
+	{
+		name:      "node shutdown API",
+		supported: (*Capabilities).SupportsNodeShutdownAPI,
+	},
+	{
+		name:      "rollover API",
+		supported: (*Capabilities).SupportsRollover,
+	},
+	{
+		name:      "ML upgrade mode",
+		supported: (*Capabilities).SupportsMLUpgradeMode,
+	},
+	{
+		name:      "zone decommission API",
+		supported: (*Capabilities).SupportsZoneDecommission,
+	},
+	{
+		name:      "composable index templates",
+		supported: (*Capabilities).SupportsComposableTemplates,
+	},
+}
+
+// Features returns whether each version dependent API is supported by the given Elasticsearch version
+func Features(version string) ([]Feature, error) {
+	c, err := NewCapabilities(DistributionElasticsearch, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Features(), nil
+}
+
+// SupportedVersion returns whether esnctl has API client for the given Elasticsearch version
+// OpenSearch is supported regardless of version, so do not pass its version
+func SupportedVersion(version string) bool {
+	c, err := NewCapabilities(DistributionElasticsearch, version)
+	if err != nil {
+		return false
+	}
+
+	return c.Supported()
+}
+
+func parseVersion(version string) (int, int, error) {
+	digits := strings.Split(version, ".")
+	if len(digits) < 2 {
+		return 0, 0, errors.Errorf("version %q is invalid", version)
+	}
+
+	major, err := strconv.Atoi(digits[0])
+	if err != nil {
+		return 0, 0, errors.Errorf("version %q is invalid", version)
+	}
+
+	minor, err := strconv.Atoi(digits[1])
+	if err != nil {
+		return 0, 0, errors.Errorf("version %q is invalid", version)
+	}
+
+	return major, minor, nil
+}
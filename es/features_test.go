@@ -0,0 +1,91 @@
+package es
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	testcases := []struct {
+		version  string
+		expected []Feature
+	}{
+		{
+			version: "1.7.5",
+			expected: []Feature{
+				{Name: "shutdown API", Supported: true},
+				{Name: "voting exclusions", Supported: false},
+				{Name: "snapshot lifecycle management", Supported: false},
+				{Name: "node shutdown API", Supported: false},
+				{Name: "rollover API", Supported: false},
+				{Name: "ML upgrade mode", Supported: false},
+				{Name: "zone decommission API", Supported: false},
+				{Name: "composable index templates", Supported: false},
+			},
+		},
+		{
+			version: "7.10.2",
+			expected: []Feature{
+				{Name: "shutdown API", Supported: false},
+				{Name: "voting exclusions", Supported: true},
+				{Name: "snapshot lifecycle management", Supported: true},
+				{Name: "node shutdown API", Supported: false},
+				{Name: "rollover API", Supported: true},
+				{Name: "ML upgrade mode", Supported: true},
+				{Name: "zone decommission API", Supported: false},
+				{Name: "composable index templates", Supported: true},
+			},
+		},
+		{
+			version: "7.15.0",
+			expected: []Feature{
+				{Name: "shutdown API", Supported: false},
+				{Name: "voting exclusions", Supported: true},
+				{Name: "snapshot lifecycle management", Supported: true},
+				{Name: "node shutdown API", Supported: true},
+				{Name: "rollover API", Supported: true},
+				{Name: "ML upgrade mode", Supported: true},
+				{Name: "zone decommission API", Supported: false},
+				{Name: "composable index templates", Supported: true},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		got, err := Features(tc.version)
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("features do not match. version: %s, expected: %v, got: %v", tc.version, tc.expected, got)
+		}
+	}
+
+	if _, err := Features("latest"); err == nil {
+		t.Errorf("error should be raised for invalid version")
+	}
+}
+
+func TestSupportedVersion(t *testing.T) {
+	testcases := []struct {
+		version  string
+		expected bool
+	}{
+		{"1.0.0", true},
+		{"2.3.0", true},
+		{"5.2.2", true},
+		{"6.8.23", true},
+		{"7.10.2", true},
+		{"8.13.0", true},
+		{"9.0.0", false},
+		{"latest", false},
+	}
+
+	for _, tc := range testcases {
+		if got := SupportedVersion(tc.version); got != tc.expected {
+			t.Errorf("result does not match. version: %s, expected: %t, got: %t", tc.version, tc.expected, got)
+		}
+	}
+}
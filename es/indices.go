@@ -0,0 +1,82 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Index represents index summary returned by _cat/indices
+type Index struct {
+	Name      string `json:"name"`
+	Health    string `json:"health"`
+	Status    string `json:"status"`
+	Primaries int    `json:"primaries"`
+	Replicas  int    `json:"replicas"`
+	Docs      int64  `json:"docs"`
+
+	// StoreBytes represents total size of primaries and replicas
+	StoreBytes int64 `json:"store_bytes"`
+}
+
+// ListIndices returns every index in the cluster sorted by name
+// Counts of closed indices are left zero because _cat/indices does not report them
+func ListIndices(clusterURL string, httpClient *http.Client) ([]*Index, error) {
+	query := url.Values{}
+	query.Set("format", "json")
+	query.Set("bytes", "b")
+	query.Set("h", "health,status,index,pri,rep,docs.count,store.size")
+
+	body, err := cat(clusterURL, httpClient, "indices", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]*string
+
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, errors.Wrap(err, "failed to parse indices")
+	}
+
+	indices := make([]*Index, 0, len(rows))
+
+	for _, row := range rows {
+		indices = append(indices, &Index{
+			Name:       catString(row["index"]),
+			Health:     catString(row["health"]),
+			Status:     catString(row["status"]),
+			Primaries:  int(catInt(row["pri"])),
+			Replicas:   int(catInt(row["rep"])),
+			Docs:       catInt(row["docs.count"]),
+			StoreBytes: catInt(row["store.size"]),
+		})
+	}
+
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i].Name < indices[j].Name
+	})
+
+	return indices, nil
+}
+
+func catString(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+func catInt(s *string) int64 {
+	if s == nil {
+		return 0
+	}
+
+	n, _ := strconv.ParseInt(*s, 10, 64)
+
+	return n
+}
@@ -0,0 +1,38 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListIndices(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cat/indices" || r.URL.Query().Get("bytes") != "b" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+
+		w.Write([]byte(`[
+  {"health": "green", "status": "open", "index": "logs-2", "pri": "2", "rep": "1", "docs.count": "100", "store.size": "2048"},
+  {"health": null, "status": "close", "index": "logs-0", "pri": null, "rep": null, "docs.count": null, "store.size": null},
+  {"health": "yellow", "status": "open", "index": "logs-1", "pri": "1", "rep": "1", "docs.count": "10", "store.size": "512"}
+]`))
+	}))
+	defer ts.Close()
+
+	got, err := ListIndices(ts.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []*Index{
+		{Name: "logs-0", Status: "close"},
+		{Name: "logs-1", Health: "yellow", Status: "open", Primaries: 1, Replicas: 1, Docs: 10, StoreBytes: 512},
+		{Name: "logs-2", Health: "green", Status: "open", Primaries: 2, Replicas: 1, Docs: 100, StoreBytes: 2048},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("indices do not match. expected: %+v, got: %+v", expected, got)
+	}
+}
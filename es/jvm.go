@@ -0,0 +1,79 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// NodeJVM represents JVM and process limits of node, which should be the same among nodes launched from the same template
+type NodeJVM struct {
+	Name               string
+	HeapMaxBytes       int64
+	GCCollectors       []string
+	MaxFileDescriptors int64
+}
+
+// NodeJVMs returns JVM and process limits of every node from _nodes/jvm and _nodes/stats/process
+func NodeJVMs(clusterURL string, httpClient *http.Client) ([]*NodeJVM, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/_nodes/jvm", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Nodes map[string]struct {
+			Name string `json:"name"`
+			JVM  struct {
+				GCCollectors []string `json:"gc_collectors"`
+				Mem          struct {
+					HeapMaxInBytes int64 `json:"heap_max_in_bytes"`
+				} `json:"mem"`
+			} `json:"jvm"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, errors.Wrap(err, "failed to parse nodes info")
+	}
+
+	body, err = request(clusterURL, httpClient, http.MethodGet, "/_nodes/stats/process", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var stats struct {
+		Nodes map[string]struct {
+			Process struct {
+				MaxFileDescriptors int64 `json:"max_file_descriptors"`
+			} `json:"process"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, errors.Wrap(err, "failed to parse nodes stats")
+	}
+
+	nodes := []*NodeJVM{}
+
+	for id, n := range info.Nodes {
+		collectors := append([]string{}, n.JVM.GCCollectors...)
+		sort.Strings(collectors)
+
+		nodes = append(nodes, &NodeJVM{
+			Name:               n.Name,
+			HeapMaxBytes:       n.JVM.Mem.HeapMaxInBytes,
+			GCCollectors:       collectors,
+			MaxFileDescriptors: stats.Nodes[id].Process.MaxFileDescriptors,
+		})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	return nodes, nil
+}
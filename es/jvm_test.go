@@ -0,0 +1,42 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNodeJVMs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_nodes/jvm":
+			w.Write([]byte(`{"nodes": {
+  "n2": {"name": "ip-10-0-1-4.ec2.internal", "jvm": {"gc_collectors": ["old", "young"], "mem": {"heap_max_in_bytes": 1073741824}}},
+  "n1": {"name": "ip-10-0-1-2.ec2.internal", "jvm": {"gc_collectors": ["young", "old"], "mem": {"heap_max_in_bytes": 4294967296}}}
+}}`))
+		case "/_nodes/stats/process":
+			w.Write([]byte(`{"nodes": {
+  "n1": {"process": {"max_file_descriptors": 65536}},
+  "n2": {"process": {"max_file_descriptors": 4096}}
+}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	nodes, err := NodeJVMs(ts.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []*NodeJVM{
+		{Name: "ip-10-0-1-2.ec2.internal", HeapMaxBytes: 4294967296, GCCollectors: []string{"old", "young"}, MaxFileDescriptors: 65536},
+		{Name: "ip-10-0-1-4.ec2.internal", HeapMaxBytes: 1073741824, GCCollectors: []string{"old", "young"}, MaxFileDescriptors: 4096},
+	}
+
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("nodes do not match. expected: %+v, got: %+v", expected, nodes)
+	}
+}
@@ -0,0 +1,209 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/pkg/errors"
+)
+
+// IndexDocCounts returns the number of documents in primaries of indices matching the given pattern
+// _stats is used instead of _cat/indices?format=json, so that Elasticsearch 1.x and 2.x can be migrated from
+func IndexDocCounts(clusterURL string, httpClient *http.Client, pattern string) (map[string]int64, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/"+pattern+"/_stats/docs", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Indices map[string]struct {
+			Primaries struct {
+				Docs struct {
+					Count int64 `json:"count"`
+				} `json:"docs"`
+			} `json:"primaries"`
+		} `json:"indices"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse index stats")
+	}
+
+	counts := map[string]int64{}
+
+	for index, s := range resp.Indices {
+		counts[index] = s.Primaries.Docs.Count
+	}
+
+	return counts, nil
+}
+
+// RefreshIndices makes all documents in the given indices searchable, so that they are counted
+func RefreshIndices(clusterURL string, httpClient *http.Client, indices []string) error {
+	if _, err := request(clusterURL, httpClient, http.MethodPost, "/"+strings.Join(indices, ",")+"/_refresh", url.Values{}); err != nil {
+		return errors.Wrap(err, "failed to refresh indices")
+	}
+
+	return nil
+}
+
+// BlockWrites makes the given indices read-only, while they can still be searched and snapshotted
+func BlockWrites(clusterURL string, httpClient *http.Client, indices []string) error {
+	body := []byte(`{"index.blocks.write": true}`)
+
+	if _, err := requestWithBody(clusterURL, httpClient, http.MethodPut, "/"+strings.Join(indices, ",")+"/_settings", url.Values{}, body); err != nil {
+		return errors.Wrap(err, "failed to block writes to indices")
+	}
+
+	return nil
+}
+
+// CreateIndicesSnapshot starts snapshot of the given indices without cluster state
+func CreateIndicesSnapshot(clusterURL string, httpClient *http.Client, repository, name string, indices []string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"indices":              strings.Join(indices, ","),
+		"include_global_state": false,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to build snapshot request")
+	}
+
+	if _, err := requestWithBody(clusterURL, httpClient, http.MethodPut, "/_snapshot/"+repository+"/"+name, url.Values{}, body); err != nil {
+		return errors.Wrapf(err, "failed to create snapshot %s", name)
+	}
+
+	return nil
+}
+
+// GetIndicesSnapshot returns the given snapshot
+func GetIndicesSnapshot(clusterURL string, httpClient *http.Client, repository, name string) (*snapshot.Snapshot, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/_snapshot/"+repository+"/"+name, url.Values{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve snapshot %s", name)
+	}
+
+	snapshots, err := snapshot.Decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) == 0 {
+		return nil, errors.Errorf("snapshot %s not found", name)
+	}
+
+	return snapshots[0], nil
+}
+
+// RestoreIndicesSnapshot starts restoring the given indices from snapshot without cluster state
+// Restore fails if an open index with the same name exists
+func RestoreIndicesSnapshot(clusterURL string, httpClient *http.Client, repository, name string, indices []string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"indices":              strings.Join(indices, ","),
+		"include_global_state": false,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to build restore request")
+	}
+
+	if _, err := requestWithBody(clusterURL, httpClient, http.MethodPost, "/_snapshot/"+repository+"/"+name+"/_restore", url.Values{}, body); err != nil {
+		return errors.Wrapf(err, "failed to restore snapshot %s", name)
+	}
+
+	return nil
+}
+
+// IndicesHealth returns health of the given indices
+func IndicesHealth(clusterURL string, httpClient *http.Client, indices []string) (*stats.Health, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/_cluster/health/"+strings.Join(indices, ","), url.Values{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve health of indices")
+	}
+
+	var resp struct {
+		Status             string `json:"status"`
+		RelocatingShards   int    `json:"relocating_shards"`
+		InitializingShards int    `json:"initializing_shards"`
+		UnassignedShards   int    `json:"unassigned_shards"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse health of indices")
+	}
+
+	return &stats.Health{
+		Status:             resp.Status,
+		RelocatingShards:   resp.RelocatingShards,
+		InitializingShards: resp.InitializingShards,
+		UnassignedShards:   resp.UnassignedShards,
+	}, nil
+}
+
+// CopyAliases adds aliases of the given indices in source cluster to the indices with the same name in the cluster
+// Filter and routing of aliases are kept. The number of copied aliases is returned
+func CopyAliases(sourceURL string, sourceHTTPClient *http.Client, clusterURL string, httpClient *http.Client, indices []string) (int, error) {
+	body, err := request(sourceURL, sourceHTTPClient, http.MethodGet, "/"+strings.Join(indices, ",")+"/_alias", url.Values{})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve aliases")
+	}
+
+	var resp map[string]struct {
+		Aliases map[string]map[string]json.RawMessage `json:"aliases"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, errors.Wrap(err, "failed to parse aliases")
+	}
+
+	names := []string{}
+
+	for index := range resp {
+		names = append(names, index)
+	}
+
+	sort.Strings(names)
+
+	actions := []map[string]interface{}{}
+
+	for _, index := range names {
+		aliases := []string{}
+
+		for alias := range resp[index].Aliases {
+			aliases = append(aliases, alias)
+		}
+
+		sort.Strings(aliases)
+
+		for _, alias := range aliases {
+			add := map[string]interface{}{
+				"index": index,
+				"alias": alias,
+			}
+
+			for k, v := range resp[index].Aliases[alias] {
+				add[k] = v
+			}
+
+			actions = append(actions, map[string]interface{}{"add": add})
+		}
+	}
+
+	if len(actions) == 0 {
+		return 0, nil
+	}
+
+	reqBody, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to build aliases request")
+	}
+
+	if _, err := requestWithBody(clusterURL, httpClient, http.MethodPost, "/_aliases", url.Values{}, reqBody); err != nil {
+		return 0, errors.Wrap(err, "failed to add aliases")
+	}
+
+	return len(actions), nil
+}
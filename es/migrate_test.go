@@ -0,0 +1,35 @@
+package es
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCopyAliases(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"logs-2": {"aliases": {}}, "logs-1": {"aliases": {"logs-read": {}, "logs": {"index_routing": "1"}}}}`))
+	}))
+	defer source.Close()
+
+	dest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if expected := `{"actions":[{"add":{"alias":"logs","index":"logs-1","index_routing":"1"}},{"add":{"alias":"logs-read","index":"logs-1"}}]}`; string(body) != expected {
+			t.Errorf("body does not match. expected: %s, got: %s", expected, body)
+		}
+
+		w.Write([]byte(`{"acknowledged": true}`))
+	}))
+	defer dest.Close()
+
+	n, err := CopyAliases(source.URL, &http.Client{}, dest.URL, &http.Client{}, []string{"logs-1", "logs-2"})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if n != 2 {
+		t.Errorf("2 aliases should be copied. got: %d", n)
+	}
+}
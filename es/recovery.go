@@ -0,0 +1,99 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/pkg/errors"
+)
+
+// ActiveRecoveries returns shard recoveries in progress from _cat/recovery?active_only=true
+func ActiveRecoveries(clusterURL string, httpClient *http.Client) ([]*stats.Recovery, error) {
+	query := url.Values{}
+	query.Set("active_only", "true")
+	query.Set("bytes", "b")
+	query.Set("format", "json")
+	query.Set("time", "ms")
+
+	body, err := cat(clusterURL, httpClient, "recovery", []string{}, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRecoveries(body)
+}
+
+// ShardRecovery returns the latest recovery of the given shard onto the given node, or nil if there is none
+// Completed recoveries are included, so that duration and size can be read after the shard started
+func ShardRecovery(clusterURL string, httpClient *http.Client, index string, shard int, targetNode string) (*stats.Recovery, error) {
+	query := url.Values{}
+	query.Set("bytes", "b")
+	query.Set("format", "json")
+	query.Set("time", "ms")
+
+	body, err := cat(clusterURL, httpClient, "recovery", []string{index}, query)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveries, err := parseRecoveries(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *stats.Recovery
+
+	for _, r := range recoveries {
+		if r.Index == index && r.Shard == shard && r.TargetNode == targetNode {
+			found = r
+		}
+	}
+
+	return found, nil
+}
+
+// parseRecoveries parses _cat/recovery response in JSON with bytes and time in raw numbers
+func parseRecoveries(body []byte) ([]*stats.Recovery, error) {
+	var rows []struct {
+		Index          string `json:"index"`
+		Shard          string `json:"shard"`
+		Time           string `json:"time"`
+		Type           string `json:"type"`
+		Stage          string `json:"stage"`
+		SourceNode     string `json:"source_node"`
+		TargetNode     string `json:"target_node"`
+		BytesRecovered string `json:"bytes_recovered"`
+		BytesTotal     string `json:"bytes_total"`
+	}
+
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, errors.Wrap(err, "invalid response body")
+	}
+
+	recoveries := []*stats.Recovery{}
+
+	for _, row := range rows {
+		shard, _ := strconv.Atoi(row.Shard)
+		recovered, _ := strconv.ParseInt(row.BytesRecovered, 10, 64)
+		total, _ := strconv.ParseInt(row.BytesTotal, 10, 64)
+		ms, _ := strconv.ParseInt(row.Time, 10, 64)
+
+		recoveries = append(recoveries, &stats.Recovery{
+			Index:          row.Index,
+			Shard:          shard,
+			Type:           row.Type,
+			Stage:          row.Stage,
+			SourceNode:     row.SourceNode,
+			TargetNode:     row.TargetNode,
+			BytesRecovered: recovered,
+			BytesTotal:     total,
+			Time:           time.Duration(ms) * time.Millisecond,
+		})
+	}
+
+	return recoveries, nil
+}
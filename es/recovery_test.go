@@ -0,0 +1,68 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestActiveRecoveries(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cat/recovery" || r.URL.Query().Get("active_only") != "true" || r.URL.Query().Get("format") != "json" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+
+		w.Write([]byte(`[
+  {"index": "logs", "shard": "0", "time": "20000", "type": "peer", "stage": "index", "source_node": "node-1", "target_node": "node-2", "bytes_recovered": "1048576", "bytes_total": "4194304"}
+]`))
+	}))
+	defer ts.Close()
+
+	got, err := ActiveRecoveries(ts.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("number of recoveries does not match. expected: 1, got: %d", len(got))
+	}
+
+	r := got[0]
+
+	if r.Index != "logs" || r.SourceNode != "node-1" || r.TargetNode != "node-2" || r.Stage != "index" {
+		t.Errorf("recovery does not match. got: %+v", r)
+	}
+
+	if r.BytesRecovered != 1048576 || r.BytesTotal != 4194304 || r.Time != 20*time.Second {
+		t.Errorf("bytes and time do not match. got: %+v", r)
+	}
+}
+
+func TestShardRecovery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cat/recovery/logs" || r.URL.Query().Get("active_only") != "" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+
+		w.Write([]byte(`[
+  {"index": "logs", "shard": "0", "time": "1000", "type": "peer", "stage": "done", "source_node": "node-1", "target_node": "node-2", "bytes_recovered": "100", "bytes_total": "100"},
+  {"index": "logs", "shard": "0", "time": "2000", "type": "peer", "stage": "done", "source_node": "node-2", "target_node": "node-1", "bytes_recovered": "200", "bytes_total": "200"},
+  {"index": "logs", "shard": "1", "time": "3000", "type": "peer", "stage": "done", "source_node": "node-2", "target_node": "node-1", "bytes_recovered": "300", "bytes_total": "300"}
+]`))
+	}))
+	defer ts.Close()
+
+	got, err := ShardRecovery(ts.URL, &http.Client{}, "logs", 0, "node-1")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got == nil || got.BytesRecovered != 200 || got.Time != 2*time.Second {
+		t.Errorf("recovery does not match. got: %+v", got)
+	}
+
+	if got, _ := ShardRecovery(ts.URL, &http.Client{}, "logs", 0, "node-3"); got != nil {
+		t.Errorf("nil should be returned for unknown target. got: %+v", got)
+	}
+}
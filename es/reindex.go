@@ -0,0 +1,193 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ReindexRequest represents request of _reindex API
+type ReindexRequest struct {
+	Source string
+	Dest   string
+
+	// RemoteURL represents remote cluster to read Source from. Its host must be listed in reindex.remote.whitelist
+	RemoteURL string
+
+	// Slices is auto or the number of slices. Remote reindex cannot be sliced
+	Slices string
+
+	// RequestsPerSecond throttles reindex if positive
+	RequestsPerSecond float64
+
+	// Conflicts is abort (default) or proceed, which counts version conflicts instead of aborting
+	Conflicts string
+
+	// OnlyMissing creates documents missing in Dest and leaves existing ones, e.g. to resume failed reindex
+	OnlyMissing bool
+}
+
+// ReindexTask represents progress of reindex task, summed over slices
+type ReindexTask struct {
+	Completed bool
+
+	Total            int64
+	Created          int64
+	Updated          int64
+	Deleted          int64
+	VersionConflicts int64
+	Noops            int64
+
+	// Failures represents the number of failed bulk or search requests
+	Failures int
+
+	// Error represents error which stopped the task
+	Error string
+}
+
+// Done returns the number of documents processed so far
+func (t *ReindexTask) Done() int64 {
+	return t.Created + t.Updated + t.Deleted + t.VersionConflicts + t.Noops
+}
+
+// StartReindex starts reindex task and returns its ID
+func StartReindex(clusterURL string, httpClient *http.Client, req *ReindexRequest) (string, error) {
+	source := map[string]interface{}{
+		"index": req.Source,
+	}
+
+	if req.RemoteURL != "" {
+		u, err := url.Parse(req.RemoteURL)
+		if err != nil || u.Host == "" {
+			return "", errors.Errorf("remote cluster URL %q is invalid", req.RemoteURL)
+		}
+
+		remote := map[string]string{
+			"host": u.Scheme + "://" + u.Host,
+		}
+
+		if u.User != nil {
+			password, _ := u.User.Password()
+			remote["username"] = u.User.Username()
+			remote["password"] = password
+		}
+
+		source["remote"] = remote
+	}
+
+	dest := map[string]string{
+		"index": req.Dest,
+	}
+
+	body := map[string]interface{}{
+		"source": source,
+		"dest":   dest,
+	}
+
+	if req.OnlyMissing {
+		dest["op_type"] = "create"
+		body["conflicts"] = "proceed"
+	} else if req.Conflicts != "" {
+		body["conflicts"] = req.Conflicts
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to build reindex request")
+	}
+
+	query := url.Values{}
+	query.Set("wait_for_completion", "false")
+
+	if req.Slices != "" {
+		query.Set("slices", req.Slices)
+	}
+
+	if req.RequestsPerSecond > 0 {
+		query.Set("requests_per_second", strconv.FormatFloat(req.RequestsPerSecond, 'f', -1, 64))
+	}
+
+	respBody, err := requestWithBody(clusterURL, httpClient, http.MethodPost, "/_reindex", query, b)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to start reindex of %s", req.Source)
+	}
+
+	var resp struct {
+		Task string `json:"task"`
+	}
+
+	if err := json.Unmarshal(respBody, &resp); err != nil || resp.Task == "" {
+		return "", errors.Errorf("task of reindex is not returned: %s", respBody)
+	}
+
+	return resp.Task, nil
+}
+
+// GetReindexTask returns progress of the given reindex task
+func GetReindexTask(clusterURL string, httpClient *http.Client, taskID string) (*ReindexTask, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, "/_tasks/"+taskID, url.Values{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve task %s", taskID)
+	}
+
+	var resp struct {
+		Completed bool `json:"completed"`
+		Task      struct {
+			Status struct {
+				Total            int64 `json:"total"`
+				Created          int64 `json:"created"`
+				Updated          int64 `json:"updated"`
+				Deleted          int64 `json:"deleted"`
+				VersionConflicts int64 `json:"version_conflicts"`
+				Noops            int64 `json:"noops"`
+			} `json:"status"`
+		} `json:"task"`
+		Response struct {
+			Failures []json.RawMessage `json:"failures"`
+		} `json:"response"`
+		Error json.RawMessage `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse task")
+	}
+
+	s := resp.Task.Status
+
+	task := &ReindexTask{
+		Completed:        resp.Completed,
+		Total:            s.Total,
+		Created:          s.Created,
+		Updated:          s.Updated,
+		Deleted:          s.Deleted,
+		VersionConflicts: s.VersionConflicts,
+		Noops:            s.Noops,
+		Failures:         len(resp.Response.Failures),
+	}
+
+	if len(resp.Error) > 0 {
+		task.Error = string(resp.Error)
+	}
+
+	return task, nil
+}
+
+// RethrottleReindex changes requests per second of running reindex task. 0 or negative means unlimited
+func RethrottleReindex(clusterURL string, httpClient *http.Client, taskID string, requestsPerSecond float64) error {
+	rps := "-1"
+	if requestsPerSecond > 0 {
+		rps = strconv.FormatFloat(requestsPerSecond, 'f', -1, 64)
+	}
+
+	query := url.Values{}
+	query.Set("requests_per_second", rps)
+
+	if _, err := request(clusterURL, httpClient, http.MethodPost, "/_reindex/"+taskID+"/_rethrottle", query); err != nil {
+		return errors.Wrapf(err, "failed to rethrottle task %s", taskID)
+	}
+
+	return nil
+}
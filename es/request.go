@@ -0,0 +1,82 @@
+package es
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// request sends request without body to the given path and returns response body
+// It is used for APIs which are the same among Elasticsearch versions, instead of versioned Client
+func request(clusterURL string, httpClient *http.Client, method, path string, query url.Values) ([]byte, error) {
+	return requestWithBody(clusterURL, httpClient, method, path, query, nil)
+}
+
+// requestWithBody sends request with the given JSON body to the given path and returns response body
+func requestWithBody(clusterURL string, httpClient *http.Client, method, path string, query url.Values, body []byte) ([]byte, error) {
+	urls := SplitURLs(clusterURL)
+
+	if len(urls) > 1 {
+		c, err := withFailover(httpClient, urls[0], urls, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		httpClient = c
+	}
+
+	u, err := url.Parse(urls[0])
+	if err != nil || u.Host == "" {
+		return nil, errors.Errorf("cluster URL %q is invalid", urls[0])
+	}
+
+	endpoint := fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, path)
+
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	name := strings.TrimPrefix(path, "/")
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reqBody)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make %s request", name)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if u.User != nil {
+		password, _ := u.User.Password()
+		req.SetBasicAuth(u.User.Username(), password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to execute %s request", name)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to execute %s request. code: %d, body: %s", name, resp.StatusCode, respBody)
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,86 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ShardStore represents one copy of shard with its size
+type ShardStore struct {
+	Index      string
+	Shard      int
+	Primary    bool
+	State      string
+	Node       string
+	StoreBytes int64
+}
+
+// ShardStores returns every copy of every shard with its size from _cat/shards
+func ShardStores(clusterURL string, httpClient *http.Client) ([]*ShardStore, error) {
+	query := url.Values{}
+	query.Set("bytes", "b")
+	query.Set("format", "json")
+	query.Set("h", "index,shard,prirep,state,store,node")
+
+	body, err := cat(clusterURL, httpClient, "shards", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]*string
+
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, errors.Wrap(err, "failed to parse shards")
+	}
+
+	shards := make([]*ShardStore, 0, len(rows))
+
+	for _, row := range rows {
+		id, _ := strconv.Atoi(catString(row["shard"]))
+
+		// Relocating copy is shown as "source -> ip id target"
+		node := catString(row["node"])
+		if i := strings.Index(node, " -> "); i >= 0 {
+			node = node[:i]
+		}
+
+		shards = append(shards, &ShardStore{
+			Index:      catString(row["index"]),
+			Shard:      id,
+			Primary:    catString(row["prirep"]) == "p",
+			State:      catString(row["state"]),
+			Node:       node,
+			StoreBytes: catInt(row["store"]),
+		})
+	}
+
+	return shards, nil
+}
+
+// MoveShard relocates the copy of the given shard from one node to another with _cluster/reroute
+func MoveShard(clusterURL string, httpClient *http.Client, index string, shard int, fromNode, toNode string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"commands": []map[string]interface{}{
+			{
+				"move": map[string]interface{}{
+					"index":     index,
+					"shard":     shard,
+					"from_node": fromNode,
+					"to_node":   toNode,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode reroute commands")
+	}
+
+	_, err = requestWithBody(clusterURL, httpClient, http.MethodPost, "/_cluster/reroute", url.Values{}, body)
+
+	return err
+}
@@ -0,0 +1,60 @@
+package es
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestShardStores(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cat/shards" || r.URL.Query().Get("bytes") != "b" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+
+		w.Write([]byte(`[
+  {"index": "logs", "shard": "0", "prirep": "p", "state": "STARTED", "store": "1024", "node": "node-1"},
+  {"index": "logs", "shard": "0", "prirep": "r", "state": "RELOCATING", "store": "1024", "node": "node-2 -> 10.0.0.3 abc node-3"},
+  {"index": "logs", "shard": "1", "prirep": "r", "state": "UNASSIGNED", "store": null, "node": null}
+]`))
+	}))
+	defer ts.Close()
+
+	got, err := ShardStores(ts.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []*ShardStore{
+		{Index: "logs", Shard: 0, Primary: true, State: "STARTED", Node: "node-1", StoreBytes: 1024},
+		{Index: "logs", Shard: 0, Primary: false, State: "RELOCATING", Node: "node-2", StoreBytes: 1024},
+		{Index: "logs", Shard: 1, Primary: false, State: "UNASSIGNED"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("shards do not match. expected: %+v, got: %+v", expected, got)
+	}
+}
+
+func TestMoveShard(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/_cluster/reroute" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if expected := `{"commands":[{"move":{"from_node":"node-1","index":"logs","shard":0,"to_node":"node-2"}}]}`; string(body) != expected {
+			t.Errorf("body does not match. expected: %s, got: %s", expected, body)
+		}
+
+		w.Write([]byte(`{"acknowledged": true}`))
+	}))
+	defer ts.Close()
+
+	if err := MoveShard(ts.URL, &http.Client{}, "logs", 0, "node-1", "node-2"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Policy represents snapshot lifecycle management (SLM) policy
+type Policy struct {
+	ID         string
+	Schedule   string
+	Repository string
+
+	// NextExecution represents when the next snapshot of the policy is scheduled
+	NextExecution time.Time
+
+	// InProgress is true while snapshot of the policy is being taken
+	InProgress bool
+}
+
+// Imminent returns whether snapshot of the policy is being taken or scheduled within window from now
+func (p *Policy) Imminent(now time.Time, window time.Duration) bool {
+	if p.InProgress {
+		return true
+	}
+
+	return !p.NextExecution.IsZero() && p.NextExecution.Before(now.Add(window))
+}
+
+// DecodePolicies parses response of get SLM policy API, sorted by policy ID
+// https://www.elastic.co/guide/en/elasticsearch/reference/7.17/slm-api-get-policy.html
+func DecodePolicies(body []byte) ([]*Policy, error) {
+	var resp map[string]struct {
+		Policy struct {
+			Schedule   string `json:"schedule"`
+			Repository string `json:"repository"`
+		} `json:"policy"`
+		NextExecutionMillis int64           `json:"next_execution_millis"`
+		InProgress          json.RawMessage `json:"in_progress"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return []*Policy{}, errors.Wrap(err, "invalid response body")
+	}
+
+	policies := []*Policy{}
+
+	for id, p := range resp {
+		policy := &Policy{
+			ID:         id,
+			Schedule:   p.Policy.Schedule,
+			Repository: p.Policy.Repository,
+			InProgress: len(p.InProgress) > 0 && string(p.InProgress) != "null",
+		}
+
+		if p.NextExecutionMillis > 0 {
+			policy.NextExecution = fromMillis(p.NextExecutionMillis)
+		}
+
+		policies = append(policies, policy)
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].ID < policies[j].ID
+	})
+
+	return policies, nil
+}
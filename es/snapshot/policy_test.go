@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodePolicies(t *testing.T) {
+	body := []byte(`{
+  "nightly": {
+    "version": 1,
+    "modified_date_millis": 1514764800000,
+    "policy": {
+      "name": "<nightly-snap-{now/d}>",
+      "schedule": "0 30 1 * * ?",
+      "repository": "backup",
+      "config": {"indices": ["*"]}
+    },
+    "next_execution_millis": 1514856600000,
+    "stats": {"policy": "nightly"}
+  },
+  "hourly": {
+    "version": 1,
+    "modified_date_millis": 1514764800000,
+    "policy": {
+      "name": "<hourly-snap-{now/h}>",
+      "schedule": "0 0 * * * ?",
+      "repository": "backup"
+    },
+    "next_execution_millis": 1514772000000,
+    "in_progress": {
+      "name": "hourly-snap-2018.01.01-01",
+      "uuid": "b9B1kZsQQ2eyE4L8V5dXMg",
+      "state": "STARTED",
+      "start_time_millis": 1514768400000
+    }
+  }
+}`)
+
+	got, err := DecodePolicies(body)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("number of policies does not match. expected: 2, got: %d", len(got))
+	}
+
+	if got[0].ID != "hourly" || !got[0].InProgress {
+		t.Errorf("hourly policy should be in progress. got: %+v", got[0])
+	}
+
+	expected := time.Date(2018, 1, 2, 1, 30, 0, 0, time.UTC)
+
+	if got[1].ID != "nightly" || got[1].InProgress || !got[1].NextExecution.Equal(expected) || got[1].Repository != "backup" {
+		t.Errorf("nightly policy does not match. got: %+v", got[1])
+	}
+
+	now := time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	if got[1].Imminent(now, time.Hour) {
+		t.Errorf("nightly policy should not be imminent 1.5 hours before")
+	}
+
+	if !got[1].Imminent(now, 2*time.Hour) {
+		t.Errorf("nightly policy should be imminent within 2 hours")
+	}
+}
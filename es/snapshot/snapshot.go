@@ -0,0 +1,91 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// StateInProgress represents snapshot being taken
+	StateInProgress = "IN_PROGRESS"
+	// StateSuccess represents snapshot completed without failure
+	StateSuccess = "SUCCESS"
+)
+
+// Snapshot represents snapshot in repository
+type Snapshot struct {
+	Name             string
+	State            string
+	Indices          []string
+	StartTime        time.Time
+	EndTime          time.Time
+	TotalShards      int
+	SuccessfulShards int
+	FailedShards     int
+}
+
+// Done returns whether the snapshot is no longer in progress
+func (s *Snapshot) Done() bool {
+	return s.State != StateInProgress
+}
+
+// Duration returns how long the snapshot took, or has taken so far if in progress
+func (s *Snapshot) Duration() time.Duration {
+	if s.EndTime.IsZero() {
+		return time.Since(s.StartTime)
+	}
+
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Decode parses response of get snapshot API, which is common among Elasticsearch 1.x - 6.x
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/modules-snapshots.html
+func Decode(body []byte) ([]*Snapshot, error) {
+	var resp struct {
+		Snapshots []struct {
+			Snapshot          string   `json:"snapshot"`
+			State             string   `json:"state"`
+			Indices           []string `json:"indices"`
+			StartTimeInMillis int64    `json:"start_time_in_millis"`
+			EndTimeInMillis   int64    `json:"end_time_in_millis"`
+			Shards            struct {
+				Total      int `json:"total"`
+				Successful int `json:"successful"`
+				Failed     int `json:"failed"`
+			} `json:"shards"`
+		} `json:"snapshots"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return []*Snapshot{}, errors.Wrap(err, "invalid response body")
+	}
+
+	snapshots := []*Snapshot{}
+
+	for _, s := range resp.Snapshots {
+		snapshot := &Snapshot{
+			Name:             s.Snapshot,
+			State:            s.State,
+			Indices:          s.Indices,
+			StartTime:        fromMillis(s.StartTimeInMillis),
+			TotalShards:      s.Shards.Total,
+			SuccessfulShards: s.Shards.Successful,
+			FailedShards:     s.Shards.Failed,
+		}
+
+		// end_time_in_millis is 0 while in progress
+		if s.EndTimeInMillis > 0 {
+			snapshot.EndTime = fromMillis(s.EndTimeInMillis)
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)).UTC()
+}
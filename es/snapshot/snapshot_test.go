@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	body := []byte(`{
+  "snapshots": [
+    {
+      "snapshot": "esnctl-20180101000000",
+      "indices": ["logs-2018.01.01"],
+      "state": "SUCCESS",
+      "start_time": "2018-01-01T00:00:00.000Z",
+      "start_time_in_millis": 1514764800000,
+      "end_time": "2018-01-01T00:01:30.500Z",
+      "end_time_in_millis": 1514764890500,
+      "duration_in_millis": 90500,
+      "failures": [],
+      "shards": {"total": 5, "failed": 0, "successful": 5}
+    },
+    {
+      "snapshot": "esnctl-20180102000000",
+      "indices": ["logs-2018.01.02"],
+      "state": "IN_PROGRESS",
+      "start_time_in_millis": 1514851200000,
+      "end_time_in_millis": 0,
+      "shards": {"total": 0, "failed": 0, "successful": 0}
+    }
+  ]
+}`)
+
+	got, err := Decode(body)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []*Snapshot{
+		&Snapshot{
+			Name:             "esnctl-20180101000000",
+			State:            StateSuccess,
+			Indices:          []string{"logs-2018.01.01"},
+			StartTime:        time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:          time.Date(2018, 1, 1, 0, 1, 30, 500*int(time.Millisecond), time.UTC),
+			TotalShards:      5,
+			SuccessfulShards: 5,
+		},
+		&Snapshot{
+			Name:      "esnctl-20180102000000",
+			State:     StateInProgress,
+			Indices:   []string{"logs-2018.01.02"},
+			StartTime: time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("snapshots do not match. expected: %+v, got: %+v", expected, got)
+	}
+
+	if !got[0].Done() || got[1].Done() {
+		t.Errorf("only completed snapshot should be done")
+	}
+
+	if d := got[0].Duration(); d != 90500*time.Millisecond {
+		t.Errorf("duration does not match. expected: 1m30.5s, got: %s", d)
+	}
+}
+
+func TestDecode_invalid(t *testing.T) {
+	if _, err := Decode([]byte(`<html>`)); err == nil {
+		t.Errorf("error should be raised")
+	}
+}
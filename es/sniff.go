@@ -0,0 +1,122 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type nodesHTTPResponse struct {
+	Nodes map[string]struct {
+		Roles []string `json:"roles"`
+		HTTP  struct {
+			PublishAddress string `json:"publish_address"`
+		} `json:"http"`
+	} `json:"nodes"`
+}
+
+// NewWithSniffing creates Elasticsearch client which distributes requests among nodes discovered from the cluster
+// Seed URLs given by clusterURL are used if discovered nodes are unavailable or discovery fails
+func NewWithSniffing(clusterURL string, httpClient *http.Client) (Client, error) {
+	seeds := SplitURLs(clusterURL)
+
+	seedClient := httpClient
+
+	if len(seeds) > 1 {
+		c, err := withFailover(httpClient, seeds[0], seeds, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		seedClient = c
+	}
+
+	nodes, err := Sniff(seeds[0], seedClient)
+	if err != nil || len(nodes) == 0 {
+		return newClient(seeds[0], seedClient)
+	}
+
+	c, err := withFailover(httpClient, seeds[0], append(nodes, seeds...), len(nodes))
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(seeds[0], c)
+}
+
+// Sniff returns URLs of data and coordinating nodes retrieved from _nodes/http API
+// Scheme and credentials of the given URL are used for node URLs
+func Sniff(clusterURL string, httpClient *http.Client) ([]string, error) {
+	u, err := url.Parse(clusterURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "cluster URL is invalid")
+	}
+
+	var prefix string
+
+	if u.User == nil {
+		prefix = fmt.Sprintf("%s://", u.Scheme)
+	} else {
+		prefix = fmt.Sprintf("%s://%s@", u.Scheme, u.User.String())
+	}
+
+	req, err := http.NewRequest("GET", prefix+u.Host+"/_nodes/http", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make http request")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve nodes")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to retrieve nodes. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	var r nodesHTTPResponse
+
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, errors.Wrap(err, "invalid response body")
+	}
+
+	urls := []string{}
+
+	for _, node := range r.Nodes {
+		if len(node.Roles) == 1 && node.Roles[0] == "master" {
+			continue
+		}
+
+		if addr := parsePublishAddress(node.HTTP.PublishAddress); addr != "" {
+			urls = append(urls, prefix+addr)
+		}
+	}
+
+	sort.Strings(urls)
+
+	return urls, nil
+}
+
+// parsePublishAddress extracts host:port from publish_address
+// e.g. "10.0.1.21:9200", "ip-10-0-1-21/10.0.1.21:9200", "inet[/10.0.1.21:9200]" (1.x)
+func parsePublishAddress(address string) string {
+	address = strings.TrimSuffix(strings.TrimPrefix(address, "inet["), "]")
+
+	if i := strings.LastIndex(address, "/"); i >= 0 {
+		address = address[i+1:]
+	}
+
+	return address
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"time"
+)
+
+// Node represents resource usage of node
+type Node struct {
+	Name        string
+	CPUPercent  float64
+	HeapPercent float64
+	DiskPercent float64
+	Load1m      float64
+	Shards      int
+
+	// StoreBytes represents total size of shards stored on node
+	StoreBytes int64
+}
+
+// DocumentVersion represents version of document used for optimistic concurrency control
+// Version is compared by Elasticsearch 6.x and older, SeqNo and PrimaryTerm by 7.x and later
+type DocumentVersion struct {
+	Version     int64
+	SeqNo       int64
+	PrimaryTerm int64
+}
+
+// Health represents cluster health
+type Health struct {
+	Status             string
+	RelocatingShards   int
+	InitializingShards int
+	UnassignedShards   int
+}
+
+// Recovery represents shard recovery in progress, e.g. relocation or replica recovery
+type Recovery struct {
+	Index      string
+	Shard      int
+	Type       string
+	Stage      string
+	SourceNode string
+	TargetNode string
+
+	BytesRecovered int64
+	BytesTotal     int64
+
+	// Time represents how long the recovery has been running
+	Time time.Duration
+}
+
+// Task represents task running on node, returned by _tasks API
+type Task struct {
+	// ID represents task ID in node_id:task_number format
+	ID          string        `json:"id"`
+	Node        string        `json:"node"`
+	Action      string        `json:"action"`
+	Description string        `json:"description"`
+	StartTime   time.Time     `json:"start_time"`
+	RunningTime time.Duration `json:"running_time_in_nanos"`
+	Cancellable bool          `json:"cancellable"`
+	ParentID    string        `json:"parent_task_id,omitempty"`
+}
+
+// PendingTask represents cluster-state update task waiting in master queue
+type PendingTask struct {
+	InsertOrder int           `json:"insert_order"`
+	Priority    string        `json:"priority"`
+	Source      string        `json:"source"`
+	TimeInQueue time.Duration `json:"time_in_queue_in_nanos"`
+	Executing   bool          `json:"executing"`
+}
+
+// Shard represents one copy of shard
+type Shard struct {
+	Index   string
+	Shard   int
+	Primary bool
+	State   string
+
+	// Node represents node holding the copy. Source node is set for relocating copy
+	Node string
+}
+
+// Started returns whether the copy can serve requests, i.e. it is started or relocating away
+func (s *Shard) Started() bool {
+	return s.State == "STARTED" || s.State == "RELOCATING"
+}
+
+// MLJob represents machine learning anomaly detection job
+type MLJob struct {
+	ID    string
+	State string
+
+	// Node represents node running the job. Empty if the job is not assigned
+	Node string
+}
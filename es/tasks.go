@@ -0,0 +1,154 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/pkg/errors"
+)
+
+// Tasks returns tasks running in cluster from _tasks API
+// actions filters tasks by comma-separated action patterns, e.g. "*reindex,cluster:*". Empty means all tasks
+func Tasks(clusterURL string, httpClient *http.Client, actions string) ([]*stats.Task, error) {
+	query := url.Values{}
+	query.Set("detailed", "true")
+
+	if actions != "" {
+		query.Set("actions", actions)
+	}
+
+	body, err := request(clusterURL, httpClient, "GET", "/_tasks", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Nodes map[string]struct {
+			Name  string `json:"name"`
+			Tasks map[string]struct {
+				Node               string `json:"node"`
+				Action             string `json:"action"`
+				Description        string `json:"description"`
+				StartTimeInMillis  int64  `json:"start_time_in_millis"`
+				RunningTimeInNanos int64  `json:"running_time_in_nanos"`
+				Cancellable        bool   `json:"cancellable"`
+				ParentTaskID       string `json:"parent_task_id"`
+			} `json:"tasks"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "invalid response body")
+	}
+
+	tasks := []*stats.Task{}
+
+	for _, n := range resp.Nodes {
+		for id, t := range n.Tasks {
+			tasks = append(tasks, &stats.Task{
+				ID:          id,
+				Node:        n.Name,
+				Action:      t.Action,
+				Description: t.Description,
+				StartTime:   time.Unix(0, t.StartTimeInMillis*int64(time.Millisecond)),
+				RunningTime: time.Duration(t.RunningTimeInNanos),
+				Cancellable: t.Cancellable,
+				ParentID:    t.ParentTaskID,
+			})
+		}
+	}
+
+	// Long-running tasks are the suspicious ones
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].RunningTime != tasks[j].RunningTime {
+			return tasks[i].RunningTime > tasks[j].RunningTime
+		}
+
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return tasks, nil
+}
+
+// CancelTask cancels the given task via _tasks/<id>/_cancel
+func CancelTask(clusterURL string, httpClient *http.Client, id string) error {
+	if !strings.Contains(id, ":") {
+		return errors.Errorf("task ID %q is invalid, must be node_id:task_number", id)
+	}
+
+	body, err := request(clusterURL, httpClient, "POST", "/_tasks/"+url.PathEscape(id)+"/_cancel", url.Values{})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		NodeFailures []struct {
+			Reason string `json:"reason"`
+		} `json:"node_failures"`
+		TaskFailures []struct {
+			Reason struct {
+				Reason string `json:"reason"`
+			} `json:"reason"`
+		} `json:"task_failures"`
+		Nodes map[string]interface{} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return errors.Wrap(err, "invalid response body")
+	}
+
+	if len(resp.TaskFailures) > 0 {
+		return errors.Errorf("failed to cancel task %s: %s", id, resp.TaskFailures[0].Reason.Reason)
+	}
+
+	if len(resp.NodeFailures) > 0 {
+		return errors.Errorf("failed to cancel task %s: %s", id, resp.NodeFailures[0].Reason)
+	}
+
+	if len(resp.Nodes) == 0 {
+		return errors.Errorf("task %s is not found", id)
+	}
+
+	return nil
+}
+
+// PendingTasks returns cluster-state update tasks queued in master from _cluster/pending_tasks
+func PendingTasks(clusterURL string, httpClient *http.Client) ([]*stats.PendingTask, error) {
+	body, err := request(clusterURL, httpClient, "GET", "/_cluster/pending_tasks", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Tasks []struct {
+			InsertOrder       int    `json:"insert_order"`
+			Priority          string `json:"priority"`
+			Source            string `json:"source"`
+			TimeInQueueMillis int64  `json:"time_in_queue_millis"`
+			Executing         bool   `json:"executing"`
+		} `json:"tasks"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "invalid response body")
+	}
+
+	tasks := []*stats.PendingTask{}
+
+	for _, t := range resp.Tasks {
+		tasks = append(tasks, &stats.PendingTask{
+			InsertOrder: t.InsertOrder,
+			Priority:    t.Priority,
+			Source:      t.Source,
+			TimeInQueue: time.Duration(t.TimeInQueueMillis) * time.Millisecond,
+			Executing:   t.Executing,
+		})
+	}
+
+	return tasks, nil
+}
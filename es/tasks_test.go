@@ -0,0 +1,114 @@
+package es
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTasks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_tasks" || r.URL.Query().Get("actions") != "*reindex" || r.URL.Query().Get("detailed") != "true" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+
+		w.Write([]byte(`{
+  "nodes": {
+    "oTUltX4IQMOUUVeiohTt8A": {
+      "name": "node-1",
+      "tasks": {
+        "oTUltX4IQMOUUVeiohTt8A:12": {"node": "oTUltX4IQMOUUVeiohTt8A", "action": "indices:data/write/reindex", "description": "reindex from [a] to [b]", "start_time_in_millis": 1514764800000, "running_time_in_nanos": 5000000000, "cancellable": true},
+        "oTUltX4IQMOUUVeiohTt8A:13": {"node": "oTUltX4IQMOUUVeiohTt8A", "action": "indices:data/write/reindex", "start_time_in_millis": 1514764800000, "running_time_in_nanos": 60000000000, "cancellable": true, "parent_task_id": "oTUltX4IQMOUUVeiohTt8A:12"}
+      }
+    }
+  }
+}`))
+	}))
+	defer ts.Close()
+
+	got, err := Tasks(ts.URL, &http.Client{}, "*reindex")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("number of tasks does not match. expected: 2, got: %d", len(got))
+	}
+
+	if got[0].ID != "oTUltX4IQMOUUVeiohTt8A:13" || got[0].RunningTime != time.Minute || got[0].ParentID != "oTUltX4IQMOUUVeiohTt8A:12" {
+		t.Errorf("longest task should come first. got: %+v", got[0])
+	}
+
+	if got[1].Node != "node-1" || got[1].Description != "reindex from [a] to [b]" || !got[1].Cancellable {
+		t.Errorf("task does not match. got: %+v", got[1])
+	}
+}
+
+func TestCancelTask(t *testing.T) {
+	testcases := []struct {
+		body    string
+		wantErr bool
+	}{
+		{
+			body:    `{"nodes": {"oTUltX4IQMOUUVeiohTt8A": {"tasks": {}}}}`,
+			wantErr: false,
+		},
+		{
+			body:    `{"task_failures": [{"reason": {"type": "illegal_argument_exception", "reason": "task [x] doesn't support cancellation"}}], "nodes": {}}`,
+			wantErr: true,
+		},
+		{
+			body:    `{"nodes": {}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		var gotMethod, gotPath string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotMethod, gotPath = r.Method, r.URL.Path
+			w.Write([]byte(tc.body))
+		}))
+
+		err := CancelTask(ts.URL, &http.Client{}, "oTUltX4IQMOUUVeiohTt8A:12")
+		ts.Close()
+
+		if gotMethod != "POST" || gotPath != "/_tasks/oTUltX4IQMOUUVeiohTt8A:12/_cancel" {
+			t.Errorf("unexpected request: %s %s", gotMethod, gotPath)
+		}
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("error does not match. body: %s, wantErr: %t, got: %v", tc.body, tc.wantErr, err)
+		}
+	}
+
+	if err := CancelTask("http://localhost:9200", &http.Client{}, "12"); err == nil {
+		t.Errorf("error should be raised for task ID without node")
+	}
+}
+
+func TestPendingTasks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cluster/pending_tasks" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+
+		w.Write([]byte(`{"tasks": [{"insert_order": 101, "priority": "URGENT", "source": "create-index [foo], cause [api]", "executing": true, "time_in_queue_millis": 86000, "time_in_queue": "1.4m"}]}`))
+	}))
+	defer ts.Close()
+
+	got, err := PendingTasks(ts.URL, &http.Client{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("number of tasks does not match. expected: 1, got: %d", len(got))
+	}
+
+	if got[0].InsertOrder != 101 || got[0].Priority != "URGENT" || !got[0].Executing || got[0].TimeInQueue != 86*time.Second {
+		t.Errorf("pending task does not match. got: %+v", got[0])
+	}
+}
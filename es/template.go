@@ -0,0 +1,152 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Template represents index template
+type Template struct {
+	Name     string   `json:"name"`
+	Patterns []string `json:"index_patterns"`
+	Order    int      `json:"order"`
+
+	// Composable is true for composable index template (_index_template), and false for legacy one (_template)
+	Composable bool `json:"composable"`
+}
+
+// templatePath returns API path of the given template, or of all templates if name is empty
+func templatePath(name string, composable bool) string {
+	path := "/_template"
+	if composable {
+		path = "/_index_template"
+	}
+
+	if name != "" {
+		path += "/" + name
+	}
+
+	return path
+}
+
+// ListTemplates returns index templates sorted by name
+// Composable templates have priority instead of order, which is returned as Order
+func ListTemplates(clusterURL string, httpClient *http.Client, composable bool) ([]*Template, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, templatePath("", composable), url.Values{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list index templates")
+	}
+
+	templates := []*Template{}
+
+	if composable {
+		var resp struct {
+			IndexTemplates []struct {
+				Name          string `json:"name"`
+				IndexTemplate struct {
+					IndexPatterns []string `json:"index_patterns"`
+					Priority      int      `json:"priority"`
+				} `json:"index_template"`
+			} `json:"index_templates"`
+		}
+
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, errors.Wrap(err, "failed to parse index templates")
+		}
+
+		for _, t := range resp.IndexTemplates {
+			templates = append(templates, &Template{
+				Name:       t.Name,
+				Patterns:   t.IndexTemplate.IndexPatterns,
+				Order:      t.IndexTemplate.Priority,
+				Composable: true,
+			})
+		}
+	} else {
+		// Elasticsearch 5.x and older have single pattern in template field instead of index_patterns
+		var resp map[string]struct {
+			IndexPatterns []string `json:"index_patterns"`
+			Template      string   `json:"template"`
+			Order         int      `json:"order"`
+		}
+
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, errors.Wrap(err, "failed to parse index templates")
+		}
+
+		for name, t := range resp {
+			patterns := t.IndexPatterns
+			if len(patterns) == 0 && t.Template != "" {
+				patterns = []string{t.Template}
+			}
+
+			templates = append(templates, &Template{
+				Name:     name,
+				Patterns: patterns,
+				Order:    t.Order,
+			})
+		}
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates, nil
+}
+
+// GetTemplate returns body of the given index template, which can be put again as is
+func GetTemplate(clusterURL string, httpClient *http.Client, name string, composable bool) (json.RawMessage, error) {
+	body, err := request(clusterURL, httpClient, http.MethodGet, templatePath(name, composable), url.Values{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve index template %s", name)
+	}
+
+	if composable {
+		var resp struct {
+			IndexTemplates []struct {
+				Name          string          `json:"name"`
+				IndexTemplate json.RawMessage `json:"index_template"`
+			} `json:"index_templates"`
+		}
+
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, errors.Wrap(err, "failed to parse index template")
+		}
+
+		for _, t := range resp.IndexTemplates {
+			if t.Name == name {
+				return t.IndexTemplate, nil
+			}
+		}
+	} else {
+		var resp map[string]json.RawMessage
+
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, errors.Wrap(err, "failed to parse index template")
+		}
+
+		if t, ok := resp[name]; ok {
+			return t, nil
+		}
+	}
+
+	return nil, errors.Errorf("index template %s not found", name)
+}
+
+// PutTemplate creates or replaces the given index template
+func PutTemplate(clusterURL string, httpClient *http.Client, name string, composable bool, body []byte) error {
+	if !json.Valid(body) {
+		return errors.Errorf("index template %s is not valid JSON", name)
+	}
+
+	if _, err := requestWithBody(clusterURL, httpClient, http.MethodPut, templatePath(name, composable), url.Values{}, body); err != nil {
+		return errors.Wrapf(err, "failed to put index template %s", name)
+	}
+
+	return nil
+}
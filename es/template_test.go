@@ -0,0 +1,102 @@
+package es
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListTemplates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_template":
+			w.Write([]byte(`{"logs": {"order": 1, "index_patterns": ["logs-*"]}, "legacy": {"order": 0, "template": "old-*"}}`))
+		case "/_index_template":
+			w.Write([]byte(`{"index_templates": [{"name": "metrics", "index_template": {"index_patterns": ["metrics-*"], "priority": 100}}]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	got, err := ListTemplates(ts.URL, &http.Client{}, false)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []*Template{
+		{Name: "legacy", Patterns: []string{"old-*"}},
+		{Name: "logs", Patterns: []string{"logs-*"}, Order: 1},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("templates do not match. expected: %+v, got: %+v", expected, got)
+	}
+
+	got, err = ListTemplates(ts.URL, &http.Client{}, true)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected = []*Template{
+		{Name: "metrics", Patterns: []string{"metrics-*"}, Order: 100, Composable: true},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("templates do not match. expected: %+v, got: %+v", expected, got)
+	}
+}
+
+func TestGetTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_template/logs":
+			w.Write([]byte(`{"logs": {"order": 1}}`))
+		case "/_index_template/metrics":
+			w.Write([]byte(`{"index_templates": [{"name": "metrics", "index_template": {"priority": 100}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	got, err := GetTemplate(ts.URL, &http.Client{}, "logs", false)
+	if err != nil || string(got) != `{"order": 1}` {
+		t.Errorf("legacy template does not match. got: %s, error: %v", got, err)
+	}
+
+	got, err = GetTemplate(ts.URL, &http.Client{}, "metrics", true)
+	if err != nil || string(got) != `{"priority": 100}` {
+		t.Errorf("composable template does not match. got: %s, error: %v", got, err)
+	}
+
+	if _, err := GetTemplate(ts.URL, &http.Client{}, "missing", false); err == nil {
+		t.Errorf("error should be raised for missing template")
+	}
+}
+
+func TestPutTemplate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/_index_template/metrics" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != `{"index_patterns": ["metrics-*"]}` {
+			t.Errorf("body does not match. got: %s", body)
+		}
+
+		w.Write([]byte(`{"acknowledged": true}`))
+	}))
+	defer ts.Close()
+
+	if err := PutTemplate(ts.URL, &http.Client{}, "metrics", true, []byte(`{"index_patterns": ["metrics-*"]}`)); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if err := PutTemplate(ts.URL, &http.Client{}, "metrics", true, []byte(`{`)); err == nil {
+		t.Errorf("error should be raised for invalid JSON")
+	}
+}
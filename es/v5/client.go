@@ -1,13 +1,18 @@
 package v5
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/es/stats"
 	"github.com/pkg/errors"
 	"gopkg.in/olivere/elastic.v5"
 )
@@ -41,6 +46,7 @@ func NewClient(clusterURL string, httpClient *http.Client) (*Client, error) {
 	client, err = elastic.NewClient(
 		elastic.SetURL(clusterEndpoint),
 		elastic.SetSniff(false),
+		elastic.SetHttpClient(httpClient),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create Elasticsearch API client")
@@ -54,6 +60,198 @@ func NewClient(clusterURL string, httpClient *http.Client) (*Client, error) {
 	}, nil
 }
 
+// ClearNodeShutdown does nothing, because node shutdown API was introduced in Elasticsearch 7.15
+func (c *Client) ClearNodeShutdown(nodeName string) error {
+	return nil
+}
+
+// ClearVotingExclusions does nothing, because voting configuration exclusions were introduced in Elasticsearch 7.0
+func (c *Client) ClearVotingExclusions() error {
+	return nil
+}
+
+// ClusterHealth returns status and the number of shards not started of cluster
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cluster-health.html
+func (c *Client) ClusterHealth() (*stats.Health, error) {
+	body, err := c.get("/_cluster/health", "cluster-health")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Status             string `json:"status"`
+		RelocatingShards   int    `json:"relocating_shards"`
+		InitializingShards int    `json:"initializing_shards"`
+		UnassignedShards   int    `json:"unassigned_shards"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "invalid response body")
+	}
+
+	return &stats.Health{
+		Status:             resp.Status,
+		RelocatingShards:   resp.RelocatingShards,
+		InitializingShards: resp.InitializingShards,
+		UnassignedShards:   resp.UnassignedShards,
+	}, nil
+}
+
+// ClusterSettings returns cluster settings in flat format. Transient settings take precedence over persistent ones
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cluster-update-settings.html
+func (c *Client) ClusterSettings() (map[string]string, error) {
+	body, err := c.get("/_cluster/settings?flat_settings=true", "cluster-settings")
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	var resp struct {
+		Persistent map[string]interface{} `json:"persistent"`
+		Transient  map[string]interface{} `json:"transient"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return map[string]string{}, errors.Wrap(err, "invalid response body")
+	}
+
+	settings := map[string]string{}
+
+	for _, s := range []map[string]interface{}{resp.Persistent, resp.Transient} {
+		for k, v := range s {
+			settings[k] = fmt.Sprint(v)
+		}
+	}
+
+	return settings, nil
+}
+
+// CreateDocument stores the given JSON document with the given ID only if it does not exist yet
+// Returns false if the document already exists
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/docs-index_.html#operation-type
+func (c *Client) CreateDocument(index, docType, id string, doc []byte) (bool, error) {
+	endpoint := c.clusterEndpoint + "/" + index + "/" + docType + "/" + url.PathEscape(id) + "/_create"
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(doc))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to make CreateDocument request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to execute CreateDocument request")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read response body")
+	}
+
+	return false, errors.Errorf("failed to execute CreateDocument request. code: %d, body: %s", resp.StatusCode, body)
+}
+
+// CreateSnapshot starts taking snapshot of all indices into the given repository
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/modules-snapshots.html
+func (c *Client) CreateSnapshot(repository, name string) error {
+	endpoint := c.clusterEndpoint + "/_snapshot/" + repository + "/" + name
+
+	req, err := http.NewRequest("PUT", endpoint, strings.NewReader(`{}`))
+	if err != nil {
+		return errors.Wrap(err, "failed to make CreateSnapshot request")
+	}
+	defer req.Body.Close()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute CreateSnapshot request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute CreateSnapshot request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// DeleteDocument deletes the document with the given ID
+// Does nothing if the document does not exist
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/docs-delete.html
+func (c *Client) DeleteDocument(index, docType, id string) error {
+	endpoint := c.clusterEndpoint + "/" + index + "/" + docType + "/" + url.PathEscape(id)
+
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to make DeleteDocument request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute DeleteDocument request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute DeleteDocument request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// DeleteDocumentIfVersion deletes the document with the given ID only if its version still matches the given one
+// Returns false if the document has been changed since version was retrieved. Missing document is regarded as deleted
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/docs-index_.html#index-versioning
+func (c *Client) DeleteDocumentIfVersion(index, docType, id string, version *stats.DocumentVersion) (bool, error) {
+	query := url.Values{}
+	query.Set("version", strconv.FormatInt(version.Version, 10))
+
+	endpoint := c.clusterEndpoint + "/" + index + "/" + docType + "/" + url.PathEscape(id) + "?" + query.Encode()
+
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to make DeleteDocument request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to execute DeleteDocument request")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNotFound:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read response body")
+	}
+
+	return false, errors.Errorf("failed to execute DeleteDocument request. code: %d, body: %s", resp.StatusCode, body)
+}
+
 // DisableReallocation enables shard reallocation
 // Modifies cluster.routing.allocation.enable to "none"
 // https://www.elastic.co/guide/en/elasticsearch/reference/1.5/cluster-update-settings.html
@@ -84,6 +282,61 @@ func (c *Client) DisableReallocation() error {
 	return nil
 }
 
+// DiskUsage returns disk usage in percent of each node
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/cluster-nodes-stats.html
+func (c *Client) DiskUsage() (map[string]float64, error) {
+	endpoint := c.clusterEndpoint + "/_nodes/stats/fs"
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return map[string]float64{}, errors.Wrap(err, "failed to make NodesStats request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return map[string]float64{}, errors.Wrap(err, "failed to execute NodesStats request")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return map[string]float64{}, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return map[string]float64{}, errors.Errorf("failed to execute NodesStats request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	var stats struct {
+		Nodes map[string]struct {
+			Name string `json:"name"`
+			FS   struct {
+				Total struct {
+					TotalInBytes     int64 `json:"total_in_bytes"`
+					AvailableInBytes int64 `json:"available_in_bytes"`
+				} `json:"total"`
+			} `json:"fs"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return map[string]float64{}, errors.Wrap(err, "invalid response body")
+	}
+
+	usage := map[string]float64{}
+
+	for _, node := range stats.Nodes {
+		total := node.FS.Total.TotalInBytes
+		if total == 0 {
+			continue
+		}
+
+		usage[node.Name] = float64(total-node.FS.Total.AvailableInBytes) / float64(total) * 100
+	}
+
+	return usage, nil
+}
+
 // EnableReallocation enables shard reallocation
 // Modifies cluster.routing.allocation.enable to "all"
 // https://www.elastic.co/guide/en/elasticsearch/reference/1.5/cluster-update-settings.html
@@ -115,10 +368,30 @@ func (c *Client) EnableReallocation() error {
 }
 
 // ExcludeNodeFromAllocation excludes the given node from shard allocation group
+// The node is appended to nodes already excluded, e.g. by another removal, instead of replacing them
 // https://www.elastic.co/guide/en/elasticsearch/reference/current/allocation-filtering.html
 func (c *Client) ExcludeNodeFromAllocation(nodeName string) error {
+	settings, err := c.ClusterSettings()
+	if err != nil {
+		return err
+	}
+
+	nodes := []string{}
+
+	for _, n := range strings.Split(settings["cluster.routing.allocation.exclude._name"], ",") {
+		n = strings.TrimSpace(n)
+
+		if n == nodeName {
+			return nil
+		}
+
+		if n != "" {
+			nodes = append(nodes, n)
+		}
+	}
+
 	endpoint := c.clusterEndpoint + "/_cluster/settings"
-	reqBody := fmt.Sprintf(`{"transient":{"cluster.routing.allocation.exclude._name":"%s"}}`, nodeName)
+	reqBody := fmt.Sprintf(`{"transient":{"cluster.routing.allocation.exclude._name":"%s"}}`, strings.Join(append(nodes, nodeName), ","))
 
 	req, err := http.NewRequest("PUT", endpoint, strings.NewReader(reqBody))
 	if err != nil {
@@ -144,60 +417,869 @@ func (c *Client) ExcludeNodeFromAllocation(nodeName string) error {
 	return nil
 }
 
-// ListNodes returns the list of node names
-func (c *Client) ListNodes() ([]string, error) {
-	nodesInfo, err := c.client.NodesInfo().Do(c.ctx)
+// ExcludeNodeFromIndexAllocation excludes the given node from shard allocation of the given index
+// Empty nodeName clears the exclusion
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/shard-allocation-filtering.html
+func (c *Client) ExcludeNodeFromIndexAllocation(index, nodeName string) error {
+	endpoint := c.clusterEndpoint + "/" + index + "/_settings"
+	reqBody := fmt.Sprintf(`{"index.routing.allocation.exclude._name":"%s"}`, nodeName)
+
+	req, err := http.NewRequest("PUT", endpoint, strings.NewReader(reqBody))
 	if err != nil {
-		return []string{}, errors.Wrap(err, "failed to execute NodesInfo API")
+		return errors.Wrap(err, "failed to make ExcludeNodeFromIndexAllocation request")
 	}
+	defer req.Body.Close()
 
-	nodes := []string{}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute ExcludeNodeFromIndexAllocation request")
+	}
+	defer resp.Body.Close()
 
-	for _, node := range nodesInfo.Nodes {
-		nodes = append(nodes, node.Name)
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute ExcludeNodeFromIndexAllocation request. code: %d, body: %s", resp.StatusCode, body)
 	}
 
-	return nodes, nil
+	return nil
 }
 
-// ListShardsOnNode returns the list of shards on the given node
-func (c *Client) ListShardsOnNode(nodeName string) ([]string, error) {
-	endpoint := c.clusterEndpoint + "/_cat/shards/"
+// GetDocument returns the source of the document with the given ID
+// Returns nil if the document does not exist
+func (c *Client) GetDocument(index, docType, id string) ([]byte, error) {
+	doc, _, err := c.GetDocumentVersion(index, docType, id)
+
+	return doc, err
+}
+
+// GetDocumentVersion returns the source and version of the document with the given ID
+// Returns nil if the document does not exist
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/docs-get.html
+func (c *Client) GetDocumentVersion(index, docType, id string) ([]byte, *stats.DocumentVersion, error) {
+	endpoint := c.clusterEndpoint + "/" + index + "/" + docType + "/" + url.PathEscape(id)
 
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
-		return []string{}, errors.Wrap(err, "failed to make cat-shards request")
+		return nil, nil, errors.Wrap(err, "failed to make GetDocument request")
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return []string{}, errors.Wrap(err, "failed to execute Shutdown request")
+		return nil, nil, errors.Wrap(err, "failed to execute GetDocument request")
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return []string{}, errors.Wrap(err, "failed to read response body")
+		return nil, nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, nil
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return []string{}, errors.Errorf("failed to execute Shutdown request. code: %d, body: %s", resp.StatusCode, body)
+		return nil, nil, errors.Errorf("failed to execute GetDocument request. code: %d, body: %s", resp.StatusCode, body)
 	}
 
-	lines := strings.Split(string(body), "\n")
+	var r struct {
+		Found   bool            `json:"found"`
+		Version int64           `json:"_version"`
+		Source  json.RawMessage `json:"_source"`
+	}
 
-	shardsOnNode := []string{}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to parse response body")
+	}
 
-	for _, line := range lines {
-		if strings.HasSuffix(line, nodeName) {
-			shardsOnNode = append(shardsOnNode, line)
+	if !r.Found {
+		return nil, nil, nil
+	}
+
+	return r.Source, &stats.DocumentVersion{
+		Version: r.Version,
+	}, nil
+}
+
+// GetSnapshot returns the given snapshot in the given repository
+func (c *Client) GetSnapshot(repository, name string) (*snapshot.Snapshot, error) {
+	body, err := c.get("/_snapshot/"+repository+"/"+name, "get-snapshot")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := snapshot.Decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) == 0 {
+		return nil, errors.Errorf("snapshot %q is not found in repository %q", name, repository)
+	}
+
+	return snapshots[0], nil
+}
+
+// IndexAllocationFilters returns index-level allocation filters, e.g. index.routing.allocation.require._name, of each index
+// Indices without filter are omitted
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/shard-allocation-filtering.html
+func (c *Client) IndexAllocationFilters() (map[string]map[string]string, error) {
+	body, err := c.get("/_all/_settings?flat_settings=true", "index-settings")
+	if err != nil {
+		return map[string]map[string]string{}, err
+	}
+
+	var resp map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return map[string]map[string]string{}, errors.Wrap(err, "invalid response body")
+	}
+
+	filters := map[string]map[string]string{}
+
+	for index, r := range resp {
+		for k, v := range r.Settings {
+			if !strings.HasPrefix(k, "index.routing.allocation.require.") && !strings.HasPrefix(k, "index.routing.allocation.include.") && !strings.HasPrefix(k, "index.routing.allocation.exclude.") {
+				continue
+			}
+
+			if _, ok := filters[index]; !ok {
+				filters[index] = map[string]string{}
+			}
+
+			filters[index][k] = fmt.Sprint(v)
 		}
 	}
 
-	return shardsOnNode, nil
+	return filters, nil
 }
 
-// Shutdown does nothing, because Elasticsearch 2.x does not have shutdown API
-func (c *Client) Shutdown(nodeName string) error {
+// IndexDocument stores the given JSON document into the given index
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/docs-index_.html
+func (c *Client) IndexDocument(index, docType string, doc []byte) error {
+	endpoint := c.clusterEndpoint + "/" + index + "/" + docType
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(doc))
+	if err != nil {
+		return errors.Wrap(err, "failed to make IndexDocument request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute IndexDocument request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute IndexDocument request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
 	return nil
 }
+
+// IndexSettings returns explicit values of the given setting per index, in flat format
+// Indices using the default value are not included
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/indices-get-settings.html
+func (c *Client) IndexSettings(key string) (map[string]string, error) {
+	body, err := c.get("/_all/_settings?flat_settings=true", "index-settings")
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	var resp map[string]struct {
+		Settings map[string]interface{} `json:"settings"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return map[string]string{}, errors.Wrap(err, "invalid response body")
+	}
+
+	values := map[string]string{}
+
+	for index, r := range resp {
+		if v, ok := r.Settings[key]; ok {
+			values[index] = fmt.Sprint(v)
+		}
+	}
+
+	return values, nil
+}
+
+// ListNodes returns the list of node names
+func (c *Client) ListNodes() ([]string, error) {
+	nodesInfo, err := c.client.NodesInfo().Do(c.ctx)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to execute NodesInfo API")
+	}
+
+	nodes := []string{}
+
+	for _, node := range nodesInfo.Nodes {
+		nodes = append(nodes, node.Name)
+	}
+
+	return nodes, nil
+}
+
+// ListShards returns every copy of every shard in the cluster
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cat-shards.html
+func (c *Client) ListShards() ([]*stats.Shard, error) {
+	body, err := c.get("/_cat/shards?h=index,shard,prirep,state,node", "cat-shards")
+	if err != nil {
+		return []*stats.Shard{}, err
+	}
+
+	shards := []*stats.Shard{}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		// Node name may contain spaces, and relocating copy is shown as "source -> ip id target"
+		node := strings.Join(fields[4:], " ")
+		if i := strings.Index(node, " -> "); i >= 0 {
+			node = node[:i]
+		}
+
+		shards = append(shards, &stats.Shard{
+			Index:   fields[0],
+			Shard:   id,
+			Primary: fields[2] == "p",
+			State:   fields[3],
+			Node:    node,
+		})
+	}
+
+	return shards, nil
+}
+
+// ListShardsOnNode returns the list of shards on the given node
+func (c *Client) ListShardsOnNode(nodeName string) ([]string, error) {
+	endpoint := c.clusterEndpoint + "/_cat/shards/"
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to make cat-shards request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to execute Shutdown request")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return []string{}, errors.Errorf("failed to execute Shutdown request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	lines := strings.Split(string(body), "\n")
+
+	shardsOnNode := []string{}
+
+	for _, line := range lines {
+		if strings.HasSuffix(line, nodeName) {
+			shardsOnNode = append(shardsOnNode, line)
+		}
+	}
+
+	return shardsOnNode, nil
+}
+
+// ListSnapshots returns all snapshots in the given repository
+func (c *Client) ListSnapshots(repository string) ([]*snapshot.Snapshot, error) {
+	body, err := c.get("/_snapshot/"+repository+"/_all", "get-snapshot")
+	if err != nil {
+		return []*snapshot.Snapshot{}, err
+	}
+
+	return snapshot.Decode(body)
+}
+
+// MLJobs returns anomaly detection jobs with the node running them
+// No job is returned if machine learning is not available, e.g. X-Pack is not installed
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/ml-get-job-stats.html
+func (c *Client) MLJobs() ([]*stats.MLJob, error) {
+	req, err := http.NewRequest("GET", c.clusterEndpoint+"/_xpack/ml/anomaly_detectors/_stats", nil)
+	if err != nil {
+		return []*stats.MLJob{}, errors.Wrap(err, "failed to make ml-job-stats request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return []*stats.MLJob{}, errors.Wrap(err, "failed to execute ml-job-stats request")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return []*stats.MLJob{}, errors.Wrap(err, "failed to read response body")
+	}
+
+	// Unknown endpoint is reported as 400 or 404 depending on version
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+		return []*stats.MLJob{}, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return []*stats.MLJob{}, errors.Errorf("failed to execute ml-job-stats request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	var r struct {
+		Jobs []struct {
+			JobID string `json:"job_id"`
+			State string `json:"state"`
+			Node  *struct {
+				Name string `json:"name"`
+			} `json:"node"`
+		} `json:"jobs"`
+	}
+
+	if err := json.Unmarshal(body, &r); err != nil {
+		return []*stats.MLJob{}, errors.Wrap(err, "invalid response body")
+	}
+
+	jobs := []*stats.MLJob{}
+
+	for _, j := range r.Jobs {
+		job := &stats.MLJob{
+			ID:    j.JobID,
+			State: j.State,
+		}
+
+		if j.Node != nil {
+			job.Node = j.Node.Name
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// NodeAttributes returns custom attributes of the given node, e.g. box_type for hot/warm architecture
+func (c *Client) NodeAttributes(nodeName string) (map[string]string, error) {
+	body, err := c.get("/_nodes", "nodes-info")
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	var resp struct {
+		Nodes map[string]struct {
+			Name       string            `json:"name"`
+			Attributes map[string]string `json:"attributes"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return map[string]string{}, errors.Wrap(err, "failed to parse nodes-info response")
+	}
+
+	for _, node := range resp.Nodes {
+		if node.Name != nodeName {
+			continue
+		}
+
+		if node.Attributes == nil {
+			return map[string]string{}, nil
+		}
+
+		return node.Attributes, nil
+	}
+
+	return map[string]string{}, errors.Errorf("node %q is not found in the cluster", nodeName)
+}
+
+// NodeRoles returns roles (master, data, ingest) of each node
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/modules-node.html
+func (c *Client) NodeRoles() (map[string][]string, error) {
+	body, err := c.get("/_nodes", "nodes-info")
+	if err != nil {
+		return map[string][]string{}, err
+	}
+
+	var resp struct {
+		Nodes map[string]struct {
+			Name  string   `json:"name"`
+			Roles []string `json:"roles"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return map[string][]string{}, errors.Wrap(err, "failed to parse nodes-info response")
+	}
+
+	roles := map[string][]string{}
+
+	for _, node := range resp.Nodes {
+		if node.Roles == nil {
+			node.Roles = []string{}
+		}
+
+		roles[node.Name] = node.Roles
+	}
+
+	return roles, nil
+}
+
+// NodeStats returns CPU, heap, disk, load and shard count of each node
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/cluster-nodes-stats.html
+func (c *Client) NodeStats() ([]*stats.Node, error) {
+	body, err := c.get("/_nodes/stats/os,process,jvm,fs,indices", "NodesStats")
+	if err != nil {
+		return []*stats.Node{}, err
+	}
+
+	var resp struct {
+		Nodes map[string]struct {
+			Name string `json:"name"`
+			OS   struct {
+				CPU struct {
+					LoadAverage struct {
+						Load1m float64 `json:"1m"`
+					} `json:"load_average"`
+				} `json:"cpu"`
+			} `json:"os"`
+			Process struct {
+				CPU struct {
+					Percent float64 `json:"percent"`
+				} `json:"cpu"`
+			} `json:"process"`
+			JVM struct {
+				Mem struct {
+					HeapUsedPercent float64 `json:"heap_used_percent"`
+				} `json:"mem"`
+			} `json:"jvm"`
+			FS struct {
+				Total struct {
+					TotalInBytes     int64 `json:"total_in_bytes"`
+					AvailableInBytes int64 `json:"available_in_bytes"`
+				} `json:"total"`
+			} `json:"fs"`
+			Indices struct {
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"indices"`
+		} `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return []*stats.Node{}, errors.Wrap(err, "invalid response body")
+	}
+
+	shards, err := c.countShards()
+	if err != nil {
+		return []*stats.Node{}, err
+	}
+
+	nodes := []*stats.Node{}
+
+	for _, node := range resp.Nodes {
+		n := &stats.Node{
+			Name:        node.Name,
+			CPUPercent:  node.Process.CPU.Percent,
+			HeapPercent: node.JVM.Mem.HeapUsedPercent,
+			Shards:      shards[node.Name],
+			StoreBytes:  node.Indices.Store.SizeInBytes,
+		}
+
+		if total := node.FS.Total.TotalInBytes; total > 0 {
+			n.DiskPercent = float64(total-node.FS.Total.AvailableInBytes) / float64(total) * 100
+		}
+
+		n.Load1m = node.OS.CPU.LoadAverage.Load1m
+
+		nodes = append(nodes, n)
+	}
+
+	return nodes, nil
+}
+
+// PrepareNodeShutdown does nothing, because node shutdown API was introduced in Elasticsearch 7.15
+func (c *Client) PrepareNodeShutdown(nodeName string) error {
+	return nil
+}
+
+// Rollover rolls the given alias over to new index unconditionally and returns the new index
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/indices-rollover-index.html
+func (c *Client) Rollover(alias string) (string, error) {
+	endpoint := c.clusterEndpoint + "/" + alias + "/_rollover"
+
+	req, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make Rollover request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to execute Rollover request")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("failed to execute Rollover request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	var r struct {
+		NewIndex   string `json:"new_index"`
+		RolledOver bool   `json:"rolled_over"`
+	}
+
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", errors.Wrap(err, "invalid response body")
+	}
+
+	if !r.RolledOver {
+		return "", errors.Errorf("alias %s was not rolled over", alias)
+	}
+
+	return r.NewIndex, nil
+}
+
+// RequireIndexAllocationAttribute allocates shards of the given index only to nodes with the given attribute
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/shard-allocation-filtering.html
+func (c *Client) RequireIndexAllocationAttribute(index, key, value string) error {
+	endpoint := c.clusterEndpoint + "/" + index + "/_settings"
+	reqBody := fmt.Sprintf(`{"index.routing.allocation.require.%s":"%s"}`, key, value)
+
+	req, err := http.NewRequest("PUT", endpoint, strings.NewReader(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to make RequireIndexAllocationAttribute request")
+	}
+	defer req.Body.Close()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute RequireIndexAllocationAttribute request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute RequireIndexAllocationAttribute request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// Reroute triggers allocation of unassigned shards, including ones which failed to be allocated too many times
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cluster-reroute.html
+func (c *Client) Reroute() error {
+	endpoint := c.clusterEndpoint + "/_cluster/reroute?retry_failed=true"
+
+	req, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to make Reroute request")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute Reroute request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute Reroute request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// ResetIndexSettings removes the given settings from the given index
+// Settings are set to null, which restores their defaults
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/indices-update-settings.html
+func (c *Client) ResetIndexSettings(index string, keys []string) error {
+	endpoint := c.clusterEndpoint + "/" + index + "/_settings"
+
+	settings := map[string]interface{}{}
+
+	for _, k := range keys {
+		settings[k] = nil
+	}
+
+	reqBody, err := json.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode index settings")
+	}
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to make ResetIndexSettings request")
+	}
+	defer req.Body.Close()
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute ResetIndexSettings request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute ResetIndexSettings request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// SLMPolicies returns no policy, because snapshot lifecycle management was introduced in Elasticsearch 7.4
+func (c *Client) SLMPolicies() ([]*snapshot.Policy, error) {
+	return []*snapshot.Policy{}, nil
+}
+
+// SetMLUpgradeMode does nothing, because upgrade mode was introduced in Elasticsearch 6.7
+// Jobs are reassigned after the node leaves the cluster
+func (c *Client) SetMLUpgradeMode(enabled bool) error {
+	return nil
+}
+
+// Shutdown does nothing, because Elasticsearch 2.x does not have shutdown API
+func (c *Client) Shutdown(nodeName string) error {
+	return nil
+}
+
+// UpdateClusterSettings updates the given transient cluster settings
+// https://www.elastic.co/guide/en/elasticsearch/reference/current/cluster-update-settings.html
+func (c *Client) UpdateClusterSettings(settings map[string]string) error {
+	endpoint := c.clusterEndpoint + "/_cluster/settings"
+
+	reqBody, err := json.Marshal(map[string]map[string]string{"transient": settings})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode cluster settings")
+	}
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to make UpdateClusterSettings request")
+	}
+	defer req.Body.Close()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute UpdateClusterSettings request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute UpdateClusterSettings request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// UpdateDocumentIfVersion replaces the document with the given ID only if its version still matches the given one
+// Returns false if the document has been changed or deleted since version was retrieved
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/docs-index_.html#index-versioning
+func (c *Client) UpdateDocumentIfVersion(index, docType, id string, doc []byte, version *stats.DocumentVersion) (bool, error) {
+	query := url.Values{}
+	query.Set("version", strconv.FormatInt(version.Version, 10))
+
+	endpoint := c.clusterEndpoint + "/" + index + "/" + docType + "/" + url.PathEscape(id) + "?" + query.Encode()
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(doc))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to make UpdateDocument request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to execute UpdateDocument request")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusCreated, http.StatusConflict:
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read response body")
+	}
+
+	return false, errors.Errorf("failed to execute UpdateDocument request. code: %d, body: %s", resp.StatusCode, body)
+}
+
+// UpdateIndexSettings updates the given settings of the given index
+// "_all" updates all indices
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/indices-update-settings.html
+func (c *Client) UpdateIndexSettings(index string, settings map[string]string) error {
+	endpoint := c.clusterEndpoint + "/" + index + "/_settings"
+
+	reqBody, err := json.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode index settings")
+	}
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to make UpdateIndexSettings request")
+	}
+	defer req.Body.Close()
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to execute UpdateIndexSettings request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+
+		return errors.Errorf("failed to execute UpdateIndexSettings request. code: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return nil
+}
+
+// WriteIndices returns write index of each alias
+// Alias pointing to exactly one index is regarded as write alias, because is_write_index was introduced in Elasticsearch 6.4
+// https://www.elastic.co/guide/en/elasticsearch/reference/5.6/indices-aliases.html
+func (c *Client) WriteIndices() (map[string]string, error) {
+	body, err := c.get("/_aliases", "aliases")
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	var resp map[string]struct {
+		Aliases map[string]struct {
+			IsWriteIndex *bool `json:"is_write_index"`
+		} `json:"aliases"`
+	}
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return map[string]string{}, errors.Wrap(err, "invalid response body")
+	}
+
+	indices := map[string][]string{}
+	writeIndices := map[string]string{}
+
+	for index, r := range resp {
+		for alias, a := range r.Aliases {
+			indices[alias] = append(indices[alias], index)
+
+			if a.IsWriteIndex != nil && *a.IsWriteIndex {
+				writeIndices[alias] = index
+			}
+		}
+	}
+
+	for alias, list := range indices {
+		if _, ok := writeIndices[alias]; !ok && len(list) == 1 {
+			writeIndices[alias] = list[0]
+		}
+	}
+
+	return writeIndices, nil
+}
+
+// countShards returns the number of shards on each node from _cat/allocation
+func (c *Client) countShards() (map[string]int, error) {
+	body, err := c.get("/_cat/allocation?h=shards,node", "cat-allocation")
+	if err != nil {
+		return map[string]int{}, err
+	}
+
+	shards := map[string]int{}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		// Unassigned shards are counted in "UNASSIGNED" row without node
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		shards[strings.Join(fields[1:], " ")] = n
+	}
+
+	return shards, nil
+}
+
+// get executes GET request to the given path and returns response body
+func (c *Client) get(path, name string) ([]byte, error) {
+	req, err := http.NewRequest("GET", c.clusterEndpoint+path, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make %s request", name)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to execute %s request", name)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to execute %s request. code: %d, body: %s", name, resp.StatusCode, body)
+	}
+
+	return body, nil
+}
@@ -3,13 +3,145 @@ package v5
 import (
 	"context"
 	"net/http"
+	"reflect"
 	"testing"
 
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/es/stats"
 	"gopkg.in/h2non/gock.v1"
 )
 
 const testClusterEndpoint = "http://example.com:9200"
 
+func TestClusterHealth(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_cluster/health").Reply(200).BodyString(`{"cluster_name":"elasticsearch","status":"green","relocating_shards":3,"initializing_shards":0,"unassigned_shards":0}`)
+
+	got, err := client.ClusterHealth()
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := &stats.Health{
+		Status:           "green",
+		RelocatingShards: 3,
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("health does not match. expected: %+v, got: %+v", expected, got)
+	}
+}
+
+func TestClusterSettings(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_cluster/settings").MatchParam("flat_settings", "true").Reply(200).BodyString(`{
+  "persistent": {"cluster.routing.allocation.cluster_concurrent_rebalance": "4", "cluster.routing.rebalance.enable": "all"},
+  "transient": {"cluster.routing.rebalance.enable": "none"}
+}`)
+
+	got, err := client.ClusterSettings()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := map[string]string{
+		"cluster.routing.allocation.cluster_concurrent_rebalance": "4",
+		"cluster.routing.rebalance.enable":                        "none",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("settings do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestCreateDocument(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	testcases := []struct {
+		code     int
+		expected bool
+	}{
+		{
+			code:     201,
+			expected: true,
+		},
+		{
+			code:     409,
+			expected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		gock.New(testClusterEndpoint).Put("/.esnctl-lock/lock/cluster/_create").Reply(tc.code)
+
+		got, err := client.CreateDocument(".esnctl-lock", "lock", "cluster", []byte(`{"owner":"alice"}`))
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("result does not match. expected: %t, got: %t", tc.expected, got)
+		}
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Put("/_snapshot/backup/esnctl-20180101000000").Reply(200).BodyString(`{"accepted":true}`)
+
+	if err := client.CreateSnapshot("backup", "esnctl-20180101000000"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestDeleteDocument(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Delete("/.esnctl-lock/lock/cluster").Reply(200)
+
+	if err := client.DeleteDocument(".esnctl-lock", "lock", "cluster"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
 func TestDisableReallocation(t *testing.T) {
 	defer gock.Off()
 
@@ -27,6 +159,44 @@ func TestDisableReallocation(t *testing.T) {
 	}
 }
 
+func TestDiskUsage(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_nodes/stats/fs").Reply(200).BodyString(`{
+  "cluster_name": "elasticsearch",
+  "nodes": {
+    "ZzSE1m5nQ6C0XgzEcbVYjQ": {
+      "name": "ip-10-0-1-21.ap-northeast-1.compute.internal",
+      "fs": {
+        "total": {
+          "total_in_bytes": 1000,
+          "free_in_bytes": 300,
+          "available_in_bytes": 250
+        }
+      }
+    }
+  }
+}`)
+
+	got, err := client.DiskUsage()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := 75.0
+
+	if got["ip-10-0-1-21.ap-northeast-1.compute.internal"] != expected {
+		t.Errorf("disk usage does not match. expected: %f, got: %v", expected, got)
+	}
+}
+
 func TestEnableReallocation(t *testing.T) {
 	defer gock.Off()
 
@@ -54,13 +224,205 @@ func TestExcludeNodeFromAllocation(t *testing.T) {
 		ctx:             context.Background(),
 	}
 
-	gock.New(testClusterEndpoint).Put("/_cluster/settings").BodyString(`{"transient":{"cluster.routing.allocation.exclude._name":"ip-10-0-1-23.ap-northeast-1.compute.internal"}}`).Reply(200)
+	gock.New(testClusterEndpoint).Get("/_cluster/settings").Reply(200).BodyString(`{"persistent":{},"transient":{"cluster.routing.allocation.exclude._name":"ip-10-0-1-21.ap-northeast-1.compute.internal"}}`)
+	gock.New(testClusterEndpoint).Put("/_cluster/settings").BodyString(`{"transient":{"cluster.routing.allocation.exclude._name":"ip-10-0-1-21.ap-northeast-1.compute.internal,ip-10-0-1-23.ap-northeast-1.compute.internal"}}`).Reply(200)
 
 	nodeName := "ip-10-0-1-23.ap-northeast-1.compute.internal"
 
 	if err := client.ExcludeNodeFromAllocation(nodeName); err != nil {
 		t.Errorf("error should not be raised: %s", err)
 	}
+
+	if !gock.IsDone() {
+		t.Errorf("node should be appended to nodes already excluded")
+	}
+
+	// Node already excluded is not excluded again
+	gock.New(testClusterEndpoint).Get("/_cluster/settings").Reply(200).BodyString(`{"persistent":{},"transient":{"cluster.routing.allocation.exclude._name":"ip-10-0-1-21.ap-northeast-1.compute.internal,ip-10-0-1-23.ap-northeast-1.compute.internal"}}`)
+
+	if err := client.ExcludeNodeFromAllocation(nodeName); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestExcludeNodeFromIndexAllocation(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Put("/logs-2018.01.01/_settings").BodyString(`{"index.routing.allocation.exclude._name":"ip-10-0-1-23.ap-northeast-1.compute.internal"}`).Reply(200)
+
+	if err := client.ExcludeNodeFromIndexAllocation("logs-2018.01.01", "ip-10-0-1-23.ap-northeast-1.compute.internal"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestGetDocument(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/.esnctl-lock/lock/cluster").Reply(200).BodyString(`{"_index":".esnctl-lock","_type":"lock","_id":"cluster","_version":1,"found":true,"_source":{"owner":"alice"}}`)
+
+	got, err := client.GetDocument(".esnctl-lock", "lock", "cluster")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := `{"owner":"alice"}`
+
+	if string(got) != expected {
+		t.Errorf("document does not match. expected: %q, got: %q", expected, string(got))
+	}
+
+	gock.New(testClusterEndpoint).Get("/.esnctl-lock/lock/cluster").Reply(404).BodyString(`{"_index":".esnctl-lock","_type":"lock","_id":"cluster","found":false}`)
+
+	got, err = client.GetDocument(".esnctl-lock", "lock", "cluster")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got != nil {
+		t.Errorf("document should be nil. got: %q", string(got))
+	}
+}
+
+func TestGetSnapshot(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_snapshot/backup/esnctl-20180101000000").Reply(200).BodyString(`{"snapshots":[{"snapshot":"esnctl-20180101000000","indices":["logs-2018.01.01"],"state":"IN_PROGRESS","start_time_in_millis":1514764800000,"end_time_in_millis":0,"shards":{"total":0,"failed":0,"successful":0}}]}`)
+
+	got, err := client.GetSnapshot("backup", "esnctl-20180101000000")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got.Name != "esnctl-20180101000000" || got.State != snapshot.StateInProgress {
+		t.Errorf("snapshot does not match. got: %+v", got)
+	}
+}
+
+func TestIndexAllocationFilters(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_all/_settings").MatchParam("flat_settings", "true").Reply(200).BodyString(`{
+  "logs-2018.01.01": {"settings": {"index.number_of_shards": "5", "index.routing.allocation.require._name": "node-1"}},
+  "logs-2018.01.02": {"settings": {"index.number_of_shards": "5"}}
+}`)
+
+	got, err := client.IndexAllocationFilters()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := map[string]map[string]string{
+		"logs-2018.01.01": {"index.routing.allocation.require._name": "node-1"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("filters do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestIndexDocument(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Post("/.esnctl-audit/operation").BodyString(`{"command":"remove"}`).Reply(201)
+
+	if err := client.IndexDocument(".esnctl-audit", "operation", []byte(`{"command":"remove"}`)); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestIndexSettings(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_all/_settings").MatchParam("flat_settings", "true").Reply(200).BodyString(`{
+  "logs-2018.01.01": {"settings": {"index.number_of_shards": "5", "index.unassigned.node_left.delayed_timeout": "5m"}},
+  "logs-2018.01.02": {"settings": {"index.number_of_shards": "5"}}
+}`)
+
+	got, err := client.IndexSettings("index.unassigned.node_left.delayed_timeout")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := map[string]string{
+		"logs-2018.01.01": "5m",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("settings do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestListShards(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_cat/shards").MatchParam("h", "index,shard,prirep,state,node").Reply(200).BodyString(`wiki1 0 p STARTED ip-10-0-1-23.ap-northeast-1.compute.internal
+wiki1 0 r UNASSIGNED
+wiki1 1 p RELOCATING Frankie Raye -> 192.168.56.20 Z8ZV2Wn6TYC5C1X3Yg5Hnw Commander Kraken
+`)
+
+	shards, err := client.ListShards()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := []*stats.Shard{
+		{Index: "wiki1", Shard: 0, Primary: true, State: "STARTED", Node: "ip-10-0-1-23.ap-northeast-1.compute.internal"},
+		{Index: "wiki1", Shard: 0, Primary: false, State: "UNASSIGNED", Node: ""},
+		{Index: "wiki1", Shard: 1, Primary: true, State: "RELOCATING", Node: "Frankie Raye"},
+	}
+
+	if !reflect.DeepEqual(shards, expected) {
+		t.Errorf("shards do not match. expected: %v, got: %v", expected, shards)
+	}
 }
 
 func TestListShardsOnNode(t *testing.T) {
@@ -94,3 +456,330 @@ wiki1 2 p STARTED 3973 38.1mb 192.168.56.20 Commander Kraken`)
 		t.Errorf("shard does not match. expected: %q, got: %q", expected, shards[0])
 	}
 }
+
+func TestListSnapshots(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_snapshot/backup/_all").Reply(200).BodyString(`{"snapshots":[{"snapshot":"snapshot-1","state":"SUCCESS"},{"snapshot":"snapshot-2","state":"FAILED"}]}`)
+
+	got, err := client.ListSnapshots("backup")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(got) != 2 || got[0].Name != "snapshot-1" || got[1].State != "FAILED" {
+		t.Errorf("snapshots do not match. got: %+v", got)
+	}
+}
+
+func TestMLJobs(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_xpack/ml/anomaly_detectors/_stats").Reply(200).BodyString(`{
+  "count": 2,
+  "jobs": [
+    {"job_id": "requests", "state": "opened", "node": {"id": "ZzSE1m5nQ6C0XgzEcbVYjQ", "name": "ip-10-0-1-21.ap-northeast-1.compute.internal"}},
+    {"job_id": "errors", "state": "closed"}
+  ]
+}`)
+
+	got, err := client.MLJobs()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := []*stats.MLJob{
+		{ID: "requests", State: "opened", Node: "ip-10-0-1-21.ap-northeast-1.compute.internal"},
+		{ID: "errors", State: "closed"},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("jobs do not match. expected: %v, got: %v", expected, got)
+	}
+
+	gock.New(testClusterEndpoint).Get("/_xpack/ml/anomaly_detectors/_stats").Reply(400).BodyString(`{"error": "no handler found"}`)
+
+	got, err = client.MLJobs()
+	if err != nil {
+		t.Errorf("error should not be raised without machine learning: %s", err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("no job should be returned without machine learning. got: %v", got)
+	}
+}
+
+func TestNodeAttributes(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_nodes").Reply(200).BodyString(`{
+  "cluster_name": "elasticsearch",
+  "nodes": {
+    "ZzSE1m5nQ6C0XgzEcbVYjQ": {
+      "name": "ip-10-0-1-21.ap-northeast-1.compute.internal",
+      "attributes": {"box_type": "hot"}
+    },
+    "Bn2p3jPOTg6ydCTYjVFFBg": {
+      "name": "ip-10-0-1-22.ap-northeast-1.compute.internal"
+    }
+  }
+}`)
+
+	got, err := client.NodeAttributes("ip-10-0-1-21.ap-northeast-1.compute.internal")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := map[string]string{"box_type": "hot"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("attributes do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestNodeRoles(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_nodes").Reply(200).BodyString(`{
+  "cluster_name": "elasticsearch",
+  "nodes": {
+    "ZzSE1m5nQ6C0XgzEcbVYjQ": {"name": "data-1", "roles": ["data", "ingest"]},
+    "Bn2p3jPOTg6ydCTYjVFFBg": {"name": "master-1", "roles": ["master"]},
+    "Qb2w3jPOTg6ydCTYjVFFBg": {"name": "coordinating-1", "roles": []}
+  }
+}`)
+
+	got, err := client.NodeRoles()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := map[string][]string{
+		"data-1":         {"data", "ingest"},
+		"master-1":       {"master"},
+		"coordinating-1": {},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("roles do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestNodeStats(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_nodes/stats/os,process,jvm,fs,indices").Reply(200).BodyString(`{
+  "cluster_name": "elasticsearch",
+  "nodes": {
+    "ZzSE1m5nQ6C0XgzEcbVYjQ": {
+      "name": "ip-10-0-1-21.ap-northeast-1.compute.internal",
+      "os": {"cpu": {"percent": 30, "load_average": {"1m": 1.5, "5m": 1.2, "15m": 1.0}}},
+      "process": {"cpu": {"percent": 12}},
+      "jvm": {"mem": {"heap_used_percent": 45}},
+      "fs": {"total": {"total_in_bytes": 1000, "available_in_bytes": 250}},
+      "indices": {"store": {"size_in_bytes": 4096}}
+    }
+  }
+}`)
+	gock.New(testClusterEndpoint).Get("/_cat/allocation").MatchParam("h", "shards,node").Reply(200).BodyString(`    12 ip-10-0-1-21.ap-northeast-1.compute.internal
+     1 UNASSIGNED
+`)
+
+	got, err := client.NodeStats()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := []*stats.Node{
+		&stats.Node{
+			Name:        "ip-10-0-1-21.ap-northeast-1.compute.internal",
+			CPUPercent:  12,
+			HeapPercent: 45,
+			DiskPercent: 75,
+			Load1m:      1.5,
+			Shards:      12,
+			StoreBytes:  4096,
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("stats do not match. expected: %+v, got: %+v", expected[0], got)
+	}
+}
+
+func TestRequireIndexAllocationAttribute(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Put("/logs-2018.01.01/_settings").BodyString(`{"index.routing.allocation.require.box_type":"warm"}`).Reply(200)
+
+	if err := client.RequireIndexAllocationAttribute("logs-2018.01.01", "box_type", "warm"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestReroute(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Post("/_cluster/reroute").MatchParam("retry_failed", "true").Reply(200)
+
+	if err := client.Reroute(); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestResetIndexSettings(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Put("/logs-2018.01.01/_settings").BodyString(`{"index.routing.allocation.require._name":null}`).Reply(200)
+
+	if err := client.ResetIndexSettings("logs-2018.01.01", []string{"index.routing.allocation.require._name"}); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestRollover(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Post("/logs-write/_rollover").Reply(200).BodyString(`{"old_index": "logs-000001", "new_index": "logs-000002", "rolled_over": true, "dry_run": false, "conditions": {}}`)
+
+	got, err := client.Rollover("logs-write")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got != "logs-000002" {
+		t.Errorf("new index does not match. expected: logs-000002, got: %q", got)
+	}
+}
+
+func TestUpdateClusterSettings(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Put("/_cluster/settings").BodyString(`{"transient":{"cluster.routing.allocation.cluster_concurrent_rebalance":"8","cluster.routing.rebalance.enable":"all"}}`).Reply(200)
+
+	settings := map[string]string{
+		"cluster.routing.allocation.cluster_concurrent_rebalance": "8",
+		"cluster.routing.rebalance.enable":                        "all",
+	}
+
+	if err := client.UpdateClusterSettings(settings); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestUpdateIndexSettings(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Put("/_all/_settings").BodyString(`{"index.unassigned.node_left.delayed_timeout":"5m"}`).Reply(200)
+
+	if err := client.UpdateIndexSettings("_all", map[string]string{"index.unassigned.node_left.delayed_timeout": "5m"}); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestWriteIndices(t *testing.T) {
+	defer gock.Off()
+
+	client := &Client{
+		client:          nil,
+		clusterEndpoint: testClusterEndpoint,
+		httpClient:      &http.Client{},
+		ctx:             context.Background(),
+	}
+
+	gock.New(testClusterEndpoint).Get("/_aliases").Reply(200).BodyString(`{
+  "metrics-2018.01.01": {"aliases": {"metrics": {}}},
+  "metrics-2018.01.02": {"aliases": {"metrics": {}}},
+  "events-000001": {"aliases": {"events-write": {}}}
+}`)
+
+	got, err := client.WriteIndices()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := map[string]string{
+		"events-write": "events-000001",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("write indices do not match. expected: %v, got: %v", expected, got)
+	}
+}
@@ -0,0 +1,52 @@
+package es
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+// WarmupQuery represents search sent to new node before it serves traffic
+type WarmupQuery struct {
+	// Index represents index name or pattern to search, e.g. logs-*. All indices are searched if empty
+	Index string `json:"index"`
+
+	// Body represents request body of _search API. match_all is searched if empty
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// ParseWarmupQueries parses JSON array of warm-up queries, e.g. [{"index": "logs-*", "body": {"query": {"match_all": {}}}}]
+func ParseWarmupQueries(data []byte) ([]*WarmupQuery, error) {
+	queries := []*WarmupQuery{}
+
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, exitcode.Wrap(errors.Wrap(err, "failed to parse warm-up queries"), exitcode.Validation)
+	}
+
+	return queries, nil
+}
+
+// WarmUp sends the given query only to shard copies on the given node, so that its caches are primed
+func WarmUp(clusterURL string, httpClient *http.Client, nodeName string, q *WarmupQuery) error {
+	path := "/_search"
+	if q.Index != "" {
+		path = "/" + q.Index + "/_search"
+	}
+
+	query := url.Values{}
+	query.Set("preference", "_only_nodes:"+nodeName)
+
+	body := []byte(q.Body)
+	if len(body) == 0 {
+		body = nil
+	}
+
+	if _, err := requestWithBody(clusterURL, httpClient, http.MethodPost, path, query, body); err != nil {
+		return errors.Wrapf(err, "failed to warm up %s", nodeName)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package es
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+func TestParseWarmupQueries(t *testing.T) {
+	queries, err := ParseWarmupQueries([]byte(`[{"index": "logs-*", "body": {"query": {"match_all": {}}}}, {"index": "metrics"}]`))
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(queries) != 2 || queries[0].Index != "logs-*" || string(queries[0].Body) != `{"query": {"match_all": {}}}` || queries[1].Body != nil {
+		t.Errorf("queries do not match. got: %+v", queries)
+	}
+
+	if _, err := ParseWarmupQueries([]byte(`{"index": "logs-*"}`)); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised. got: %v", err)
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/logs-*/_search" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		if got := r.URL.Query().Get("preference"); got != "_only_nodes:ip-10-0-1-4.ec2.internal" {
+			t.Errorf("preference does not match. got: %q", got)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != `{"size":0}` {
+			t.Errorf("body does not match. got: %q", body)
+		}
+
+		w.Write([]byte(`{"hits": {"total": 0, "hits": []}}`))
+	}))
+	defer ts.Close()
+
+	q := &WarmupQuery{Index: "logs-*", Body: []byte(`{"size":0}`)}
+
+	if err := WarmUp(ts.URL, &http.Client{}, "ip-10-0-1-4.ec2.internal", q); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
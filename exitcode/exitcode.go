@@ -0,0 +1,163 @@
+package exitcode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+const (
+	// OK represents success
+	OK = 0
+	// General represents unclassified error
+	General = 1
+	// Validation represents invalid flags, plan or manifest
+	Validation = 2
+	// Timeout represents timeout waiting for cluster state change
+	Timeout = 3
+	// ESUnreachable represents that Elasticsearch cluster cannot be reached
+	ESUnreachable = 4
+	// AWSPermissionDenied represents that AWS credentials are missing or not permitted
+	AWSPermissionDenied = 5
+	// Aborted represents that operation was aborted before completion
+	Aborted = 6
+	// Pending represents that the checked condition is not satisfied yet, retry later
+	Pending = 7
+)
+
+// Description represents meaning of exit code shown in documentation
+type Description struct {
+	Code        int
+	Name        string
+	Description string
+}
+
+// Descriptions lists all exit codes in ascending order
+var Descriptions = []Description{
+	{OK, "OK", "Success"},
+	{General, "General", "Unclassified error"},
+	{Validation, "Validation", "Invalid flags, plan, manifest or configuration file"},
+	{Timeout, "Timeout", "Timed out waiting for cluster state change, or --operation-timeout passed"},
+	{ESUnreachable, "ESUnreachable", "Elasticsearch cluster cannot be reached"},
+	{AWSPermissionDenied, "AWSPermissionDenied", "AWS credentials are missing or not permitted"},
+	{Aborted, "Aborted", "Operation was aborted before completion"},
+	{Pending, "Pending", "Checked condition is not satisfied yet, retry later"},
+}
+
+// Name returns name of the given exit code, e.g. "Timeout"
+func Name(code int) string {
+	for _, d := range Descriptions {
+		if d.Code == code {
+			return d.Name
+		}
+	}
+
+	return "Unknown"
+}
+
+// awsPermissionErrorCodes represents AWS error codes classified into AWSPermissionDenied
+var awsPermissionErrorCodes = map[string]bool{
+	"AccessDenied":          true,
+	"AccessDeniedException": true,
+	"ExpiredToken":          true,
+	"InvalidClientTokenId":  true,
+	"NoCredentialProviders": true,
+	"UnauthorizedOperation": true,
+}
+
+// Error represents error with exit code
+type Error struct {
+	code int
+	err  error
+}
+
+type causer interface {
+	Cause() error
+}
+
+type coder interface {
+	Code() string
+}
+
+// New returns error with the given message and exit code
+func New(code int, message string) error {
+	return &Error{
+		code: code,
+		err:  fmt.Errorf("%s", message),
+	}
+}
+
+// Errorf returns error with the formatted message and exit code
+func Errorf(code int, format string, args ...interface{}) error {
+	return &Error{
+		code: code,
+		err:  fmt.Errorf(format, args...),
+	}
+}
+
+// Wrap annotates err with exit code
+// Returns nil if err is nil
+func Wrap(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{
+		code: code,
+		err:  err,
+	}
+}
+
+// Error returns error message
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the underlying error
+func (e *Error) Cause() error {
+	return e.err
+}
+
+// Code returns exit code for the given error
+// Explicit exit code is preferred, otherwise the root cause is classified
+func Code(err error) int {
+	if err == nil {
+		return OK
+	}
+
+	for {
+		if e, ok := err.(*Error); ok {
+			return e.code
+		}
+
+		c, ok := err.(causer)
+		if !ok {
+			break
+		}
+
+		err = c.Cause()
+	}
+
+	return classify(err)
+}
+
+func classify(err error) int {
+	switch err {
+	case context.DeadlineExceeded:
+		return Timeout
+	case context.Canceled:
+		return Aborted
+	}
+
+	if c, ok := err.(coder); ok && awsPermissionErrorCodes[c.Code()] {
+		return AWSPermissionDenied
+	}
+
+	switch err.(type) {
+	case *url.Error, net.Error:
+		return ESUnreachable
+	}
+
+	return General
+}
@@ -0,0 +1,105 @@
+package exitcode
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type awsError struct {
+	code string
+}
+
+func (e *awsError) Error() string {
+	return e.code + ": error"
+}
+
+func (e *awsError) Code() string {
+	return e.code
+}
+
+func TestCode(t *testing.T) {
+	testcases := []struct {
+		err      error
+		expected int
+	}{
+		{
+			err:      nil,
+			expected: OK,
+		},
+		{
+			err:      errors.New("something wrong"),
+			expected: General,
+		},
+		{
+			err:      errors.Wrap(New(Validation, "--group must be specified"), "failed"),
+			expected: Validation,
+		},
+		{
+			err:      errors.Wrap(context.DeadlineExceeded, "failed to wait"),
+			expected: Timeout,
+		},
+		{
+			err:      errors.Wrap(&url.Error{Op: "Get", URL: "http://elasticsearch.example.com", Err: errors.New("connection refused")}, "failed to detect Elasticsearch version"),
+			expected: ESUnreachable,
+		},
+		{
+			err:      errors.Wrap(&awsError{code: "AccessDenied"}, "failed to detach instance"),
+			expected: AWSPermissionDenied,
+		},
+		{
+			err:      errors.Wrap(&awsError{code: "Throttling"}, "failed to detach instance"),
+			expected: General,
+		},
+		{
+			err:      Wrap(context.Canceled, Timeout),
+			expected: Timeout,
+		},
+		{
+			err:      context.Canceled,
+			expected: Aborted,
+		},
+	}
+
+	for _, tc := range testcases {
+		if got := Code(tc.err); got != tc.expected {
+			t.Errorf("exit code of %v does not match. expected: %d, got: %d", tc.err, tc.expected, got)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil, Validation) != nil {
+		t.Errorf("nil should be returned for nil error")
+	}
+
+	err := Wrap(errors.New("plan is invalid"), Validation)
+
+	if err.Error() != "plan is invalid" {
+		t.Errorf("error message does not match. expected: %q, got: %q", "plan is invalid", err.Error())
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	for i, d := range Descriptions {
+		if d.Code != i {
+			t.Errorf("exit codes should be listed in ascending order without gap. expected: %d, got: %d (%s)", i, d.Code, d.Name)
+		}
+
+		if d.Description == "" {
+			t.Errorf("description of exit code %d is empty", d.Code)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := Name(Timeout); got != "Timeout" {
+		t.Errorf("name does not match. expected: %q, got: %q", "Timeout", got)
+	}
+
+	if got := Name(99); got != "Unknown" {
+		t.Errorf("name does not match. expected: %q, got: %q", "Unknown", got)
+	}
+}
@@ -0,0 +1,1653 @@
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/aws/autoscaling"
+	"github.com/dtan4/esnctl/aws/ec2"
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/pkg/errors"
+)
+
+const (
+	// GroupName represents name of fake Auto Scaling Group
+	GroupName = "esnctl-fake"
+
+	// GroupTagKey and GroupTagValue represent tag of fake Auto Scaling Group
+	GroupTagKey   = "cluster"
+	GroupTagValue = "fake"
+
+	// InstanceType represents instance type of fake instances
+	InstanceType = "r5.large"
+
+	// TargetGroupARN represents ARN of target group attached to fake Auto Scaling Group
+	TargetGroupARN = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/esnctl-fake/0123456789abcdef"
+
+	// Version represents Elasticsearch version which fake cluster behaves as
+	Version = "6.2.4"
+
+	// GroupMaxSize represents max size of fake Auto Scaling Group
+	GroupMaxSize = 10
+
+	shardsPerNode = 3
+
+	// diskUsagePerShard represents disk usage in percent taken by each shard
+	diskUsagePerShard = 10.0
+
+	// storeBytesPerShard represents size of each shard
+	storeBytesPerShard = 512 * 1024 * 1024
+)
+
+// availabilityZones represents AZs where nodes are launched in turn
+var availabilityZones = []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+
+type shard struct {
+	index string
+	id    int
+}
+
+type node struct {
+	name          string
+	instanceID    string
+	zone          string
+	attributes    map[string]string
+	roles         []string
+	shards        []*shard
+	inService     bool
+	inTargetGroup bool
+	running       bool
+
+	// tags represents tags of the instance set by SetTags
+	tags map[string]string
+
+	// standby represents running instance outside Auto Scaling Group, whose node has not joined the cluster yet
+	standby    bool
+	terminated bool
+
+	// warmed represents stopped instance in warm pool
+	warmed bool
+
+	// shuttingDown represents node registered for removal by PrepareNodeShutdown
+	shuttingDown bool
+	rebooting    bool
+}
+
+// Cluster represents in-memory Elasticsearch cluster running on Auto Scaling Group
+// Cluster implements es.Client, and simulated AWS clients are returned by AutoScaling, EC2 and ELBv2
+// Instances belong to Auto Scaling Group named GroupName, and state changes complete immediately
+type Cluster struct {
+	mu sync.Mutex
+
+	nodes           []*node
+	reallocation    bool
+	settings        map[string]string
+	indexExclusions map[string]string
+	indexSettings   map[string]map[string]string
+	writeIndices    map[string]string
+	mlJobs          map[string]string
+	mlUpgradeMode   bool
+	votingExcluded  []string
+	slmPolicies     []*snapshot.Policy
+	snapshots       map[string][]*snapshot.Snapshot
+	documents       map[string][]byte
+	documentVersion map[string]int64
+	nextDocID       int
+
+	warmPool       bool
+	reuseOnScaleIn bool
+	groupTags      map[string]string
+}
+
+// NewCluster creates new Cluster object with the given number of nodes
+// Nodes are named ip-10-0-1-1.ec2.internal, ip-10-0-1-2.ec2.internal, ...
+func NewCluster(size int) *Cluster {
+	c := &Cluster{
+		nodes:           []*node{},
+		reallocation:    true,
+		settings:        map[string]string{},
+		indexExclusions: map[string]string{},
+		indexSettings:   map[string]map[string]string{},
+		writeIndices:    map[string]string{},
+		mlJobs:          map[string]string{},
+		snapshots:       map[string][]*snapshot.Snapshot{},
+		documents:       map[string][]byte{},
+		documentVersion: map[string]int64{},
+		groupTags:       map[string]string{GroupTagKey: GroupTagValue},
+	}
+
+	for i := 0; i < size; i++ {
+		n := c.launch()
+
+		for j := 0; j < shardsPerNode; j++ {
+			n.shards = append(n.shards, &shard{
+				index: "fake",
+				id:    i*shardsPerNode + j,
+			})
+		}
+	}
+
+	return c
+}
+
+// ExcludedNode returns nodes excluded from shard allocation by ExcludeNodeFromAllocation, as comma-separated list
+func (c *Cluster) ExcludedNode() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.settings["cluster.routing.allocation.exclude._name"]
+}
+
+// GroupTag returns the value of the given tag of fake Auto Scaling Group
+func (c *Cluster) GroupTag(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.groupTags[key]
+}
+
+// InstanceTags returns tags of the given instance set by EC2().SetTags
+func (c *Cluster) InstanceTags(instanceID string) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tags := map[string]string{}
+
+	if n := c.findByInstanceID(instanceID); n != nil {
+		for k, v := range n.tags {
+			tags[k] = v
+		}
+	}
+
+	return tags
+}
+
+// IndexExclusion returns the node excluded from allocation of the given index
+func (c *Cluster) IndexExclusion(index string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.indexExclusions[index]
+}
+
+// IndexSetting returns the given setting of the given index, e.g. index.unassigned.node_left.delayed_timeout
+func (c *Cluster) IndexSetting(index, key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.indexSettings[index][key]
+}
+
+// ReallocationEnabled returns whether shard reallocation is enabled
+func (c *Cluster) ReallocationEnabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.reallocation
+}
+
+// SetIndexSetting sets the given setting of the given index
+// Settings do not move shards, and only allocation filters are returned by IndexAllocationFilters
+func (c *Cluster) SetIndexSetting(index, key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.indexSettings[index]; !ok {
+		c.indexSettings[index] = map[string]string{}
+	}
+
+	c.indexSettings[index][key] = value
+}
+
+// SetMLJob opens anomaly detection job on the given node
+func (c *Cluster) SetMLJob(id, nodeName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mlJobs[id] = nodeName
+}
+
+// MLUpgradeMode returns whether machine learning upgrade mode is enabled
+func (c *Cluster) MLUpgradeMode() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.mlUpgradeMode
+}
+
+// NodeShutdownPrepared returns whether the given node is registered for removal by PrepareNodeShutdown
+func (c *Cluster) NodeShutdownPrepared(nodeName string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.findByName(nodeName)
+
+	return n != nil && n.shuttingDown
+}
+
+// VotingExclusions returns names of nodes excluded from voting configuration by Shutdown
+func (c *Cluster) VotingExclusions() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string{}, c.votingExcluded...)
+}
+
+// SetNodeRoles sets roles of the given node. Nodes are launched with master, data and ingest roles
+// Shards on the node are moved to other running nodes if data role is removed
+func (c *Cluster) SetNodeRoles(nodeName string, roles ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.findByName(nodeName)
+	if n == nil {
+		return
+	}
+
+	n.roles = roles
+
+	for _, r := range roles {
+		if r == "data" {
+			return
+		}
+	}
+
+	others := []*node{}
+
+	for _, o := range c.nodes {
+		if o.running && o != n {
+			others = append(others, o)
+		}
+	}
+
+	if len(others) == 0 {
+		return
+	}
+
+	for i, s := range n.shards {
+		others[i%len(others)].shards = append(others[i%len(others)].shards, s)
+	}
+
+	n.shards = []*shard{}
+}
+
+// SetWriteIndex points the given write alias to the given index
+func (c *Cluster) SetWriteIndex(alias, index string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeIndices[alias] = index
+}
+
+// EnableWarmPool creates warm pool of Auto Scaling Group with the given number of warmed instances
+// Increasing instances moves warmed ones into service first. Scaled in instances return to warm pool if reuseOnScaleIn is true
+func (c *Cluster) EnableWarmPool(size int, reuseOnScaleIn bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.warmPool = true
+	c.reuseOnScaleIn = reuseOnScaleIn
+
+	for i := 0; i < size; i++ {
+		n := c.launch()
+		n.inService = false
+		n.inTargetGroup = false
+		n.running = false
+		n.warmed = true
+	}
+}
+
+// LaunchStandby launches instance outside Auto Scaling Group and returns its instance ID
+// Its node joins the cluster when the instance is attached to Auto Scaling Group
+func (c *Cluster) LaunchStandby() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.launch()
+	n.inService = false
+	n.inTargetGroup = false
+	n.running = false
+	n.standby = true
+
+	return n.instanceID
+}
+
+// SetNodeAttribute sets custom attribute of the given node, e.g. box_type=warm
+func (c *Cluster) SetNodeAttribute(nodeName, key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n := c.findByName(nodeName); n != nil {
+		n.attributes[key] = value
+	}
+}
+
+// SetSLMPolicy adds snapshot lifecycle management policy returned by SLMPolicies
+func (c *Cluster) SetSLMPolicy(p *snapshot.Policy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.slmPolicies = append(c.slmPolicies, p)
+}
+
+// AutoScaling returns simulated Auto Scaling API client
+func (c *Cluster) AutoScaling() aws.AutoScalingClient {
+	return &autoScalingClient{c: c}
+}
+
+// EC2 returns simulated EC2 API client
+func (c *Cluster) EC2() aws.EC2Client {
+	return &ec2Client{c: c}
+}
+
+// ELBv2 returns simulated ELBv2 API client
+func (c *Cluster) ELBv2() aws.ELBv2Client {
+	return &elbv2Client{c: c}
+}
+
+// ClearNodeShutdown cancels registration of the given node by PrepareNodeShutdown
+func (c *Cluster) ClearNodeShutdown(nodeName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n := c.findByName(nodeName); n != nil {
+		n.shuttingDown = false
+	}
+
+	return nil
+}
+
+// ClearVotingExclusions clears voting configuration exclusions added by Shutdown if no excluded node is running
+func (c *Cluster) ClearVotingExclusions() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, name := range c.votingExcluded {
+		if n := c.findByName(name); n != nil && n.running {
+			return nil
+		}
+	}
+
+	c.votingExcluded = nil
+
+	return nil
+}
+
+// ClusterHealth returns red status if shards are left on stopped nodes
+// No shard is relocating or initializing, because shards are relocated immediately
+func (c *Cluster) ClusterHealth() (*stats.Health, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	health := &stats.Health{
+		Status: "green",
+	}
+
+	for _, n := range c.nodes {
+		if !n.running {
+			health.UnassignedShards += len(n.shards)
+		}
+	}
+
+	if health.UnassignedShards > 0 {
+		health.Status = "red"
+	}
+
+	return health, nil
+}
+
+// ClusterSettings returns cluster settings updated by UpdateClusterSettings and ExcludeNodeFromAllocation
+func (c *Cluster) ClusterSettings() (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	settings := map[string]string{}
+
+	for k, v := range c.settings {
+		settings[k] = v
+	}
+
+	return settings, nil
+}
+
+// CreateDocument stores document only if the document with the same ID does not exist
+func (c *Cluster) CreateDocument(index, docType, id string, doc []byte) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := documentKey(index, docType, id)
+
+	if _, ok := c.documents[key]; ok {
+		return false, nil
+	}
+
+	c.documents[key] = doc
+	c.documentVersion[key]++
+
+	return true, nil
+}
+
+// CreateSnapshot takes snapshot of all shards, which completes immediately
+// Any repository name is accepted
+func (c *Cluster) CreateSnapshot(repository, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, s := range c.snapshots[repository] {
+		if s.Name == name {
+			return errors.Errorf("snapshot %q already exists in repository %q", name, repository)
+		}
+	}
+
+	indices := map[string]bool{}
+	total := 0
+
+	for _, n := range c.nodes {
+		for _, s := range n.shards {
+			indices[s.index] = true
+			total++
+		}
+	}
+
+	s := &snapshot.Snapshot{
+		Name:             name,
+		State:            snapshot.StateSuccess,
+		Indices:          []string{},
+		StartTime:        time.Now().UTC(),
+		TotalShards:      total,
+		SuccessfulShards: total,
+	}
+	s.EndTime = s.StartTime
+
+	for index := range indices {
+		s.Indices = append(s.Indices, index)
+	}
+
+	sort.Strings(s.Indices)
+
+	c.snapshots[repository] = append(c.snapshots[repository], s)
+
+	return nil
+}
+
+// DeleteDocument deletes the given document
+func (c *Cluster) DeleteDocument(index, docType, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.documents, documentKey(index, docType, id))
+
+	return nil
+}
+
+// DeleteDocumentIfVersion deletes the document only if its version matches
+func (c *Cluster) DeleteDocumentIfVersion(index, docType, id string, version *stats.DocumentVersion) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := documentKey(index, docType, id)
+
+	if _, ok := c.documents[key]; !ok {
+		return true, nil
+	}
+
+	if c.documentVersion[key] != version.Version {
+		return false, nil
+	}
+
+	delete(c.documents, key)
+	c.documentVersion[key]++
+
+	return true, nil
+}
+
+// DisableReallocation disables shard reallocation
+func (c *Cluster) DisableReallocation() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reallocation = false
+
+	return nil
+}
+
+// DiskUsage returns disk usage in percent of each running node, which is proportional to the number of shards
+func (c *Cluster) DiskUsage() (map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage := map[string]float64{}
+
+	for _, n := range c.nodes {
+		if n.running {
+			usage[n.name] = float64(len(n.shards)) * diskUsagePerShard
+		}
+	}
+
+	return usage, nil
+}
+
+// EnableReallocation enables shard reallocation
+func (c *Cluster) EnableReallocation() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reallocation = true
+
+	return nil
+}
+
+// ExcludeNodeFromAllocation moves shards on the given node to the other nodes which are not excluded
+func (c *Cluster) ExcludeNodeFromAllocation(nodeName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	target := c.findByName(nodeName)
+	if target == nil {
+		return errors.Errorf("node %q does not exist", nodeName)
+	}
+
+	if !c.excluded(nodeName) {
+		c.settings["cluster.routing.allocation.exclude._name"] = strings.Join(append(c.excludedNodes(), nodeName), ",")
+	}
+
+	others := []*node{}
+
+	for _, n := range c.nodes {
+		if n.running && !c.excluded(n.name) {
+			others = append(others, n)
+		}
+	}
+
+	if len(others) == 0 {
+		return nil
+	}
+
+	for i, s := range target.shards {
+		others[i%len(others)].shards = append(others[i%len(others)].shards, s)
+	}
+
+	target.shards = []*shard{}
+
+	return nil
+}
+
+// ExcludeNodeFromIndexAllocation moves shards of the given index from the given node to other running nodes
+func (c *Cluster) ExcludeNodeFromIndexAllocation(index, nodeName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.indexExclusions[index] = nodeName
+
+	target := c.findByName(nodeName)
+	if target == nil {
+		return nil
+	}
+
+	others := []*node{}
+
+	for _, n := range c.nodes {
+		if n.running && n.name != nodeName {
+			others = append(others, n)
+		}
+	}
+
+	if len(others) == 0 {
+		return nil
+	}
+
+	rest := []*shard{}
+
+	for _, s := range target.shards {
+		if s.index != index {
+			rest = append(rest, s)
+			continue
+		}
+
+		o := others[s.id%len(others)]
+		o.shards = append(o.shards, s)
+	}
+
+	target.shards = rest
+
+	return nil
+}
+
+// GetDocument returns the given document, or nil if it does not exist
+func (c *Cluster) GetDocument(index, docType, id string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.documents[documentKey(index, docType, id)], nil
+}
+
+// GetDocumentVersion returns the document and its version, which is incremented on every change
+func (c *Cluster) GetDocumentVersion(index, docType, id string) ([]byte, *stats.DocumentVersion, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := documentKey(index, docType, id)
+
+	doc, ok := c.documents[key]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	return doc, &stats.DocumentVersion{Version: c.documentVersion[key]}, nil
+}
+
+// GetSnapshot returns the given snapshot
+func (c *Cluster) GetSnapshot(repository, name string) (*snapshot.Snapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, s := range c.snapshots[repository] {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	return nil, errors.Errorf("snapshot %q is not found in repository %q", name, repository)
+}
+
+// IndexAllocationFilters returns allocation filters set by SetIndexSetting
+func (c *Cluster) IndexAllocationFilters() (map[string]map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	filters := map[string]map[string]string{}
+
+	for index, settings := range c.indexSettings {
+		for k, v := range settings {
+			if !strings.HasPrefix(k, "index.routing.allocation.") {
+				continue
+			}
+
+			if _, ok := filters[index]; !ok {
+				filters[index] = map[string]string{}
+			}
+
+			filters[index][k] = v
+		}
+	}
+
+	return filters, nil
+}
+
+// IndexDocument stores document with generated ID
+func (c *Cluster) IndexDocument(index, docType string, doc []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextDocID++
+	c.documents[documentKey(index, docType, fmt.Sprintf("%d", c.nextDocID))] = doc
+
+	return nil
+}
+
+// IndexSettings returns explicit values of the given setting per index, set by SetIndexSetting or UpdateIndexSettings
+func (c *Cluster) IndexSettings(key string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := map[string]string{}
+
+	for index, settings := range c.indexSettings {
+		if v, ok := settings[key]; ok {
+			values[index] = v
+		}
+	}
+
+	return values, nil
+}
+
+// ListNodes returns the list of running node names
+// Rebooted node is missing once, and joins again with its shards after that
+func (c *Cluster) ListNodes() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nodes := []string{}
+
+	for _, n := range c.nodes {
+		if n.rebooting {
+			n.rebooting = false
+			n.running = true
+			continue
+		}
+
+		if n.running {
+			nodes = append(nodes, n.name)
+		}
+	}
+
+	sort.Strings(nodes)
+
+	return nodes, nil
+}
+
+// ListShards returns every shard as primary without replica. Shards left on stopped nodes are unassigned
+func (c *Cluster) ListShards() ([]*stats.Shard, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shards := []*stats.Shard{}
+
+	for _, n := range c.nodes {
+		for _, s := range n.shards {
+			shard := &stats.Shard{
+				Index:   s.index,
+				Shard:   s.id,
+				Primary: true,
+				State:   "UNASSIGNED",
+			}
+
+			if n.running {
+				shard.State = "STARTED"
+				shard.Node = n.name
+			}
+
+			shards = append(shards, shard)
+		}
+	}
+
+	return shards, nil
+}
+
+// ListShardsOnNode returns the list of shards on the given node in _cat/shards format
+func (c *Cluster) ListShardsOnNode(nodeName string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	shards := []string{}
+
+	n := c.findByName(nodeName)
+	if n == nil {
+		return shards, nil
+	}
+
+	for _, s := range n.shards {
+		shards = append(shards, fmt.Sprintf("%s %d p STARTED %s", s.index, s.id, n.name))
+	}
+
+	return shards, nil
+}
+
+// ListSnapshots returns snapshots in the given repository in creation order
+func (c *Cluster) ListSnapshots(repository string) ([]*snapshot.Snapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*snapshot.Snapshot{}, c.snapshots[repository]...), nil
+}
+
+// MLJobs returns opened jobs. Jobs are unassigned while upgrade mode is enabled or their node is stopped
+func (c *Cluster) MLJobs() ([]*stats.MLJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	jobs := []*stats.MLJob{}
+
+	for id, nodeName := range c.mlJobs {
+		job := &stats.MLJob{ID: id, State: "opened"}
+
+		if n := c.findByName(nodeName); n != nil && n.running && !c.mlUpgradeMode {
+			job.Node = nodeName
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	return jobs, nil
+}
+
+// NodeAttributes returns custom attributes of the given node. Nodes are launched with box_type=hot
+func (c *Cluster) NodeAttributes(nodeName string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.findByName(nodeName)
+	if n == nil {
+		return map[string]string{}, errors.Errorf("node %q does not exist", nodeName)
+	}
+
+	attrs := map[string]string{}
+
+	for k, v := range n.attributes {
+		attrs[k] = v
+	}
+
+	return attrs, nil
+}
+
+// NodeRoles returns roles of each running node
+func (c *Cluster) NodeRoles() (map[string][]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	roles := map[string][]string{}
+
+	for _, n := range c.nodes {
+		if n.running {
+			roles[n.name] = append([]string{}, n.roles...)
+		}
+	}
+
+	return roles, nil
+}
+
+// NodeStats returns resource usage of each running node, which is proportional to the number of shards
+func (c *Cluster) NodeStats() ([]*stats.Node, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nodes := []*stats.Node{}
+
+	for _, n := range c.nodes {
+		if !n.running {
+			continue
+		}
+
+		shards := float64(len(n.shards))
+
+		nodes = append(nodes, &stats.Node{
+			Name:        n.name,
+			CPUPercent:  5 + shards*5,
+			HeapPercent: 20 + shards*5,
+			DiskPercent: shards * diskUsagePerShard,
+			Load1m:      shards * 0.2,
+			Shards:      len(n.shards),
+			StoreBytes:  int64(len(n.shards)) * storeBytesPerShard,
+		})
+	}
+
+	return nodes, nil
+}
+
+// PrepareNodeShutdown registers the given node for removal, so that jobs are not reassigned to it
+func (c *Cluster) PrepareNodeShutdown(nodeName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.findByName(nodeName)
+	if n == nil {
+		return errors.Errorf("node %q does not exist", nodeName)
+	}
+
+	n.shuttingDown = true
+
+	return nil
+}
+
+// Reroute assigns shards left on stopped nodes to the least loaded running nodes, as if recovered from replicas
+func (c *Cluster) Reroute() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	running := []*node{}
+
+	for _, n := range c.nodes {
+		if n.running {
+			running = append(running, n)
+		}
+	}
+
+	if len(running) == 0 {
+		return nil
+	}
+
+	for _, n := range c.nodes {
+		if n.running {
+			continue
+		}
+
+		for _, s := range n.shards {
+			least := running[0]
+
+			for _, r := range running[1:] {
+				if len(r.shards) < len(least.shards) {
+					least = r
+				}
+			}
+
+			least.shards = append(least.shards, s)
+		}
+
+		n.shards = []*shard{}
+	}
+
+	return nil
+}
+
+// RequireIndexAllocationAttribute moves shards of the given index to running nodes with the given attribute
+// Shards stay if there is no such node
+func (c *Cluster) RequireIndexAllocationAttribute(index, key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	matched := []*node{}
+
+	for _, n := range c.nodes {
+		if n.running && n.attributes[key] == value {
+			matched = append(matched, n)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil
+	}
+
+	for _, n := range c.nodes {
+		if n.attributes[key] == value {
+			continue
+		}
+
+		rest := []*shard{}
+
+		for _, s := range n.shards {
+			if s.index != index {
+				rest = append(rest, s)
+				continue
+			}
+
+			m := matched[s.id%len(matched)]
+			m.shards = append(m.shards, s)
+		}
+
+		n.shards = rest
+	}
+
+	return nil
+}
+
+// ResetIndexSettings removes the given settings from the given index
+func (c *Cluster) ResetIndexSettings(index string, keys []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, k := range keys {
+		delete(c.indexSettings[index], k)
+	}
+
+	return nil
+}
+
+// Rollover points the given alias to new index whose name has the number suffix incremented, e.g. logs-000002
+// New index has no shards
+func (c *Cluster) Rollover(alias string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, ok := c.writeIndices[alias]
+	if !ok {
+		return "", errors.Errorf("alias %q does not exist", alias)
+	}
+
+	i := strings.LastIndex(index, "-")
+
+	n, err := strconv.Atoi(index[i+1:])
+	if i < 0 || err != nil {
+		return "", errors.Errorf("index name %q does not end with - and number", index)
+	}
+
+	next := fmt.Sprintf("%s-%06d", index[:i], n+1)
+	c.writeIndices[alias] = next
+
+	return next, nil
+}
+
+// SLMPolicies returns policies added by SetSLMPolicy
+func (c *Cluster) SLMPolicies() ([]*snapshot.Policy, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*snapshot.Policy{}, c.slmPolicies...), nil
+}
+
+// SetMLUpgradeMode enables or disables upgrade mode
+// Disabling it reassigns jobs on stopped nodes or nodes registered for removal to the first other running node
+func (c *Cluster) SetMLUpgradeMode(enabled bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mlUpgradeMode = enabled
+
+	if enabled {
+		return nil
+	}
+
+	var first *node
+
+	for _, n := range c.nodes {
+		if n.running && !n.shuttingDown {
+			first = n
+			break
+		}
+	}
+
+	if first == nil {
+		return nil
+	}
+
+	for id, nodeName := range c.mlJobs {
+		if n := c.findByName(nodeName); n == nil || !n.running || n.shuttingDown {
+			c.mlJobs[id] = first.name
+		}
+	}
+
+	return nil
+}
+
+// Shutdown stops the given node, and excludes it from voting configuration if it is master-eligible
+func (c *Cluster) Shutdown(nodeName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.findByName(nodeName)
+	if n == nil {
+		return errors.Errorf("node %q does not exist", nodeName)
+	}
+
+	n.running = false
+
+	for _, r := range n.roles {
+		if r == "master" {
+			c.votingExcluded = append(c.votingExcluded, nodeName)
+		}
+	}
+
+	return nil
+}
+
+// UpdateClusterSettings updates cluster settings
+// Shards are balanced among running nodes if cluster.routing.rebalance.enable is set to "all",
+// or if allocation exclusion is cleared while rebalancing is not disabled
+func (c *Cluster) UpdateClusterSettings(settings map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	excluded := len(c.excludedNodes())
+
+	for k, v := range settings {
+		c.settings[k] = v
+	}
+
+	_, ok := settings["cluster.routing.allocation.exclude._name"]
+	included := ok && len(c.excludedNodes()) < excluded && c.settings["cluster.routing.rebalance.enable"] != "none"
+
+	if c.settings["cluster.routing.rebalance.enable"] == "all" || included {
+		c.rebalance()
+	}
+
+	return nil
+}
+
+// UpdateDocumentIfVersion replaces the document only if it exists with the given version
+func (c *Cluster) UpdateDocumentIfVersion(index, docType, id string, doc []byte, version *stats.DocumentVersion) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := documentKey(index, docType, id)
+
+	if _, ok := c.documents[key]; !ok || c.documentVersion[key] != version.Version {
+		return false, nil
+	}
+
+	c.documents[key] = doc
+	c.documentVersion[key]++
+
+	return true, nil
+}
+
+// UpdateIndexSettings sets the given settings of the given index, or of all indices if index is "_all"
+func (c *Cluster) UpdateIndexSettings(index string, settings map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, i := range c.resolveIndices(index) {
+		if _, ok := c.indexSettings[i]; !ok {
+			c.indexSettings[i] = map[string]string{}
+		}
+
+		for k, v := range settings {
+			c.indexSettings[i][k] = v
+		}
+	}
+
+	return nil
+}
+
+// WriteIndices returns write index of each alias set by SetWriteIndex
+func (c *Cluster) WriteIndices() (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	indices := map[string]string{}
+
+	for k, v := range c.writeIndices {
+		indices[k] = v
+	}
+
+	return indices, nil
+}
+
+// excludedNodes returns nodes listed in allocation exclusion
+func (c *Cluster) excludedNodes() []string {
+	nodes := []string{}
+
+	for _, n := range strings.Split(c.settings["cluster.routing.allocation.exclude._name"], ",") {
+		if n != "" {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
+
+// excluded returns whether the given node is excluded from allocation
+func (c *Cluster) excluded(nodeName string) bool {
+	for _, n := range c.excludedNodes() {
+		if n == nodeName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rebalance moves shards from the most loaded node to the least loaded one until they differ by 1 at most
+// Node excluded from allocation is left as is
+func (c *Cluster) rebalance() {
+	for {
+		var most, least *node
+
+		for _, n := range c.nodes {
+			if !n.running || c.excluded(n.name) {
+				continue
+			}
+
+			if most == nil || len(n.shards) > len(most.shards) {
+				most = n
+			}
+
+			if least == nil || len(n.shards) < len(least.shards) {
+				least = n
+			}
+		}
+
+		if most == nil || len(most.shards)-len(least.shards) <= 1 {
+			return
+		}
+
+		last := len(most.shards) - 1
+		least.shards = append(least.shards, most.shards[last])
+		most.shards = most.shards[:last]
+	}
+}
+
+// resolveIndices returns the given index, or all indices with shards or settings if index is "_all"
+func (c *Cluster) resolveIndices(index string) []string {
+	if index != "_all" {
+		return []string{index}
+	}
+
+	seen := map[string]bool{}
+	indices := []string{}
+
+	for _, n := range c.nodes {
+		for _, s := range n.shards {
+			if !seen[s.index] {
+				seen[s.index] = true
+				indices = append(indices, s.index)
+			}
+		}
+	}
+
+	for i := range c.indexSettings {
+		if !seen[i] {
+			seen[i] = true
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+// launch must be called with c.mu held, or before c is shared
+func (c *Cluster) launch() *node {
+	i := len(c.nodes) + 1
+
+	n := &node{
+		name:          fmt.Sprintf("ip-10-0-1-%d.ec2.internal", i),
+		instanceID:    fmt.Sprintf("i-%08x", i),
+		zone:          availabilityZones[(i-1)%len(availabilityZones)],
+		attributes:    map[string]string{"box_type": "hot"},
+		roles:         []string{"master", "data", "ingest"},
+		shards:        []*shard{},
+		inService:     true,
+		inTargetGroup: true,
+		running:       true,
+	}
+
+	c.nodes = append(c.nodes, n)
+
+	return n
+}
+
+func (c *Cluster) findByName(name string) *node {
+	for _, n := range c.nodes {
+		if n.name == name {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func (c *Cluster) findWarmed() *node {
+	for _, n := range c.nodes {
+		if n.warmed {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func (c *Cluster) findByInstanceID(instanceID string) *node {
+	for _, n := range c.nodes {
+		if n.instanceID == instanceID {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func documentKey(index, docType, id string) string {
+	return index + "/" + docType + "/" + id
+}
+
+type autoScalingClient struct {
+	c *Cluster
+}
+
+func (a *autoScalingClient) AttachInstance(groupName, instanceID string) error {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	n := a.c.findByInstanceID(instanceID)
+	if n == nil || n.terminated {
+		return errors.Errorf("instance %s is not running", instanceID)
+	}
+
+	if n.inService {
+		return errors.Errorf("instance %s is already attached to %q", instanceID, groupName)
+	}
+
+	n.inService = true
+	n.inTargetGroup = true
+	n.warmed = false
+
+	if n.standby {
+		n.standby = false
+		n.running = true
+	}
+
+	return nil
+}
+
+func (a *autoScalingClient) DescribeGroup(groupName string) (*autoscaling.Group, error) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	desiredCapacity := 0
+
+	for _, n := range a.c.nodes {
+		if n.inService {
+			desiredCapacity++
+		}
+	}
+
+	tags := map[string]string{}
+
+	for k, v := range a.c.groupTags {
+		tags[k] = v
+	}
+
+	return &autoscaling.Group{
+		Name:            GroupName,
+		DesiredCapacity: desiredCapacity,
+		MaxSize:         GroupMaxSize,
+		Tags:            tags,
+	}, nil
+}
+
+func (a *autoScalingClient) DescribeInstances(groupName string) ([]*autoscaling.Instance, error) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	instances := []*autoscaling.Instance{}
+
+	for _, n := range a.c.nodes {
+		if n.inService {
+			instances = append(instances, &autoscaling.Instance{
+				ID:               n.instanceID,
+				AvailabilityZone: n.zone,
+				LifecycleState:   "InService",
+			})
+		}
+	}
+
+	return instances, nil
+}
+
+func (a *autoScalingClient) DescribeWarmPool(groupName string) (*autoscaling.WarmPool, error) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	if !a.c.warmPool {
+		return nil, nil
+	}
+
+	pool := &autoscaling.WarmPool{
+		ReuseOnScaleIn: a.c.reuseOnScaleIn,
+		Instances:      []*autoscaling.Instance{},
+	}
+
+	for _, n := range a.c.nodes {
+		if n.warmed {
+			pool.Instances = append(pool.Instances, &autoscaling.Instance{
+				ID:               n.instanceID,
+				AvailabilityZone: n.zone,
+				LifecycleState:   "Warmed:Stopped",
+			})
+		}
+	}
+
+	return pool, nil
+}
+
+func (a *autoScalingClient) DetachInstance(groupName, instanceID string, decrementDesiredCapacity bool) error {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	n := a.c.findByInstanceID(instanceID)
+	if n == nil || !n.inService {
+		return errors.Errorf("instance %s is not attached to %q", instanceID, groupName)
+	}
+
+	n.inService = false
+
+	if !decrementDesiredCapacity {
+		a.c.launch()
+	}
+
+	return nil
+}
+
+func (a *autoScalingClient) IncreaseInstances(groupName string, delta int) (int, error) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	for i := 0; i < delta; i++ {
+		if n := a.c.findWarmed(); n != nil {
+			n.warmed = false
+			n.inService = true
+			n.inTargetGroup = true
+			n.running = true
+
+			continue
+		}
+
+		a.c.launch()
+	}
+
+	desiredCapacity := 0
+
+	for _, n := range a.c.nodes {
+		if n.inService {
+			desiredCapacity++
+		}
+	}
+
+	return desiredCapacity, nil
+}
+
+func (a *autoScalingClient) ListGroups() ([]string, error) {
+	return []string{GroupName}, nil
+}
+
+func (a *autoScalingClient) ListGroupsByTag(key, value string) ([]string, error) {
+	if key == GroupTagKey && value == GroupTagValue {
+		return []string{GroupName}, nil
+	}
+
+	return []string{}, nil
+}
+
+func (a *autoScalingClient) ListInstances(groupName string) ([]string, error) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	instances := []string{}
+
+	for _, n := range a.c.nodes {
+		if n.inService {
+			instances = append(instances, n.instanceID)
+		}
+	}
+
+	return instances, nil
+}
+
+func (a *autoScalingClient) RetrieveGroupOfInstance(instanceID string) (string, error) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	if n := a.c.findByInstanceID(instanceID); n != nil && n.inService {
+		return GroupName, nil
+	}
+
+	return "", nil
+}
+
+func (a *autoScalingClient) RetrieveTargetGroup(groupName string) (string, error) {
+	return TargetGroupARN, nil
+}
+
+func (a *autoScalingClient) ReturnToWarmPool(groupName, instanceID string) error {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	n := a.c.findByInstanceID(instanceID)
+	if n == nil || !n.inService {
+		return errors.Errorf("instance %s is not attached to %q", instanceID, groupName)
+	}
+
+	n.inService = false
+	n.inTargetGroup = false
+	n.running = false
+
+	if a.c.warmPool && a.c.reuseOnScaleIn {
+		n.warmed = true
+	} else {
+		n.terminated = true
+	}
+
+	return nil
+}
+
+func (a *autoScalingClient) SetTag(groupName, key, value string) error {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	a.c.groupTags[key] = value
+
+	return nil
+}
+
+type ec2Client struct {
+	c *Cluster
+}
+
+func (e *ec2Client) DescribeInstance(instanceID string) (*ec2.Instance, error) {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	n := e.c.findByInstanceID(instanceID)
+	if n == nil {
+		return nil, errors.Errorf("instance %s not found", instanceID)
+	}
+
+	state := "running"
+
+	switch {
+	case n.terminated:
+		state = "terminated"
+	case n.warmed:
+		state = "stopped"
+	}
+
+	return &ec2.Instance{
+		ID:           n.instanceID,
+		PrivateDNS:   n.name,
+		State:        state,
+		InstanceType: InstanceType,
+		Lifecycle:    "on-demand",
+	}, nil
+}
+
+func (e *ec2Client) RebootInstance(instanceID string) error {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	n := e.c.findByInstanceID(instanceID)
+	if n == nil || !n.running {
+		return errors.Errorf("instance %s is not running", instanceID)
+	}
+
+	n.running = false
+	n.rebooting = true
+
+	return nil
+}
+
+func (e *ec2Client) RetrieveInstanceIDFromPrivateDNS(privateDNS string) (string, error) {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	n := e.c.findByName(privateDNS)
+	if n == nil {
+		return "", errors.Errorf("instance with %q not found", privateDNS)
+	}
+
+	return n.instanceID, nil
+}
+
+func (e *ec2Client) SetTags(instanceID string, tags map[string]string) error {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	n := e.c.findByInstanceID(instanceID)
+	if n == nil {
+		return errors.Errorf("instance %s does not exist", instanceID)
+	}
+
+	if n.tags == nil {
+		n.tags = map[string]string{}
+	}
+
+	for k, v := range tags {
+		n.tags[k] = v
+	}
+
+	return nil
+}
+
+func (e *ec2Client) TerminateInstance(instanceID string) error {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	n := e.c.findByInstanceID(instanceID)
+	if n == nil {
+		return errors.Errorf("instance %s does not exist", instanceID)
+	}
+
+	n.inService = false
+	n.inTargetGroup = false
+	n.running = false
+	n.standby = false
+	n.terminated = true
+
+	return nil
+}
+
+type elbv2Client struct {
+	c *Cluster
+}
+
+func (e *elbv2Client) DetachInstance(targetGroupARN, instanceID string) error {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	if targetGroupARN != TargetGroupARN {
+		return errors.Errorf("target group %s does not exist", targetGroupARN)
+	}
+
+	n := e.c.findByInstanceID(instanceID)
+	if n == nil {
+		return errors.Errorf("instance %s does not exist", instanceID)
+	}
+
+	n.inTargetGroup = false
+
+	return nil
+}
+
+func (e *elbv2Client) ListTargetInstances(targetGroupARN string) ([]string, error) {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	if targetGroupARN != TargetGroupARN {
+		return []string{}, errors.Errorf("target group %s does not exist", targetGroupARN)
+	}
+
+	instances := []string{}
+
+	for _, n := range e.c.nodes {
+		if n.inTargetGroup {
+			instances = append(instances, n.instanceID)
+		}
+	}
+
+	return instances, nil
+}
+
+func (e *elbv2Client) RegisterInstance(targetGroupARN, instanceID string) error {
+	e.c.mu.Lock()
+	defer e.c.mu.Unlock()
+
+	if targetGroupARN != TargetGroupARN {
+		return errors.Errorf("target group %s does not exist", targetGroupARN)
+	}
+
+	n := e.c.findByInstanceID(instanceID)
+	if n == nil || n.terminated {
+		return errors.Errorf("instance %s is not running", instanceID)
+	}
+
+	n.inTargetGroup = true
+
+	return nil
+}
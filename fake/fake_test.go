@@ -0,0 +1,270 @@
+package fake
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewCluster(t *testing.T) {
+	c := NewCluster(3)
+
+	nodes, err := c.ListNodes()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := []string{"ip-10-0-1-1.ec2.internal", "ip-10-0-1-2.ec2.internal", "ip-10-0-1-3.ec2.internal"}
+
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("nodes do not match. expected: %v, got: %v", expected, nodes)
+	}
+
+	instanceID, err := c.EC2().RetrieveInstanceIDFromPrivateDNS("ip-10-0-1-2.ec2.internal")
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if instanceID != "i-00000002" {
+		t.Errorf("instance ID does not match. expected: %q, got: %q", "i-00000002", instanceID)
+	}
+}
+
+func TestExcludeNodeFromAllocation(t *testing.T) {
+	c := NewCluster(3)
+
+	if err := c.ExcludeNodeFromAllocation("ip-10-0-1-1.ec2.internal"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	shards, _ := c.ListShardsOnNode("ip-10-0-1-1.ec2.internal")
+	if len(shards) != 0 {
+		t.Errorf("shards should escape from excluded node. got: %v", shards)
+	}
+
+	total := 0
+
+	for _, name := range []string{"ip-10-0-1-2.ec2.internal", "ip-10-0-1-3.ec2.internal"} {
+		shards, _ := c.ListShardsOnNode(name)
+		total += len(shards)
+	}
+
+	if total != 3*shardsPerNode {
+		t.Errorf("number of shards does not match. expected: %d, got: %d", 3*shardsPerNode, total)
+	}
+}
+
+func TestExcludeNodeFromIndexAllocation(t *testing.T) {
+	c := NewCluster(3)
+
+	if err := c.ExcludeNodeFromIndexAllocation("other", "ip-10-0-1-1.ec2.internal"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	shards, _ := c.ListShardsOnNode("ip-10-0-1-1.ec2.internal")
+	if len(shards) != shardsPerNode {
+		t.Errorf("shards of other index should stay. got: %v", shards)
+	}
+
+	if err := c.ExcludeNodeFromIndexAllocation("fake", "ip-10-0-1-1.ec2.internal"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	shards, _ = c.ListShardsOnNode("ip-10-0-1-1.ec2.internal")
+	if len(shards) != 0 {
+		t.Errorf("shards should escape from excluded node. got: %v", shards)
+	}
+
+	if got := c.IndexExclusion("fake"); got != "ip-10-0-1-1.ec2.internal" {
+		t.Errorf("excluded node does not match. got: %q", got)
+	}
+
+	if err := c.ExcludeNodeFromIndexAllocation("fake", ""); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got := c.IndexExclusion("fake"); got != "" {
+		t.Errorf("exclusion should be cleared. got: %q", got)
+	}
+}
+
+func TestRequireIndexAllocationAttribute(t *testing.T) {
+	c := NewCluster(3)
+	c.SetNodeAttribute("ip-10-0-1-3.ec2.internal", "box_type", "warm")
+
+	if err := c.RequireIndexAllocationAttribute("fake", "box_type", "warm"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	for _, name := range []string{"ip-10-0-1-1.ec2.internal", "ip-10-0-1-2.ec2.internal"} {
+		shards, _ := c.ListShardsOnNode(name)
+		if len(shards) != 0 {
+			t.Errorf("shards should escape from hot node %s. got: %v", name, shards)
+		}
+	}
+
+	attrs, _ := c.NodeAttributes("ip-10-0-1-3.ec2.internal")
+	if attrs["box_type"] != "warm" {
+		t.Errorf("attribute does not match. got: %v", attrs)
+	}
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	c := NewCluster(2)
+
+	if err := c.CreateSnapshot("backup", "snapshot-1"); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	s, err := c.GetSnapshot("backup", "snapshot-1")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !s.Done() || s.TotalShards != 2*shardsPerNode {
+		t.Errorf("snapshot of all shards should be completed. got: %+v", s)
+	}
+
+	if err := c.CreateSnapshot("backup", "snapshot-1"); err == nil {
+		t.Errorf("error should be raised for duplicated snapshot")
+	}
+
+	snapshots, _ := c.ListSnapshots("backup")
+	if len(snapshots) != 1 {
+		t.Errorf("number of snapshots does not match. expected: 1, got: %d", len(snapshots))
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	c := NewCluster(3)
+
+	if err := c.ExcludeNodeFromAllocation("ip-10-0-1-1.ec2.internal"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	usage, err := c.DiskUsage()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if usage["ip-10-0-1-1.ec2.internal"] != 0 {
+		t.Errorf("excluded node should not use disk. got: %f", usage["ip-10-0-1-1.ec2.internal"])
+	}
+
+	if usage["ip-10-0-1-2.ec2.internal"]+usage["ip-10-0-1-3.ec2.internal"] != 3*shardsPerNode*diskUsagePerShard {
+		t.Errorf("disk usage does not match. got: %v", usage)
+	}
+}
+
+func TestDescribeInstances(t *testing.T) {
+	c := NewCluster(3)
+
+	instances, err := c.AutoScaling().DescribeInstances(GroupName)
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if len(instances) != 3 || instances[1].AvailabilityZone != "us-east-1b" {
+		t.Errorf("instances do not match. got: %v", instances)
+	}
+}
+
+func TestIncreaseInstances(t *testing.T) {
+	c := NewCluster(3)
+
+	if err := c.AutoScaling().DetachInstance("elasticsearch", "i-00000001", true); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	desiredCapacity, err := c.AutoScaling().IncreaseInstances("elasticsearch", 2)
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if desiredCapacity != 4 {
+		t.Errorf("desired capacity does not match. expected: 4, got: %d", desiredCapacity)
+	}
+
+	instances, _ := c.ELBv2().ListTargetInstances(TargetGroupARN)
+	if len(instances) != 5 {
+		t.Errorf("number of target instances does not match. expected: 5, got: %d", len(instances))
+	}
+}
+
+func TestUpdateClusterSettings(t *testing.T) {
+	c := NewCluster(2)
+
+	if _, err := c.AutoScaling().IncreaseInstances("elasticsearch", 2); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if err := c.UpdateClusterSettings(map[string]string{"cluster.routing.rebalance.enable": "all"}); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.NodeStats()
+	for _, n := range nodes {
+		if n.Shards < 1 || n.Shards > 2 {
+			t.Errorf("shards should be balanced. got: %d shards on %s", n.Shards, n.Name)
+		}
+	}
+
+	settings, _ := c.ClusterSettings()
+	if settings["cluster.routing.rebalance.enable"] != "all" {
+		t.Errorf("setting should be updated. got: %v", settings)
+	}
+}
+
+func TestCreateDocument(t *testing.T) {
+	c := NewCluster(1)
+
+	created, err := c.CreateDocument(".esnctl-lock", "lock", "cluster", []byte(`{}`))
+	if err != nil || !created {
+		t.Errorf("document should be created. created: %t, err: %v", created, err)
+	}
+
+	created, err = c.CreateDocument(".esnctl-lock", "lock", "cluster", []byte(`{}`))
+	if err != nil || created {
+		t.Errorf("document should not be created twice. created: %t, err: %v", created, err)
+	}
+
+	if err := c.DeleteDocument(".esnctl-lock", "lock", "cluster"); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	doc, _ := c.GetDocument(".esnctl-lock", "lock", "cluster")
+	if doc != nil {
+		t.Errorf("document should be deleted. got: %s", doc)
+	}
+}
+
+func TestRebootInstance(t *testing.T) {
+	c := NewCluster(3)
+
+	if err := c.EC2().RebootInstance("i-00000002"); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	health, _ := c.ClusterHealth()
+	if health.UnassignedShards != shardsPerNode {
+		t.Errorf("shards on rebooted node should be unassigned. expected: %d, got: %d", shardsPerNode, health.UnassignedShards)
+	}
+
+	nodes, _ := c.ListNodes()
+	if len(nodes) != 2 {
+		t.Errorf("rebooted node should leave first. got: %v", nodes)
+	}
+
+	nodes, _ = c.ListNodes()
+	if len(nodes) != 3 {
+		t.Errorf("rebooted node should join again. got: %v", nodes)
+	}
+
+	health, _ = c.ClusterHealth()
+	if health.UnassignedShards != 0 {
+		t.Errorf("shards should be allocated to rejoined node. got: %d", health.UnassignedShards)
+	}
+
+	if err := c.EC2().RebootInstance("i-00000009"); err == nil {
+		t.Errorf("error should be raised for unknown instance")
+	}
+}
@@ -0,0 +1,275 @@
+package forecast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+const fileName = "samples.jsonl"
+
+// HighWatermarkSetting represents disk usage above which shards are relocated away from node
+const HighWatermarkSetting = "cluster.routing.allocation.disk.watermark.high"
+
+// DefaultHighWatermark represents the default of HighWatermarkSetting in percent
+const DefaultHighWatermark = 90.0
+
+// Sample represents disk usage of cluster at a point in time
+type Sample struct {
+	Cluster string    `json:"cluster"`
+	Time    time.Time `json:"time"`
+
+	es.DiskUsage
+}
+
+// Store represents append-only disk usage samples stored in local file
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the default sample directory, ~/.esnctl/forecast
+func DefaultDir() string {
+	return filepath.Join(config.Dir(), "forecast")
+}
+
+// New creates new Store object
+func New(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Append appends the given sample
+func (s *Store) Append(sample *Sample) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create sample directory")
+	}
+
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize sample")
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, fileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open sample file")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write sample")
+	}
+
+	return nil
+}
+
+// List returns samples of the given cluster taken since the given time, oldest first
+func (s *Store) List(cluster string, since time.Time) ([]*Sample, error) {
+	f, err := os.Open(filepath.Join(s.dir, fileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Sample{}, nil
+		}
+
+		return []*Sample{}, errors.Wrap(err, "failed to open sample file")
+	}
+	defer f.Close()
+
+	samples := []*Sample{}
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var sample Sample
+
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return []*Sample{}, errors.Wrap(err, "sample file is broken")
+		}
+
+		if sample.Cluster == cluster && !sample.Time.Before(since) {
+			samples = append(samples, &sample)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return []*Sample{}, errors.Wrap(err, "failed to read sample file")
+	}
+
+	return samples, nil
+}
+
+// Forecast represents disk usage projected from growth of shard size
+type Forecast struct {
+	Samples int `json:"samples"`
+
+	// GrowthPerDay represents growth of shard size in bytes per day, fitted by least squares
+	GrowthPerDay float64 `json:"growth_per_day"`
+
+	Nodes          int     `json:"nodes"`
+	UsedBytes      int64   `json:"used_bytes"`
+	TotalBytes     int64   `json:"total_bytes"`
+	HighWatermark  float64 `json:"high_watermark"`
+	TargetPercent  float64 `json:"target_percent"`
+	Days           int     `json:"days"`
+	ProjectedBytes int64   `json:"projected_bytes"`
+
+	// DaysUntilHighWatermark is negative if disk usage is not growing
+	DaysUntilHighWatermark float64 `json:"days_until_high_watermark"`
+
+	// NodesToAdd represents the number of nodes needed to stay under target utilization for Days
+	NodesToAdd int `json:"nodes_to_add"`
+}
+
+// Project projects disk usage of the latest sample after the given days from growth of shard size over samples
+// Every data node is assumed to have the same disk size, so that added nodes bring the mean disk size
+func Project(samples []*Sample, highWatermark, target float64, days int) (*Forecast, error) {
+	if len(samples) < 2 || !samples[len(samples)-1].Time.After(samples[0].Time) {
+		return nil, errors.New("at least 2 samples taken at different times are needed")
+	}
+
+	latest := samples[len(samples)-1]
+
+	if latest.Nodes == 0 || latest.TotalBytes == 0 {
+		return nil, errors.New("no data node has disk in the latest sample")
+	}
+
+	f := &Forecast{
+		Samples:       len(samples),
+		GrowthPerDay:  slope(samples),
+		Nodes:         latest.Nodes,
+		UsedBytes:     latest.UsedBytes(),
+		TotalBytes:    latest.TotalBytes,
+		HighWatermark: highWatermark,
+		TargetPercent: target,
+		Days:          days,
+	}
+
+	f.ProjectedBytes = f.UsedBytes + int64(math.Max(f.GrowthPerDay, 0)*float64(days))
+
+	f.DaysUntilHighWatermark = -1
+
+	if f.GrowthPerDay > 0 {
+		f.DaysUntilHighWatermark = math.Max((float64(f.TotalBytes)*highWatermark/100-float64(f.UsedBytes))/f.GrowthPerDay, 0)
+	}
+
+	perNode := float64(f.TotalBytes) / float64(f.Nodes) * target / 100
+
+	if needed := int(math.Ceil(float64(f.ProjectedBytes) / perNode)); needed > f.Nodes {
+		f.NodesToAdd = needed - f.Nodes
+	}
+
+	return f, nil
+}
+
+// Render prints forecast in human readable form
+func (f *Forecast) Render(out io.Writer) {
+	fmt.Fprintf(out, "Samples:    %d\n", f.Samples)
+	fmt.Fprintf(out, "Disk usage: %s / %s (%.1f%%) on %d data nodes\n", formatBytes(f.UsedBytes), formatBytes(f.TotalBytes), percent(f.UsedBytes, f.TotalBytes), f.Nodes)
+	fmt.Fprintf(out, "Growth:     %s / day\n", formatBytes(int64(f.GrowthPerDay)))
+
+	if f.DaysUntilHighWatermark < 0 {
+		fmt.Fprintf(out, "High watermark (%.0f%%): not reached, disk usage is not growing\n", f.HighWatermark)
+	} else {
+		fmt.Fprintf(out, "High watermark (%.0f%%): in %.1f days\n", f.HighWatermark, f.DaysUntilHighWatermark)
+	}
+
+	fmt.Fprintf(out, "In %d days:  %s (%.1f%%)\n", f.Days, formatBytes(f.ProjectedBytes), percent(f.ProjectedBytes, f.TotalBytes))
+
+	if f.NodesToAdd > 0 {
+		fmt.Fprintf(out, "\nAdd %d nodes to stay under %.0f%% for %d days: esnctl add --number %d\n", f.NodesToAdd, f.TargetPercent, f.Days, f.NodesToAdd)
+	} else {
+		fmt.Fprintf(out, "\nNo node needs to be added to stay under %.0f%% for %d days\n", f.TargetPercent, f.Days)
+	}
+}
+
+// ParseWatermark returns the given watermark in percent, e.g. 90% or 0.9
+// Watermark in absolute free bytes, e.g. 50gb, cannot be compared with usage of the whole cluster
+func ParseWatermark(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return DefaultHighWatermark, nil
+	}
+
+	if strings.HasSuffix(v, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		if err != nil {
+			return 0, exitcode.Errorf(exitcode.Validation, "invalid watermark %q", v)
+		}
+
+		return f, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, exitcode.Errorf(exitcode.Validation, "watermark %q is not in percent or ratio. Give it with --high-watermark", v)
+	}
+
+	return f * 100, nil
+}
+
+// slope returns growth of shard size per day by least squares fit
+func slope(samples []*Sample) float64 {
+	origin := samples[0].Time
+
+	var sumX, sumY, sumXY, sumXX float64
+
+	for _, s := range samples {
+		x := s.Time.Sub(origin).Hours() / 24
+		y := float64(s.StoreBytes)
+
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+
+	d := n*sumXX - sumX*sumX
+	if d == 0 {
+		return 0
+	}
+
+	return (n*sumXY - sumX*sumY) / d
+}
+
+func percent(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return float64(a) / float64(b) * 100
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
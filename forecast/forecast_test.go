@@ -0,0 +1,140 @@
+package forecast
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+const gib = 1024 * 1024 * 1024
+
+func testSamples() []*Sample {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	samples := []*Sample{}
+
+	for i, store := range []int64{100, 110, 120} {
+		samples = append(samples, &Sample{
+			Cluster: "http://elasticsearch.example.com",
+			Time:    origin.Add(time.Duration(i) * 24 * time.Hour),
+			DiskUsage: es.DiskUsage{
+				Nodes:          4,
+				TotalBytes:     1000 * gib,
+				AvailableBytes: 400 * gib,
+				StoreBytes:     store * gib,
+			},
+		})
+	}
+
+	return samples
+}
+
+func TestProject(t *testing.T) {
+	f, err := Project(testSamples(), 90, 70, 30)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if f.GrowthPerDay != 10*gib {
+		t.Errorf("growth does not match. expected: %d, got: %f", 10*gib, f.GrowthPerDay)
+	}
+
+	if f.DaysUntilHighWatermark != 30 {
+		t.Errorf("days until high watermark does not match. expected: 30, got: %f", f.DaysUntilHighWatermark)
+	}
+
+	if f.ProjectedBytes != 900*gib {
+		t.Errorf("projected bytes does not match. expected: %d, got: %d", 900*gib, f.ProjectedBytes)
+	}
+
+	// 900 GiB over 175 GiB (70% of 250 GiB) per node needs 6 nodes
+	if f.NodesToAdd != 2 {
+		t.Errorf("nodes to add does not match. expected: 2, got: %d", f.NodesToAdd)
+	}
+
+	var buf bytes.Buffer
+	f.Render(&buf)
+
+	if !strings.Contains(buf.String(), "esnctl add --number 2") {
+		t.Errorf("output should suggest add. got:\n%s", buf.String())
+	}
+}
+
+func TestProject_notGrowing(t *testing.T) {
+	samples := testSamples()
+	samples[2].StoreBytes = 90 * gib
+
+	f, err := Project(samples, 90, 70, 30)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if f.DaysUntilHighWatermark >= 0 || f.ProjectedBytes != f.UsedBytes || f.NodesToAdd != 0 {
+		t.Errorf("shrinking cluster should not be projected to grow. got: %+v", f)
+	}
+
+	if _, err := Project(samples[:1], 90, 70, 30); err == nil {
+		t.Errorf("error should be raised with 1 sample")
+	}
+}
+
+func TestParseWatermark(t *testing.T) {
+	testcases := []struct {
+		v        string
+		expected float64
+	}{
+		{v: "", expected: DefaultHighWatermark},
+		{v: "85%", expected: 85},
+		{v: "0.95", expected: 95},
+	}
+
+	for _, tc := range testcases {
+		got, err := ParseWatermark(tc.v)
+		if err != nil {
+			t.Errorf("%q: error should not be raised: %s", tc.v, err)
+			continue
+		}
+
+		if got != tc.expected {
+			t.Errorf("%q: watermark does not match. expected: %f, got: %f", tc.v, tc.expected, got)
+		}
+	}
+
+	if _, err := ParseWatermark("50gb"); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised for absolute watermark. got: %v", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-forecast")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := New(dir)
+
+	samples := testSamples()
+	other := &Sample{Cluster: "http://other.example.com", Time: samples[2].Time}
+
+	for _, sample := range append(samples, other) {
+		if err := s.Append(sample); err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	got, err := s.List("http://elasticsearch.example.com", samples[1].Time)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(got) != 2 || !got[0].Time.Equal(samples[1].Time) || got[1].StoreBytes != 120*gib {
+		t.Errorf("samples do not match. got: %+v", got)
+	}
+}
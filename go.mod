@@ -1,11 +1,13 @@
 module github.com/dtan4/esnctl
 
+go 1.25.0
+
 require (
 	github.com/aws/aws-sdk-go v1.7.9
 	github.com/fortytw2/leaktest v1.3.0 // indirect
 	github.com/go-ini/ini v0.0.0-20161120031036-2ba15ac2dc9c // indirect
 	github.com/golang/mock v0.0.0-20160127222235-bd3c8e81be01
-	github.com/google/go-cmp v0.2.0 // indirect
+	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/jmespath/go-jmespath v0.0.0-20160803190731-bd40a432e4c7 // indirect
 	github.com/mailru/easyjson v0.0.0-20190312143242-1de009706dbe // indirect
@@ -13,13 +15,19 @@ require (
 	github.com/pkg/errors v0.8.0
 	github.com/smartystreets/goconvey v0.0.0-20190306220146-200a235640ff // indirect
 	github.com/spf13/cobra v0.0.0-20161222151250-de09d9ce07d0
-	github.com/spf13/pflag v0.0.0-20160915153101-c7e63cf4530b // indirect
+	github.com/spf13/pflag v0.0.0-20160915153101-c7e63cf4530b
 	github.com/stretchr/testify v1.3.0 // indirect
-	golang.org/x/net v0.0.0-20160715184138-e90d6d0afc4c // indirect
-	golang.org/x/sync v0.0.0-20190227155943-e225da77a7e6 // indirect
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sync v0.20.0
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
+	google.golang.org/grpc v1.82.1
+	google.golang.org/protobuf v1.36.11
 	gopkg.in/h2non/gock.v1 v1.0.14
 	gopkg.in/olivere/elastic.v2 v2.0.58
 	gopkg.in/olivere/elastic.v3 v3.0.68
 	gopkg.in/olivere/elastic.v5 v5.0.34
 	gopkg.in/olivere/elastic.v6 v6.2.16
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,231 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+const (
+	fileName = "operations.jsonl"
+
+	// runningDir stores operations which have started but not finished yet, one file per operation
+	runningDir = "running"
+)
+
+// runningRecord represents operation recorded as running, with the process running it
+type runningRecord struct {
+	*operation.Operation
+
+	PID  int    `json:"pid,omitempty"`
+	Host string `json:"host,omitempty"`
+}
+
+// Store represents append-only operation history stored in local file
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the default history directory, ~/.esnctl/history
+func DefaultDir() string {
+	return filepath.Join(config.Dir(), "history")
+}
+
+// New creates new Store object
+func New(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Begin records the given operation as running until it is appended to history
+// Operations left running, e.g. by crashed process, are returned by Unfinished
+func (s *Store) Begin(op *operation.Operation) error {
+	if err := os.MkdirAll(filepath.Join(s.dir, runningDir), 0700); err != nil {
+		return errors.Wrap(err, "failed to create history directory")
+	}
+
+	host, _ := os.Hostname()
+
+	body, err := json.Marshal(&runningRecord{
+		Operation: op,
+		PID:       os.Getpid(),
+		Host:      host,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize operation")
+	}
+
+	if err := ioutil.WriteFile(s.runningPath(op.ID), body, 0600); err != nil {
+		return errors.Wrap(err, "failed to record running operation")
+	}
+
+	return nil
+}
+
+// Unfinished returns operations which have begun but not been appended to history
+func (s *Store) Unfinished() ([]*operation.Operation, error) {
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, runningDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*operation.Operation{}, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to list running operations")
+	}
+
+	ops := []*operation.Operation{}
+
+	for _, f := range files {
+		record, err := s.readRunning(filepath.Join(s.dir, runningDir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		ops = append(ops, record.Operation)
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].StartedAt.Before(ops[j].StartedAt)
+	})
+
+	return ops, nil
+}
+
+// Alive returns whether the process which began the given operation is still running on this host
+// Operation recorded by the calling process itself is not regarded as alive, since its PID may be reused after restart, e.g. as PID 1 in container
+// Operation recorded on other host or by older esnctl without PID cannot be checked and is not regarded as alive either
+func (s *Store) Alive(op *operation.Operation) (bool, error) {
+	record, err := s.readRunning(s.runningPath(op.ID))
+	if err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	host, _ := os.Hostname()
+
+	if record.PID == 0 || record.PID == os.Getpid() || record.Host != host {
+		return false, nil
+	}
+
+	return processExists(record.PID), nil
+}
+
+// Append appends the given operation to history
+// The operation is no longer regarded as running
+func (s *Store) Append(op *operation.Operation) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create history directory")
+	}
+
+	line, err := json.Marshal(op)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize operation")
+	}
+
+	f, err := os.OpenFile(s.path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open history file")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write history")
+	}
+
+	if err := os.Remove(s.runningPath(op.ID)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to clear running operation")
+	}
+
+	return nil
+}
+
+// Get returns the latest operation with the given ID
+// Resumed operation is appended with the same ID as the prior attempt
+func (s *Store) Get(id string) (*operation.Operation, error) {
+	ops, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(ops) - 1; i >= 0; i-- {
+		if ops[i].ID == id {
+			return ops[i], nil
+		}
+	}
+
+	return nil, errors.Errorf("operation %q not found", id)
+}
+
+// List returns all operations in history, oldest first
+func (s *Store) List() ([]*operation.Operation, error) {
+	f, err := os.Open(s.path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*operation.Operation{}, nil
+		}
+
+		return []*operation.Operation{}, errors.Wrap(err, "failed to open history file")
+	}
+	defer f.Close()
+
+	ops := []*operation.Operation{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var op operation.Operation
+
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			return []*operation.Operation{}, errors.Wrap(err, "history file is broken")
+		}
+
+		ops = append(ops, &op)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return []*operation.Operation{}, errors.Wrap(err, "failed to read history file")
+	}
+
+	return ops, nil
+}
+
+func (s *Store) path() string {
+	return filepath.Join(s.dir, fileName)
+}
+
+func (s *Store) readRunning(path string) (*runningRecord, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read running operation")
+	}
+
+	record := &runningRecord{Operation: &operation.Operation{}}
+
+	if err := json.Unmarshal(body, record); err != nil {
+		return nil, errors.Wrapf(err, "running operation %s is broken", filepath.Base(path))
+	}
+
+	return record, nil
+}
+
+func (s *Store) runningPath(id string) string {
+	// Operation ID can be given by user with --operation-id
+	return filepath.Join(s.dir, runningDir, url.PathEscape(id)+".json")
+}
@@ -0,0 +1,221 @@
+package history
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+func TestAppendAndList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-history")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New(filepath.Join(dir, "history"))
+
+	ops, err := store.List()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if len(ops) != 0 {
+		t.Errorf("history should be empty. got: %d operations", len(ops))
+	}
+
+	for _, command := range []string{"add", "remove"} {
+		op := operation.New(command, "http://example.com:9200")
+		op.Finish(nil)
+
+		if err := store.Append(op); err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+	}
+
+	ops, err = store.List()
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if len(ops) != 2 {
+		t.Fatalf("number of operations does not match. expected: 2, got: %d", len(ops))
+	}
+
+	if ops[1].Command != "remove" {
+		t.Errorf("command does not match. expected: %q, got: %q", "remove", ops[1].Command)
+	}
+}
+
+func TestGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-history")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New(dir)
+
+	op := operation.New("remove", "http://example.com:9200")
+	op.Node = "ip-10-0-1-23.ap-northeast-1.compute.internal"
+	op.Finish(nil)
+
+	if err := store.Append(op); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	got, err := store.Get(op.ID)
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got.Node != op.Node {
+		t.Errorf("node does not match. expected: %q, got: %q", op.Node, got.Node)
+	}
+
+	if _, err := store.Get("notfound"); err == nil {
+		t.Errorf("error should be raised")
+	}
+}
+
+func TestGet_resumed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-history")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New(dir)
+
+	op := operation.New("remove", "http://example.com:9200")
+	op.Finish(errors.New("timed out"))
+
+	resumed := operation.New("remove", "http://example.com:9200")
+	resumed.ID = op.ID
+	resumed.Finish(nil)
+
+	for _, o := range []*operation.Operation{op, resumed} {
+		if err := store.Append(o); err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+	}
+
+	got, err := store.Get(op.ID)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got.Result != operation.ResultSucceeded {
+		t.Errorf("the latest attempt should be returned. got result: %q", got.Result)
+	}
+}
+
+func TestUnfinished(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-history")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New(dir)
+
+	finished := operation.New("add", "http://example.com:9200")
+	interrupted := operation.New("remove", "http://example.com:9200")
+	interrupted.Node = "ip-10-0-1-23.ap-northeast-1.compute.internal"
+
+	for _, op := range []*operation.Operation{finished, interrupted} {
+		if err := store.Begin(op); err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	finished.Finish(nil)
+
+	if err := store.Append(finished); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	ops, err := store.Unfinished()
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(ops) != 1 {
+		t.Fatalf("the number of unfinished operations does not match. expected: 1, got: %d", len(ops))
+	}
+
+	if ops[0].ID != interrupted.ID || ops[0].Node != interrupted.Node {
+		t.Errorf("unfinished operation does not match. expected: %s, got: %s", interrupted.ID, ops[0].ID)
+	}
+}
+
+func TestAlive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-history")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New(dir)
+
+	op := operation.New("remove", "http://example.com:9200")
+
+	alive, err := store.Alive(op)
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if alive {
+		t.Errorf("operation without running record should not be alive")
+	}
+
+	if err := store.Begin(op); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	alive, err = store.Alive(op)
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if alive {
+		t.Errorf("operation begun by the calling process should not be alive")
+	}
+
+	host, _ := os.Hostname()
+
+	testcases := []struct {
+		host     string
+		pid      int
+		expected bool
+	}{
+		{host: host, pid: os.Getppid(), expected: true},
+		{host: "other-host", pid: os.Getppid(), expected: false},
+		{host: host, pid: 0, expected: false},
+	}
+
+	for _, tc := range testcases {
+		body, err := json.Marshal(&runningRecord{Operation: op, PID: tc.pid, Host: tc.host})
+		if err != nil {
+			t.Fatalf("failed to serialize operation: %s", err)
+		}
+
+		if err := ioutil.WriteFile(store.runningPath(op.ID), body, 0600); err != nil {
+			t.Fatalf("failed to write running operation: %s", err)
+		}
+
+		alive, err := store.Alive(op)
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+
+		if alive != tc.expected {
+			t.Errorf("liveness of operation begun by PID %d on %s does not match. expected: %t, got: %t", tc.pid, tc.host, tc.expected, alive)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package history
+
+import (
+	"os"
+	"syscall"
+)
+
+// processExists returns whether process with the given PID exists
+// Signal 0 only checks the process, permission error means it exists but is owned by other user
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = p.Signal(syscall.Signal(0))
+
+	return err == nil || os.IsPermission(err)
+}
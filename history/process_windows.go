@@ -0,0 +1,18 @@
+package history
+
+import (
+	"os"
+)
+
+// processExists returns whether process with the given PID exists
+// FindProcess opens the process on Windows, which fails if it does not exist
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	p.Release()
+
+	return true
+}
@@ -0,0 +1,191 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/retry"
+	"github.com/pkg/errors"
+)
+
+// maxIdleConnsPerHost represents the number of idle connections kept for each node
+// Default of net/http (2) causes reconnection when requests are sent concurrently, e.g. with --sniff
+const maxIdleConnsPerHost = 16
+
+// Options represents options of HTTP client used to call Elasticsearch API
+type Options struct {
+	// DialAddr is connected instead of the host in request URL, e.g. local end of tunnel
+	// Host header and TLS server name are kept. Proxy is not used if DialAddr is set
+	DialAddr string
+	// Headers are added to every request, in "Name: value" format
+	Headers []string
+	// MaxRetries represents the maximum number of retries for transient errors
+	MaxRetries int
+	// ProxyURL overrides proxy given by HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	ProxyURL string
+	// Timeout bounds each attempt. 0 means no timeout
+	Timeout time.Duration
+	// TLSConfig is used for HTTPS connections if not nil, e.g. for client certificate
+	TLSConfig *tls.Config
+	// Trace logs every attempt of request if not nil
+	Trace *log.Logger
+}
+
+// headerTransport adds fixed headers to every request
+type headerTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+// traceTransport logs method, URL without credentials, status and duration of every request
+type traceTransport struct {
+	base   http.RoundTripper
+	logger *log.Logger
+}
+
+// New creates http.Client with the given options
+// The returned client keeps connections alive, so reuse it among requests to the same cluster
+func New(opts Options) (*http.Client, error) {
+	header, err := ParseHeaders(opts.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+
+	switch {
+	case opts.DialAddr != "":
+		proxy = nil
+	case opts.ProxyURL != "":
+		u, err := url.Parse(opts.ProxyURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.Errorf("proxy URL %q is invalid", opts.ProxyURL)
+		}
+
+		proxy = http.ProxyURL(u)
+	}
+
+	t := newTransport(proxy, opts.DialAddr)
+	t.TLSClientConfig = opts.TLSConfig
+
+	var base http.RoundTripper = t
+
+	if len(header) > 0 {
+		base = &headerTransport{
+			base:   base,
+			header: header,
+		}
+	}
+
+	if opts.Trace != nil {
+		base = NewTraceTransport(base, opts.Trace)
+	}
+
+	return &http.Client{
+		Transport: &retry.Transport{
+			Base:       base,
+			MaxRetries: opts.MaxRetries,
+			Timeout:    opts.Timeout,
+		},
+	}, nil
+}
+
+// NewTraceTransport returns http.RoundTripper which logs every request sent through base to logger
+// Credentials in URL are not logged
+func NewTraceTransport(base http.RoundTripper, logger *log.Logger) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &traceTransport{
+		base:   base,
+		logger: logger,
+	}
+}
+
+// ParseHeaders parses headers in "Name: value" format
+func ParseHeaders(headers []string) (http.Header, error) {
+	header := http.Header{}
+
+	for _, h := range headers {
+		kv := strings.SplitN(h, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, errors.Errorf("header %q is invalid, must be in \"Name: value\" format", h)
+		}
+
+		header.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+
+	return header, nil
+}
+
+// newTransport creates http.Transport tuned for polling the same cluster repeatedly
+// Unlike http.DefaultTransport, more idle connections are kept per host and HTTP/2 is attempted even with custom TLS config
+func newTransport(proxy func(*http.Request) (*url.URL, error), dialAddr string) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	dialContext := dialer.DialContext
+
+	if dialAddr != "" {
+		dialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, dialAddr)
+		}
+	}
+
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := *req
+	r.Header = http.Header{}
+
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+
+	for k, v := range t.header {
+		r.Header[k] = v
+	}
+
+	return t.base.RoundTrip(&r)
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := *req.URL
+	u.User = nil
+
+	start := time.Now()
+
+	resp, err := t.base.RoundTrip(req)
+
+	elapsed := time.Since(start) / time.Millisecond * time.Millisecond
+
+	if err != nil {
+		t.logger.Printf("DEBUG %s %s error=%q duration=%s\n", req.Method, u.String(), err, elapsed)
+		return nil, err
+	}
+
+	t.logger.Printf("DEBUG %s %s status=%d duration=%s\n", req.Method, u.String(), resp.StatusCode, elapsed)
+
+	return resp, nil
+}
@@ -0,0 +1,213 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew_headers(t *testing.T) {
+	var got http.Header
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer ts.Close()
+
+	client, err := New(Options{
+		Headers: []string{"X-Auth-Token: secret", "X-Team:search"},
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if got.Get("X-Auth-Token") != "secret" {
+		t.Errorf("X-Auth-Token does not match. expected: %q, got: %q", "secret", got.Get("X-Auth-Token"))
+	}
+
+	if got.Get("X-Team") != "search" {
+		t.Errorf("X-Team does not match. expected: %q, got: %q", "search", got.Get("X-Team"))
+	}
+}
+
+func TestNew_proxy(t *testing.T) {
+	var got string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := New(Options{
+		ProxyURL: proxy.URL,
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	resp, err := client.Get("http://elasticsearch.example.com:9200/_cat/nodes")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if got != "http://elasticsearch.example.com:9200/_cat/nodes" {
+		t.Errorf("request should be sent via proxy. got: %q", got)
+	}
+}
+
+func TestNew_http2(t *testing.T) {
+	var got int
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.ProtoMajor
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	client, err := New(Options{
+		TLSConfig: ts.Client().Transport.(*http.Transport).TLSClientConfig,
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if got != 2 {
+		t.Errorf("request should be sent over HTTP/2. got: HTTP/%d", got)
+	}
+}
+
+func TestNew_invalid(t *testing.T) {
+	if _, err := New(Options{ProxyURL: "proxy.example.com"}); err == nil {
+		t.Errorf("error should be raised for proxy URL without scheme")
+	}
+
+	if _, err := New(Options{Headers: []string{"X-Auth-Token"}}); err == nil {
+		t.Errorf("error should be raised for header without value")
+	}
+}
+
+func TestNew_keepAlive(t *testing.T) {
+	conns := 0
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"status":"green"}`)
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns++
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	client, err := New(Options{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(ts.URL + "/_cluster/health")
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if conns != 1 {
+		t.Errorf("connection should be reused. got: %d connections", conns)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	header, err := ParseHeaders([]string{"X-Foo: bar", "X-Foo: baz", "Authorization: Basic Zm9vOmJhcg=="})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(header["X-Foo"]) != 2 {
+		t.Errorf("repeated header should be kept. got: %v", header["X-Foo"])
+	}
+
+	if header.Get("Authorization") != "Basic Zm9vOmJhcg==" {
+		t.Errorf("Authorization does not match. got: %q", header.Get("Authorization"))
+	}
+}
+
+func TestNew_dialAddr(t *testing.T) {
+	var got string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Host
+	}))
+	defer ts.Close()
+
+	client, err := New(Options{
+		DialAddr: strings.TrimPrefix(ts.URL, "http://"),
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	resp, err := client.Get("http://elasticsearch.example.com:9200/")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if got != "elasticsearch.example.com:9200" {
+		t.Errorf("Host header should be kept. got: %q", got)
+	}
+}
+
+func TestNew_trace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+
+	client, err := New(Options{
+		Trace: log.New(&buf, "", 0),
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	resp, err := client.Get(strings.Replace(ts.URL, "http://", "http://elastic:secret@", 1) + "/_cat/nodes")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	got := buf.String()
+
+	if !strings.HasPrefix(got, "DEBUG GET "+ts.URL+"/_cat/nodes status=404 duration=") {
+		t.Errorf("request should be traced. got: %q", got)
+	}
+
+	if strings.Contains(got, "secret") {
+		t.Errorf("credentials should not be traced. got: %q", got)
+	}
+}
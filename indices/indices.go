@@ -0,0 +1,167 @@
+package indices
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+)
+
+// Impacts of removing node on index
+const (
+	// ImpactNone represents that index has no shard on the node
+	ImpactNone = ""
+
+	// ImpactReplica represents that only replicas are lost and recreated from other copies
+	ImpactReplica = "yellow"
+
+	// ImpactUnavailable represents that some shard has no other started copy and becomes unavailable
+	ImpactUnavailable = "red"
+)
+
+// Row represents index with placement of its shards on the given node
+type Row struct {
+	*es.Index
+
+	// OnNode represents shards on the node, e.g. "0p" for primary of shard 0 and "1r" for replica of shard 1
+	OnNode []string `json:"on_node,omitempty"`
+	Impact string   `json:"impact,omitempty"`
+}
+
+// New joins indices and shards
+// If nodeName is given, only indices having shards on the node are returned with impact of removing the node
+func New(indices []*es.Index, shards []*stats.Shard, nodeName string) []*Row {
+	rows := []*Row{}
+
+	if nodeName == "" {
+		for _, i := range indices {
+			rows = append(rows, &Row{Index: i})
+		}
+
+		return rows
+	}
+
+	onNode := map[string][]*stats.Shard{}
+
+	// Started copies of each shard outside of the node
+	elsewhere := map[string]int{}
+
+	for _, s := range shards {
+		if s.Node == nodeName {
+			onNode[s.Index] = append(onNode[s.Index], s)
+			continue
+		}
+
+		if s.Started() {
+			elsewhere[shardKey(s)]++
+		}
+	}
+
+	for _, i := range indices {
+		ss, ok := onNode[i.Name]
+		if !ok {
+			continue
+		}
+
+		sort.Slice(ss, func(a, b int) bool {
+			if ss[a].Shard != ss[b].Shard {
+				return ss[a].Shard < ss[b].Shard
+			}
+
+			return ss[a].Primary
+		})
+
+		row := &Row{Index: i, Impact: ImpactReplica}
+
+		for _, s := range ss {
+			kind := "r"
+			if s.Primary {
+				kind = "p"
+			}
+
+			row.OnNode = append(row.OnNode, fmt.Sprintf("%d%s", s.Shard, kind))
+
+			if elsewhere[shardKey(s)] == 0 {
+				row.Impact = ImpactUnavailable
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Render prints table of indices
+// ON NODE and IMPACT columns are added if the rows are filtered by node
+func Render(out io.Writer, rows []*Row, nodeName string) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	header := "INDEX\tHEALTH\tSTATUS\tPRI\tREP\tDOCS\tSIZE"
+	if nodeName != "" {
+		header += "\tON NODE\tIMPACT"
+	}
+
+	fmt.Fprintln(w, header)
+
+	unavailable := 0
+
+	for _, r := range rows {
+		line := fmt.Sprintf("%s\t%s\t%s\t%d\t%d\t%d\t%s", r.Name, orDash(r.Health), r.Status, r.Primaries, r.Replicas, r.Docs, formatBytes(r.StoreBytes))
+
+		if nodeName != "" {
+			line += fmt.Sprintf("\t%s\t%s", strings.Join(r.OnNode, ","), r.Impact)
+		}
+
+		fmt.Fprintln(w, line)
+
+		if r.Impact == ImpactUnavailable {
+			unavailable++
+		}
+	}
+
+	w.Flush()
+
+	if nodeName != "" {
+		fmt.Fprintf(out, "\n%d indices have shards on %s", len(rows), nodeName)
+
+		if unavailable > 0 {
+			fmt.Fprintf(out, ", %d of them have shards without other started copy", unavailable)
+		}
+
+		fmt.Fprintln(out)
+	}
+}
+
+func shardKey(s *stats.Shard) string {
+	return fmt.Sprintf("%s/%d", s.Index, s.Shard)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
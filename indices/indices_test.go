@@ -0,0 +1,83 @@
+package indices
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+)
+
+func testIndices() []*es.Index {
+	return []*es.Index{
+		{Name: "logs-1", Health: "green", Status: "open", Primaries: 2, Replicas: 1, Docs: 100, StoreBytes: 2048},
+		{Name: "logs-2", Health: "green", Status: "open", Primaries: 1, Replicas: 0, Docs: 10, StoreBytes: 512},
+		{Name: "logs-3", Health: "green", Status: "open", Primaries: 1, Replicas: 1, Docs: 1, StoreBytes: 100},
+	}
+}
+
+func testShards() []*stats.Shard {
+	return []*stats.Shard{
+		{Index: "logs-1", Shard: 0, Primary: false, State: "STARTED", Node: "node-1"},
+		{Index: "logs-1", Shard: 0, Primary: true, State: "STARTED", Node: "node-2"},
+		{Index: "logs-1", Shard: 1, Primary: true, State: "STARTED", Node: "node-1"},
+		{Index: "logs-1", Shard: 1, Primary: false, State: "STARTED", Node: "node-2"},
+		{Index: "logs-2", Shard: 0, Primary: true, State: "STARTED", Node: "node-1"},
+		{Index: "logs-3", Shard: 0, Primary: true, State: "STARTED", Node: "node-2"},
+		{Index: "logs-3", Shard: 0, Primary: false, State: "UNASSIGNED", Node: ""},
+	}
+}
+
+func TestNew(t *testing.T) {
+	rows := New(testIndices(), testShards(), "")
+
+	if len(rows) != 3 {
+		t.Fatalf("all indices should be returned without node. got: %d", len(rows))
+	}
+
+	rows = New(testIndices(), testShards(), "node-1")
+
+	expected := []struct {
+		name   string
+		onNode []string
+		impact string
+	}{
+		{name: "logs-1", onNode: []string{"0r", "1p"}, impact: ImpactReplica},
+		{name: "logs-2", onNode: []string{"0p"}, impact: ImpactUnavailable},
+	}
+
+	if len(rows) != len(expected) {
+		t.Fatalf("rows do not match. expected: %d rows, got: %d rows", len(expected), len(rows))
+	}
+
+	for i, e := range expected {
+		r := rows[i]
+
+		if r.Name != e.name || !reflect.DeepEqual(r.OnNode, e.onNode) || r.Impact != e.impact {
+			t.Errorf("row %d does not match. expected: %+v, got: %s %v %s", i, e, r.Name, r.OnNode, r.Impact)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+
+	Render(&buf, New(testIndices(), testShards(), "node-1"), "node-1")
+	got := buf.String()
+
+	for _, s := range []string{"ON NODE", "0r,1p", "2.0 KiB", "2 indices have shards on node-1, 1 of them have shards without other started copy"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("output should contain %q. got:\n%s", s, got)
+		}
+	}
+
+	buf.Reset()
+
+	Render(&buf, New(testIndices(), testShards(), ""), "")
+
+	if strings.Contains(buf.String(), "ON NODE") {
+		t.Errorf("output should not contain ON NODE without node. got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,202 @@
+package kube
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Group represents API group of esnctl custom resources
+	Group = "esnctl.dtan4.net"
+	// Version represents API version of esnctl custom resources
+	Version = "v1alpha1"
+	// NodeRemovalKind represents kind of node removal resource
+	NodeRemovalKind = "ESNodeRemoval"
+
+	// PhaseRunning represents that the removal is being executed
+	PhaseRunning = "Running"
+	// PhaseSucceeded represents that the removal finished successfully
+	PhaseSucceeded = "Succeeded"
+	// PhaseFailed represents that the removal finished with error
+	PhaseFailed = "Failed"
+
+	nodeRemovalPlural = "esnoderemovals"
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// NodeRemoval represents ESNodeRemoval resource
+type NodeRemoval struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   ObjectMeta        `json:"metadata"`
+	Spec       NodeRemovalSpec   `json:"spec"`
+	Status     NodeRemovalStatus `json:"status"`
+}
+
+// ObjectMeta represents metadata of resource
+type ObjectMeta struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// NodeRemovalSpec represents desired node removal
+type NodeRemovalSpec struct {
+	ClusterURL string `json:"clusterURL"`
+	Group      string `json:"group"`
+	Region     string `json:"region,omitempty"`
+	NodeName   string `json:"nodeName"`
+}
+
+// NodeRemovalStatus represents progress of node removal
+type NodeRemovalStatus struct {
+	Phase       string     `json:"phase,omitempty"`
+	Step        string     `json:"step,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	OperationID string     `json:"operationID,omitempty"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+type nodeRemovalList struct {
+	Items []*NodeRemoval `json:"items"`
+}
+
+// Client represents a minimal Kubernetes API client for esnctl custom resources
+type Client struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+// New creates new Client object
+func New(endpoint, token string, httpClient *http.Client) *Client {
+	return &Client{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// NewInCluster creates new Client object from service account mounted in Pod
+func NewInCluster() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	}
+
+	token, err := ioutil.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read service account token")
+	}
+
+	ca, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read service account CA certificate")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("failed to parse service account CA certificate")
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs: pool,
+			},
+		},
+	}
+
+	return New("https://"+net.JoinHostPort(host, port), strings.TrimSpace(string(token)), httpClient), nil
+}
+
+// ListNodeRemovals returns ESNodeRemoval resources in the given namespace
+// Resources in all namespaces are returned if namespace is empty
+func (c *Client) ListNodeRemovals(namespace string) ([]*NodeRemoval, error) {
+	endpoint := c.endpoint + "/apis/" + Group + "/" + Version
+
+	if namespace != "" {
+		endpoint += "/namespaces/" + namespace
+	}
+
+	endpoint += "/" + nodeRemovalPlural
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make list request")
+	}
+
+	body, err := c.do(req, "list")
+	if err != nil {
+		return nil, err
+	}
+
+	var list nodeRemovalList
+
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, errors.Wrap(err, "failed to parse list response")
+	}
+
+	return list.Items, nil
+}
+
+// UpdateNodeRemovalStatus replaces status of the given resource
+func (c *Client) UpdateNodeRemovalStatus(r *NodeRemoval) error {
+	endpoint := c.endpoint + "/apis/" + Group + "/" + Version + "/namespaces/" + r.Metadata.Namespace + "/" + nodeRemovalPlural + "/" + r.Metadata.Name + "/status"
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": r.Status,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode status")
+	}
+
+	req, err := http.NewRequest("PATCH", endpoint, bytes.NewReader(patch))
+	if err != nil {
+		return errors.Wrap(err, "failed to make status update request")
+	}
+
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	if _, err := c.do(req, "status update"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) do(req *http.Request, name string) ([]byte, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to execute %s request", name)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to execute %s request. code: %d, body: %s", name, resp.StatusCode, body)
+	}
+
+	return body, nil
+}
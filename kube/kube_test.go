@@ -0,0 +1,99 @@
+package kube
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListNodeRemovals(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/esnctl.dtan4.net/v1alpha1/namespaces/logging/esnoderemovals" {
+			t.Errorf("path does not match. got: %q", r.URL.Path)
+		}
+
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("authorization header does not match. expected: %q, got: %q", "Bearer token", got)
+		}
+
+		w.Write([]byte(`{
+  "items": [
+    {
+      "apiVersion": "esnctl.dtan4.net/v1alpha1",
+      "kind": "ESNodeRemoval",
+      "metadata": {"name": "remove-node-21", "namespace": "logging"},
+      "spec": {
+        "clusterURL": "http://elasticsearch.example.com",
+        "group": "elasticsearch",
+        "nodeName": "ip-10-0-1-21.ap-northeast-1.compute.internal"
+      }
+    }
+  ]
+}`))
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "token", &http.Client{})
+
+	removals, err := client.ListNodeRemovals("logging")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(removals) != 1 {
+		t.Fatalf("number of resources does not match. expected: 1, got: %d", len(removals))
+	}
+
+	if removals[0].Spec.NodeName != "ip-10-0-1-21.ap-northeast-1.compute.internal" {
+		t.Errorf("node name does not match. got: %q", removals[0].Spec.NodeName)
+	}
+
+	if removals[0].Status.Phase != "" {
+		t.Errorf("phase should be empty. got: %q", removals[0].Status.Phase)
+	}
+}
+
+func TestUpdateNodeRemovalStatus(t *testing.T) {
+	var got map[string]NodeRemovalStatus
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" {
+			t.Errorf("method does not match. expected: PATCH, got: %s", r.Method)
+		}
+
+		if r.URL.Path != "/apis/esnctl.dtan4.net/v1alpha1/namespaces/logging/esnoderemovals/remove-node-21/status" {
+			t.Errorf("path does not match. got: %q", r.URL.Path)
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("request body is invalid: %s", err)
+		}
+
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "", &http.Client{})
+
+	r := &NodeRemoval{
+		Metadata: ObjectMeta{
+			Name:      "remove-node-21",
+			Namespace: "logging",
+		},
+		Status: NodeRemovalStatus{
+			Phase: PhaseRunning,
+			Step:  "Shutting down target node",
+		},
+	}
+
+	if err := client.UpdateNodeRemovalStatus(r); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if got["status"].Step != "Shutting down target node" {
+		t.Errorf("step does not match. got: %q", got["status"].Step)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/httpclient"
+	"github.com/dtan4/esnctl/lambda"
+	"github.com/dtan4/esnctl/workflow"
+)
+
+const (
+	// defaultMaxAPIRetries is used if ESNCTL_MAX_API_RETRIES is not set
+	defaultMaxAPIRetries = 5
+	// defaultRequestTimeout is used if ESNCTL_REQUEST_TIMEOUT is not set
+	defaultRequestTimeout = 30 * time.Second
+)
+
+func main() {
+	maxAPIRetries := defaultMaxAPIRetries
+
+	if v := os.Getenv("ESNCTL_MAX_API_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("ESNCTL_MAX_API_RETRIES is invalid: %s\n", err)
+		}
+
+		maxAPIRetries = n
+	}
+
+	requestTimeout := defaultRequestTimeout
+
+	if v := os.Getenv("ESNCTL_REQUEST_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("ESNCTL_REQUEST_TIMEOUT is invalid: %s\n", err)
+		}
+
+		requestTimeout = d
+	}
+
+	var awsMaxRPS float64
+
+	if v := os.Getenv("ESNCTL_AWS_MAX_RPS"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Fatalf("ESNCTL_AWS_MAX_RPS is invalid: %s\n", err)
+		}
+
+		awsMaxRPS = f
+	}
+
+	limiter := aws.NewRateLimiter(awsMaxRPS)
+
+	// Warm invocations reuse connections to Elasticsearch
+	httpClient, err := httpclient.New(httpclient.Options{
+		MaxRetries: maxAPIRetries,
+		Timeout:    requestTimeout,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	h := lambda.NewHandler(func(clusterURL, region string) (*workflow.Workflow, error) {
+		return workflow.New(clusterURL, region, workflow.ClientOptions{
+			AWSRateLimiter: limiter,
+			HTTPClient:     httpClient,
+			MaxAPIRetries:  maxAPIRetries,
+			RequestTimeout: requestTimeout,
+		})
+	})
+
+	if err := lambda.Start(h.Handle); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,205 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+)
+
+const (
+	runtimeAPIVersion = "2018-06-01"
+
+	// deadlineMargin represents time left for returning state before Lambda times out
+	deadlineMargin = 30 * time.Second
+)
+
+// waitInterval represents how long to wait before checking waiting step again
+var waitInterval = 5 * time.Second
+
+// Event represents input and output of the function
+// Output can be passed to the next invocation as is until step becomes "done"
+type Event struct {
+	ClusterURL string `json:"cluster_url"`
+	Region     string `json:"region,omitempty"`
+
+	workflow.RemoveState
+}
+
+// WorkflowFunc creates Workflow object for the given cluster and AWS region
+type WorkflowFunc func(clusterURL, region string) (*workflow.Workflow, error)
+
+// HandlerFunc handles one invocation
+type HandlerFunc func(ctx context.Context, payload []byte) ([]byte, error)
+
+// Handler executes node removal step by step within the time limit of one invocation
+type Handler struct {
+	newWorkflow WorkflowFunc
+}
+
+// NewHandler creates new Handler object
+func NewHandler(newWorkflow WorkflowFunc) *Handler {
+	return &Handler{
+		newWorkflow: newWorkflow,
+	}
+}
+
+// Handle executes steps until all steps finish or the invocation deadline approaches
+// If a waiting step is not satisfied before the deadline, the state is returned with waiting: true
+func (h *Handler) Handle(ctx context.Context, payload []byte) ([]byte, error) {
+	var event Event
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, errors.Wrap(err, "failed to parse event")
+	}
+
+	if event.ClusterURL == "" || event.Group == "" || event.NodeName == "" {
+		return nil, errors.New("cluster_url, group and node_name must be specified")
+	}
+
+	w, err := h.newWorkflow(event.ClusterURL, event.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &event.RemoveState
+
+	for s.Step != workflow.StepDone {
+		if !hasTime(ctx, 0) {
+			break
+		}
+
+		log.Printf("===> %s...\n", workflow.RemoveStepDescription(s.Step))
+
+		next, err := w.RemoveStep(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+
+		if next.Skipped {
+			log.Printf("===> %s\n", workflow.SkippedMessage)
+		}
+
+		s = next
+
+		if s.Waiting {
+			if !hasTime(ctx, waitInterval) {
+				break
+			}
+
+			time.Sleep(waitInterval)
+		}
+	}
+
+	event.RemoveState = *s
+
+	return json.Marshal(&event)
+}
+
+// hasTime returns whether d can be spent before deadline, leaving deadlineMargin
+func hasTime(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return true
+	}
+
+	return time.Until(deadline) > d+deadlineMargin
+}
+
+// Runtime represents client of Lambda Runtime API
+type Runtime struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewRuntime creates new Runtime object
+// api is the value of AWS_LAMBDA_RUNTIME_API
+func NewRuntime(api string, httpClient *http.Client) *Runtime {
+	return &Runtime{
+		endpoint:   "http://" + api + "/" + runtimeAPIVersion + "/runtime/invocation/",
+		httpClient: httpClient,
+	}
+}
+
+// Start serves invocations with the given handler using AWS_LAMBDA_RUNTIME_API
+func Start(handler HandlerFunc) error {
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		return errors.New("AWS_LAMBDA_RUNTIME_API is not set; this binary must run on AWS Lambda")
+	}
+
+	return NewRuntime(api, &http.Client{}).Serve(handler)
+}
+
+// Serve serves invocations forever
+func (r *Runtime) Serve(handler HandlerFunc) error {
+	for {
+		if err := r.serveOne(handler); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Runtime) serveOne(handler HandlerFunc) error {
+	resp, err := r.httpClient.Get(r.endpoint + "next")
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve next invocation")
+	}
+	defer resp.Body.Close()
+
+	payload, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read invocation payload")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to retrieve next invocation. code: %d, body: %s", resp.StatusCode, payload)
+	}
+
+	requestID := resp.Header.Get("Lambda-Runtime-Aws-Request-Id")
+	ctx := context.Background()
+
+	if ms, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithDeadline(ctx, time.Unix(0, ms*int64(time.Millisecond)))
+		defer cancel()
+	}
+
+	out, err := handler(ctx, payload)
+	if err != nil {
+		log.Println(err)
+
+		body, _ := json.Marshal(map[string]string{
+			"errorMessage": err.Error(),
+			"errorType":    "EsnctlError",
+		})
+
+		return r.post(requestID+"/error", body)
+	}
+
+	return r.post(requestID+"/response", out)
+}
+
+func (r *Runtime) post(path string, body []byte) error {
+	resp, err := r.httpClient.Post(r.endpoint+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to post invocation result")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("failed to post invocation result. code: %d, body: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
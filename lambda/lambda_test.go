@@ -0,0 +1,120 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/fake"
+	"github.com/dtan4/esnctl/workflow"
+)
+
+func newFakeWorkflow(clusterURL, region string) (*workflow.Workflow, error) {
+	c := fake.NewCluster(3)
+
+	return &workflow.Workflow{
+		ClusterURL:  clusterURL,
+		Region:      region,
+		AutoScaling: c.AutoScaling(),
+		EC2:         c.EC2(),
+		ELBv2:       c.ELBv2(),
+		ES:          c,
+	}, nil
+}
+
+func TestHandle(t *testing.T) {
+	h := NewHandler(newFakeWorkflow)
+
+	payload := []byte(`{"cluster_url":"http://elasticsearch.example.com","group":"esnctl-fake","node_name":"ip-10-0-1-1.ec2.internal"}`)
+
+	out, err := h.Handle(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	var event Event
+
+	if err := json.Unmarshal(out, &event); err != nil {
+		t.Fatalf("output is invalid: %s", err)
+	}
+
+	if event.Step != workflow.StepDone {
+		t.Errorf("step does not match. expected: %q, got: %q", workflow.StepDone, event.Step)
+	}
+
+	if event.InstanceID != "i-00000001" {
+		t.Errorf("instance ID does not match. expected: %q, got: %q", "i-00000001", event.InstanceID)
+	}
+
+	if event.ClusterURL != "http://elasticsearch.example.com" {
+		t.Errorf("cluster URL should be kept. got: %q", event.ClusterURL)
+	}
+}
+
+func TestHandle_deadline(t *testing.T) {
+	h := NewHandler(newFakeWorkflow)
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadlineMargin-time.Second)
+	defer cancel()
+
+	payload := []byte(`{"cluster_url":"http://elasticsearch.example.com","group":"esnctl-fake","node_name":"ip-10-0-1-1.ec2.internal"}`)
+
+	out, err := h.Handle(ctx, payload)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	var event Event
+
+	if err := json.Unmarshal(out, &event); err != nil {
+		t.Fatalf("output is invalid: %s", err)
+	}
+
+	if event.Step != "" {
+		t.Errorf("no step should be executed near deadline. got: %q", event.Step)
+	}
+}
+
+func TestServeOne(t *testing.T) {
+	var got string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2018-06-01/runtime/invocation/next":
+			w.Header().Set("Lambda-Runtime-Aws-Request-Id", "8476a536-e9f4-11e8-9739-2dfe598c3fcd")
+			w.Header().Set("Lambda-Runtime-Deadline-Ms", "4102444800000")
+			w.Write([]byte(`{"name":"esnctl"}`))
+		case "/2018-06-01/runtime/invocation/8476a536-e9f4-11e8-9739-2dfe598c3fcd/error":
+			body, _ := ioutil.ReadAll(r.Body)
+			got = string(body)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	runtime := NewRuntime(strings.TrimPrefix(ts.URL, "http://"), &http.Client{})
+
+	err := runtime.serveOne(func(ctx context.Context, payload []byte) ([]byte, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("deadline should be set")
+		}
+
+		return nil, errors.New("failed to shutdown node")
+	})
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if !strings.Contains(got, "failed to shutdown node") {
+		t.Errorf("error response does not contain error message. got: %s", got)
+	}
+}
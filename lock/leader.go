@@ -0,0 +1,210 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LeaderIndex represents the index name which leader lease documents are stored in
+	LeaderIndex = ".esnctl-leader"
+
+	leaderDocType = "lease"
+)
+
+// Lease represents the holder of leadership in a time slot
+type Lease struct {
+	Holder     string    `json:"holder"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// Elector elects one leader among instances sharing the same election name
+// Time is divided into slots of lease duration, and the instance which creates the lease document of the slot first
+// is the leader during the slot. The leader creates the document of the next slot in advance to keep leadership,
+// so that the other instances take over within two slots after the leader dies.
+// Creating document is atomic in Elasticsearch, so at most one instance holds each slot.
+type Elector struct {
+	client   es.Client
+	name     string
+	identity string
+	duration time.Duration
+
+	// OnChange is called when leadership of this instance changes if not nil
+	OnChange func(leader bool)
+
+	mu     sync.Mutex
+	leader bool
+	until  time.Time
+	now    func() time.Time
+}
+
+// NewElector creates new Elector object
+// identity must be unique among instances, e.g. hostname and process ID
+func NewElector(client es.Client, name, identity string, duration time.Duration) *Elector {
+	return &Elector{
+		client:   client,
+		name:     name,
+		identity: identity,
+		duration: duration,
+		now:      time.Now,
+	}
+}
+
+// Identity returns identity of this instance
+func (e *Elector) Identity() string {
+	return e.identity
+}
+
+// IsLeader returns whether this instance holds the lease of the current slot
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leader && e.now().Before(e.until)
+}
+
+// Run campaigns for leadership every third of lease duration until ctx is done
+func (e *Elector) Run(ctx context.Context) {
+	for {
+		if err := e.Campaign(); err != nil {
+			log.Println(errors.Wrap(err, "failed to campaign for leadership"))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.duration / 3):
+		}
+	}
+}
+
+// Campaign tries to hold the lease of the current slot, and renews the lease of the next slot if this instance is the leader
+func (e *Elector) Campaign() error {
+	slot := e.now().UnixNano() / int64(e.duration)
+
+	holder, err := e.claim(slot)
+	if err != nil {
+		e.setLeader(false, time.Time{})
+		return err
+	}
+
+	if holder != e.identity {
+		e.setLeader(false, time.Time{})
+		return nil
+	}
+
+	until := e.slotEnd(slot)
+
+	next, err := e.claim(slot + 1)
+	if err != nil {
+		log.Println(errors.Wrap(err, "failed to renew leader lease"))
+	} else if next == e.identity {
+		until = e.slotEnd(slot + 1)
+	}
+
+	if err := e.client.DeleteDocument(LeaderIndex, leaderDocType, e.docID(slot-2)); err != nil {
+		log.Println(errors.Wrap(err, "failed to delete expired leader lease"))
+	}
+
+	e.setLeader(true, until)
+
+	return nil
+}
+
+// Holder returns the holder of the current slot, or empty string if no instance holds it
+func (e *Elector) Holder() (string, error) {
+	lease, err := e.get(e.now().UnixNano() / int64(e.duration))
+	if err != nil || lease == nil {
+		return "", err
+	}
+
+	return lease.Holder, nil
+}
+
+// claim creates the lease document of the given slot if it does not exist, and returns its holder
+func (e *Elector) claim(slot int64) (string, error) {
+	doc, err := json.Marshal(&Lease{
+		Holder:     e.identity,
+		AcquiredAt: e.now(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize leader lease")
+	}
+
+	created, err := e.client.CreateDocument(LeaderIndex, leaderDocType, e.docID(slot), doc)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create leader lease document")
+	}
+
+	if created {
+		return e.identity, nil
+	}
+
+	lease, err := e.get(slot)
+	if err != nil {
+		return "", err
+	}
+
+	if lease == nil {
+		// Deleted after creation failed, the slot has already passed
+		return "", nil
+	}
+
+	return lease.Holder, nil
+}
+
+func (e *Elector) get(slot int64) (*Lease, error) {
+	doc, err := e.client.GetDocument(LeaderIndex, leaderDocType, e.docID(slot))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get leader lease document")
+	}
+
+	if doc == nil {
+		return nil, nil
+	}
+
+	var lease Lease
+
+	if err := json.Unmarshal(doc, &lease); err != nil {
+		return nil, errors.Wrap(err, "leader lease document is invalid")
+	}
+
+	return &lease, nil
+}
+
+func (e *Elector) setLeader(leader bool, until time.Time) {
+	e.mu.Lock()
+	changed := e.leader != leader
+	e.leader = leader
+	e.until = until
+	e.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if leader {
+		log.Printf("===> %s became the leader of %s\n", e.identity, e.name)
+	} else {
+		log.Printf("===> %s is no longer the leader of %s\n", e.identity, e.name)
+	}
+
+	if e.OnChange != nil {
+		e.OnChange(leader)
+	}
+}
+
+func (e *Elector) docID(slot int64) string {
+	return fmt.Sprintf("%s-%d", e.name, slot)
+}
+
+func (e *Elector) slotEnd(slot int64) time.Time {
+	return time.Unix(0, (slot+1)*int64(e.duration))
+}
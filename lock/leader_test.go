@@ -0,0 +1,83 @@
+package lock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestElector(t *testing.T) {
+	client := newFakeClient()
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	e1 := NewElector(client, "server", "host-1", 15*time.Second)
+	e1.now = clock
+	e2 := NewElector(client, "server", "host-2", 15*time.Second)
+	e2.now = clock
+
+	changes := []bool{}
+	e1.OnChange = func(leader bool) {
+		changes = append(changes, leader)
+	}
+
+	for _, e := range []*Elector{e1, e2} {
+		if err := e.Campaign(); err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	if !e1.IsLeader() {
+		t.Errorf("first instance should be the leader")
+	}
+
+	if e2.IsLeader() {
+		t.Errorf("second instance should not be the leader")
+	}
+
+	// Leader keeps leadership over the next slot because it holds the lease in advance
+	now = now.Add(20 * time.Second)
+
+	if err := e2.Campaign(); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if e2.IsLeader() {
+		t.Errorf("second instance should not take over while the leader renews lease")
+	}
+
+	holder, err := e2.Holder()
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if holder != "host-1" {
+		t.Errorf("holder does not match. expected: %q, got: %q", "host-1", holder)
+	}
+
+	// Leader dies and its lease expires
+	now = now.Add(30 * time.Second)
+
+	if e1.IsLeader() {
+		t.Errorf("first instance should not be the leader after its lease expired")
+	}
+
+	if err := e2.Campaign(); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !e2.IsLeader() {
+		t.Errorf("second instance should take over after the lease expired")
+	}
+
+	if err := e1.Campaign(); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if e1.IsLeader() {
+		t.Errorf("first instance should not regain leadership")
+	}
+
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("leadership changes do not match. expected: [true false], got: %v", changes)
+	}
+}
@@ -0,0 +1,258 @@
+package lock
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Index represents the index name which lock document is stored in
+	Index = ".esnctl-lock"
+
+	docType = "lock"
+	docID   = "cluster"
+)
+
+var (
+	retryInterval = 5 * time.Second
+
+	// ttl represents how long lock stays valid without renewal
+	// Holder renews the lock every third of ttl, so that lock of crashed process expires soon
+	ttl = 2 * time.Minute
+
+	// ErrTakenOver is returned when the lock document is no longer held by this lock, e.g. taken over by another operation after expiry
+	ErrTakenOver = errors.New("lock has been taken over by another operation")
+)
+
+// Info represents the holder of cluster lock
+type Info struct {
+	OperationID string    `json:"operation_id"`
+	Command     string    `json:"command"`
+	Owner       string    `json:"owner"`
+	Host        string    `json:"host"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+
+	// ExpiresAt represents when the lock is regarded as free unless renewed by holder
+	// Lock acquired by older esnctl without expiry never expires
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired returns whether the lock is regarded as free at the given time
+func (i *Info) Expired(now time.Time) bool {
+	return !i.ExpiresAt.IsZero() && now.After(i.ExpiresAt)
+}
+
+// Lock represents cluster-wide lock held by esnctl operation
+type Lock struct {
+	client es.Client
+	info   *Info
+	stop   chan struct{}
+	done   chan struct{}
+
+	// lost is closed when the lock is taken over or has expired without renewal
+	lost chan struct{}
+}
+
+// Acquire acquires cluster lock for the given operation
+// Retries until timeout passes while the lock is held by other operation
+func Acquire(client es.Client, op *operation.Operation, timeout time.Duration) (*Lock, error) {
+	host, _ := os.Hostname()
+
+	info := &Info{
+		OperationID: op.ID,
+		Command:     op.Command,
+		Owner:       op.User,
+		Host:        host,
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		info.AcquiredAt = time.Now()
+		info.ExpiresAt = info.AcquiredAt.Add(ttl)
+
+		doc, err := json.Marshal(info)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to serialize lock")
+		}
+
+		created, err := client.CreateDocument(Index, docType, docID, doc)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create lock document")
+		}
+
+		if created {
+			l := &Lock{
+				client: client,
+				info:   info,
+				stop:   make(chan struct{}),
+				done:   make(chan struct{}),
+				lost:   make(chan struct{}),
+			}
+
+			go l.renew()
+
+			return l, nil
+		}
+
+		holder, version, err := get(client)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve lock holder")
+		}
+
+		if holder != nil && holder.Expired(time.Now()) {
+			// Deleted only if nobody has renewed or taken over the lock in the meantime
+			if _, err := client.DeleteDocumentIfVersion(Index, docType, docID, version); err != nil {
+				return nil, errors.Wrap(err, "failed to delete expired lock document")
+			}
+
+			continue
+		}
+
+		if time.Now().Add(retryInterval).After(deadline) {
+			if holder == nil {
+				return nil, errors.New("cluster is locked by another operation")
+			}
+
+			return nil, errors.Errorf("cluster is locked by %s@%s (operation %s, %s) since %s", holder.Owner, holder.Host, holder.OperationID, holder.Command, holder.AcquiredAt.Format(time.RFC3339))
+		}
+
+		time.Sleep(retryInterval)
+	}
+}
+
+// Get returns the current holder of cluster lock
+// Returns nil if the cluster is not locked. Expired lock is returned as well, check it with Info.Expired
+func Get(client es.Client) (*Info, error) {
+	info, _, err := get(client)
+
+	return info, err
+}
+
+// get returns the current holder of cluster lock and version of the lock document
+func get(client es.Client) (*Info, *stats.DocumentVersion, error) {
+	doc, version, err := client.GetDocumentVersion(Index, docType, docID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get lock document")
+	}
+
+	if doc == nil {
+		return nil, nil, nil
+	}
+
+	var info Info
+
+	if err := json.Unmarshal(doc, &info); err != nil {
+		return nil, nil, errors.Wrap(err, "lock document is invalid")
+	}
+
+	return &info, version, nil
+}
+
+// ForceUnlock releases cluster lock regardless of its holder
+func ForceUnlock(client es.Client) error {
+	if err := client.DeleteDocument(Index, docType, docID); err != nil {
+		return errors.Wrap(err, "failed to delete lock document")
+	}
+
+	return nil
+}
+
+// Release stops renewal and releases cluster lock if it is still held by this lock
+// The lock document is deleted only if it has not been changed since it was read, so that lock taken over by another operation in the meantime is kept
+func (l *Lock) Release() error {
+	close(l.stop)
+	<-l.done
+
+	holder, version, err := get(l.client)
+	if err != nil {
+		return err
+	}
+
+	if holder == nil || holder.OperationID != l.info.OperationID {
+		return ErrTakenOver
+	}
+
+	deleted, err := l.client.DeleteDocumentIfVersion(Index, docType, docID, version)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete lock document")
+	}
+
+	if !deleted {
+		return ErrTakenOver
+	}
+
+	return nil
+}
+
+// Lost returns channel closed when the lock is taken over by another operation, or has expired because it could not be renewed
+// Holder must stop modifying the cluster once it is closed
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// renew extends expiry of the lock every third of ttl until Release is called or the lock is lost
+// Failure to reach the cluster is retried until the lock expires
+func (l *Lock) renew() {
+	defer close(l.done)
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-time.After(ttl / 3):
+		}
+
+		err := l.extend()
+		if err == nil {
+			continue
+		}
+
+		log.Println(errors.Wrap(err, "failed to renew cluster lock"))
+
+		if err == ErrTakenOver || l.info.Expired(time.Now()) {
+			close(l.lost)
+			return
+		}
+	}
+}
+
+// extend updates expiry of the lock if it is still held by this lock
+func (l *Lock) extend() error {
+	holder, version, err := get(l.client)
+	if err != nil {
+		return err
+	}
+
+	if holder == nil || holder.OperationID != l.info.OperationID {
+		return ErrTakenOver
+	}
+
+	info := *l.info
+	info.ExpiresAt = time.Now().Add(ttl)
+
+	doc, err := json.Marshal(&info)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize lock")
+	}
+
+	updated, err := l.client.UpdateDocumentIfVersion(Index, docType, docID, doc, version)
+	if err != nil {
+		return errors.Wrap(err, "failed to update lock document")
+	}
+
+	if !updated {
+		return ErrTakenOver
+	}
+
+	l.info = &info
+
+	return nil
+}
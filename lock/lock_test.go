@@ -0,0 +1,299 @@
+package lock
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/operation"
+)
+
+type fakeClient struct {
+	es.Client
+
+	mu       sync.Mutex
+	docs     map[string][]byte
+	versions map[string]int64
+
+	// beforeDelete is called before conditional delete if not nil
+	beforeDelete func()
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		docs:     map[string][]byte{},
+		versions: map[string]int64{},
+	}
+}
+
+func (c *fakeClient) CreateDocument(index, docType, id string, doc []byte) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := index + "/" + docType + "/" + id
+
+	if _, ok := c.docs[key]; ok {
+		return false, nil
+	}
+
+	c.docs[key] = doc
+	c.versions[key]++
+
+	return true, nil
+}
+
+func (c *fakeClient) DeleteDocument(index, docType, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := index + "/" + docType + "/" + id
+
+	delete(c.docs, key)
+	c.versions[key]++
+
+	return nil
+}
+
+func (c *fakeClient) DeleteDocumentIfVersion(index, docType, id string, version *stats.DocumentVersion) (bool, error) {
+	if c.beforeDelete != nil {
+		c.beforeDelete()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := index + "/" + docType + "/" + id
+
+	if c.versions[key] != version.Version {
+		return false, nil
+	}
+
+	delete(c.docs, key)
+	c.versions[key]++
+
+	return true, nil
+}
+
+func (c *fakeClient) GetDocument(index, docType, id string) ([]byte, error) {
+	doc, _, err := c.GetDocumentVersion(index, docType, id)
+
+	return doc, err
+}
+
+func (c *fakeClient) GetDocumentVersion(index, docType, id string) ([]byte, *stats.DocumentVersion, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := index + "/" + docType + "/" + id
+
+	doc, ok := c.docs[key]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	return doc, &stats.DocumentVersion{Version: c.versions[key]}, nil
+}
+
+func (c *fakeClient) UpdateDocumentIfVersion(index, docType, id string, doc []byte, version *stats.DocumentVersion) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := index + "/" + docType + "/" + id
+
+	if _, ok := c.docs[key]; !ok || c.versions[key] != version.Version {
+		return false, nil
+	}
+
+	c.docs[key] = doc
+	c.versions[key]++
+
+	return true, nil
+}
+
+func TestAcquire(t *testing.T) {
+	retryInterval = 10 * time.Millisecond
+
+	client := newFakeClient()
+
+	op1 := operation.New("remove", "http://example.com:9200")
+	op2 := operation.New("remove", "http://example.com:9200")
+
+	l, err := Acquire(client, op1, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if _, err := Acquire(client, op2, 30*time.Millisecond); err == nil {
+		t.Errorf("error should be raised while lock is held")
+	}
+
+	holder, err := Get(client)
+	if err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if holder.OperationID != op1.ID {
+		t.Errorf("lock holder does not match. expected: %q, got: %q", op1.ID, holder.OperationID)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if _, err := Acquire(client, op2, 0); err != nil {
+		t.Errorf("error should not be raised after release: %s", err)
+	}
+}
+
+func TestRelease_takenOver(t *testing.T) {
+	client := newFakeClient()
+
+	op1 := operation.New("remove", "http://example.com:9200")
+	op2 := operation.New("add", "http://example.com:9200")
+
+	l, err := Acquire(client, op1, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if err := ForceUnlock(client); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if _, err := Acquire(client, op2, 0); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if err := l.Release(); err == nil {
+		t.Errorf("error should be raised when lock was taken over")
+	}
+}
+
+func TestAcquire_expired(t *testing.T) {
+	client := newFakeClient()
+
+	op1 := operation.New("remove", "http://example.com:9200")
+	op2 := operation.New("add", "http://example.com:9200")
+
+	// Lock left by crashed process
+	doc, _ := json.Marshal(&Info{
+		OperationID: op1.ID,
+		AcquiredAt:  time.Now().Add(-time.Hour),
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	client.CreateDocument(Index, docType, docID, doc)
+
+	l, err := Acquire(client, op2, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised for expired lock: %s", err)
+	}
+
+	holder, _ := Get(client)
+	if holder.OperationID != op2.ID || holder.Expired(time.Now()) {
+		t.Errorf("lock should be held by %s without expiry. got: %+v", op2.ID, holder)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestRenew(t *testing.T) {
+	defer func(d time.Duration) { ttl = d }(ttl)
+	ttl = 30 * time.Millisecond
+
+	client := newFakeClient()
+
+	op := operation.New("remove", "http://example.com:9200")
+
+	l, err := Acquire(client, op, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	holder, _ := Get(client)
+	if holder.Expired(time.Now()) {
+		t.Errorf("lock should be renewed by holder. expired at: %s", holder.ExpiresAt)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if holder, _ := Get(client); holder != nil {
+		t.Errorf("lock should be released. got: %+v", holder)
+	}
+}
+
+func TestRenew_takenOver(t *testing.T) {
+	defer func(d time.Duration) { ttl = d }(ttl)
+	ttl = 30 * time.Millisecond
+
+	client := newFakeClient()
+
+	op1 := operation.New("remove", "http://example.com:9200")
+	op2 := operation.New("add", "http://example.com:9200")
+
+	l, err := Acquire(client, op1, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	ForceUnlock(client)
+
+	if _, err := Acquire(client, op2, 0); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatalf("lock should be reported as lost")
+	}
+
+	if err := l.Release(); err != ErrTakenOver {
+		t.Errorf("error should be %q. got: %v", ErrTakenOver, err)
+	}
+
+	holder, _ := Get(client)
+	if holder == nil || holder.OperationID != op2.ID {
+		t.Errorf("lock of %s should be kept. got: %+v", op2.ID, holder)
+	}
+}
+
+func TestRelease_takenOverBeforeDelete(t *testing.T) {
+	client := newFakeClient()
+
+	op1 := operation.New("remove", "http://example.com:9200")
+	op2 := operation.New("add", "http://example.com:9200")
+
+	l, err := Acquire(client, op1, 0)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	// Another operation takes over the lock between Get and delete of Release
+	client.beforeDelete = func() {
+		client.beforeDelete = nil
+
+		ForceUnlock(client)
+
+		if _, err := Acquire(client, op2, 0); err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+	}
+
+	if err := l.Release(); err == nil {
+		t.Errorf("error should be raised when lock was taken over")
+	}
+
+	holder, _ := Get(client)
+	if holder == nil || holder.OperationID != op2.ID {
+		t.Errorf("lock of %s should be kept. got: %+v", op2.ID, holder)
+	}
+}
@@ -0,0 +1,114 @@
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Writer appends to log file and rotates it when it exceeds the maximum size
+// Rotated files are renamed to <path>.1, <path>.2, ... and files older than maxBackups are removed
+type Writer struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// New opens the given log file for appending
+// File is never rotated if maxSize is not greater than 0
+func New(path string, maxSize int64, maxBackups int) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create log directory")
+	}
+
+	w := &Writer{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Close closes the current log file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
+
+// Write implements io.Writer
+// Log file is rotated before writing if p does not fit in the current file
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// backupPath returns path of the n-th rotated file
+func (w *Writer) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d", w.path, n)
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open log file")
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to stat log file")
+	}
+
+	w.file = f
+	w.size = info.Size()
+
+	return nil
+}
+
+// rotate must be called with w.mu held
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return errors.Wrap(err, "failed to close log file")
+	}
+
+	if w.maxBackups > 0 {
+		os.Remove(w.backupPath(w.maxBackups))
+
+		for n := w.maxBackups - 1; n > 0; n-- {
+			os.Rename(w.backupPath(n), w.backupPath(n+1))
+		}
+
+		if err := os.Rename(w.path, w.backupPath(1)); err != nil {
+			return errors.Wrap(err, "failed to rotate log file")
+		}
+	} else if err := os.Remove(w.path); err != nil {
+		return errors.Wrap(err, "failed to rotate log file")
+	}
+
+	return w.open()
+}
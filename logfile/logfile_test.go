@@ -0,0 +1,83 @@
+package logfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriter_rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-logfile")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "log", "esnctl.log")
+
+	w, err := New(path, 10, 2)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := map[string]string{
+		path:        "line 4\n",
+		path + ".1": "line 3\n",
+		path + ".2": "line 2\n",
+	}
+
+	for p, content := range expected {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		if string(b) != content {
+			t.Errorf("content of %s does not match. expected: %q, got: %q", p, content, string(b))
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("files older than max backups should be removed")
+	}
+}
+
+func TestWriter_append(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-logfile")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "esnctl.log")
+
+	for _, line := range []string{"first\n", "second\n"} {
+		w, err := New(path, 0, 0)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		w.Write([]byte(line))
+		w.Close()
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if string(b) != "first\nsecond\n" {
+		t.Errorf("log should be appended to existing file. got: %q", string(b))
+	}
+}
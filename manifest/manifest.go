@@ -0,0 +1,78 @@
+package manifest
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ActionAdd represents node addition
+	ActionAdd = "add"
+	// ActionRemove represents node removal
+	ActionRemove = "remove"
+)
+
+// Manifest represents a list of operations executed sequentially
+type Manifest struct {
+	ClusterURL string       `yaml:"cluster_url"`
+	Group      string       `yaml:"group"`
+	Region     string       `yaml:"region"`
+	Operations []*Operation `yaml:"operations"`
+}
+
+// Operation represents one operation in manifest
+type Operation struct {
+	Action string `yaml:"action"`
+	Node   string `yaml:"node"`
+	Count  int    `yaml:"count"`
+}
+
+// Load reads manifest from the given file
+func Load(path string) (*Manifest, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest file")
+	}
+
+	var m Manifest
+
+	if err := yaml.UnmarshalStrict(body, &m); err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest file")
+	}
+
+	return &m, nil
+}
+
+// Validate checks whether all operations in manifest can be executed
+func (m *Manifest) Validate() error {
+	if m.ClusterURL == "" {
+		return errors.New("cluster_url is empty")
+	}
+
+	if m.Group == "" {
+		return errors.New("group is empty")
+	}
+
+	if len(m.Operations) == 0 {
+		return errors.New("no operation is defined")
+	}
+
+	for i, op := range m.Operations {
+		switch op.Action {
+		case ActionAdd:
+			if op.Count < 1 {
+				return errors.Errorf("operations[%d]: count must be greater than 0", i)
+			}
+		case ActionRemove:
+			if op.Node == "" {
+				return errors.Errorf("operations[%d]: node is empty", i)
+			}
+		default:
+			return errors.Errorf("operations[%d]: unsupported action %q", i, op.Action)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "esnctl-manifest")
+	if err != nil {
+		t.Fatalf("failed to create temporary file: %s", err)
+	}
+	defer os.Remove(f.Name())
+
+	f.WriteString(`cluster_url: http://elasticsearch.example.com
+group: elasticsearch
+operations:
+  - action: remove
+    node: ip-10-0-1-21.ap-northeast-1.compute.internal
+  - action: add
+    count: 2
+`)
+	f.Close()
+
+	m, err := Load(f.Name())
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(m.Operations) != 2 {
+		t.Fatalf("number of operations does not match. expected: 2, got: %d", len(m.Operations))
+	}
+
+	if m.Operations[0].Action != ActionRemove || m.Operations[0].Node != "ip-10-0-1-21.ap-northeast-1.compute.internal" {
+		t.Errorf("first operation does not match. got: %#v", m.Operations[0])
+	}
+
+	if m.Operations[1].Action != ActionAdd || m.Operations[1].Count != 2 {
+		t.Errorf("second operation does not match. got: %#v", m.Operations[1])
+	}
+
+	if err := m.Validate(); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testcases := []struct {
+		operation *Operation
+		invalid   bool
+	}{
+		{
+			operation: &Operation{Action: ActionAdd, Count: 1},
+			invalid:   false,
+		},
+		{
+			operation: &Operation{Action: ActionAdd},
+			invalid:   true,
+		},
+		{
+			operation: &Operation{Action: ActionRemove},
+			invalid:   true,
+		},
+		{
+			operation: &Operation{Action: "restart", Node: "ip-10-0-1-21.ap-northeast-1.compute.internal"},
+			invalid:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		m := &Manifest{
+			ClusterURL: "http://elasticsearch.example.com",
+			Group:      "elasticsearch",
+			Operations: []*Operation{tc.operation},
+		}
+
+		err := m.Validate()
+
+		if tc.invalid && err == nil {
+			t.Errorf("error should be raised for %#v", tc.operation)
+		}
+
+		if !tc.invalid && err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+	}
+}
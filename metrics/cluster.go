@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"strings"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/pkg/errors"
+)
+
+// excludeNameSetting represents cluster setting listing nodes excluded from shard allocation
+const excludeNameSetting = "cluster.routing.allocation.exclude._name"
+
+// healthLevels maps cluster health status to metric value, so that alarm can be set on threshold
+var healthLevels = map[string]float64{
+	"green":  0,
+	"yellow": 1,
+	"red":    2,
+}
+
+// ClusterState represents cluster health and node usage exposed as metrics
+type ClusterState struct {
+	Status             string
+	Nodes              int
+	RelocatingShards   int
+	InitializingShards int
+	UnassignedShards   int
+
+	// ExcludedNodes represents the number of nodes excluded from shard allocation, e.g. being drained
+	ExcludedNodes int
+
+	MaxDiskPercent float64
+	MaxHeapPercent float64
+}
+
+// CollectCluster retrieves state of the cluster
+func CollectCluster(client es.Client) (*ClusterState, error) {
+	health, err := client.ClusterHealth()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve cluster health")
+	}
+
+	nodes, err := client.NodeStats()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve node stats")
+	}
+
+	settings, err := client.ClusterSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	s := &ClusterState{
+		Status:             health.Status,
+		Nodes:              len(nodes),
+		RelocatingShards:   health.RelocatingShards,
+		InitializingShards: health.InitializingShards,
+		UnassignedShards:   health.UnassignedShards,
+	}
+
+	for _, name := range strings.Split(settings[excludeNameSetting], ",") {
+		if strings.TrimSpace(name) != "" {
+			s.ExcludedNodes++
+		}
+	}
+
+	for _, n := range nodes {
+		if n.DiskPercent > s.MaxDiskPercent {
+			s.MaxDiskPercent = n.DiskPercent
+		}
+
+		if n.HeapPercent > s.MaxHeapPercent {
+			s.MaxHeapPercent = n.HeapPercent
+		}
+	}
+
+	return s, nil
+}
+
+// HealthLevel returns 0 for green, 1 for yellow and 2 for red or unknown status
+func (s *ClusterState) HealthLevel() float64 {
+	level, ok := healthLevels[s.Status]
+	if !ok {
+		return healthLevels["red"]
+	}
+
+	return level
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestCollectCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+
+	if err := c.ExcludeNodeFromAllocation("ip-10-0-1-1.ec2.internal"); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	s, err := CollectCluster(c)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if s.Nodes != 3 {
+		t.Errorf("number of nodes does not match. expected: 3, got: %d", s.Nodes)
+	}
+
+	if s.ExcludedNodes != 1 {
+		t.Errorf("number of excluded nodes does not match. expected: 1, got: %d", s.ExcludedNodes)
+	}
+
+	if s.MaxDiskPercent <= 0 {
+		t.Errorf("max disk usage should be positive. got: %v", s.MaxDiskPercent)
+	}
+}
+
+func TestHealthLevel(t *testing.T) {
+	testcases := []struct {
+		status   string
+		expected float64
+	}{
+		{"green", 0},
+		{"yellow", 1},
+		{"red", 2},
+		{"", 2},
+	}
+
+	for _, tc := range testcases {
+		if got := (&ClusterState{Status: tc.status}).HealthLevel(); got != tc.expected {
+			t.Errorf("health level of %q does not match. expected: %v, got: %v", tc.status, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/dtan4/esnctl/aws/cloudwatch"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+)
+
+const (
+	// OperationNamespace represents CloudWatch namespace of per-operation metrics
+	OperationNamespace = "esnctl/Operations"
+	// ClusterNamespace represents CloudWatch namespace of periodic cluster metrics
+	ClusterNamespace = "esnctl/Cluster"
+)
+
+// Operation returns metrics of the finished operation, dimensioned by cluster and command
+// Drain metrics are included only if the operation drained node
+func Operation(op *operation.Operation) []*cloudwatch.Datum {
+	dimensions := map[string]string{
+		"Cluster": ClusterName(op.Cluster),
+		"Command": op.Command,
+	}
+
+	failed := 0.0
+	if op.Result == operation.ResultFailed {
+		failed = 1
+	}
+
+	data := []*cloudwatch.Datum{
+		{Name: "Duration", Value: op.Duration().Seconds(), Unit: cloudwatch.UnitSeconds, Dimensions: dimensions, Timestamp: op.FinishedAt},
+		{Name: "Failures", Value: failed, Unit: cloudwatch.UnitCount, Dimensions: dimensions, Timestamp: op.FinishedAt},
+	}
+
+	if drain := op.PhaseDuration(workflow.RemoveStepDescription(workflow.StepWaitDrain)); drain > 0 {
+		data = append(data,
+			&cloudwatch.Datum{Name: "DrainDuration", Value: drain.Seconds(), Unit: cloudwatch.UnitSeconds, Dimensions: dimensions, Timestamp: op.FinishedAt},
+			&cloudwatch.Datum{Name: "ShardsMoved", Value: float64(op.Shards), Unit: cloudwatch.UnitCount, Dimensions: dimensions, Timestamp: op.FinishedAt},
+		)
+	}
+
+	return data
+}
+
+// Cluster returns metrics of the given cluster state, dimensioned by cluster
+func Cluster(cluster string, state *ClusterState) []*cloudwatch.Datum {
+	dimensions := map[string]string{
+		"Cluster": cluster,
+	}
+
+	return []*cloudwatch.Datum{
+		{Name: "HealthStatus", Value: state.HealthLevel(), Unit: cloudwatch.UnitNone, Dimensions: dimensions},
+		{Name: "Nodes", Value: float64(state.Nodes), Unit: cloudwatch.UnitCount, Dimensions: dimensions},
+		{Name: "RelocatingShards", Value: float64(state.RelocatingShards), Unit: cloudwatch.UnitCount, Dimensions: dimensions},
+		{Name: "InitializingShards", Value: float64(state.InitializingShards), Unit: cloudwatch.UnitCount, Dimensions: dimensions},
+		{Name: "UnassignedShards", Value: float64(state.UnassignedShards), Unit: cloudwatch.UnitCount, Dimensions: dimensions},
+		{Name: "MaxDiskPercent", Value: state.MaxDiskPercent, Unit: cloudwatch.UnitPercent, Dimensions: dimensions},
+		{Name: "MaxHeapPercent", Value: state.MaxHeapPercent, Unit: cloudwatch.UnitPercent, Dimensions: dimensions},
+	}
+}
+
+// ClusterName returns host of the first cluster URL, used as Cluster dimension
+func ClusterName(clusterURL string) string {
+	first := strings.Split(clusterURL, ",")[0]
+
+	u, err := url.Parse(first)
+	if err != nil || u.Host == "" {
+		return first
+	}
+
+	return u.Hostname()
+}
@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dtan4/esnctl/operation"
+)
+
+// phaseDurationBuckets represents upper bounds in seconds of phase duration histogram
+var phaseDurationBuckets = []float64{1, 5, 10, 30, 60, 300, 600, 1800, 3600, 7200}
+
+// labelEscaper escapes label value in Prometheus text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type operationKey struct {
+	cluster string
+	command string
+	result  string
+}
+
+type phaseKey struct {
+	command string
+	phase   string
+}
+
+type histogram struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+// Collector exposes operation and cluster metrics in Prometheus text format
+type Collector struct {
+	mu         sync.Mutex
+	operations map[operationKey]uint64
+	phases     map[phaseKey]*histogram
+	clusters   map[string]*ClusterState
+
+	// leader is nil unless leader election is enabled
+	leader *bool
+}
+
+// NewCollector creates new Collector object
+func NewCollector() *Collector {
+	return &Collector{
+		operations: map[operationKey]uint64{},
+		phases:     map[phaseKey]*histogram{},
+		clusters:   map[string]*ClusterState{},
+	}
+}
+
+// ObserveOperation counts the finished operation and records durations of its phases
+// Phases named after variable values, e.g. node names or instance counts, are not recorded to bound cardinality
+func (c *Collector) ObserveOperation(cluster string, op *operation.Operation) {
+	op = op.Snapshot()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.operations[operationKey{cluster: cluster, command: op.Command, result: op.Result}]++
+
+	for _, p := range op.Phases {
+		if p.FinishedAt.IsZero() || strings.ContainsAny(p.Name, "0123456789") {
+			continue
+		}
+
+		key := phaseKey{command: op.Command, phase: p.Name}
+
+		h, ok := c.phases[key]
+		if !ok {
+			h = &histogram{buckets: make([]uint64, len(phaseDurationBuckets))}
+			c.phases[key] = h
+		}
+
+		d := p.FinishedAt.Sub(p.StartedAt).Seconds()
+
+		for i, le := range phaseDurationBuckets {
+			if d <= le {
+				h.buckets[i]++
+			}
+		}
+
+		h.sum += d
+		h.count++
+	}
+}
+
+// SetCluster updates state of the given cluster
+func (c *Collector) SetCluster(name string, s *ClusterState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clusters[name] = s
+}
+
+// SetLeader updates whether this instance is the leader
+func (c *Collector) SetLeader(leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.leader = &leader
+}
+
+// ServeHTTP writes metrics in Prometheus text format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	c.Write(w)
+}
+
+// Write writes metrics in Prometheus text format
+func (c *Collector) Write(out io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintln(out, "# HELP esnctl_operations_total Number of finished operations")
+	fmt.Fprintln(out, "# TYPE esnctl_operations_total counter")
+
+	opKeys := make([]operationKey, 0, len(c.operations))
+
+	for k := range c.operations {
+		opKeys = append(opKeys, k)
+	}
+
+	sort.Slice(opKeys, func(i, j int) bool {
+		a, b := opKeys[i], opKeys[j]
+		if a.cluster != b.cluster {
+			return a.cluster < b.cluster
+		}
+		if a.command != b.command {
+			return a.command < b.command
+		}
+		return a.result < b.result
+	})
+
+	for _, k := range opKeys {
+		fmt.Fprintf(out, "esnctl_operations_total{cluster=\"%s\",command=\"%s\",result=\"%s\"} %d\n", escape(k.cluster), escape(k.command), escape(k.result), c.operations[k])
+	}
+
+	fmt.Fprintln(out, "# HELP esnctl_operation_phase_duration_seconds Duration of operation phases")
+	fmt.Fprintln(out, "# TYPE esnctl_operation_phase_duration_seconds histogram")
+
+	phaseKeys := make([]phaseKey, 0, len(c.phases))
+
+	for k := range c.phases {
+		phaseKeys = append(phaseKeys, k)
+	}
+
+	sort.Slice(phaseKeys, func(i, j int) bool {
+		a, b := phaseKeys[i], phaseKeys[j]
+		if a.command != b.command {
+			return a.command < b.command
+		}
+		return a.phase < b.phase
+	})
+
+	for _, k := range phaseKeys {
+		h := c.phases[k]
+		labels := fmt.Sprintf("command=\"%s\",phase=\"%s\"", escape(k.command), escape(k.phase))
+
+		for i, le := range phaseDurationBuckets {
+			fmt.Fprintf(out, "esnctl_operation_phase_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(le, 'g', -1, 64), h.buckets[i])
+		}
+
+		fmt.Fprintf(out, "esnctl_operation_phase_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(out, "esnctl_operation_phase_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "esnctl_operation_phase_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	if c.leader != nil {
+		fmt.Fprintln(out, "# HELP esnctl_leader Whether this instance is the leader (1: leader, 0: standby)")
+		fmt.Fprintln(out, "# TYPE esnctl_leader gauge")
+
+		if *c.leader {
+			fmt.Fprintln(out, "esnctl_leader 1")
+		} else {
+			fmt.Fprintln(out, "esnctl_leader 0")
+		}
+	}
+
+	names := make([]string, 0, len(c.clusters))
+
+	for name := range c.clusters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	gauges := []struct {
+		name  string
+		help  string
+		value func(s *ClusterState) float64
+	}{
+		{"esnctl_cluster_health_status", "Cluster health status (0: green, 1: yellow, 2: red)", (*ClusterState).HealthLevel},
+		{"esnctl_cluster_nodes", "Number of nodes in the cluster", func(s *ClusterState) float64 { return float64(s.Nodes) }},
+		{"esnctl_cluster_relocating_shards", "Number of relocating shards", func(s *ClusterState) float64 { return float64(s.RelocatingShards) }},
+		{"esnctl_cluster_initializing_shards", "Number of initializing shards", func(s *ClusterState) float64 { return float64(s.InitializingShards) }},
+		{"esnctl_cluster_unassigned_shards", "Number of unassigned shards", func(s *ClusterState) float64 { return float64(s.UnassignedShards) }},
+		{"esnctl_cluster_excluded_nodes", "Number of nodes excluded from shard allocation", func(s *ClusterState) float64 { return float64(s.ExcludedNodes) }},
+	}
+
+	for _, g := range gauges {
+		fmt.Fprintf(out, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(out, "# TYPE %s gauge\n", g.name)
+
+		for _, name := range names {
+			fmt.Fprintf(out, "%s{cluster=\"%s\"} %s\n", g.name, escape(name), strconv.FormatFloat(g.value(c.clusters[name]), 'g', -1, 64))
+		}
+	}
+}
+
+func escape(v string) string {
+	return labelEscaper.Replace(v)
+}
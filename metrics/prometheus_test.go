@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/operation"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	op := operation.New("remove", "http://logs.example.com")
+	op.Phases = []*operation.Phase{
+		{Name: "Waiting for shards escape from target node", StartedAt: start, FinishedAt: start.Add(90 * time.Second)},
+		{Name: "Clearing allocation exclusion of ip-10-0-1-1.ec2.internal", StartedAt: start, FinishedAt: start.Add(time.Second)},
+	}
+	op.Finish(nil)
+
+	c.ObserveOperation("logs", op)
+	c.ObserveOperation("logs", op)
+	c.SetCluster("logs", &ClusterState{Status: "yellow", Nodes: 3, RelocatingShards: 2, ExcludedNodes: 1})
+
+	var buf bytes.Buffer
+	c.Write(&buf)
+	got := buf.String()
+
+	for _, line := range []string{
+		`esnctl_operations_total{cluster="logs",command="remove",result="succeeded"} 2`,
+		`esnctl_operation_phase_duration_seconds_bucket{command="remove",phase="Waiting for shards escape from target node",le="60"} 0`,
+		`esnctl_operation_phase_duration_seconds_bucket{command="remove",phase="Waiting for shards escape from target node",le="300"} 2`,
+		`esnctl_operation_phase_duration_seconds_bucket{command="remove",phase="Waiting for shards escape from target node",le="+Inf"} 2`,
+		`esnctl_operation_phase_duration_seconds_sum{command="remove",phase="Waiting for shards escape from target node"} 180`,
+		`esnctl_cluster_health_status{cluster="logs"} 1`,
+		`esnctl_cluster_nodes{cluster="logs"} 3`,
+		`esnctl_cluster_relocating_shards{cluster="logs"} 2`,
+		`esnctl_cluster_excluded_nodes{cluster="logs"} 1`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("metrics should contain %q. got:\n%s", line, got)
+		}
+	}
+
+	if strings.Contains(got, "esnctl_leader") {
+		t.Errorf("leader gauge should not be exposed unless leader election is enabled. got:\n%s", got)
+	}
+
+	c.SetLeader(true)
+	buf.Reset()
+	c.Write(&buf)
+
+	if !strings.Contains(buf.String(), "esnctl_leader 1\n") {
+		t.Errorf("metrics should contain leader gauge. got:\n%s", buf.String())
+	}
+
+	if strings.Contains(got, "ip-10-0-1-1") {
+		t.Errorf("phase named after node should not be recorded. got:\n%s", got)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, expected := escape("a\"b\\c\nd"), `a\"b\\c\nd`; got != expected {
+		t.Errorf("escaped value does not match. expected: %q, got: %q", expected, got)
+	}
+}
@@ -0,0 +1,127 @@
+package operation
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// EventPhase represents that new phase started
+	EventPhase = "phase"
+	// EventProgress represents one check of waiting phase
+	EventProgress = "progress"
+	// EventFinish represents that the operation finished
+	EventFinish = "finish"
+)
+
+// Observer receives phase transitions and progress of operation, e.g. to stream them to other programs
+type Observer interface {
+	Finish(op *Operation)
+	Phase(op *Operation, name string)
+	Progress(op *Operation, p Progress)
+}
+
+// Progress represents what waiting phase still waits for
+type Progress struct {
+	// Unit represents what is counted, e.g. shards
+	Unit      string
+	Total     int
+	Remaining int
+	// Bytes represents size of remaining shards. 0 means unknown
+	Bytes int64
+}
+
+// Event represents one line written by JSONLObserver
+type Event struct {
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	OperationID string    `json:"operation_id"`
+	Command     string    `json:"command"`
+	Node        string    `json:"node,omitempty"`
+	Phase       string    `json:"phase,omitempty"`
+
+	// Elapsed represents seconds since the operation started
+	Elapsed float64 `json:"elapsed"`
+
+	Unit      string `json:"unit,omitempty"`
+	Total     int    `json:"total,omitempty"`
+	Remaining *int   `json:"remaining,omitempty"`
+	Bytes     int64  `json:"bytes,omitempty"`
+
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// JSONLObserver writes one JSON object per event
+type JSONLObserver struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLObserver creates JSONLObserver writing to w
+// It can be shared among concurrent operations, events are told apart by operation_id
+func NewJSONLObserver(w io.Writer) *JSONLObserver {
+	return &JSONLObserver{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Finish implements Observer
+func (j *JSONLObserver) Finish(op *Operation) {
+	j.write(newEvent(EventFinish, op))
+}
+
+// Phase implements Observer
+func (j *JSONLObserver) Phase(op *Operation, name string) {
+	j.write(newEvent(EventPhase, op))
+}
+
+// Progress implements Observer
+func (j *JSONLObserver) Progress(op *Operation, p Progress) {
+	remaining := p.Remaining
+
+	e := newEvent(EventProgress, op)
+	e.Unit = p.Unit
+	e.Total = p.Total
+	e.Remaining = &remaining
+	e.Bytes = p.Bytes
+
+	j.write(e)
+}
+
+func (j *JSONLObserver) write(e *Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.enc.Encode(e)
+}
+
+// newEvent returns event filled with the current state of the given operation
+func newEvent(typ string, op *Operation) *Event {
+	s := op.Snapshot()
+	now := time.Now()
+
+	e := &Event{
+		Type:        typ,
+		Time:        now,
+		OperationID: s.ID,
+		Command:     s.Command,
+		Node:        s.Node,
+		Elapsed:     now.Sub(s.StartedAt).Seconds(),
+	}
+
+	if len(s.Phases) > 0 {
+		e.Phase = s.Phases[len(s.Phases)-1].Name
+	}
+
+	if !s.FinishedAt.IsZero() {
+		e.Time = s.FinishedAt
+		e.Elapsed = s.FinishedAt.Sub(s.StartedAt).Seconds()
+		e.Result = s.Result
+		e.Error = s.Error
+	}
+
+	return e
+}
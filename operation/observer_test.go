@@ -0,0 +1,59 @@
+package operation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONLObserver(t *testing.T) {
+	var buf bytes.Buffer
+
+	op := New("remove", "http://elasticsearch.example.com")
+	op.Node = "ip-10-0-1-21.ap-northeast-1.compute.internal"
+	op.Observer = NewJSONLObserver(&buf)
+
+	op.Phase("Waiting for shards escape from target node")
+	op.Progress(Progress{Unit: "shards", Total: 40, Remaining: 30, Bytes: 1024})
+	op.Progress(Progress{Unit: "shards", Total: 40})
+	op.Finish(errors.New("timed out"))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("one line should be written per event. got: %q", buf.String())
+	}
+
+	events := []*Event{}
+
+	for _, line := range lines {
+		var e Event
+
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line should be JSON object: %s", err)
+		}
+
+		if e.OperationID != op.ID || e.Command != "remove" || e.Node != op.Node {
+			t.Errorf("event should have operation ID, command and node. got: %q", line)
+		}
+
+		events = append(events, &e)
+	}
+
+	if events[0].Type != EventPhase || events[0].Phase != "Waiting for shards escape from target node" {
+		t.Errorf("phase event does not match. got: %q", lines[0])
+	}
+
+	if events[1].Type != EventProgress || events[1].Remaining == nil || *events[1].Remaining != 30 || events[1].Total != 40 || events[1].Bytes != 1024 {
+		t.Errorf("progress event does not match. got: %q", lines[1])
+	}
+
+	if events[2].Remaining == nil || *events[2].Remaining != 0 {
+		t.Errorf("remaining should be written even if 0. got: %q", lines[2])
+	}
+
+	if events[3].Type != EventFinish || events[3].Result != ResultFailed || events[3].Error != "timed out" {
+		t.Errorf("finish event does not match. got: %q", lines[3])
+	}
+}
@@ -0,0 +1,241 @@
+package operation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ResultSucceeded represents that the operation finished successfully
+	ResultSucceeded = "succeeded"
+	// ResultFailed represents that the operation finished with error
+	ResultFailed = "failed"
+)
+
+// Operation represents a record of one esnctl operation
+type Operation struct {
+	ID         string    `json:"id"`
+	Command    string    `json:"command"`
+	User       string    `json:"user"`
+	Cluster    string    `json:"cluster"`
+	Group      string    `json:"group,omitempty"`
+	Node       string    `json:"node,omitempty"`
+	Phases     []*Phase  `json:"phases"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Result     string    `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+
+	// Shards represents the number of shards moved off the node by the operation
+	Shards int `json:"shards,omitempty"`
+
+	// Observer is notified of phase transitions, progress and finish if not nil
+	Observer Observer `json:"-"`
+
+	mu sync.Mutex
+}
+
+// Phase represents one step of operation
+type Phase struct {
+	Name       string    `json:"name"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// New creates new Operation object
+func New(command, clusterURL string) *Operation {
+	return &Operation{
+		ID:        newID(),
+		Command:   command,
+		User:      currentUser(),
+		Cluster:   SanitizeURL(clusterURL),
+		Phases:    []*Phase{},
+		StartedAt: time.Now(),
+	}
+}
+
+// Phase finishes the current phase and starts new phase with the given name
+func (o *Operation) Phase(name string) {
+	o.mu.Lock()
+
+	now := time.Now()
+
+	o.finishPhase(now)
+	o.Phases = append(o.Phases, &Phase{
+		Name:      name,
+		StartedAt: now,
+	})
+
+	o.logf("===> %s...\n", name)
+
+	o.mu.Unlock()
+
+	if o.Observer != nil {
+		o.Observer.Phase(o, name)
+	}
+}
+
+// Progress notifies Observer of progress of the current waiting phase
+func (o *Operation) Progress(p Progress) {
+	if o.Observer != nil {
+		o.Observer.Progress(o, p)
+	}
+}
+
+// Logf prints log line prefixed with operation ID, so that lines of concurrent operations can be told apart
+func (o *Operation) Logf(format string, args ...interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.logf(format, args...)
+}
+
+// Finish marks the operation as finished
+func (o *Operation) Finish(err error) {
+	o.mu.Lock()
+
+	now := time.Now()
+
+	o.finishPhase(now)
+	o.FinishedAt = now
+
+	if err != nil {
+		o.Result = ResultFailed
+		o.Error = err.Error()
+	} else {
+		o.Result = ResultSucceeded
+	}
+
+	o.mu.Unlock()
+
+	if o.Observer != nil {
+		o.Observer.Finish(o)
+	}
+}
+
+// Duration returns how long the operation took
+func (o *Operation) Duration() time.Duration {
+	if o.FinishedAt.IsZero() {
+		return time.Since(o.StartedAt)
+	}
+
+	return o.FinishedAt.Sub(o.StartedAt)
+}
+
+// Snapshot returns a copy of the operation
+// Use this to read operation which is being executed in another goroutine
+func (o *Operation) Snapshot() *Operation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	phases := make([]*Phase, 0, len(o.Phases))
+
+	for _, phase := range o.Phases {
+		p := *phase
+		phases = append(phases, &p)
+	}
+
+	return &Operation{
+		ID:         o.ID,
+		Command:    o.Command,
+		User:       o.User,
+		Cluster:    o.Cluster,
+		Group:      o.Group,
+		Node:       o.Node,
+		Phases:     phases,
+		StartedAt:  o.StartedAt,
+		FinishedAt: o.FinishedAt,
+		Result:     o.Result,
+		Error:      o.Error,
+		Shards:     o.Shards,
+	}
+}
+
+// PhaseDuration returns total duration of phases with the given name
+// Phase which is still running is counted until now
+func (o *Operation) PhaseDuration(name string) time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var d time.Duration
+
+	for _, p := range o.Phases {
+		if p.Name != name {
+			continue
+		}
+
+		if p.FinishedAt.IsZero() {
+			d += time.Since(p.StartedAt)
+		} else {
+			d += p.FinishedAt.Sub(p.StartedAt)
+		}
+	}
+
+	return d
+}
+
+// SetShards records the number of shards moved off the node by the operation
+func (o *Operation) SetShards(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.Shards = n
+}
+
+func (o *Operation) finishPhase(now time.Time) {
+	if len(o.Phases) == 0 {
+		return
+	}
+
+	if last := o.Phases[len(o.Phases)-1]; last.FinishedAt.IsZero() {
+		last.FinishedAt = now
+	}
+}
+
+func (o *Operation) logf(format string, args ...interface{}) {
+	log.Printf("[%s] "+format, append([]interface{}{o.ID}, args...)...)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
+
+// SanitizeURL strips credentials from the given URL not to persist or show them
+// Comma-separated URLs are sanitized respectively
+func SanitizeURL(rawurl string) string {
+	urls := []string{}
+
+	for _, r := range strings.Split(rawurl, ",") {
+		u, err := url.Parse(strings.TrimSpace(r))
+		if err != nil {
+			return ""
+		}
+
+		u.User = nil
+		urls = append(urls, u.String())
+	}
+
+	return strings.Join(urls, ",")
+}
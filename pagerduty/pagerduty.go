@@ -0,0 +1,179 @@
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultAddr represents endpoint of PagerDuty REST API
+const DefaultAddr = "https://api.pagerduty.com"
+
+// Client represents PagerDuty REST API client
+type Client struct {
+	addr       string
+	token      string
+	from       string
+	httpClient *http.Client
+}
+
+// MaintenanceWindow represents maintenance window of PagerDuty services
+type MaintenanceWindow struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
+
+type reference struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type maintenanceWindowRequest struct {
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	StartTime   time.Time   `json:"start_time"`
+	EndTime     time.Time   `json:"end_time"`
+	Services    []reference `json:"services"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors"`
+	} `json:"error"`
+}
+
+// New creates new Client object
+// from is email address of PagerDuty user, which is required for account-level API token
+func New(addr, token, from string, httpClient *http.Client) *Client {
+	return &Client{
+		addr:       strings.TrimSuffix(addr, "/"),
+		token:      token,
+		from:       from,
+		httpClient: httpClient,
+	}
+}
+
+// NewFromEnv creates new Client object from PAGERDUTY_TOKEN and PAGERDUTY_FROM
+func NewFromEnv() (*Client, error) {
+	token := os.Getenv("PAGERDUTY_TOKEN")
+	if token == "" {
+		return nil, errors.New("PAGERDUTY_TOKEN must be set")
+	}
+
+	return New(DefaultAddr, token, os.Getenv("PAGERDUTY_FROM"), &http.Client{}), nil
+}
+
+// CreateMaintenanceWindow creates maintenance window of the given services from now until now + duration
+// Incidents are not created on the services during the window
+func (c *Client) CreateMaintenanceWindow(serviceIDs []string, description string, duration time.Duration) (*MaintenanceWindow, error) {
+	now := time.Now().UTC()
+
+	services := make([]reference, 0, len(serviceIDs))
+
+	for _, id := range serviceIDs {
+		services = append(services, reference{ID: id, Type: "service_reference"})
+	}
+
+	body := map[string]interface{}{
+		"maintenance_window": &maintenanceWindowRequest{
+			Type:        "maintenance_window",
+			Description: description,
+			StartTime:   now,
+			EndTime:     now.Add(duration),
+			Services:    services,
+		},
+	}
+
+	var resp struct {
+		MaintenanceWindow *MaintenanceWindow `json:"maintenance_window"`
+	}
+
+	if err := c.do("POST", "/maintenance_windows", body, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to create maintenance window")
+	}
+
+	if resp.MaintenanceWindow == nil {
+		return nil, errors.New("maintenance window is not returned")
+	}
+
+	return resp.MaintenanceWindow, nil
+}
+
+// EndMaintenanceWindow ends ongoing maintenance window, or deletes future one
+func (c *Client) EndMaintenanceWindow(id string) error {
+	if err := c.do("DELETE", "/maintenance_windows/"+id, nil, nil); err != nil {
+		return errors.Wrapf(err, "failed to end maintenance window %s", id)
+	}
+
+	return nil
+}
+
+func (c *Client) do(method, path string, body interface{}, v interface{}) error {
+	var reqBody []byte
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to build request body")
+		}
+
+		reqBody = b
+	}
+
+	req, err := http.NewRequest(method, c.addr+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to make http request")
+	}
+
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.from != "" {
+		req.Header.Set("From", c.from)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to access to PagerDuty")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode/100 != 2 {
+		var e errorResponse
+
+		if err := json.Unmarshal(respBody, &e); err == nil && e.Error.Message != "" {
+			if len(e.Error.Errors) > 0 {
+				return errors.Errorf("code: %d, message: %s (%s)", resp.StatusCode, e.Error.Message, strings.Join(e.Error.Errors, ", "))
+			}
+
+			return errors.Errorf("code: %d, message: %s", resp.StatusCode, e.Error.Message)
+		}
+
+		return errors.Errorf("code: %d, body: %s", resp.StatusCode, respBody)
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return errors.Wrap(err, "invalid response body")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateMaintenanceWindow(t *testing.T) {
+	var got struct {
+		MaintenanceWindow maintenanceWindowRequest `json:"maintenance_window"`
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token token=u+token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"Unauthorized","code":2006}}`))
+			return
+		}
+
+		if r.Method != http.MethodPost || r.URL.Path != "/maintenance_windows" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Header.Get("From") != "sre@example.com" {
+			t.Errorf("From header does not match. got: %q", r.Header.Get("From"))
+		}
+
+		json.NewDecoder(r.Body).Decode(&got)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"maintenance_window":{"id":"PW98YIO","description":"esnctl remove","start_time":"2026-10-16T09:00:00Z","end_time":"2026-10-16T11:00:00Z"}}`))
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "u+token", "sre@example.com", &http.Client{})
+
+	mw, err := client.CreateMaintenanceWindow([]string{"PIJ90N7", "PF9KMXH"}, "esnctl remove", 2*time.Hour)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if mw.ID != "PW98YIO" {
+		t.Errorf("maintenance window ID does not match. expected: %q, got: %q", "PW98YIO", mw.ID)
+	}
+
+	if len(got.MaintenanceWindow.Services) != 2 || got.MaintenanceWindow.Services[1].ID != "PF9KMXH" || got.MaintenanceWindow.Services[1].Type != "service_reference" {
+		t.Errorf("services do not match. got: %+v", got.MaintenanceWindow.Services)
+	}
+
+	if d := got.MaintenanceWindow.EndTime.Sub(got.MaintenanceWindow.StartTime); d != 2*time.Hour {
+		t.Errorf("window duration does not match. expected: 2h, got: %s", d)
+	}
+
+	client = New(ts.URL, "wrong", "", &http.Client{})
+
+	if _, err := client.CreateMaintenanceWindow([]string{"PIJ90N7"}, "esnctl remove", time.Hour); err == nil {
+		t.Errorf("error should be raised with wrong token")
+	}
+}
+
+func TestEndMaintenanceWindow(t *testing.T) {
+	deleted := ""
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path == "/maintenance_windows/PGONE01" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte(`{"error":{"message":"Maintenance window has already ended","code":2001}}`))
+			return
+		}
+
+		deleted = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "u+token", "", &http.Client{})
+
+	if err := client.EndMaintenanceWindow("PW98YIO"); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if deleted != "/maintenance_windows/PW98YIO" {
+		t.Errorf("deleted path does not match. got: %q", deleted)
+	}
+
+	if err := client.EndMaintenanceWindow("PGONE01"); err == nil {
+		t.Errorf("error should be raised for ended window")
+	}
+}
@@ -0,0 +1,101 @@
+package plan
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ActionRemove represents node removal
+	ActionRemove = "remove"
+
+	// FormatVersion represents the version of plan file format
+	FormatVersion = 1
+)
+
+// Plan represents resolved operation which can be reviewed and applied later
+type Plan struct {
+	Version        int       `json:"version"`
+	Action         string    `json:"action"`
+	ClusterURL     string    `json:"cluster_url"`
+	Region         string    `json:"region,omitempty"`
+	Group          string    `json:"group"`
+	NodeName       string    `json:"node_name"`
+	InstanceID     string    `json:"instance_id"`
+	TargetGroupARN string    `json:"target_group_arn"`
+	Shards         int       `json:"shards"`
+	Steps          []string  `json:"steps"`
+	CreatedBy      string    `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Load reads plan from the given file
+func Load(path string) (*Plan, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read plan file")
+	}
+
+	var p Plan
+
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, errors.Wrap(err, "failed to parse plan file")
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, errors.Wrap(err, "plan is invalid")
+	}
+
+	return &p, nil
+}
+
+// Validate checks whether the plan has enough information to be applied
+func (p *Plan) Validate() error {
+	if p.Version != FormatVersion {
+		return errors.Errorf("unsupported plan version %d", p.Version)
+	}
+
+	if p.Action != ActionRemove {
+		return errors.Errorf("unsupported action %q", p.Action)
+	}
+
+	if p.ClusterURL == "" {
+		return errors.New("cluster_url is empty")
+	}
+
+	if p.Group == "" {
+		return errors.New("group is empty")
+	}
+
+	if p.NodeName == "" {
+		return errors.New("node_name is empty")
+	}
+
+	if p.InstanceID == "" {
+		return errors.New("instance_id is empty")
+	}
+
+	if p.TargetGroupARN == "" {
+		return errors.New("target_group_arn is empty")
+	}
+
+	return nil
+}
+
+// Write writes plan to the given writer as JSON
+func (p *Plan) Write(w io.Writer) error {
+	body, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize plan")
+	}
+
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		return errors.Wrap(err, "failed to write plan")
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package plan
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWriteAndLoad(t *testing.T) {
+	p := &Plan{
+		Version:        FormatVersion,
+		Action:         ActionRemove,
+		ClusterURL:     "http://elasticsearch.example.com",
+		Group:          "elasticsearch",
+		NodeName:       "ip-10-0-1-23.ap-northeast-1.compute.internal",
+		InstanceID:     "i-1234abcd",
+		TargetGroupARN: "arn:aws:elasticloadbalancing:ap-northeast-1:012345678901:targetgroup/elasticsearch/0123abcd5678efab",
+		Shards:         12,
+		Steps:          []string{"Detaching instance from target group"},
+		CreatedBy:      "alice",
+		CreatedAt:      time.Date(2017, 3, 20, 10, 15, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	f, err := ioutil.TempFile("", "esnctl-plan")
+	if err != nil {
+		t.Fatalf("failed to create temporary file: %s", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		t.Fatalf("failed to write temporary file: %s", err)
+	}
+	f.Close()
+
+	got, err := Load(f.Name())
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got.InstanceID != p.InstanceID {
+		t.Errorf("instance ID does not match. expected: %q, got: %q", p.InstanceID, got.InstanceID)
+	}
+
+	if !got.CreatedAt.Equal(p.CreatedAt) {
+		t.Errorf("created time does not match. expected: %s, got: %s", p.CreatedAt, got.CreatedAt)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testcases := []struct {
+		plan    Plan
+		invalid bool
+	}{
+		{
+			plan: Plan{
+				Version:        FormatVersion,
+				Action:         ActionRemove,
+				ClusterURL:     "http://elasticsearch.example.com",
+				Group:          "elasticsearch",
+				NodeName:       "ip-10-0-1-23.ap-northeast-1.compute.internal",
+				InstanceID:     "i-1234abcd",
+				TargetGroupARN: "arn:aws:elasticloadbalancing:ap-northeast-1:012345678901:targetgroup/elasticsearch/0123abcd5678efab",
+			},
+			invalid: false,
+		},
+		{
+			plan: Plan{
+				Version:    FormatVersion,
+				Action:     "restart",
+				ClusterURL: "http://elasticsearch.example.com",
+			},
+			invalid: true,
+		},
+		{
+			plan: Plan{
+				Version:    FormatVersion,
+				Action:     ActionRemove,
+				ClusterURL: "http://elasticsearch.example.com",
+				Group:      "elasticsearch",
+				NodeName:   "ip-10-0-1-23.ap-northeast-1.compute.internal",
+			},
+			invalid: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		err := tc.plan.Validate()
+
+		if tc.invalid && err == nil {
+			t.Errorf("error should be raised for %#v", tc.plan)
+		}
+
+		if !tc.invalid && err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+	}
+}
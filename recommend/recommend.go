@@ -0,0 +1,276 @@
+package recommend
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/cost"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+// Target utilization in percent which recommended fleet is sized for
+// Disk target leaves room below the low disk watermark (85%) so that shards can still be allocated after a node is lost
+const (
+	CPUTarget  = 60.0
+	HeapTarget = 70.0
+	DiskTarget = 70.0
+)
+
+// MinNodes represents the minimum number of nodes recommended, so that replicas can be allocated
+const MinNodes = 2
+
+// ChangeThreshold represents savings in percent required to recommend another instance type
+// Replacing every instance is expensive, so small savings are not worth it
+const ChangeThreshold = 10.0
+
+// Spec represents resources of instance type
+type Spec struct {
+	VCPU      int     `json:"vcpu"`
+	MemoryGiB float64 `json:"memory_gib"`
+}
+
+// Specs represents resources of instance types which have bundled price in cost.DefaultPrices
+var Specs = map[string]*Spec{
+	"c5.large":    {VCPU: 2, MemoryGiB: 4},
+	"c5.xlarge":   {VCPU: 4, MemoryGiB: 8},
+	"c5.2xlarge":  {VCPU: 8, MemoryGiB: 16},
+	"c5.4xlarge":  {VCPU: 16, MemoryGiB: 32},
+	"i3.large":    {VCPU: 2, MemoryGiB: 15.25},
+	"i3.xlarge":   {VCPU: 4, MemoryGiB: 30.5},
+	"i3.2xlarge":  {VCPU: 8, MemoryGiB: 61},
+	"i3.4xlarge":  {VCPU: 16, MemoryGiB: 122},
+	"m4.large":    {VCPU: 2, MemoryGiB: 8},
+	"m4.xlarge":   {VCPU: 4, MemoryGiB: 16},
+	"m5.large":    {VCPU: 2, MemoryGiB: 8},
+	"m5.xlarge":   {VCPU: 4, MemoryGiB: 16},
+	"m5.2xlarge":  {VCPU: 8, MemoryGiB: 32},
+	"m5.4xlarge":  {VCPU: 16, MemoryGiB: 64},
+	"r4.large":    {VCPU: 2, MemoryGiB: 15.25},
+	"r4.xlarge":   {VCPU: 4, MemoryGiB: 30.5},
+	"r4.2xlarge":  {VCPU: 8, MemoryGiB: 61},
+	"r5.large":    {VCPU: 2, MemoryGiB: 16},
+	"r5.xlarge":   {VCPU: 4, MemoryGiB: 32},
+	"r5.2xlarge":  {VCPU: 8, MemoryGiB: 64},
+	"r5.4xlarge":  {VCPU: 16, MemoryGiB: 128},
+	"r5d.large":   {VCPU: 2, MemoryGiB: 16},
+	"r5d.xlarge":  {VCPU: 4, MemoryGiB: 32},
+	"r5d.2xlarge": {VCPU: 8, MemoryGiB: 64},
+	"t3.medium":   {VCPU: 2, MemoryGiB: 4},
+	"t3.large":    {VCPU: 2, MemoryGiB: 8},
+	"t3.xlarge":   {VCPU: 4, MemoryGiB: 16},
+}
+
+// Actions of Recommendation
+const (
+	ActionKeep               = "keep"
+	ActionScaleOut           = "scale-out"
+	ActionScaleIn            = "scale-in"
+	ActionChangeInstanceType = "change-instance-type"
+)
+
+// Node represents utilization of node in percent
+type Node struct {
+	Name         string  `json:"name"`
+	InstanceType string  `json:"instance_type"`
+	CPUPercent   float64 `json:"cpu_percent"`
+	HeapPercent  float64 `json:"heap_percent"`
+	DiskPercent  float64 `json:"disk_percent"`
+}
+
+// Recommendation represents recommended size of fleet
+// Heap is assumed to be sized in proportion to memory of instance, e.g. half of it
+type Recommendation struct {
+	Nodes        []*Node `json:"nodes"`
+	InstanceType string  `json:"instance_type"`
+	Count        int     `json:"count"`
+	CPUPercent   float64 `json:"cpu_percent"`
+	HeapPercent  float64 `json:"heap_percent"`
+	DiskPercent  float64 `json:"disk_percent"`
+
+	Action                  string   `json:"action"`
+	RecommendedInstanceType string   `json:"recommended_instance_type"`
+	RecommendedCount        int      `json:"recommended_count"`
+	Reasons                 []string `json:"reasons"`
+
+	// MonthlyChange represents difference of on-demand cost in USD per month, which is negative on savings
+	MonthlyChange float64 `json:"monthly_change"`
+}
+
+// New recommends instance type and number of nodes which keep mean utilization of the given nodes under targets at the lowest cost
+// Disk usage decides the number of nodes, because data volume does not depend on instance type. CPU and heap decide instance type
+func New(nodes []*Node, prices map[string]*cost.Price) (*Recommendation, error) {
+	if len(nodes) == 0 {
+		return nil, exitcode.New(exitcode.Validation, "no node to recommend for")
+	}
+
+	r := &Recommendation{
+		Nodes:   nodes,
+		Count:   len(nodes),
+		Reasons: []string{},
+	}
+
+	sort.Slice(r.Nodes, func(i, j int) bool {
+		return r.Nodes[i].Name < r.Nodes[j].Name
+	})
+
+	types := map[string]int{}
+
+	for _, n := range nodes {
+		r.CPUPercent += n.CPUPercent
+		r.HeapPercent += n.HeapPercent
+		r.DiskPercent += n.DiskPercent
+		types[n.InstanceType]++
+	}
+
+	r.CPUPercent /= float64(r.Count)
+	r.HeapPercent /= float64(r.Count)
+	r.DiskPercent /= float64(r.Count)
+	r.InstanceType = mostCommon(types)
+
+	current, ok := Specs[r.InstanceType]
+	if !ok {
+		return nil, exitcode.Errorf(exitcode.Validation, "spec of %s is unknown", r.InstanceType)
+	}
+
+	if _, ok := prices[r.InstanceType]; !ok {
+		return nil, exitcode.Errorf(exitcode.Validation, "price of %s is unknown. Give it with --prices", r.InstanceType)
+	}
+
+	floor := MinNodes
+	if r.Count < floor {
+		floor = r.Count
+	}
+
+	count := ceil(float64(r.Count) * r.DiskPercent / DiskTarget)
+	if count < floor {
+		count = floor
+	}
+
+	// Total demand of the fleet in vCPUs and GiB of memory at target utilization
+	vcpus := float64(r.Count*current.VCPU) * r.CPUPercent / CPUTarget
+	memory := float64(r.Count) * current.MemoryGiB * r.HeapPercent / HeapTarget
+
+	size := func(t string) int {
+		s := Specs[t]
+
+		c := count
+		if v := ceil(vcpus / float64(s.VCPU)); v > c {
+			c = v
+		}
+
+		if m := ceil(memory / s.MemoryGiB); m > c {
+			c = m
+		}
+
+		return c
+	}
+
+	monthly := func(t string, c int) float64 {
+		return prices[t].OnDemand * cost.HoursPerMonth * float64(c)
+	}
+
+	r.RecommendedInstanceType = r.InstanceType
+	r.RecommendedCount = size(r.InstanceType)
+	best := monthly(r.InstanceType, r.RecommendedCount)
+
+	candidates := []string{}
+
+	for t := range Specs {
+		if _, ok := prices[t]; ok {
+			candidates = append(candidates, t)
+		}
+	}
+
+	sort.Strings(candidates)
+
+	for _, t := range candidates {
+		// Utilization of burstable instances depends on CPU credits, which the stats do not tell
+		if strings.HasPrefix(t, "t") && !strings.HasPrefix(r.InstanceType, "t") {
+			continue
+		}
+
+		c := size(t)
+
+		if m := monthly(t, c); m < best*(1-ChangeThreshold/100) {
+			r.RecommendedInstanceType = t
+			r.RecommendedCount = c
+			best = m
+		}
+	}
+
+	r.MonthlyChange = best - monthly(r.InstanceType, r.Count)
+
+	switch {
+	case r.RecommendedInstanceType != r.InstanceType:
+		r.Action = ActionChangeInstanceType
+	case r.RecommendedCount > r.Count:
+		r.Action = ActionScaleOut
+	case r.RecommendedCount < r.Count:
+		r.Action = ActionScaleIn
+	default:
+		r.Action = ActionKeep
+	}
+
+	for _, u := range []struct {
+		name   string
+		value  float64
+		target float64
+	}{
+		{name: "CPU", value: r.CPUPercent, target: CPUTarget},
+		{name: "heap", value: r.HeapPercent, target: HeapTarget},
+		{name: "disk", value: r.DiskPercent, target: DiskTarget},
+	} {
+		switch {
+		case u.value > u.target:
+			r.Reasons = append(r.Reasons, fmt.Sprintf("mean %s usage %.1f%% is above target %.0f%%", u.name, u.value, u.target))
+		case u.value < u.target/2:
+			r.Reasons = append(r.Reasons, fmt.Sprintf("mean %s usage %.1f%% is below half of target %.0f%%", u.name, u.value, u.target))
+		}
+	}
+
+	return r, nil
+}
+
+// Render prints utilization of nodes followed by the recommendation
+func (r *Recommendation) Render(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tTYPE\tCPU\tHEAP\tDISK")
+
+	for _, n := range r.Nodes {
+		fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%.1f%%\t%.1f%%\n", n.Name, n.InstanceType, n.CPUPercent, n.HeapPercent, n.DiskPercent)
+	}
+
+	fmt.Fprintf(w, "MEAN\t\t%.1f%%\t%.1f%%\t%.1f%%\n", r.CPUPercent, r.HeapPercent, r.DiskPercent)
+	w.Flush()
+
+	fmt.Fprintf(out, "\nCurrent:     %d x %s\n", r.Count, r.InstanceType)
+	fmt.Fprintf(out, "Recommended: %d x %s (%s, %+.2f USD / month)\n", r.RecommendedCount, r.RecommendedInstanceType, r.Action, r.MonthlyChange)
+
+	for _, reason := range r.Reasons {
+		fmt.Fprintf(out, "  - %s\n", reason)
+	}
+}
+
+func ceil(f float64) int {
+	// Round before ceiling so that floating point error does not add a node, e.g. 3.0000000000000004
+	return int(math.Ceil(math.Round(f*1000) / 1000))
+}
+
+// mostCommon returns the key counted the most. The smallest key in lexical order wins on tie
+func mostCommon(counts map[string]int) string {
+	key := ""
+	count := 0
+
+	for k, c := range counts {
+		if c > count || (c == count && k < key) {
+			key = k
+			count = c
+		}
+	}
+
+	return key
+}
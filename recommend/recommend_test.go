@@ -0,0 +1,126 @@
+package recommend
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dtan4/esnctl/cost"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+func testNodes(count int, instanceType string, cpu, heap, disk float64) []*Node {
+	nodes := []*Node{}
+
+	for i := 0; i < count; i++ {
+		nodes = append(nodes, &Node{
+			Name:         "node-" + string(rune('a'+i)),
+			InstanceType: instanceType,
+			CPUPercent:   cpu,
+			HeapPercent:  heap,
+			DiskPercent:  disk,
+		})
+	}
+
+	return nodes
+}
+
+func TestNew(t *testing.T) {
+	testcases := []struct {
+		name         string
+		nodes        []*Node
+		action       string
+		instanceType string
+		count        int
+	}{
+		{
+			name:         "keep",
+			nodes:        testNodes(3, "r5.large", 40, 50, 50),
+			action:       ActionKeep,
+			instanceType: "r5.large",
+			count:        3,
+		},
+		{
+			name:         "disk above target",
+			nodes:        testNodes(3, "r5.large", 40, 50, 90),
+			action:       ActionScaleOut,
+			instanceType: "r5.large",
+			count:        4,
+		},
+		{
+			name:         "underutilized",
+			nodes:        testNodes(6, "r5.large", 10, 20, 20),
+			action:       ActionScaleIn,
+			instanceType: "r5.large",
+			count:        2,
+		},
+		{
+			name:         "heap bound",
+			nodes:        testNodes(3, "m5.large", 20, 90, 40),
+			action:       ActionChangeInstanceType,
+			instanceType: "r5.large",
+			count:        2,
+		},
+	}
+
+	for _, tc := range testcases {
+		r, err := New(tc.nodes, cost.DefaultPrices)
+		if err != nil {
+			t.Fatalf("%s: error should not be raised: %s", tc.name, err)
+		}
+
+		if r.Action != tc.action || r.RecommendedInstanceType != tc.instanceType || r.RecommendedCount != tc.count {
+			t.Errorf("%s: recommendation does not match. expected: %s %d x %s, got: %s %d x %s", tc.name, tc.action, tc.count, tc.instanceType, r.Action, r.RecommendedCount, r.RecommendedInstanceType)
+		}
+	}
+}
+
+func TestNew_monthlyChange(t *testing.T) {
+	r, err := New(testNodes(6, "r5.large", 10, 20, 20), map[string]*cost.Price{"r5.large": {OnDemand: 0.1}})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	// 4 nodes fewer for 730 hours
+	if d := r.MonthlyChange + 292; d > 1e-6 || d < -1e-6 {
+		t.Errorf("monthly change does not match. expected: -292, got: %f", r.MonthlyChange)
+	}
+
+	if len(r.Reasons) != 3 {
+		t.Errorf("every utilization should be reported as below target. got: %q", r.Reasons)
+	}
+}
+
+func TestNew_unknownSpec(t *testing.T) {
+	if _, err := New(testNodes(3, "x1.large", 10, 20, 20), cost.DefaultPrices); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised. got: %v", err)
+	}
+
+	if _, err := New([]*Node{}, cost.DefaultPrices); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised without node. got: %v", err)
+	}
+}
+
+func TestSpecsHavePrice(t *testing.T) {
+	for instanceType := range Specs {
+		if _, ok := cost.DefaultPrices[instanceType]; !ok {
+			t.Errorf("%s has no bundled price", instanceType)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r, err := New(testNodes(3, "r5.large", 40, 50, 90), cost.DefaultPrices)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	var buf bytes.Buffer
+	r.Render(&buf)
+
+	for _, s := range []string{"MEAN", "Current:     3 x r5.large", "Recommended: 4 x r5.large (scale-out, +91.98 USD / month)", "mean disk usage 90.0% is above target 70%"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("output should contain %q. got:\n%s", s, buf.String())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package recovery
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/es/stats"
+)
+
+// Shard represents one shard recovery with its progress
+type Shard struct {
+	Index      string  `json:"index"`
+	Shard      int     `json:"shard"`
+	Type       string  `json:"type"`
+	Stage      string  `json:"stage"`
+	SourceNode string  `json:"source_node"`
+	TargetNode string  `json:"target_node"`
+	Percent    float64 `json:"percent"`
+
+	// Throughput represents average bytes per second since the recovery started
+	Throughput float64 `json:"throughput"`
+}
+
+// Node represents recoveries from and to one node
+type Node struct {
+	Name     string `json:"name"`
+	Inbound  int    `json:"inbound"`
+	Outbound int    `json:"outbound"`
+
+	// InboundThroughput and OutboundThroughput represent the sum of throughput of recoveries in bytes per second
+	InboundThroughput  float64 `json:"inbound_throughput"`
+	OutboundThroughput float64 `json:"outbound_throughput"`
+}
+
+// Report represents active recoveries of cluster
+type Report struct {
+	Shards []*Shard `json:"shards"`
+	Nodes  []*Node  `json:"nodes"`
+
+	// Percent represents recovered bytes of all recoveries in percent
+	Percent float64 `json:"percent"`
+}
+
+// New aggregates throughput of the given recoveries per node
+// Recoveries without source node, e.g. from local store or snapshot, are counted only as inbound
+func New(recoveries []*stats.Recovery) *Report {
+	r := &Report{
+		Shards: []*Shard{},
+		Nodes:  []*Node{},
+	}
+
+	nodes := map[string]*Node{}
+
+	node := func(name string) *Node {
+		n, ok := nodes[name]
+		if !ok {
+			n = &Node{Name: name}
+			nodes[name] = n
+			r.Nodes = append(r.Nodes, n)
+		}
+
+		return n
+	}
+
+	var recovered, total int64
+
+	for _, rec := range recoveries {
+		s := &Shard{
+			Index:      rec.Index,
+			Shard:      rec.Shard,
+			Type:       rec.Type,
+			Stage:      rec.Stage,
+			SourceNode: rec.SourceNode,
+			TargetNode: rec.TargetNode,
+			Percent:    percent(rec.BytesRecovered, rec.BytesTotal),
+		}
+
+		if rec.Time > 0 {
+			s.Throughput = float64(rec.BytesRecovered) / rec.Time.Seconds()
+		}
+
+		r.Shards = append(r.Shards, s)
+
+		recovered += rec.BytesRecovered
+		total += rec.BytesTotal
+
+		if rec.TargetNode != "" {
+			n := node(rec.TargetNode)
+			n.Inbound++
+			n.InboundThroughput += s.Throughput
+		}
+
+		if rec.SourceNode != "" {
+			n := node(rec.SourceNode)
+			n.Outbound++
+			n.OutboundThroughput += s.Throughput
+		}
+	}
+
+	r.Percent = percent(recovered, total)
+
+	sort.Slice(r.Shards, func(i, j int) bool {
+		if r.Shards[i].Index != r.Shards[j].Index {
+			return r.Shards[i].Index < r.Shards[j].Index
+		}
+
+		return r.Shards[i].Shard < r.Shards[j].Shard
+	})
+
+	sort.Slice(r.Nodes, func(i, j int) bool {
+		return r.Nodes[i].Name < r.Nodes[j].Name
+	})
+
+	return r
+}
+
+// Render prints tables of nodes and shards
+func (r *Report) Render(out io.Writer) {
+	if len(r.Shards) == 0 {
+		fmt.Fprintln(out, "No active recovery")
+		return
+	}
+
+	fmt.Fprintf(out, "%d active recoveries, %.1f%% done\n\n", len(r.Shards), r.Percent)
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tIN\tIN/S\tOUT\tOUT/S")
+
+	for _, n := range r.Nodes {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\n", n.Name, n.Inbound, formatRate(n.InboundThroughput), n.Outbound, formatRate(n.OutboundThroughput))
+	}
+
+	w.Flush()
+
+	fmt.Fprintln(out)
+
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tSHARD\tTYPE\tSTAGE\tSOURCE\tTARGET\tDONE\tRATE")
+
+	for _, s := range r.Shards {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%.1f%%\t%s\n", s.Index, s.Shard, s.Type, s.Stage, orDash(s.SourceNode), s.TargetNode, s.Percent, formatRate(s.Throughput))
+	}
+
+	w.Flush()
+}
+
+// formatRate returns human-readable bytes per second in binary units
+func formatRate(bps float64) string {
+	const unit = 1024
+
+	if bps < unit {
+		return fmt.Sprintf("%.0f B/s", bps)
+	}
+
+	div, exp := float64(unit), 0
+
+	for n := bps / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB/s", bps/div, "KMGTPE"[exp])
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func percent(v, total int64) float64 {
+	if total == 0 {
+		return 100
+	}
+
+	return float64(v) / float64(total) * 100
+}
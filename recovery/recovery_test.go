@@ -0,0 +1,68 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/es/stats"
+)
+
+func TestNew(t *testing.T) {
+	r := New([]*stats.Recovery{
+		{Index: "logs", Shard: 1, Type: "peer", Stage: "index", SourceNode: "node-1", TargetNode: "node-2", BytesRecovered: 30 << 20, BytesTotal: 40 << 20, Time: 10 * time.Second},
+		{Index: "logs", Shard: 0, Type: "peer", Stage: "index", SourceNode: "node-1", TargetNode: "node-3", BytesRecovered: 10 << 20, BytesTotal: 40 << 20, Time: 10 * time.Second},
+		{Index: "metrics", Shard: 0, Type: "existing_store", Stage: "translog", TargetNode: "node-3", BytesRecovered: 0, BytesTotal: 0, Time: time.Second},
+	})
+
+	if r.Percent != 50 {
+		t.Errorf("percent of all recoveries does not match. expected: 50, got: %f", r.Percent)
+	}
+
+	if r.Shards[0].Index != "logs" || r.Shards[0].Shard != 0 {
+		t.Errorf("shards should be sorted by index and shard. got: %+v", r.Shards[0])
+	}
+
+	if r.Shards[1].Percent != 75 || r.Shards[1].Throughput != 3<<20 {
+		t.Errorf("percent and throughput of shard do not match. got: %+v", r.Shards[1])
+	}
+
+	expected := []Node{
+		{Name: "node-1", Outbound: 2, OutboundThroughput: 4 << 20},
+		{Name: "node-2", Inbound: 1, InboundThroughput: 3 << 20},
+		{Name: "node-3", Inbound: 2, InboundThroughput: 1 << 20},
+	}
+
+	if len(r.Nodes) != len(expected) {
+		t.Fatalf("number of nodes does not match. expected: %d, got: %d", len(expected), len(r.Nodes))
+	}
+
+	for i, n := range expected {
+		if *r.Nodes[i] != n {
+			t.Errorf("node does not match. expected: %+v, got: %+v", n, *r.Nodes[i])
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	var buf bytes.Buffer
+
+	New([]*stats.Recovery{}).Render(&buf)
+
+	if buf.String() != "No active recovery\n" {
+		t.Errorf("output without recoveries does not match. got: %q", buf.String())
+	}
+
+	buf.Reset()
+
+	New([]*stats.Recovery{
+		{Index: "logs", Shard: 0, Type: "peer", Stage: "index", SourceNode: "node-1", TargetNode: "node-2", BytesRecovered: 15 << 20, BytesTotal: 30 << 20, Time: 10 * time.Second},
+	}).Render(&buf)
+
+	for _, s := range []string{"1 active recoveries, 50.0% done", "node-1  0   0 B/s", "1.5 MiB/s"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("output should contain %q. got: %q", s, buf.String())
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package retry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	stderrors "errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// baseInterval represents wait time before the first retry
+	baseInterval = 200 * time.Millisecond
+	// maxInterval represents upper limit of wait time between retries
+	maxInterval = 10 * time.Second
+)
+
+// idempotentPutSuffixes represents paths of PUT requests which have the same effect however many times they are sent
+var idempotentPutSuffixes = []string{
+	"/_cluster/settings",
+	"/_settings",
+}
+
+// idempotentKey is the context key marking request as idempotent
+type idempotentKey struct{}
+
+// Transport retries requests failed by transient errors with exponential backoff and jitter
+// Transient network errors (see RetryableError), 429 Too Many Requests and 5xx responses are retried, only for requests which are safe to send twice (see Idempotent)
+type Transport struct {
+	// Base is used to send requests. http.DefaultTransport is used if nil
+	Base http.RoundTripper
+	// MaxRetries represents the maximum number of retries. 0 disables retry
+	MaxRetries int
+	// Timeout bounds each attempt including reading response body. 0 means no timeout
+	Timeout time.Duration
+}
+
+// NewClient creates http.Client which retries requests up to maxRetries times
+// Each attempt is bounded by timeout if it is greater than 0
+func NewClient(maxRetries int, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &Transport{
+			MaxRetries: maxRetries,
+			Timeout:    timeout,
+		},
+	}
+}
+
+// cancelBody cancels the request context of the attempt when response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// Backoff returns wait time before the given retry attempt, starting from 0
+// Full jitter is applied, so the returned value is between 0 and the exponential upper limit
+func Backoff(attempt int) time.Duration {
+	d := maxInterval
+
+	if attempt < 32 {
+		if e := baseInterval << uint(attempt); e > 0 && e < maxInterval {
+			d = e
+		}
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// Retryable returns whether the request should be retried for the given response status code
+func Retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// RetryableError returns whether the request failed by the given error may succeed if sent again
+// Only timeouts, reset connections and refused connections are transient. TLS and certificate errors are never retried, since they persist until configuration is fixed
+func RetryableError(err error) bool {
+	var (
+		certInvalid      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		unknownAuthority x509.UnknownAuthorityError
+		recordHeader     tls.RecordHeaderError
+	)
+
+	if stderrors.As(err, &certInvalid) || stderrors.As(err, &hostname) || stderrors.As(err, &unknownAuthority) || stderrors.As(err, &recordHeader) {
+		return false
+	}
+
+	if stderrors.Is(err, syscall.ECONNRESET) || stderrors.Is(err, syscall.ECONNREFUSED) || stderrors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return stderrors.As(err, &netErr) && netErr.Timeout()
+}
+
+// MarkIdempotent returns the request marked as safe to retry regardless of its method
+// Use it for POST and PUT requests whose second attempt has no extra effect even if the first one succeeded
+func MarkIdempotent(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), idempotentKey{}, true))
+}
+
+// Idempotent returns whether the request can be retried after the previous attempt may have reached the server
+// GET, HEAD, OPTIONS and DELETE requests, PUT requests of settings and requests marked by MarkIdempotent are idempotent.
+// The other requests, e.g. _create, _rollover, _reindex and snapshot creation, are sent only once
+func Idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		return true
+	case http.MethodPut:
+		for _, suffix := range idempotentPutSuffixes {
+			if strings.HasSuffix(req.URL.Path, suffix) {
+				return true
+			}
+		}
+	}
+
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+
+	return marked
+}
+
+// RoundTrip implements http.RoundTripper
+// The given request is never modified. Body of every retry is obtained from GetBody, so request whose body cannot be rewound is sent only once
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if t.MaxRetries <= 0 || !Idempotent(req) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.roundTrip(base, req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		r := req.Clone(req.Context())
+
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to rewind request body")
+			}
+
+			r.Body = body
+		}
+
+		resp, err := t.roundTrip(base, r)
+
+		if attempt >= t.MaxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		if err != nil {
+			if !RetryableError(err) {
+				return nil, err
+			}
+		} else {
+			if !Retryable(resp.StatusCode) {
+				return resp, nil
+			}
+
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(Backoff(attempt))
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// roundTrip sends the request once, bounded by Timeout
+func (t *Transport) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if t.Timeout <= 0 {
+		return base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.Timeout)
+
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{
+		ReadCloser: resp.Body,
+		cancel:     cancel,
+	}
+
+	return resp, nil
+}
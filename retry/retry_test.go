@@ -0,0 +1,316 @@
+package retry
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// countingTransport counts requests sent through http.DefaultTransport
+type countingTransport struct {
+	calls int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.calls, 1)
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func init() {
+	baseInterval = time.Millisecond
+	maxInterval = 5 * time.Millisecond
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 100; attempt++ {
+		if d := Backoff(attempt); d < 0 || d > maxInterval {
+			t.Errorf("backoff of attempt %d is out of range. got: %s", attempt, d)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	testcases := []struct {
+		failures   int
+		maxRetries int
+		expected   int
+		calls      int
+	}{
+		{
+			failures:   0,
+			maxRetries: 3,
+			expected:   http.StatusOK,
+			calls:      1,
+		},
+		{
+			failures:   2,
+			maxRetries: 3,
+			expected:   http.StatusOK,
+			calls:      3,
+		},
+		{
+			failures:   5,
+			maxRetries: 3,
+			expected:   http.StatusTooManyRequests,
+			calls:      4,
+		},
+		{
+			failures:   1,
+			maxRetries: 0,
+			expected:   http.StatusTooManyRequests,
+			calls:      1,
+		},
+	}
+
+	for _, tc := range testcases {
+		calls := 0
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+
+			body, _ := ioutil.ReadAll(r.Body)
+			if string(body) != `{"transient":{}}` {
+				t.Errorf("request body should be sent on every attempt. got: %q", body)
+			}
+
+			if calls <= tc.failures {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req, _ := http.NewRequest(http.MethodPut, ts.URL+"/_cluster/settings", strings.NewReader(`{"transient":{}}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := NewClient(tc.maxRetries, 0).Do(req)
+		ts.Close()
+
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.expected {
+			t.Errorf("status code does not match. expected: %d, got: %d", tc.expected, resp.StatusCode)
+		}
+
+		if calls != tc.calls {
+			t.Errorf("number of requests does not match. expected: %d, got: %d", tc.calls, calls)
+		}
+	}
+}
+
+func TestRoundTrip_bodyNotRewindable(t *testing.T) {
+	calls := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	// Body of other type than bytes.Reader, bytes.Buffer and strings.Reader cannot be rewound by GetBody
+	req, _ := http.NewRequest(http.MethodPut, ts.URL+"/_cluster/settings", ioutil.NopCloser(strings.NewReader(`{"transient":{}}`)))
+
+	resp, err := NewClient(3, 0).Do(req)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if calls != 1 {
+		t.Errorf("request whose body cannot be rewound should not be retried. got: %d requests", calls)
+	}
+}
+
+func TestRoundTrip_certificateError(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	base := &countingTransport{}
+	client := &http.Client{
+		Transport: &Transport{
+			Base:       base,
+			MaxRetries: 3,
+		},
+	}
+
+	// Certificate of test server is not trusted by http.DefaultTransport
+	if _, err := client.Get(ts.URL); err == nil {
+		t.Fatalf("error should be raised")
+	}
+
+	if got := atomic.LoadInt32(&base.calls); got != 1 {
+		t.Errorf("certificate error should not be retried. got: %d requests", got)
+	}
+}
+
+func TestRoundTrip_notIdempotent(t *testing.T) {
+	calls := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(3, 0).Post(ts.URL+"/logs-write/_rollover", "application/json", nil)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if calls != 1 {
+		t.Errorf("POST should not be retried. got: %d requests", calls)
+	}
+
+	calls = 0
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/_refresh", nil)
+
+	resp, err = NewClient(3, 0).Do(MarkIdempotent(req))
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if calls != 4 {
+		t.Errorf("POST marked idempotent should be retried. got: %d requests", calls)
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	testcases := []struct {
+		method   string
+		path     string
+		expected bool
+	}{
+		{method: http.MethodGet, path: "/_cluster/health", expected: true},
+		{method: http.MethodDelete, path: "/.esnctl-lock/_doc/cluster", expected: true},
+		{method: http.MethodPut, path: "/_cluster/settings", expected: true},
+		{method: http.MethodPut, path: "/logs-2018.01.01/_settings", expected: true},
+		{method: http.MethodPut, path: "/.esnctl-lock/_create/cluster", expected: false},
+		{method: http.MethodPut, path: "/_snapshot/backup/esnctl-20180101000000", expected: false},
+		{method: http.MethodPost, path: "/_reindex", expected: false},
+		{method: http.MethodPost, path: "/_ml/set_upgrade_mode", expected: false},
+	}
+
+	for _, tc := range testcases {
+		req, _ := http.NewRequest(tc.method, "http://elasticsearch.example.com"+tc.path, nil)
+
+		if got := Idempotent(req); got != tc.expected {
+			t.Errorf("idempotency of %s %s does not match. expected: %t, got: %t", tc.method, tc.path, tc.expected, got)
+		}
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	testcases := []struct {
+		err      error
+		expected bool
+	}{
+		{
+			err:      &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			expected: true,
+		},
+		{
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			expected: true,
+		},
+		{
+			err:      &url.Error{Op: "Get", URL: "http://elasticsearch.example.com", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}},
+			expected: true,
+		},
+		{
+			err:      &url.Error{Op: "Get", URL: "https://elasticsearch.example.com", Err: x509.UnknownAuthorityError{}},
+			expected: false,
+		},
+		{
+			err:      &url.Error{Op: "Get", URL: "https://elasticsearch.example.com", Err: x509.HostnameError{Host: "elasticsearch.example.com"}},
+			expected: false,
+		},
+		{
+			err:      &net.DNSError{Err: "no such host", Name: "elasticsearch.example.com"},
+			expected: false,
+		},
+		{
+			err:      errors.New("unexpected error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		if got := RetryableError(tc.err); got != tc.expected {
+			t.Errorf("retryability of %q does not match. expected: %t, got: %t", tc.err, tc.expected, got)
+		}
+	}
+}
+
+func TestRoundTrip_notRetryable(t *testing.T) {
+	calls := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(3, 0).Get(ts.URL)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if calls != 1 {
+		t.Errorf("404 should not be retried. got: %d requests", calls)
+	}
+}
+
+func TestRoundTrip_timeout(t *testing.T) {
+	var calls int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	resp, err := NewClient(1, 20*time.Millisecond).Get(ts.URL)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("timed out request should be retried. got: %d requests", got)
+	}
+
+	if _, err := NewClient(0, 20*time.Millisecond).Get(ts.URL); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+
+	if _, err := NewClient(0, 20*time.Millisecond).Get(ts.URL); err == nil {
+		t.Errorf("error should be raised on timeout")
+	}
+}
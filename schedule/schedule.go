@@ -0,0 +1,132 @@
+package schedule
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/pkg/errors"
+)
+
+// timeLayouts represents accepted formats of scheduled time, RFC 3339 with optional seconds
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseTime parses scheduled time, e.g. "2024-06-01T02:00Z" or "2024-06-01T11:00:00+09:00"
+func ParseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("invalid time %q, must be in RFC 3339 format, e.g. 2024-06-01T02:00Z", s)
+}
+
+// InWindow returns whether t is in any of the given maintenance windows
+// Window which ends before it starts, e.g. 22:00-02:00, ends on the next day
+func InWindow(windows []*config.MaintenanceWindow, t time.Time) (bool, error) {
+	for _, w := range windows {
+		ok, err := inWindow(w, t)
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Validate returns error if any of the given maintenance windows is invalid
+func Validate(windows []*config.MaintenanceWindow) error {
+	_, err := InWindow(windows, time.Now())
+	return err
+}
+
+func inWindow(w *config.MaintenanceWindow, t time.Time) (bool, error) {
+	loc := time.UTC
+
+	if w.Timezone != "" {
+		l, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid timezone %q of maintenance window", w.Timezone)
+		}
+
+		loc = l
+	}
+
+	startHour, startMin, err := parseClock(w.Start)
+	if err != nil {
+		return false, err
+	}
+
+	endHour, endMin, err := parseClock(w.End)
+	if err != nil {
+		return false, err
+	}
+
+	days := map[time.Weekday]bool{}
+
+	for _, d := range w.Days {
+		key := strings.ToLower(d)
+		if len(key) > 3 {
+			key = key[:3]
+		}
+
+		wd, ok := weekdays[key]
+		if !ok {
+			return false, errors.Errorf("invalid day %q of maintenance window, must be one of sun, mon, tue, wed, thu, fri and sat", d)
+		}
+
+		days[wd] = true
+	}
+
+	local := t.In(loc)
+
+	// Window started on the previous day may still be open
+	for _, offset := range []int{0, -1} {
+		day := local.AddDate(0, 0, offset)
+
+		start := time.Date(day.Year(), day.Month(), day.Day(), startHour, startMin, 0, 0, loc)
+		end := time.Date(day.Year(), day.Month(), day.Day(), endHour, endMin, 0, 0, loc)
+
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+
+		if len(days) > 0 && !days[start.Weekday()] {
+			continue
+		}
+
+		if !t.Before(start) && t.Before(end) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parseClock parses time of day in "15:04" format
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid time of day %q of maintenance window, must be in HH:MM format", s)
+	}
+
+	return t.Hour(), t.Minute(), nil
+}
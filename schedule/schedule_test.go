@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+)
+
+func TestParseTime(t *testing.T) {
+	testcases := []struct {
+		s       string
+		success bool
+	}{
+		{"2024-06-01T02:00Z", true},
+		{"2024-06-01T11:00:00+09:00", true},
+		{"2024-06-01 02:00", false},
+	}
+
+	for _, tc := range testcases {
+		_, err := ParseTime(tc.s)
+
+		if tc.success && err != nil {
+			t.Errorf("error should not be raised for %q: %s", tc.s, err)
+		}
+
+		if !tc.success && err == nil {
+			t.Errorf("error should be raised for %q", tc.s)
+		}
+	}
+}
+
+func TestInWindow(t *testing.T) {
+	windows := []*config.MaintenanceWindow{
+		{
+			Days:  []string{"sat", "sun"},
+			Start: "22:00",
+			End:   "04:00",
+		},
+	}
+
+	testcases := []struct {
+		t        string
+		expected bool
+	}{
+		// Saturday
+		{"2024-06-01T23:00:00Z", true},
+		// Sunday morning, in the window started on Saturday
+		{"2024-06-02T03:59:00Z", true},
+		{"2024-06-02T04:00:00Z", false},
+		// Monday morning, in the window started on Sunday
+		{"2024-06-03T01:00:00Z", true},
+		// Friday night
+		{"2024-05-31T23:00:00Z", false},
+	}
+
+	for _, tc := range testcases {
+		ts, _ := time.Parse(time.RFC3339, tc.t)
+
+		got, err := InWindow(windows, ts)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("result of %s does not match. expected: %t, got: %t", tc.t, tc.expected, got)
+		}
+	}
+
+	if err := Validate([]*config.MaintenanceWindow{{Days: []string{"someday"}, Start: "02:00", End: "04:00"}}); err == nil {
+		t.Errorf("error should be raised for invalid day")
+	}
+}
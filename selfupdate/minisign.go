@@ -0,0 +1,106 @@
+package selfupdate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// legacyAlgorithm represents minisign signature of the whole message with Ed25519, made by minisign -l
+	legacyAlgorithm = "Ed"
+	// prehashedAlgorithm represents minisign signature of BLAKE2b-512 digest of the message, the default of minisign 0.10 and later
+	prehashedAlgorithm = "ED"
+
+	trustedCommentPrefix = "trusted comment: "
+)
+
+// PublicKey represents minisign public key verifying releases, i.e. the second line of minisign.pub
+// It is set at build time by make, and self-update refuses to update esnctl built without it
+var PublicKey string
+
+// minisignKey represents minisign public key
+type minisignKey struct {
+	id  []byte
+	key ed25519.PublicKey
+}
+
+// parsePublicKey parses minisign public key, either the whole minisign.pub or its base64 line
+func parsePublicKey(s string) (*minisignKey, error) {
+	line := ""
+
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+			break
+		}
+	}
+
+	b, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return nil, errors.Wrap(err, "public key is not base64 encoded")
+	}
+
+	if len(b) != 2+8+ed25519.PublicKeySize || string(b[:2]) != legacyAlgorithm {
+		return nil, errors.New("public key is not minisign Ed25519 key")
+	}
+
+	return &minisignKey{
+		id:  b[2:10],
+		key: ed25519.PublicKey(b[10:]),
+	}, nil
+}
+
+// verify verifies minisign signature file (.minisig) of the given message
+// Only signatures made by minisign -l are supported, since BLAKE2b used by prehashed signatures is not in the standard library
+func (k *minisignKey) verify(message, signature []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return errors.New("signature is not in minisign format")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("signature is not in minisign format")
+	}
+
+	switch string(sig[:2]) {
+	case legacyAlgorithm:
+	case prehashedAlgorithm:
+		return errors.New("prehashed signature is not supported, sign with minisign -S -l")
+	default:
+		return errors.Errorf("signature algorithm %q is not supported", sig[:2])
+	}
+
+	if !bytes.Equal(sig[2:10], k.id) {
+		return errors.Errorf("signature is made by key %X, not by the release key %X", reverse(sig[2:10]), reverse(k.id))
+	}
+
+	if !ed25519.Verify(k.key, message, sig[10:]) {
+		return errors.New("signature does not match")
+	}
+
+	// Trusted comment, e.g. timestamp and file name, is signed together with the signature
+	comment := strings.TrimSuffix(strings.TrimPrefix(lines[2], trustedCommentPrefix), "\r")
+
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(k.key, append(append([]byte{}, sig[10:]...), comment...), global) {
+		return errors.New("trusted comment signature does not match")
+	}
+
+	return nil
+}
+
+// reverse returns the given key ID in the byte order minisign prints it
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+
+	return r
+}
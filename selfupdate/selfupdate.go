@@ -0,0 +1,284 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultEndpoint   = "https://api.github.com"
+	defaultRepository = "dtan4/esnctl"
+	binaryName        = "esnctl"
+)
+
+// Client represents client of GitHub Releases
+type Client struct {
+	httpClient *http.Client
+	token      string
+
+	// publicKey verifies signature of checksums file. Defaults to PublicKey
+	publicKey string
+
+	// endpoint and repository are overridden in tests
+	endpoint   string
+	repository string
+}
+
+// Release represents GitHub release
+type Release struct {
+	TagName string   `json:"tag_name"`
+	Assets  []*Asset `json:"assets"`
+}
+
+// Asset represents file attached to GitHub release
+type Asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// New creates new Client object
+// token is used to raise API rate limit, and can be empty
+func New(httpClient *http.Client, token string) *Client {
+	return &Client{
+		httpClient: httpClient,
+		token:      token,
+		publicKey:  PublicKey,
+		endpoint:   defaultEndpoint,
+		repository: defaultRepository,
+	}
+}
+
+// Download downloads archive of the given platform from release, verifies its checksum and returns binary in it
+// Checksums file is trusted only if its minisign signature attached to the release is made by PublicKey
+func (c *Client) Download(release *Release, goos, goarch string) ([]byte, error) {
+	if c.publicKey == "" {
+		return nil, errors.New("release signature cannot be verified, because this esnctl is built without public key. Download the release manually")
+	}
+
+	key, err := parsePublicKey(c.publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid public key")
+	}
+
+	platform := goos + "-" + goarch
+	archiveName := binaryName + "-" + release.TagName + "-" + platform + ".tar.gz"
+	checksumsName := binaryName + "-" + release.TagName + "-checksums.txt"
+	signatureName := checksumsName + ".minisig"
+
+	name := binaryName
+	if goos == "windows" {
+		name += ".exe"
+	}
+
+	archiveAsset := release.asset(archiveName)
+	if archiveAsset == nil {
+		return nil, errors.Errorf("release %s does not have binary for %s", release.TagName, platform)
+	}
+
+	checksumsAsset := release.asset(checksumsName)
+	if checksumsAsset == nil {
+		return nil, errors.Errorf("release %s does not have checksums file %s", release.TagName, checksumsName)
+	}
+
+	signatureAsset := release.asset(signatureName)
+	if signatureAsset == nil {
+		return nil, errors.Errorf("release %s does not have signature file %s", release.TagName, signatureName)
+	}
+
+	checksums, err := c.get(checksumsAsset.BrowserDownloadURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download checksums")
+	}
+
+	signature, err := c.get(signatureAsset.BrowserDownloadURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download signature")
+	}
+
+	if err := key.verify(checksums, signature); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify signature of %s", checksumsName)
+	}
+
+	expected, err := findChecksum(checksums, archiveName)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := c.get(archiveAsset.BrowserDownloadURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download archive")
+	}
+
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != expected {
+		return nil, errors.Errorf("checksum of %s does not match. expected: %s, got: %s", archiveName, expected, got)
+	}
+
+	return extractBinary(archive, platform+"/"+name)
+}
+
+// LatestRelease retrieves the latest release
+func (c *Client) LatestRelease() (*Release, error) {
+	body, err := c.get(c.endpoint + "/repos/" + c.repository + "/releases/latest")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve the latest release")
+	}
+
+	var r Release
+
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, errors.Wrap(err, "invalid response body")
+	}
+
+	return &r, nil
+}
+
+// Replace replaces executable at the given path with binary
+// New binary is written next to the executable, then renamed so that the executable is never left half written
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to get executable info")
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".new")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(binary); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to write new binary")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to write new binary")
+	}
+
+	if err := os.Chmod(f.Name(), info.Mode()); err != nil {
+		return errors.Wrap(err, "failed to change permission of new binary")
+	}
+
+	// Running executable cannot be overwritten on Windows, but can be renamed
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+
+		if err := os.Rename(path, old); err != nil {
+			return errors.Wrap(err, "failed to move current executable")
+		}
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Wrap(err, "failed to replace executable")
+	}
+
+	return nil
+}
+
+func (c *Client) get(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make http request")
+	}
+
+	// Token must not be sent to asset storage redirected from GitHub
+	if c.token != "" && strings.HasPrefix(url, c.endpoint+"/") {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to access to %s", url)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get %s. code: %d, body: %s", url, resp.StatusCode, body)
+	}
+
+	return body, nil
+}
+
+func (r *Release) asset(name string) *Asset {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// extractBinary returns content of the given file in tar.gz archive
+func extractBinary(archive []byte, name string) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open archive")
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read archive")
+		}
+
+		if strings.TrimPrefix(hdr.Name, "./") != name {
+			continue
+		}
+
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to extract %s", name)
+		}
+
+		return b, nil
+	}
+
+	return nil, errors.Errorf("%s is not found in archive", name)
+}
+
+// findChecksum returns SHA-256 checksum of the given file from sha256sum output
+func findChecksum(checksums []byte, name string) (string, error) {
+	sc := bufio.NewScanner(bytes.NewReader(checksums))
+
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		if strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	return "", errors.Errorf("checksum of %s is not found", name)
+}
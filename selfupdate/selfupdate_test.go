@@ -0,0 +1,315 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func makeArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0755,
+			Size: int64(len(content)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tw.Close()
+	gw.Close()
+
+	return buf.Bytes()
+}
+
+// testKeyID represents key ID of minisign key generated in tests
+var testKeyID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+// newTestKey returns minisign public key file and private key signing releases in tests
+func newTestKey(t *testing.T) (string, ed25519.PrivateKey) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := append(append([]byte(legacyAlgorithm), testKeyID...), pub...)
+
+	return "untrusted comment: minisign public key 0807060504030201\n" + base64.StdEncoding.EncodeToString(key) + "\n", priv
+}
+
+// sign returns minisign signature file of the given message, same as made by minisign -S -l
+func sign(priv ed25519.PrivateKey, message []byte) string {
+	sig := ed25519.Sign(priv, message)
+	comment := "timestamp:1700000000\tfile:esnctl-v0.3.0-checksums.txt"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(legacyAlgorithm), testKeyID...), sig...)) + "\n" +
+		trustedCommentPrefix + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+func newTestServer(t *testing.T, archive []byte, checksum string, priv ed25519.PrivateKey) *httptest.Server {
+	mux := http.NewServeMux()
+
+	ts := httptest.NewServer(mux)
+
+	checksums := fmt.Sprintf("0123  esnctl-v0.3.0-darwin-amd64.tar.gz\n%[1]s  esnctl-v0.3.0-linux-amd64.tar.gz\n%[1]s  esnctl-v0.3.0-windows-amd64.tar.gz\n", checksum)
+
+	mux.HandleFunc("/repos/dtan4/esnctl/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fmt.Fprintf(w, `{"tag_name":"v0.3.0","assets":[{"name":"esnctl-v0.3.0-linux-amd64.tar.gz","browser_download_url":"%[1]s/download/archive.tar.gz"},{"name":"esnctl-v0.3.0-windows-amd64.tar.gz","browser_download_url":"%[1]s/download/archive.tar.gz"},{"name":"esnctl-v0.3.0-checksums.txt","browser_download_url":"%[1]s/download/esnctl-v0.3.0-checksums.txt"},{"name":"esnctl-v0.3.0-checksums.txt.minisig","browser_download_url":"%[1]s/download/esnctl-v0.3.0-checksums.txt.minisig"}]}`, ts.URL)
+	})
+	mux.HandleFunc("/download/archive.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/download/esnctl-v0.3.0-checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, checksums)
+	})
+	mux.HandleFunc("/download/esnctl-v0.3.0-checksums.txt.minisig", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, sign(priv, []byte(checksums)))
+	})
+
+	return ts
+}
+
+func TestDownload(t *testing.T) {
+	archive := makeArchive(t, map[string]string{
+		"linux-amd64/README.md": "# esnctl",
+		"linux-amd64/esnctl":    "new binary",
+	})
+	sum := sha256.Sum256(archive)
+
+	pub, priv := newTestKey(t)
+
+	ts := newTestServer(t, archive, hex.EncodeToString(sum[:]), priv)
+	defer ts.Close()
+
+	client := New(&http.Client{}, "t0ken")
+	client.endpoint = ts.URL
+	client.publicKey = pub
+
+	release, err := client.LatestRelease()
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if release.TagName != "v0.3.0" {
+		t.Errorf("tag does not match. expected: %q, got: %q", "v0.3.0", release.TagName)
+	}
+
+	got, err := client.Download(release, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if string(got) != "new binary" {
+		t.Errorf("binary does not match. expected: %q, got: %q", "new binary", got)
+	}
+
+	if _, err := client.Download(release, "windows", "386"); err == nil {
+		t.Errorf("error should be raised for missing platform")
+	}
+}
+
+func TestDownload_checksumMismatch(t *testing.T) {
+	archive := makeArchive(t, map[string]string{
+		"linux-amd64/esnctl": "tampered binary",
+	})
+
+	pub, priv := newTestKey(t)
+
+	ts := newTestServer(t, archive, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", priv)
+	defer ts.Close()
+
+	client := New(&http.Client{}, "t0ken")
+	client.endpoint = ts.URL
+	client.publicKey = pub
+
+	release, err := client.LatestRelease()
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if _, err := client.Download(release, "linux", "amd64"); err == nil {
+		t.Errorf("error should be raised for checksum mismatch")
+	}
+}
+
+func TestDownload_signature(t *testing.T) {
+	archive := makeArchive(t, map[string]string{
+		"linux-amd64/esnctl": "new binary",
+	})
+	sum := sha256.Sum256(archive)
+
+	pub, _ := newTestKey(t)
+	_, other := newTestKey(t)
+
+	// Checksums file is signed by another key, e.g. replaced by attacker
+	ts := newTestServer(t, archive, hex.EncodeToString(sum[:]), other)
+	defer ts.Close()
+
+	client := New(&http.Client{}, "t0ken")
+	client.endpoint = ts.URL
+	client.publicKey = pub
+
+	release, err := client.LatestRelease()
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if _, err := client.Download(release, "linux", "amd64"); err == nil {
+		t.Errorf("error should be raised for signature made by another key")
+	}
+
+	client.publicKey = ""
+
+	if _, err := client.Download(release, "linux", "amd64"); err == nil {
+		t.Errorf("error should be raised without public key")
+	}
+
+	release.Assets = release.Assets[:3]
+	client.publicKey = pub
+
+	if _, err := client.Download(release, "linux", "amd64"); err == nil {
+		t.Errorf("error should be raised for release without signature")
+	}
+}
+
+func TestDownload_windows(t *testing.T) {
+	archive := makeArchive(t, map[string]string{
+		"windows-amd64/esnctl.exe": "new binary",
+	})
+	sum := sha256.Sum256(archive)
+
+	pub, priv := newTestKey(t)
+
+	ts := newTestServer(t, archive, hex.EncodeToString(sum[:]), priv)
+	defer ts.Close()
+
+	client := New(&http.Client{}, "t0ken")
+	client.endpoint = ts.URL
+	client.publicKey = pub
+
+	release, err := client.LatestRelease()
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	got, err := client.Download(release, "windows", "amd64")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if string(got) != "new binary" {
+		t.Errorf("binary does not match. expected: %q, got: %q", "new binary", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv := newTestKey(t)
+
+	key, err := parsePublicKey(pub)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	message := []byte("checksums")
+	signature := sign(priv, message)
+
+	if err := key.verify(message, []byte(signature)); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	if err := key.verify([]byte("tampered checksums"), []byte(signature)); err == nil {
+		t.Errorf("error should be raised for tampered message")
+	}
+
+	// Trusted comment cannot be changed without the secret key
+	tampered := strings.Replace(signature, "timestamp:1700000000", "timestamp:1800000000", 1)
+
+	if err := key.verify(message, []byte(tampered)); err == nil {
+		t.Errorf("error should be raised for tampered trusted comment")
+	}
+
+	// Prehashed signature is made by minisign without -l
+	lines := strings.Split(signature, "\n")
+	b, _ := base64.StdEncoding.DecodeString(lines[1])
+	copy(b, prehashedAlgorithm)
+	lines[1] = base64.StdEncoding.EncodeToString(b)
+
+	if err := key.verify(message, []byte(strings.Join(lines, "\n"))); err == nil {
+		t.Errorf("error should be raised for prehashed signature")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "selfupdate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "esnctl")
+
+	if err := ioutil.WriteFile(path, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Replace(path, []byte("new binary")); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "new binary" {
+		t.Errorf("binary does not match. expected: %q, got: %q", "new binary", b)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("permission does not match. expected: %v, got: %v", os.FileMode(0755), info.Mode().Perm())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 {
+		t.Errorf("temporary file should be removed. got: %d files", len(files))
+	}
+}
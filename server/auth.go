@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RoleViewer can read clusters and operations
+	RoleViewer = "viewer"
+	// RoleOperator can also add and drain nodes
+	RoleOperator = "operator"
+	// RoleAdmin can also remove nodes
+	RoleAdmin = "admin"
+)
+
+// roleActions represents actions allowed for each role
+var roleActions = map[string][]string{
+	RoleViewer:   {},
+	RoleOperator: {ActionAdd, ActionDrain},
+	RoleAdmin:    {ActionAdd, ActionDrain, ActionRemove},
+}
+
+type principalKey struct{}
+
+// Principal represents authenticated client of API
+type Principal struct {
+	Name     string
+	Role     string
+	Clusters []string
+}
+
+// NewPrincipals returns principals keyed by SHA-256 digest of their tokens
+func NewPrincipals(api *config.API) (map[string]*Principal, error) {
+	principals := map[string]*Principal{}
+
+	if api == nil {
+		return principals, nil
+	}
+
+	for _, t := range api.Tokens {
+		if t.Name == "" {
+			return nil, errors.New("name of API token must be specified")
+		}
+
+		if _, ok := roleActions[t.Role]; !ok {
+			return nil, errors.Errorf("role of API token %q must be one of %s, %s and %s", t.Name, RoleViewer, RoleOperator, RoleAdmin)
+		}
+
+		digest := strings.ToLower(t.SHA256)
+
+		if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+			return nil, errors.Errorf("sha256 of API token %q must be hex-encoded SHA-256 digest", t.Name)
+		}
+
+		if _, ok := principals[digest]; ok {
+			return nil, errors.Errorf("API token %q is duplicated", t.Name)
+		}
+
+		principals[digest] = &Principal{
+			Name:     t.Name,
+			Role:     t.Role,
+			Clusters: t.Clusters,
+		}
+	}
+
+	return principals, nil
+}
+
+// CanAccess returns whether the principal can access the given cluster
+func (p *Principal) CanAccess(cluster string) bool {
+	if p == nil || len(p.Clusters) == 0 {
+		return true
+	}
+
+	for _, c := range p.Clusters {
+		if c == cluster {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanExecute returns whether the principal can execute the given action against the given cluster
+func (p *Principal) CanExecute(cluster, action string) bool {
+	if p == nil {
+		return true
+	}
+
+	if !p.CanAccess(cluster) {
+		return false
+	}
+
+	for _, a := range roleActions[p.Role] {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanOverrideWindow returns whether the principal can run operations outside maintenance windows
+func (p *Principal) CanOverrideWindow() bool {
+	return p == nil || p.Role == RoleAdmin
+}
+
+// authenticate rejects request without valid bearer token if any principal is configured
+// Authenticated principal is passed to next through request context
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.Principals) == 0 {
+			next(w, r)
+			return
+		}
+
+		p, err := s.lookupPrincipal(r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	}
+}
+
+// lookupPrincipal returns the principal of bearer token in the given Authorization header value
+func (s *Server) lookupPrincipal(authorization string) (*Principal, error) {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == "" || token == authorization {
+		return nil, errors.New("bearer token is required")
+	}
+
+	digest := sha256.Sum256([]byte(token))
+
+	p, ok := s.Principals[hex.EncodeToString(digest[:])]
+	if !ok {
+		return nil, errors.New("bearer token is invalid")
+	}
+
+	return p, nil
+}
+
+// principal returns the authenticated principal of the request, or nil if authentication is disabled
+func principal(r *http.Request) *Principal {
+	return principalFromContext(r.Context())
+}
+
+// principalFromContext returns the authenticated principal stored in ctx, or nil if authentication is disabled
+func principalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+
+	return p
+}
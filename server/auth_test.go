@@ -0,0 +1,153 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/operation"
+)
+
+func digest(token string) string {
+	d := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(d[:])
+}
+
+func TestNewPrincipals(t *testing.T) {
+	testcases := []struct {
+		token   *config.Token
+		success bool
+	}{
+		{
+			token:   &config.Token{Name: "alice", SHA256: digest("alice-token"), Role: RoleAdmin},
+			success: true,
+		},
+		{
+			token:   &config.Token{Name: "bob", SHA256: digest("bob-token"), Role: "root"},
+			success: false,
+		},
+		{
+			token:   &config.Token{Name: "carol", SHA256: "carol-token", Role: RoleViewer},
+			success: false,
+		},
+		{
+			token:   &config.Token{SHA256: digest("dave-token"), Role: RoleViewer},
+			success: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		_, err := NewPrincipals(&config.API{Tokens: []*config.Token{tc.token}})
+
+		if tc.success && err != nil {
+			t.Errorf("error should not be raised for %+v: %s", tc.token, err)
+		}
+
+		if !tc.success && err == nil {
+			t.Errorf("error should be raised for %+v", tc.token)
+		}
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	c := &config.Config{
+		Clusters: map[string]*config.Cluster{
+			"logs": {
+				ClusterURL: "http://logs.example.com",
+				Group:      "elasticsearch-logs",
+			},
+			"search": {
+				ClusterURL: "http://search.example.com",
+				Group:      "elasticsearch-search",
+			},
+		},
+	}
+
+	principals, err := NewPrincipals(&config.API{
+		Tokens: []*config.Token{
+			{Name: "viewer", SHA256: digest("viewer-token"), Role: RoleViewer},
+			{Name: "operator", SHA256: digest("operator-token"), Role: RoleOperator, Clusters: []string{"logs"}},
+			{Name: "admin", SHA256: digest("admin-token"), Role: RoleAdmin},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	s := New(c, func(op *operation.Operation, cluster *config.Cluster, req *Request) error {
+		return nil
+	})
+	s.Principals = principals
+
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	testcases := []struct {
+		token string
+		path  string
+		body  string
+		code  int
+	}{
+		{"", "/clusters/logs/add", `{"count":1}`, http.StatusUnauthorized},
+		{"unknown-token", "/clusters/logs/add", `{"count":1}`, http.StatusUnauthorized},
+		{"viewer-token", "/clusters/logs/add", `{"count":1}`, http.StatusForbidden},
+		{"operator-token", "/clusters/search/add", `{"count":1}`, http.StatusForbidden},
+		{"operator-token", "/clusters/logs/remove", `{"node_name":"ip-10-0-1-21.ap-northeast-1.compute.internal"}`, http.StatusForbidden},
+		{"operator-token", "/clusters/logs/add", `{"count":1}`, http.StatusAccepted},
+		{"admin-token", "/clusters/search/remove", `{"node_name":"ip-10-0-2-21.ap-northeast-1.compute.internal"}`, http.StatusAccepted},
+	}
+
+	for _, tc := range testcases {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+tc.path, strings.NewReader(tc.body))
+
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		var status Status
+
+		json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.code {
+			t.Errorf("status code of %s with %q does not match. expected: %d, got: %d", tc.path, tc.token, tc.code, resp.StatusCode)
+			continue
+		}
+
+		if tc.code == http.StatusAccepted {
+			if expected := strings.TrimSuffix(tc.token, "-token"); status.User != expected {
+				t.Errorf("operation user does not match. expected: %q, got: %q", expected, status.User)
+			}
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/operations", nil)
+	req.Header.Set("Authorization", "Bearer operator-token")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var statuses []*Status
+
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(statuses) != 1 {
+		t.Errorf("operations of inaccessible clusters should be hidden. expected: 1, got: %d", len(statuses))
+	}
+}
@@ -0,0 +1,241 @@
+package server
+
+// dashboardHTML is single page served at /, built on the API of this server
+// Operations are confirmed before being requested, and their progress is followed through /operations/{id}/events
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>esnctl</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f4f4f4; }
+td.num { text-align: right; }
+button { margin-right: 4px; }
+button.danger { color: #b00; }
+#log { background: #111; color: #ddd; padding: 1em; height: 16em; overflow-y: scroll; font-family: monospace; white-space: pre-wrap; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>esnctl</h1>
+
+<p>
+Cluster: <select id="cluster"></select>
+<button id="reload">Reload</button>
+<button id="add">Add nodes...</button>
+Token: <input id="token" type="password" size="24">
+</p>
+<p id="message"></p>
+
+<table id="nodes">
+<thead><tr><th>Node</th><th>Instance</th><th>AZ</th><th>State</th><th>Shards</th><th>Disk</th><th></th></tr></thead>
+<tbody></tbody>
+</table>
+
+<h2>Operations</h2>
+<table id="operations">
+<thead><tr><th>ID</th><th>Command</th><th>Node</th><th>Status</th><th>Started at</th><th></th></tr></thead>
+<tbody></tbody>
+</table>
+
+<h2>Log</h2>
+<div id="log"></div>
+
+<script>
+(function() {
+  var clusterSelect = document.getElementById("cluster");
+  var message = document.getElementById("message");
+  var log = document.getElementById("log");
+  var tokenInput = document.getElementById("token");
+
+  tokenInput.value = window.localStorage.getItem("esnctl-token") || "";
+  tokenInput.onchange = function() {
+    window.localStorage.setItem("esnctl-token", tokenInput.value);
+    loadClusters().then(loadNodes).catch(showError);
+    loadOperations().catch(showError);
+  };
+
+  function headers() {
+    var h = {};
+    if (tokenInput.value) {
+      h["Authorization"] = "Bearer " + tokenInput.value;
+    }
+    return h;
+  }
+
+  function el(tag, text) {
+    var e = document.createElement(tag);
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    return e;
+  }
+
+  function showError(err) {
+    message.className = "error";
+    message.textContent = String(err);
+  }
+
+  function api(method, path, body) {
+    var opts = { method: method, headers: headers() };
+    if (body !== undefined) {
+      opts.headers["Content-Type"] = "application/json";
+      opts.body = JSON.stringify(body);
+    }
+    return fetch(path, opts).then(function(resp) {
+      return resp.json().then(function(v) {
+        if (!resp.ok) {
+          throw new Error(v.error || resp.statusText);
+        }
+        return v;
+      });
+    });
+  }
+
+  function loadClusters() {
+    return api("GET", "/clusters").then(function(clusters) {
+      clusterSelect.textContent = "";
+      clusters.forEach(function(c) {
+        var o = el("option", c.name + " (" + c.cluster_url + ", " + c.group + ")");
+        o.value = c.name;
+        clusterSelect.appendChild(o);
+      });
+    });
+  }
+
+  function loadNodes() {
+    var tbody = document.querySelector("#nodes tbody");
+    var cluster = clusterSelect.value;
+    if (!cluster) {
+      return Promise.resolve();
+    }
+    return api("GET", "/clusters/" + encodeURIComponent(cluster) + "/nodes").then(function(nodes) {
+      tbody.textContent = "";
+      nodes.forEach(function(n) {
+        var tr = el("tr");
+        tr.appendChild(el("td", n.name));
+        tr.appendChild(el("td", n.instance_id || "-"));
+        tr.appendChild(el("td", n.availability_zone || "-"));
+        tr.appendChild(el("td", n.lifecycle_state || "-"));
+        var shards = el("td", String(n.shards));
+        shards.className = "num";
+        tr.appendChild(shards);
+        var disk = el("td", n.disk_usage.toFixed(1) + "%");
+        disk.className = "num";
+        tr.appendChild(disk);
+        var actions = el("td");
+        if (n.instance_id) {
+          ["drain", "remove"].forEach(function(action) {
+            var b = el("button", action);
+            if (action === "remove") {
+              b.className = "danger";
+            }
+            b.onclick = function() { request(action, { node_name: n.name }, action + " " + n.name); };
+            actions.appendChild(b);
+          });
+        }
+        tr.appendChild(actions);
+        tbody.appendChild(tr);
+      });
+    });
+  }
+
+  function loadOperations() {
+    var tbody = document.querySelector("#operations tbody");
+    return api("GET", "/operations").then(function(ops) {
+      tbody.textContent = "";
+      ops.slice().reverse().forEach(function(op) {
+        var tr = el("tr");
+        tr.appendChild(el("td", op.id));
+        tr.appendChild(el("td", op.command));
+        tr.appendChild(el("td", op.node || "-"));
+        tr.appendChild(el("td", op.status));
+        tr.appendChild(el("td", op.started_at));
+        var td = el("td");
+        var b = el("button", "log");
+        b.onclick = function() { follow(op.id); };
+        td.appendChild(b);
+        tr.appendChild(td);
+        tbody.appendChild(tr);
+      });
+    });
+  }
+
+  function request(action, body, description) {
+    var cluster = clusterSelect.value;
+    if (!window.confirm(description + " in cluster " + cluster + "?")) {
+      return;
+    }
+    api("POST", "/clusters/" + encodeURIComponent(cluster) + "/" + action, body).then(function(status) {
+      message.className = "";
+      message.textContent = "Requested " + description + " (operation " + status.id + ")";
+      loadOperations();
+      follow(status.id);
+    }).catch(showError);
+  }
+
+  function follow(id) {
+    log.textContent = "";
+    fetch("/operations/" + encodeURIComponent(id) + "/events", { headers: headers() }).then(function(resp) {
+      var reader = resp.body.getReader();
+      var decoder = new TextDecoder();
+      var buf = "";
+      function read() {
+        return reader.read().then(function(r) {
+          if (r.done) {
+            loadOperations();
+            loadNodes().catch(showError);
+            return;
+          }
+          buf += decoder.decode(r.value, { stream: true });
+          var lines = buf.split("\n");
+          buf = lines.pop();
+          lines.forEach(function(line) {
+            if (!line) {
+              return;
+            }
+            var e = JSON.parse(line);
+            var text = e.time + " " + e.status;
+            if (e.phase) {
+              text += " " + e.phase;
+            }
+            if (e.error) {
+              text += " " + e.error;
+            }
+            log.textContent += text + "\n";
+            log.scrollTop = log.scrollHeight;
+          });
+          return read();
+        });
+      }
+      return read();
+    }).catch(showError);
+  }
+
+  document.getElementById("reload").onclick = function() {
+    loadNodes().catch(showError);
+    loadOperations().catch(showError);
+  };
+
+  document.getElementById("add").onclick = function() {
+    var count = parseInt(window.prompt("Number of nodes to add", "1"), 10);
+    if (!(count > 0)) {
+      return;
+    }
+    request("add", { count: count }, "add " + count + " node(s)");
+  };
+
+  clusterSelect.onchange = function() {
+    loadNodes().catch(showError);
+  };
+
+  loadClusters().then(loadNodes).catch(showError);
+  loadOperations().catch(showError);
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dtan4/esnctl/server/serverpb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcCodes maps HTTP status codes of rejected requests to gRPC status codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.FailedPrecondition,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// grpcService serves Operations service of esnctl.proto with the operations queue of Server
+type grpcService struct {
+	s *Server
+}
+
+// GRPCServer returns gRPC server serving Operations service
+// Clients authenticate with "authorization: Bearer <token>" metadata, same tokens and roles as HTTP API
+func (s *Server) GRPCServer() *grpc.Server {
+	g := grpc.NewServer(
+		grpc.ConnectionTimeout(readHeaderTimeout),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: idleTimeout,
+		}),
+		grpc.UnaryInterceptor(s.authenticateUnary),
+		grpc.StreamInterceptor(s.authenticateStream),
+	)
+
+	serverpb.RegisterOperationsServer(g, &grpcService{s: s})
+
+	return g
+}
+
+// ListenAndServeGRPC starts gRPC server on the given address
+func (s *Server) ListenAndServeGRPC(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", addr)
+	}
+
+	return s.GRPCServer().Serve(l)
+}
+
+// authenticateGRPC returns ctx with the principal of bearer token in metadata if any principal is configured
+func (s *Server) authenticateGRPC(ctx context.Context) (context.Context, error) {
+	if len(s.Principals) == 0 {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	authorization := ""
+	if values := md.Get("authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+
+	p, err := s.lookupPrincipal(authorization)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return context.WithValue(ctx, principalKey{}, p), nil
+}
+
+func (s *Server) authenticateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticateGRPC(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) authenticateStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticateGRPC(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticatedStream passes context with authenticated principal to stream handler
+type authenticatedStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// Submit queues the operation, or schedules it if at is given
+func (g *grpcService) Submit(ctx context.Context, req *serverpb.SubmitRequest) (*serverpb.OperationStatus, error) {
+	r := &Request{
+		Action:         req.Action,
+		NodeName:       req.NodeName,
+		Count:          int(req.Count),
+		OverrideWindow: req.OverrideWindow,
+	}
+
+	if req.At != nil {
+		at := req.At.AsTime()
+		r.At = &at
+	}
+
+	st, err := g.s.submitRequest(principalFromContext(ctx), req.Cluster, r)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return toOperationStatus(st), nil
+}
+
+// Get returns status of the operation
+func (g *grpcService) Get(ctx context.Context, req *serverpb.GetRequest) (*serverpb.OperationStatus, error) {
+	_, st, err := g.s.findJob(principalFromContext(ctx), req.Id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return toOperationStatus(st), nil
+}
+
+// List returns operations in the order they were requested
+func (g *grpcService) List(ctx context.Context, req *serverpb.ListRequest) (*serverpb.ListResponse, error) {
+	p := principalFromContext(ctx)
+	resp := &serverpb.ListResponse{}
+
+	g.s.mu.Lock()
+	defer g.s.mu.Unlock()
+
+	for _, id := range g.s.order {
+		if j := g.s.jobs[id]; p.CanAccess(j.name) {
+			resp.Operations = append(resp.Operations, toOperationStatus(g.s.status(j)))
+		}
+	}
+
+	return resp, nil
+}
+
+// Watch streams status changes and phase starts of the operation until it finishes
+func (g *grpcService) Watch(req *serverpb.WatchRequest, stream grpc.ServerStreamingServer[serverpb.Event]) error {
+	j, _, err := g.s.findJob(principalFromContext(stream.Context()), req.Id)
+	if err != nil {
+		return grpcError(err)
+	}
+
+	err = g.s.watch(stream.Context(), j, func(e *Event) error {
+		return stream.Send(&serverpb.Event{
+			Status: e.Status,
+			Phase:  e.Phase,
+			Error:  e.Error,
+			Time:   timestamp(e.Time),
+		})
+	})
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return status.FromContextError(err).Err()
+	}
+
+	return err
+}
+
+// grpcError converts error of request into gRPC status error
+func grpcError(err error) error {
+	code, ok := grpcCodes[errorCode(err)]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	return status.Error(code, err.Error())
+}
+
+func toOperationStatus(st *Status) *serverpb.OperationStatus {
+	phases := make([]*serverpb.Phase, 0, len(st.Phases))
+
+	for _, p := range st.Phases {
+		phases = append(phases, &serverpb.Phase{
+			Name:       p.Name,
+			StartedAt:  timestamp(p.StartedAt),
+			FinishedAt: timestamp(p.FinishedAt),
+		})
+	}
+
+	return &serverpb.OperationStatus{
+		Id:         st.ID,
+		Status:     st.Status,
+		Command:    st.Command,
+		User:       st.User,
+		Cluster:    st.Cluster,
+		Group:      st.Group,
+		Node:       st.Node,
+		Phases:     phases,
+		StartedAt:  timestamp(st.StartedAt),
+		FinishedAt: timestamp(st.FinishedAt),
+		Error:      st.Error,
+	}
+}
+
+// timestamp returns nil for zero time, e.g. finished_at of running operation
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
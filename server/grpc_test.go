@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/server/serverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestGRPCClient(t *testing.T, s *Server) serverpb.OperationsClient {
+	l := bufconn.Listen(1024 * 1024)
+	g := s.GRPCServer()
+
+	go g.Serve(l)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return serverpb.NewOperationsClient(conn)
+}
+
+func newTestGRPCServer(run RunFunc) *Server {
+	c := &config.Config{
+		Clusters: map[string]*config.Cluster{
+			"logs": {
+				ClusterURL: "http://logs.example.com",
+				Group:      "elasticsearch-logs",
+			},
+		},
+	}
+
+	return New(c, run)
+}
+
+func TestGRPC_submit(t *testing.T) {
+	var got *Request
+
+	client := newTestGRPCClient(t, newTestGRPCServer(func(op *operation.Operation, cluster *config.Cluster, req *Request) error {
+		got = req
+		op.Phase("Detaching instance from target group")
+		return nil
+	}))
+
+	ctx := context.Background()
+
+	submitted, err := client.Submit(ctx, &serverpb.SubmitRequest{
+		Cluster:  "logs",
+		Action:   ActionRemove,
+		NodeName: "ip-10-0-1-21.ap-northeast-1.compute.internal",
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if submitted.Command != ActionRemove || submitted.Node != "ip-10-0-1-21.ap-northeast-1.compute.internal" {
+		t.Errorf("submitted operation does not match. got: %+v", submitted)
+	}
+
+	var st *serverpb.OperationStatus
+
+	for i := 0; i < 100; i++ {
+		st, err = client.Get(ctx, &serverpb.GetRequest{Id: submitted.Id})
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		if st.Status == StatusSucceeded || st.Status == StatusFailed {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if st.Status != StatusSucceeded {
+		t.Fatalf("operation should succeed. got: %+v", st)
+	}
+
+	if len(st.Phases) != 1 || st.Phases[0].Name != "Detaching instance from target group" || st.Phases[0].StartedAt == nil {
+		t.Errorf("phases do not match. got: %v", st.Phases)
+	}
+
+	if got == nil || got.Action != ActionRemove || got.NodeName != "ip-10-0-1-21.ap-northeast-1.compute.internal" {
+		t.Errorf("request does not match. got: %+v", got)
+	}
+
+	list, err := client.List(ctx, &serverpb.ListRequest{})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(list.Operations) != 1 || list.Operations[0].Id != submitted.Id {
+		t.Errorf("operations do not match. got: %v", list.Operations)
+	}
+}
+
+func TestGRPC_badRequest(t *testing.T) {
+	client := newTestGRPCClient(t, newTestGRPCServer(func(op *operation.Operation, cluster *config.Cluster, req *Request) error {
+		return nil
+	}))
+
+	testcases := []struct {
+		req  *serverpb.SubmitRequest
+		code codes.Code
+	}{
+		{
+			req:  &serverpb.SubmitRequest{Cluster: "logs", Action: ActionAdd},
+			code: codes.InvalidArgument,
+		},
+		{
+			req:  &serverpb.SubmitRequest{Cluster: "metrics", Action: ActionAdd, Count: 1},
+			code: codes.NotFound,
+		},
+		{
+			req:  &serverpb.SubmitRequest{Cluster: "logs", Action: "reboot"},
+			code: codes.NotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		_, err := client.Submit(context.Background(), tc.req)
+
+		if got := status.Code(err); got != tc.code {
+			t.Errorf("code should be %s for %+v. got: %s (%v)", tc.code, tc.req, got, err)
+		}
+	}
+
+	if _, err := client.Get(context.Background(), &serverpb.GetRequest{Id: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Errorf("code should be NotFound for unknown operation. got: %v", err)
+	}
+}
+
+func TestGRPC_watch(t *testing.T) {
+	eventInterval = 10 * time.Millisecond
+
+	start := make(chan struct{})
+
+	client := newTestGRPCClient(t, newTestGRPCServer(func(op *operation.Operation, cluster *config.Cluster, req *Request) error {
+		<-start
+		op.Phase("Detaching instance from target group")
+		op.Phase("Shutting down target node")
+		return nil
+	}))
+
+	ctx := context.Background()
+
+	submitted, err := client.Submit(ctx, &serverpb.SubmitRequest{
+		Cluster:  "logs",
+		Action:   ActionRemove,
+		NodeName: "ip-10-0-1-21.ap-northeast-1.compute.internal",
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	stream, err := client.Watch(ctx, &serverpb.WatchRequest{Id: submitted.Id})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	close(start)
+
+	got := []string{}
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		if event.Phase != "" {
+			got = append(got, event.Phase)
+		} else {
+			got = append(got, event.Status)
+		}
+	}
+
+	if len(got) < 3 {
+		t.Fatalf("too few events. got: %v", got)
+	}
+
+	expected := []string{"Detaching instance from target group", "Shutting down target node", StatusSucceeded}
+
+	if tail := got[len(got)-3:]; strings.Join(tail, ",") != strings.Join(expected, ",") {
+		t.Errorf("events do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestGRPC_authorization(t *testing.T) {
+	s := newTestGRPCServer(func(op *operation.Operation, cluster *config.Cluster, req *Request) error {
+		return nil
+	})
+
+	principals, err := NewPrincipals(&config.API{
+		Tokens: []*config.Token{
+			{Name: "viewer", SHA256: digest("viewer-token"), Role: RoleViewer},
+			{Name: "operator", SHA256: digest("operator-token"), Role: RoleOperator},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	s.Principals = principals
+
+	client := newTestGRPCClient(t, s)
+
+	req := &serverpb.SubmitRequest{Cluster: "logs", Action: ActionAdd, Count: 1}
+
+	testcases := []struct {
+		authorization string
+		code          codes.Code
+	}{
+		{
+			authorization: "",
+			code:          codes.Unauthenticated,
+		},
+		{
+			authorization: "Bearer wrong-token",
+			code:          codes.Unauthenticated,
+		},
+		{
+			authorization: "Bearer viewer-token",
+			code:          codes.PermissionDenied,
+		},
+		{
+			authorization: "Bearer operator-token",
+			code:          codes.OK,
+		},
+	}
+
+	for _, tc := range testcases {
+		ctx := context.Background()
+		if tc.authorization != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.authorization)
+		}
+
+		st, err := client.Submit(ctx, req)
+
+		if got := status.Code(err); got != tc.code {
+			t.Errorf("code should be %s for %q. got: %s (%v)", tc.code, tc.authorization, got, err)
+		}
+
+		if err == nil && st.User != "operator" {
+			t.Errorf("operation should be recorded as executed by operator. got: %q", st.User)
+		}
+	}
+
+	stream, err := client.Watch(context.Background(), &serverpb.WatchRequest{Id: "unknown"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("code should be Unauthenticated for stream without token. got: %v", err)
+	}
+}
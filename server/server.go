@@ -0,0 +1,731 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dtan4/esnctl/config"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/schedule"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ActionAdd represents node addition
+	ActionAdd = "add"
+	// ActionRemove represents node removal
+	ActionRemove = "remove"
+	// ActionDrain represents moving shards and connections out of node without shutting it down
+	ActionDrain = "drain"
+
+	// StatusScheduled represents that the operation is waiting for the requested time
+	StatusScheduled = "scheduled"
+	// StatusQueued represents that the operation is waiting for preceding operations
+	StatusQueued = "queued"
+	// StatusRunning represents that the operation is being executed
+	StatusRunning = "running"
+	// StatusSucceeded represents that the operation finished successfully
+	StatusSucceeded = "succeeded"
+	// StatusFailed represents that the operation finished with error
+	StatusFailed = "failed"
+
+	queueSize = 100
+)
+
+// eventInterval represents how often operation progress is checked while streaming events
+var eventInterval = 1 * time.Second
+
+const (
+	// readHeaderTimeout bounds reading request headers, so that slow clients cannot hold connections open
+	readHeaderTimeout = 10 * time.Second
+	// readTimeout bounds reading the whole request including body
+	readTimeout = 30 * time.Second
+	// idleTimeout closes keep-alive connections left unused
+	idleTimeout = 2 * time.Minute
+)
+
+// Request represents parameters of operation requested via API
+type Request struct {
+	Action   string `json:"-"`
+	NodeName string `json:"node_name"`
+	Count    int    `json:"count"`
+
+	// At schedules the operation. It is queued at the given time instead of immediately
+	At *time.Time `json:"at,omitempty"`
+
+	// OverrideWindow runs the operation outside maintenance windows. Only admin can request it
+	OverrideWindow bool `json:"override_window,omitempty"`
+}
+
+// RunFunc executes the requested operation against the given cluster
+type RunFunc func(op *operation.Operation, cluster *config.Cluster, req *Request) error
+
+// NodesFunc lists nodes of the given cluster
+type NodesFunc func(cluster *config.Cluster) ([]*Node, error)
+
+// ClusterInfo represents configured cluster returned by API
+type ClusterInfo struct {
+	Name       string `json:"name"`
+	ClusterURL string `json:"cluster_url"`
+	Group      string `json:"group"`
+	Region     string `json:"region,omitempty"`
+}
+
+// Node represents node of cluster returned by API
+type Node struct {
+	Name             string  `json:"name"`
+	InstanceID       string  `json:"instance_id,omitempty"`
+	AvailabilityZone string  `json:"availability_zone,omitempty"`
+	LifecycleState   string  `json:"lifecycle_state,omitempty"`
+	Shards           int     `json:"shards"`
+	DiskUsage        float64 `json:"disk_usage"`
+}
+
+// Status represents operation status returned by API
+type Status struct {
+	Status string `json:"status"`
+	*operation.Operation
+}
+
+// Event represents progress of operation streamed to client
+type Event struct {
+	Status string    `json:"status"`
+	Phase  string    `json:"phase,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Metrics represents collector of operation metrics served at /metrics
+type Metrics interface {
+	http.Handler
+
+	ObserveOperation(cluster string, op *operation.Operation)
+}
+
+// CheckFunc returns error if the dependency checked is not available
+type CheckFunc func() error
+
+// Readiness represents result of readiness checks returned by /readyz
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type job struct {
+	op      *operation.Operation
+	name    string
+	cluster *config.Cluster
+	req     *Request
+	status  string
+}
+
+// Server represents esnctl HTTP API server
+// Operations are executed one by one in the order they are requested
+type Server struct {
+	config *config.Config
+	run    RunFunc
+
+	// Metrics is served at /metrics and notified of finished operations if not nil
+	Metrics Metrics
+
+	// Checks are run by /readyz, keyed by check name
+	Checks map[string]CheckFunc
+
+	// Leader reports whether this instance is the leader if not nil
+	// Operations are accepted and executed only by the leader
+	Leader func() bool
+
+	// Nodes lists nodes shown in dashboard if not nil
+	Nodes NodesFunc
+
+	// OverrideWindow allows every operation outside maintenance windows configured
+	OverrideWindow bool
+
+	// Principals are clients allowed to access API, keyed by SHA-256 digest of their tokens
+	// API is open to anyone if empty
+	Principals map[string]*Principal
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	order []string
+	queue chan *job
+}
+
+// New creates new Server object and starts operation worker
+func New(c *config.Config, run RunFunc) *Server {
+	s := &Server{
+		config: c,
+		run:    run,
+		jobs:   map[string]*job{},
+		order:  []string{},
+		queue:  make(chan *job, queueSize),
+	}
+
+	go s.work()
+
+	return s
+}
+
+// Handler returns HTTP handler serving API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleDashboard)
+	mux.HandleFunc("/clusters", s.authenticate(s.handleClusters))
+	mux.HandleFunc("/clusters/", s.authenticate(s.handleCluster))
+	mux.HandleFunc("/operations", s.authenticate(s.handleOperations))
+	mux.HandleFunc("/operations/", s.authenticate(s.handleOperation))
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+
+	if s.Metrics != nil {
+		mux.Handle("/metrics", s.Metrics)
+	}
+
+	return mux
+}
+
+// ListenAndServe starts API server on the given address
+func (s *Server) ListenAndServe(addr string) error {
+	return s.httpServer(addr).ListenAndServe()
+}
+
+// httpServer returns HTTP server serving API on the given address
+// Write timeout is not set, since operation events are streamed for as long as the operation runs
+func (s *Server) httpServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
+
+// GET /
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(dashboardHTML))
+}
+
+// GET /clusters
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	p := principal(r)
+	clusters := []*ClusterInfo{}
+
+	for _, name := range s.config.ClusterNames() {
+		if !p.CanAccess(name) {
+			continue
+		}
+
+		c := s.config.Clusters[name]
+
+		clusters = append(clusters, &ClusterInfo{
+			Name:       name,
+			ClusterURL: operation.SanitizeURL(c.ClusterURL),
+			Group:      c.Group,
+			Region:     c.Region,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, clusters)
+}
+
+// Submit queues the operation against the named cluster, or schedules it if req.At is in the future
+// Operations other than API requests, e.g. resumed on startup, are submitted directly
+func (s *Server) Submit(name string, op *operation.Operation, req *Request) (*Status, error) {
+	cluster, err := s.config.Cluster(name)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &job{
+		op:      op,
+		name:    name,
+		cluster: cluster,
+		req:     req,
+		status:  StatusQueued,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var wait time.Duration
+
+	if req.At != nil {
+		wait = time.Until(*req.At)
+	}
+
+	if wait > 0 {
+		j.status = StatusScheduled
+		time.AfterFunc(wait, func() { s.enqueueScheduled(j) })
+	} else if !s.enqueue(j) {
+		return nil, errors.New("too many queued operations")
+	}
+
+	s.jobs[op.ID] = j
+	s.order = append(s.order, op.ID)
+
+	return s.status(j), nil
+}
+
+// GET /clusters/{name}/nodes
+// POST /clusters/{name}/{add,remove,drain}
+func (s *Server) handleCluster(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/clusters/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	name, action := parts[0], parts[1]
+	p := principal(r)
+
+	if !p.CanAccess(name) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("%s cannot access cluster %q", p.Name, name))
+		return
+	}
+
+	cluster, err := s.config.Cluster(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if action == "nodes" {
+		s.handleNodes(w, r, cluster)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	req := &Request{
+		Action: action,
+	}
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse request body: %s", err))
+			return
+		}
+	}
+
+	status, err := s.submitRequest(p, name, req)
+	if err != nil {
+		writeError(w, errorCode(err), err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// requestError represents rejected API request with HTTP status code
+// gRPC API maps the code to gRPC status code
+type requestError struct {
+	code    int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// errorCode returns HTTP status code of the given error, 500 unless it is requestError
+func errorCode(err error) int {
+	if e, ok := err.(*requestError); ok {
+		return e.code
+	}
+
+	return http.StatusInternalServerError
+}
+
+// submitRequest validates operation requested by the principal via HTTP or gRPC API, then submits it
+func (s *Server) submitRequest(p *Principal, name string, req *Request) (*Status, error) {
+	if !p.CanAccess(name) {
+		return nil, &requestError{http.StatusForbidden, fmt.Sprintf("%s cannot access cluster %q", p.Name, name)}
+	}
+
+	cluster, err := s.config.Cluster(name)
+	if err != nil {
+		return nil, &requestError{http.StatusNotFound, err.Error()}
+	}
+
+	if !s.isLeader() {
+		return nil, &requestError{http.StatusServiceUnavailable, "this instance is standby, request the leader"}
+	}
+
+	action := req.Action
+
+	switch action {
+	case ActionAdd:
+		if req.Count < 1 {
+			return nil, &requestError{http.StatusBadRequest, "count must be greater than 0"}
+		}
+	case ActionRemove, ActionDrain:
+		if req.NodeName == "" {
+			return nil, &requestError{http.StatusBadRequest, "node_name must be specified"}
+		}
+	default:
+		return nil, &requestError{http.StatusNotFound, "not found"}
+	}
+
+	if !p.CanExecute(name, action) {
+		return nil, &requestError{http.StatusForbidden, fmt.Sprintf("%s (%s) cannot %s nodes of cluster %q", p.Name, p.Role, action, name)}
+	}
+
+	if req.OverrideWindow && !p.CanOverrideWindow() {
+		return nil, &requestError{http.StatusForbidden, fmt.Sprintf("%s (%s) cannot override maintenance windows", p.Name, p.Role)}
+	}
+
+	start := time.Now()
+	if req.At != nil && req.At.After(start) {
+		start = *req.At
+	}
+
+	if !req.OverrideWindow && !s.OverrideWindow && len(s.config.MaintenanceWindows) > 0 {
+		ok, err := schedule.InWindow(s.config.MaintenanceWindows, start)
+		if err != nil {
+			return nil, &requestError{http.StatusInternalServerError, err.Error()}
+		}
+
+		if !ok {
+			return nil, &requestError{http.StatusConflict, fmt.Sprintf("%s is outside maintenance windows, schedule with at or request override_window", start.Format(time.RFC3339))}
+		}
+	}
+
+	if cluster.Group == "" {
+		return nil, &requestError{http.StatusBadRequest, fmt.Sprintf("group of cluster %q is not configured", name)}
+	}
+
+	op := operation.New(action, cluster.ClusterURL)
+	op.Group = cluster.Group
+	op.Node = req.NodeName
+
+	// Operation is recorded as executed by the client, so that history and audit log tell who triggered it
+	if p != nil {
+		op.User = p.Name
+	}
+
+	status, err := s.Submit(name, op, req)
+	if err != nil {
+		return nil, &requestError{http.StatusServiceUnavailable, err.Error()}
+	}
+
+	log.Printf("%s requested %s on cluster %s (operation %s, node %q, count %d, start at %s)\n", op.User, action, name, op.ID, req.NodeName, req.Count, start.Format(time.RFC3339))
+
+	return status, nil
+}
+
+// GET /clusters/{name}/nodes
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request, cluster *config.Cluster) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if s.Nodes == nil {
+		writeError(w, http.StatusNotFound, "listing nodes is not supported")
+		return
+	}
+
+	nodes, err := s.Nodes(cluster)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+// GET /operations
+func (s *Server) handleOperations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	p := principal(r)
+
+	s.mu.Lock()
+
+	statuses := make([]*Status, 0, len(s.order))
+
+	for _, id := range s.order {
+		if j := s.jobs[id]; p.CanAccess(j.name) {
+			statuses = append(statuses, s.status(j))
+		}
+	}
+
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// GET /operations/{id}
+// GET /operations/{id}/events
+func (s *Server) handleOperation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/operations/")
+	events := false
+
+	if strings.HasSuffix(id, "/events") {
+		id = strings.TrimSuffix(id, "/events")
+		events = true
+	}
+
+	j, status, err := s.findJob(principal(r), id)
+	if err != nil {
+		writeError(w, errorCode(err), err.Error())
+		return
+	}
+
+	if events {
+		s.streamEvents(w, r, j)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// GET /healthz
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "ok",
+	})
+}
+
+// GET /readyz
+// Returns 503 if any of the checks fails
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.Checks))
+
+	for name := range s.Checks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	readiness := &Readiness{
+		Status: "ok",
+		Checks: map[string]string{},
+	}
+	code := http.StatusOK
+
+	for _, name := range names {
+		if err := s.Checks[name](); err != nil {
+			readiness.Checks[name] = err.Error()
+			readiness.Status = "failed"
+			code = http.StatusServiceUnavailable
+
+			continue
+		}
+
+		readiness.Checks[name] = "ok"
+	}
+
+	writeJSON(w, code, readiness)
+}
+
+// findJob returns the operation with the given ID and its status if the principal can access it
+func (s *Server) findJob(p *Principal, id string) (*job, *Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok || !p.CanAccess(j.name) {
+		return nil, nil, &requestError{http.StatusNotFound, fmt.Sprintf("operation %q is not found", id)}
+	}
+
+	return j, s.status(j), nil
+}
+
+// streamEvents writes one JSON line per status change or phase start until the operation finishes
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, j *job) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+
+	s.watch(r.Context(), j, func(e *Event) error {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+
+		flusher.Flush()
+
+		return nil
+	})
+}
+
+// watch sends one event per status change or phase start until the operation finishes, ctx is done or send fails
+func (s *Server) watch(ctx context.Context, j *job, send func(*Event) error) error {
+	lastStatus := ""
+	sentPhases := 0
+
+	for {
+		s.mu.Lock()
+		status := s.status(j)
+		s.mu.Unlock()
+
+		if status.Status != lastStatus && status.Status != StatusSucceeded && status.Status != StatusFailed {
+			if err := send(&Event{
+				Status: status.Status,
+				Time:   time.Now(),
+			}); err != nil {
+				return err
+			}
+
+			lastStatus = status.Status
+		}
+
+		for _, phase := range status.Phases[sentPhases:] {
+			if err := send(&Event{
+				Status: StatusRunning,
+				Phase:  phase.Name,
+				Time:   phase.StartedAt,
+			}); err != nil {
+				return err
+			}
+		}
+
+		sentPhases = len(status.Phases)
+
+		if status.Status == StatusSucceeded || status.Status == StatusFailed {
+			return send(&Event{
+				Status: status.Status,
+				Error:  status.Error,
+				Time:   status.FinishedAt,
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(eventInterval):
+		}
+	}
+}
+
+func (s *Server) work() {
+	for j := range s.queue {
+		s.setStatus(j, StatusRunning)
+
+		var err error
+
+		if s.isLeader() {
+			err = s.run(j.op, j.cluster, j.req)
+		} else {
+			err = errors.New("leadership was lost before the operation started")
+		}
+
+		if err != nil {
+			log.Printf("operation %s failed: %s\n", j.op.ID, err)
+		}
+
+		if j.op.Snapshot().FinishedAt.IsZero() {
+			j.op.Finish(err)
+		}
+
+		if s.Metrics != nil {
+			s.Metrics.ObserveOperation(j.name, j.op)
+		}
+
+		if err != nil {
+			s.setStatus(j, StatusFailed)
+		} else {
+			s.setStatus(j, StatusSucceeded)
+		}
+	}
+}
+
+// enqueue must be called with s.mu held
+func (s *Server) enqueue(j *job) bool {
+	select {
+	case s.queue <- j:
+		j.status = StatusQueued
+		return true
+	default:
+		return false
+	}
+}
+
+// enqueueScheduled queues scheduled operation when its time comes
+func (s *Server) enqueueScheduled(j *job) {
+	s.mu.Lock()
+	ok := s.enqueue(j)
+	s.mu.Unlock()
+
+	if !ok {
+		log.Printf("operation %s failed: too many queued operations at scheduled time\n", j.op.ID)
+		j.op.Finish(errors.New("too many queued operations at scheduled time"))
+		s.setStatus(j, StatusFailed)
+	}
+}
+
+func (s *Server) isLeader() bool {
+	return s.Leader == nil || s.Leader()
+}
+
+func (s *Server) setStatus(j *job, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j.status = status
+}
+
+// status must be called with s.mu held
+func (s *Server) status(j *job) *Status {
+	return &Status{
+		Status:    j.status,
+		Operation: j.op.Snapshot(),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{
+		"error": message,
+	})
+}
@@ -0,0 +1,179 @@
+package serverpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative esnctl.proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Service registration and client below are written by hand in the shape protoc-gen-go-grpc produces,
+// so that only messages are generated and the build does not depend on the gRPC plugin
+
+const (
+	submitMethod = "/esnctl.v1.Operations/Submit"
+	getMethod    = "/esnctl.v1.Operations/Get"
+	listMethod   = "/esnctl.v1.Operations/List"
+	watchMethod  = "/esnctl.v1.Operations/Watch"
+)
+
+// OperationsServer represents server API of Operations service
+type OperationsServer interface {
+	Submit(ctx context.Context, req *SubmitRequest) (*OperationStatus, error)
+	Get(ctx context.Context, req *GetRequest) (*OperationStatus, error)
+	List(ctx context.Context, req *ListRequest) (*ListResponse, error)
+	Watch(req *WatchRequest, stream grpc.ServerStreamingServer[Event]) error
+}
+
+// OperationsServiceDesc represents Operations service defined in esnctl.proto
+var OperationsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "esnctl.v1.Operations",
+	HandlerType: (*OperationsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Submit",
+			Handler:    submitHandler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    getHandler,
+		},
+		{
+			MethodName: "List",
+			Handler:    listHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       watchHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "esnctl.proto",
+}
+
+// RegisterOperationsServer registers srv as Operations service of s
+func RegisterOperationsServer(s grpc.ServiceRegistrar, srv OperationsServer) {
+	s.RegisterService(&OperationsServiceDesc, srv)
+}
+
+func submitHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &SubmitRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return srv.(OperationsServer).Submit(ctx, req)
+	}
+
+	return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: submitMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperationsServer).Submit(ctx, req.(*SubmitRequest))
+	})
+}
+
+func getHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &GetRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return srv.(OperationsServer).Get(ctx, req)
+	}
+
+	return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: getMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperationsServer).Get(ctx, req.(*GetRequest))
+	})
+}
+
+func listHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := &ListRequest{}
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return srv.(OperationsServer).List(ctx, req)
+	}
+
+	return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: listMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperationsServer).List(ctx, req.(*ListRequest))
+	})
+}
+
+func watchHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := &WatchRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	return srv.(OperationsServer).Watch(req, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// OperationsClient represents client API of Operations service
+type OperationsClient interface {
+	Submit(ctx context.Context, req *SubmitRequest, opts ...grpc.CallOption) (*OperationStatus, error)
+	Get(ctx context.Context, req *GetRequest, opts ...grpc.CallOption) (*OperationStatus, error)
+	List(ctx context.Context, req *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	Watch(ctx context.Context, req *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type operationsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewOperationsClient creates client of Operations service over cc
+func NewOperationsClient(cc grpc.ClientConnInterface) OperationsClient {
+	return &operationsClient{
+		cc: cc,
+	}
+}
+
+func (c *operationsClient) Submit(ctx context.Context, req *SubmitRequest, opts ...grpc.CallOption) (*OperationStatus, error) {
+	resp := &OperationStatus{}
+	if err := c.cc.Invoke(ctx, submitMethod, req, resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *operationsClient) Get(ctx context.Context, req *GetRequest, opts ...grpc.CallOption) (*OperationStatus, error) {
+	resp := &OperationStatus{}
+	if err := c.cc.Invoke(ctx, getMethod, req, resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *operationsClient) List(ctx context.Context, req *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	resp := &ListResponse{}
+	if err := c.cc.Invoke(ctx, listMethod, req, resp, opts...); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *operationsClient) Watch(ctx context.Context, req *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	stream, err := c.cc.NewStream(ctx, &OperationsServiceDesc.Streams[0], watchMethod, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+
+	if err := x.SendMsg(req); err != nil {
+		return nil, err
+	}
+
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
@@ -0,0 +1,145 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// EnvVar is environment variable to opt in telemetry by setting "1"
+	EnvVar = "ESNCTL_TELEMETRY"
+	// EndpointEnvVar is environment variable to set the URL events are posted to
+	EndpointEnvVar = "ESNCTL_TELEMETRY_ENDPOINT"
+	// DefaultEndpoint is the URL events are posted to without ESNCTL_TELEMETRY_ENDPOINT
+	// It is empty, since esnctl has no collector run by maintainers, so nothing is sent unless endpoint is given explicitly
+	DefaultEndpoint = ""
+
+	fileName = "telemetry.json"
+)
+
+// Event represents usage report of one command execution
+// It must not contain anything identifying users or clusters, e.g. URL, node name, group or account ID
+type Event struct {
+	Command    string   `json:"command"`
+	Version    string   `json:"version"`
+	OS         string   `json:"os"`
+	Arch       string   `json:"arch"`
+	ESVersions []string `json:"es_versions"`
+	Provider   string   `json:"provider"`
+	ErrorClass string   `json:"error_class"`
+}
+
+// Status represents whether telemetry is enabled and why
+type Status struct {
+	Enabled  bool   `json:"enabled"`
+	Reason   string `json:"reason"`
+	Endpoint string `json:"endpoint"`
+}
+
+type settings struct {
+	Disabled   bool      `json:"disabled"`
+	DisabledAt time.Time `json:"disabled_at"`
+}
+
+// CurrentStatus returns telemetry status from environment and settings in dir
+// Telemetry is disabled unless opted in with ESNCTL_TELEMETRY=1 and endpoint is given, and "telemetry off" takes precedence over it
+func CurrentStatus(dir string) (*Status, error) {
+	s := &Status{
+		Endpoint: Endpoint(),
+	}
+
+	st, err := load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case st.Disabled:
+		s.Reason = "turned off with \"esnctl telemetry off\" at " + st.DisabledAt.Format(time.RFC3339)
+	case os.Getenv(EnvVar) != "1":
+		s.Reason = EnvVar + " is not set to 1"
+	case s.Endpoint == "":
+		s.Reason = EndpointEnvVar + " is not set"
+	default:
+		s.Enabled = true
+		s.Reason = "opted in with " + EnvVar + "=1"
+	}
+
+	return s, nil
+}
+
+// Disable turns off telemetry regardless of ESNCTL_TELEMETRY
+func Disable(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create configuration directory")
+	}
+
+	body, err := json.Marshal(&settings{
+		Disabled:   true,
+		DisabledAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize telemetry settings")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, fileName), body, 0600); err != nil {
+		return errors.Wrap(err, "failed to write telemetry settings")
+	}
+
+	return nil
+}
+
+// Endpoint returns the URL events are posted to, or empty string if it is not given
+func Endpoint() string {
+	if endpoint := os.Getenv(EndpointEnvVar); endpoint != "" {
+		return endpoint
+	}
+
+	return DefaultEndpoint
+}
+
+// Send posts the event to endpoint
+func Send(client *http.Client, endpoint string, e *Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize telemetry event")
+	}
+
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to send telemetry event")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("failed to send telemetry event: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func load(dir string) (*settings, error) {
+	body, err := ioutil.ReadFile(filepath.Join(dir, fileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &settings{}, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to read telemetry settings")
+	}
+
+	var st settings
+
+	if err := json.Unmarshal(body, &st); err != nil {
+		return nil, errors.Wrap(err, "failed to parse telemetry settings")
+	}
+
+	return &st, nil
+}
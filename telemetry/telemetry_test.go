@@ -0,0 +1,94 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCurrentStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "esnctl-telemetry")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv(EnvVar, os.Getenv(EnvVar))
+	defer os.Setenv(EndpointEnvVar, os.Getenv(EndpointEnvVar))
+
+	os.Unsetenv(EnvVar)
+	os.Unsetenv(EndpointEnvVar)
+
+	s, err := CurrentStatus(dir)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if s.Enabled {
+		t.Errorf("telemetry should be disabled unless opted in")
+	}
+
+	os.Setenv(EnvVar, "1")
+
+	s, err = CurrentStatus(dir)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if s.Enabled {
+		t.Errorf("telemetry should be disabled without %s", EndpointEnvVar)
+	}
+
+	os.Setenv(EndpointEnvVar, "https://telemetry.example.com/v1/events")
+
+	s, err = CurrentStatus(dir)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !s.Enabled {
+		t.Errorf("telemetry should be enabled with %s=1", EnvVar)
+	}
+
+	if err := Disable(dir); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	s, err = CurrentStatus(dir)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if s.Enabled {
+		t.Errorf("telemetry should be disabled after turned off even with %s=1", EnvVar)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Event
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	e := &Event{
+		Command:    "remove",
+		Version:    "v0.2.1",
+		ESVersions: []string{"elasticsearch 7.x"},
+		Provider:   "aws",
+		ErrorClass: "Timeout",
+	}
+
+	if err := Send(http.DefaultClient, ts.URL, e); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got.Command != e.Command || got.ErrorClass != e.ErrorClass || len(got.ESVersions) != 1 {
+		t.Errorf("event does not match. expected: %+v, got: %+v", e, got)
+	}
+}
@@ -0,0 +1,185 @@
+package tunnel
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TypeSSH forwards port via SSH bastion host
+	TypeSSH = "ssh"
+	// TypeSSM forwards port via EC2 instance using AWS Systems Manager Session Manager
+	TypeSSM = "ssm"
+
+	// ssmDocumentName represents SSM document used for port forwarding to remote host
+	ssmDocumentName = "AWS-StartPortForwardingSessionToRemoteHost"
+)
+
+var (
+	// listenTimeout represents how long to wait for the local port to be ready
+	listenTimeout = 30 * time.Second
+	// pollInterval represents interval of checking the local port
+	pollInterval = 100 * time.Millisecond
+)
+
+// Tunnel represents local port forward to remote endpoint
+type Tunnel struct {
+	// LocalAddr represents local address connected to remote endpoint
+	LocalAddr string
+
+	cmd *exec.Cmd
+}
+
+// Spec represents tunnel specification given as "<type>:<target>"
+// e.g. "ssm:i-0123456789abcdef0", "ssh:ec2-user@bastion.example.com"
+type Spec struct {
+	Type   string
+	Target string
+}
+
+// ParseSpec parses tunnel specification
+func ParseSpec(spec string) (*Spec, error) {
+	kv := strings.SplitN(spec, ":", 2)
+	if len(kv) != 2 || kv[1] == "" {
+		return nil, errors.Errorf("tunnel %q is invalid, must be in \"ssm:<instance ID>\" or \"ssh:[user@]<host>\" format", spec)
+	}
+
+	switch kv[0] {
+	case TypeSSH, TypeSSM:
+		return &Spec{
+			Type:   kv[0],
+			Target: kv[1],
+		}, nil
+	}
+
+	return nil, errors.Errorf("tunnel type %q is not supported, must be %q or %q", kv[0], TypeSSM, TypeSSH)
+}
+
+// RemoteAddr returns host:port of the given cluster URL
+// Port is determined from scheme if it is omitted
+func RemoteAddr(clusterURL string) (string, error) {
+	u, err := url.Parse(clusterURL)
+	if err != nil {
+		return "", errors.Wrap(err, "cluster URL is invalid")
+	}
+
+	if u.Hostname() == "" {
+		return "", errors.Errorf("cluster URL %q does not have host", clusterURL)
+	}
+
+	port := u.Port()
+
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		default:
+			port = "80"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// Open starts port forward from a free local port to remoteAddr and waits until it becomes ready
+func Open(spec *Spec, remoteAddr string) (*Tunnel, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find free local port")
+	}
+
+	args, err := spec.command(remoteAddr, port)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "failed to start %s", args[0])
+	}
+
+	t := &Tunnel{
+		LocalAddr: fmt.Sprintf("127.0.0.1:%d", port),
+		cmd:       cmd,
+	}
+
+	exited := make(chan error, 1)
+
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	timeout := time.After(listenTimeout)
+
+	for {
+		if conn, err := net.DialTimeout("tcp", t.LocalAddr, pollInterval); err == nil {
+			conn.Close()
+			return t, nil
+		}
+
+		select {
+		case err := <-exited:
+			return nil, errors.Errorf("%s exited before tunnel became ready: %v", args[0], err)
+		case <-timeout:
+			t.Close()
+			return nil, errors.Errorf("timed out waiting for tunnel to %s via %s", remoteAddr, spec.Target)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Close stops port forward
+func (t *Tunnel) Close() error {
+	if t.cmd.Process == nil {
+		return nil
+	}
+
+	return t.cmd.Process.Kill()
+}
+
+// command returns command line which forwards localPort to remoteAddr
+func (s *Spec) command(remoteAddr string, localPort int) ([]string, error) {
+	host, port, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "remote address is invalid")
+	}
+
+	switch s.Type {
+	case TypeSSH:
+		return []string{
+			"ssh", "-N",
+			"-o", "ExitOnForwardFailure=yes",
+			"-L", fmt.Sprintf("%d:%s:%s", localPort, host, port),
+			s.Target,
+		}, nil
+	case TypeSSM:
+		return []string{
+			"aws", "ssm", "start-session",
+			"--target", s.Target,
+			"--document-name", ssmDocumentName,
+			"--parameters", fmt.Sprintf("host=%s,portNumber=%s,localPortNumber=%d", host, port, localPort),
+		}, nil
+	}
+
+	return nil, errors.Errorf("tunnel type %q is not supported", s.Type)
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+// WatchDrain prints the number and size of shards left on the given node every interval until the node becomes empty
+// ETA is estimated from the size of shards moved since watching started. API errors are printed and retried at next check
+func WatchDrain(ctx context.Context, client es.Client, nodeName string, out io.Writer, interval time.Duration) error {
+	var (
+		startBytes int64 = -1
+		startedAt  time.Time
+	)
+
+	for i := 0; ; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		nodes, err := client.NodeStats()
+		if err != nil {
+			fmt.Fprintf(out, "===> failed to retrieve node stats: %s\n", err)
+			continue
+		}
+
+		n := findNodeStats(nodes, nodeName)
+		if n == nil {
+			return exitcode.Errorf(exitcode.Validation, "node %q is not found in the cluster", nodeName)
+		}
+
+		if n.Shards == 0 {
+			fmt.Fprintf(out, "===> %s is empty\n", nodeName)
+			return nil
+		}
+
+		if startBytes < 0 {
+			startBytes, startedAt = n.StoreBytes, time.Now()
+		}
+
+		eta := "unknown"
+
+		if d, ok := estimateDrain(startBytes, n.StoreBytes, time.Since(startedAt)); ok {
+			eta = d.String()
+		}
+
+		fmt.Fprintf(out, "===> %d shards (%s) left on %s, ETA: %s\n", n.Shards, formatBytes(n.StoreBytes), nodeName, eta)
+	}
+}
+
+// estimateDrain returns how long it takes to move the rest of bytes at the rate observed since start
+// false is returned if no bytes have moved yet
+func estimateDrain(startBytes, currentBytes int64, elapsed time.Duration) (time.Duration, bool) {
+	moved := startBytes - currentBytes
+	if moved <= 0 || elapsed <= 0 {
+		return 0, false
+	}
+
+	eta := time.Duration(float64(elapsed) * float64(currentBytes) / float64(moved))
+
+	return eta / time.Second * time.Second, true
+}
+
+// findNodeStats returns stats of the given node, or nil if not found
+func findNodeStats(nodes []*stats.Node, nodeName string) *stats.Node {
+	for _, n := range nodes {
+		if n.Name == nodeName {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// formatBytes returns human-readable size in binary units
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
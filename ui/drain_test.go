@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestWatchDrain(t *testing.T) {
+	c := fake.NewCluster(2)
+
+	if err := c.ExcludeNodeFromAllocation("ip-10-0-1-1.ec2.internal"); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	var out bytes.Buffer
+
+	if err := WatchDrain(context.Background(), c, "ip-10-0-1-1.ec2.internal", &out, time.Millisecond); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := "===> ip-10-0-1-1.ec2.internal is empty\n"
+
+	if out.String() != expected {
+		t.Errorf("output does not match. expected: %q, got: %q", expected, out.String())
+	}
+}
+
+func TestWatchDrain_waiting(t *testing.T) {
+	c := fake.NewCluster(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+
+	if err := WatchDrain(ctx, c, "ip-10-0-1-1.ec2.internal", &out, time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("deadline should be exceeded. got: %v", err)
+	}
+
+	expected := "===> 3 shards (1.5 GiB) left on ip-10-0-1-1.ec2.internal, ETA: unknown\n"
+
+	if !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("output does not match. expected: %q, got: %q", expected, out.String())
+	}
+}
+
+func TestWatchDrain_notFound(t *testing.T) {
+	c := fake.NewCluster(2)
+
+	var out bytes.Buffer
+
+	err := WatchDrain(context.Background(), c, "ip-10-0-9-9.ec2.internal", &out, time.Millisecond)
+	if err == nil {
+		t.Fatalf("error should be raised")
+	}
+
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Errorf("exit code does not match. expected: %d, got: %d", exitcode.Validation, got)
+	}
+}
+
+func TestEstimateDrain(t *testing.T) {
+	testcases := []struct {
+		startBytes   int64
+		currentBytes int64
+		elapsed      time.Duration
+		expected     time.Duration
+		ok           bool
+	}{
+		{
+			startBytes:   1000,
+			currentBytes: 750,
+			elapsed:      time.Minute,
+			expected:     3 * time.Minute,
+			ok:           true,
+		},
+		{
+			startBytes:   1000,
+			currentBytes: 999,
+			elapsed:      1500 * time.Millisecond,
+			expected:     24*time.Minute + 58*time.Second,
+			ok:           true,
+		},
+		{
+			startBytes:   1000,
+			currentBytes: 1000,
+			elapsed:      time.Minute,
+			ok:           false,
+		},
+		{
+			// Shards grew by indexing
+			startBytes:   1000,
+			currentBytes: 1200,
+			elapsed:      time.Minute,
+			ok:           false,
+		},
+	}
+
+	for _, tc := range testcases {
+		got, ok := estimateDrain(tc.startBytes, tc.currentBytes, tc.elapsed)
+		if ok != tc.ok {
+			t.Errorf("ok does not match. expected: %t, got: %t", tc.ok, ok)
+		}
+
+		if got != tc.expected {
+			t.Errorf("ETA does not match. expected: %s, got: %s", tc.expected, got)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	testcases := []struct {
+		b        int64
+		expected string
+	}{
+		{b: 0, expected: "0 B"},
+		{b: 1023, expected: "1023 B"},
+		{b: 1536, expected: "1.5 KiB"},
+		{b: 3 * 1024 * 1024 * 1024, expected: "3.0 GiB"},
+	}
+
+	for _, tc := range testcases {
+		if got := formatBytes(tc.b); got != tc.expected {
+			t.Errorf("size does not match. expected: %q, got: %q", tc.expected, got)
+		}
+	}
+}
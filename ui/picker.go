@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+)
+
+// PickNode lets user select node in the given ASG with arrow keys, and confirm it
+// Nodes are listed with the number of shards and AZ. Returns error with exitcode.Aborted if canceled
+func PickNode(w *workflow.Workflow, group string, in io.Reader, out io.Writer) (string, error) {
+	u := New(w, group, in, out, nil)
+
+	if err := u.refresh(); err != nil {
+		return "", err
+	}
+
+	// Nodes outside of the group, e.g. dedicated masters, cannot be removed
+	nodes := []*Node{}
+
+	for _, n := range u.nodes {
+		if n.LifecycleState != "" {
+			nodes = append(nodes, n)
+		}
+	}
+
+	if len(nodes) == 0 {
+		return "", errors.Errorf("no node is found in Auto Scaling Group %q", group)
+	}
+
+	u.nodes = nodes
+
+	for {
+		fmt.Fprint(out, clearScreen)
+		fmt.Fprintf(out, "Select node to remove from %s:\n\n", group)
+		u.renderNodes()
+		fmt.Fprintln(out, "[j/k] move  [enter] select  [q] cancel")
+
+		key, err := u.readKey()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read key")
+		}
+
+		switch key {
+		case keyQuit, keyInterrupt:
+			return "", exitcode.New(exitcode.Aborted, "canceled")
+		case keyUp, "k":
+			if u.cursor > 0 {
+				u.cursor--
+			}
+		case keyDown, "j":
+			if u.cursor < len(u.nodes)-1 {
+				u.cursor++
+			}
+		case keyEnter, "\r":
+			n := u.nodes[u.cursor]
+
+			fmt.Fprintf(out, "\nRemove %s (%s, %d shards)? [y/N] ", n.Name, orDash(n.AvailabilityZone), n.Shards)
+
+			key, err := u.readKey()
+			if err != nil {
+				return "", errors.Wrap(err, "failed to read key")
+			}
+
+			fmt.Fprintln(out)
+
+			// Other answers go back to the list
+			if key == keyYes {
+				return n.Name, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestPickNode(t *testing.T) {
+	testcases := []struct {
+		keys     string
+		expected string
+	}{
+		{
+			keys:     "\n" + "y",
+			expected: "ip-10-0-1-1.ec2.internal",
+		},
+		{
+			// Answering n goes back to the list
+			keys:     "j\x1b[B\n" + "n" + "\x1b[A\n" + "y",
+			expected: "ip-10-0-1-2.ec2.internal",
+		},
+	}
+
+	for _, tc := range testcases {
+		w, _ := newFakeWorkflow()
+
+		var out bytes.Buffer
+
+		got, err := PickNode(w, fake.GroupName, strings.NewReader(tc.keys), &out)
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+			continue
+		}
+
+		if got != tc.expected {
+			t.Errorf("node does not match. expected: %q, got: %q", tc.expected, got)
+		}
+
+		if !strings.Contains(out.String(), "us-east-1") {
+			t.Errorf("AZ should be shown. got: %s", out.String())
+		}
+	}
+}
+
+func TestPickNode_canceled(t *testing.T) {
+	w, _ := newFakeWorkflow()
+
+	var out bytes.Buffer
+
+	_, err := PickNode(w, fake.GroupName, strings.NewReader("jq"), &out)
+	if err == nil {
+		t.Fatalf("error should be raised")
+	}
+
+	if got := exitcode.Code(err); got != exitcode.Aborted {
+		t.Errorf("exit code does not match. expected: %d, got: %d", exitcode.Aborted, got)
+	}
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/operation"
+)
+
+// Top redraws resource usage of each node every interval until ctx is done
+// iterations limits the number of refreshes, and 0 means unlimited. Errors are shown on screen and retried at next refresh
+func Top(ctx context.Context, client es.Client, clusterURL string, out io.Writer, interval time.Duration, iterations int) error {
+	prev := map[string]int{}
+
+	for i := 0; iterations == 0 || i < iterations; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+
+		fmt.Fprint(out, clearScreen)
+		fmt.Fprintf(out, "esnctl top - cluster: %s, every %s, updated at %s\n\n", operation.SanitizeURL(clusterURL), interval, time.Now().Format("15:04:05"))
+
+		nodes, err := client.NodeStats()
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+
+		renderStats(out, nodes, prev)
+
+		prev = map[string]int{}
+
+		for _, n := range nodes {
+			prev[n.Name] = n.Shards
+		}
+	}
+
+	return nil
+}
+
+// renderStats prints table of node stats
+// Change of shards from the previous refresh is shown next to the number of shards
+func renderStats(out io.Writer, nodes []*stats.Node, prev map[string]int) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	total := 0
+
+	for _, n := range nodes {
+		total += n.Shards
+	}
+
+	fmt.Fprintf(out, "Nodes: %d, Shards: %d\n\n", len(nodes), total)
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCPU\tHEAP\tDISK\tLOAD\tSHARDS")
+
+	for _, n := range nodes {
+		shards := fmt.Sprintf("%d", n.Shards)
+
+		if p, ok := prev[n.Name]; ok && p != n.Shards {
+			shards += fmt.Sprintf(" (%+d)", n.Shards-p)
+		}
+
+		fmt.Fprintf(w, "%s\t%.1f%%\t%.1f%%\t%.1f%%\t%.2f\t%s\n", n.Name, n.CPUPercent, n.HeapPercent, n.DiskPercent, n.Load1m, shards)
+	}
+
+	w.Flush()
+}
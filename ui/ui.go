@@ -0,0 +1,436 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dtan4/esnctl/aws/autoscaling"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/workflow"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ActionDrain moves shards and connections out of node, and keeps its instance
+	ActionDrain = "drain"
+	// ActionRemove drains node, shuts it down and detaches its instance from ASG
+	ActionRemove = "remove"
+)
+
+const (
+	keyDown      = "down"
+	keyDrain     = "d"
+	keyEnter     = "\n"
+	keyInterrupt = "\x03"
+	keyQuit      = "q"
+	keyRefresh   = "r"
+	keyRemove    = "x"
+	keyUp        = "up"
+	keyYes       = "y"
+)
+
+// clearScreen moves cursor to top left and clears screen
+const clearScreen = "\x1b[H\x1b[2J"
+
+// pollInterval represents interval between checks of waiting steps
+var pollInterval = 5 * time.Second
+
+// Node represents row of node list
+type Node struct {
+	Name             string
+	InstanceID       string
+	AvailabilityZone string
+	LifecycleState   string
+	Shards           int
+	DiskUsage        float64
+}
+
+// RunFunc executes fn as the given operation, e.g. with cluster lock and operation history
+// Context passed to fn may be canceled earlier than ctx, e.g. when cluster lock is lost
+type RunFunc func(ctx context.Context, op *operation.Operation, fn func(ctx context.Context) error) error
+
+// UI represents interactive terminal UI to operate nodes
+type UI struct {
+	w     *workflow.Workflow
+	group string
+	in    *bufio.Reader
+	out   io.Writer
+	run   RunFunc
+
+	keys    chan string
+	readErr chan error
+	nodes   []*Node
+	cursor  int
+	message string
+}
+
+// New creates new UI object
+// in is expected to be terminal in non-canonical mode without signals so that each key press including Ctrl-C is read immediately
+func New(w *workflow.Workflow, group string, in io.Reader, out io.Writer, run RunFunc) *UI {
+	if run == nil {
+		run = func(ctx context.Context, op *operation.Operation, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}
+	}
+
+	return &UI{
+		w:     w,
+		group: group,
+		in:    bufio.NewReader(in),
+		out:   out,
+		run:   run,
+
+		keys:    make(chan string),
+		readErr: make(chan error, 1),
+	}
+}
+
+// LoadNodes collects shard, disk and ASG information of each node
+// Node name is regarded as private DNS name of its instance
+func LoadNodes(w *workflow.Workflow, group string) ([]*Node, error) {
+	names, err := w.ES.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes")
+	}
+
+	sort.Strings(names)
+
+	usage, err := w.ES.DiskUsage()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve disk usage")
+	}
+
+	list, err := w.AutoScaling.DescribeInstances(group)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list instances in AutoScaling Group")
+	}
+
+	instances := map[string]*autoscaling.Instance{}
+
+	for _, i := range list {
+		instances[i.ID] = i
+	}
+
+	nodes := []*Node{}
+
+	for _, name := range names {
+		shards, err := w.ES.ListShardsOnNode(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list shards on node")
+		}
+
+		n := &Node{
+			Name:      name,
+			Shards:    len(shards),
+			DiskUsage: usage[name],
+		}
+
+		// Nodes outside of EC2, e.g. dedicated master on other platform, are listed without instance
+		if id, err := w.EC2.RetrieveInstanceIDFromPrivateDNS(name); err == nil {
+			n.InstanceID = id
+
+			if i, ok := instances[id]; ok {
+				n.AvailabilityZone = i.AvailabilityZone
+				n.LifecycleState = i.LifecycleState
+			}
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	return nodes, nil
+}
+
+// Run shows node list and handles key input until q or Ctrl-C is pressed, or input is closed
+func (u *UI) Run(ctx context.Context) error {
+	if err := u.refresh(); err != nil {
+		return err
+	}
+
+	go u.readKeys()
+
+	for {
+		u.render(nil, "")
+
+		key, err := u.nextKey(ctx)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case keyQuit, keyInterrupt:
+			return nil
+		case keyUp, "k":
+			if u.cursor > 0 {
+				u.cursor--
+			}
+		case keyDown, "j":
+			if u.cursor < len(u.nodes)-1 {
+				u.cursor++
+			}
+		case keyRefresh:
+			u.message = ""
+
+			if err := u.refresh(); err != nil {
+				u.message = err.Error()
+			}
+		case keyDrain, keyRemove:
+			if len(u.nodes) == 0 {
+				continue
+			}
+
+			action := ActionDrain
+			if key == keyRemove {
+				action = ActionRemove
+			}
+
+			u.confirmAndExecute(ctx, action, u.nodes[u.cursor])
+		}
+	}
+}
+
+func (u *UI) confirmAndExecute(ctx context.Context, action string, node *Node) {
+	u.message = fmt.Sprintf("%s %s? [y/N]", action, node.Name)
+	u.render(nil, "")
+
+	key, err := u.nextKey(ctx)
+	if err != nil || key != keyYes {
+		u.message = "canceled"
+		return
+	}
+
+	if err := u.execute(ctx, action, node); err != nil {
+		u.message = fmt.Sprintf("failed to %s %s: %s", action, node.Name, err)
+	} else {
+		u.message = fmt.Sprintf("%s %s finished", action, node.Name)
+	}
+
+	if err := u.refresh(); err != nil {
+		u.message = err.Error()
+	}
+}
+
+// execute runs removal steps of the given node one by one, rendering progress after each step
+// Drain stops before shutting down node. q or Ctrl-C aborts action while waiting
+func (u *UI) execute(ctx context.Context, action string, node *Node) error {
+	until := workflow.StepDone
+	if action == ActionDrain {
+		until = workflow.StepShutdown
+	}
+
+	op := operation.New(action, u.w.ClusterURL)
+	op.Group = u.group
+	op.Node = node.Name
+
+	return u.run(ctx, op, func(ctx context.Context) error {
+		s := &workflow.RemoveState{
+			Group:    u.group,
+			NodeName: node.Name,
+			Step:     workflow.StepResolve,
+		}
+
+		for s.Step != until {
+			u.render(s, action)
+
+			if len(op.Phases) == 0 || op.Phases[len(op.Phases)-1].Name != workflow.RemoveStepDescription(s.Step) {
+				op.Phase(workflow.RemoveStepDescription(s.Step))
+			}
+
+			next, err := u.w.RemoveStep(ctx, s)
+			if err != nil {
+				return err
+			}
+
+			if next.Waiting {
+				u.render(next, action)
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case key := <-u.keys:
+					if key == keyQuit || key == keyInterrupt {
+						return exitcode.New(exitcode.Aborted, "aborted by user")
+					}
+				case <-time.After(pollInterval):
+				}
+			}
+
+			s = next
+		}
+
+		return nil
+	})
+}
+
+// nextKey returns key read by readKeys
+func (u *UI) nextKey(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case err := <-u.readErr:
+		return "", err
+	case key := <-u.keys:
+		return key, nil
+	}
+}
+
+func (u *UI) readKey() (string, error) {
+	b, err := u.in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	// Arrow keys are sent as ESC [ A / ESC [ B
+	if b == 0x1b {
+		seq := make([]byte, 2)
+
+		if _, err := io.ReadFull(u.in, seq); err != nil {
+			return "", err
+		}
+
+		switch string(seq) {
+		case "[A":
+			return keyUp, nil
+		case "[B":
+			return keyDown, nil
+		}
+
+		return "", nil
+	}
+
+	return string(b), nil
+}
+
+// readKeys sends key press to u.keys until input is closed
+// Keys are read in background so that running action can be aborted
+func (u *UI) readKeys() {
+	for {
+		key, err := u.readKey()
+		if err != nil {
+			u.readErr <- err
+			return
+		}
+
+		u.keys <- key
+	}
+}
+
+func (u *UI) refresh() error {
+	nodes, err := LoadNodes(u.w, u.group)
+	if err != nil {
+		return err
+	}
+
+	u.nodes = nodes
+
+	if u.cursor >= len(u.nodes) {
+		u.cursor = len(u.nodes) - 1
+	}
+
+	if u.cursor < 0 {
+		u.cursor = 0
+	}
+
+	return nil
+}
+
+// render redraws whole screen
+// Progress of the given state is shown below node list while action is running
+func (u *UI) render(s *workflow.RemoveState, action string) {
+	fmt.Fprint(u.out, clearScreen)
+	fmt.Fprintf(u.out, "esnctl ui - cluster: %s, group: %s\n\n", operation.SanitizeURL(u.w.ClusterURL), u.group)
+
+	u.renderNodes()
+
+	if s != nil {
+		fmt.Fprintf(u.out, "%s %s:\n", action, s.NodeName)
+
+		for _, p := range progress(s, action) {
+			fmt.Fprintln(u.out, p)
+		}
+
+		return
+	}
+
+	if u.message != "" {
+		fmt.Fprintln(u.out, u.message)
+	}
+
+	fmt.Fprintln(u.out, "[j/k] move  [d] drain  [x] remove  [r] refresh  [q] quit")
+}
+
+// renderNodes prints node list with cursor
+func (u *UI) renderNodes() {
+	w := tabwriter.NewWriter(u.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tINSTANCE\tAZ\tASG STATE\tSHARDS\tDISK")
+
+	for i, n := range u.nodes {
+		line := fmt.Sprintf("  %s\t%s\t%s\t%s\t%d\t%.1f%%", n.Name, orDash(n.InstanceID), orDash(n.AvailabilityZone), orDash(n.LifecycleState), n.Shards, n.DiskUsage)
+
+		if i == u.cursor {
+			line = "> " + line[2:]
+		}
+
+		fmt.Fprintln(w, line)
+	}
+
+	w.Flush()
+
+	fmt.Fprintln(u.out)
+}
+
+// progress returns lines of steps with marks of done, running and pending
+func progress(s *workflow.RemoveState, action string) []string {
+	steps := append([]string{workflow.RemoveStepDescription(workflow.StepResolve)}, workflow.RemoveSteps...)
+
+	if action == ActionDrain {
+		for i, step := range steps {
+			if step == workflow.RemoveStepDescription(workflow.StepWaitDrain) {
+				steps = steps[:i+1]
+				break
+			}
+		}
+	}
+
+	current := workflow.RemoveStepDescription(s.Step)
+	done := true
+
+	lines := []string{}
+
+	for _, step := range steps {
+		switch {
+		case step == current:
+			done = false
+
+			if s.Waiting {
+				lines = append(lines, "  [>] "+step+" (waiting...)")
+			} else {
+				lines = append(lines, "  [>] "+step)
+			}
+		case done:
+			lines = append(lines, "  [x] "+step)
+		default:
+			lines = append(lines, "  [ ] "+step)
+		}
+	}
+
+	return lines
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,269 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// minRenewInterval represents lower limit of interval between lease renewals
+var minRenewInterval = 10 * time.Second
+
+// Client represents Vault HTTP API client
+type Client struct {
+	addr       string
+	token      string
+	namespace  string
+	httpClient *http.Client
+}
+
+// Secret represents secret returned from Vault
+type Secret struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// Credentials represents Elasticsearch credentials stored in Vault
+// Certificates and key are PEM encoded
+type Credentials struct {
+	Username   string
+	Password   string
+	ClientCert string
+	ClientKey  string
+	CACert     string
+}
+
+type errorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// New creates new Client object
+func New(addr, token string, httpClient *http.Client) *Client {
+	return &Client{
+		addr:       strings.TrimSuffix(addr, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// NewFromEnv creates new Client object from VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE
+// Token is read from ~/.vault-token if VAULT_TOKEN is not set, as vault CLI does
+func NewFromEnv() (*Client, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, errors.New("VAULT_ADDR must be set")
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+
+	if token == "" {
+		if home := os.Getenv("HOME"); home != "" {
+			b, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+			if err == nil {
+				token = strings.TrimSpace(string(b))
+			}
+		}
+	}
+
+	if token == "" {
+		return nil, errors.New("VAULT_TOKEN must be set")
+	}
+
+	c := New(addr, token, &http.Client{})
+	c.namespace = os.Getenv("VAULT_NAMESPACE")
+
+	return c, nil
+}
+
+// Credentials extracts Elasticsearch credentials from secret
+// Data of KV version 2 is unwrapped. Certificate fields of PKI secrets engine are also accepted
+func (s *Secret) Credentials() (*Credentials, error) {
+	data := s.Data
+
+	// KV version 2 nests data in "data" with "metadata"
+	if d, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = d
+		}
+	}
+
+	c := &Credentials{
+		Username:   stringField(data, "username"),
+		Password:   stringField(data, "password"),
+		ClientCert: stringField(data, "client_cert", "certificate"),
+		ClientKey:  stringField(data, "client_key", "private_key"),
+		CACert:     stringField(data, "ca_cert", "issuing_ca"),
+	}
+
+	if c.Password == "" && c.ClientCert == "" {
+		return nil, errors.New("secret has neither password nor client_cert")
+	}
+
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return nil, errors.New("both client_cert and client_key must be stored")
+	}
+
+	return c, nil
+}
+
+// TLSConfig returns TLS configuration with client certificate
+// Returns nil if client certificate is not stored
+func (c *Credentials) TLSConfig() (*tls.Config, error) {
+	if c.ClientCert == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load client certificate")
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if c.CACert != "" {
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM([]byte(c.CACert)) {
+			return nil, errors.New("failed to load CA certificate")
+		}
+
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// KeepRenewing renews lease of the given secret until ctx is canceled
+// Renewal is done at half of lease duration. Nothing is done if the secret is not renewable
+func (c *Client) KeepRenewing(ctx context.Context, s *Secret) {
+	if !s.Renewable || s.LeaseID == "" {
+		return
+	}
+
+	duration := s.LeaseDuration
+
+	for {
+		interval := time.Duration(duration) * time.Second / 2
+		if interval < minRenewInterval {
+			interval = minRenewInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		renewed, err := c.Renew(s.LeaseID, s.LeaseDuration)
+		if err != nil {
+			log.Printf("failed to renew Vault lease %s: %s\n", s.LeaseID, err)
+			continue
+		}
+
+		duration = renewed.LeaseDuration
+	}
+}
+
+// Read reads secret at the given path
+func (c *Client) Read(path string) (*Secret, error) {
+	var s Secret
+
+	if err := c.do("GET", "/v1/"+strings.TrimPrefix(path, "/"), nil, &s); err != nil {
+		return nil, errors.Wrapf(err, "failed to read secret %s", path)
+	}
+
+	return &s, nil
+}
+
+// Renew renews lease with the given increment in seconds
+func (c *Client) Renew(leaseID string, increment int) (*Secret, error) {
+	body := map[string]interface{}{
+		"lease_id":  leaseID,
+		"increment": increment,
+	}
+
+	var s Secret
+
+	if err := c.do("PUT", "/v1/sys/leases/renew", body, &s); err != nil {
+		return nil, errors.Wrap(err, "failed to renew lease")
+	}
+
+	return &s, nil
+}
+
+func (c *Client) do(method, path string, body interface{}, v interface{}) error {
+	var reqBody []byte
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "failed to build request body")
+		}
+
+		reqBody = b
+	}
+
+	req, err := http.NewRequest(method, c.addr+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return errors.Wrap(err, "failed to make http request")
+	}
+
+	req.Header.Set("X-Vault-Token", c.token)
+
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to access to Vault")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+
+		if err := json.Unmarshal(respBody, &e); err == nil && len(e.Errors) > 0 {
+			return errors.Errorf("code: %d, errors: %s", resp.StatusCode, strings.Join(e.Errors, ", "))
+		}
+
+		return errors.Errorf("code: %d, body: %s", resp.StatusCode, respBody)
+	}
+
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return errors.Wrap(err, "invalid response body")
+	}
+
+	return nil
+}
+
+// stringField returns the first non-empty string field of the given keys
+func stringField(data map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := data[k].(string); ok && s != "" {
+			return s
+		}
+	}
+
+	return ""
+}
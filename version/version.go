@@ -9,9 +9,11 @@ var (
 	Version string
 	// Revision represents commit hash at built binary
 	Revision string
+	// BuildDate represents date when binary was built
+	BuildDate string
 )
 
 // String returns version string
 func String() string {
-	return fmt.Sprintf("esnctl version %s, build %s", Version, Revision)
+	return fmt.Sprintf("esnctl version %s, build %s, built at %s", Version, Revision, BuildDate)
 }
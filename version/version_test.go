@@ -7,8 +7,9 @@ import (
 func TestString(t *testing.T) {
 	Version = "v0.1.0"
 	Revision = "abcd1234"
+	BuildDate = "2017-03-01T00:00:00Z"
 
-	expected := "esnctl version v0.1.0, build abcd1234"
+	expected := "esnctl version v0.1.0, build abcd1234, built at 2017-03-01T00:00:00Z"
 	actual := String()
 
 	if actual != expected {
@@ -0,0 +1,115 @@
+package workflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// awarenessAttributesSetting represents node attributes which copies of each shard are spread over
+	awarenessAttributesSetting = "cluster.routing.allocation.awareness.attributes"
+
+	// forcedAwarenessSettingPrefix precedes attribute name and ".values" in forced awareness setting
+	forcedAwarenessSettingPrefix = "cluster.routing.allocation.awareness.force."
+)
+
+// warnAwareness prints warnings about allocation awareness which nodes would violate
+// removing is the node to be removed, and added are nodes which have just joined. Either can be empty
+// Failure is reported as warning as well, because the check is advisory
+func (w *Workflow) warnAwareness(removing string, added []string) {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	warnings, err := w.awarenessWarnings(removing, added)
+	if err != nil {
+		fmt.Fprintf(progress, "WARNING: failed to check allocation awareness: %s\n", err)
+		return
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(progress, "WARNING: %s\n", warning)
+	}
+}
+
+func (w *Workflow) awarenessWarnings(removing string, added []string) ([]string, error) {
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	attributes := splitSettingValues(settings[awarenessAttributesSetting])
+	if len(attributes) == 0 {
+		return []string{}, nil
+	}
+
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes")
+	}
+
+	nodeAttributes := map[string]map[string]string{}
+
+	for _, n := range nodes {
+		attrs, err := w.ES.NodeAttributes(n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve attributes of node %s", n)
+		}
+
+		nodeAttributes[n] = attrs
+	}
+
+	return awarenessViolations(settings, attributes, nodeAttributes, removing, added), nil
+}
+
+// awarenessViolations returns violations of allocation awareness
+// Removing the last node having forced awareness value leaves its shards nowhere to go, so drain never finishes
+// Node without awareness attribute never gets shards
+func awarenessViolations(settings map[string]string, attributes []string, nodeAttributes map[string]map[string]string, removing string, added []string) []string {
+	warnings := []string{}
+
+	for _, attr := range attributes {
+		if removing != "" {
+			value := nodeAttributes[removing][attr]
+
+			if value != "" && contains(splitSettingValues(settings[forcedAwarenessSettingPrefix+attr+".values"]), value) && !hasAttribute(nodeAttributes, removing, attr, value) {
+				warnings = append(warnings, fmt.Sprintf("%s is the last node with %s=%s, which is forced awareness value. Shards on the node cannot be relocated to other %s and drain will never finish", removing, attr, value, attr))
+			}
+		}
+
+		for _, n := range added {
+			if _, ok := nodeAttributes[n][attr]; !ok {
+				warnings = append(warnings, fmt.Sprintf("%s does not have awareness attribute %s, so shards will not be allocated to it", n, attr))
+			}
+		}
+	}
+
+	sort.Strings(warnings)
+
+	return warnings
+}
+
+// hasAttribute returns whether any node other than the excluded one has the given attribute value
+func hasAttribute(nodeAttributes map[string]map[string]string, excluded, attr, value string) bool {
+	for n, attrs := range nodeAttributes {
+		if n != excluded && attrs[attr] == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitSettingValues splits list setting given in comma-separated string, or in "[a b]" if it was set as JSON array
+func splitSettingValues(v string) []string {
+	v = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(v), "["), "]")
+
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
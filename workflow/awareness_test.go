@@ -0,0 +1,53 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAwarenessViolations(t *testing.T) {
+	nodeAttributes := map[string]map[string]string{
+		"node-1": {"zone": "ap-northeast-1a"},
+		"node-2": {"zone": "ap-northeast-1a"},
+		"node-3": {"zone": "ap-northeast-1c"},
+		"node-4": {},
+	}
+
+	testcases := []struct {
+		settings map[string]string
+		removing string
+		added    []string
+		expected []string
+	}{
+		{
+			settings: map[string]string{"cluster.routing.allocation.awareness.attributes": "zone", "cluster.routing.allocation.awareness.force.zone.values": "ap-northeast-1a,ap-northeast-1c"},
+			removing: "node-3",
+			expected: []string{"node-3 is the last node with zone=ap-northeast-1c, which is forced awareness value. Shards on the node cannot be relocated to other zone and drain will never finish"},
+		},
+		{
+			settings: map[string]string{"cluster.routing.allocation.awareness.attributes": "zone", "cluster.routing.allocation.awareness.force.zone.values": "[ap-northeast-1a ap-northeast-1c]"},
+			removing: "node-1",
+			expected: []string{},
+		},
+		{
+			// Without forced awareness, copies are spread over the remaining zones
+			settings: map[string]string{"cluster.routing.allocation.awareness.attributes": "zone"},
+			removing: "node-3",
+			expected: []string{},
+		},
+		{
+			settings: map[string]string{"cluster.routing.allocation.awareness.attributes": "zone"},
+			added:    []string{"node-3", "node-4"},
+			expected: []string{"node-4 does not have awareness attribute zone, so shards will not be allocated to it"},
+		},
+	}
+
+	for _, tc := range testcases {
+		attributes := splitSettingValues(tc.settings[awarenessAttributesSetting])
+
+		got := awarenessViolations(tc.settings, attributes, nodeAttributes, tc.removing, tc.added)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("warnings do not match. settings: %v, expected: %v, got: %v", tc.settings, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+const maxBytesPerSecSetting = "indices.recovery.max_bytes_per_sec"
+
+// defaultMaxBytesPerSec represents the default of indices.recovery.max_bytes_per_sec
+const defaultMaxBytesPerSec = "40mb"
+
+// RecoveryBenchmark represents recovery throughput measured by BenchRecovery
+type RecoveryBenchmark struct {
+	Index    string `json:"index"`
+	Shard    int    `json:"shard"`
+	FromNode string `json:"from_node"`
+	ToNode   string `json:"to_node"`
+
+	// SampleBytes represents size of sample shard
+	SampleBytes int64 `json:"sample_bytes"`
+
+	// Bytes and Duration are summed over relocation to ToNode and back
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"duration"`
+
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	MaxBytesPerSec string  `json:"max_bytes_per_sec"`
+
+	Nodes []*DrainProjection `json:"nodes"`
+}
+
+// DrainProjection represents how long draining node would take at measured throughput
+type DrainProjection struct {
+	Name       string        `json:"name"`
+	StoreBytes int64         `json:"store_bytes"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// BenchRecovery relocates sample shard to another node and back, and measures recovery throughput
+// Drain duration of each node is projected from the throughput, assuming shards are recovered one after another
+func (w *Workflow) BenchRecovery(ctx context.Context, opts BenchRecoveryOptions) (*RecoveryBenchmark, error) {
+	if opts.FromNode == "" || opts.ToNode == "" {
+		return nil, exitcode.New(exitcode.Validation, "source and destination nodes must be specified")
+	}
+
+	if opts.FromNode == opts.ToNode {
+		return nil, exitcode.New(exitcode.Validation, "source and destination nodes must be different")
+	}
+
+	if opts.HTTPClient == nil {
+		return nil, errors.New("HTTP client must be given to benchmark recovery")
+	}
+
+	op := w.operation(opts.Operation, "bench-recovery")
+
+	op.Phase("Picking sample shard")
+
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes")
+	}
+
+	for _, n := range []string{opts.FromNode, opts.ToNode} {
+		if !contains(nodes, n) {
+			return nil, exitcode.Errorf(exitcode.Validation, "node %s is not in the cluster", n)
+		}
+	}
+
+	shards, err := es.ShardStores(w.ClusterURL, opts.HTTPClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list shards")
+	}
+
+	sample, err := pickSampleShard(shards, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	b := &RecoveryBenchmark{
+		Index:          sample.Index,
+		Shard:          sample.Shard,
+		FromNode:       opts.FromNode,
+		ToNode:         opts.ToNode,
+		SampleBytes:    sample.StoreBytes,
+		MaxBytesPerSec: settings[maxBytesPerSecSetting],
+	}
+
+	if b.MaxBytesPerSec == "" {
+		b.MaxBytesPerSec = defaultMaxBytesPerSec
+	}
+
+	for _, hop := range [][2]string{{opts.FromNode, opts.ToNode}, {opts.ToNode, opts.FromNode}} {
+		r, err := w.relocateShard(ctx, op, opts, sample, hop[0], hop[1])
+		if err != nil {
+			return nil, err
+		}
+
+		b.Bytes += r.BytesRecovered
+		b.Duration += r.Time
+	}
+
+	if b.Duration > 0 {
+		b.BytesPerSecond = float64(b.Bytes) / b.Duration.Seconds()
+	}
+
+	op.Phase("Projecting drain duration of each node")
+
+	nodeStats, err := w.ES.NodeStats()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve node stats")
+	}
+
+	b.Nodes = projectDrain(nodeStats, b.BytesPerSecond)
+
+	return b, nil
+}
+
+// relocateShard moves sample shard and waits until it starts on the destination
+func (w *Workflow) relocateShard(ctx context.Context, op *operation.Operation, opts BenchRecoveryOptions, sample *es.ShardStore, from, to string) (*stats.Recovery, error) {
+	op.Phase(fmt.Sprintf("Relocating [%s][%d] from %s to %s", sample.Index, sample.Shard, from, to))
+
+	if err := es.MoveShard(w.ClusterURL, opts.HTTPClient, sample.Index, sample.Shard, from, to); err != nil {
+		return nil, errors.Wrap(err, "failed to relocate sample shard")
+	}
+
+	err := w.waitFor(ctx, op, rebalanceTimeout, "shards", "timed out: sample shard is not relocated", func() (waitStatus, error) {
+		shards, err := es.ShardStores(w.ClusterURL, opts.HTTPClient)
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to list shards")
+		}
+
+		for _, s := range shards {
+			if s.Index == sample.Index && s.Shard == sample.Shard && s.Node == to && s.State == "STARTED" {
+				return waitStatus{}, nil
+			}
+		}
+
+		return waitStatus{Remaining: 1, Bytes: sample.StoreBytes}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := es.ShardRecovery(w.ClusterURL, opts.HTTPClient, sample.Index, sample.Shard, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve recovery of sample shard")
+	}
+
+	if r == nil {
+		return nil, errors.Errorf("recovery of [%s][%d] onto %s is not found", sample.Index, sample.Shard, to)
+	}
+
+	return r, nil
+}
+
+// pickSampleShard returns the given shard on FromNode, or the largest started shard up to MaxBytes
+// Shard whose another copy is on ToNode cannot be moved there, so it is never picked
+func pickSampleShard(shards []*es.ShardStore, opts BenchRecoveryOptions) (*es.ShardStore, error) {
+	onTo := map[string]bool{}
+
+	for _, s := range shards {
+		if s.Node == opts.ToNode {
+			onTo[fmt.Sprintf("%s/%d", s.Index, s.Shard)] = true
+		}
+	}
+
+	var picked *es.ShardStore
+
+	for _, s := range shards {
+		if s.Node != opts.FromNode || s.State != "STARTED" || onTo[fmt.Sprintf("%s/%d", s.Index, s.Shard)] {
+			continue
+		}
+
+		if opts.Index != "" {
+			if s.Index == opts.Index && s.Shard == opts.Shard {
+				return s, nil
+			}
+
+			continue
+		}
+
+		if opts.MaxBytes > 0 && s.StoreBytes > opts.MaxBytes {
+			continue
+		}
+
+		if picked == nil || s.StoreBytes > picked.StoreBytes {
+			picked = s
+		}
+	}
+
+	if opts.Index != "" {
+		return nil, exitcode.Errorf(exitcode.Validation, "[%s][%d] has no started copy on %s which can be moved to %s", opts.Index, opts.Shard, opts.FromNode, opts.ToNode)
+	}
+
+	if picked == nil || picked.StoreBytes == 0 {
+		return nil, exitcode.Errorf(exitcode.Validation, "no shard on %s can be moved to %s as sample", opts.FromNode, opts.ToNode)
+	}
+
+	return picked, nil
+}
+
+// projectDrain returns drain duration of each node with shards, sorted from the longest
+func projectDrain(nodes []*stats.Node, bytesPerSecond float64) []*DrainProjection {
+	projections := []*DrainProjection{}
+
+	for _, n := range nodes {
+		if n.StoreBytes == 0 {
+			continue
+		}
+
+		p := &DrainProjection{
+			Name:       n.Name,
+			StoreBytes: n.StoreBytes,
+		}
+
+		if bytesPerSecond > 0 {
+			p.Duration = time.Duration(float64(n.StoreBytes) / bytesPerSecond * float64(time.Second))
+		}
+
+		projections = append(projections, p)
+	}
+
+	sort.Slice(projections, func(i, j int) bool {
+		if projections[i].StoreBytes != projections[j].StoreBytes {
+			return projections[i].StoreBytes > projections[j].StoreBytes
+		}
+
+		return projections[i].Name < projections[j].Name
+	})
+
+	return projections
+}
+
+// Render prints measured throughput and drain duration of each node
+func (b *RecoveryBenchmark) Render(out io.Writer) {
+	fmt.Fprintf(out, "Sample:     [%s][%d] (%s) between %s and %s\n", b.Index, b.Shard, formatBytes(b.SampleBytes), b.FromNode, b.ToNode)
+	fmt.Fprintf(out, "Throughput: %s/s in %s (%s: %s)\n\n", formatBytes(int64(b.BytesPerSecond)), roundDuration(b.Duration), maxBytesPerSecSetting, b.MaxBytesPerSec)
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tSTORE\tDRAIN")
+
+	for _, n := range b.Nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", n.Name, formatBytes(n.StoreBytes), roundDuration(n.Duration))
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,141 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+type benchClient struct {
+	es.Client
+}
+
+func (c *benchClient) ClusterSettings() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (c *benchClient) ListNodes() ([]string, error) {
+	return []string{"node-1", "node-2"}, nil
+}
+
+func (c *benchClient) NodeStats() ([]*stats.Node, error) {
+	return []*stats.Node{
+		{Name: "node-1", StoreBytes: 100 << 20},
+		{Name: "node-2", StoreBytes: 200 << 20},
+		{Name: "master-1"},
+	}, nil
+}
+
+func TestBenchRecovery(t *testing.T) {
+	node := "node-1"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_cat/shards":
+			fmt.Fprintf(w, `[
+  {"index": "logs", "shard": "0", "prirep": "p", "state": "STARTED", "store": "10485760", "node": %q},
+  {"index": "logs", "shard": "1", "prirep": "p", "state": "STARTED", "store": "1048576", "node": "node-1"}
+]`, node)
+		case "/_cluster/reroute":
+			var body struct {
+				Commands []struct {
+					Move struct {
+						ToNode string `json:"to_node"`
+					} `json:"move"`
+				} `json:"commands"`
+			}
+
+			json.NewDecoder(r.Body).Decode(&body)
+			node = body.Commands[0].Move.ToNode
+
+			w.Write([]byte(`{"acknowledged": true}`))
+		case "/_cat/recovery/logs":
+			w.Write([]byte(`[
+  {"index": "logs", "shard": "0", "time": "1000", "type": "peer", "stage": "done", "source_node": "node-1", "target_node": "node-2", "bytes_recovered": "10485760", "bytes_total": "10485760"},
+  {"index": "logs", "shard": "0", "time": "1000", "type": "peer", "stage": "done", "source_node": "node-2", "target_node": "node-1", "bytes_recovered": "10485760", "bytes_total": "10485760"}
+]`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	w := &Workflow{
+		ClusterURL: ts.URL,
+		ES:         &benchClient{},
+		MinPoll:    time.Millisecond,
+		MaxPoll:    time.Millisecond,
+	}
+
+	b, err := w.BenchRecovery(context.Background(), BenchRecoveryOptions{
+		FromNode:   "node-1",
+		ToNode:     "node-2",
+		HTTPClient: &http.Client{},
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if b.Index != "logs" || b.Shard != 0 || b.BytesPerSecond != 10<<20 || b.MaxBytesPerSec != defaultMaxBytesPerSec {
+		t.Errorf("benchmark does not match. got: %+v", b)
+	}
+
+	if node != "node-1" {
+		t.Errorf("sample shard should be moved back. got: %s", node)
+	}
+
+	if len(b.Nodes) != 2 || b.Nodes[0].Name != "node-2" || b.Nodes[0].Duration != 20*time.Second {
+		t.Errorf("projection does not match. got: %+v", b.Nodes[0])
+	}
+}
+
+func TestPickSampleShard(t *testing.T) {
+	shards := []*es.ShardStore{
+		{Index: "logs", Shard: 0, State: "STARTED", Node: "node-1", StoreBytes: 300},
+		{Index: "logs", Shard: 0, State: "STARTED", Node: "node-2", StoreBytes: 300},
+		{Index: "logs", Shard: 1, State: "STARTED", Node: "node-1", StoreBytes: 200},
+		{Index: "logs", Shard: 2, State: "STARTED", Node: "node-1", StoreBytes: 100},
+		{Index: "logs", Shard: 3, State: "RELOCATING", Node: "node-1", StoreBytes: 250},
+	}
+
+	testcases := []struct {
+		opts     BenchRecoveryOptions
+		expected int
+		code     int
+	}{
+		{opts: BenchRecoveryOptions{FromNode: "node-1", ToNode: "node-2"}, expected: 1},
+		{opts: BenchRecoveryOptions{FromNode: "node-1", ToNode: "node-2", MaxBytes: 150}, expected: 2},
+		{opts: BenchRecoveryOptions{FromNode: "node-1", ToNode: "node-2", Index: "logs", Shard: 2}, expected: 2},
+		{opts: BenchRecoveryOptions{FromNode: "node-1", ToNode: "node-2", Index: "logs", Shard: 0}, code: exitcode.Validation},
+		{opts: BenchRecoveryOptions{FromNode: "node-1", ToNode: "node-2", MaxBytes: 50}, code: exitcode.Validation},
+	}
+
+	for _, tc := range testcases {
+		got, err := pickSampleShard(shards, tc.opts)
+
+		if tc.code != 0 {
+			if exitcode.Code(err) != tc.code {
+				t.Errorf("exit code does not match. expected: %d, got: %d (%v)", tc.code, exitcode.Code(err), err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+			continue
+		}
+
+		if got.Shard != tc.expected {
+			t.Errorf("sample shard does not match. expected: %d, got: %d", tc.expected, got.Shard)
+		}
+	}
+}
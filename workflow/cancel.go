@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// CancelRemoval rolls back node removal interrupted before shutdown
+// Allocation exclusion of the node is cleared and its instance is registered with target group again
+func (w *Workflow) CancelRemoval(ctx context.Context, opts RemoveOptions) error {
+	op := opts.Operation
+	if op == nil {
+		op = operation.New("cancel-remove", w.ClusterURL)
+	}
+
+	op.Phase(RemoveStepDescription(StepResolve))
+
+	s, err := w.RemoveStep(ctx, &RemoveState{
+		Group:    opts.Group,
+		NodeName: opts.NodeName,
+		Step:     StepResolve,
+	})
+	if err != nil {
+		return err
+	}
+
+	op.Phase(fmt.Sprintf("Clearing allocation exclusion of %s", opts.NodeName))
+
+	included, err := w.includeInAllocation(opts.NodeName)
+	if err != nil {
+		return err
+	}
+
+	if !included {
+		op.Logf("%s\n", SkippedMessage)
+	}
+
+	// Node registered for removal by shutdown of machine learning node would keep rejecting jobs
+	if w.supports((*es.Capabilities).SupportsNodeShutdownAPI) {
+		if err := w.ES.ClearNodeShutdown(opts.NodeName); err != nil {
+			return errors.Wrap(err, "failed to cancel registration of node for removal")
+		}
+	}
+
+	op.Phase("Registering instance with target group")
+
+	attached, err := w.attachedToTargetGroup(s)
+	if err != nil {
+		return err
+	}
+
+	if attached {
+		op.Logf("%s\n", SkippedMessage)
+		return nil
+	}
+
+	if err := w.ELBv2.RegisterInstance(s.TargetGroupARN, s.InstanceID); err != nil {
+		return errors.Wrap(err, "failed to register instance with target group")
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestCancelRemoval(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	s, err := w.RemoveStep(context.Background(), &RemoveState{Group: fake.GroupName, NodeName: nodeName, Step: StepResolve})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	// Removal interrupted while waiting for shards to escape
+	for s.Step != StepWaitDrain {
+		s, err = w.RemoveStep(context.Background(), s)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	if err := w.CancelRemoval(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("allocation exclusion should be cleared. excluded: %q", got)
+	}
+
+	instances, _ := c.ELBv2().ListTargetInstances(fake.TargetGroupARN)
+
+	found := false
+
+	for _, id := range instances {
+		if id == s.InstanceID {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("instance %s should be registered with target group again. got: %v", s.InstanceID, instances)
+	}
+}
+
+func TestCancelRemoval_otherExclusionKept(t *testing.T) {
+	c := fake.NewCluster(4)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+	other := "ip-10-0-1-3.ec2.internal"
+
+	// Another node is being removed at the same time
+	c.ExcludeNodeFromAllocation(other)
+
+	s, err := w.RemoveStep(context.Background(), &RemoveState{Group: fake.GroupName, NodeName: nodeName, Step: StepResolve})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	for s.Step != StepWaitDrain {
+		s, err = w.RemoveStep(context.Background(), s)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	if got, expected := c.ExcludedNode(), other+","+nodeName; got != expected {
+		t.Errorf("node should be appended to allocation exclusion. expected: %q, got: %q", expected, got)
+	}
+
+	if err := w.CancelRemoval(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := c.ExcludedNode(); got != other {
+		t.Errorf("only the cancelled node should be included in allocation again. expected: %q, got: %q", other, got)
+	}
+}
@@ -0,0 +1,24 @@
+package workflow
+
+import (
+	"github.com/dtan4/esnctl/es"
+)
+
+// supports returns whether the API is available in the cluster
+// Every API is assumed to be available if Capabilities is not set, e.g. workflow with fake client
+func (w *Workflow) supports(f func(c *es.Capabilities) bool) bool {
+	if w.Capabilities == nil {
+		return true
+	}
+
+	return f(w.Capabilities)
+}
+
+// require fails with the given advice if the API is not available in the cluster
+func (w *Workflow) require(f func(c *es.Capabilities) bool, feature, advice string) error {
+	if w.Capabilities == nil {
+		return nil
+	}
+
+	return w.Capabilities.Require(f(w.Capabilities), feature, advice)
+}
@@ -0,0 +1,106 @@
+package workflow
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxShardsPerNodeSetting limits the number of shards in the cluster to this value times the number of nodes
+	// Exceeding it blocks index creation, e.g. daily indices, after the node is removed
+	maxShardsPerNodeSetting = "cluster.max_shards_per_node"
+
+	// totalShardsPerNodeSetting limits the number of shards allocated to each node
+	// Shards which cannot fit anywhere stay on the excluded node, and drain never finishes
+	totalShardsPerNodeSetting = "cluster.routing.allocation.total_shards_per_node"
+)
+
+// checkShardCapacity verifies the remaining nodes can host all shards after the given node is removed
+// Limits are checked only if they are set explicitly, because Elasticsearch 6.x and older do not enforce max_shards_per_node
+func (w *Workflow) checkShardCapacity(nodeName string, opts RemoveOptions) error {
+	op := opts.Operation
+
+	op.Phase("Checking shard capacity of remaining nodes")
+
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	maxShards, err := shardLimit(settings, maxShardsPerNodeSetting)
+	if err != nil {
+		return err
+	}
+
+	totalShards, err := shardLimit(settings, totalShardsPerNodeSetting)
+	if err != nil {
+		return err
+	}
+
+	if maxShards == 0 && totalShards == 0 {
+		return nil
+	}
+
+	nodes, err := w.ES.NodeStats()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve node stats")
+	}
+
+	shards, err := w.ES.ListShards()
+	if err != nil {
+		return errors.Wrap(err, "failed to list shards")
+	}
+
+	// Nodes without data role are counted as well, so capacity may be overestimated but never underestimated
+	var remaining, displaced, free int
+
+	for _, n := range nodes {
+		if n.Name == nodeName {
+			displaced = n.Shards
+			continue
+		}
+
+		remaining++
+
+		if totalShards > 0 && n.Shards < totalShards {
+			free += totalShards - n.Shards
+		}
+	}
+
+	if remaining == 0 {
+		return exitcode.Errorf(exitcode.Validation, "no node would remain to host %d shards of %s", displaced, nodeName)
+	}
+
+	if totalShards > 0 && free < displaced {
+		return exitcode.Errorf(exitcode.Validation, "%d shards on %s cannot be relocated, because %s=%d leaves room for only %d shards on %d remaining nodes. Raise the limit or add nodes first", displaced, nodeName, totalShardsPerNodeSetting, totalShards, free, remaining)
+	}
+
+	if maxShards > 0 && len(shards) > maxShards*remaining {
+		return exitcode.Errorf(exitcode.Validation, "cluster has %d shards, but %s=%d allows only %d shards on %d remaining nodes. Raise the limit, close or delete indices, or add nodes first", len(shards), maxShardsPerNodeSetting, maxShards, maxShards*remaining, remaining)
+	}
+
+	return nil
+}
+
+// shardLimit returns the given limit in cluster settings, or 0 if it is not set
+// Negative total_shards_per_node means unlimited
+func shardLimit(settings map[string]string, key string) (int, error) {
+	v := strings.TrimSpace(settings[key])
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s %q", key, v)
+	}
+
+	if n < 0 {
+		return 0, nil
+	}
+
+	return n, nil
+}
@@ -0,0 +1,142 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dtan4/esnctl/aws/autoscaling"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// decommissionTimeout represents how long to wait for nodes in the zone to be decommissioned
+const decommissionTimeout = 30 * time.Minute
+
+// DecommissionZoneOptions represents options of DecommissionZone
+type DecommissionZoneOptions struct {
+	Group string
+	Zone  string
+
+	// Attribute represents awareness attribute whose value is AZ, e.g. zone
+	Attribute string
+
+	// HTTPClient is used for zone decommission API, which is available only in OpenSearch
+	HTTPClient *http.Client
+
+	// TerminateInstances terminates instances after detaching them from Auto Scaling Group
+	TerminateInstances bool
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// DecommissionZone drains all nodes in the given AZ with OpenSearch zone decommission API, then detaches their instances
+func (w *Workflow) DecommissionZone(ctx context.Context, opts DecommissionZoneOptions) error {
+	if opts.Group == "" {
+		return exitcode.New(exitcode.Validation, "group must be specified")
+	}
+
+	if opts.Zone == "" {
+		return exitcode.New(exitcode.Validation, "zone must be specified")
+	}
+
+	if opts.Attribute == "" {
+		return exitcode.New(exitcode.Validation, "awareness attribute must be specified")
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	op := w.operation(opts.Operation, "decommission-zone")
+
+	if err := w.require((*es.Capabilities).SupportsZoneDecommission, "zone decommission API", "It requires OpenSearch 2.4 or later. Use esnctl remove for each node instead."); err != nil {
+		return err
+	}
+
+	op.Phase(fmt.Sprintf("Retrieving instances in %s", opts.Zone))
+
+	instances, err := w.AutoScaling.DescribeInstances(opts.Group)
+	if err != nil {
+		return errors.Wrap(err, "failed to describe instances in AutoScaling Group")
+	}
+
+	targets := instancesInZone(instances, opts.Zone)
+
+	if len(targets) == 0 {
+		return exitcode.Errorf(exitcode.Validation, "no instance of %s is in %s", opts.Group, opts.Zone)
+	}
+
+	if len(targets) == len(instances) {
+		return exitcode.Errorf(exitcode.Validation, "all instances of %s are in %s, no node would remain", opts.Group, opts.Zone)
+	}
+
+	op.Phase(fmt.Sprintf("Decommissioning %d nodes in %s", len(targets), opts.Zone))
+
+	if err := es.DecommissionZone(w.ClusterURL, httpClient, opts.Attribute, opts.Zone); err != nil {
+		return errors.Wrap(err, "failed to decommission zone")
+	}
+
+	op.Phase("Waiting for zone decommission")
+
+	if err := w.waitFor(ctx, op, decommissionTimeout, "zones", "timed out: zone is not decommissioned", func() (waitStatus, error) {
+		status, err := es.DecommissionStatus(w.ClusterURL, httpClient, opts.Attribute, opts.Zone)
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to retrieve decommission status")
+		}
+
+		switch status {
+		case es.DecommissionSuccessful:
+			return waitStatus{}, nil
+		case es.DecommissionFailed:
+			return waitStatus{}, errors.Errorf("decommission of %s failed", opts.Zone)
+		}
+
+		return waitStatus{Remaining: 1}, nil
+	}); err != nil {
+		return err
+	}
+
+	op.Phase("Detaching instances")
+
+	targetGroupARN, err := w.AutoScaling.RetrieveTargetGroup(opts.Group)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve target group")
+	}
+
+	for _, id := range targets {
+		if err := w.ELBv2.DetachInstance(targetGroupARN, id); err != nil {
+			return errors.Wrapf(err, "failed to detach %s from target group", id)
+		}
+
+		if err := w.AutoScaling.DetachInstance(opts.Group, id, true); err != nil {
+			return errors.Wrapf(err, "failed to detach %s from AutoScaling Group", id)
+		}
+
+		if opts.TerminateInstances {
+			if err := w.EC2.TerminateInstance(id); err != nil {
+				return errors.Wrapf(err, "failed to terminate %s", id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// instancesInZone returns IDs of in-service instances in the given AZ
+func instancesInZone(instances []*autoscaling.Instance, zone string) []string {
+	ids := []string{}
+
+	for _, i := range instances {
+		if i.AvailabilityZone == zone && i.LifecycleState == "InService" {
+			ids = append(ids, i.ID)
+		}
+	}
+
+	return ids
+}
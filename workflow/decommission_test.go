@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestDecommissionZone_fakeCluster(t *testing.T) {
+	decommissioned := ""
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/_cluster/decommission/awareness/zone/us-east-1a":
+			decommissioned = "us-east-1a"
+			w.Write([]byte(`{"acknowledged": true}`))
+		case r.Method == "GET" && r.URL.Path == "/_cluster/decommission/awareness/zone/_status":
+			w.Write([]byte(`{"` + decommissioned + `": "successful"}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.String())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+	w.ClusterURL = ts.URL
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionOpenSearch, "2.11.0")
+
+	opts := DecommissionZoneOptions{Group: fake.GroupName, Zone: "us-east-1a", Attribute: "zone"}
+
+	if err := w.DecommissionZone(context.Background(), opts); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	instances, _ := c.AutoScaling().DescribeInstances(fake.GroupName)
+
+	if len(instances) != 2 {
+		t.Fatalf("instances in the zone should be detached. got: %d instances", len(instances))
+	}
+
+	for _, i := range instances {
+		if i.AvailabilityZone == "us-east-1a" {
+			t.Errorf("instance in the zone should be detached. got: %s", i.ID)
+		}
+	}
+}
+
+func TestDecommissionZone_unsupported(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("decommission API should not be called. got: %s %s", r.Method, r.URL.String())
+	}))
+	defer ts.Close()
+
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+	w.ClusterURL = ts.URL
+
+	err := w.DecommissionZone(context.Background(), DecommissionZoneOptions{Group: fake.GroupName, Zone: "us-east-1a", Attribute: "zone"})
+
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Errorf("exit code for Elasticsearch does not match. expected: %d, got: %d (%v)", exitcode.Validation, got, err)
+	}
+}
@@ -0,0 +1,173 @@
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+// LastScaleTagKey represents tag of Auto Scaling Group recording the last desired capacity change made by esnctl
+const LastScaleTagKey = "esnctl:last-scale"
+
+// LastScale represents the last desired capacity change made by esnctl
+type LastScale struct {
+	Operation       string    `json:"operation"`
+	DesiredCapacity int       `json:"desired_capacity"`
+	Time            time.Time `json:"time"`
+}
+
+// String formats LastScale as value of LastScaleTagKey tag
+func (l *LastScale) String() string {
+	return fmt.Sprintf("operation=%s,desired=%d,time=%s", l.Operation, l.DesiredCapacity, l.Time.UTC().Format(time.RFC3339))
+}
+
+// ParseLastScale parses value of LastScaleTagKey tag
+func ParseLastScale(v string) (*LastScale, error) {
+	l := &LastScale{}
+
+	for _, kv := range strings.Split(v, ",") {
+		ss := strings.SplitN(kv, "=", 2)
+		if len(ss) != 2 {
+			return nil, errors.Errorf("invalid %s tag %q", LastScaleTagKey, v)
+		}
+
+		switch ss[0] {
+		case "operation":
+			l.Operation = ss[1]
+		case "desired":
+			n, err := strconv.Atoi(ss[1])
+			if err != nil {
+				return nil, errors.Errorf("invalid desired capacity in %s tag %q", LastScaleTagKey, v)
+			}
+
+			l.DesiredCapacity = n
+		case "time":
+			t, err := time.Parse(time.RFC3339, ss[1])
+			if err != nil {
+				return nil, errors.Errorf("invalid time in %s tag %q", LastScaleTagKey, v)
+			}
+
+			l.Time = t
+		}
+	}
+
+	return l, nil
+}
+
+// DriftReport represents divergence of Auto Scaling Group from desired capacity declared outside esnctl
+type DriftReport struct {
+	Group           string     `json:"group"`
+	Declared        int        `json:"declared"`
+	DesiredCapacity int        `json:"desired_capacity"`
+	MinSize         int        `json:"min_size"`
+	MaxSize         int        `json:"max_size"`
+	LastScale       *LastScale `json:"last_scale,omitempty"`
+
+	// ChangedOutside is true if desired capacity differs from the one recorded by esnctl, e.g. changed by scaling policy
+	ChangedOutside bool `json:"changed_outside"`
+}
+
+// Drifted returns whether desired capacity differs from the declared one
+func (r *DriftReport) Drifted() bool {
+	return r.DesiredCapacity != r.Declared
+}
+
+// Err returns error if desired capacity differs from the declared one
+func (r *DriftReport) Err() error {
+	if !r.Drifted() {
+		return nil
+	}
+
+	return exitcode.Errorf(exitcode.General, "desired capacity of %s is %d, but %d is declared", r.Group, r.DesiredCapacity, r.Declared)
+}
+
+// Render prints the report
+func (r *DriftReport) Render(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Group:\t%s\n", r.Group)
+	fmt.Fprintf(w, "Declared:\t%d\n", r.Declared)
+	fmt.Fprintf(w, "Desired capacity:\t%d (min %d, max %d)\n", r.DesiredCapacity, r.MinSize, r.MaxSize)
+
+	if r.LastScale != nil {
+		fmt.Fprintf(w, "Last scale by esnctl:\t%s to %d at %s\n", r.LastScale.Operation, r.LastScale.DesiredCapacity, r.LastScale.Time.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(w, "Last scale by esnctl:\t-\n")
+	}
+
+	w.Flush()
+
+	if r.ChangedOutside {
+		fmt.Fprintf(out, "WARNING: desired capacity was changed outside esnctl after its last scale\n")
+	}
+
+	if r.Drifted() {
+		fmt.Fprintf(out, "WARNING: desired capacity drifted by %+d from the declared one. Update the declaration (e.g. Terraform) or scale the group back\n", r.DesiredCapacity-r.Declared)
+	} else {
+		fmt.Fprintf(out, "No drift\n")
+	}
+}
+
+// Drift compares desired capacity of Auto Scaling Group with the declared one
+func (w *Workflow) Drift(opts DriftOptions) (*DriftReport, error) {
+	if opts.DesiredCapacity < 0 {
+		return nil, exitcode.Errorf(exitcode.Validation, "declared desired capacity must not be negative. got: %d", opts.DesiredCapacity)
+	}
+
+	group, err := w.AutoScaling.DescribeGroup(opts.Group)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe AutoScaling Group")
+	}
+
+	report := &DriftReport{
+		Group:           opts.Group,
+		Declared:        opts.DesiredCapacity,
+		DesiredCapacity: group.DesiredCapacity,
+		MinSize:         group.MinSize,
+		MaxSize:         group.MaxSize,
+	}
+
+	if v, ok := group.Tags[LastScaleTagKey]; ok {
+		l, err := ParseLastScale(v)
+		if err != nil {
+			return nil, err
+		}
+
+		report.LastScale = l
+		report.ChangedOutside = l.DesiredCapacity != group.DesiredCapacity
+	}
+
+	return report, nil
+}
+
+// tagLastScale records the current desired capacity of the group in LastScaleTagKey tag
+// Failure is only warned, because the instances have already been changed
+func (w *Workflow) tagLastScale(groupName, operation string) {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	group, err := w.AutoScaling.DescribeGroup(groupName)
+	if err != nil {
+		fmt.Fprintf(progress, "WARNING: failed to tag %s with %s: %s\n", groupName, LastScaleTagKey, err)
+		return
+	}
+
+	l := &LastScale{
+		Operation:       operation,
+		DesiredCapacity: group.DesiredCapacity,
+		Time:            time.Now(),
+	}
+
+	if err := w.AutoScaling.SetTag(groupName, LastScaleTagKey, l.String()); err != nil {
+		fmt.Fprintf(progress, "WARNING: failed to tag %s with %s: %s\n", groupName, LastScaleTagKey, err)
+	}
+}
@@ -0,0 +1,114 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestLastScale(t *testing.T) {
+	l := &LastScale{
+		Operation:       "add",
+		DesiredCapacity: 5,
+		Time:            time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+	}
+
+	v := l.String()
+	if expected := "operation=add,desired=5,time=2026-10-16T09:00:00Z"; v != expected {
+		t.Errorf("tag value does not match. expected: %q, got: %q", expected, v)
+	}
+
+	got, err := ParseLastScale(v)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got.Operation != l.Operation || got.DesiredCapacity != l.DesiredCapacity || !got.Time.Equal(l.Time) {
+		t.Errorf("last scale does not match. expected: %v, got: %v", l, got)
+	}
+
+	if _, err := ParseLastScale("desired=five"); err == nil {
+		t.Errorf("error should be raised for invalid desired capacity")
+	}
+}
+
+func TestDrift(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 2, TagScale: true}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	l, err := ParseLastScale(c.GroupTag(LastScaleTagKey))
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if l.Operation != "add" || l.DesiredCapacity != 5 {
+		t.Errorf("last scale does not match. expected: add to 5, got: %s to %d", l.Operation, l.DesiredCapacity)
+	}
+
+	report, err := w.Drift(DriftOptions{Group: fake.GroupName, DesiredCapacity: 3})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !report.Drifted() || report.ChangedOutside {
+		t.Errorf("report should be drifted without change outside esnctl. got: %+v", report)
+	}
+
+	if report.Err() == nil {
+		t.Errorf("error should be raised for drifted group")
+	}
+
+	// Scaling by others, e.g. scaling policy, is told apart from esnctl's own change
+	if _, err := c.AutoScaling().IncreaseInstances(fake.GroupName, 1); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	report, err = w.Drift(DriftOptions{Group: fake.GroupName, DesiredCapacity: 6})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if report.Drifted() || !report.ChangedOutside {
+		t.Errorf("report should not be drifted but changed outside esnctl. got: %+v", report)
+	}
+}
+
+func TestDrift_untagged(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	report, err := w.Drift(DriftOptions{Group: fake.GroupName, DesiredCapacity: 3})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if report.Drifted() || report.LastScale != nil {
+		t.Errorf("report should not be drifted without last scale. got: %+v", report)
+	}
+}
+
+func TestRemoveNode_tagScale(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodes, _ := c.ListNodes()
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodes[0], TagScale: true}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	l, err := ParseLastScale(c.GroupTag(LastScaleTagKey))
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if l.Operation != "remove" || l.DesiredCapacity != 2 {
+		t.Errorf("last scale does not match. expected: remove to 2, got: %s to %d", l.Operation, l.DesiredCapacity)
+	}
+}
@@ -0,0 +1,233 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+const rebalanceEnableSetting = "cluster.routing.rebalance.enable"
+
+// healthRank orders cluster health from the best
+var healthRank = map[string]int{
+	"green":  0,
+	"yellow": 1,
+	"red":    2,
+}
+
+// DrillReport represents timings measured by Drill
+type DrillReport struct {
+	NodeName string `json:"node_name"`
+	Shards   int    `json:"shards"`
+	Bytes    int64  `json:"bytes"`
+
+	DrainDuration  time.Duration `json:"drain_duration"`
+	ReturnDuration time.Duration `json:"return_duration"`
+
+	// WorstHealth represents the worst cluster health observed while the node was excluded
+	WorstHealth string `json:"worst_health"`
+
+	Window time.Duration `json:"window,omitempty"`
+}
+
+// WithinWindow returns whether drain finished within maintenance window
+func (r *DrillReport) WithinWindow() bool {
+	return r.Window == 0 || r.DrainDuration <= r.Window
+}
+
+// Render prints measured timings
+func (r *DrillReport) Render(out io.Writer) {
+	fmt.Fprintf(out, "Node:         %s\n", r.NodeName)
+	fmt.Fprintf(out, "Shards:       %d (%s)\n", r.Shards, formatBytes(r.Bytes))
+	fmt.Fprintf(out, "Drain:        %s\n", roundDuration(r.DrainDuration))
+	fmt.Fprintf(out, "Return:       %s\n", roundDuration(r.ReturnDuration))
+	fmt.Fprintf(out, "Worst health: %s\n", r.WorstHealth)
+
+	if r.Window > 0 {
+		fmt.Fprintf(out, "Window:       %s (within: %t)\n", r.Window, r.WithinWindow())
+	}
+}
+
+// Drill simulates removal of the given node without touching AWS resources
+// The node is excluded from allocation until drained, then included again and waited until shards return
+// Exclusion is always cleared, even if drill fails halfway. Cluster turning red aborts the drill
+func (w *Workflow) Drill(ctx context.Context, opts DrillOptions) (report *DrillReport, err error) {
+	if opts.NodeName == "" {
+		return nil, exitcode.New(exitcode.Validation, "node name must be specified")
+	}
+
+	if opts.Tolerance < 0 {
+		return nil, exitcode.New(exitcode.Validation, "tolerance must not be negative")
+	}
+
+	op := w.operation(opts.Operation, "drill")
+
+	op.Phase("Checking cluster before drill")
+
+	if err := w.checkBeforeDrill(opts.NodeName); err != nil {
+		return nil, err
+	}
+
+	shards, err := w.ES.ListShardsOnNode(opts.NodeName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list shards on the given node")
+	}
+
+	report = &DrillReport{
+		NodeName:    opts.NodeName,
+		Shards:      len(shards),
+		Bytes:       w.nodeStoreBytes(opts.NodeName),
+		WorstHealth: "green",
+		Window:      opts.Window,
+	}
+
+	op.Phase("Excluding target node from shard allocation group")
+
+	if err := w.ES.ExcludeNodeFromAllocation(opts.NodeName); err != nil {
+		return nil, errors.Wrap(err, "failed to exclude node from allocation group")
+	}
+
+	included := false
+
+	defer func() {
+		if included {
+			return
+		}
+
+		op.Phase("Including target node in shard allocation group")
+
+		if _, rerr := w.includeInAllocation(opts.NodeName); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	op.Phase("Waiting for shards escape from target node")
+
+	start := time.Now()
+
+	err = w.waitFor(ctx, op, w.shardDrainTimeout(), "shards", "timed out: shards do not escape from target node", func() (waitStatus, error) {
+		if err := w.observeHealth(report); err != nil {
+			return waitStatus{}, err
+		}
+
+		shards, err := w.ES.ListShardsOnNode(opts.NodeName)
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to list shards on the given node")
+		}
+
+		return waitStatus{Remaining: len(shards), Bytes: w.nodeStoreBytes(opts.NodeName)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.DrainDuration = time.Since(start)
+
+	op.Phase("Including target node in shard allocation group")
+
+	if _, err := w.includeInAllocation(opts.NodeName); err != nil {
+		return nil, err
+	}
+
+	included = true
+
+	op.Phase("Waiting for shards to return to target node")
+
+	start = time.Now()
+	want := report.Shards - opts.Tolerance
+
+	err = w.waitFor(ctx, op, rebalanceTimeout, "shards", "timed out: shards do not return to target node", func() (waitStatus, error) {
+		health, err := w.ES.ClusterHealth()
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to retrieve cluster health")
+		}
+
+		shards, err := w.ES.ListShardsOnNode(opts.NodeName)
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to list shards on the given node")
+		}
+
+		remaining := want - len(shards)
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		// Shards still moving may be on the way to other nodes, so the cluster has to settle as well
+		return waitStatus{Remaining: remaining + health.RelocatingShards}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.ReturnDuration = time.Since(start)
+
+	if !report.WithinWindow() {
+		return report, exitcode.Errorf(exitcode.General, "drain took %s, longer than maintenance window %s", roundDuration(report.DrainDuration), report.Window)
+	}
+
+	return report, nil
+}
+
+// checkBeforeDrill verifies that the node is in the cluster, the cluster is green and nothing else uses allocation exclusion
+// Shards would never return if rebalancing is disabled
+func (w *Workflow) checkBeforeDrill(nodeName string) error {
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+
+	if !contains(nodes, nodeName) {
+		return exitcode.Errorf(exitcode.Validation, "node %s is not in the cluster", nodeName)
+	}
+
+	health, err := w.ES.ClusterHealth()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster health")
+	}
+
+	if health.Status != "green" {
+		return exitcode.Errorf(exitcode.Validation, "cluster health is %s. Drill must start from green cluster", health.Status)
+	}
+
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	if excluded := settings[ExcludeNameSetting]; excluded != "" {
+		return exitcode.Errorf(exitcode.Validation, "%s is already excluded from allocation, maybe by another operation", excluded)
+	}
+
+	if settings[rebalanceEnableSetting] == "none" {
+		return exitcode.Errorf(exitcode.Validation, "%s is none, so shards would never return to the node", rebalanceEnableSetting)
+	}
+
+	return nil
+}
+
+// observeHealth records the worst cluster health into report, and returns error if the cluster turns red
+func (w *Workflow) observeHealth(report *DrillReport) error {
+	health, err := w.ES.ClusterHealth()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster health")
+	}
+
+	if healthRank[health.Status] > healthRank[report.WorstHealth] {
+		report.WorstHealth = health.Status
+	}
+
+	if health.Status == "red" {
+		return exitcode.New(exitcode.Aborted, "cluster turned red while draining target node")
+	}
+
+	return nil
+}
+
+// roundDuration rounds duration to seconds for messages
+func roundDuration(d time.Duration) time.Duration {
+	return (d + time.Second/2) / time.Second * time.Second
+}
@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestDrill_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	before, _ := c.ListShardsOnNode(nodeName)
+
+	report, err := w.Drill(context.Background(), DrillOptions{NodeName: nodeName, Tolerance: 1})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if report.Shards != len(before) || report.WorstHealth != "green" || !report.WithinWindow() {
+		t.Errorf("report does not match. got: %+v", report)
+	}
+
+	after, _ := c.ListShardsOnNode(nodeName)
+	if len(after) < len(before)-1 {
+		t.Errorf("shards should return to target node. expected: >= %d, got: %d", len(before)-1, len(after))
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("exclusion should be cleared after drill. got: %q", got)
+	}
+}
+
+func TestDrill_window(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	report, err := w.Drill(context.Background(), DrillOptions{NodeName: "ip-10-0-1-2.ec2.internal", Window: time.Nanosecond})
+	if exitcode.Code(err) != exitcode.General {
+		t.Errorf("exit code does not match. expected: %d, got: %d (%v)", exitcode.General, exitcode.Code(err), err)
+	}
+
+	if report == nil || report.WithinWindow() {
+		t.Errorf("report should be returned with drain exceeding window. got: %+v", report)
+	}
+}
+
+func TestDrill_validation(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	if _, err := w.Drill(context.Background(), DrillOptions{NodeName: "ip-10-0-1-9.ec2.internal"}); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("unknown node should be rejected. got: %v", err)
+	}
+
+	c.ExcludeNodeFromAllocation("ip-10-0-1-3.ec2.internal")
+
+	if _, err := w.Drill(context.Background(), DrillOptions{NodeName: "ip-10-0-1-2.ec2.internal"}); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("drill should be rejected while another node is excluded. got: %v", err)
+	}
+
+	if got := c.ExcludedNode(); got != "ip-10-0-1-3.ec2.internal" {
+		t.Errorf("existing exclusion should be kept. got: %q", got)
+	}
+}
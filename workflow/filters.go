@@ -0,0 +1,118 @@
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pinningFilterSettings represents index allocation filters which can keep shards on the filtered node
+// Exclusion is not listed, because it never forces shards onto a node
+var pinningFilterSettings = []string{
+	"index.routing.allocation.require._name",
+	"index.routing.allocation.include._name",
+}
+
+// indexPin represents index allocation filter which allows shards of the index only on the target node
+type indexPin struct {
+	Index   string
+	Setting string
+	Value   string
+}
+
+func (p *indexPin) String() string {
+	return fmt.Sprintf("%s (%s=%s)", p.Index, p.Setting, p.Value)
+}
+
+// indexPins returns index allocation filters matching the given node but no other node in the cluster
+// Shards of such indices never escape from the node even if it is excluded from cluster-level allocation
+func (w *Workflow) indexPins(nodeName string) ([]*indexPin, error) {
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes")
+	}
+
+	filters, err := w.ES.IndexAllocationFilters()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve index allocation filters")
+	}
+
+	pins := []*indexPin{}
+
+	for index, settings := range filters {
+		for _, setting := range pinningFilterSettings {
+			value, ok := settings[setting]
+			if !ok || value == "" {
+				continue
+			}
+
+			patterns := strings.Split(value, ",")
+
+			if !matchesAny(patterns, nodeName) {
+				continue
+			}
+
+			pinned := true
+
+			for _, n := range nodes {
+				if n != nodeName && matchesAny(patterns, n) {
+					pinned = false
+					break
+				}
+			}
+
+			if pinned {
+				pins = append(pins, &indexPin{Index: index, Setting: setting, Value: value})
+			}
+		}
+	}
+
+	sort.Slice(pins, func(i, j int) bool {
+		if pins[i].Index != pins[j].Index {
+			return pins[i].Index < pins[j].Index
+		}
+
+		return pins[i].Setting < pins[j].Setting
+	})
+
+	return pins, nil
+}
+
+// matchesAny returns whether the given name matches any of node name patterns in allocation filter
+// Patterns support "*" wildcard like Elasticsearch does
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if simpleMatch(strings.TrimSpace(p), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func simpleMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+
+	if len(parts) == 1 {
+		return pattern == s
+	}
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+
+	s = s[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+
+		s = s[i+len(part):]
+	}
+
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
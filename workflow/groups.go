@@ -0,0 +1,128 @@
+package workflow
+
+import (
+	"strings"
+
+	"github.com/dtan4/esnctl/aws/ec2"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+// ResolveGroups returns the given groups, plus groups which have the given tag in key=value format
+// Clusters spread over several Auto Scaling Groups, e.g. one per AZ or per tier, are selected by tag
+func (w *Workflow) ResolveGroups(groups []string, tag string) ([]string, error) {
+	resolved := []string{}
+
+	for _, g := range groups {
+		if g != "" && !contains(resolved, g) {
+			resolved = append(resolved, g)
+		}
+	}
+
+	if tag != "" {
+		kv := strings.SplitN(tag, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, exitcode.Errorf(exitcode.Validation, "group tag must be in key=value format. got: %q", tag)
+		}
+
+		tagged, err := w.AutoScaling.ListGroupsByTag(kv[0], kv[1])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list AutoScaling Groups by tag")
+		}
+
+		if len(tagged) == 0 {
+			return nil, exitcode.Errorf(exitcode.Validation, "no AutoScaling Group has tag %s", tag)
+		}
+
+		for _, g := range tagged {
+			if !contains(resolved, g) {
+				resolved = append(resolved, g)
+			}
+		}
+	}
+
+	if len(resolved) == 0 {
+		return nil, exitcode.New(exitcode.Validation, "group must be specified")
+	}
+
+	return resolved, nil
+}
+
+// GroupOfNode returns the group which the instance of the given node actually belongs to, out of the given groups
+func (w *Workflow) GroupOfNode(nodeName string, groups []string) (string, error) {
+	instanceID, err := w.EC2.RetrieveInstanceIDFromPrivateDNS(nodeName)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve instance ID")
+	}
+
+	group, err := w.AutoScaling.RetrieveGroupOfInstance(instanceID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve AutoScaling Group of instance")
+	}
+
+	if !contains(groups, group) {
+		return "", exitcode.Errorf(exitcode.Validation, "instance %s of %s belongs to none of %s", instanceID, nodeName, strings.Join(groups, ", "))
+	}
+
+	return group, nil
+}
+
+// distribute splits count among the given groups, so that each new instance goes to the group with the fewest instances
+// Earlier group is preferred on tie, e.g. the order of --group flags
+func (w *Workflow) distribute(groups []string, count int) (map[string]int, error) {
+	shares := map[string]int{}
+
+	if len(groups) == 1 {
+		shares[groups[0]] = count
+		return shares, nil
+	}
+
+	sizes := map[string]int{}
+
+	for _, g := range groups {
+		instances, err := w.AutoScaling.ListInstances(g)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list instances in AutoScaling Group %s", g)
+		}
+
+		sizes[g] = len(instances)
+	}
+
+	for i := 0; i < count; i++ {
+		smallest := groups[0]
+
+		for _, g := range groups[1:] {
+			if sizes[g] < sizes[smallest] {
+				smallest = g
+			}
+		}
+
+		sizes[smallest]++
+		shares[smallest]++
+	}
+
+	return shares, nil
+}
+
+// DescribeGroupInstances returns EC2 instances in service of the given groups, e.g. to know their instance types
+func (w *Workflow) DescribeGroupInstances(groups []string) ([]*ec2.Instance, error) {
+	instances := []*ec2.Instance{}
+
+	for _, g := range groups {
+		ids, err := w.AutoScaling.ListInstances(g)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list instances in AutoScaling Group %s", g)
+		}
+
+		for _, id := range ids {
+			instance, err := w.EC2.DescribeInstance(id)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to describe instance %s", id)
+			}
+
+			instances = append(instances, instance)
+		}
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,107 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+)
+
+type multiGroupClient struct {
+	aws.AutoScalingClient
+
+	instances map[string][]string
+	tagged    []string
+}
+
+func (c *multiGroupClient) ListGroupsByTag(key, value string) ([]string, error) {
+	return c.tagged, nil
+}
+
+func (c *multiGroupClient) ListInstances(groupName string) ([]string, error) {
+	return c.instances[groupName], nil
+}
+
+func TestResolveGroups(t *testing.T) {
+	w := &Workflow{
+		AutoScaling: &multiGroupClient{tagged: []string{"es-a", "es-c"}},
+	}
+
+	got, err := w.ResolveGroups([]string{"es-a", "es-b"}, "cluster=logs")
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if expected := []string{"es-a", "es-b", "es-c"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("groups do not match. expected: %v, got: %v", expected, got)
+	}
+
+	if _, err := w.ResolveGroups([]string{}, "cluster"); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised for tag without value. got: %v", err)
+	}
+
+	if _, err := w.ResolveGroups([]string{}, ""); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised without group. got: %v", err)
+	}
+}
+
+func TestGroupOfNode(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	got, err := w.GroupOfNode("ip-10-0-1-2.ec2.internal", []string{"esnctl-other", fake.GroupName})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got != fake.GroupName {
+		t.Errorf("group does not match. expected: %q, got: %q", fake.GroupName, got)
+	}
+
+	if _, err := w.GroupOfNode("ip-10-0-1-2.ec2.internal", []string{"esnctl-other"}); exitcode.Code(err) != exitcode.Validation {
+		t.Errorf("validation error should be raised for node outside of the groups. got: %v", err)
+	}
+}
+
+func TestDistribute(t *testing.T) {
+	w := &Workflow{
+		AutoScaling: &multiGroupClient{
+			instances: map[string][]string{
+				"es-a": []string{"i-1", "i-2", "i-3"},
+				"es-b": []string{"i-4", "i-5"},
+				"es-c": []string{"i-6", "i-7"},
+			},
+		},
+	}
+
+	got, err := w.distribute([]string{"es-a", "es-b", "es-c"}, 4)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if expected := map[string]int{"es-a": 1, "es-b": 2, "es-c": 1}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("shares do not match. expected: %v, got: %v", expected, got)
+	}
+}
+
+func TestDescribeGroupInstances(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	got, err := w.DescribeGroupInstances([]string{fake.GroupName})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("3 instances should be returned. got: %d", len(got))
+	}
+
+	for _, i := range got {
+		if i.InstanceType != fake.InstanceType || i.Lifecycle != "on-demand" {
+			t.Errorf("instance does not match. got: %+v", i)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/dtan4/esnctl/operation"
+)
+
+const (
+	// OperationIDTagKey represents tag of instance recording ID of the last esnctl operation which added or removed it
+	OperationIDTagKey = "esnctl:operation-id"
+	// AddedByTagKey represents tag of instance recording user who added it
+	AddedByTagKey = "esnctl:added-by"
+	// AddedAtTagKey represents tag of instance recording when it joined the cluster
+	AddedAtTagKey = "esnctl:added-at"
+	// RemovedByTagKey represents tag of instance recording user who removed it
+	RemovedByTagKey = "esnctl:removed-by"
+	// RemovedAtTagKey represents tag of instance recording when its removal started
+	RemovedAtTagKey = "esnctl:removed-at"
+)
+
+// tagInstance records the operation in tags of the given instance, e.g. esnctl:operation-id and esnctl:removed-by
+// Tags are for traceability only, so failure is warned instead of failing the operation
+func (w *Workflow) tagInstance(instanceID string, op *operation.Operation, byKey, atKey string) {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	tags := map[string]string{
+		OperationIDTagKey: op.ID,
+		byKey:             op.User,
+		atKey:             time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if err := w.EC2.SetTags(instanceID, tags); err != nil {
+		fmt.Fprintf(progress, "WARNING: failed to tag %s with operation %s: %s\n", instanceID, op.ID, err)
+	}
+}
+
+// tagAddedNodes records the operation in tags of instances of the added nodes
+func (w *Workflow) tagAddedNodes(nodeNames []string, op *operation.Operation) {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	for _, name := range nodeNames {
+		instanceID, err := w.EC2.RetrieveInstanceIDFromPrivateDNS(name)
+		if err != nil {
+			fmt.Fprintf(progress, "WARNING: failed to tag instance of %s with operation %s: %s\n", name, op.ID, err)
+			continue
+		}
+
+		w.tagInstance(instanceID, op, AddedByTagKey, AddedAtTagKey)
+	}
+}
@@ -0,0 +1,68 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dtan4/esnctl/fake"
+	"github.com/dtan4/esnctl/operation"
+)
+
+func TestTagInstances(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	before, _ := c.ListNodes()
+
+	add := operation.New("add", w.ClusterURL)
+	add.User = "alice"
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 1, Operation: add}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.ListNodes()
+
+	var addedName string
+
+	for _, n := range nodes {
+		if !contains(before, n) {
+			addedName = n
+		}
+	}
+
+	addedID, err := c.EC2().RetrieveInstanceIDFromPrivateDNS(addedName)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	tags := c.InstanceTags(addedID)
+
+	if tags[OperationIDTagKey] != add.ID || tags[AddedByTagKey] != "alice" || tags[AddedAtTagKey] == "" {
+		t.Errorf("added instance is not tagged with the operation. got: %v", tags)
+	}
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	removedID, err := c.EC2().RetrieveInstanceIDFromPrivateDNS(nodeName)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	remove := operation.New("remove", w.ClusterURL)
+	remove.User = "bob"
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, Operation: remove}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	tags = c.InstanceTags(removedID)
+
+	if tags[OperationIDTagKey] != remove.ID || tags[RemovedByTagKey] != "bob" || tags[RemovedAtTagKey] == "" {
+		t.Errorf("removed instance is not tagged with the operation. got: %v", tags)
+	}
+
+	if _, ok := tags[AddedByTagKey]; ok {
+		t.Errorf("removed instance should not be tagged as added. got: %v", tags)
+	}
+}
@@ -0,0 +1,92 @@
+package workflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/dtan4/esnctl/es"
+)
+
+// warnJVM prints warnings about added nodes whose heap size, GC collectors or max file descriptors differ from the rest of the fleet
+// It catches broken user data of launch template before the nodes take traffic. Failure is reported as warning, because the check is advisory
+func (w *Workflow) warnJVM(added []string, httpClient *http.Client) {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	nodes, err := es.NodeJVMs(w.ClusterURL, httpClient)
+	if err != nil {
+		fmt.Fprintf(progress, "WARNING: failed to check JVM of added nodes: %s\n", err)
+		return
+	}
+
+	for _, warning := range jvmDeviations(nodes, added) {
+		fmt.Fprintf(progress, "WARNING: %s\n", warning)
+	}
+}
+
+// jvmDeviations compares each added node with the most common values among the other nodes
+func jvmDeviations(nodes []*es.NodeJVM, added []string) []string {
+	warnings := []string{}
+
+	heap := map[string]int{}
+	gc := map[string]int{}
+	fds := map[string]int{}
+
+	for _, n := range nodes {
+		if contains(added, n.Name) {
+			continue
+		}
+
+		heap[formatBytes(n.HeapMaxBytes)]++
+		gc[strings.Join(n.GCCollectors, ",")]++
+		fds[fmt.Sprintf("%d", n.MaxFileDescriptors)]++
+	}
+
+	// Nothing to compare with, e.g. the first nodes of the cluster
+	if len(heap) == 0 {
+		return warnings
+	}
+
+	for _, n := range nodes {
+		if !contains(added, n.Name) {
+			continue
+		}
+
+		checks := []struct {
+			name   string
+			value  string
+			others map[string]int
+		}{
+			{name: "heap size", value: formatBytes(n.HeapMaxBytes), others: heap},
+			{name: "GC collectors", value: strings.Join(n.GCCollectors, ","), others: gc},
+			{name: "max file descriptors", value: fmt.Sprintf("%d", n.MaxFileDescriptors), others: fds},
+		}
+
+		for _, c := range checks {
+			if expected := mostCommon(c.others); c.value != expected {
+				warnings = append(warnings, fmt.Sprintf("%s has %s %s, while other nodes have %s", n.Name, c.name, c.value, expected))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// mostCommon returns the value counted the most. The smallest value in lexical order wins on tie
+func mostCommon(counts map[string]int) string {
+	value := ""
+	count := 0
+
+	for v, c := range counts {
+		if c > count || (c == count && v < value) {
+			value = v
+			count = c
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,67 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dtan4/esnctl/es"
+)
+
+func TestJVMDeviations(t *testing.T) {
+	gc := []string{"G1 Old Generation", "G1 Young Generation"}
+
+	testcases := []struct {
+		nodes    []*es.NodeJVM
+		added    []string
+		expected []string
+	}{
+		{
+			nodes: []*es.NodeJVM{
+				{Name: "node-1", HeapMaxBytes: 4 << 30, GCCollectors: gc, MaxFileDescriptors: 65536},
+				{Name: "node-2", HeapMaxBytes: 4 << 30, GCCollectors: gc, MaxFileDescriptors: 65536},
+				{Name: "node-3", HeapMaxBytes: 4 << 30, GCCollectors: gc, MaxFileDescriptors: 65536},
+			},
+			added:    []string{"node-3"},
+			expected: []string{},
+		},
+		{
+			nodes: []*es.NodeJVM{
+				{Name: "node-1", HeapMaxBytes: 4 << 30, GCCollectors: gc, MaxFileDescriptors: 65536},
+				{Name: "node-2", HeapMaxBytes: 4 << 30, GCCollectors: gc, MaxFileDescriptors: 65536},
+				{Name: "node-3", HeapMaxBytes: 1 << 30, GCCollectors: []string{"ParNew", "ConcurrentMarkSweep"}, MaxFileDescriptors: 4096},
+			},
+			added: []string{"node-3"},
+			expected: []string{
+				"node-3 has heap size 1.0 GiB, while other nodes have 4.0 GiB",
+				"node-3 has GC collectors ParNew,ConcurrentMarkSweep, while other nodes have G1 Old Generation,G1 Young Generation",
+				"node-3 has max file descriptors 4096, while other nodes have 65536",
+			},
+		},
+		{
+			nodes: []*es.NodeJVM{
+				{Name: "node-1", HeapMaxBytes: 4 << 30, GCCollectors: gc, MaxFileDescriptors: 65536},
+				{Name: "node-2", HeapMaxBytes: 1 << 30, GCCollectors: gc, MaxFileDescriptors: 65536},
+			},
+			added:    []string{"node-1", "node-2"},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testcases {
+		got := jvmDeviations(tc.nodes, tc.added)
+
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("warnings do not match. expected: %q, got: %q", tc.expected, got)
+		}
+	}
+}
+
+func TestMostCommon(t *testing.T) {
+	if got := mostCommon(map[string]int{"65536": 2, "4096": 1}); got != "65536" {
+		t.Errorf("most common value does not match. got: %q", got)
+	}
+
+	if got := mostCommon(map[string]int{"b": 1, "a": 1}); got != "a" {
+		t.Errorf("tie should be broken in lexical order. got: %q", got)
+	}
+}
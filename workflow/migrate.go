@@ -0,0 +1,286 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// Methods of MigrateCluster
+const (
+	MigrateReindex  = "reindex"
+	MigrateSnapshot = "snapshot"
+)
+
+// MigrateCluster copies indices of the source cluster into the cluster of Workflow, e.g. a new cluster of the next major version
+// Rolling upgrade cannot jump major versions, so the new cluster is built beside the old one and clients are switched over
+func (w *Workflow) MigrateCluster(ctx context.Context, opts MigrateClusterOptions) error {
+	if opts.SourceURL == "" {
+		return exitcode.New(exitcode.Validation, "source cluster URL must be specified")
+	}
+
+	if len(opts.Indices) == 0 {
+		return exitcode.New(exitcode.Validation, "indices to migrate must be specified")
+	}
+
+	switch opts.Method {
+	case MigrateReindex:
+	case MigrateSnapshot:
+		if opts.Repository == "" {
+			return exitcode.New(exitcode.Validation, "snapshot repository must be specified to migrate with snapshot")
+		}
+	default:
+		return exitcode.Errorf(exitcode.Validation, "migration method must be %s or %s. got: %q", MigrateReindex, MigrateSnapshot, opts.Method)
+	}
+
+	op := w.operation(opts.Operation, "migrate-cluster")
+
+	op.Phase("Resolving indices on source cluster")
+
+	counts, err := es.IndexDocCounts(opts.SourceURL, opts.SourceHTTPClient, strings.Join(opts.Indices, ","))
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve indices on source cluster")
+	}
+
+	if len(counts) == 0 {
+		return exitcode.Errorf(exitcode.Validation, "no index matches %s on source cluster", strings.Join(opts.Indices, ","))
+	}
+
+	indices := []string{}
+
+	for index := range counts {
+		indices = append(indices, index)
+	}
+
+	sort.Strings(indices)
+
+	if opts.Cutover {
+		op.Phase(fmt.Sprintf("Blocking writes to %d indices on source cluster", len(indices)))
+
+		if err := es.BlockWrites(opts.SourceURL, opts.SourceHTTPClient, indices); err != nil {
+			return err
+		}
+
+		// Documents written before the block are counted again
+		counts, err = es.IndexDocCounts(opts.SourceURL, opts.SourceHTTPClient, strings.Join(indices, ","))
+		if err != nil {
+			return errors.Wrap(err, "failed to count documents on source cluster")
+		}
+	}
+
+	if opts.Method == MigrateReindex {
+		err = w.migrateByReindex(ctx, op, indices, opts)
+	} else {
+		err = w.migrateBySnapshot(ctx, op, indices, opts)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := w.verifyMigration(op, indices, counts, opts); err != nil {
+		return err
+	}
+
+	if !opts.Cutover {
+		return nil
+	}
+
+	return w.cutover(op, indices, opts)
+}
+
+// migrateByReindex copies indices one by one with reindex from remote running on the destination cluster
+func (w *Workflow) migrateByReindex(ctx context.Context, op *operation.Operation, indices []string, opts MigrateClusterOptions) error {
+	for _, index := range indices {
+		op.Phase(fmt.Sprintf("Reindexing %s from source cluster", index))
+
+		task, taskID, err := w.runReindex(ctx, op, opts.HTTPClient, &es.ReindexRequest{
+			Source:    index,
+			Dest:      index,
+			RemoteURL: opts.SourceURL,
+		})
+		if err != nil {
+			return err
+		}
+
+		if task.Failures > 0 || task.Error != "" {
+			return errors.Errorf("reindex of %s finished with %d failures. task: %s", index, task.Failures, taskID)
+		}
+	}
+
+	return nil
+}
+
+// migrateBySnapshot takes snapshot of indices on the source cluster and restores it on the destination cluster
+// Destination indices must not exist, because restore does not overwrite open indices
+func (w *Workflow) migrateBySnapshot(ctx context.Context, op *operation.Operation, indices []string, opts MigrateClusterOptions) error {
+	name := "esnctl-migrate-" + time.Now().UTC().Format("20060102150405")
+
+	op.Phase(fmt.Sprintf("Taking snapshot %s of %d indices on source cluster", name, len(indices)))
+
+	if err := es.CreateIndicesSnapshot(opts.SourceURL, opts.SourceHTTPClient, opts.Repository, name, indices); err != nil {
+		return err
+	}
+
+	err := w.waitFor(ctx, op, reindexTimeout, "shards", fmt.Sprintf("timed out: snapshot %s does not complete", name), func() (waitStatus, error) {
+		s, err := es.GetIndicesSnapshot(opts.SourceURL, opts.SourceHTTPClient, opts.Repository, name)
+		if err != nil {
+			return waitStatus{}, err
+		}
+
+		if s.Done() {
+			if s.State != snapshot.StateSuccess {
+				return waitStatus{}, errors.Errorf("snapshot %s finished with state %s. failed shards: %d/%d", name, s.State, s.FailedShards, s.TotalShards)
+			}
+
+			return waitStatus{}, nil
+		}
+
+		remaining := s.TotalShards - s.SuccessfulShards
+		if remaining < 1 {
+			remaining = 1
+		}
+
+		return waitStatus{Remaining: remaining}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	op.Phase(fmt.Sprintf("Restoring snapshot %s on destination cluster", name))
+
+	// The repository is shared, but the destination cluster may not have seen the new snapshot yet
+	if _, err := es.GetIndicesSnapshot(w.ClusterURL, opts.HTTPClient, opts.Repository, name); err != nil {
+		return errors.Wrapf(err, "snapshot %s is not visible from destination cluster. Register repository %s there", name, opts.Repository)
+	}
+
+	if err := es.RestoreIndicesSnapshot(w.ClusterURL, opts.HTTPClient, opts.Repository, name, indices); err != nil {
+		return err
+	}
+
+	return w.waitFor(ctx, op, reindexTimeout, "shards", fmt.Sprintf("timed out: restore of snapshot %s does not complete", name), func() (waitStatus, error) {
+		h, err := es.IndicesHealth(w.ClusterURL, opts.HTTPClient, indices)
+		if err != nil {
+			return waitStatus{}, err
+		}
+
+		// Replicas may stay unassigned on small cluster, so restore of primaries is enough
+		remaining := h.InitializingShards
+		if h.Status == "red" {
+			remaining += h.UnassignedShards
+		}
+
+		if remaining == 0 && h.Status == "red" {
+			remaining = 1
+		}
+
+		return waitStatus{Remaining: remaining}, nil
+	})
+}
+
+// verifyMigration compares the number of documents of each index between the source and destination clusters
+// Indices still written to on the source cluster differ without cutover, so differences are reported as warnings then
+func (w *Workflow) verifyMigration(op *operation.Operation, indices []string, counts map[string]int64, opts MigrateClusterOptions) error {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Verifying the number of documents on destination cluster")
+
+	if err := es.RefreshIndices(w.ClusterURL, opts.HTTPClient, indices); err != nil {
+		return err
+	}
+
+	copied, err := es.IndexDocCounts(w.ClusterURL, opts.HTTPClient, strings.Join(indices, ","))
+	if err != nil {
+		return errors.Wrap(err, "failed to count documents on destination cluster")
+	}
+
+	mismatched := []string{}
+
+	for _, index := range indices {
+		if copied[index] != counts[index] {
+			mismatched = append(mismatched, fmt.Sprintf("%s (source: %d, destination: %d)", index, counts[index], copied[index]))
+		}
+	}
+
+	if len(mismatched) == 0 {
+		return nil
+	}
+
+	if opts.Cutover {
+		return errors.Errorf("documents do not match between clusters: %s", strings.Join(mismatched, ", "))
+	}
+
+	for _, m := range mismatched {
+		fmt.Fprintf(progress, "WARNING: documents do not match: %s\n", m)
+	}
+
+	return nil
+}
+
+// cutover copies aliases of the migrated indices, and verifies the target group of the destination cluster is ready to take traffic
+// Switching clients, e.g. DNS record or listener of load balancer, is left to the user
+func (w *Workflow) cutover(op *operation.Operation, indices []string, opts MigrateClusterOptions) error {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Copying aliases to destination cluster")
+
+	n, err := es.CopyAliases(opts.SourceURL, opts.SourceHTTPClient, w.ClusterURL, opts.HTTPClient, indices)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(progress, "%d aliases copied\n", n)
+
+	if opts.DestGroup == "" {
+		fmt.Fprintf(progress, "Switch clients from %s to %s\n", operation.SanitizeURL(opts.SourceURL), operation.SanitizeURL(w.ClusterURL))
+		return nil
+	}
+
+	op.Phase("Verifying target group of destination cluster")
+
+	targetGroupARN, err := w.AutoScaling.RetrieveTargetGroup(opts.DestGroup)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve target group")
+	}
+
+	instances, err := w.AutoScaling.ListInstances(opts.DestGroup)
+	if err != nil {
+		return errors.Wrap(err, "failed to list instances in AutoScaling Group")
+	}
+
+	targets, err := w.ELBv2.ListTargetInstances(targetGroupARN)
+	if err != nil {
+		return errors.Wrap(err, "failed to list instances in target group")
+	}
+
+	missing := []string{}
+
+	for _, i := range instances {
+		if !contains(targets, i) {
+			missing = append(missing, i)
+		}
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("instances %s of %s are not registered with target group %s", strings.Join(missing, ", "), opts.DestGroup, targetGroupARN)
+	}
+
+	fmt.Fprintf(progress, "Switch clients from %s to target group %s\n", operation.SanitizeURL(opts.SourceURL), targetGroupARN)
+
+	return nil
+}
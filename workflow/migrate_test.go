@@ -0,0 +1,157 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+// migrationServers simulates source and destination clusters, where reindex task completes on the second check
+type migrationServers struct {
+	mu       sync.Mutex
+	requests []string
+	checks   int
+	copied   bool
+}
+
+func (m *migrationServers) record(cluster string, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, cluster+" "+r.Method+" "+r.URL.Path)
+}
+
+func (m *migrationServers) source() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.record("source", r)
+
+		switch {
+		case r.URL.Path == "/logs-*/_stats/docs" || r.URL.Path == "/logs-1/_stats/docs":
+			w.Write([]byte(`{"indices": {"logs-1": {"primaries": {"docs": {"count": 10}}}}}`))
+		case r.URL.Path == "/logs-1/_settings":
+			w.Write([]byte(`{"acknowledged": true}`))
+		case r.URL.Path == "/logs-1/_alias":
+			w.Write([]byte(`{"logs-1": {"aliases": {"logs": {"is_write_index": true}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func (m *migrationServers) dest(t *testing.T, sourceURL string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.record("dest", r)
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		switch r.URL.Path {
+		case "/_reindex":
+			if !strings.Contains(string(body), `"host":"`+sourceURL+`"`) {
+				t.Errorf("reindex should read from source cluster. got: %s", body)
+			}
+
+			w.Write([]byte(`{"task": "node-1:42"}`))
+		case "/_tasks/node-1:42":
+			m.mu.Lock()
+			m.checks++
+			completed := m.checks > 1
+			m.copied = completed
+			m.mu.Unlock()
+
+			if completed {
+				w.Write([]byte(`{"completed": true, "task": {"status": {"total": 10, "created": 10}}, "response": {"failures": []}}`))
+			} else {
+				w.Write([]byte(`{"completed": false, "task": {"status": {"total": 10, "created": 4}}}`))
+			}
+		case "/logs-1/_refresh":
+			w.Write([]byte(`{}`))
+		case "/logs-1/_stats/docs":
+			m.mu.Lock()
+			count := 0
+			if m.copied {
+				count = 10
+			}
+			m.mu.Unlock()
+
+			fmt.Fprintf(w, `{"indices": {"logs-1": {"primaries": {"docs": {"count": %d}}}}}`, count)
+		case "/_aliases":
+			if !strings.Contains(string(body), `"alias":"logs"`) || !strings.Contains(string(body), `"is_write_index":true`) {
+				t.Errorf("alias should be copied with its definition. got: %s", body)
+			}
+
+			w.Write([]byte(`{"acknowledged": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestMigrateCluster_reindexCutover(t *testing.T) {
+	m := &migrationServers{}
+
+	source := m.source()
+	defer source.Close()
+
+	dest := m.dest(t, source.URL)
+	defer dest.Close()
+
+	w := &Workflow{
+		ClusterURL: dest.URL,
+		MinPoll:    time.Millisecond,
+		MaxPoll:    time.Millisecond,
+	}
+
+	err := w.MigrateCluster(context.Background(), MigrateClusterOptions{
+		SourceURL:        source.URL,
+		Indices:          []string{"logs-*"},
+		Method:           MigrateReindex,
+		Cutover:          true,
+		SourceHTTPClient: &http.Client{},
+		HTTPClient:       &http.Client{},
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []string{
+		"source GET /logs-*/_stats/docs",
+		"source PUT /logs-1/_settings",
+		"source GET /logs-1/_stats/docs",
+		"dest POST /_reindex",
+		"dest GET /_tasks/node-1:42",
+		"dest GET /_tasks/node-1:42",
+		"dest POST /logs-1/_refresh",
+		"dest GET /logs-1/_stats/docs",
+		"source GET /logs-1/_alias",
+		"dest POST /_aliases",
+	}
+
+	if strings.Join(m.requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("requests do not match. expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(m.requests, "\n"))
+	}
+}
+
+func TestMigrateCluster_validation(t *testing.T) {
+	w := &Workflow{ClusterURL: "http://elasticsearch.example.com"}
+
+	testcases := []MigrateClusterOptions{
+		{Indices: []string{"logs-*"}, Method: MigrateReindex},
+		{SourceURL: "http://old.example.com", Method: MigrateReindex},
+		{SourceURL: "http://old.example.com", Indices: []string{"logs-*"}, Method: MigrateSnapshot},
+		{SourceURL: "http://old.example.com", Indices: []string{"logs-*"}, Method: "rsync"},
+	}
+
+	for _, opts := range testcases {
+		if err := w.MigrateCluster(context.Background(), opts); exitcode.Code(err) != exitcode.Validation {
+			t.Errorf("validation error should be raised for %+v. got: %v", opts, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"time"
+)
+
+var (
+	// defaultMinPoll represents the first interval between status checks
+	defaultMinPoll = 2 * time.Second
+	// defaultMaxPoll represents upper limit of interval between status checks
+	defaultMaxPoll = 30 * time.Second
+)
+
+// poller decides interval between status checks from how fast the observed value changes
+// The interval is halved while the value is changing and doubled while it stays the same
+type poller struct {
+	min      time.Duration
+	max      time.Duration
+	interval time.Duration
+	last     int
+	observed bool
+}
+
+// newPoller creates poller bounded by MinPoll and MaxPoll of the workflow
+func (w *Workflow) newPoller() *poller {
+	min, max := w.MinPoll, w.MaxPoll
+
+	if min <= 0 {
+		min = defaultMinPoll
+	}
+
+	if max <= 0 {
+		max = defaultMaxPoll
+	}
+
+	if max < min {
+		max = min
+	}
+
+	return &poller{
+		min:      min,
+		max:      max,
+		interval: min,
+	}
+}
+
+// next returns how long to wait before the next check, given the observed value e.g. the number of remaining shards
+func (p *poller) next(value int) time.Duration {
+	if p.observed {
+		if value == p.last {
+			p.interval *= 2
+		} else {
+			p.interval /= 2
+		}
+	}
+
+	if p.interval < p.min {
+		p.interval = p.min
+	}
+
+	if p.interval > p.max {
+		p.interval = p.max
+	}
+
+	p.last = value
+	p.observed = true
+
+	return p.interval
+}
@@ -0,0 +1,53 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollerNext(t *testing.T) {
+	w := &Workflow{
+		MinPoll: 2 * time.Second,
+		MaxPoll: 30 * time.Second,
+	}
+
+	p := w.newPoller()
+
+	testcases := []struct {
+		value    int
+		expected time.Duration
+	}{
+		{value: 10, expected: 2 * time.Second},
+		{value: 10, expected: 4 * time.Second},
+		{value: 10, expected: 8 * time.Second},
+		{value: 10, expected: 16 * time.Second},
+		{value: 10, expected: 30 * time.Second},
+		{value: 10, expected: 30 * time.Second},
+		{value: 8, expected: 15 * time.Second},
+		{value: 5, expected: 7500 * time.Millisecond},
+		{value: 3, expected: 3750 * time.Millisecond},
+		{value: 1, expected: 2 * time.Second},
+	}
+
+	for i, tc := range testcases {
+		got := p.next(tc.value)
+
+		if got != tc.expected {
+			t.Errorf("interval of check #%d does not match. expected: %s, got: %s", i, tc.expected, got)
+		}
+	}
+}
+
+func TestNewPoller_defaults(t *testing.T) {
+	p := (&Workflow{}).newPoller()
+
+	if p.min != defaultMinPoll || p.max != defaultMaxPoll {
+		t.Errorf("default bounds should be used. got: %s-%s", p.min, p.max)
+	}
+
+	p = (&Workflow{MinPoll: time.Minute, MaxPoll: time.Second}).newPoller()
+
+	if p.max != time.Minute {
+		t.Errorf("MaxPoll less than MinPoll should be raised to MinPoll. got: %s", p.max)
+	}
+}
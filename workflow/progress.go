@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// progressBarWidth represents the number of cells of progress bar
+	progressBarWidth = 30
+	// progressLogInterval represents interval between progress lines written to non-terminal
+	progressLogInterval = 30 * time.Second
+)
+
+// waitStatus represents what waiting step still waits for
+type waitStatus struct {
+	Remaining int
+	// Bytes represents size of remaining shards. 0 means unknown
+	Bytes int64
+}
+
+// progressWriter renders progress of waiting step
+// Progress bar is redrawn in place on terminal, otherwise key=value lines are written every progressLogInterval
+type progressWriter struct {
+	out  io.Writer
+	bar  bool
+	unit string
+	now  func() time.Time
+
+	startedAt  time.Time
+	loggedAt   time.Time
+	total      int
+	startBytes int64
+	drawn      bool
+}
+
+func newProgressWriter(out io.Writer, bar bool, unit string) *progressWriter {
+	return &progressWriter{
+		out:  out,
+		bar:  bar,
+		unit: unit,
+		now:  time.Now,
+	}
+}
+
+// finish terminates progress bar line
+func (p *progressWriter) finish() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\n")
+	}
+}
+
+// update renders the given status
+func (p *progressWriter) update(s waitStatus) {
+	now := p.now()
+
+	if p.startedAt.IsZero() {
+		p.startedAt = now
+		p.startBytes = s.Bytes
+	}
+
+	if s.Remaining > p.total {
+		p.total = s.Remaining
+	}
+
+	elapsed := now.Sub(p.startedAt) / time.Second * time.Second
+
+	eta := "unknown"
+
+	if d, ok := p.eta(s, elapsed); ok {
+		eta = d.String()
+	}
+
+	done := p.total - s.Remaining
+
+	if p.bar {
+		filled := progressBarWidth * done / p.total
+
+		line := fmt.Sprintf("[%s%s] %d/%d %s", strings.Repeat("#", filled), strings.Repeat(".", progressBarWidth-filled), done, p.total, p.unit)
+
+		if s.Bytes > 0 {
+			line += fmt.Sprintf(", %s left", formatBytes(s.Bytes))
+		}
+
+		// Trailing spaces clear the rest of longer previous line
+		fmt.Fprintf(p.out, "\r%s, elapsed: %s, ETA: %s   ", line, elapsed, eta)
+		p.drawn = true
+
+		return
+	}
+
+	if !p.loggedAt.IsZero() && now.Sub(p.loggedAt) < progressLogInterval {
+		return
+	}
+
+	line := fmt.Sprintf("progress unit=%s done=%d total=%d", p.unit, done, p.total)
+
+	if s.Bytes > 0 {
+		line += fmt.Sprintf(" remaining_bytes=%d", s.Bytes)
+	}
+
+	fmt.Fprintf(p.out, "%s elapsed=%s eta=%s\n", line, elapsed, eta)
+	p.loggedAt = now
+}
+
+// eta estimates how long it takes to finish at the rate observed so far
+// Rate of bytes is preferred to the number of items, because shards differ in size
+func (p *progressWriter) eta(s waitStatus, elapsed time.Duration) (time.Duration, bool) {
+	if elapsed <= 0 {
+		return 0, false
+	}
+
+	var rest, moved float64
+
+	if s.Bytes > 0 && p.startBytes > s.Bytes {
+		rest, moved = float64(s.Bytes), float64(p.startBytes-s.Bytes)
+	} else {
+		rest, moved = float64(s.Remaining), float64(p.total-s.Remaining)
+	}
+
+	if moved <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(float64(elapsed)*rest/moved) / time.Second * time.Second, true
+}
+
+// formatBytes returns human-readable size in binary units
+func formatBytes(b int64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,89 @@
+package workflow
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressWriter_bar(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+
+	p := newProgressWriter(&out, true, "shards")
+	p.now = func() time.Time { return now }
+
+	p.update(waitStatus{Remaining: 40, Bytes: 4 << 30})
+
+	now = now.Add(10 * time.Minute)
+	out.Reset()
+
+	p.update(waitStatus{Remaining: 30, Bytes: 2 << 30})
+
+	expected := "\r[#######.......................] 10/40 shards, 2.0 GiB left, elapsed: 10m0s, ETA: 10m0s   "
+	if out.String() != expected {
+		t.Errorf("progress bar does not match. expected: %q, got: %q", expected, out.String())
+	}
+
+	out.Reset()
+	p.finish()
+
+	if out.String() != "\n" {
+		t.Errorf("progress bar line should be terminated. got: %q", out.String())
+	}
+}
+
+func TestProgressWriter_log(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var out bytes.Buffer
+
+	p := newProgressWriter(&out, false, "nodes")
+	p.now = func() time.Time { return now }
+
+	p.update(waitStatus{Remaining: 2})
+
+	now = now.Add(10 * time.Second)
+	p.update(waitStatus{Remaining: 1})
+
+	now = now.Add(progressLogInterval)
+	p.update(waitStatus{Remaining: 1})
+
+	p.finish()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+
+	expected := []string{
+		"progress unit=nodes done=0 total=2 elapsed=0s eta=unknown",
+		"progress unit=nodes done=1 total=2 elapsed=40s eta=40s",
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("progress should be written every %s. got: %q", progressLogInterval, out.String())
+	}
+
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("progress line does not match. expected: %q, got: %q", expected[i], lines[i])
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	testcases := []struct {
+		bytes    int64
+		expected string
+	}{
+		{bytes: 512, expected: "512 B"},
+		{bytes: 1536, expected: "1.5 KiB"},
+		{bytes: 3 << 30, expected: "3.0 GiB"},
+	}
+
+	for _, tc := range testcases {
+		if got := formatBytes(tc.bytes); got != tc.expected {
+			t.Errorf("formatted size does not match. expected: %q, got: %q", tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// pendingTarget represents added node kept out of target group until it is ready to serve traffic
+type pendingTarget struct {
+	nodeName       string
+	instanceID     string
+	targetGroupARN string
+}
+
+// detachUntilReady detaches instances of the added nodes from target groups, which Auto Scaling registered them with on launch
+func (w *Workflow) detachUntilReady(added []string) ([]*pendingTarget, error) {
+	targets := []*pendingTarget{}
+
+	for _, nodeName := range added {
+		instanceID, err := w.EC2.RetrieveInstanceIDFromPrivateDNS(nodeName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve instance ID")
+		}
+
+		group, err := w.AutoScaling.RetrieveGroupOfInstance(instanceID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve AutoScaling Group of instance")
+		}
+
+		targetGroupARN, err := w.AutoScaling.RetrieveTargetGroup(group)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve target group")
+		}
+
+		if err := w.ELBv2.DetachInstance(targetGroupARN, instanceID); err != nil {
+			return nil, errors.Wrapf(err, "failed to detach %s from target group", instanceID)
+		}
+
+		targets = append(targets, &pendingTarget{
+			nodeName:       nodeName,
+			instanceID:     instanceID,
+			targetGroupARN: targetGroupARN,
+		})
+	}
+
+	return targets, nil
+}
+
+// registerWhenReady waits for the cluster to be green without shards moving, searches the given warm-up queries on the added nodes, then registers them with target groups
+// Failed queries are reported as warnings, because e.g. the node may have no shard of the index
+func (w *Workflow) registerWhenReady(ctx context.Context, op *operation.Operation, targets []*pendingTarget, queries []*es.WarmupQuery, httpClient *http.Client) error {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	op.Phase("Waiting for cluster to be green and shard allocation to settle")
+
+	if err := w.waitFor(ctx, op, rebalanceTimeout, "shards", "timed out: cluster is not green or shards are still moving", func() (waitStatus, error) {
+		health, err := w.ES.ClusterHealth()
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to retrieve cluster health")
+		}
+
+		remaining := health.RelocatingShards + health.InitializingShards + health.UnassignedShards
+		if remaining == 0 && health.Status != "green" {
+			remaining = 1
+		}
+
+		return waitStatus{Remaining: remaining}, nil
+	}); err != nil {
+		return err
+	}
+
+	if len(queries) > 0 {
+		op.Phase("Warming up added nodes")
+
+		for _, t := range targets {
+			for _, q := range queries {
+				if err := es.WarmUp(w.ClusterURL, httpClient, t.nodeName, q); err != nil {
+					fmt.Fprintf(progress, "WARNING: %s\n", err)
+				}
+			}
+		}
+	}
+
+	op.Phase("Registering added instances with target group")
+
+	for _, t := range targets {
+		if err := w.ELBv2.RegisterInstance(t.targetGroupARN, t.instanceID); err != nil {
+			return errors.Wrapf(err, "failed to register %s with target group", t.instanceID)
+		}
+	}
+
+	return nil
+}
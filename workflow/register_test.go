@@ -0,0 +1,71 @@
+package workflow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/fake"
+	"github.com/dtan4/esnctl/operation"
+)
+
+func TestAddNodes_registerAfterJoinFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	observer := &recordingObserver{}
+	op := operation.New("add", w.ClusterURL)
+	op.Observer = observer
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 1, RegisterAfterJoin: true, Operation: op}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !contains(observer.phases, "Detaching added instances from target group until they are ready") {
+		t.Errorf("added instance should be detached from target group after join. got: %v", observer.phases)
+	}
+
+	if last := observer.phases[len(observer.phases)-1]; last != "Registering added instances with target group" {
+		t.Errorf("added instance should be registered at last. got: %v", observer.phases)
+	}
+
+	instances, _ := c.ELBv2().ListTargetInstances(fake.TargetGroupARN)
+	if !contains(instances, "i-00000004") {
+		t.Errorf("added instance should be registered with target group. got: %v", instances)
+	}
+}
+
+func TestAddNodes_warmupFakeCluster(t *testing.T) {
+	warmed := []string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		warmed = append(warmed, r.URL.Query().Get("preference"))
+		w.Write([]byte(`{"hits": {"total": 0, "hits": []}}`))
+	}))
+	defer ts.Close()
+
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+	w.ClusterURL = ts.URL
+
+	opts := AddOptions{
+		Group:         fake.GroupName,
+		Count:         1,
+		WarmupQueries: []*es.WarmupQuery{{Index: "fake"}},
+	}
+
+	if err := w.AddNodes(context.Background(), opts); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(warmed) != 1 || warmed[0] != "_only_nodes:ip-10-0-1-4.ec2.internal" {
+		t.Errorf("only added node should be warmed up. got: %v", warmed)
+	}
+
+	instances, _ := c.ELBv2().ListTargetInstances(fake.TargetGroupARN)
+	if !contains(instances, "i-00000004") {
+		t.Errorf("added instance should be registered with target group after warm-up. got: %v", instances)
+	}
+}
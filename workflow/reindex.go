@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// reindexTimeout bounds each reindex, snapshot or restore, which takes hours for large indices
+const reindexTimeout = 24 * time.Hour
+
+// Reindex copies documents of the source index into the destination index, optionally from remote cluster
+// Failed reindex is retried only for documents missing in the destination index, so that documents copied already are not written again
+func (w *Workflow) Reindex(ctx context.Context, opts ReindexOptions) error {
+	if opts.Source == "" || opts.Dest == "" {
+		return exitcode.New(exitcode.Validation, "source and destination indices must be specified")
+	}
+
+	if opts.SourceURL == "" && opts.Source == opts.Dest {
+		return exitcode.Errorf(exitcode.Validation, "source and destination must be different indices. got: %s", opts.Source)
+	}
+
+	if opts.SourceURL != "" && opts.Slices != "" {
+		return exitcode.New(exitcode.Validation, "reindex from remote cluster cannot be sliced")
+	}
+
+	if opts.Conflicts != "abort" && opts.Conflicts != "proceed" {
+		return exitcode.Errorf(exitcode.Validation, "conflicts must be abort or proceed. got: %q", opts.Conflicts)
+	}
+
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op := w.operation(opts.Operation, "reindex")
+
+	req := &es.ReindexRequest{
+		Source:            opts.Source,
+		Dest:              opts.Dest,
+		RemoteURL:         opts.SourceURL,
+		Slices:            opts.Slices,
+		RequestsPerSecond: opts.RequestsPerSecond,
+		Conflicts:         opts.Conflicts,
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt == 0 {
+			op.Phase(fmt.Sprintf("Reindexing %s into %s", opts.Source, opts.Dest))
+		} else {
+			op.Phase(fmt.Sprintf("Retrying reindex of documents missing in %s (%d/%d)", opts.Dest, attempt, opts.Retries))
+			req.OnlyMissing = true
+		}
+
+		task, taskID, err := w.runReindex(ctx, op, opts.HTTPClient, req)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(progress, "%d created, %d updated, %d version conflicts, %d failures\n", task.Created, task.Updated, task.VersionConflicts, task.Failures)
+
+		if task.Failures == 0 && task.Error == "" {
+			return nil
+		}
+
+		if attempt >= opts.Retries {
+			if task.Error != "" {
+				return errors.Errorf("reindex of %s into %s failed. task: %s, error: %s", opts.Source, opts.Dest, taskID, task.Error)
+			}
+
+			return errors.Errorf("reindex of %s into %s finished with %d failures. task: %s", opts.Source, opts.Dest, task.Failures, taskID)
+		}
+	}
+}
+
+// runReindex starts reindex task and waits for it to complete
+// Task ID is printed so that the task can be rethrottled or cancelled while waiting
+func (w *Workflow) runReindex(ctx context.Context, op *operation.Operation, httpClient *http.Client, req *es.ReindexRequest) (*es.ReindexTask, string, error) {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	taskID, err := es.StartReindex(w.ClusterURL, httpClient, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fmt.Fprintf(progress, "Task: %s\n", taskID)
+
+	var task *es.ReindexTask
+
+	err = w.waitFor(ctx, op, reindexTimeout, "docs", fmt.Sprintf("timed out: reindex of %s does not finish. task: %s", req.Source, taskID), func() (waitStatus, error) {
+		t, err := es.GetReindexTask(w.ClusterURL, httpClient, taskID)
+		if err != nil {
+			return waitStatus{}, err
+		}
+
+		if t.Completed {
+			task = t
+			return waitStatus{}, nil
+		}
+
+		// Total is 0 until the task starts scrolling the source index
+		remaining := int(t.Total - t.Done())
+		if remaining < 1 {
+			remaining = 1
+		}
+
+		return waitStatus{Remaining: remaining}, nil
+	})
+	if err != nil {
+		return nil, taskID, err
+	}
+
+	return task, taskID, nil
+}
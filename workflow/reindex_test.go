@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+func TestReindex_retry(t *testing.T) {
+	bodies := []string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_reindex":
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+
+			if len(bodies) == 1 {
+				w.Write([]byte(`{"task": "node-1:1"}`))
+			} else {
+				w.Write([]byte(`{"task": "node-1:2"}`))
+			}
+		case "/_tasks/node-1:1":
+			w.Write([]byte(`{"completed": true, "task": {"status": {"total": 10, "created": 8}}, "response": {"failures": [{"status": 429}]}}`))
+		case "/_tasks/node-1:2":
+			w.Write([]byte(`{"completed": true, "task": {"status": {"total": 10, "created": 2, "version_conflicts": 8}}, "response": {"failures": []}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	w := &Workflow{
+		ClusterURL: ts.URL,
+		MinPoll:    time.Millisecond,
+		MaxPoll:    time.Millisecond,
+	}
+
+	err := w.Reindex(context.Background(), ReindexOptions{
+		Source:     "idx-a",
+		Dest:       "idx-b",
+		Conflicts:  "abort",
+		Retries:    1,
+		HTTPClient: &http.Client{},
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(bodies) != 2 || strings.Contains(bodies[0], "op_type") || !strings.Contains(bodies[1], `"op_type":"create"`) {
+		t.Errorf("only missing documents should be reindexed on retry. got: %q", bodies)
+	}
+}
+
+func TestReindex_failure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_reindex":
+			w.Write([]byte(`{"task": "node-1:1"}`))
+		default:
+			w.Write([]byte(`{"completed": true, "task": {"status": {"total": 10}}, "error": {"type": "index_not_found_exception"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	w := &Workflow{ClusterURL: ts.URL}
+
+	err := w.Reindex(context.Background(), ReindexOptions{Source: "idx-a", Dest: "idx-b", Conflicts: "proceed", HTTPClient: &http.Client{}})
+	if err == nil || !strings.Contains(err.Error(), "index_not_found_exception") {
+		t.Errorf("error of task should be raised. got: %v", err)
+	}
+}
+
+func TestReindex_validation(t *testing.T) {
+	w := &Workflow{ClusterURL: "http://elasticsearch.example.com"}
+
+	testcases := []ReindexOptions{
+		{Dest: "idx-b", Conflicts: "abort"},
+		{Source: "idx-a", Dest: "idx-a", Conflicts: "abort"},
+		{Source: "idx-a", Dest: "idx-a", SourceURL: "http://old.example.com", Slices: "auto", Conflicts: "abort"},
+		{Source: "idx-a", Dest: "idx-b", Conflicts: "retry"},
+	}
+
+	for _, opts := range testcases {
+		if err := w.Reindex(context.Background(), opts); exitcode.Code(err) != exitcode.Validation {
+			t.Errorf("validation error should be raised for %+v. got: %v", opts, err)
+		}
+	}
+}
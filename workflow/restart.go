@@ -0,0 +1,165 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+// delayedTimeoutSetting delays allocation of replicas on node which left the cluster
+// https://www.elastic.co/guide/en/elasticsearch/reference/6.8/delayed-allocation.html
+const (
+	delayedTimeoutSetting = "index.unassigned.node_left.delayed_timeout"
+	defaultDelayedTimeout = "1m"
+)
+
+// RestartNode reboots instance of the given node and waits for the cluster to recover
+// Allocation of shards on the node is delayed while it is away, so they are recovered on the node instead of rebuilt elsewhere
+// Delayed allocation settings of indices are restored afterwards, even if restart fails
+func (w *Workflow) RestartNode(ctx context.Context, opts RestartOptions) (err error) {
+	if opts.NodeName == "" {
+		return exitcode.New(exitcode.Validation, "node name must be specified")
+	}
+
+	if opts.DelayedTimeout < time.Second {
+		return exitcode.New(exitcode.Validation, "delayed timeout must be 1s or longer")
+	}
+
+	op := w.operation(opts.Operation, "restart")
+
+	if err := w.checkSLMWindow(ctx, op, opts.SLMWindow, opts.RespectSLMWindow); err != nil {
+		return err
+	}
+
+	op.Phase("Retrieving target instance ID")
+
+	instanceID, err := w.EC2.RetrieveInstanceIDFromPrivateDNS(opts.NodeName)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve instance ID")
+	}
+
+	op.Phase("Retrieving delayed allocation settings")
+
+	prior, err := w.ES.IndexSettings(delayedTimeoutSetting)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve delayed allocation settings")
+	}
+
+	timeout := timeValue(opts.DelayedTimeout)
+
+	op.Phase(fmt.Sprintf("Delaying allocation of shards on target node for %s", timeout))
+
+	if err := w.ES.UpdateIndexSettings("_all", map[string]string{delayedTimeoutSetting: timeout}); err != nil {
+		return errors.Wrap(err, "failed to delay allocation")
+	}
+
+	defer func() {
+		op.Phase("Restoring delayed allocation settings")
+
+		if rerr := w.restoreDelayedTimeouts(prior, timeout); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+
+	op.Phase("Rebooting target instance")
+
+	if err := w.EC2.RebootInstance(instanceID); err != nil {
+		return errors.Wrap(err, "failed to reboot instance")
+	}
+
+	// Reboot takes longer than polling interval, so the node is seen leaving before it joins again
+	op.Phase("Waiting for target node leave from Elasticsearch cluster")
+
+	err = w.waitFor(ctx, op, removeTimeout, "nodes", "timed out: target node does not leave Elasticsearch cluster", func() (waitStatus, error) {
+		found, err := w.hasNode(opts.NodeName)
+		if err != nil || !found {
+			return waitStatus{}, err
+		}
+
+		return waitStatus{Remaining: 1}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	op.Phase("Waiting for target node join to Elasticsearch cluster")
+
+	err = w.waitFor(ctx, op, w.joinTimeout(), "nodes", "timed out: target node does not join to Elasticsearch cluster", func() (waitStatus, error) {
+		found, err := w.hasNode(opts.NodeName)
+		if err != nil || found {
+			return waitStatus{}, err
+		}
+
+		return waitStatus{Remaining: 1}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	op.Phase("Waiting for unassigned shards to be allocated")
+
+	return w.waitFor(ctx, op, w.shardDrainTimeout(), "shards", "timed out: unassigned shards are not allocated", func() (waitStatus, error) {
+		health, err := w.ES.ClusterHealth()
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to retrieve cluster health")
+		}
+
+		return waitStatus{Remaining: health.UnassignedShards}, nil
+	})
+}
+
+// hasNode returns whether the given node is in the cluster
+func (w *Workflow) hasNode(nodeName string) (bool, error) {
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list nodes")
+	}
+
+	return contains(nodes, nodeName), nil
+}
+
+// restoreDelayedTimeouts sets the given prior value back to each index, and the default to indices without prior value
+// The default is set explicitly, because Elasticsearch before 5.0 cannot reset index settings with null
+// Indices whose value is no longer timeout, i.e. changed by someone else during restart, are left as they are
+func (w *Workflow) restoreDelayedTimeouts(prior map[string]string, timeout string) error {
+	current, err := w.ES.IndexSettings(delayedTimeoutSetting)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve delayed allocation settings")
+	}
+
+	indices := []string{}
+
+	for index, value := range current {
+		if value == timeout {
+			indices = append(indices, index)
+		}
+	}
+
+	sort.Strings(indices)
+
+	for _, index := range indices {
+		value, ok := prior[index]
+		if !ok {
+			value = defaultDelayedTimeout
+		}
+
+		if err := w.ES.UpdateIndexSettings(index, map[string]string{delayedTimeoutSetting: value}); err != nil {
+			return errors.Wrapf(err, "failed to restore delayed allocation of %s", index)
+		}
+	}
+
+	return nil
+}
+
+// timeValue formats d in time unit of Elasticsearch settings, e.g. 5m, which does not accept Go duration like 5m0s
+func timeValue(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+
+	return fmt.Sprintf("%ds", d/time.Second)
+}
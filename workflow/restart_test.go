@@ -0,0 +1,99 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestRestartNode_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.SetIndexSetting("logs", delayedTimeoutSetting, "10m")
+
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	if err := w.RestartNode(context.Background(), RestartOptions{NodeName: nodeName, DelayedTimeout: 5 * time.Minute}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.ListNodes()
+	if len(nodes) != 3 {
+		t.Errorf("restarted node should join again. got: %v", nodes)
+	}
+
+	shards, _ := c.ListShardsOnNode(nodeName)
+	if len(shards) != 3 {
+		t.Errorf("shards should stay on restarted node. got: %v", shards)
+	}
+
+	if got := c.IndexSetting("logs", delayedTimeoutSetting); got != "10m" {
+		t.Errorf("explicit delayed timeout should be restored. expected: %q, got: %q", "10m", got)
+	}
+
+	if got := c.IndexSetting("fake", delayedTimeoutSetting); got != defaultDelayedTimeout {
+		t.Errorf("delayed timeout should be set back to the default. expected: %q, got: %q", defaultDelayedTimeout, got)
+	}
+}
+
+func TestRestartNode_validation(t *testing.T) {
+	w := newFakeWorkflow(fake.NewCluster(3))
+
+	testcases := []RestartOptions{
+		{DelayedTimeout: 5 * time.Minute},
+		{NodeName: "ip-10-0-1-2.ec2.internal"},
+		{NodeName: "ip-10-0-1-2.ec2.internal", DelayedTimeout: time.Millisecond},
+	}
+
+	for _, opts := range testcases {
+		if err := w.RestartNode(context.Background(), opts); exitcode.Code(err) != exitcode.Validation {
+			t.Errorf("validation error should be raised for %+v. got: %v", opts, err)
+		}
+	}
+}
+
+func TestRestoreDelayedTimeouts(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.SetIndexSetting("logs", delayedTimeoutSetting, "5m")
+	c.SetIndexSetting("fake", delayedTimeoutSetting, "5m")
+	c.SetIndexSetting("metrics", delayedTimeoutSetting, "30s")
+
+	w := newFakeWorkflow(c)
+
+	if err := w.restoreDelayedTimeouts(map[string]string{"logs": "10m", "metrics": "2m"}, "5m"); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := map[string]string{
+		"logs":    "10m",
+		"fake":    "1m",
+		"metrics": "30s",
+	}
+
+	for index, value := range expected {
+		if got := c.IndexSetting(index, delayedTimeoutSetting); got != value {
+			t.Errorf("delayed timeout of %s does not match. expected: %q, got: %q", index, value, got)
+		}
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	testcases := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{d: 5 * time.Minute, expected: "5m"},
+		{d: 90 * time.Second, expected: "90s"},
+		{d: 2 * time.Hour, expected: "120m"},
+	}
+
+	for _, tc := range testcases {
+		if got := timeValue(tc.d); got != tc.expected {
+			t.Errorf("time value of %s does not match. expected: %q, got: %q", tc.d, tc.expected, got)
+		}
+	}
+}
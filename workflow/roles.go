@@ -0,0 +1,167 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+const (
+	roleData   = "data"
+	roleIngest = "ingest"
+	roleMaster = "master"
+
+	// minimumMasterNodesSetting represents quorum of master-eligible nodes in Elasticsearch 6.x and older
+	minimumMasterNodesSetting = "discovery.zen.minimum_master_nodes"
+)
+
+// checkNodeRoles verifies the cluster keeps working without the node, and returns whether the node holds shards to drain
+// Coordinating-only, ingest-only and dedicated master nodes have no shards, so drain is skipped
+func (w *Workflow) checkNodeRoles(nodeName string, opts RemoveOptions) (bool, error) {
+	op := opts.Operation
+
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Checking roles of target node")
+
+	roles, err := w.ES.NodeRoles()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to retrieve node roles")
+	}
+
+	target, ok := roles[nodeName]
+	if !ok {
+		// Treat unknown node as data node, which is the safest
+		return true, nil
+	}
+
+	others := map[string]int{}
+
+	for n, r := range roles {
+		if n == nodeName {
+			continue
+		}
+
+		for _, role := range r {
+			others[role]++
+		}
+	}
+
+	if contains(target, roleIngest) && others[roleIngest] == 0 {
+		return false, exitcode.Errorf(exitcode.Validation, "%s is the last ingest node, and requests with ingest pipeline would fail without it. Removing it requires force", nodeName)
+	}
+
+	if contains(target, roleMaster) {
+		if err := w.checkMasterQuorum(nodeName, others[roleMaster]); err != nil {
+			return false, err
+		}
+
+		// Voting configuration exclusions are applied at shutdown if available, instead of minimum_master_nodes
+		if !w.supports((*es.Capabilities).SupportsVotingExclusions) {
+			fmt.Fprintf(progress, "WARNING: %s is master-eligible. Make sure %s is a majority of the remaining %d master-eligible nodes\n", nodeName, minimumMasterNodesSetting, others[roleMaster])
+		}
+	}
+
+	if !contains(target, roleData) {
+		fmt.Fprintf(progress, "%s is not a data node, shard drain will be skipped\n", nodeName)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// checkMasterQuorum refuses to remove master-eligible node if the remaining ones cannot elect master
+// minimum_master_nodes is checked only if it is set in cluster settings, not in elasticsearch.yml
+func (w *Workflow) checkMasterQuorum(nodeName string, remaining int) error {
+	if remaining == 0 {
+		return exitcode.Errorf(exitcode.Validation, "%s is the last master-eligible node", nodeName)
+	}
+
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	v, ok := settings[minimumMasterNodesSetting]
+	if !ok {
+		return nil
+	}
+
+	min, err := strconv.Atoi(v)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s %q", minimumMasterNodesSetting, v)
+	}
+
+	if remaining < min {
+		return exitcode.Errorf(exitcode.Validation, "only %d master-eligible nodes would remain after removing %s, fewer than %s=%d. Lower it first", remaining, nodeName, minimumMasterNodesSetting, min)
+	}
+
+	return nil
+}
+
+// excludedFromVoting returns whether the node may have been excluded from voting configuration at shutdown
+// Node which has already left the cluster is regarded as excluded, because its roles are unknown
+func (w *Workflow) excludedFromVoting(nodeName string) (bool, error) {
+	if !w.supports((*es.Capabilities).SupportsVotingExclusions) {
+		return false, nil
+	}
+
+	roles, err := w.ES.NodeRoles()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to retrieve node roles")
+	}
+
+	r, ok := roles[nodeName]
+
+	return !ok || contains(r, roleMaster), nil
+}
+
+// clearVotingExclusions waits for the node to leave the cluster, then clears voting configuration exclusions
+// Exclusions left would keep the node from voting if a node with the same name joins, and count towards the limit of exclusions
+// Timeout is warned only, because the node has been removed anyway and exclusions are cleared at the next shutdown
+func (w *Workflow) clearVotingExclusions(ctx context.Context, nodeName string, op *operation.Operation) error {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Waiting for target node to leave the cluster")
+
+	err := w.waitFor(ctx, op, removeTimeout, "nodes", "timed out: target node does not leave the cluster", func() (waitStatus, error) {
+		nodes, err := w.ES.ListNodes()
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to list nodes")
+		}
+
+		if contains(nodes, nodeName) {
+			return waitStatus{Remaining: 1}, nil
+		}
+
+		return waitStatus{}, nil
+	})
+	if err != nil {
+		if exitcode.Code(err) != exitcode.Timeout {
+			return err
+		}
+
+		fmt.Fprintf(progress, "WARNING: %s is still excluded from voting configuration: %s\n", nodeName, err)
+		return nil
+	}
+
+	op.Phase("Clearing voting configuration exclusions")
+
+	if err := w.ES.ClearVotingExclusions(); err != nil {
+		return errors.Wrap(err, "failed to clear voting configuration exclusions")
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package workflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// writeIndexOnNode represents write index of alias which has primaries on the node
+type writeIndexOnNode struct {
+	Alias     string
+	Index     string
+	Primaries int
+}
+
+// writeIndicesOnNode returns write indices having primaries on the given node
+// Ingestion into them may slow down while their primaries relocate
+func (w *Workflow) writeIndicesOnNode(nodeName string) ([]*writeIndexOnNode, error) {
+	writeIndices, err := w.ES.WriteIndices()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve write indices")
+	}
+
+	if len(writeIndices) == 0 {
+		return []*writeIndexOnNode{}, nil
+	}
+
+	shards, err := w.ES.ListShards()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list shards")
+	}
+
+	primaries := map[string]int{}
+
+	for _, s := range shards {
+		if s.Primary && s.Node == nodeName {
+			primaries[s.Index]++
+		}
+	}
+
+	result := []*writeIndexOnNode{}
+
+	for alias, index := range writeIndices {
+		if primaries[index] > 0 {
+			result = append(result, &writeIndexOnNode{Alias: alias, Index: index, Primaries: primaries[index]})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Alias < result[j].Alias
+	})
+
+	return result, nil
+}
+
+// checkWriteIndices warns about write indices with primaries on the node, and rolls the given alias over if any
+// Rolled over index stops receiving writes, so relocating its primaries does not slow down ingestion
+func (w *Workflow) checkWriteIndices(nodeName string, opts RemoveOptions) error {
+	op := opts.Operation
+
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Checking write indices on target node")
+
+	indices, err := w.writeIndicesOnNode(nodeName)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range indices {
+		fmt.Fprintf(progress, "WARNING: write index %s of alias %s has %d primaries on %s. Ingestion latency may increase while they relocate\n", i.Index, i.Alias, i.Primaries, nodeName)
+	}
+
+	if opts.RolloverAlias == "" {
+		return nil
+	}
+
+	onNode := false
+
+	for _, i := range indices {
+		if i.Alias == opts.RolloverAlias {
+			onNode = true
+		}
+	}
+
+	if !onNode {
+		fmt.Fprintf(progress, "write index of alias %s has no primary on %s, rollover skipped\n", opts.RolloverAlias, nodeName)
+		return nil
+	}
+
+	op.Phase(fmt.Sprintf("Rolling over alias %s", opts.RolloverAlias))
+
+	index, err := w.ES.Rollover(opts.RolloverAlias)
+	if err != nil {
+		return errors.Wrapf(err, "failed to roll over alias %s", opts.RolloverAlias)
+	}
+
+	fmt.Fprintf(progress, "alias %s now writes into %s\n", opts.RolloverAlias, index)
+
+	return nil
+}
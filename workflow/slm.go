@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+// checkSLMWindow looks for snapshots of SLM policies in progress or scheduled within window
+// They are only warned about unless respect is true, in which case operation waits for them to finish
+// Waiting times out after twice the window, i.e. the window plus the same for the snapshot itself
+func (w *Workflow) checkSLMWindow(ctx context.Context, op *operation.Operation, window time.Duration, respect bool) error {
+	if window <= 0 || !w.supports((*es.Capabilities).SupportsSLM) {
+		return nil
+	}
+
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Checking snapshot lifecycle management schedules")
+
+	imminent, err := w.imminentSLMPolicies(window)
+	if err != nil {
+		return err
+	}
+
+	if len(imminent) == 0 {
+		return nil
+	}
+
+	if !respect {
+		for _, p := range imminent {
+			if p.InProgress {
+				fmt.Fprintf(progress, "WARNING: snapshot of SLM policy %s is in progress. Give --respect-slm-window to wait for it\n", p.ID)
+			} else {
+				fmt.Fprintf(progress, "WARNING: snapshot of SLM policy %s is scheduled at %s, within %s. Give --respect-slm-window to wait for it\n", p.ID, p.NextExecution.Local().Format(time.RFC3339), window)
+			}
+		}
+
+		return nil
+	}
+
+	op.Phase("Waiting for scheduled snapshots to finish")
+
+	return w.waitFor(ctx, op, 2*window, "snapshots", "timed out: scheduled snapshots do not finish", func() (waitStatus, error) {
+		imminent, err := w.imminentSLMPolicies(window)
+		if err != nil {
+			return waitStatus{}, err
+		}
+
+		return waitStatus{Remaining: len(imminent)}, nil
+	})
+}
+
+// imminentSLMPolicies returns SLM policies whose snapshot is in progress or scheduled within window
+func (w *Workflow) imminentSLMPolicies(window time.Duration) ([]*snapshot.Policy, error) {
+	policies, err := w.ES.SLMPolicies()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get SLM policies")
+	}
+
+	now := time.Now()
+	imminent := []*snapshot.Policy{}
+
+	for _, p := range policies {
+		if p.Imminent(now, window) {
+			imminent = append(imminent, p)
+		}
+	}
+
+	return imminent, nil
+}
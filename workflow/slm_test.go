@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/snapshot"
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestRemoveNode_slmWindowFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.SetSLMPolicy(&snapshot.Policy{ID: "nightly", NextExecution: time.Now().Add(30 * time.Minute)})
+	c.SetSLMPolicy(&snapshot.Policy{ID: "weekly", NextExecution: time.Now().Add(72 * time.Hour)})
+
+	var buf bytes.Buffer
+
+	w := newFakeWorkflow(c)
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "7.4.0")
+	w.Progress = &buf
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, SLMWindow: time.Hour}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !strings.Contains(buf.String(), "WARNING: snapshot of SLM policy nightly is scheduled at") {
+		t.Errorf("snapshot scheduled within window should be warned. got: %q", buf.String())
+	}
+
+	if strings.Contains(buf.String(), "weekly") {
+		t.Errorf("snapshot scheduled after window should not be warned. got: %q", buf.String())
+	}
+}
+
+func TestRemoveNode_respectSLMWindowFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.SetSLMPolicy(&snapshot.Policy{ID: "nightly", InProgress: true})
+
+	w := newFakeWorkflow(c)
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "7.4.0")
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := w.RemoveNode(ctx, RemoveOptions{Group: fake.GroupName, NodeName: nodeName, SLMWindow: time.Hour, RespectSLMWindow: true})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("removal should wait for snapshot in progress until canceled. got: %v", err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("node should not be drained while snapshot is in progress. excluded: %q", got)
+	}
+}
+
+func TestAddNodes_slmWindowDisabled(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.SetSLMPolicy(&snapshot.Policy{ID: "nightly", InProgress: true})
+
+	var buf bytes.Buffer
+
+	w := newFakeWorkflow(c)
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "7.4.0")
+	w.Progress = &buf
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 1, RespectSLMWindow: true}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if strings.Contains(buf.String(), "nightly") {
+		t.Errorf("SLM policies should not be checked with zero window. got: %q", buf.String())
+	}
+}
@@ -0,0 +1,567 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StepResolve retrieves instance ID and target group of the node
+	StepResolve = "resolve"
+	// StepDetachLB detaches instance from target group
+	StepDetachLB = "detach-lb"
+	// StepWaitLB waits for connection draining
+	StepWaitLB = "wait-lb"
+	// StepExclude excludes node from shard allocation
+	StepExclude = "exclude"
+	// StepWaitDrain waits for shards escape from node
+	StepWaitDrain = "wait-drain"
+	// StepShutdown shuts down node
+	StepShutdown = "shutdown"
+	// StepWaitML waits for machine learning jobs on node to be reassigned to other nodes
+	StepWaitML = "wait-ml"
+	// StepDetachASG detaches instance from Auto Scaling Group
+	StepDetachASG = "detach-asg"
+	// StepDone represents that all steps have finished
+	StepDone = "done"
+)
+
+// SkippedMessage is printed when the step had already been done
+const SkippedMessage = "already done, skipped"
+
+// ExcludeNameSetting represents cluster setting which ExcludeNodeFromAllocation appends node to, as comma-separated list
+const ExcludeNameSetting = "cluster.routing.allocation.exclude._name"
+
+// removeStepOrder represents steps executed after StepResolve, in the same order as RemoveSteps
+var removeStepOrder = []string{
+	StepDetachLB,
+	StepWaitLB,
+	StepExclude,
+	StepWaitDrain,
+	StepShutdown,
+	StepWaitML,
+	StepDetachASG,
+}
+
+var removeStepUnits = map[string]string{
+	StepWaitLB:    "targets",
+	StepWaitDrain: "shards",
+	StepWaitML:    "jobs",
+}
+
+var removeStepTimeoutMessages = map[string]string{
+	StepWaitLB:    "timed out: instance still remains on target group",
+	StepWaitDrain: "timed out: shards do not escaped from the given node",
+	StepWaitML:    "timed out: machine learning jobs are not reassigned to other nodes",
+}
+
+// RemoveState represents progress of node removal executed step by step
+// State is designed to be passed between separate invocations as JSON
+type RemoveState struct {
+	Group          string `json:"group"`
+	NodeName       string `json:"node_name"`
+	Step           string `json:"step"`
+	InstanceID     string `json:"instance_id,omitempty"`
+	TargetGroupARN string `json:"target_group_arn,omitempty"`
+
+	// Waiting is true if the condition of waiting step is not satisfied yet
+	// The same step should be executed again after a while
+	Waiting bool `json:"waiting"`
+
+	// Remaining represents what the waiting step still waits for, e.g. the number of shards left on the node
+	Remaining int `json:"remaining,omitempty"`
+
+	// Skipped is true if the executed step had already been done, e.g. by previous failed run
+	Skipped bool `json:"skipped,omitempty"`
+
+	// KeepDesiredCapacity detaches instance without decrementing desired capacity, so that Auto Scaling Group launches replacement
+	KeepDesiredCapacity bool `json:"keep_desired_capacity,omitempty"`
+
+	// WarmPool returns instance to warm pool of Auto Scaling Group instead of detaching it
+	WarmPool bool `json:"warm_pool,omitempty"`
+
+	// TagScale records the new desired capacity in LastScaleTagKey tag of Auto Scaling Group after detach
+	TagScale bool `json:"tag_scale,omitempty"`
+
+	// SkipDrain skips exclusion and drain of node without shards, e.g. coordinating-only or dedicated master node
+	SkipDrain bool `json:"skip_drain,omitempty"`
+
+	// Shards represents shards on the node when it was excluded, in [index][shard] format
+	// Every one of them must have started copy on other nodes before shutdown
+	Shards []string `json:"shards,omitempty"`
+
+	// MLJobs represents machine learning jobs opened on the node when it was shut down
+	// Only they are waited for to be reassigned to other nodes
+	MLJobs []string `json:"ml_jobs,omitempty"`
+}
+
+// RemoveStep executes the current step and returns the state pointing the next step
+// Every step checks whether it has already been done, so executing the same state twice is safe
+// Waiting steps check their condition only once and return the same step with Waiting
+func (w *Workflow) RemoveStep(ctx context.Context, s *RemoveState) (*RemoveState, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	next := *s
+	next.Waiting = false
+	next.Remaining = 0
+	next.Skipped = false
+
+	if s.Step == "" {
+		next.Step = StepResolve
+	}
+
+	switch next.Step {
+	case StepResolve:
+		p, err := w.PlanRemoval(ctx, RemoveOptions{
+			Group:     s.Group,
+			NodeName:  s.NodeName,
+			Operation: operation.New("remove", w.ClusterURL),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		next.InstanceID = p.InstanceID
+		next.TargetGroupARN = p.TargetGroupARN
+		next.Step = StepDetachLB
+
+		return &next, nil
+	case StepDone:
+		return &next, nil
+	}
+
+	if s.InstanceID == "" || s.TargetGroupARN == "" {
+		return nil, errors.Errorf("instance_id and target_group_arn must be resolved before step %q", next.Step)
+	}
+
+	if s.SkipDrain && (next.Step == StepExclude || next.Step == StepWaitDrain) {
+		next.Skipped = true
+		next.Step = nextStep(next.Step)
+
+		return &next, nil
+	}
+
+	switch next.Step {
+	case StepDetachLB:
+		attached, err := w.attachedToTargetGroup(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if attached {
+			if err := w.ELBv2.DetachInstance(s.TargetGroupARN, s.InstanceID); err != nil {
+				return nil, errors.Wrap(err, "failed to detach instance from target group")
+			}
+		} else {
+			next.Skipped = true
+		}
+	case StepWaitLB:
+		attached, err := w.attachedToTargetGroup(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if attached {
+			next.Waiting = true
+			next.Remaining = 1
+			return &next, nil
+		}
+	case StepExclude:
+		settings, err := w.ES.ClusterSettings()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve cluster settings")
+		}
+
+		if len(next.Shards) == 0 {
+			shards, err := w.ES.ListShards()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to list shards")
+			}
+
+			next.Shards = shardIDsOnNode(shards, s.NodeName)
+		}
+
+		if contains(excludedNodes(settings), s.NodeName) {
+			next.Skipped = true
+		} else if err := w.ES.ExcludeNodeFromAllocation(s.NodeName); err != nil {
+			return nil, errors.Wrap(err, "failed to exclude node from allocation group")
+		}
+	case StepWaitDrain:
+		shards, err := w.ES.ListShardsOnNode(s.NodeName)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list shards on the given node")
+		}
+
+		if len(shards) > 0 {
+			next.Waiting = true
+			next.Remaining = len(shards)
+			return &next, nil
+		}
+	case StepShutdown:
+		nodes, err := w.ES.ListNodes()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list nodes")
+		}
+
+		if contains(nodes, s.NodeName) {
+			if err := w.verifyShardCopies(s); err != nil {
+				return nil, err
+			}
+
+			jobs, err := w.shutdown(s.NodeName)
+			if err != nil {
+				return nil, err
+			}
+
+			next.MLJobs = jobs
+		} else {
+			next.Skipped = true
+		}
+	case StepWaitML:
+		jobs, err := w.unassignedMLJobs(s.NodeName, s.MLJobs)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(jobs) > 0 {
+			next.Waiting = true
+			next.Remaining = len(jobs)
+			return &next, nil
+		}
+	case StepDetachASG:
+		instances, err := w.AutoScaling.ListInstances(s.Group)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list instances in AutoScaling Group")
+		}
+
+		switch {
+		case !contains(instances, s.InstanceID):
+			next.Skipped = true
+		case s.WarmPool:
+			if err := w.AutoScaling.ReturnToWarmPool(s.Group, s.InstanceID); err != nil {
+				return nil, errors.Wrap(err, "failed to return instance to warm pool")
+			}
+		default:
+			if err := w.AutoScaling.DetachInstance(s.Group, s.InstanceID, !s.KeepDesiredCapacity); err != nil {
+				return nil, errors.Wrap(err, "failed to detach instance from AutoScaling Group")
+			}
+		}
+
+		if s.TagScale && !next.Skipped && (s.WarmPool || !s.KeepDesiredCapacity) {
+			w.tagLastScale(s.Group, "remove")
+		}
+	default:
+		return nil, errors.Errorf("unknown step %q", next.Step)
+	}
+
+	next.Step = nextStep(next.Step)
+
+	return &next, nil
+}
+
+// RemoveStepDescription returns human readable description of the given step
+func RemoveStepDescription(step string) string {
+	for i, s := range removeStepOrder {
+		if s == step {
+			return RemoveSteps[i]
+		}
+	}
+
+	if step == StepResolve {
+		return "Retrieving target instance and target group"
+	}
+
+	return step
+}
+
+// runRemoveSteps executes steps from s until all steps finish, waiting on waiting steps
+func (w *Workflow) runRemoveSteps(ctx context.Context, s *RemoveState, op *operation.Operation) error {
+	return w.runRemoveStepsUntil(ctx, s, op, StepDone)
+}
+
+// runRemoveStepsUntil executes steps from s until the given step, which is not executed
+func (w *Workflow) runRemoveStepsUntil(ctx context.Context, s *RemoveState, op *operation.Operation, until string) error {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	for s.Step != until && s.Step != StepDone {
+		op.Phase(RemoveStepDescription(s.Step))
+
+		if s.Step == StepShutdown {
+			w.tagInstance(s.InstanceID, op, RemovedByTagKey, RemovedAtTagKey)
+		}
+
+		timeoutMessage, ok := removeStepTimeoutMessages[s.Step]
+		if !ok {
+			next, err := w.RemoveStep(ctx, s)
+			if err != nil {
+				return err
+			}
+
+			if next.Skipped {
+				fmt.Fprintln(progress, SkippedMessage)
+			}
+
+			if len(next.Shards) > 0 {
+				op.SetShards(len(next.Shards))
+			}
+
+			s = next
+			continue
+		}
+
+		err := w.waitFor(ctx, op, w.stepTimeout(s.Step), removeStepUnits[s.Step], timeoutMessage, func() (waitStatus, error) {
+			next, err := w.RemoveStep(ctx, s)
+			if err != nil {
+				return waitStatus{}, err
+			}
+
+			if next.Waiting {
+				status := waitStatus{Remaining: next.Remaining}
+
+				if next.Step == StepWaitDrain {
+					status.Bytes = w.nodeStoreBytes(next.NodeName)
+				}
+
+				return status, nil
+			}
+
+			s = next
+
+			return waitStatus{}, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nodeStoreBytes returns size of shards on the given node, or 0 if unknown
+// Failure is ignored because the size is used only to show progress
+func (w *Workflow) nodeStoreBytes(nodeName string) int64 {
+	nodes, err := w.ES.NodeStats()
+	if err != nil {
+		return 0
+	}
+
+	for _, n := range nodes {
+		if n.Name == nodeName {
+			return n.StoreBytes
+		}
+	}
+
+	return 0
+}
+
+// shutdown shuts down the given node, and returns IDs of machine learning jobs opened on it
+// If such jobs exist, they are moved to other nodes gracefully before shutdown, so that they do not fail mid-bucket:
+// upgrade mode persists their state and unassigns them, then the node is registered for removal so that they are assigned to other nodes once upgrade mode is disabled
+// They are not kept paused until the node leaves the cluster, because the node keeps running after shutdown without shutdown API
+func (w *Workflow) shutdown(nodeName string) (ids []string, err error) {
+	jobs, err := w.ES.MLJobs()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve machine learning jobs")
+	}
+
+	ids = []string{}
+
+	for _, j := range jobs {
+		if j.State == "opened" && j.Node == nodeName {
+			ids = append(ids, j.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		if err := w.ES.Shutdown(nodeName); err != nil {
+			return nil, errors.Wrap(err, "failed to shutdown node")
+		}
+
+		return ids, nil
+	}
+
+	advice := fmt.Sprintf("Close machine learning jobs on %s (%s) or move them to other nodes before removal.", nodeName, strings.Join(ids, ", "))
+	if err := w.require((*es.Capabilities).SupportsNodeShutdownAPI, "node shutdown API", advice); err != nil {
+		return nil, err
+	}
+
+	if err := w.ES.SetMLUpgradeMode(true); err != nil {
+		return nil, errors.Wrap(err, "failed to enable machine learning upgrade mode")
+	}
+
+	// Upgrade mode pauses jobs on all nodes, so it is disabled even if shutdown fails
+	defer func() {
+		if e := w.ES.SetMLUpgradeMode(false); e != nil && err == nil {
+			ids, err = nil, errors.Wrap(e, "failed to disable machine learning upgrade mode")
+		}
+	}()
+
+	if err := w.ES.PrepareNodeShutdown(nodeName); err != nil {
+		return nil, errors.Wrap(err, "failed to register node for removal")
+	}
+
+	if err := w.ES.Shutdown(nodeName); err != nil {
+		return nil, errors.Wrap(err, "failed to shutdown node")
+	}
+
+	return ids, nil
+}
+
+// unassignedMLJobs returns the given machine learning jobs which are opened but not running on nodes other than the given one
+// Jobs unassigned for other reasons, e.g. on other removed nodes, are not waited for
+func (w *Workflow) unassignedMLJobs(nodeName string, ids []string) ([]string, error) {
+	jobs, err := w.ES.MLJobs()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve machine learning jobs")
+	}
+
+	unassigned := []string{}
+
+	for _, j := range jobs {
+		if contains(ids, j.ID) && j.State == "opened" && (j.Node == "" || j.Node == nodeName) {
+			unassigned = append(unassigned, j.ID)
+		}
+	}
+
+	return unassigned, nil
+}
+
+// verifyShardCopies fails unless every shard recorded at exclusion or still on the node has started copy on other nodes
+// Network hiccups may leave the only started copy on the node even after drain, and shutting it down turns the cluster red
+func (w *Workflow) verifyShardCopies(s *RemoveState) error {
+	shards, err := w.ES.ListShards()
+	if err != nil {
+		return errors.Wrap(err, "failed to list shards")
+	}
+
+	ids := append([]string{}, s.Shards...)
+
+	for _, id := range shardIDsOnNode(shards, s.NodeName) {
+		if !contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	available := map[string]bool{}
+
+	for _, shard := range shards {
+		if shard.Started() && shard.Node != "" && shard.Node != s.NodeName {
+			available[shardID(shard)] = true
+		}
+	}
+
+	missing := []string{}
+
+	for _, id := range ids {
+		if !available[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		return exitcode.Errorf(exitcode.Aborted, "refused to shut down %s, because %d shards have no started copy on other nodes: %s", s.NodeName, len(missing), strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (w *Workflow) attachedToTargetGroup(s *RemoveState) (bool, error) {
+	instances, err := w.ELBv2.ListTargetInstances(s.TargetGroupARN)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list instances attached to target group")
+	}
+
+	return contains(instances, s.InstanceID), nil
+}
+
+func nextStep(step string) string {
+	for i, s := range removeStepOrder {
+		if s == step && i+1 < len(removeStepOrder) {
+			return removeStepOrder[i+1]
+		}
+	}
+
+	return StepDone
+}
+
+// excludedNodes returns nodes listed in allocation exclusion
+func excludedNodes(settings map[string]string) []string {
+	nodes := []string{}
+
+	for _, n := range strings.Split(settings[ExcludeNameSetting], ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes
+}
+
+// includeInAllocation removes the given node from allocation exclusion, leaving the other nodes excluded
+// It returns false if the node is not excluded
+func (w *Workflow) includeInAllocation(nodeName string) (bool, error) {
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	nodes := excludedNodes(settings)
+	if !contains(nodes, nodeName) {
+		return false, nil
+	}
+
+	others := []string{}
+
+	for _, n := range nodes {
+		if n != nodeName {
+			others = append(others, n)
+		}
+	}
+
+	if err := w.ES.UpdateClusterSettings(map[string]string{ExcludeNameSetting: strings.Join(others, ",")}); err != nil {
+		return false, errors.Wrap(err, "failed to clear allocation exclusion")
+	}
+
+	return true, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shardIDsOnNode returns IDs of shards having copy on the given node
+func shardIDsOnNode(shards []*stats.Shard, nodeName string) []string {
+	ids := []string{}
+
+	for _, shard := range shards {
+		if shard.Node == nodeName && !contains(ids, shardID(shard)) {
+			ids = append(ids, shardID(shard))
+		}
+	}
+
+	return ids
+}
+
+// shardID returns ID of shard in [index][shard] format as Elasticsearch logs
+func shardID(shard *stats.Shard) string {
+	return fmt.Sprintf("[%s][%d]", shard.Index, shard.Shard)
+}
@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	"time"
+)
+
+var (
+	// defaultLBDrainTimeout represents how long to wait for instance to be deregistered from target group
+	defaultLBDrainTimeout = 5 * time.Minute
+	// defaultShardDrainTimeout represents how long to wait for shards to escape from node
+	defaultShardDrainTimeout = 5 * time.Minute
+	// defaultJoinTimeout represents how long to wait for added nodes to join the cluster
+	defaultJoinTimeout = 10 * time.Minute
+	// defaultMLTimeout represents how long to wait for machine learning jobs of removed node to be reassigned
+	defaultMLTimeout = 5 * time.Minute
+)
+
+// lbDrainTimeout returns LBDrainTimeout of the workflow, or the default if not set
+func (w *Workflow) lbDrainTimeout() time.Duration {
+	if w.LBDrainTimeout > 0 {
+		return w.LBDrainTimeout
+	}
+
+	return defaultLBDrainTimeout
+}
+
+// shardDrainTimeout returns ShardDrainTimeout of the workflow, or the default if not set
+func (w *Workflow) shardDrainTimeout() time.Duration {
+	if w.ShardDrainTimeout > 0 {
+		return w.ShardDrainTimeout
+	}
+
+	return defaultShardDrainTimeout
+}
+
+// joinTimeout returns JoinTimeout of the workflow, or the default if not set
+func (w *Workflow) joinTimeout() time.Duration {
+	if w.JoinTimeout > 0 {
+		return w.JoinTimeout
+	}
+
+	return defaultJoinTimeout
+}
+
+// mlTimeout returns MLTimeout of the workflow, or the default if not set
+func (w *Workflow) mlTimeout() time.Duration {
+	if w.MLTimeout > 0 {
+		return w.MLTimeout
+	}
+
+	return defaultMLTimeout
+}
+
+// stepTimeout returns timeout of the given waiting step of node removal
+func (w *Workflow) stepTimeout(step string) time.Duration {
+	switch step {
+	case StepWaitLB:
+		return w.lbDrainTimeout()
+	case StepWaitDrain:
+		return w.shardDrainTimeout()
+	case StepWaitML:
+		return w.mlTimeout()
+	}
+
+	return removeTimeout
+}
@@ -0,0 +1,33 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStepTimeout(t *testing.T) {
+	w := &Workflow{
+		ShardDrainTimeout: 3 * time.Hour,
+		MLTimeout:         20 * time.Minute,
+	}
+
+	testcases := []struct {
+		step     string
+		expected time.Duration
+	}{
+		{StepWaitLB, defaultLBDrainTimeout},
+		{StepWaitDrain, 3 * time.Hour},
+		{StepWaitML, 20 * time.Minute},
+		{StepShutdown, removeTimeout},
+	}
+
+	for _, tc := range testcases {
+		if got := w.stepTimeout(tc.step); got != tc.expected {
+			t.Errorf("timeout of %s does not match. expected: %s, got: %s", tc.step, tc.expected, got)
+		}
+	}
+
+	if got := w.joinTimeout(); got != defaultJoinTimeout {
+		t.Errorf("join timeout does not match. expected: %s, got: %s", defaultJoinTimeout, got)
+	}
+}
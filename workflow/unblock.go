@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+const (
+	floodStageSetting = "cluster.routing.allocation.disk.watermark.flood_stage"
+
+	// defaultFloodStage represents the default of flood-stage disk watermark in percent
+	defaultFloodStage = 95.0
+)
+
+// warnBlocks prints warning about indices made read-only by flood-stage disk watermark
+// Elasticsearch older than 7.4 never releases the block by itself even after nodes are added
+func (w *Workflow) warnBlocks(httpClient *http.Client) {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	blocked, err := es.BlockedIndices(w.ClusterURL, httpClient, []string{es.ReadOnlyAllowDeleteBlock})
+	if err != nil {
+		fmt.Fprintf(progress, "WARNING: failed to check blocked indices: %s\n", err)
+		return
+	}
+
+	if len(blocked) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(blocked))
+
+	for _, b := range blocked {
+		names = append(names, b.Index)
+	}
+
+	fmt.Fprintf(progress, "WARNING: %d indices are read-only after exceeding flood-stage disk watermark: %s. Clear the blocks with esnctl unblock\n", len(names), strings.Join(names, ", "))
+}
+
+// Unblock clears the given blocks from indices and returns the unblocked indices
+// read_only_allow_delete is refused while some node is above flood-stage disk watermark, because Elasticsearch sets it again
+func (w *Workflow) Unblock(opts UnblockOptions) ([]*es.IndexBlocks, error) {
+	if opts.HTTPClient == nil {
+		return nil, errors.New("HTTP client must be given to unblock indices")
+	}
+
+	blocks := opts.Blocks
+	if len(blocks) == 0 {
+		blocks = []string{es.ReadOnlyAllowDeleteBlock}
+	}
+
+	op := w.operation(opts.Operation, "unblock")
+
+	op.Phase("Retrieving blocked indices")
+
+	blocked, err := es.BlockedIndices(w.ClusterURL, opts.HTTPClient, blocks)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve blocked indices")
+	}
+
+	if len(opts.Indices) > 0 {
+		filtered := []*es.IndexBlocks{}
+
+		for _, b := range blocked {
+			if contains(opts.Indices, b.Index) {
+				filtered = append(filtered, b)
+			}
+		}
+
+		blocked = filtered
+	}
+
+	if len(blocked) == 0 {
+		return blocked, nil
+	}
+
+	if contains(blocks, es.ReadOnlyAllowDeleteBlock) && !opts.Force {
+		op.Phase("Checking disk usage against flood-stage watermark")
+
+		if err := w.checkFloodStage(); err != nil {
+			return nil, err
+		}
+	}
+
+	indices := make([]string, 0, len(blocked))
+
+	for _, b := range blocked {
+		indices = append(indices, b.Index)
+	}
+
+	op.Phase(fmt.Sprintf("Clearing %s from %d indices", strings.Join(blocks, ", "), len(indices)))
+
+	if err := es.ClearBlocks(w.ClusterURL, opts.HTTPClient, indices, blocks); err != nil {
+		return nil, errors.Wrap(err, "failed to clear blocks")
+	}
+
+	return blocked, nil
+}
+
+// checkFloodStage returns error if any node is at or above flood-stage disk watermark
+// Watermark in absolute bytes cannot be compared with disk percent, so the check is skipped
+func (w *Workflow) checkFloodStage() error {
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	floodStage, ok := parseWatermarkPercent(settings[floodStageSetting], defaultFloodStage)
+	if !ok {
+		return nil
+	}
+
+	nodes, err := w.ES.NodeStats()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve node stats")
+	}
+
+	full := []string{}
+
+	for _, n := range nodes {
+		if n.DiskPercent >= floodStage {
+			full = append(full, fmt.Sprintf("%s (%.1f%%)", n.Name, n.DiskPercent))
+		}
+	}
+
+	if len(full) == 0 {
+		return nil
+	}
+
+	sort.Strings(full)
+
+	return exitcode.Errorf(exitcode.Validation, "%d nodes are above flood-stage watermark %g%%: %s. The blocks would be set again, so add nodes with esnctl add or delete indices first, or give --force", len(full), floodStage, strings.Join(full, ", "))
+}
+
+// parseWatermarkPercent parses watermark in percent, e.g. 95%, or ratio, e.g. 0.95, and returns def if it is empty
+// false is returned for watermark in absolute bytes, e.g. 10gb
+func parseWatermarkPercent(v string, def float64) (float64, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return def, true
+	}
+
+	if strings.HasSuffix(v, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		return f, err == nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return f * 100, true
+}
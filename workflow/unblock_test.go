@@ -0,0 +1,107 @@
+package workflow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+)
+
+type diskClient struct {
+	es.Client
+
+	diskPercent float64
+}
+
+func (c *diskClient) ClusterSettings() (map[string]string, error) {
+	return map[string]string{floodStageSetting: "0.95"}, nil
+}
+
+func (c *diskClient) NodeStats() ([]*stats.Node, error) {
+	return []*stats.Node{
+		{Name: "node-1", DiskPercent: 50},
+		{Name: "node-2", DiskPercent: c.diskPercent},
+	}, nil
+}
+
+func TestUnblock(t *testing.T) {
+	cleared := ""
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"logs-1": {"settings": {"index.blocks.read_only_allow_delete": "true"}}, "logs-2": {"settings": {"index.blocks.read_only_allow_delete": "true"}}}`))
+		case http.MethodPut:
+			cleared = r.URL.Path
+			w.Write([]byte(`{"acknowledged": true}`))
+		}
+	}))
+	defer ts.Close()
+
+	testcases := []struct {
+		diskPercent float64
+		force       bool
+		indices     []string
+		code        int
+		cleared     string
+	}{
+		{diskPercent: 96, code: exitcode.Validation},
+		{diskPercent: 96, force: true, cleared: "/logs-1,logs-2/_settings"},
+		{diskPercent: 80, indices: []string{"logs-2", "logs-3"}, cleared: "/logs-2/_settings"},
+	}
+
+	for _, tc := range testcases {
+		cleared = ""
+
+		w := &Workflow{
+			ClusterURL: ts.URL,
+			ES:         &diskClient{diskPercent: tc.diskPercent},
+		}
+
+		_, err := w.Unblock(UnblockOptions{
+			Indices:    tc.indices,
+			Force:      tc.force,
+			HTTPClient: &http.Client{},
+		})
+
+		if tc.code != 0 {
+			if exitcode.Code(err) != tc.code {
+				t.Errorf("exit code does not match. expected: %d, got: %d (%v)", tc.code, exitcode.Code(err), err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("error should not be raised: %s", err)
+		}
+
+		if cleared != tc.cleared {
+			t.Errorf("cleared indices do not match. expected: %q, got: %q", tc.cleared, cleared)
+		}
+	}
+}
+
+func TestParseWatermarkPercent(t *testing.T) {
+	testcases := []struct {
+		value    string
+		expected float64
+		ok       bool
+	}{
+		{value: "", expected: defaultFloodStage, ok: true},
+		{value: "97%", expected: 97, ok: true},
+		{value: "0.9", expected: 90, ok: true},
+		{value: "10gb", ok: false},
+	}
+
+	for _, tc := range testcases {
+		got, ok := parseWatermarkPercent(tc.value, defaultFloodStage)
+
+		if ok != tc.ok || (ok && got != tc.expected) {
+			t.Errorf("watermark of %q does not match. expected: %g %t, got: %g %t", tc.value, tc.expected, tc.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/pkg/errors"
+)
+
+// Statuses of validation check
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+const (
+	highWatermarkSetting = "cluster.routing.allocation.disk.watermark.high"
+
+	// defaultHighWatermark represents the default of high disk watermark in percent
+	defaultHighWatermark = 90.0
+)
+
+// Check represents result of one pre-flight check
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	// Code represents exit code which the operation would fail with
+	Code int `json:"code,omitempty"`
+}
+
+// ValidationReport represents results of pre-flight checks of operation
+type ValidationReport struct {
+	Operation string   `json:"operation"`
+	Group     string   `json:"group"`
+	NodeName  string   `json:"node_name"`
+	Passed    bool     `json:"passed"`
+	Checks    []*Check `json:"checks"`
+}
+
+func (r *ValidationReport) add(name, status, message string) {
+	r.Checks = append(r.Checks, &Check{Name: name, Status: status, Message: message})
+}
+
+// fail adds failed check with exit code of err
+func (r *ValidationReport) fail(name string, err error) {
+	r.Checks = append(r.Checks, &Check{Name: name, Status: CheckFail, Message: err.Error(), Code: exitcode.Code(err)})
+}
+
+// addResult adds passed check, or failed one if err is given
+func (r *ValidationReport) addResult(name string, err error, message string) {
+	if err != nil {
+		r.fail(name, err)
+		return
+	}
+
+	r.add(name, CheckPass, message)
+}
+
+// Err returns error with exit code of the first failed check, or nil if every check passed
+func (r *ValidationReport) Err() error {
+	for _, c := range r.Checks {
+		if c.Status == CheckFail {
+			return exitcode.Errorf(c.Code, "%s would be refused: %s", r.Operation, c.Message)
+		}
+	}
+
+	return nil
+}
+
+// Render prints results of checks as table
+func (r *ValidationReport) Render(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+
+	for _, c := range r.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, strings.ToUpper(c.Status), c.Message)
+	}
+
+	w.Flush()
+
+	if r.Passed {
+		fmt.Fprintf(out, "\n%s of %s would be accepted\n", r.Operation, r.NodeName)
+	} else {
+		fmt.Fprintf(out, "\n%s of %s would be refused\n", r.Operation, r.NodeName)
+	}
+}
+
+// ValidateRemoval runs pre-flight checks of RemoveNode without changing anything
+// AWS permissions are verified only for lookups, because detaching and terminating cannot be tried without effect
+func (w *Workflow) ValidateRemoval(ctx context.Context, opts RemoveOptions) (*ValidationReport, error) {
+	opts.Operation = w.operation(opts.Operation, "validate")
+
+	r := &ValidationReport{
+		Operation: "remove",
+		Group:     opts.Group,
+		NodeName:  opts.NodeName,
+		Checks:    []*Check{},
+	}
+
+	defer func() {
+		r.Passed = r.Err() == nil
+	}()
+
+	p, err := w.PlanRemoval(ctx, opts)
+	if err != nil {
+		r.fail("aws", err)
+		return r, nil
+	}
+
+	r.add("aws", CheckPass, fmt.Sprintf("instance %s in %s, target group %s", p.InstanceID, p.Group, p.TargetGroupARN))
+
+	if opts.RolloverAlias != "" {
+		r.addResult("rollover", w.require((*es.Capabilities).SupportsRollover, "rollover API", "Roll over the alias manually before removal instead of --rollover-first."), "rollover API is available")
+	}
+
+	if opts.ReturnToWarmPool {
+		r.addResult("warm-pool", w.checkWarmPool(p.Group, opts), "instance returns to warm pool")
+	}
+
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		r.fail("node", errors.Wrap(err, "failed to list nodes"))
+		return r, nil
+	}
+
+	switch {
+	case !contains(nodes, opts.NodeName):
+		r.add("node", CheckWarn, "node is not in the cluster, shard drain will be skipped")
+		return r, nil
+	case opts.Force:
+		r.add("node", CheckWarn, "shard drain will be skipped by force, shards are recovered from replicas")
+		return r, nil
+	}
+
+	r.add("node", CheckPass, "node is in the cluster")
+
+	w.validateHealth(r)
+
+	drain, err := w.checkNodeRoles(opts.NodeName, opts)
+	if err != nil {
+		r.fail("roles", err)
+		return r, nil
+	}
+
+	if !drain {
+		r.add("roles", CheckPass, "node is not a data node, shard drain will be skipped")
+		return r, nil
+	}
+
+	r.add("roles", CheckPass, "cluster keeps working without the node")
+
+	w.validateIndexPins(r, opts)
+	r.addResult("shard-capacity", w.checkShardCapacity(opts.NodeName, opts), "remaining nodes can host all shards")
+	w.validateDisk(r, opts.NodeName)
+	w.validateReplicas(r, opts.NodeName)
+	w.validateAwareness(r, opts.NodeName)
+	w.validateWriteIndices(r, opts)
+
+	return r, nil
+}
+
+// validateHealth fails on red cluster, whose missing shards cannot be drained, and warns on yellow cluster
+func (w *Workflow) validateHealth(r *ValidationReport) {
+	health, err := w.ES.ClusterHealth()
+	if err != nil {
+		r.fail("health", errors.Wrap(err, "failed to retrieve cluster health"))
+		return
+	}
+
+	switch health.Status {
+	case "green":
+		r.add("health", CheckPass, "cluster is green")
+	case "red":
+		r.Checks = append(r.Checks, &Check{Name: "health", Status: CheckFail, Message: fmt.Sprintf("cluster is red with %d unassigned shards", health.UnassignedShards), Code: exitcode.Validation})
+	default:
+		r.add("health", CheckWarn, fmt.Sprintf("cluster is %s with %d unassigned shards", health.Status, health.UnassignedShards))
+	}
+}
+
+// validateIndexPins is the same as checkIndexPins, except that filters are never reset
+func (w *Workflow) validateIndexPins(r *ValidationReport, opts RemoveOptions) {
+	pins, err := w.indexPins(opts.NodeName)
+	if err != nil {
+		r.fail("index-pins", err)
+		return
+	}
+
+	if len(pins) == 0 {
+		r.add("index-pins", CheckPass, "no index allocation filter pins shards to the node")
+		return
+	}
+
+	list := make([]string, 0, len(pins))
+
+	for _, pin := range pins {
+		list = append(list, pin.String())
+	}
+
+	if opts.FixIndexFilters {
+		r.add("index-pins", CheckWarn, fmt.Sprintf("index allocation filters will be reset: %s", strings.Join(list, ", ")))
+		return
+	}
+
+	r.Checks = append(r.Checks, &Check{
+		Name:    "index-pins",
+		Status:  CheckFail,
+		Message: fmt.Sprintf("shards would never escape, because index allocation filters allow them only on the node: %s", strings.Join(list, ", ")),
+		Code:    exitcode.Validation,
+	})
+}
+
+// validateDisk projects disk usage of the remaining data nodes after the node's shards move to them
+// Every data node is assumed to have the same disk size, and watermark in absolute bytes is not checked
+func (w *Workflow) validateDisk(r *ValidationReport, nodeName string) {
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		r.fail("disk-watermark", errors.Wrap(err, "failed to retrieve cluster settings"))
+		return
+	}
+
+	high, ok := parseWatermarkPercent(settings[highWatermarkSetting], defaultHighWatermark)
+	if !ok {
+		r.add("disk-watermark", CheckWarn, fmt.Sprintf("%s is given in absolute bytes, so projected disk usage is not checked", highWatermarkSetting))
+		return
+	}
+
+	nodes, err := w.ES.NodeStats()
+	if err != nil {
+		r.fail("disk-watermark", errors.Wrap(err, "failed to retrieve node stats"))
+		return
+	}
+
+	projected, ok := projectDiskPercent(nodes, nodeName)
+	if !ok {
+		r.Checks = append(r.Checks, &Check{Name: "disk-watermark", Status: CheckFail, Message: "no data node would remain to host shards", Code: exitcode.Validation})
+		return
+	}
+
+	if projected >= high {
+		r.Checks = append(r.Checks, &Check{
+			Name:    "disk-watermark",
+			Status:  CheckFail,
+			Message: fmt.Sprintf("remaining nodes would use %.1f%% of disk on average, above high watermark %g%%. Add nodes first", projected, high),
+			Code:    exitcode.Validation,
+		})
+
+		return
+	}
+
+	r.add("disk-watermark", CheckPass, fmt.Sprintf("remaining nodes would use %.1f%% of disk on average, below high watermark %g%%", projected, high))
+}
+
+// projectDiskPercent returns mean disk usage of nodes with shards after shards of the given node move to them
+func projectDiskPercent(nodes []*stats.Node, nodeName string) (float64, bool) {
+	var total float64
+
+	remaining := 0
+
+	for _, n := range nodes {
+		if n.Name == nodeName {
+			total += n.DiskPercent
+			continue
+		}
+
+		if n.Shards == 0 {
+			continue
+		}
+
+		total += n.DiskPercent
+		remaining++
+	}
+
+	if remaining == 0 {
+		return 0, false
+	}
+
+	return total / float64(remaining), true
+}
+
+// validateReplicas warns about shards on the node without started copy elsewhere
+// Drain copies them before shutdown, but they are lost if the node dies while draining
+func (w *Workflow) validateReplicas(r *ValidationReport, nodeName string) {
+	shards, err := w.ES.ListShards()
+	if err != nil {
+		r.fail("replicas", errors.Wrap(err, "failed to list shards"))
+		return
+	}
+
+	copies := map[string]int{}
+
+	for _, s := range shards {
+		if s.Node != nodeName && s.Started() {
+			copies[shardID(s)]++
+		}
+	}
+
+	single := []string{}
+
+	for _, s := range shards {
+		if s.Node == nodeName && copies[shardID(s)] == 0 {
+			single = append(single, shardID(s))
+		}
+	}
+
+	if len(single) == 0 {
+		r.add("replicas", CheckPass, "every shard on the node has started copy elsewhere")
+		return
+	}
+
+	r.add("replicas", CheckWarn, fmt.Sprintf("%d shards have no started copy elsewhere until drained: %s", len(single), strings.Join(single, ", ")))
+}
+
+func (w *Workflow) validateAwareness(r *ValidationReport, nodeName string) {
+	warnings, err := w.awarenessWarnings(nodeName, []string{})
+	if err != nil {
+		r.add("awareness", CheckWarn, fmt.Sprintf("failed to check allocation awareness: %s", err))
+		return
+	}
+
+	if len(warnings) == 0 {
+		r.add("awareness", CheckPass, "allocation awareness is kept")
+		return
+	}
+
+	r.add("awareness", CheckWarn, strings.Join(warnings, "; "))
+}
+
+func (w *Workflow) validateWriteIndices(r *ValidationReport, opts RemoveOptions) {
+	indices, err := w.writeIndicesOnNode(opts.NodeName)
+	if err != nil {
+		r.fail("write-indices", err)
+		return
+	}
+
+	list := []string{}
+
+	for _, i := range indices {
+		if i.Alias == opts.RolloverAlias {
+			continue
+		}
+
+		list = append(list, fmt.Sprintf("%s (%s, %d primaries)", i.Index, i.Alias, i.Primaries))
+	}
+
+	if len(list) == 0 {
+		r.add("write-indices", CheckPass, "no write index has primaries on the node")
+		return
+	}
+
+	r.add("write-indices", CheckWarn, fmt.Sprintf("ingestion latency may increase while primaries of write indices relocate: %s", strings.Join(list, ", ")))
+}
@@ -0,0 +1,92 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+)
+
+func TestValidateRemoval_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	r, err := w.ValidateRemoval(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !r.Passed || r.Err() != nil {
+		t.Errorf("validation should pass. got: %+v", r.Checks)
+	}
+
+	for _, name := range []string{"aws", "node", "health", "roles", "index-pins", "shard-capacity", "disk-watermark", "replicas", "awareness", "write-indices"} {
+		found := false
+
+		for _, check := range r.Checks {
+			if check.Name == name {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Errorf("check %s should be run", name)
+		}
+	}
+
+	c.SetIndexSetting("fake", "index.routing.allocation.require._name", nodeName)
+
+	r, err = w.ValidateRemoval(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if r.Passed || exitcode.Code(r.Err()) != exitcode.Validation {
+		t.Errorf("validation should fail with pinned shards. got: %v", r.Err())
+	}
+
+	if got := c.IndexSetting("fake", "index.routing.allocation.require._name"); got != nodeName {
+		t.Errorf("index allocation filter should not be reset. got: %q", got)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("node should not be excluded by validation. got: %q", got)
+	}
+
+	var buf bytes.Buffer
+
+	r.Render(&buf)
+
+	if !strings.Contains(buf.String(), "index-pins") || !strings.Contains(buf.String(), "FAIL") || !strings.Contains(buf.String(), "would be refused") {
+		t.Errorf("output should show failed check. got:\n%s", buf.String())
+	}
+
+	r, _ = w.ValidateRemoval(context.Background(), RemoveOptions{Group: "unknown", NodeName: nodeName})
+	if r.Passed || r.Checks[0].Name != "aws" || r.Checks[0].Status != CheckFail {
+		t.Errorf("validation should fail on group mismatch. got: %+v", r.Checks)
+	}
+}
+
+func TestProjectDiskPercent(t *testing.T) {
+	nodes := []*stats.Node{
+		{Name: "node-1", DiskPercent: 60, Shards: 10},
+		{Name: "node-2", DiskPercent: 60, Shards: 10},
+		{Name: "node-3", DiskPercent: 60, Shards: 10},
+		{Name: "master-1", DiskPercent: 5},
+	}
+
+	got, ok := projectDiskPercent(nodes, "node-3")
+	if !ok || got != 90 {
+		t.Errorf("projected disk usage does not match. expected: 90, got: %f", got)
+	}
+
+	if _, ok := projectDiskPercent(nodes[2:], "node-3"); ok {
+		t.Errorf("projection should fail without remaining data node")
+	}
+}
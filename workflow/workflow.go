@@ -0,0 +1,1421 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtan4/esnctl/aws"
+	"github.com/dtan4/esnctl/aws/ec2"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/dtan4/esnctl/plan"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	rebalanceTimeout = time.Hour
+	removeTimeout    = 5 * time.Minute
+)
+
+const (
+	concurrentRebalanceSetting = "cluster.routing.allocation.cluster_concurrent_rebalance"
+
+	// defaultConcurrentRebalance represents the default of cluster_concurrent_rebalance, restored if it was not set
+	defaultConcurrentRebalance = "2"
+)
+
+// RemoveSteps represents the steps executed after target resources are resolved
+var RemoveSteps = []string{
+	"Detaching instance from target group",
+	"Waiting for connection draining",
+	"Excluding target node from shard allocation group",
+	"Waiting for shards escape from target node",
+	"Shutting down target node",
+	"Waiting for machine learning jobs to be reassigned",
+	"Detaching target instance",
+}
+
+// Workflow executes node operations against one Elasticsearch cluster
+type Workflow struct {
+	ClusterURL string
+	Region     string
+
+	AutoScaling aws.AutoScalingClient
+	EC2         aws.EC2Client
+	ELBv2       aws.ELBv2Client
+	ES          es.Client
+
+	// Capabilities represents APIs available in the cluster, consulted to pick strategies and to fail early
+	Capabilities *es.Capabilities
+
+	// Progress receives progress of waiting for cluster state change
+	Progress io.Writer
+
+	// ProgressBar redraws progress bar with ETA in Progress instead of writing progress lines periodically
+	// Enable it only if Progress is terminal
+	ProgressBar bool
+
+	// MinPoll and MaxPoll bound interval between status checks while waiting. Defaults are used if 0
+	MinPoll time.Duration
+	MaxPoll time.Duration
+
+	// LBDrainTimeout, ShardDrainTimeout, JoinTimeout and MLTimeout bound waiting for connection draining, shards escaping from node,
+	// added nodes joining the cluster and machine learning jobs being reassigned respectively. Defaults are used if 0
+	LBDrainTimeout    time.Duration
+	ShardDrainTimeout time.Duration
+	JoinTimeout       time.Duration
+	MLTimeout         time.Duration
+}
+
+// AddOptions represents options of AddNodes
+type AddOptions struct {
+	Group string
+	Count int
+
+	// Groups distributes Count across the given groups instead of Group, preferring groups with fewer instances
+	Groups []string
+
+	// InstanceID attaches the given running instance instead of launching new instances. Count is ignored
+	InstanceID string
+
+	// WarmupQueries are searched on added nodes after shards are allocated to them, before they are registered with target group
+	WarmupQueries []*es.WarmupQuery
+
+	// HTTPClient is used for warm-up queries and JVM check of added nodes
+	// JVM check is skipped if nil, e.g. for fake cluster
+	HTTPClient *http.Client
+
+	// RegisterAfterJoin keeps added instances out of target group until the cluster is green without shards moving
+	// It is implied by WarmupQueries
+	RegisterAfterJoin bool
+
+	// TagScale records the new desired capacity in LastScaleTagKey tag of Auto Scaling Group
+	TagScale bool
+
+	// SLMWindow warns about snapshots of SLM policies in progress or scheduled within the window. 0 disables the check
+	SLMWindow time.Duration
+
+	// RespectSLMWindow waits for such snapshots to finish instead of warning
+	RespectSLMWindow bool
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// DrainIndexOptions represents options of DrainIndex
+type DrainIndexOptions struct {
+	Index    string
+	NodeName string
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// MigrateTierOptions represents options of MigrateTier
+type MigrateTierOptions struct {
+	NodeName string
+
+	// From and To represent node attributes of tiers in key=value format, e.g. box_type=hot
+	From string
+	To   string
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// MigrateClusterOptions represents options of MigrateCluster
+type MigrateClusterOptions struct {
+	// SourceURL represents cluster to copy indices from. Workflow represents the destination cluster
+	SourceURL string
+
+	// Indices represents index names or patterns in the source cluster
+	Indices []string
+
+	// Method is MigrateReindex or MigrateSnapshot
+	Method string
+
+	// Repository represents snapshot repository registered in both clusters, used by MigrateSnapshot
+	Repository string
+
+	// Cutover blocks writes to the source indices before copying them, then copies their aliases to the destination cluster
+	Cutover bool
+
+	// DestGroup represents Auto Scaling Group of the destination cluster, whose target group is verified on cutover if given
+	DestGroup string
+
+	// SourceHTTPClient and HTTPClient are used to call the source and destination clusters respectively
+	SourceHTTPClient *http.Client
+	HTTPClient       *http.Client
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// ReindexOptions represents options of Reindex
+type ReindexOptions struct {
+	Source string
+	Dest   string
+
+	// SourceURL represents remote cluster to read Source from. Source is read from the cluster of Workflow if empty
+	SourceURL string
+
+	// Slices is auto or the number of slices to parallelize reindex. Not sliced if empty
+	Slices string
+
+	// RequestsPerSecond throttles reindex if positive
+	RequestsPerSecond float64
+
+	// Conflicts is abort or proceed, which counts version conflicts instead of aborting
+	Conflicts string
+
+	// Retries represents how many times failed reindex is retried for missing documents
+	Retries int
+
+	// HTTPClient is used to call reindex and task APIs
+	HTTPClient *http.Client
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// BenchRecoveryOptions represents options of BenchRecovery
+type BenchRecoveryOptions struct {
+	FromNode string
+	ToNode   string
+
+	// Index and Shard pick sample shard. The largest shard on FromNode up to MaxBytes is picked if Index is empty
+	Index    string
+	Shard    int
+	MaxBytes int64
+
+	HTTPClient *http.Client
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// DrillOptions represents options of Drill
+type DrillOptions struct {
+	NodeName string
+
+	// Tolerance represents how many shards fewer than before the node may hold when shards return
+	Tolerance int
+
+	// Window represents maintenance window which drain must finish within. 0 means no limit
+	Window time.Duration
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// DriftOptions represents options of Drift
+type DriftOptions struct {
+	Group string
+
+	// DesiredCapacity represents desired capacity declared outside esnctl, e.g. in Terraform
+	DesiredCapacity int
+}
+
+// UnblockOptions represents options of Unblock
+type UnblockOptions struct {
+	// Indices limits indices to unblock. Every blocked index is unblocked if empty
+	Indices []string
+
+	// Blocks represents blocks to clear, e.g. read_only_allow_delete
+	Blocks []string
+
+	// Force clears blocks even if some node is still above flood-stage disk watermark
+	Force bool
+
+	HTTPClient *http.Client
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// RebalanceOptions represents options of Rebalance
+type RebalanceOptions struct {
+	// Concurrency temporarily overrides cluster_concurrent_rebalance if positive
+	Concurrency int
+
+	// Tolerance represents acceptable difference of the number of shards between nodes
+	Tolerance int
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// RemoveOptions represents options of RemoveNode and PlanRemoval
+type RemoveOptions struct {
+	Group    string
+	NodeName string
+
+	// Force skips shard drain even if the node is still in the cluster, e.g. partitioned one flapping in and out
+	// Shards on the node are recovered from replicas by rerouting
+	Force bool
+
+	// TerminateInstance terminates the instance after detaching it from Auto Scaling Group
+	TerminateInstance bool
+
+	// KeepDesiredCapacity detaches the instance without decrementing desired capacity, so that Auto Scaling Group launches replacement
+	KeepDesiredCapacity bool
+
+	// ReturnToWarmPool scales in the instance into warm pool of Auto Scaling Group instead of detaching it
+	ReturnToWarmPool bool
+
+	// RolloverAlias rolls the given write alias over before drain if its write index has primaries on the node
+	RolloverAlias string
+
+	// FixIndexFilters resets index allocation filters pinning shards to the node before drain
+	// Removal is aborted if such filters are found and FixIndexFilters is false
+	FixIndexFilters bool
+
+	// TagScale records the new desired capacity in LastScaleTagKey tag of Auto Scaling Group
+	TagScale bool
+
+	// SLMWindow warns about snapshots of SLM policies in progress or scheduled within the window. 0 disables the check
+	SLMWindow time.Duration
+
+	// RespectSLMWindow waits for such snapshots to finish instead of warning
+	RespectSLMWindow bool
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// RestartOptions represents options of RestartNode
+type RestartOptions struct {
+	NodeName string
+
+	// DelayedTimeout delays reallocation of shards on the restarting node while it is away. It should be longer than reboot takes
+	DelayedTimeout time.Duration
+
+	// SLMWindow warns about snapshots of SLM policies in progress or scheduled within the window. 0 disables the check
+	SLMWindow time.Duration
+
+	// RespectSLMWindow waits for such snapshots to finish instead of warning
+	RespectSLMWindow bool
+
+	// Operation records phases if given
+	Operation *operation.Operation
+}
+
+// ClientOptions represents options of API clients created by New
+type ClientOptions struct {
+	// AWSRateLimiter limits the rate of AWS API requests if given. Share it among workflows in the same process
+	AWSRateLimiter *aws.RateLimiter
+
+	// HTTPClient is used to call Elasticsearch API. http.Client with default transport is used if nil
+	HTTPClient *http.Client
+
+	// MaxAPIRetries represents the maximum number of retries for throttled or failed AWS API calls
+	MaxAPIRetries int
+
+	// RequestTimeout bounds each AWS API call. 0 means no timeout
+	RequestTimeout time.Duration
+
+	// RoleARN is assumed to call AWS API if given, e.g. for cluster in another account
+	RoleARN string
+
+	// PasswordFrom retrieves Elasticsearch password from "ssm:<parameter name>" or Secrets Manager ARN
+	// Username must be given in cluster URL
+	PasswordFrom string
+
+	// Sniff distributes Elasticsearch API requests among nodes discovered from the cluster
+	Sniff bool
+
+	// Trace logs every AWS API request if not nil. Trace Elasticsearch API requests by HTTPClient
+	Trace *log.Logger
+}
+
+// New creates Workflow object for the given cluster and AWS region
+func New(clusterURL, region string, opts ClientOptions) (*Workflow, error) {
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	clients, err := aws.NewClients(region, aws.Options{
+		MaxRetries:  opts.MaxAPIRetries,
+		RateLimiter: opts.AWSRateLimiter,
+		RoleARN:     opts.RoleARN,
+		Timeout:     opts.RequestTimeout,
+		Trace:       opts.Trace,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize AWS service clients")
+	}
+
+	esURL := clusterURL
+
+	if opts.PasswordFrom != "" {
+		password, err := clients.RetrievePassword(opts.PasswordFrom)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve Elasticsearch password")
+		}
+
+		esURL, err = es.SetPassword(clusterURL, password)
+		if err != nil {
+			return nil, exitcode.Wrap(err, exitcode.Validation)
+		}
+	}
+
+	var client es.Client
+
+	if opts.Sniff {
+		client, err = es.NewWithSniffing(esURL, httpClient)
+	} else {
+		client, err = es.New(esURL, httpClient)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Elasitcsearch API client")
+	}
+
+	// Detected once while creating client above, so this does not request again
+	capabilities, err := es.DetectCapabilities(es.SplitURLs(esURL)[0], httpClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect Elasticsearch version")
+	}
+
+	return &Workflow{
+		ClusterURL:   clusterURL,
+		Region:       region,
+		AutoScaling:  clients.AutoScaling,
+		EC2:          clients.EC2,
+		ELBv2:        clients.ELBv2,
+		ES:           client,
+		Capabilities: capabilities,
+		Progress:     ioutil.Discard,
+	}, nil
+}
+
+// AddNodes launches new instances and waits for them to join the cluster
+// If InstanceID is given, the running instance is attached to Auto Scaling Group and target group instead
+func (w *Workflow) AddNodes(ctx context.Context, opts AddOptions) error {
+	groups := opts.Groups
+	if len(groups) == 0 && opts.Group != "" {
+		groups = []string{opts.Group}
+	}
+
+	if len(groups) == 0 {
+		return exitcode.New(exitcode.Validation, "group must be specified")
+	}
+
+	if opts.InstanceID == "" && opts.Count < 1 {
+		return exitcode.New(exitcode.Validation, "number to add instances must be greater than 0")
+	}
+
+	if opts.InstanceID != "" && len(groups) > 1 {
+		return exitcode.New(exitcode.Validation, "instance can be attached to only one group")
+	}
+
+	op := w.operation(opts.Operation, "add")
+
+	if err := w.checkSLMWindow(ctx, op, opts.SLMWindow, opts.RespectSLMWindow); err != nil {
+		return err
+	}
+
+	var instance *ec2.Instance
+
+	if opts.InstanceID != "" {
+		op.Phase(fmt.Sprintf("Verifying instance %s", opts.InstanceID))
+
+		i, err := w.verifyAttachableInstance(groups[0], opts.InstanceID)
+		if err != nil {
+			return err
+		}
+
+		instance = i
+	}
+
+	op.Phase("Disabling shard reallocation")
+
+	if err := w.ES.DisableReallocation(); err != nil {
+		return errors.Wrap(err, "failed to disable reallocation")
+	}
+
+	before, err := w.ES.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+
+	var joined func(nodes []string) int
+
+	if instance != nil {
+		op.Phase(fmt.Sprintf("Attaching %s to %s", instance.ID, groups[0]))
+
+		if err := w.attachInstance(groups[0], instance.ID); err != nil {
+			return err
+		}
+
+		if opts.TagScale {
+			w.tagLastScale(groups[0], "add")
+		}
+
+		joined = func(nodes []string) int {
+			if contains(nodes, instance.PrivateDNS) {
+				return 0
+			}
+
+			return 1
+		}
+	} else {
+		shares, err := w.distribute(groups, opts.Count)
+		if err != nil {
+			return err
+		}
+
+		desiredCapacity := 0
+
+		for _, g := range groups {
+			if shares[g] == 0 {
+				continue
+			}
+
+			capacity, err := w.launchInstances(op, g, shares[g])
+			if err != nil {
+				return err
+			}
+
+			if opts.TagScale {
+				w.tagLastScale(g, "add")
+			}
+
+			desiredCapacity += capacity
+		}
+
+		// Desired capacity of a group does not cover nodes in other groups of the cluster
+		if len(groups) > 1 {
+			desiredCapacity = len(before) + opts.Count
+		}
+
+		joined = func(nodes []string) int {
+			return desiredCapacity - len(nodes)
+		}
+	}
+
+	op.Phase("Waiting for nodes join to Elasticsearch cluster")
+
+	err = w.waitFor(ctx, op, w.joinTimeout(), "nodes", "timed out: added nodes do not join to Elasticsearch cluster", func() (waitStatus, error) {
+		nodes, err := w.ES.ListNodes()
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to list nodes")
+		}
+
+		if remaining := joined(nodes); remaining > 0 {
+			return waitStatus{Remaining: remaining}, nil
+		}
+
+		return waitStatus{}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+
+	added := []string{}
+
+	for _, n := range nodes {
+		if !contains(before, n) {
+			added = append(added, n)
+		}
+	}
+
+	w.tagAddedNodes(added, op)
+
+	if err := w.clearReturnedExclusion(added, op); err != nil {
+		return err
+	}
+
+	op.Phase("Checking allocation awareness")
+
+	w.warnAwareness("", added)
+
+	if opts.HTTPClient != nil {
+		op.Phase("Checking JVM of added nodes and blocked indices")
+
+		w.warnJVM(added, opts.HTTPClient)
+		w.warnBlocks(opts.HTTPClient)
+	}
+
+	var targets []*pendingTarget
+
+	if opts.RegisterAfterJoin || len(opts.WarmupQueries) > 0 {
+		op.Phase("Detaching added instances from target group until they are ready")
+
+		targets, err = w.detachUntilReady(added)
+		if err != nil {
+			return err
+		}
+	}
+
+	op.Phase("Enabling shard reallocation")
+
+	if err := w.ES.EnableReallocation(); err != nil {
+		return errors.Wrap(err, "failed to enable reallocation")
+	}
+
+	if len(targets) > 0 {
+		return w.registerWhenReady(ctx, op, targets, opts.WarmupQueries, opts.HTTPClient)
+	}
+
+	return nil
+}
+
+// launchInstances increases instances of the given group and returns its new desired capacity
+func (w *Workflow) launchInstances(op *operation.Operation, group string, count int) (int, error) {
+	warmed, err := w.warmedInstances(group)
+	if err != nil {
+		return 0, err
+	}
+
+	if warmed > 0 {
+		if warmed > count {
+			warmed = count
+		}
+
+		op.Phase(fmt.Sprintf("Launching %d instances on %s (%d from warm pool)", count, group, warmed))
+	} else {
+		op.Phase(fmt.Sprintf("Launching %d instances on %s", count, group))
+	}
+
+	desiredCapacity, err := w.AutoScaling.IncreaseInstances(group, count)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to increase instance")
+	}
+
+	return desiredCapacity, nil
+}
+
+// clearReturnedExclusion clears allocation exclusion left by removal of the added nodes
+// Instance returned to warm pool keeps its private DNS name, so shards would never be allocated to it again
+func (w *Workflow) clearReturnedExclusion(added []string, op *operation.Operation) error {
+	settings, err := w.ES.ClusterSettings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster settings")
+	}
+
+	for _, node := range excludedNodes(settings) {
+		if !contains(added, node) {
+			continue
+		}
+
+		op.Phase(fmt.Sprintf("Clearing allocation exclusion of %s", node))
+
+		if _, err := w.includeInAllocation(node); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// warmedInstances returns the number of instances in warm pool which are ready to be moved into service
+// Auto Scaling takes them before launching new instances, so scale out finishes much faster
+func (w *Workflow) warmedInstances(groupName string) (int, error) {
+	pool, err := w.AutoScaling.DescribeWarmPool(groupName)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to describe warm pool")
+	}
+
+	if pool == nil {
+		return 0, nil
+	}
+
+	return len(pool.Warmed()), nil
+}
+
+// verifyAttachableInstance returns the given instance if it is running and not attached to any Auto Scaling Group
+func (w *Workflow) verifyAttachableInstance(groupName, instanceID string) (*ec2.Instance, error) {
+	instance, err := w.EC2.DescribeInstance(instanceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe instance")
+	}
+
+	if instance.State != "running" {
+		return nil, exitcode.Errorf(exitcode.Validation, "instance %s is not running. state: %s", instanceID, instance.State)
+	}
+
+	group, err := w.AutoScaling.RetrieveGroupOfInstance(instanceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve AutoScaling Group of instance")
+	}
+
+	if group == groupName {
+		return nil, exitcode.Errorf(exitcode.Validation, "instance %s is already attached to %s", instanceID, groupName)
+	}
+
+	if group != "" {
+		return nil, exitcode.Errorf(exitcode.Validation, "instance %s belongs to another AutoScaling Group %s", instanceID, group)
+	}
+
+	return instance, nil
+}
+
+// attachInstance attaches the given instance to Auto Scaling Group and its target group
+// Auto Scaling registers attached instances with target groups, but registering explicitly covers groups attached to target groups later
+func (w *Workflow) attachInstance(groupName, instanceID string) error {
+	if err := w.AutoScaling.AttachInstance(groupName, instanceID); err != nil {
+		return errors.Wrapf(err, "failed to attach %s to AutoScaling Group", instanceID)
+	}
+
+	targetGroupARN, err := w.AutoScaling.RetrieveTargetGroup(groupName)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve target group")
+	}
+
+	if err := w.ELBv2.RegisterInstance(targetGroupARN, instanceID); err != nil {
+		return errors.Wrapf(err, "failed to register %s with target group", instanceID)
+	}
+
+	return nil
+}
+
+// ApplyPlan removes node following the given plan
+// Plan is rejected if the node is now running on another instance
+func (w *Workflow) ApplyPlan(ctx context.Context, p *plan.Plan, op *operation.Operation) error {
+	op = w.operation(op, "apply")
+
+	op.Phase("Verifying target instance ID")
+
+	instanceID, err := w.EC2.RetrieveInstanceIDFromPrivateDNS(p.NodeName)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve instance ID")
+	}
+
+	if instanceID != p.InstanceID {
+		return exitcode.Errorf(exitcode.Aborted, "instance ID of %s has changed since plan was made. planned: %s, current: %s", p.NodeName, p.InstanceID, instanceID)
+	}
+
+	return w.executeRemoval(ctx, p, RemoveOptions{Operation: op})
+}
+
+// DrainIndex moves shards of the given index off the given node using index-level allocation filtering
+// The exclusion is cleared after the node has no shards of the index, and kept if waiting fails
+func (w *Workflow) DrainIndex(ctx context.Context, opts DrainIndexOptions) error {
+	if opts.Index == "" {
+		return exitcode.New(exitcode.Validation, "index must be specified")
+	}
+
+	if opts.NodeName == "" {
+		return exitcode.New(exitcode.Validation, "node name must be specified")
+	}
+
+	op := w.operation(opts.Operation, "drain-index")
+
+	op.Phase(fmt.Sprintf("Excluding target node from shard allocation of %s", opts.Index))
+
+	if err := w.ES.ExcludeNodeFromIndexAllocation(opts.Index, opts.NodeName); err != nil {
+		return errors.Wrap(err, "failed to exclude node from index allocation")
+	}
+
+	op.Phase(fmt.Sprintf("Waiting for shards of %s escape from target node", opts.Index))
+
+	err := w.waitFor(ctx, op, w.shardDrainTimeout(), "shards", "timed out: shards of the index do not escape from target node", func() (waitStatus, error) {
+		shards, err := w.ES.ListShardsOnNode(opts.NodeName)
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to list shards on the given node")
+		}
+
+		return waitStatus{Remaining: countIndexShards(shards, opts.Index)}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	op.Phase(fmt.Sprintf("Clearing shard allocation exclusion of %s", opts.Index))
+
+	if err := w.ES.ExcludeNodeFromIndexAllocation(opts.Index, ""); err != nil {
+		return errors.Wrap(err, "failed to clear index allocation exclusion")
+	}
+
+	return nil
+}
+
+// MigrateTier moves indices having shards on the given node to another tier of hot/warm architecture
+// Shards are allocated by index-level attribute requirement instead of relocating them to other nodes in the same tier
+func (w *Workflow) MigrateTier(ctx context.Context, opts MigrateTierOptions) error {
+	if opts.NodeName == "" {
+		return exitcode.New(exitcode.Validation, "node name must be specified")
+	}
+
+	fromKey, fromValue, err := parseAttribute(opts.From)
+	if err != nil {
+		return err
+	}
+
+	toKey, toValue, err := parseAttribute(opts.To)
+	if err != nil {
+		return err
+	}
+
+	op := w.operation(opts.Operation, "tier-migrate")
+
+	op.Phase("Verifying tier of target node")
+
+	attrs, err := w.ES.NodeAttributes(opts.NodeName)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve node attributes")
+	}
+
+	if attrs[fromKey] != fromValue {
+		return exitcode.Errorf(exitcode.Validation, "%s is not in tier %s", opts.NodeName, opts.From)
+	}
+
+	if attrs[toKey] == toValue {
+		return exitcode.Errorf(exitcode.Validation, "%s is already in tier %s", opts.NodeName, opts.To)
+	}
+
+	op.Phase("Retrieving indices on target node")
+
+	shards, err := w.ES.ListShardsOnNode(opts.NodeName)
+	if err != nil {
+		return errors.Wrap(err, "failed to list shards on the given node")
+	}
+
+	indices := listIndices(shards)
+
+	op.Phase(fmt.Sprintf("Moving %d indices to tier %s", len(indices), opts.To))
+
+	for _, index := range indices {
+		if err := w.ES.RequireIndexAllocationAttribute(index, toKey, toValue); err != nil {
+			return errors.Wrapf(err, "failed to move %s to tier %s", index, opts.To)
+		}
+	}
+
+	op.Phase("Waiting for shards of the indices escape from target node")
+
+	return w.waitFor(ctx, op, w.shardDrainTimeout(), "shards", "timed out: shards of the indices do not escape from target node", func() (waitStatus, error) {
+		shards, err := w.ES.ListShardsOnNode(opts.NodeName)
+		if err != nil {
+			return waitStatus{}, errors.Wrap(err, "failed to list shards on the given node")
+		}
+
+		remaining := 0
+
+		for _, index := range indices {
+			remaining += countIndexShards(shards, index)
+		}
+
+		return waitStatus{Remaining: remaining}, nil
+	})
+}
+
+// PlanRemoval retrieves AWS resources related to the given node and returns removal plan
+func (w *Workflow) PlanRemoval(ctx context.Context, opts RemoveOptions) (*plan.Plan, error) {
+	if opts.Group == "" {
+		return nil, exitcode.New(exitcode.Validation, "group must be specified")
+	}
+
+	if opts.NodeName == "" {
+		return nil, exitcode.New(exitcode.Validation, "node name must be specified")
+	}
+
+	op := w.operation(opts.Operation, "plan")
+
+	op.Phase("Retrieving target instance, target group and shards")
+
+	// Lookups are independent of each other, so they run concurrently to cut setup time per node
+	var (
+		g              errgroup.Group
+		instanceID     string
+		targetGroupARN string
+		shards         []string
+	)
+
+	g.Go(func() error {
+		id, err := w.EC2.RetrieveInstanceIDFromPrivateDNS(opts.NodeName)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve instance ID")
+		}
+
+		// Typo in group would be found only at the last step, after the node is drained and shut down
+		group, err := w.AutoScaling.RetrieveGroupOfInstance(id)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve Auto Scaling Group of instance")
+		}
+
+		// Instance outside of any group may have been detached by previous run. Detaching it is skipped later
+		if group != "" && group != opts.Group {
+			return exitcode.Errorf(exitcode.Validation, "instance %s of %s belongs to Auto Scaling Group %q, not %q", id, opts.NodeName, group, opts.Group)
+		}
+
+		instanceID = id
+
+		return nil
+	})
+
+	g.Go(func() error {
+		arn, err := w.AutoScaling.RetrieveTargetGroup(opts.Group)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve target group")
+		}
+
+		targetGroupARN = arn
+
+		return nil
+	})
+
+	g.Go(func() error {
+		s, err := w.ES.ListShardsOnNode(opts.NodeName)
+		if err != nil {
+			return errors.Wrap(err, "failed to list shards on the given node")
+		}
+
+		shards = s
+
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &plan.Plan{
+		Version:        plan.FormatVersion,
+		Action:         plan.ActionRemove,
+		ClusterURL:     op.Cluster,
+		Region:         w.Region,
+		Group:          opts.Group,
+		NodeName:       opts.NodeName,
+		InstanceID:     instanceID,
+		TargetGroupARN: targetGroupARN,
+		Shards:         len(shards),
+		Steps:          RemoveSteps,
+		CreatedBy:      op.User,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// Rebalance enables shard rebalancing and waits until the number of shards on each node is within tolerance
+// Relocation progress is printed to Progress. cluster_concurrent_rebalance is restored even if waiting fails
+func (w *Workflow) Rebalance(ctx context.Context, opts RebalanceOptions) (err error) {
+	if opts.Concurrency < 0 {
+		return exitcode.New(exitcode.Validation, "concurrency must not be negative")
+	}
+
+	if opts.Tolerance < 0 {
+		return exitcode.New(exitcode.Validation, "tolerance must not be negative")
+	}
+
+	op := w.operation(opts.Operation, "rebalance")
+
+	settings := map[string]string{"cluster.routing.rebalance.enable": "all"}
+
+	if opts.Concurrency > 0 {
+		current, err := w.ES.ClusterSettings()
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve cluster settings")
+		}
+
+		prev, ok := current[concurrentRebalanceSetting]
+		if !ok {
+			prev = defaultConcurrentRebalance
+		}
+
+		settings[concurrentRebalanceSetting] = strconv.Itoa(opts.Concurrency)
+
+		defer func() {
+			op.Phase(fmt.Sprintf("Restoring cluster_concurrent_rebalance to %s", prev))
+
+			if rerr := w.ES.UpdateClusterSettings(map[string]string{concurrentRebalanceSetting: prev}); rerr != nil && err == nil {
+				err = errors.Wrap(rerr, "failed to restore cluster_concurrent_rebalance")
+			}
+		}()
+	}
+
+	op.Phase("Enabling shard rebalancing")
+
+	if err := w.ES.UpdateClusterSettings(settings); err != nil {
+		return errors.Wrap(err, "failed to enable rebalancing")
+	}
+
+	op.Phase("Waiting for shards to be balanced")
+
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	poller := w.newPoller()
+	deadline := time.Now().Add(rebalanceTimeout)
+	maxRelocating := 0
+
+	for {
+		nodes, err := w.ES.NodeStats()
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve node stats")
+		}
+
+		health, err := w.ES.ClusterHealth()
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve cluster health")
+		}
+
+		relocating := health.RelocatingShards
+
+		least, most := shardRange(nodes)
+
+		fmt.Fprintf(progress, "relocating: %d, shards per node: %d-%d\n", relocating, least, most)
+
+		if relocating > maxRelocating {
+			maxRelocating = relocating
+		}
+
+		op.Progress(operation.Progress{
+			Unit:      "relocating shards",
+			Total:     maxRelocating,
+			Remaining: relocating,
+		})
+
+		if relocating == 0 && most-least <= opts.Tolerance {
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return exitcode.New(exitcode.Timeout, "timed out: shards are not balanced")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poller.next(relocating + most - least)):
+		}
+	}
+}
+
+// RemoveNode drains the given node and detaches its instance from the cluster
+// Shard drain is skipped if the node has already left the cluster, e.g. after hardware failure
+func (w *Workflow) RemoveNode(ctx context.Context, opts RemoveOptions) error {
+	opts.Operation = w.operation(opts.Operation, "remove")
+
+	p, err := w.PlanRemoval(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	return w.executeRemoval(ctx, p, opts)
+}
+
+// DrainNode runs removal steps of the given node until shutdown, so that the node keeps running without shards and connections
+// Pre-flight checks and timeouts are the same as RemoveNode. The node must be in the cluster
+func (w *Workflow) DrainNode(ctx context.Context, opts RemoveOptions) error {
+	opts.Operation = w.operation(opts.Operation, "drain")
+	op := opts.Operation
+
+	p, err := w.PlanRemoval(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	op.Phase("Checking whether target node is in the cluster")
+
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+
+	if !contains(nodes, p.NodeName) {
+		return exitcode.Errorf(exitcode.Validation, "node %s is not in the cluster", p.NodeName)
+	}
+
+	drain, err := w.checkNodeRoles(p.NodeName, opts)
+	if err != nil {
+		return err
+	}
+
+	if drain {
+		if err := w.checkBeforeDrain(p.NodeName, opts); err != nil {
+			return err
+		}
+	}
+
+	return w.runRemoveStepsUntil(ctx, &RemoveState{
+		Group:          p.Group,
+		NodeName:       p.NodeName,
+		Step:           StepDetachLB,
+		InstanceID:     p.InstanceID,
+		TargetGroupARN: p.TargetGroupARN,
+		SkipDrain:      !drain,
+	}, op, StepShutdown)
+}
+
+func (w *Workflow) executeRemoval(ctx context.Context, p *plan.Plan, opts RemoveOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	op := opts.Operation
+
+	if opts.RolloverAlias != "" {
+		if err := w.require((*es.Capabilities).SupportsRollover, "rollover API", "Roll over the alias manually before removal instead of --rollover-first."); err != nil {
+			return err
+		}
+	}
+
+	if opts.ReturnToWarmPool {
+		if err := w.checkWarmPool(p.Group, opts); err != nil {
+			return err
+		}
+	}
+
+	if err := w.checkSLMWindow(ctx, op, opts.SLMWindow, opts.RespectSLMWindow); err != nil {
+		return err
+	}
+
+	op.Phase("Checking whether target node is in the cluster")
+
+	nodes, err := w.ES.ListNodes()
+	if err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+
+	if opts.Force || !contains(nodes, p.NodeName) {
+		return w.executeRemovalWithoutDrain(ctx, p, opts)
+	}
+
+	drain, err := w.checkNodeRoles(p.NodeName, opts)
+	if err != nil {
+		return err
+	}
+
+	if drain {
+		if err := w.checkBeforeDrain(p.NodeName, opts); err != nil {
+			return err
+		}
+	}
+
+	err = w.runRemoveSteps(ctx, &RemoveState{
+		Group:               p.Group,
+		NodeName:            p.NodeName,
+		Step:                StepDetachLB,
+		InstanceID:          p.InstanceID,
+		TargetGroupARN:      p.TargetGroupARN,
+		SkipDrain:           !drain,
+		WarmPool:            opts.ReturnToWarmPool,
+		KeepDesiredCapacity: opts.KeepDesiredCapacity,
+		TagScale:            opts.TagScale,
+	}, op)
+	if err != nil {
+		return err
+	}
+
+	if opts.TerminateInstance {
+		return w.terminateInstance(ctx, p, op)
+	}
+
+	return nil
+}
+
+// checkWarmPool verifies that the instance returns to warm pool of the group on scale in, instead of being terminated
+func (w *Workflow) checkWarmPool(groupName string, opts RemoveOptions) error {
+	if opts.TerminateInstance {
+		return exitcode.New(exitcode.Validation, "instance cannot be terminated and returned to warm pool at the same time")
+	}
+
+	// Replacement would be taken from warm pool, possibly the instance itself
+	if opts.KeepDesiredCapacity {
+		return exitcode.New(exitcode.Validation, "desired capacity cannot be kept when instance is returned to warm pool")
+	}
+
+	opts.Operation.Phase("Checking warm pool")
+
+	pool, err := w.AutoScaling.DescribeWarmPool(groupName)
+	if err != nil {
+		return errors.Wrap(err, "failed to describe warm pool")
+	}
+
+	if pool == nil {
+		return exitcode.Errorf(exitcode.Validation, "AutoScaling Group %s has no warm pool", groupName)
+	}
+
+	if !pool.ReuseOnScaleIn {
+		return exitcode.Errorf(exitcode.Validation, "instance would be terminated, because warm pool of %s does not reuse instances on scale in. Set ReuseOnScaleIn of its instance reuse policy", groupName)
+	}
+
+	return nil
+}
+
+// checkBeforeDrain verifies shards on the node can escape, and warns about what drain affects
+func (w *Workflow) checkBeforeDrain(nodeName string, opts RemoveOptions) error {
+	op := opts.Operation
+
+	if err := w.checkIndexPins(nodeName, opts); err != nil {
+		return err
+	}
+
+	if err := w.checkShardCapacity(nodeName, opts); err != nil {
+		return err
+	}
+
+	op.Phase("Checking allocation awareness")
+
+	w.warnAwareness(nodeName, []string{})
+
+	return w.checkWriteIndices(nodeName, opts)
+}
+
+// checkIndexPins aborts removal if index allocation filters pin shards to the node, unless FixIndexFilters resets them
+// Otherwise waiting for shards to escape would time out without any hint
+func (w *Workflow) checkIndexPins(nodeName string, opts RemoveOptions) error {
+	op := opts.Operation
+
+	op.Phase("Checking index allocation filters pinning shards to target node")
+
+	pins, err := w.indexPins(nodeName)
+	if err != nil {
+		return err
+	}
+
+	if len(pins) == 0 {
+		return nil
+	}
+
+	list := make([]string, 0, len(pins))
+
+	for _, pin := range pins {
+		list = append(list, pin.String())
+	}
+
+	if !opts.FixIndexFilters {
+		return exitcode.Errorf(exitcode.Validation, "shards would never escape from %s, because index allocation filters allow them only on it: %s", nodeName, strings.Join(list, ", "))
+	}
+
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Resetting index allocation filters pinning shards to target node")
+
+	for _, pin := range pins {
+		fmt.Fprintf(progress, "resetting %s\n", pin)
+
+		if err := w.ES.ResetIndexSettings(pin.Index, []string{pin.Setting}); err != nil {
+			return errors.Wrapf(err, "failed to reset %s of index %s", pin.Setting, pin.Index)
+		}
+	}
+
+	return nil
+}
+
+// executeRemovalWithoutDrain detaches instance without touching the node, then lets the cluster recover lost shards
+func (w *Workflow) executeRemovalWithoutDrain(ctx context.Context, p *plan.Plan, opts RemoveOptions) error {
+	op := opts.Operation
+
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	op.Phase("Checking cluster health")
+
+	health, err := w.ES.ClusterHealth()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve cluster health")
+	}
+
+	fmt.Fprintf(progress, "WARNING: skipping shard drain of %s. cluster status: %s, unassigned shards: %d\n", p.NodeName, health.Status, health.UnassignedShards)
+
+	s := &RemoveState{
+		Group:               p.Group,
+		NodeName:            p.NodeName,
+		InstanceID:          p.InstanceID,
+		TargetGroupARN:      p.TargetGroupARN,
+		WarmPool:            opts.ReturnToWarmPool,
+		KeepDesiredCapacity: opts.KeepDesiredCapacity,
+		TagScale:            opts.TagScale,
+	}
+
+	w.tagInstance(p.InstanceID, op, RemovedByTagKey, RemovedAtTagKey)
+
+	// Connection draining is not waited for, because dead node cannot serve requests anyway
+	for _, step := range []string{StepDetachLB, StepDetachASG} {
+		op.Phase(RemoveStepDescription(step))
+
+		s.Step = step
+
+		next, err := w.RemoveStep(ctx, s)
+		if err != nil {
+			return err
+		}
+
+		if next.Skipped {
+			fmt.Fprintln(progress, SkippedMessage)
+		}
+	}
+
+	// Terminate before reroute, so that shards of forcibly removed live node are recovered as well
+	if opts.TerminateInstance {
+		if err := w.terminateInstance(ctx, p, op); err != nil {
+			return err
+		}
+	}
+
+	op.Phase("Retrying allocation of unassigned shards")
+
+	if err := w.ES.Reroute(); err != nil {
+		return errors.Wrap(err, "failed to reroute shards")
+	}
+
+	return nil
+}
+
+// terminateInstance terminates instance of the node, and clears its voting configuration exclusion once it has left the cluster
+func (w *Workflow) terminateInstance(ctx context.Context, p *plan.Plan, op *operation.Operation) error {
+	master, err := w.excludedFromVoting(p.NodeName)
+	if err != nil {
+		return err
+	}
+
+	op.Phase("Terminating target instance")
+
+	if err := w.EC2.TerminateInstance(p.InstanceID); err != nil {
+		return errors.Wrap(err, "failed to terminate instance")
+	}
+
+	if master {
+		return w.clearVotingExclusions(ctx, p.NodeName, op)
+	}
+
+	return nil
+}
+
+// countIndexShards returns the number of shards of the given index in _cat/shards lines
+func countIndexShards(shards []string, index string) int {
+	count := 0
+
+	for _, line := range shards {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == index {
+			count++
+		}
+	}
+
+	return count
+}
+
+// listIndices returns sorted names of indices in _cat/shards lines
+func listIndices(shards []string) []string {
+	seen := map[string]bool{}
+	indices := []string{}
+
+	for _, line := range shards {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || seen[fields[0]] {
+			continue
+		}
+
+		seen[fields[0]] = true
+		indices = append(indices, fields[0])
+	}
+
+	sort.Strings(indices)
+
+	return indices
+}
+
+// parseAttribute splits node attribute in key=value format
+func parseAttribute(attr string) (string, string, error) {
+	kv := strings.SplitN(attr, "=", 2)
+	if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+		return "", "", exitcode.Errorf(exitcode.Validation, "node attribute must be in key=value format. got: %q", attr)
+	}
+
+	return kv[0], kv[1], nil
+}
+
+// shardRange returns the least and the most number of shards on node
+func shardRange(nodes []*stats.Node) (int, int) {
+	if len(nodes) == 0 {
+		return 0, 0
+	}
+
+	least, most := nodes[0].Shards, nodes[0].Shards
+
+	for _, n := range nodes[1:] {
+		if n.Shards < least {
+			least = n.Shards
+		}
+
+		if n.Shards > most {
+			most = n.Shards
+		}
+	}
+
+	return least, most
+}
+
+// waitFor calls check until nothing remains, for timeout at most
+// Interval between calls adapts to how fast the number of remaining unit changes
+// Progress of every check is also notified to op
+func (w *Workflow) waitFor(ctx context.Context, op *operation.Operation, timeout time.Duration, unit, timeoutMessage string, check func() (waitStatus, error)) error {
+	progress := w.Progress
+	if progress == nil {
+		progress = ioutil.Discard
+	}
+
+	poller := w.newPoller()
+	deadline := time.Now().Add(timeout)
+
+	pw := newProgressWriter(progress, w.ProgressBar, unit)
+	defer pw.finish()
+
+	for {
+		s, err := check()
+		if err != nil {
+			return err
+		}
+
+		if s.Remaining == 0 {
+			if pw.total > 0 {
+				op.Progress(operation.Progress{Unit: unit, Total: pw.total})
+			}
+
+			return nil
+		}
+
+		pw.update(s)
+
+		op.Progress(operation.Progress{
+			Unit:      unit,
+			Total:     pw.total,
+			Remaining: s.Remaining,
+			Bytes:     s.Bytes,
+		})
+
+		if !time.Now().Before(deadline) {
+			return exitcode.New(exitcode.Timeout, timeoutMessage)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poller.next(s.Remaining)):
+		}
+	}
+}
+
+func (w *Workflow) operation(op *operation.Operation, command string) *operation.Operation {
+	if op != nil {
+		return op
+	}
+
+	return operation.New(command, w.ClusterURL)
+}
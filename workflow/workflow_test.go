@@ -0,0 +1,1331 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	autoscalingapi "github.com/aws/aws-sdk-go/service/autoscaling"
+	ec2api "github.com/aws/aws-sdk-go/service/ec2"
+	elbv2api "github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/dtan4/esnctl/aws/autoscaling"
+	"github.com/dtan4/esnctl/aws/ec2"
+	"github.com/dtan4/esnctl/aws/elbv2"
+	"github.com/dtan4/esnctl/aws/mock"
+	"github.com/dtan4/esnctl/es"
+	"github.com/dtan4/esnctl/es/stats"
+	"github.com/dtan4/esnctl/exitcode"
+	"github.com/dtan4/esnctl/fake"
+	"github.com/dtan4/esnctl/operation"
+	"github.com/golang/mock/gomock"
+)
+
+const (
+	testGroup          = "elasticsearch"
+	testInstanceID     = "i-1234abcd"
+	testNodeName       = "ip-10-0-1-21.ap-northeast-1.compute.internal"
+	testTargetGroupARN = "arn:aws:elasticloadbalancing:ap-northeast-1:012345678901:targetgroup/elasticsearch/0123abcd5678efab"
+)
+
+type fakeClient struct {
+	es.Client
+
+	calls        []string
+	copies       []*stats.Shard
+	excluded     string
+	nodes        []string
+	shards       []string
+	writeIndices map[string]string
+}
+
+func (c *fakeClient) ClusterSettings() (map[string]string, error) {
+	return map[string]string{ExcludeNameSetting: c.excluded}, nil
+}
+
+func (c *fakeClient) DisableReallocation() error {
+	c.calls = append(c.calls, "DisableReallocation")
+	return nil
+}
+
+func (c *fakeClient) EnableReallocation() error {
+	c.calls = append(c.calls, "EnableReallocation")
+	return nil
+}
+
+func (c *fakeClient) ExcludeNodeFromAllocation(nodeName string) error {
+	c.calls = append(c.calls, "ExcludeNodeFromAllocation")
+	c.excluded = nodeName
+	c.shards = []string{}
+	return nil
+}
+
+func (c *fakeClient) IndexAllocationFilters() (map[string]map[string]string, error) {
+	return map[string]map[string]string{}, nil
+}
+
+func (c *fakeClient) ListNodes() ([]string, error) {
+	return c.nodes, nil
+}
+
+func (c *fakeClient) ListShards() ([]*stats.Shard, error) {
+	return c.copies, nil
+}
+
+func (c *fakeClient) ListShardsOnNode(nodeName string) ([]string, error) {
+	return c.shards, nil
+}
+
+func (c *fakeClient) MLJobs() ([]*stats.MLJob, error) {
+	return []*stats.MLJob{}, nil
+}
+
+func (c *fakeClient) NodeRoles() (map[string][]string, error) {
+	return map[string][]string{}, nil
+}
+
+func (c *fakeClient) NodeStats() ([]*stats.Node, error) {
+	return []*stats.Node{}, nil
+}
+
+func (c *fakeClient) Rollover(alias string) (string, error) {
+	c.calls = append(c.calls, "Rollover")
+	c.writeIndices[alias] = c.writeIndices[alias] + "-next"
+	return c.writeIndices[alias], nil
+}
+
+func (c *fakeClient) Shutdown(nodeName string) error {
+	c.calls = append(c.calls, "Shutdown")
+	return nil
+}
+
+func (c *fakeClient) WriteIndices() (map[string]string, error) {
+	return c.writeIndices, nil
+}
+
+func init() {
+	defaultMinPoll = 1 * time.Millisecond
+	defaultMaxPoll = 1 * time.Millisecond
+}
+
+func TestAddNodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	asAPI := mock.NewMockAutoScalingAPI(ctrl)
+	asAPI.EXPECT().DescribeAutoScalingGroups(gomock.Any()).Return(&autoscalingapi.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []*autoscalingapi.Group{
+			&autoscalingapi.Group{
+				AutoScalingGroupName: aws.String(testGroup),
+				DesiredCapacity:      aws.Int64(3),
+			},
+		},
+	}, nil)
+	asAPI.EXPECT().SetDesiredCapacity(&autoscalingapi.SetDesiredCapacityInput{
+		AutoScalingGroupName: aws.String(testGroup),
+		DesiredCapacity:      aws.Int64(5),
+	}).Return(&autoscalingapi.SetDesiredCapacityOutput{}, nil)
+
+	client := &fakeClient{
+		nodes: []string{"node-1", "node-2", "node-3", "node-4", "node-5"},
+	}
+
+	w := &Workflow{
+		ClusterURL:  "http://elasticsearch.example.com",
+		AutoScaling: autoscaling.New(asAPI),
+		ES:          client,
+	}
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: testGroup, Count: 2}); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := []string{"DisableReallocation", "EnableReallocation"}
+
+	if len(client.calls) != len(expected) || client.calls[0] != expected[0] || client.calls[1] != expected[1] {
+		t.Errorf("Elasticsearch API calls do not match. expected: %v, got: %v", expected, client.calls)
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2API := mock.NewMockEC2API(ctrl)
+	ec2API.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2api.DescribeInstancesOutput{
+		Reservations: []*ec2api.Reservation{
+			&ec2api.Reservation{
+				Instances: []*ec2api.Instance{
+					&ec2api.Instance{
+						InstanceId: aws.String(testInstanceID),
+					},
+				},
+			},
+		},
+	}, nil)
+	ec2API.EXPECT().CreateTags(gomock.Any()).Return(&ec2api.CreateTagsOutput{}, nil)
+
+	asAPI := mock.NewMockAutoScalingAPI(ctrl)
+	asAPI.EXPECT().DescribeAutoScalingInstances(gomock.Any()).Return(&autoscalingapi.DescribeAutoScalingInstancesOutput{
+		AutoScalingInstances: []*autoscalingapi.InstanceDetails{
+			&autoscalingapi.InstanceDetails{
+				AutoScalingGroupName: aws.String(testGroup),
+				InstanceId:           aws.String(testInstanceID),
+			},
+		},
+	}, nil)
+	asAPI.EXPECT().DescribeLoadBalancerTargetGroups(gomock.Any()).Return(&autoscalingapi.DescribeLoadBalancerTargetGroupsOutput{
+		LoadBalancerTargetGroups: []*autoscalingapi.LoadBalancerTargetGroupState{
+			&autoscalingapi.LoadBalancerTargetGroupState{
+				LoadBalancerTargetGroupARN: aws.String(testTargetGroupARN),
+			},
+		},
+	}, nil)
+	asAPI.EXPECT().DescribeAutoScalingGroups(gomock.Any()).Return(&autoscalingapi.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []*autoscalingapi.Group{
+			&autoscalingapi.Group{
+				AutoScalingGroupName: aws.String(testGroup),
+				Instances: []*autoscalingapi.Instance{
+					&autoscalingapi.Instance{
+						InstanceId: aws.String(testInstanceID),
+					},
+				},
+			},
+		},
+	}, nil)
+	asAPI.EXPECT().DetachInstances(gomock.Any()).Return(&autoscalingapi.DetachInstancesOutput{}, nil)
+
+	elbv2API := mock.NewMockELBV2API(ctrl)
+	elbv2API.EXPECT().DescribeTargetHealth(gomock.Any()).Return(&elbv2api.DescribeTargetHealthOutput{
+		TargetHealthDescriptions: []*elbv2api.TargetHealthDescription{
+			&elbv2api.TargetHealthDescription{
+				Target: &elbv2api.TargetDescription{
+					Id: aws.String(testInstanceID),
+				},
+			},
+		},
+	}, nil)
+	elbv2API.EXPECT().DeregisterTargets(gomock.Any()).Return(&elbv2api.DeregisterTargetsOutput{}, nil)
+	elbv2API.EXPECT().DescribeTargetHealth(gomock.Any()).Return(&elbv2api.DescribeTargetHealthOutput{
+		TargetHealthDescriptions: []*elbv2api.TargetHealthDescription{},
+	}, nil)
+
+	client := &fakeClient{
+		nodes:  []string{testNodeName},
+		shards: []string{"logs-2017.03.20 0 p"},
+	}
+
+	w := &Workflow{
+		ClusterURL:  "http://elasticsearch.example.com",
+		AutoScaling: autoscaling.New(asAPI),
+		EC2:         ec2.New(ec2API),
+		ELBv2:       elbv2.New(elbv2API),
+		ES:          client,
+	}
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: testGroup, NodeName: testNodeName}); err != nil {
+		t.Errorf("error should not be raised: %s", err)
+	}
+
+	expected := []string{"ExcludeNodeFromAllocation", "Shutdown"}
+
+	if len(client.calls) != len(expected) || client.calls[0] != expected[0] || client.calls[1] != expected[1] {
+		t.Errorf("Elasticsearch API calls do not match. expected: %v, got: %v", expected, client.calls)
+	}
+}
+
+func TestRemoveNode_canceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2API := mock.NewMockEC2API(ctrl)
+	ec2API.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2api.DescribeInstancesOutput{
+		Reservations: []*ec2api.Reservation{
+			&ec2api.Reservation{
+				Instances: []*ec2api.Instance{
+					&ec2api.Instance{
+						InstanceId: aws.String(testInstanceID),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	asAPI := mock.NewMockAutoScalingAPI(ctrl)
+	asAPI.EXPECT().DescribeAutoScalingInstances(gomock.Any()).Return(&autoscalingapi.DescribeAutoScalingInstancesOutput{
+		AutoScalingInstances: []*autoscalingapi.InstanceDetails{
+			&autoscalingapi.InstanceDetails{
+				AutoScalingGroupName: aws.String(testGroup),
+				InstanceId:           aws.String(testInstanceID),
+			},
+		},
+	}, nil)
+	asAPI.EXPECT().DescribeLoadBalancerTargetGroups(gomock.Any()).Return(&autoscalingapi.DescribeLoadBalancerTargetGroupsOutput{
+		LoadBalancerTargetGroups: []*autoscalingapi.LoadBalancerTargetGroupState{
+			&autoscalingapi.LoadBalancerTargetGroupState{
+				LoadBalancerTargetGroupARN: aws.String(testTargetGroupARN),
+			},
+		},
+	}, nil)
+
+	elbv2API := mock.NewMockELBV2API(ctrl)
+
+	w := &Workflow{
+		ClusterURL:  "http://elasticsearch.example.com",
+		AutoScaling: autoscaling.New(asAPI),
+		EC2:         ec2.New(ec2API),
+		ELBv2:       elbv2.New(elbv2API),
+		ES:          &fakeClient{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := w.RemoveNode(ctx, RemoveOptions{Group: testGroup, NodeName: testNodeName}); err != context.Canceled {
+		t.Errorf("context.Canceled should be raised. got: %v", err)
+	}
+}
+
+func newFakeWorkflow(c *fake.Cluster) *Workflow {
+	capabilities, _ := es.NewCapabilities(es.DistributionElasticsearch, fake.Version)
+
+	return &Workflow{
+		ClusterURL:   "http://elasticsearch.example.com",
+		AutoScaling:  c.AutoScaling(),
+		EC2:          c.EC2(),
+		ELBv2:        c.ELBv2(),
+		ES:           c,
+		Capabilities: capabilities,
+	}
+}
+
+type recordingObserver struct {
+	phases   []string
+	progress []operation.Progress
+}
+
+func (r *recordingObserver) Finish(op *operation.Operation) {}
+
+func (r *recordingObserver) Phase(op *operation.Operation, name string) {
+	r.phases = append(r.phases, name)
+}
+
+func (r *recordingObserver) Progress(op *operation.Operation, p operation.Progress) {
+	r.progress = append(r.progress, p)
+}
+
+func TestWaitFor_observer(t *testing.T) {
+	w := &Workflow{}
+
+	r := &recordingObserver{}
+	op := operation.New("drain-index", "http://elasticsearch.example.com")
+	op.Observer = r
+
+	remaining := 3
+
+	err := w.waitFor(context.Background(), op, time.Minute, "shards", "timed out", func() (waitStatus, error) {
+		n := remaining
+		remaining--
+
+		return waitStatus{Remaining: n}, nil
+	})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	expected := []operation.Progress{
+		{Unit: "shards", Total: 3, Remaining: 3},
+		{Unit: "shards", Total: 3, Remaining: 2},
+		{Unit: "shards", Total: 3, Remaining: 1},
+		{Unit: "shards", Total: 3, Remaining: 0},
+	}
+
+	if len(r.progress) != len(expected) {
+		t.Fatalf("progress of every check should be observed. got: %+v", r.progress)
+	}
+
+	for i := range expected {
+		if r.progress[i] != expected[i] {
+			t.Errorf("progress does not match. expected: %+v, got: %+v", expected[i], r.progress[i])
+		}
+	}
+}
+
+func TestAddNodes_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 2}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.ListNodes()
+	if len(nodes) != 5 {
+		t.Errorf("number of nodes does not match. expected: 5, got: %d", len(nodes))
+	}
+
+	if !c.ReallocationEnabled() {
+		t.Errorf("shard reallocation should be enabled after adding nodes")
+	}
+}
+
+func TestRemoveNode_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	err := w.RemoveNode(context.Background(), RemoveOptions{Group: "elasticsearch", NodeName: nodeName})
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Errorf("exit code for wrong group does not match. expected: %d, got: %d", exitcode.Validation, got)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("node should not be drained with wrong group. excluded: %q", got)
+	}
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.ListNodes()
+	for _, node := range nodes {
+		if node == nodeName {
+			t.Errorf("removed node should not be running. got: %v", nodes)
+		}
+	}
+
+	if got := c.ExcludedNode(); got != nodeName {
+		t.Errorf("node in the cluster should be drained. excluded: %q", got)
+	}
+
+	instances, _ := c.ELBv2().ListTargetInstances(fake.TargetGroupARN)
+	for _, instance := range instances {
+		if instance == "i-00000002" {
+			t.Errorf("removed instance should be detached from target group. got: %v", instances)
+		}
+	}
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: "ip-10-0-1-9.ec2.internal"}); err == nil {
+		t.Errorf("error should be raised for unknown node")
+	}
+}
+
+func TestRemoveNode_pinnedFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	c.SetIndexSetting("fake", "index.routing.allocation.require._name", nodeName)
+	// Include filter also matching another node does not pin shards
+	c.SetIndexSetting("logs", "index.routing.allocation.include._name", "ip-10-0-1-2.*,ip-10-0-1-3.*")
+
+	err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Fatalf("exit code for pinned shards does not match. expected: %d, got: %d (%v)", exitcode.Validation, got, err)
+	}
+
+	if !strings.Contains(err.Error(), "fake (index.routing.allocation.require._name="+nodeName+")") || strings.Contains(err.Error(), "logs") {
+		t.Errorf("error should list pinning filters only. got: %s", err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("node should not be drained while shards are pinned. excluded: %q", got)
+	}
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, FixIndexFilters: true}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := c.IndexSetting("fake", "index.routing.allocation.require._name"); got != "" {
+		t.Errorf("pinning filter should be reset. got: %q", got)
+	}
+
+	if got := c.IndexSetting("logs", "index.routing.allocation.include._name"); got == "" {
+		t.Errorf("filter not pinning shards should be kept")
+	}
+}
+
+func TestDrainNode_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	c.SetIndexSetting("fake", "index.routing.allocation.require._name", nodeName)
+
+	err := w.DrainNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Fatalf("exit code for pinned shards does not match. expected: %d, got: %d (%v)", exitcode.Validation, got, err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("node should not be drained while shards are pinned. excluded: %q", got)
+	}
+
+	c.ResetIndexSettings("fake", []string{"index.routing.allocation.require._name"})
+
+	if err := w.DrainNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := c.ExcludedNode(); got != nodeName {
+		t.Errorf("node should be excluded from allocation. got: %q", got)
+	}
+
+	nodes, _ := c.ListNodes()
+	if !contains(nodes, nodeName) {
+		t.Errorf("drained node should keep running")
+	}
+
+	shards, _ := c.ListShardsOnNode(nodeName)
+	if len(shards) != 0 {
+		t.Errorf("shards should escape from drained node. got: %v", shards)
+	}
+
+	instances, _ := c.AutoScaling().ListInstances(fake.GroupName)
+	if !contains(instances, "i-00000002") {
+		t.Errorf("drained instance should stay in Auto Scaling Group. got: %v", instances)
+	}
+}
+
+func TestRemoveNode_shardCapacityFakeCluster(t *testing.T) {
+	testcases := []struct {
+		settings map[string]string
+		wantErr  bool
+	}{
+		{
+			settings: map[string]string{},
+			wantErr:  false,
+		},
+		{
+			settings: map[string]string{"cluster.routing.allocation.total_shards_per_node": "4"},
+			wantErr:  true,
+		},
+		{
+			settings: map[string]string{"cluster.routing.allocation.total_shards_per_node": "5"},
+			wantErr:  false,
+		},
+		{
+			settings: map[string]string{"cluster.routing.allocation.total_shards_per_node": "-1"},
+			wantErr:  false,
+		},
+		{
+			settings: map[string]string{"cluster.max_shards_per_node": "4"},
+			wantErr:  true,
+		},
+		{
+			settings: map[string]string{"cluster.max_shards_per_node": "5"},
+			wantErr:  false,
+		},
+	}
+
+	for _, tc := range testcases {
+		// 3 nodes with 3 shards each
+		c := fake.NewCluster(3)
+		c.UpdateClusterSettings(tc.settings)
+
+		w := newFakeWorkflow(c)
+
+		nodeName := "ip-10-0-1-2.ec2.internal"
+
+		err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+
+		if !tc.wantErr {
+			if err != nil {
+				t.Errorf("error should not be raised with %v: %s", tc.settings, err)
+			}
+
+			continue
+		}
+
+		if got := exitcode.Code(err); got != exitcode.Validation {
+			t.Errorf("exit code with %v does not match. expected: %d, got: %d (%v)", tc.settings, exitcode.Validation, got, err)
+		}
+
+		if got := c.ExcludedNode(); got != "" {
+			t.Errorf("node should not be drained without capacity. excluded: %q", got)
+		}
+	}
+}
+
+func TestRemoveNode_rolloverUnsupported(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "2.3.0")
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, RolloverAlias: "fake-write"})
+
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Fatalf("exit code for unsupported rollover does not match. expected: %d, got: %d (%v)", exitcode.Validation, got, err)
+	}
+
+	nodes, _ := c.ListNodes()
+	if !contains(nodes, nodeName) {
+		t.Errorf("node should not be removed if rollover is not available")
+	}
+}
+
+func TestRemoveNode_writeIndexFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.SetWriteIndex("fake-write", "fake")
+	c.SetWriteIndex("other-write", "other-000001")
+
+	var buf bytes.Buffer
+
+	w := newFakeWorkflow(c)
+	w.Progress = &buf
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, RolloverAlias: "other-write"}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !strings.Contains(buf.String(), "WARNING: write index fake of alias fake-write has 3 primaries on "+nodeName) {
+		t.Errorf("write index on the node should be warned. got: %q", buf.String())
+	}
+
+	if strings.Contains(buf.String(), "other-000001 of alias") {
+		t.Errorf("write index not on the node should not be warned. got: %q", buf.String())
+	}
+
+	indices, _ := c.WriteIndices()
+	if indices["other-write"] != "other-000001" {
+		t.Errorf("alias whose write index is not on the node should not be rolled over. got: %q", indices["other-write"])
+	}
+}
+
+func TestCheckWriteIndices_rollover(t *testing.T) {
+	client := &fakeClient{
+		copies: []*stats.Shard{
+			{Index: "logs-000001", Shard: 0, Primary: true, State: "STARTED", Node: "node-1"},
+			{Index: "logs-000001", Shard: 0, Primary: false, State: "STARTED", Node: "node-2"},
+		},
+		writeIndices: map[string]string{"logs-write": "logs-000001"},
+	}
+
+	var buf bytes.Buffer
+
+	w := &Workflow{ES: client, Progress: &buf}
+
+	opts := RemoveOptions{RolloverAlias: "logs-write", Operation: operation.New("remove", "http://elasticsearch.example.com")}
+
+	if err := w.checkWriteIndices("node-1", opts); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(client.calls) != 1 || client.calls[0] != "Rollover" {
+		t.Errorf("alias should be rolled over. calls: %v", client.calls)
+	}
+
+	if !strings.Contains(buf.String(), "alias logs-write now writes into logs-000001-next") {
+		t.Errorf("new write index should be printed. got: %q", buf.String())
+	}
+
+	// Replica on the node does not matter
+	client.calls = []string{}
+
+	if err := w.checkWriteIndices("node-2", opts); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if len(client.calls) != 0 {
+		t.Errorf("alias should not be rolled over if write index has no primary on the node. calls: %v", client.calls)
+	}
+}
+
+func TestRemoveNode_rolesFakeCluster(t *testing.T) {
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	c := fake.NewCluster(3)
+	c.SetNodeRoles(nodeName)
+
+	w := newFakeWorkflow(c)
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("coordinating-only node should not be drained. excluded: %q", got)
+	}
+
+	if nodes, _ := c.ListNodes(); contains(nodes, nodeName) {
+		t.Errorf("coordinating-only node should be shut down. got: %v", nodes)
+	}
+
+	testcases := []struct {
+		roles    map[string][]string
+		settings map[string]string
+	}{
+		{
+			// Last ingest node
+			roles: map[string][]string{
+				"ip-10-0-1-1.ec2.internal": {"master", "data"},
+				"ip-10-0-1-3.ec2.internal": {"master", "data"},
+			},
+			settings: map[string]string{},
+		},
+		{
+			// Last master-eligible node
+			roles: map[string][]string{
+				"ip-10-0-1-1.ec2.internal": {"data", "ingest"},
+				"ip-10-0-1-3.ec2.internal": {"data", "ingest"},
+			},
+			settings: map[string]string{},
+		},
+		{
+			roles:    map[string][]string{},
+			settings: map[string]string{"discovery.zen.minimum_master_nodes": "3"},
+		},
+	}
+
+	for _, tc := range testcases {
+		c := fake.NewCluster(3)
+		c.UpdateClusterSettings(tc.settings)
+
+		for n, roles := range tc.roles {
+			c.SetNodeRoles(n, roles...)
+		}
+
+		w := newFakeWorkflow(c)
+
+		err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+		if got := exitcode.Code(err); got != exitcode.Validation {
+			t.Errorf("exit code does not match. roles: %v, settings: %v, expected: %d, got: %d (%v)", tc.roles, tc.settings, exitcode.Validation, got, err)
+		}
+
+		if nodes, _ := c.ListNodes(); !contains(nodes, nodeName) {
+			t.Errorf("node should not be shut down. roles: %v, settings: %v", tc.roles, tc.settings)
+		}
+	}
+}
+
+func TestRemoveNode_mlFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "7.17.0")
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	c.SetMLJob("requests", nodeName)
+	c.SetMLJob("errors", "ip-10-0-1-3.ec2.internal")
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if c.MLUpgradeMode() {
+		t.Errorf("upgrade mode should be disabled after shutdown")
+	}
+
+	jobs, _ := c.MLJobs()
+	for _, j := range jobs {
+		if j.Node == "" || j.Node == nodeName {
+			t.Errorf("job should be reassigned to other node. got: %+v", j)
+		}
+	}
+}
+
+func TestRemoveNode_mlNodeShutdownUnsupported(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "7.14.0")
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	c.SetMLJob("requests", nodeName)
+
+	err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Fatalf("exit code without node shutdown API does not match. expected: %d, got: %d (%v)", exitcode.Validation, got, err)
+	}
+
+	if !strings.Contains(err.Error(), "requests") {
+		t.Errorf("error should list jobs on the node. got: %s", err)
+	}
+
+	nodes, _ := c.ListNodes()
+	if !contains(nodes, nodeName) {
+		t.Errorf("node should not be shut down without node shutdown API")
+	}
+
+	if c.MLUpgradeMode() {
+		t.Errorf("upgrade mode should not be enabled without node shutdown API")
+	}
+}
+
+func TestRemoveStep_mlJobsMoved(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+	w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "7.17.0")
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	c.SetMLJob("requests", nodeName)
+	c.SetMLJob("errors", "ip-10-0-1-3.ec2.internal")
+
+	p, err := w.PlanRemoval(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName})
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	s := &RemoveState{
+		Group:          fake.GroupName,
+		NodeName:       nodeName,
+		Step:           StepExclude,
+		InstanceID:     p.InstanceID,
+		TargetGroupARN: p.TargetGroupARN,
+	}
+
+	next := s
+
+	for next.Step != StepWaitML {
+		if next, err = w.RemoveStep(context.Background(), next); err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+	}
+
+	if c.MLUpgradeMode() {
+		t.Errorf("upgrade mode should be disabled after shutdown")
+	}
+
+	if !c.NodeShutdownPrepared(nodeName) {
+		t.Errorf("node should be registered for removal, so that jobs are not assigned to it")
+	}
+
+	if len(next.MLJobs) != 1 || next.MLJobs[0] != "requests" {
+		t.Errorf("only jobs on the node should be recorded. got: %v", next.MLJobs)
+	}
+
+	next, err = w.RemoveStep(context.Background(), next)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if next.Waiting || next.Step != StepDetachASG {
+		t.Errorf("jobs of the node should be reassigned to other nodes. got: %+v", next)
+	}
+
+	// Job assigned to the node again is waited for
+	c.SetMLJob("requests", nodeName)
+
+	s.Step = StepWaitML
+	s.MLJobs = []string{"requests"}
+
+	next, err = w.RemoveStep(context.Background(), s)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if !next.Waiting || next.Remaining != 1 {
+		t.Errorf("job on the node should be waited for. got: %+v", next)
+	}
+
+	// Job unassigned for other reason, e.g. on another removed node, is not waited for
+	c.SetMLJob("requests", "ip-10-0-1-1.ec2.internal")
+	c.SetMLJob("orphan", "ip-10-0-1-9.ec2.internal")
+
+	next, err = w.RemoveStep(context.Background(), s)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if next.Waiting {
+		t.Errorf("job not on the node should not be waited for. remaining: %d", next.Remaining)
+	}
+}
+
+func TestSimpleMatch(t *testing.T) {
+	testcases := []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{"node-1", "node-1", true},
+		{"node-1", "node-10", false},
+		{"node-*", "node-10", true},
+		{"*-1", "node-1", true},
+		{"*-1", "node-10", false},
+		{"n*e-*0", "node-10", true},
+		{"ab*b", "ab", false},
+		{"*", "node-1", true},
+	}
+
+	for _, tc := range testcases {
+		if got := simpleMatch(tc.pattern, tc.s); got != tc.expected {
+			t.Errorf("simpleMatch(%q, %q) does not match. expected: %t, got: %t", tc.pattern, tc.s, tc.expected, got)
+		}
+	}
+}
+
+func TestRemoveNode_departedFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	// Simulate hardware failure leaving shards of the node unassigned
+	c.Shutdown(nodeName)
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("departed node should not be excluded from allocation. got: %q", got)
+	}
+
+	instances, _ := c.AutoScaling().ListInstances(fake.GroupName)
+	if contains(instances, "i-00000002") {
+		t.Errorf("removed instance should be detached from Auto Scaling Group. got: %v", instances)
+	}
+
+	health, _ := c.ClusterHealth()
+	if health.UnassignedShards != 0 {
+		t.Errorf("unassigned shards should be rerouted. got: %d", health.UnassignedShards)
+	}
+}
+
+func TestRemoveNode_forceFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+	opts := RemoveOptions{Group: fake.GroupName, NodeName: nodeName, Force: true, TerminateInstance: true}
+
+	if err := w.RemoveNode(context.Background(), opts); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("node should not be excluded from allocation with force. got: %q", got)
+	}
+
+	nodes, _ := c.ListNodes()
+	if contains(nodes, nodeName) {
+		t.Errorf("terminated node should not be running. got: %v", nodes)
+	}
+
+	health, _ := c.ClusterHealth()
+	if health.UnassignedShards != 0 {
+		t.Errorf("shards of terminated node should be rerouted. got: %d", health.UnassignedShards)
+	}
+}
+
+func TestRemoveNode_votingExclusionFakeCluster(t *testing.T) {
+	testcases := []struct {
+		terminate bool
+		expected  []string
+	}{
+		{
+			terminate: false,
+			expected:  []string{"ip-10-0-1-2.ec2.internal"},
+		},
+		{
+			terminate: true,
+			expected:  []string{},
+		},
+	}
+
+	for _, tc := range testcases {
+		c := fake.NewCluster(3)
+		w := newFakeWorkflow(c)
+		w.Capabilities, _ = es.NewCapabilities(es.DistributionElasticsearch, "7.17.0")
+
+		opts := RemoveOptions{Group: fake.GroupName, NodeName: "ip-10-0-1-2.ec2.internal", TerminateInstance: tc.terminate}
+
+		if err := w.RemoveNode(context.Background(), opts); err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		// Exclusion of node left running is kept, so that it does not vote again
+		if got := c.VotingExclusions(); strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("voting exclusions do not match. terminate: %t, expected: %v, got: %v", tc.terminate, tc.expected, got)
+		}
+	}
+}
+
+func TestRemoveNode_keepDesiredCapacityFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, KeepDesiredCapacity: true}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	instances, _ := c.AutoScaling().ListInstances(fake.GroupName)
+	if len(instances) != 3 || contains(instances, "i-00000002") {
+		t.Errorf("replacement instance should be launched. got: %v", instances)
+	}
+
+	err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: "ip-10-0-1-3.ec2.internal", KeepDesiredCapacity: true, ReturnToWarmPool: true})
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Errorf("keeping desired capacity with warm pool should be rejected. got: %v", err)
+	}
+}
+
+func TestRemoveNode_warmPoolFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.EnableWarmPool(0, true)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, ReturnToWarmPool: true}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	pool, _ := c.AutoScaling().DescribeWarmPool(fake.GroupName)
+	if warmed := pool.Warmed(); len(warmed) != 1 || warmed[0].ID != "i-00000002" {
+		t.Errorf("instance should return to warm pool. got: %v", warmed)
+	}
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 1}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.ListNodes()
+	if len(nodes) != 3 || !contains(nodes, nodeName) {
+		t.Errorf("warmed instance should be moved into service. got: %v", nodes)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("allocation exclusion of returned node should be cleared. got: %q", got)
+	}
+}
+
+func TestRemoveNode_warmPoolWithoutReuse(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.EnableWarmPool(0, false)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName, ReturnToWarmPool: true})
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Errorf("validation error should be raised. got: %v", err)
+	}
+
+	if got := c.ExcludedNode(); got != "" {
+		t.Errorf("node should not be excluded before warm pool is verified. got: %q", got)
+	}
+}
+
+func TestRemoveNode_rerunFakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	var out bytes.Buffer
+	w.Progress = &out
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	// Simulate previous run failed after exclusion
+	s := &RemoveState{Group: fake.GroupName, NodeName: nodeName}
+
+	for s.Step != StepWaitDrain {
+		next, err := w.RemoveStep(context.Background(), s)
+		if err != nil {
+			t.Fatalf("error should not be raised: %s", err)
+		}
+
+		s = next
+	}
+
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := strings.Count(out.String(), SkippedMessage); got != 2 {
+		t.Errorf("detaching from target group and exclusion should be skipped. got: %q", out.String())
+	}
+
+	out.Reset()
+
+	// Instance detached from Auto Scaling Group by the previous run is accepted
+	if err := w.RemoveNode(context.Background(), RemoveOptions{Group: fake.GroupName, NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if got := strings.Count(out.String(), SkippedMessage); got != 2 {
+		t.Errorf("detaching from target group and Auto Scaling Group should be skipped. got: %q", out.String())
+	}
+}
+
+func TestRemoveStep_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	s := &RemoveState{
+		Group:    fake.GroupName,
+		NodeName: "ip-10-0-1-1.ec2.internal",
+	}
+
+	steps := []string{}
+
+	for s.Step != StepDone {
+		next, err := w.RemoveStep(context.Background(), s)
+		if err != nil {
+			t.Fatalf("error should not be raised at step %q: %s", s.Step, err)
+		}
+
+		if next.Waiting {
+			t.Fatalf("fake cluster should not make step %q wait", s.Step)
+		}
+
+		// Executing the same step twice must be safe
+		if _, err := w.RemoveStep(context.Background(), s); err != nil {
+			t.Errorf("step %q should be idempotent: %s", s.Step, err)
+		}
+
+		steps = append(steps, next.Step)
+		s = next
+	}
+
+	expected := []string{StepDetachLB, StepWaitLB, StepExclude, StepWaitDrain, StepShutdown, StepWaitML, StepDetachASG, StepDone}
+
+	if len(steps) != len(expected) {
+		t.Fatalf("steps do not match. expected: %v, got: %v", expected, steps)
+	}
+
+	for i := range expected {
+		if steps[i] != expected[i] {
+			t.Errorf("steps do not match. expected: %v, got: %v", expected, steps)
+			break
+		}
+	}
+
+	if len(s.Shards) != 3 {
+		t.Errorf("shards on the node should be recorded at exclusion. got: %v", s.Shards)
+	}
+
+	instances, _ := c.AutoScaling().ListInstances(fake.GroupName)
+	if contains(instances, "i-00000001") {
+		t.Errorf("removed instance should be detached from Auto Scaling Group. got: %v", instances)
+	}
+}
+
+func TestRemoveStep_shutdownWithoutCopies(t *testing.T) {
+	client := &fakeClient{
+		nodes: []string{"node-1", "node-2"},
+		copies: []*stats.Shard{
+			{Index: "logs", Shard: 0, Primary: true, State: "STARTED", Node: "node-2"},
+			{Index: "logs", Shard: 1, Primary: true, State: "STARTED", Node: "node-1"},
+			{Index: "logs", Shard: 1, Primary: false, State: "INITIALIZING", Node: "node-2"},
+			{Index: "logs", Shard: 2, Primary: false, State: "UNASSIGNED"},
+		},
+	}
+
+	w := &Workflow{ES: client}
+
+	s := &RemoveState{
+		Group:          testGroup,
+		NodeName:       "node-1",
+		Step:           StepShutdown,
+		InstanceID:     testInstanceID,
+		TargetGroupARN: testTargetGroupARN,
+		Shards:         []string{"[logs][0]", "[logs][2]"},
+	}
+
+	_, err := w.RemoveStep(context.Background(), s)
+	if got := exitcode.Code(err); got != exitcode.Aborted {
+		t.Fatalf("exit code does not match. expected: %d, got: %d (%v)", exitcode.Aborted, got, err)
+	}
+
+	if !strings.Contains(err.Error(), "2 shards") || !strings.Contains(err.Error(), "[logs][1]") || !strings.Contains(err.Error(), "[logs][2]") {
+		t.Errorf("error should list shards without started copy on other nodes. got: %s", err)
+	}
+
+	if len(client.calls) != 0 {
+		t.Errorf("node should not be shut down. calls: %v", client.calls)
+	}
+
+	client.copies[2].State = "STARTED"
+	client.copies[3].State = "STARTED"
+	client.copies[3].Node = "node-2"
+
+	next, err := w.RemoveStep(context.Background(), s)
+	if err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if next.Step != StepWaitML || len(client.calls) != 1 || client.calls[0] != "Shutdown" {
+		t.Errorf("node should be shut down. next: %s, calls: %v", next.Step, client.calls)
+	}
+}
+
+func TestDrainIndex_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-2.ec2.internal"
+
+	if err := w.DrainIndex(context.Background(), DrainIndexOptions{Index: "fake", NodeName: nodeName}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	shards, _ := c.ListShardsOnNode(nodeName)
+	if len(shards) != 0 {
+		t.Errorf("shards of the index should escape from target node. got: %v", shards)
+	}
+
+	if got := c.IndexExclusion("fake"); got != "" {
+		t.Errorf("exclusion should be cleared after draining. got: %q", got)
+	}
+
+	if err := w.DrainIndex(context.Background(), DrainIndexOptions{NodeName: nodeName}); err == nil {
+		t.Errorf("error should be raised without index")
+	}
+}
+
+func TestCountIndexShards(t *testing.T) {
+	shards := []string{
+		"logs-2018.01.01 0 p STARTED 3014 31.1mb 10.0.1.21 ip-10-0-1-21.ap-northeast-1.compute.internal",
+		"logs-2018.01.01 1 r STARTED 3013 31.0mb 10.0.1.21 ip-10-0-1-21.ap-northeast-1.compute.internal",
+		"logs-2018.01.02 0 p STARTED 2976 30.2mb 10.0.1.21 ip-10-0-1-21.ap-northeast-1.compute.internal",
+		"",
+	}
+
+	if got := countIndexShards(shards, "logs-2018.01.01"); got != 2 {
+		t.Errorf("number of shards does not match. expected: 2, got: %d", got)
+	}
+
+	if got := countIndexShards(shards, "logs-2018"); got != 0 {
+		t.Errorf("index name should match exactly. got: %d", got)
+	}
+}
+
+func TestMigrateTier_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(3)
+	c.SetNodeAttribute("ip-10-0-1-3.ec2.internal", "box_type", "warm")
+	w := newFakeWorkflow(c)
+
+	nodeName := "ip-10-0-1-1.ec2.internal"
+
+	if err := w.MigrateTier(context.Background(), MigrateTierOptions{NodeName: nodeName, From: "box_type=hot", To: "box_type=warm"}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	shards, _ := c.ListShardsOnNode(nodeName)
+	if len(shards) != 0 {
+		t.Errorf("shards should escape from target node. got: %v", shards)
+	}
+
+	// Shards are moved to warm node, not to another hot node
+	shards, _ = c.ListShardsOnNode("ip-10-0-1-3.ec2.internal")
+	if len(shards) != 9 {
+		t.Errorf("all shards of the index should be moved to warm node. got: %v", shards)
+	}
+
+	err := w.MigrateTier(context.Background(), MigrateTierOptions{NodeName: "ip-10-0-1-3.ec2.internal", From: "box_type=hot", To: "box_type=warm"})
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Errorf("node out of the tier should be rejected. got: %v", err)
+	}
+}
+
+func TestParseAttribute(t *testing.T) {
+	testcases := []struct {
+		attr  string
+		key   string
+		value string
+		ok    bool
+	}{
+		{attr: "box_type=hot", key: "box_type", value: "hot", ok: true},
+		{attr: "rack=a=b", key: "rack", value: "a=b", ok: true},
+		{attr: "box_type", ok: false},
+		{attr: "=hot", ok: false},
+		{attr: "", ok: false},
+	}
+
+	for _, tc := range testcases {
+		key, value, err := parseAttribute(tc.attr)
+		if (err == nil) != tc.ok {
+			t.Errorf("unexpected result for %q: %v", tc.attr, err)
+			continue
+		}
+
+		if key != tc.key || value != tc.value {
+			t.Errorf("attribute does not match. expected: %s=%s, got: %s=%s", tc.key, tc.value, key, value)
+		}
+	}
+}
+
+func TestAddNodes_attachInstance(t *testing.T) {
+	c := fake.NewCluster(3)
+	w := newFakeWorkflow(c)
+
+	instanceID := c.LaunchStandby()
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, InstanceID: instanceID}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.ListNodes()
+	if len(nodes) != 4 {
+		t.Errorf("attached node should join the cluster. got: %v", nodes)
+	}
+
+	instances, _ := c.ELBv2().ListTargetInstances(fake.TargetGroupARN)
+	if !contains(instances, instanceID) {
+		t.Errorf("attached instance should be registered with target group. got: %v", instances)
+	}
+
+	if !c.ReallocationEnabled() {
+		t.Errorf("reallocation should be enabled")
+	}
+
+	err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, InstanceID: instanceID})
+	if got := exitcode.Code(err); got != exitcode.Validation {
+		t.Errorf("attaching instance twice should be rejected. got: %v", err)
+	}
+}
+
+func TestRebalance_fakeCluster(t *testing.T) {
+	c := fake.NewCluster(2)
+	w := newFakeWorkflow(c)
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 2}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	if err := w.Rebalance(context.Background(), RebalanceOptions{Concurrency: 8, Tolerance: 1}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	nodes, _ := c.NodeStats()
+	if least, most := shardRange(nodes); most-least > 1 {
+		t.Errorf("shards should be balanced. got: %d-%d", least, most)
+	}
+
+	settings, _ := c.ClusterSettings()
+	if got := settings[concurrentRebalanceSetting]; got != defaultConcurrentRebalance {
+		t.Errorf("cluster_concurrent_rebalance should be restored. got: %q", got)
+	}
+}
+
+func TestRebalance_timeout(t *testing.T) {
+	c := fake.NewCluster(2)
+	w := newFakeWorkflow(c)
+
+	if err := w.AddNodes(context.Background(), AddOptions{Group: fake.GroupName, Count: 2}); err != nil {
+		t.Fatalf("error should not be raised: %s", err)
+	}
+
+	// Fake cluster balances shards to differ by 1 at most
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := w.Rebalance(ctx, RebalanceOptions{Tolerance: 0}); err != context.DeadlineExceeded {
+		t.Errorf("deadline should be exceeded. got: %v", err)
+	}
+}